@@ -18,6 +18,7 @@ import (
 	"sidekick/env"
 	"sidekick/frontend"
 	"sidekick/srv"
+	"sidekick/telemetry"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -60,6 +61,9 @@ type Controller struct {
 // UserActionRequest defines the expected request body for user actions.
 type UserActionRequest struct {
 	ActionType string `json:"actionType"`
+	// StepNumber is the plan step whose checkpoint to restore, required for
+	// the rollback_to_checkpoint action
+	StepNumber string `json:"stepNumber,omitempty"`
 }
 
 // ArchiveTaskHandler handles the request to archive a task
@@ -130,6 +134,8 @@ func DefineRoutes(ctrl Controller) *gin.Engine {
 	r.ForwardedByClientIP = true
 	r.SetTrustedProxies(nil)
 
+	r.GET("/metrics", gin.WrapH(telemetry.MetricsHandler()))
+
 	workspaceApiRoutes := DefineWorkspaceApiRoutes(r, &ctrl)
 	workspaceApiRoutes.GET("/archived_tasks", ctrl.GetArchivedTasksHandler)
 
@@ -146,10 +152,15 @@ func DefineRoutes(ctrl Controller) *gin.Engine {
 	flowRoutes := workspaceApiRoutes.Group("/flows")
 	flowRoutes.GET("/:id", ctrl.GetFlowHandler)
 	flowRoutes.GET("/:id/actions", ctrl.GetFlowActionsHandler)
+	flowRoutes.GET("/:id/subflows", ctrl.GetSubflowsHandler)
 	flowRoutes.POST("/:id/pause", ctrl.PauseFlowHandler)
 	flowRoutes.POST("/:id/cancel", ctrl.CancelFlowHandler)
 	flowRoutes.POST("/:id/user_action", ctrl.UserActionHandler)
 
+	worktreeRoutes := workspaceApiRoutes.Group("/worktrees")
+	worktreeRoutes.GET("", ctrl.GetWorktreesHandler)
+	worktreeRoutes.POST("/prune", ctrl.PruneWorktreesHandler)
+
 	workspaceApiRoutes.POST("/flow_actions/:id/complete", ctrl.CompleteFlowActionHandler)
 	workspaceApiRoutes.PUT("/flow_actions/:id", ctrl.UpdateFlowActionHandler)
 
@@ -182,7 +193,8 @@ func DefineRoutes(ctrl Controller) *gin.Engine {
 
 func NewController() (Controller, error) {
 	clientOptions := client.Options{
-		HostPort: common.GetTemporalServerHostPort(),
+		HostPort:     common.GetTemporalServerHostPort(),
+		Interceptors: telemetry.TemporalInterceptors(),
 	}
 	temporalClient, err := client.NewLazyClient(clientOptions)
 	if err != nil {
@@ -198,6 +210,11 @@ func NewController() (Controller, error) {
 		return Controller{}, fmt.Errorf("failed to connect to storage: %w", err)
 	}
 
+	err = telemetry.RegisterCollector(taskCountCollector{service: service})
+	if err != nil {
+		return Controller{}, fmt.Errorf("failed to register task count metrics: %w", err)
+	}
+
 	return Controller{
 		service:           service,
 		temporalClient:    temporalClient,
@@ -410,15 +427,24 @@ func (ctrl *Controller) UserActionHandler(c *gin.Context) {
 		return
 	}
 
-	if req.ActionType != string(dev.UserActionGoNext) {
-		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Invalid actionType '%s'. Only '%s' is supported.", req.ActionType, dev.UserActionGoNext)})
-		return
-	}
-
 	// Note: the only way to interact with the flow's GlobalState is by
 	// signalling it. The signal handler will then process the action within the
 	// context of the temporal workflow.
-	err = ctrl.temporalClient.SignalWorkflow(c.Request.Context(), flowId, "", dev.SignalNameUserAction, dev.UserActionGoNext)
+	switch req.ActionType {
+	case string(dev.UserActionGoNext):
+		err = ctrl.temporalClient.SignalWorkflow(c.Request.Context(), flowId, "", dev.SignalNameUserAction, dev.UserActionGoNext)
+	case string(dev.UserActionRollbackToCheckpoint):
+		if req.StepNumber == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request payload: stepNumber is required for " + req.ActionType})
+			return
+		}
+		err = ctrl.temporalClient.SignalWorkflow(c.Request.Context(), flowId, "", dev.SignalNameRollbackToCheckpoint, req.StepNumber)
+	case string(dev.UserActionUpdateFromBaseBranch):
+		err = ctrl.temporalClient.SignalWorkflow(c.Request.Context(), flowId, "", dev.SignalNameUserAction, dev.UserActionUpdateFromBaseBranch)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Invalid actionType '%s'. Only '%s', '%s' and '%s' are supported.", req.ActionType, dev.UserActionGoNext, dev.UserActionRollbackToCheckpoint, dev.UserActionUpdateFromBaseBranch)})
+		return
+	}
 	if err != nil {
 		var serviceErrNotFound *serviceerror.NotFound
 		if errors.As(err, &serviceErrNotFound) {
@@ -1093,6 +1119,7 @@ func (ctrl *Controller) FlowActionChangesWebsocketHandler(c *gin.Context) {
 		return
 	}
 	defer conn.Close()
+	defer telemetry.TrackWebsocketSubscriber("flow_action_changes")()
 
 	streamMessageStartId := "0"
 
@@ -1144,6 +1171,7 @@ func (ctrl *Controller) TaskChangesWebsocketHandler(c *gin.Context) {
 		return
 	}
 	defer conn.Close()
+	defer telemetry.TrackWebsocketSubscriber("task_changes")()
 
 	// Create a new context that's canceled when the WebSocket connection is closed
 	ctx, cancel := context.WithCancel(c.Request.Context())
@@ -1242,6 +1270,7 @@ func (ctrl *Controller) FlowEventsWebsocketHandler(c *gin.Context) {
 		return
 	}
 	defer conn.Close()
+	defer telemetry.TrackWebsocketSubscriber("flow_events")()
 
 	subscriptionCh := make(chan domain.FlowEventSubscription, 100)
 	defer close(subscriptionCh)
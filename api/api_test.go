@@ -2159,11 +2159,52 @@ func TestUserActionHandler_BasicCases(t *testing.T) {
 		router.ServeHTTP(rr, req)
 
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
-		expectedResponse := gin.H{"message": fmt.Sprintf("Invalid actionType '%s'. Only '%s' is supported.", "invalid_action", dev.UserActionGoNext)}
+		expectedResponse := gin.H{"message": fmt.Sprintf("Invalid actionType '%s'. Only '%s', '%s' and '%s' are supported.", "invalid_action", dev.UserActionGoNext, dev.UserActionRollbackToCheckpoint, dev.UserActionUpdateFromBaseBranch)}
 		jsonResponse, _ := json.Marshal(expectedResponse)
 		assert.JSONEq(t, string(jsonResponse), rr.Body.String())
 	})
 
+	t.Run("Successful rollback_to_checkpoint", func(t *testing.T) {
+		payload := UserActionRequest{ActionType: string(dev.UserActionRollbackToCheckpoint), StepNumber: "2"}
+		jsonPayload, _ := json.Marshal(payload)
+
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/workspaces/%s/flows/%s/user_action", workspaceId, flowId), bytes.NewBuffer(jsonPayload))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockTemporalClient := (ctrl.temporalClient).(*mocks.Client)
+		mockTemporalClient.AssertCalled(t, "SignalWorkflow", mock.Anything, flowId, "", dev.SignalNameRollbackToCheckpoint, "2")
+	})
+
+	t.Run("Successful update_from_base_branch", func(t *testing.T) {
+		payload := UserActionRequest{ActionType: string(dev.UserActionUpdateFromBaseBranch)}
+		jsonPayload, _ := json.Marshal(payload)
+
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/workspaces/%s/flows/%s/user_action", workspaceId, flowId), bytes.NewBuffer(jsonPayload))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockTemporalClient := (ctrl.temporalClient).(*mocks.Client)
+		mockTemporalClient.AssertCalled(t, "SignalWorkflow", mock.Anything, flowId, "", dev.SignalNameUserAction, dev.UserActionUpdateFromBaseBranch)
+	})
+
+	t.Run("rollback_to_checkpoint without stepNumber", func(t *testing.T) {
+		payload := UserActionRequest{ActionType: string(dev.UserActionRollbackToCheckpoint)}
+		jsonPayload, _ := json.Marshal(payload)
+
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/workspaces/%s/flows/%s/user_action", workspaceId, flowId), bytes.NewBuffer(jsonPayload))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "stepNumber is required")
+	})
+
 	t.Run("Invalid request payload - non-JSON", func(t *testing.T) {
 		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/workspaces/%s/flows/%s/user_action", workspaceId, flowId), bytes.NewBufferString("not-json"))
 		req.Header.Set("Content-Type", "application/json")
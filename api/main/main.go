@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"sidekick/api"
+	"sidekick/telemetry"
 	"syscall"
 
 	"github.com/joho/godotenv"
@@ -21,6 +22,11 @@ func main() {
 		}
 	}
 
+	shutdownTracing, err := telemetry.InitTracing(context.Background(), "sidekick-api")
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize tracing")
+	}
+
 	srv := api.RunServer()
 
 	quit := make(chan os.Signal, 1)
@@ -29,4 +35,7 @@ func main() {
 
 	// graceful shutdown
 	srv.Shutdown(context.Background())
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.Error().Err(err).Msg("Failed to shut down tracing")
+	}
 }
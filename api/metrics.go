@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"sidekick/domain"
+	"sidekick/srv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+var taskCountDesc = prometheus.NewDesc(
+	"sidekick_tasks",
+	"Number of non-archived tasks across all workspaces, by status.",
+	[]string{"status"}, nil,
+)
+
+// taskCountCollector counts tasks from storage at scrape time, so the counts
+// are correct regardless of which process changed the task status
+type taskCountCollector struct {
+	service srv.Service
+}
+
+func (c taskCountCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- taskCountDesc
+}
+
+func (c taskCountCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	counts := make(map[domain.TaskStatus]int, len(domain.AllTaskStatuses))
+	workspaces, err := c.service.GetAllWorkspaces(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get workspaces for task count metrics")
+		return
+	}
+	for _, workspace := range workspaces {
+		tasks, err := c.service.GetTasks(ctx, workspace.Id, domain.AllTaskStatuses)
+		if err != nil {
+			log.Error().Err(err).Str("workspaceId", workspace.Id).Msg("Failed to get tasks for task count metrics")
+			return
+		}
+		for _, task := range tasks {
+			counts[task.Status]++
+		}
+	}
+
+	for _, status := range domain.AllTaskStatuses {
+		ch <- prometheus.MustNewConstMetric(taskCountDesc, prometheus.GaugeValue, float64(counts[status]), string(status))
+	}
+}
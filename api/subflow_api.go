@@ -3,6 +3,7 @@ package api
 import (
 	"errors"
 	"net/http"
+	"sidekick/domain"
 	"sidekick/srv"
 
 	"github.com/gin-gonic/gin"
@@ -33,3 +34,34 @@ func (ctrl *Controller) GetSubflowHandler(c *gin.Context) {
 	// Return the subflow data
 	c.JSON(http.StatusOK, gin.H{"subflow": subflow})
 }
+
+// GetSubflowsHandler handles GET requests to retrieve all subflows for a flow
+func (ctrl *Controller) GetSubflowsHandler(c *gin.Context) {
+	workspaceId := c.Param("workspaceId")
+	flowId := c.Param("id")
+
+	if workspaceId == "" || flowId == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Workspace ID and flow ID are required"})
+		return
+	}
+
+	if _, err := ctrl.service.GetFlow(c, workspaceId, flowId); err != nil {
+		if errors.Is(err, srv.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Flow not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get flow"})
+		}
+		return
+	}
+
+	subflows, err := ctrl.service.GetSubflows(c, workspaceId, flowId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get subflows"})
+		return
+	}
+	if subflows == nil {
+		subflows = []domain.Subflow{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subflows": subflows})
+}
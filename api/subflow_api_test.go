@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sidekick/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/segmentio/ksuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSubflowsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := NewMockController(t)
+	ctx := context.Background()
+
+	workspaceId := "ws_" + ksuid.New().String()
+	flow := domain.Flow{WorkspaceId: workspaceId, Id: "flow_" + ksuid.New().String()}
+	require.NoError(t, ctrl.service.PersistFlow(ctx, flow))
+	emptyFlow := domain.Flow{WorkspaceId: workspaceId, Id: "flow_" + ksuid.New().String()}
+	require.NoError(t, ctrl.service.PersistFlow(ctx, emptyFlow))
+
+	subflow := domain.Subflow{
+		WorkspaceId: workspaceId,
+		Id:          "sf_" + ksuid.New().String(),
+		FlowId:      flow.Id,
+		Name:        "coding",
+		Status:      domain.SubflowStatusStarted,
+	}
+	require.NoError(t, ctrl.service.PersistSubflow(ctx, subflow))
+
+	tests := []struct {
+		name             string
+		flowId           string
+		expectedStatus   int
+		expectedSubflows []domain.Subflow
+	}{
+		{"flow with subflows", flow.Id, http.StatusOK, []domain.Subflow{subflow}},
+		{"flow without subflows", emptyFlow.Id, http.StatusOK, []domain.Subflow{}},
+		{"non-existent flow", "flow_missing", http.StatusNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(resp)
+			c.Request = httptest.NewRequest("GET", "/api/v1/workspaces/"+workspaceId+"/flows/"+tt.flowId+"/subflows", nil)
+			c.Params = []gin.Param{{Key: "workspaceId", Value: workspaceId}, {Key: "id", Value: tt.flowId}}
+			ctrl.GetSubflowsHandler(c)
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			if tt.expectedSubflows == nil {
+				return
+			}
+			var result map[string][]domain.Subflow
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+			assert.Equal(t, tt.expectedSubflows, result["subflows"])
+		})
+	}
+}
@@ -0,0 +1,408 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"sidekick/coding/git"
+	"sidekick/common"
+	"sidekick/domain"
+	"sidekick/srv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// PruneWorktreesRequest defines the request body for pruning orphaned worktrees.
+type PruneWorktreesRequest struct {
+	// report what would be pruned without pruning anything
+	DryRun bool `json:"dryRun"`
+	// prune all orphaned worktrees, even those still within the retention
+	// period configured in the local config
+	IgnoreRetention bool `json:"ignoreRetention"`
+}
+
+type PruneWorktreeFailure struct {
+	Status domain.WorktreeStatus `json:"status"`
+	Error  string                `json:"error"`
+}
+
+// worktreeInventory is everything known about a workspace's worktrees, from
+// storage, git and the filesystem, before being reconciled
+type worktreeInventory struct {
+	records []domain.Worktree
+	// git worktrees within the workspace's managed worktree directory
+	gitWorktrees []git.GitWorktree
+	// directories within the managed worktree directory, by path, with their
+	// modification times
+	dirs map[string]time.Time
+	// statuses of the records' flows by flow id, excluding flows not found
+	flowStatuses map[string]string
+	// existing local branches
+	branches map[string]bool
+	// branches whose commits are all in the default branch
+	mergedBranches map[string]bool
+	// worktree directories with uncommitted changes, by path
+	dirtyDirs map[string]bool
+}
+
+func isFinishedFlowStatus(status string) bool {
+	return status == "completed" || status == "failed" || status == "canceled"
+}
+
+// reconcileWorktrees determines the status of every worktree in the
+// inventory. Worktrees whose flows are still running only count as orphaned
+// when their directory is missing, and are never prunable, since their branch
+// may not have any commits yet and so trivially counts as merged. Worktrees
+// with uncommitted changes never count as merged either. A negative retention
+// means orphans with unmerged work are never prunable.
+func reconcileWorktrees(inventory worktreeInventory, retention time.Duration, now time.Time) []domain.WorktreeStatus {
+	var statuses []domain.WorktreeStatus
+	seenPaths := make(map[string]bool)
+
+	isPrunable := func(status domain.WorktreeStatus) bool {
+		if len(status.OrphanReasons) == 0 {
+			return false
+		}
+		nothingToLose := status.Merged || (status.Branch == "" && !status.DirExists)
+		return nothingToLose || (retention >= 0 && now.Sub(status.Created) >= retention)
+	}
+
+	for _, record := range inventory.records {
+		record := record
+		path := normalizeWorktreePath(record.WorkingDirectory)
+		seenPaths[path] = true
+
+		status := domain.WorktreeStatus{
+			Worktree: &record,
+			Path:     path,
+			Created:  record.Created,
+		}
+		modTime, dirExists := inventory.dirs[path]
+		status.DirExists = dirExists
+		status.Dirty = inventory.dirtyDirs[path]
+		if inventory.branches[record.Name] {
+			status.Branch = record.Name
+			status.Merged = inventory.mergedBranches[record.Name] && !status.Dirty
+		}
+		if status.Created.IsZero() && dirExists {
+			status.Created = modTime
+		}
+
+		flowStatus, flowFound := inventory.flowStatuses[record.FlowId]
+		status.FlowStatus = flowStatus
+		flowRunning := flowFound && !isFinishedFlowStatus(flowStatus)
+		if !flowRunning {
+			status.OrphanReasons = append(status.OrphanReasons, domain.WorktreeOrphanReasonFlowFinished)
+		}
+		if !dirExists {
+			status.OrphanReasons = append(status.OrphanReasons, domain.WorktreeOrphanReasonDirMissing)
+		}
+		if !flowRunning && status.Merged {
+			status.OrphanReasons = append(status.OrphanReasons, domain.WorktreeOrphanReasonBranchMerged)
+		}
+		status.Prunable = !flowRunning && isPrunable(status)
+		statuses = append(statuses, status)
+	}
+
+	for _, gitWorktree := range inventory.gitWorktrees {
+		if seenPaths[gitWorktree.Path] {
+			continue
+		}
+		seenPaths[gitWorktree.Path] = true
+		modTime, dirExists := inventory.dirs[gitWorktree.Path]
+		status := domain.WorktreeStatus{
+			Path:          gitWorktree.Path,
+			Branch:        gitWorktree.Branch,
+			DirExists:     dirExists,
+			Dirty:         inventory.dirtyDirs[gitWorktree.Path],
+			Merged:        inventory.mergedBranches[gitWorktree.Branch] && !inventory.dirtyDirs[gitWorktree.Path],
+			Created:       modTime,
+			OrphanReasons: []domain.WorktreeOrphanReason{domain.WorktreeOrphanReasonUntracked},
+		}
+		if !dirExists {
+			status.OrphanReasons = append(status.OrphanReasons, domain.WorktreeOrphanReasonDirMissing)
+		}
+		if status.Merged {
+			status.OrphanReasons = append(status.OrphanReasons, domain.WorktreeOrphanReasonBranchMerged)
+		}
+		status.Prunable = isPrunable(status)
+		statuses = append(statuses, status)
+	}
+
+	for path, modTime := range inventory.dirs {
+		if seenPaths[path] {
+			continue
+		}
+		status := domain.WorktreeStatus{
+			Path:          path,
+			DirExists:     true,
+			Dirty:         inventory.dirtyDirs[path],
+			Created:       modTime,
+			OrphanReasons: []domain.WorktreeOrphanReason{domain.WorktreeOrphanReasonUntracked},
+		}
+		status.Prunable = isPrunable(status)
+		statuses = append(statuses, status)
+	}
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		if statuses[i].Created.Equal(statuses[j].Created) {
+			return statuses[i].Path < statuses[j].Path
+		}
+		return statuses[i].Created.After(statuses[j].Created)
+	})
+	return statuses
+}
+
+// normalizeWorktreePath resolves symlinks where possible, to match the paths
+// git reports for worktrees
+func normalizeWorktreePath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
+
+func managedWorktreeDir(workspaceId string) (string, error) {
+	sidekickDataHome, err := common.GetSidekickDataHome()
+	if err != nil {
+		return "", fmt.Errorf("failed to get sidekick data home: %w", err)
+	}
+	return normalizeWorktreePath(filepath.Join(sidekickDataHome, "worktrees", workspaceId)), nil
+}
+
+func (ctrl *Controller) getWorktreeInventory(ctx context.Context, workspaceId, repoDir, managedDir string) (worktreeInventory, error) {
+	inventory := worktreeInventory{
+		dirs:           make(map[string]time.Time),
+		flowStatuses:   make(map[string]string),
+		branches:       make(map[string]bool),
+		mergedBranches: make(map[string]bool),
+		dirtyDirs:      make(map[string]bool),
+	}
+
+	records, err := ctrl.service.GetWorktrees(ctx, workspaceId)
+	if err != nil {
+		return inventory, fmt.Errorf("failed to get worktrees: %w", err)
+	}
+	inventory.records = records
+	for _, record := range records {
+		flow, err := ctrl.service.GetFlow(ctx, workspaceId, record.FlowId)
+		if errors.Is(err, srv.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return inventory, fmt.Errorf("failed to get flow %s: %w", record.FlowId, err)
+		}
+		inventory.flowStatuses[record.FlowId] = flow.Status
+	}
+
+	gitWorktrees, err := git.ListWorktreesActivity(ctx, repoDir)
+	if err != nil {
+		return inventory, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	for _, gitWorktree := range gitWorktrees {
+		if strings.HasPrefix(gitWorktree.Path, managedDir+string(filepath.Separator)) {
+			inventory.gitWorktrees = append(inventory.gitWorktrees, gitWorktree)
+		}
+	}
+
+	entries, err := os.ReadDir(managedDir)
+	if err != nil && !os.IsNotExist(err) {
+		return inventory, fmt.Errorf("failed to read worktree directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return inventory, fmt.Errorf("failed to stat worktree directory %s: %w", entry.Name(), err)
+		}
+		path := filepath.Join(managedDir, entry.Name())
+		inventory.dirs[path] = info.ModTime()
+		if git.IsGitWorktreeDir(path) {
+			dirty, err := git.IsWorktreeDirty(ctx, path)
+			if err != nil {
+				// assume the worst, so the worktree isn't pruned as merged
+				log.Warn().Err(err).Str("path", path).Msg("Failed to check worktree for uncommitted changes")
+				dirty = true
+			}
+			inventory.dirtyDirs[path] = dirty
+		}
+	}
+
+	defaultBranch, err := git.GetDefaultBranch(ctx, repoDir)
+	if err != nil {
+		return inventory, err
+	}
+	branches, err := git.ListLocalBranches(ctx, repoDir)
+	if err != nil {
+		return inventory, err
+	}
+	for _, branch := range branches {
+		inventory.branches[branch] = true
+	}
+	candidateBranches := make([]string, 0, len(records)+len(inventory.gitWorktrees))
+	for _, record := range records {
+		candidateBranches = append(candidateBranches, record.Name)
+	}
+	for _, gitWorktree := range inventory.gitWorktrees {
+		candidateBranches = append(candidateBranches, gitWorktree.Branch)
+	}
+	for _, branch := range candidateBranches {
+		if !inventory.branches[branch] || branch == defaultBranch {
+			continue
+		}
+		merged, err := git.IsBranchMerged(ctx, repoDir, branch, defaultBranch)
+		if err != nil {
+			return inventory, err
+		}
+		inventory.mergedBranches[branch] = merged
+	}
+
+	return inventory, nil
+}
+
+// getWorktreeStatuses reconciles the workspace's worktree records with the
+// worktrees found in git and on disk, also returning the repo and managed
+// worktree directories. A negative retention makes orphans with unmerged work
+// never prunable. On failure, an error response is written and false returned.
+func (ctrl *Controller) getWorktreeStatuses(c *gin.Context, retention time.Duration) ([]domain.WorktreeStatus, string, string, bool) {
+	workspaceId := c.Param("workspaceId")
+	ctx := c.Request.Context()
+
+	workspace, err := ctrl.service.GetWorkspace(ctx, workspaceId)
+	if err != nil {
+		if errors.Is(err, srv.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+		} else {
+			ctrl.ErrorHandler(c, http.StatusInternalServerError, fmt.Errorf("failed to get workspace: %w", err))
+		}
+		return nil, "", "", false
+	}
+	if workspace.LocalRepoDir == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Workspace repository directory not configured"})
+		return nil, "", "", false
+	}
+
+	managedDir, err := managedWorktreeDir(workspaceId)
+	if err != nil {
+		ctrl.ErrorHandler(c, http.StatusInternalServerError, err)
+		return nil, "", "", false
+	}
+	inventory, err := ctrl.getWorktreeInventory(ctx, workspaceId, workspace.LocalRepoDir, managedDir)
+	if err != nil {
+		ctrl.ErrorHandler(c, http.StatusInternalServerError, err)
+		return nil, "", "", false
+	}
+	return reconcileWorktrees(inventory, retention, time.Now()), workspace.LocalRepoDir, managedDir, true
+}
+
+func worktreeRetention() (time.Duration, error) {
+	config, err := common.LoadSidekickConfig(common.GetSidekickConfigPath())
+	if err != nil {
+		return 0, err
+	}
+	return config.Worktrees.Retention(), nil
+}
+
+// GetWorktreesHandler lists the workspace's sidekick-managed worktrees,
+// flagging any that are orphaned.
+func (ctrl *Controller) GetWorktreesHandler(c *gin.Context) {
+	retention, err := worktreeRetention()
+	if err != nil {
+		ctrl.ErrorHandler(c, http.StatusInternalServerError, err)
+		return
+	}
+	statuses, _, _, ok := ctrl.getWorktreeStatuses(c, retention)
+	if !ok {
+		return
+	}
+	if statuses == nil {
+		statuses = []domain.WorktreeStatus{}
+	}
+	c.JSON(http.StatusOK, gin.H{"worktrees": statuses})
+}
+
+// PruneWorktreesHandler removes prunable worktrees along with their branches
+// and records, archiving unmerged branches and uncommitted changes as tags
+// first.
+func (ctrl *Controller) PruneWorktreesHandler(c *gin.Context) {
+	var req PruneWorktreesRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	retention := time.Duration(0)
+	if !req.IgnoreRetention {
+		var err error
+		retention, err = worktreeRetention()
+		if err != nil {
+			ctrl.ErrorHandler(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	statuses, repoDir, managedDir, ok := ctrl.getWorktreeStatuses(c, retention)
+	if !ok {
+		return
+	}
+
+	pruned := []domain.WorktreeStatus{}
+	failed := []PruneWorktreeFailure{}
+	for _, status := range statuses {
+		if !status.Prunable {
+			continue
+		}
+		if req.DryRun {
+			pruned = append(pruned, status)
+			continue
+		}
+		if err := ctrl.pruneWorktree(c.Request.Context(), c.Param("workspaceId"), repoDir, managedDir, status); err != nil {
+			log.Error().Err(err).Str("path", status.Path).Msg("Failed to prune worktree")
+			failed = append(failed, PruneWorktreeFailure{Status: status, Error: err.Error()})
+			continue
+		}
+		pruned = append(pruned, status)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pruned": pruned, "failed": failed})
+}
+
+func (ctrl *Controller) pruneWorktree(ctx context.Context, workspaceId, repoDir, managedDir string, status domain.WorktreeStatus) error {
+	params := git.PruneWorktreeParams{
+		RepoDir: repoDir,
+		Branch:  status.Branch,
+	}
+	// never remove directories sidekick doesn't manage, eg if a record has an
+	// unexpected working directory
+	if strings.HasPrefix(status.Path, managedDir+string(filepath.Separator)) {
+		params.WorktreePath = status.Path
+	}
+	if !status.Merged && (status.Branch != "" || status.Dirty) {
+		reasons := make([]string, 0, len(status.OrphanReasons))
+		for _, reason := range status.OrphanReasons {
+			reasons = append(reasons, string(reason))
+		}
+		params.ArchiveMessage = "Orphaned sidekick worktree pruned: " + strings.Join(reasons, ", ")
+	}
+	if status.Worktree != nil {
+		params.FlowId = status.Worktree.FlowId
+	}
+	if err := git.PruneWorktree(ctx, params); err != nil {
+		return err
+	}
+	if status.Worktree != nil {
+		if err := ctrl.service.DeleteWorktree(ctx, workspaceId, status.Worktree.Id); err != nil {
+			return fmt.Errorf("failed to delete worktree record: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"sidekick/coding/git"
+	"sidekick/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileWorktrees(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	inventory := worktreeInventory{
+		records: []domain.Worktree{
+			{Id: "wt_running", FlowId: "flow_running", Name: "side/running", WorkingDirectory: "/wt/running", Created: now.Add(-1 * time.Hour)},
+			{Id: "wt_done_recent", FlowId: "flow_done_recent", Name: "side/done-recent", WorkingDirectory: "/wt/done-recent", Created: now.Add(-2 * day)},
+			{Id: "wt_done_old", FlowId: "flow_done_old", Name: "side/done-old", WorkingDirectory: "/wt/done-old", Created: now.Add(-10 * day)},
+			{Id: "wt_merged", FlowId: "flow_merged", Name: "side/merged", WorkingDirectory: "/wt/merged", Created: now.Add(-3 * day)},
+			{Id: "wt_gone", FlowId: "flow_missing", Name: "side/gone", WorkingDirectory: "/wt/gone", Created: now.Add(-4 * day)},
+			{Id: "wt_dirty", FlowId: "flow_dirty", Name: "side/dirty", WorkingDirectory: "/wt/dirty", Created: now.Add(-1 * day)},
+		},
+		gitWorktrees: []git.GitWorktree{
+			{Path: "/wt/running", Branch: "side/running"},
+			{Path: "/wt/done-recent", Branch: "side/done-recent"},
+			{Path: "/wt/done-old", Branch: "side/done-old"},
+			{Path: "/wt/merged", Branch: "side/merged"},
+			{Path: "/wt/untracked", Branch: "side/untracked"},
+			{Path: "/wt/dirty", Branch: "side/dirty"},
+		},
+		dirs: map[string]time.Time{
+			"/wt/running":     now,
+			"/wt/done-recent": now,
+			"/wt/done-old":    now,
+			"/wt/merged":      now,
+			"/wt/untracked":   now.Add(-5 * day),
+			"/wt/stray":       now.Add(-6 * day),
+			"/wt/dirty":       now,
+		},
+		flowStatuses: map[string]string{
+			"flow_running":     "in_progress",
+			"flow_done_recent": "completed",
+			"flow_done_old":    "failed",
+			"flow_merged":      "completed",
+			"flow_dirty":       "failed",
+		},
+		branches: map[string]bool{
+			"side/running":     true,
+			"side/done-recent": true,
+			"side/done-old":    true,
+			"side/merged":      true,
+			"side/untracked":   true,
+			"side/dirty":       true,
+		},
+		mergedBranches: map[string]bool{
+			"side/running": true, // no commits yet
+			"side/merged":  true,
+			"side/dirty":   true, // changes were never committed
+		},
+		dirtyDirs: map[string]bool{
+			"/wt/dirty": true,
+		},
+	}
+
+	statuses := reconcileWorktrees(inventory, 7*day, now)
+	require.Len(t, statuses, 8)
+
+	byPath := make(map[string]domain.WorktreeStatus)
+	var paths []string
+	for _, status := range statuses {
+		byPath[status.Path] = status
+		paths = append(paths, status.Path)
+	}
+	assert.Equal(t, []string{"/wt/running", "/wt/dirty", "/wt/done-recent", "/wt/merged", "/wt/gone", "/wt/untracked", "/wt/stray", "/wt/done-old"}, paths)
+
+	running := byPath["/wt/running"]
+	assert.Empty(t, running.OrphanReasons)
+	assert.False(t, running.Prunable)
+	assert.Equal(t, "in_progress", running.FlowStatus)
+
+	doneRecent := byPath["/wt/done-recent"]
+	assert.Equal(t, []domain.WorktreeOrphanReason{domain.WorktreeOrphanReasonFlowFinished}, doneRecent.OrphanReasons)
+	assert.False(t, doneRecent.Prunable, "unmerged work within the retention period is kept")
+
+	doneOld := byPath["/wt/done-old"]
+	assert.True(t, doneOld.Prunable)
+	assert.False(t, doneOld.Merged)
+
+	merged := byPath["/wt/merged"]
+	assert.Equal(t, []domain.WorktreeOrphanReason{domain.WorktreeOrphanReasonFlowFinished, domain.WorktreeOrphanReasonBranchMerged}, merged.OrphanReasons)
+	assert.True(t, merged.Prunable)
+
+	gone := byPath["/wt/gone"]
+	assert.Equal(t, []domain.WorktreeOrphanReason{domain.WorktreeOrphanReasonFlowFinished, domain.WorktreeOrphanReasonDirMissing}, gone.OrphanReasons)
+	assert.Empty(t, gone.Branch)
+	assert.True(t, gone.Prunable, "nothing is left to lose")
+
+	dirty := byPath["/wt/dirty"]
+	assert.True(t, dirty.Dirty)
+	assert.False(t, dirty.Merged, "uncommitted changes aren't merged")
+	assert.Equal(t, []domain.WorktreeOrphanReason{domain.WorktreeOrphanReasonFlowFinished}, dirty.OrphanReasons)
+	assert.False(t, dirty.Prunable, "uncommitted work within the retention period is kept")
+
+	untracked := byPath["/wt/untracked"]
+	assert.Nil(t, untracked.Worktree)
+	assert.Equal(t, "side/untracked", untracked.Branch)
+	assert.Equal(t, []domain.WorktreeOrphanReason{domain.WorktreeOrphanReasonUntracked}, untracked.OrphanReasons)
+	assert.False(t, untracked.Prunable)
+
+	stray := byPath["/wt/stray"]
+	assert.Equal(t, []domain.WorktreeOrphanReason{domain.WorktreeOrphanReasonUntracked}, stray.OrphanReasons)
+	assert.False(t, stray.Prunable)
+
+	t.Run("ignoring retention", func(t *testing.T) {
+		for _, status := range reconcileWorktrees(inventory, 0, now) {
+			assert.Equal(t, len(status.OrphanReasons) > 0, status.Prunable, status.Path)
+		}
+	})
+
+	t.Run("keeping unmerged work forever", func(t *testing.T) {
+		var prunable []string
+		for _, status := range reconcileWorktrees(inventory, -1, now) {
+			if status.Prunable {
+				prunable = append(prunable, status.Path)
+			}
+		}
+		assert.ElementsMatch(t, []string{"/wt/merged", "/wt/gone"}, prunable)
+	})
+}
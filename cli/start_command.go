@@ -12,6 +12,7 @@ import (
 	"sidekick/api"
 	"sidekick/common"
 	"sidekick/nats"
+	"sidekick/telemetry"
 	"sidekick/worker"
 	"strings"
 	"sync"
@@ -436,6 +437,11 @@ func handleStartCommand(cliCtx context.Context, cmd *cli.Command) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	shutdownTracing, err := telemetry.InitTracing(ctx, "sidekick")
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+
 	var wg sync.WaitGroup
 
 	if temporal {
@@ -504,10 +510,19 @@ func handleStartCommand(cliCtx context.Context, cmd *cli.Command) error {
 			log.Info().Msg("Starting worker...")
 			w := startWorker()
 
+			// the api server already serves metrics when running in-process
+			var metricsServer *http.Server
+			if !server {
+				metricsServer = telemetry.StartMetricsServer(common.GetWorkerMetricsPort())
+			}
+
 			// Wait for cancellation
 			<-ctx.Done()
 			log.Info().Msg("Stopping worker...")
 			w.Stop()
+			if metricsServer != nil {
+				metricsServer.Shutdown(context.Background())
+			}
 		}()
 	}
 
@@ -547,6 +562,9 @@ func handleStartCommand(cliCtx context.Context, cmd *cli.Command) error {
 
 	// Wait for all processes to complete
 	wg.Wait()
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.Error().Err(err).Msg("Failed to shut down tracing")
+	}
 	log.Info().Msg("Shut down gracefully")
 	return nil
 }
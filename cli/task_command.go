@@ -32,6 +32,7 @@ func NewTaskCommand() *cli.Command {
 		Name:      "task",
 		Usage:     "Start a new task (e.g., side task \"fix the error in my tests\")",
 		ArgsUsage: "<task description>",
+		UsageText: "side task [options] <task description>\nside task <command> [options] [task id]",
 		Flags: []cli.Flag{
 			// TODO support this flag, after introducing a way to provide a customized DevConfig per invoked flow
 			//&cli.BoolFlag{Name: "disable-human-in-the-loop", Usage: "Disable human-in-the-loop prompts"},
@@ -41,7 +42,9 @@ func NewTaskCommand() *cli.Command {
 			&cli.StringFlag{Name: "flow-options", Value: `{"determineRequirements": true}`, Usage: "JSON string for flow options"},
 			&cli.StringSliceFlag{Name: "flow-option", Aliases: []string{"O"}, Usage: "Add flow option (key=value), can be specified multiple times"},
 			&cli.BoolFlag{Name: "no-requirements", Aliases: []string{"n"}, Usage: "Shorthand to set determineRequirements to false in flow options"},
+			&cli.StringFlag{Name: "sub-project", Usage: "Scope the task to a sub-project, ie a directory with its own side.toml, given relative to the repo root"},
 		},
+		Commands: taskSubcommands(),
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			c := client.NewClient(fmt.Sprintf("http://localhost:%d", common.GetServerPort()))
 			return executeTaskCommand(ctx, c, cmd)
@@ -64,6 +67,11 @@ func parseFlowOptions(cmd *cli.Command) (map[string]interface{}, error) {
 		flowOpts["determineRequirements"] = false
 	}
 
+	// --sub-project is shorthand for the "subProject" key
+	if subProject := cmd.String("sub-project"); subProject != "" {
+		flowOpts["subProject"] = subProject
+	}
+
 	// --flow-option key=value pairs override any existing keys
 	for _, optStr := range cmd.StringSlice("flow-option") {
 		key, valueStr, didCut := strings.Cut(optStr, "=")
@@ -105,7 +113,9 @@ func executeTaskCommand(ctx context.Context, c client.Client, cmd *cli.Command)
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	p := tea.NewProgram(newLifecycleModel(sigChan))
+	model := newLifecycleModel(sigChan)
+	model.completeFlowAction = flowActionCompleter(ctx, c)
+	p := tea.NewProgram(model)
 
 	var monitor *TaskMonitor
 	var task client.Task
@@ -133,7 +143,6 @@ func executeTaskCommand(ctx context.Context, c client.Client, cmd *cli.Command)
 			return
 		}
 		// task was created, but starts asyncronously
-		started := false
 		p.Send(taskChangeMsg{task: task})
 
 		if cmd.Bool("async") {
@@ -144,28 +153,7 @@ func executeTaskCommand(ctx context.Context, c client.Client, cmd *cli.Command)
 		}
 
 		monitor = NewTaskMonitor(c, workspace.Id, task.Id)
-		statusChan, progressChan := monitor.Start(ctx)
-		for {
-			select {
-			case taskProgress := <-progressChan:
-				p.Send(flowActionChangeMsg{actionType: taskProgress.ActionType, actionStatus: taskProgress.ActionStatus})
-			case taskStatus := <-statusChan:
-				if !started && len(taskStatus.Task.Flows) > 0 {
-					started = true
-					p.Send(updateLifecycleMsg{key: "init", content: "Task started"})
-				}
-				p.Send(taskChangeMsg{task: taskStatus.Task})
-				if taskStatus.Error != nil {
-					p.Send(taskErrorMsg{err: taskStatus.Error})
-				}
-				if taskStatus.Finished {
-					finalMessage := finishMessage(taskStatus.Task, kanbanLink(workspace.Id))
-					p.Send(updateLifecycleMsg{key: "init", content: finalMessage})
-					p.Quit()
-					return
-				}
-			}
-		}
+		relayTaskMonitor(ctx, p, monitor, workspace.Id, false)
 	}()
 
 	wg := sync.WaitGroup{}
@@ -203,6 +191,53 @@ func executeTaskCommand(ctx context.Context, c client.Client, cmd *cli.Command)
 	return nil
 }
 
+// relayTaskMonitor starts the monitor and relays its updates to the task UI,
+// quitting the UI once the task finishes.
+func relayTaskMonitor(ctx context.Context, p *tea.Program, monitor *TaskMonitor, workspaceId string, started bool) {
+	statusChan, progressChan := monitor.Start(ctx)
+	for statusChan != nil || progressChan != nil {
+		select {
+		case taskProgress, ok := <-progressChan:
+			if !ok {
+				progressChan = nil
+				continue
+			}
+			p.Send(flowActionChangeMsg{actionType: taskProgress.ActionType, actionStatus: taskProgress.ActionStatus})
+			if taskProgress.FlowAction.IsHumanAction {
+				p.Send(userRequestMsg{flowAction: taskProgress.FlowAction})
+			}
+		case taskStatus, ok := <-statusChan:
+			if !ok {
+				statusChan = nil
+				continue
+			}
+			if !started && len(taskStatus.Task.Flows) > 0 {
+				started = true
+				p.Send(updateLifecycleMsg{key: "init", content: "Task started"})
+			}
+			p.Send(taskChangeMsg{task: taskStatus.Task})
+			if taskStatus.Error != nil {
+				p.Send(taskErrorMsg{err: taskStatus.Error})
+			}
+			if taskStatus.Finished {
+				finalMessage := finishMessage(taskStatus.Task, kanbanLink(workspaceId))
+				p.Send(updateLifecycleMsg{key: "init", content: finalMessage})
+				p.Quit()
+				return
+			}
+		}
+	}
+}
+
+// flowActionCompleter returns a function that submits responses to requests for
+// user input via the API
+func flowActionCompleter(ctx context.Context, c client.Client) completeFlowActionFunc {
+	return func(flowAction domain.FlowAction, response client.UserResponse) error {
+		_, err := c.CompleteFlowAction(ctx, flowAction.WorkspaceId, flowAction.Id, response)
+		return err
+	}
+}
+
 func buildCreateTaskRequest(cmd *cli.Command) (*client.CreateTaskRequest, error) {
 	taskDescription := cmd.Args().First()
 
@@ -293,30 +328,9 @@ type teaSendable interface {
 func ensureWorkspace(ctx context.Context, dir string, p teaSendable, c client.Client, disableHumanInTheLoop bool) (*domain.Workspace, error) {
 	p.Send(updateLifecycleMsg{key: "init", content: "Looking up workspace...", spin: true})
 
-	// Get all potential repository paths to check
-	repoPaths, err := utils.GetRepositoryPaths(ctx, dir)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get repository paths: %w", err)
-	}
-
-	// Get all workspaces
-	allWorkspaces, err := c.GetAllWorkspaces(ctx)
+	matchingWorkspaces, err := findWorkspacesForDir(ctx, c, dir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve workspaces: %w", err)
-	}
-
-	// Find workspaces matching any of the repository paths
-	var matchingWorkspaces []domain.Workspace
-	for _, path := range repoPaths {
-		for _, ws := range allWorkspaces {
-			if filepath.Clean(ws.LocalRepoDir) == filepath.Clean(path) {
-				matchingWorkspaces = append(matchingWorkspaces, ws)
-			}
-		}
-		// If we found any workspaces for this path, stop searching further paths
-		if len(matchingWorkspaces) > 0 {
-			break
-		}
+		return nil, err
 	}
 
 	// Convert to pointer slice for consistency with existing code
@@ -387,3 +401,34 @@ func ensureWorkspace(ctx context.Context, dir string, p teaSendable, c client.Cl
 
 	return workspaceMap[selectedWorkspaceString], nil
 }
+
+// findWorkspacesForDir returns the workspaces for the closest repository path
+// containing dir.
+func findWorkspacesForDir(ctx context.Context, c client.Client, dir string) ([]domain.Workspace, error) {
+	// Get all potential repository paths to check
+	repoPaths, err := utils.GetRepositoryPaths(ctx, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository paths: %w", err)
+	}
+
+	// Get all workspaces
+	allWorkspaces, err := c.GetAllWorkspaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve workspaces: %w", err)
+	}
+
+	// Find workspaces matching any of the repository paths
+	var matchingWorkspaces []domain.Workspace
+	for _, path := range repoPaths {
+		for _, ws := range allWorkspaces {
+			if filepath.Clean(ws.LocalRepoDir) == filepath.Clean(path) {
+				matchingWorkspaces = append(matchingWorkspaces, ws)
+			}
+		}
+		// If we found any workspaces for this path, stop searching further paths
+		if len(matchingWorkspaces) > 0 {
+			break
+		}
+	}
+	return matchingWorkspaces, nil
+}
@@ -43,6 +43,9 @@ type taskLifecycleModel struct {
 	taskId    string
 	flowId    string
 	progModel tea.Model
+
+	// used to answer requests for user input from the terminal, if set
+	completeFlowAction completeFlowActionFunc
 }
 
 func newLifecycleModel(sigChan chan os.Signal) taskLifecycleModel {
@@ -87,13 +90,15 @@ func (m taskLifecycleModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.taskId = msg.task.Id
 		if m.progModel == nil && len(msg.task.Flows) > 0 {
 			m.flowId = msg.task.Flows[0].Id
-			m.progModel = newProgressModel(m.taskId, m.flowId)
+			progModel := newProgressModel(m.taskId, m.flowId)
+			progModel.completeFlowAction = m.completeFlowAction
+			m.progModel = progModel
 			cmd := m.progModel.Init()
 			return m, cmd
 		}
 		return m, nil
 
-	case flowActionChangeMsg:
+	case flowActionChangeMsg, userRequestMsg:
 		if m.progModel == nil {
 			// TODO queue messages until progModel is initialized
 			return m, nil
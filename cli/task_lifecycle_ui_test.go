@@ -109,6 +109,37 @@ func TestLifecycleModel(t *testing.T) {
 				"canceled",
 			},
 		},
+		{
+			name: "shows pending user request",
+			messages: []tea.Msg{
+				taskChangeMsg{task: newTestTaskWithFlows()},
+				userRequestMsg{flowAction: newTestUserRequest("fa_1", domain.ActionStatusPending)},
+			},
+			wantProgress: true,
+			wantContains: []string{
+				"Sidekick needs your input",
+				"Does this plan look good?",
+				"y: Approve • n: Reject",
+			},
+			wantNotExists: []string{
+				"Working...",
+			},
+		},
+		{
+			name: "hides user request once answered elsewhere",
+			messages: []tea.Msg{
+				taskChangeMsg{task: newTestTaskWithFlows()},
+				userRequestMsg{flowAction: newTestUserRequest("fa_1", domain.ActionStatusPending)},
+				userRequestMsg{flowAction: newTestUserRequest("fa_1", domain.ActionStatusComplete)},
+			},
+			wantProgress: true,
+			wantContains: []string{
+				"Working...",
+			},
+			wantNotExists: []string{
+				"Sidekick needs your input",
+			},
+		},
 		{
 			name: "handles cancellation",
 			messages: []tea.Msg{
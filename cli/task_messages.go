@@ -1,6 +1,9 @@
 package main
 
-import "sidekick/client"
+import (
+	"sidekick/client"
+	"sidekick/domain"
+)
 
 type taskChangeMsg struct {
 	task client.Task
@@ -15,3 +18,16 @@ type flowActionChangeMsg struct {
 type taskErrorMsg struct {
 	err error
 }
+
+// userRequestMsg is a tea.Msg sent when a human flow action changes, so that
+// pending requests for user input can be answered in the terminal.
+type userRequestMsg struct {
+	flowAction domain.FlowAction
+}
+
+// userResponseSubmittedMsg is a tea.Msg with the result of submitting a
+// response to a request for user input.
+type userResponseSubmittedMsg struct {
+	flowActionId string
+	err          error
+}
@@ -26,6 +26,7 @@ type TaskStatus struct {
 type TaskProgress struct {
 	ActionType   string
 	ActionStatus string
+	FlowAction   domain.FlowAction
 }
 
 // TaskMonitor handles WebSocket connections and status polling for tasks
@@ -220,6 +221,7 @@ func (m *TaskMonitor) streamFlowEvents(ctx context.Context, flowId string) error
 		m.progressChan <- TaskProgress{
 			ActionType:   action.ActionType,
 			ActionStatus: action.ActionStatus,
+			FlowAction:   action,
 		}
 	}
 }
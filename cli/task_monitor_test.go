@@ -52,6 +52,89 @@ func (m *mockClient) CancelTask(workspaceID string, taskID string) error {
 	return args.Error(1)
 }
 
+func (m *mockClient) GetTasks(ctx context.Context, workspaceID string, statuses []domain.TaskStatus) ([]client.Task, error) {
+	args := m.Called(ctx, workspaceID, statuses)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]client.Task), args.Error(1)
+}
+
+func (m *mockClient) ArchiveTask(ctx context.Context, workspaceID string, taskID string) error {
+	args := m.Called(ctx, workspaceID, taskID)
+	return args.Error(0)
+}
+
+func (m *mockClient) GetFlow(ctx context.Context, workspaceID string, flowID string) (client.Flow, error) {
+	args := m.Called(ctx, workspaceID, flowID)
+	if args.Get(0) == nil {
+		return client.Flow{}, args.Error(1)
+	}
+	return args.Get(0).(client.Flow), args.Error(1)
+}
+
+func (m *mockClient) PauseFlow(ctx context.Context, workspaceID string, flowID string) error {
+	args := m.Called(ctx, workspaceID, flowID)
+	return args.Error(0)
+}
+
+func (m *mockClient) CancelFlow(ctx context.Context, workspaceID string, flowID string) error {
+	args := m.Called(ctx, workspaceID, flowID)
+	return args.Error(0)
+}
+
+func (m *mockClient) SendUserAction(ctx context.Context, workspaceID string, flowID string, actionType string) error {
+	args := m.Called(ctx, workspaceID, flowID, actionType)
+	return args.Error(0)
+}
+
+func (m *mockClient) RollbackToCheckpoint(ctx context.Context, workspaceID string, flowID string, stepNumber string) error {
+	args := m.Called(ctx, workspaceID, flowID, stepNumber)
+	return args.Error(0)
+}
+
+func (m *mockClient) StreamFlowActionChanges(ctx context.Context, workspaceID string, flowID string) (<-chan domain.FlowAction, <-chan error) {
+	args := m.Called(ctx, workspaceID, flowID)
+	return args.Get(0).(<-chan domain.FlowAction), args.Get(1).(<-chan error)
+}
+
+func (m *mockClient) StreamFlowEvents(ctx context.Context, workspaceID string, flowID string, subscriptions <-chan domain.FlowEventSubscription) (<-chan domain.FlowEvent, <-chan error) {
+	args := m.Called(ctx, workspaceID, flowID, subscriptions)
+	return args.Get(0).(<-chan domain.FlowEvent), args.Get(1).(<-chan error)
+}
+
+func (m *mockClient) GetFlowActions(ctx context.Context, workspaceID string, flowID string) ([]domain.FlowAction, error) {
+	args := m.Called(ctx, workspaceID, flowID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.FlowAction), args.Error(1)
+}
+
+func (m *mockClient) CompleteFlowAction(ctx context.Context, workspaceID string, flowActionID string, response client.UserResponse) (domain.FlowAction, error) {
+	args := m.Called(ctx, workspaceID, flowActionID, response)
+	if args.Get(0) == nil {
+		return domain.FlowAction{}, args.Error(1)
+	}
+	return args.Get(0).(domain.FlowAction), args.Error(1)
+}
+
+func (m *mockClient) GetSubflows(ctx context.Context, workspaceID string, flowID string) ([]domain.Subflow, error) {
+	args := m.Called(ctx, workspaceID, flowID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Subflow), args.Error(1)
+}
+
+func (m *mockClient) GetSubflow(ctx context.Context, workspaceID string, subflowID string) (domain.Subflow, error) {
+	args := m.Called(ctx, workspaceID, subflowID)
+	if args.Get(0) == nil {
+		return domain.Subflow{}, args.Error(1)
+	}
+	return args.Get(0).(domain.Subflow), args.Error(1)
+}
+
 func (m *mockClient) CreateWorkspace(req *client.CreateWorkspaceRequest) (*domain.Workspace, error) {
 	args := m.Called(req)
 	if args.Get(0) == nil {
@@ -60,6 +143,22 @@ func (m *mockClient) CreateWorkspace(req *client.CreateWorkspaceRequest) (*domai
 	return args.Get(0).(*domain.Workspace), args.Error(1)
 }
 
+func (m *mockClient) GetWorktrees(ctx context.Context, workspaceID string) ([]domain.WorktreeStatus, error) {
+	args := m.Called(ctx, workspaceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.WorktreeStatus), args.Error(1)
+}
+
+func (m *mockClient) PruneWorktrees(ctx context.Context, workspaceID string, req client.PruneWorktreesRequest) (client.PruneWorktreesResult, error) {
+	args := m.Called(ctx, workspaceID, req)
+	if args.Get(0) == nil {
+		return client.PruneWorktreesResult{}, args.Error(1)
+	}
+	return args.Get(0).(client.PruneWorktreesResult), args.Error(1)
+}
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
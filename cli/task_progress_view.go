@@ -5,6 +5,7 @@ package main
 import (
 	"fmt"
 	"sidekick/common"
+	"sidekick/domain"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -19,6 +20,10 @@ type taskProgressModel struct {
 	messages []string
 	quitting bool
 	err      error
+
+	width              int
+	request            *userRequestModel
+	completeFlowAction completeFlowActionFunc
 }
 
 func newProgressModel(taskID, flowID string) taskProgressModel {
@@ -30,6 +35,7 @@ func newProgressModel(taskID, flowID string) taskProgressModel {
 		taskID:   taskID,
 		flowID:   flowID,
 		messages: []string{},
+		width:    80,
 	}
 }
 
@@ -43,6 +49,19 @@ func (m taskProgressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "ctrl+c":
 			m.quitting = true
+		default:
+			if m.request != nil {
+				var cmd tea.Cmd
+				*m.request, cmd = m.request.Update(msg)
+				return m, cmd
+			}
+		}
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		if m.request != nil {
+			*m.request, _ = m.request.Update(msg)
 		}
 		return m, nil
 
@@ -51,13 +70,54 @@ func (m taskProgressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.messages = append(m.messages, progressLine)
 		return m, nil
 
+	case userRequestMsg:
+		return m.updateUserRequest(msg.flowAction)
+
+	case userResponseSubmittedMsg:
+		if m.request == nil || m.request.flowAction.Id != msg.flowActionId {
+			return m, nil
+		}
+		if msg.err == nil {
+			m.request = nil
+			return m, nil
+		}
+		*m.request, _ = m.request.Update(msg)
+		return m, nil
+
 	default:
+		var cmds []tea.Cmd
 		var cmd tea.Cmd
 		m.spinner, cmd = m.spinner.Update(msg)
-		return m, cmd
+		cmds = append(cmds, cmd)
+		if m.request != nil {
+			*m.request, cmd = m.request.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+		return m, tea.Batch(cmds...)
 	}
 }
 
+// updateUserRequest shows pending requests for user input, and hides them once
+// they are no longer pending, eg when answered via the web UI instead
+func (m taskProgressModel) updateUserRequest(flowAction domain.FlowAction) (tea.Model, tea.Cmd) {
+	pending := flowAction.ActionStatus == domain.ActionStatusPending && isUserRequest(flowAction)
+	if m.request != nil && m.request.flowAction.Id == flowAction.Id {
+		if !pending {
+			m.request = nil
+			return m, nil
+		}
+		m.request.setFlowAction(flowAction)
+		return m, nil
+	}
+	if !pending {
+		return m, nil
+	}
+
+	request := newUserRequestModel(flowAction, m.width, m.completeFlowAction)
+	m.request = &request
+	return m, request.Init()
+}
+
 func (m taskProgressModel) View() string {
 	var b strings.Builder
 
@@ -70,6 +130,9 @@ func (m taskProgressModel) View() string {
 		if m.err != nil {
 			b.WriteString(fmt.Sprintf("Error: %v\n", m.err))
 		}
+	} else if m.request != nil {
+		b.WriteString("\n")
+		b.WriteString(m.request.View())
 	} else {
 		b.WriteString(fmt.Sprintf(`
 ⚠️  Sidekick's cli-only mode is *experimental*. Interact via http://localhost:%d/flows/%s
@@ -0,0 +1,528 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"sidekick/client"
+	"sidekick/common"
+	"sidekick/domain"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/urfave/cli/v3"
+)
+
+// taskSubcommands are the subcommands of `side task` used to manage existing
+// tasks, as opposed to starting a new one
+func taskSubcommands() []*cli.Command {
+	workspaceFlag := &cli.StringFlag{Name: "workspace-id", Aliases: []string{"W"}, Usage: "Workspace id, defaults to the workspace for the current directory"}
+
+	return []*cli.Command{
+		{
+			Name:  "list",
+			Usage: "List tasks in the workspace",
+			Flags: []cli.Flag{
+				workspaceFlag,
+				&cli.StringSliceFlag{Name: "status", Aliases: []string{"s"}, Usage: "Only list tasks with this status, can be specified multiple times"},
+			},
+			Action: taskAction(func(ctx context.Context, c client.Client, workspaceId string, cmd *cli.Command) error {
+				var statuses []domain.TaskStatus
+				for _, status := range cmd.StringSlice("status") {
+					if !slices.Contains(domain.AllTaskStatuses, domain.TaskStatus(status)) {
+						return fmt.Errorf("invalid status %q, expected one of: %s", status, joinTaskStatuses(domain.AllTaskStatuses))
+					}
+					statuses = append(statuses, domain.TaskStatus(status))
+				}
+				return listTasks(ctx, c, os.Stdout, workspaceId, statuses)
+			}),
+		},
+		{
+			Name:      "show",
+			Usage:     "Show a task's details, including its flows, worktrees and subflows",
+			ArgsUsage: "<task id>",
+			Flags:     []cli.Flag{workspaceFlag},
+			Action: taskIdAction(func(ctx context.Context, c client.Client, workspaceId, taskId string, cmd *cli.Command) error {
+				return showTask(ctx, c, os.Stdout, workspaceId, taskId)
+			}),
+		},
+		{
+			Name:      "logs",
+			Usage:     "Print a task's flow actions, optionally following new actions and progress as they happen",
+			ArgsUsage: "<task id>",
+			Flags: []cli.Flag{
+				workspaceFlag,
+				&cli.BoolFlag{Name: "follow", Aliases: []string{"f"}, Usage: "Stream flow actions and progress until the task finishes"},
+			},
+			Action: taskIdAction(func(ctx context.Context, c client.Client, workspaceId, taskId string, cmd *cli.Command) error {
+				if cmd.Bool("follow") {
+					ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+					defer stop()
+					return followTaskLogs(ctx, c, os.Stdout, workspaceId, taskId)
+				}
+				return printTaskLogs(ctx, c, os.Stdout, workspaceId, taskId)
+			}),
+		},
+		{
+			Name:      "attach",
+			Usage:     "Show progress for a running task. Ctrl+C detaches without canceling the task",
+			ArgsUsage: "<task id>",
+			Flags:     []cli.Flag{workspaceFlag},
+			Action: taskIdAction(func(ctx context.Context, c client.Client, workspaceId, taskId string, cmd *cli.Command) error {
+				return attachTask(ctx, c, workspaceId, taskId)
+			}),
+		},
+		{
+			Name:      "cancel",
+			Usage:     "Cancel a task",
+			ArgsUsage: "<task id>",
+			Flags:     []cli.Flag{workspaceFlag},
+			Action: taskIdAction(func(ctx context.Context, c client.Client, workspaceId, taskId string, cmd *cli.Command) error {
+				if err := c.CancelTask(workspaceId, taskId); err != nil {
+					return err
+				}
+				fmt.Printf("Task %s canceled\n", taskId)
+				return nil
+			}),
+		},
+		{
+			Name:      "archive",
+			Usage:     "Archive a finished task",
+			ArgsUsage: "<task id>",
+			Flags:     []cli.Flag{workspaceFlag},
+			Action: taskIdAction(func(ctx context.Context, c client.Client, workspaceId, taskId string, cmd *cli.Command) error {
+				if err := c.ArchiveTask(ctx, workspaceId, taskId); err != nil {
+					return err
+				}
+				fmt.Printf("Task %s archived\n", taskId)
+				return nil
+			}),
+		},
+		{
+			Name:      "pause",
+			Usage:     "Pause a task's flow, so that it waits for guidance before continuing",
+			ArgsUsage: "<task id>",
+			Flags:     []cli.Flag{workspaceFlag},
+			Action: taskIdAction(func(ctx context.Context, c client.Client, workspaceId, taskId string, cmd *cli.Command) error {
+				return pauseTask(ctx, c, os.Stdout, workspaceId, taskId)
+			}),
+		},
+		{
+			Name:      "retry",
+			Usage:     "Start a new task with the same description and options as a failed or canceled task",
+			ArgsUsage: "<task id>",
+			Flags: []cli.Flag{
+				workspaceFlag,
+				&cli.BoolFlag{Name: "async", Usage: "Don't show progress for the new task and exit immediately"},
+			},
+			Action: taskIdAction(func(ctx context.Context, c client.Client, workspaceId, taskId string, cmd *cli.Command) error {
+				task, err := retryTask(ctx, c, workspaceId, taskId)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Started task %s, retrying task %s\n", task.Id, taskId)
+				if cmd.Bool("async") {
+					return nil
+				}
+				return attachTask(ctx, c, workspaceId, task.Id)
+			}),
+		},
+	}
+}
+
+type taskActionFunc func(ctx context.Context, c client.Client, workspaceId string, cmd *cli.Command) error
+
+// taskAction wraps a task subcommand's action with client setup and workspace
+// resolution, and turns errors into exit errors
+func taskAction(action taskActionFunc) cli.ActionFunc {
+	return func(ctx context.Context, cmd *cli.Command) error {
+		c := client.NewClient(fmt.Sprintf("http://localhost:%d", common.GetServerPort()))
+		if !checkServerStatus() {
+			return cli.Exit("Sidekick server is not running. Start it with `side start`.", 1)
+		}
+		workspaceId, err := resolveWorkspaceId(ctx, c, cmd.String("workspace-id"))
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		if err := action(ctx, c, workspaceId, cmd); err != nil {
+			return cli.Exit(fmt.Sprintf("Error: %v", err), 1)
+		}
+		return nil
+	}
+}
+
+// taskIdAction is like taskAction, for subcommands that take a task id as
+// their only argument
+func taskIdAction(action func(ctx context.Context, c client.Client, workspaceId, taskId string, cmd *cli.Command) error) cli.ActionFunc {
+	return taskAction(func(ctx context.Context, c client.Client, workspaceId string, cmd *cli.Command) error {
+		taskId := cmd.Args().First()
+		if taskId == "" {
+			return fmt.Errorf("a task id is required. Run `side task list` to find one")
+		}
+		return action(ctx, c, workspaceId, taskId, cmd)
+	})
+}
+
+// resolveWorkspaceId returns the given workspace id if set, otherwise the id of
+// the workspace for the current directory. Unlike ensureWorkspace, it never
+// creates a workspace or prompts for one.
+func resolveWorkspaceId(ctx context.Context, c client.Client, workspaceId string) (string, error) {
+	if workspaceId != "" {
+		return workspaceId, nil
+	}
+
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("Error getting current working directory: %w", err)
+	}
+	workspaces, err := findWorkspacesForDir(ctx, c, currentDir)
+	if err != nil {
+		return "", err
+	}
+
+	switch len(workspaces) {
+	case 0:
+		return "", fmt.Errorf("No workspace found for %s. Run `side init` or specify one with --workspace-id", currentDir)
+	case 1:
+		return workspaces[0].Id, nil
+	default:
+		ids := make([]string, len(workspaces))
+		for i, ws := range workspaces {
+			ids[i] = fmt.Sprintf("%s (%s)", ws.Id, ws.Name)
+		}
+		return "", fmt.Errorf("Multiple workspaces found for %s, specify one with --workspace-id: %s", currentDir, strings.Join(ids, ", "))
+	}
+}
+
+func joinTaskStatuses(statuses []domain.TaskStatus) string {
+	strs := make([]string, len(statuses))
+	for i, status := range statuses {
+		strs[i] = string(status)
+	}
+	return strings.Join(strs, ", ")
+}
+
+func isTaskFinished(status domain.TaskStatus) bool {
+	return status == domain.TaskStatusComplete || status == domain.TaskStatusFailed || status == domain.TaskStatusCanceled
+}
+
+// taskSummary returns the title of the task, or else the first line of its
+// description, truncated to fit on a single line
+func taskSummary(task domain.Task) string {
+	summary := task.Title
+	if summary == "" {
+		summary, _, _ = strings.Cut(strings.TrimSpace(task.Description), "\n")
+	}
+	const maxLength = 60
+	if runes := []rune(summary); len(runes) > maxLength {
+		summary = string(runes[:maxLength-3]) + "..."
+	}
+	return summary
+}
+
+func listTasks(ctx context.Context, c client.Client, w io.Writer, workspaceId string, statuses []domain.TaskStatus) error {
+	tasks, err := c.GetTasks(ctx, workspaceId, statuses)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		fmt.Fprintln(w, "No tasks found")
+		return nil
+	}
+
+	slices.SortFunc(tasks, func(a, b client.Task) int {
+		return b.Updated.Compare(a.Updated)
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tFLOW\tUPDATED\tTASK")
+	for _, task := range tasks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", task.Id, task.Status, task.FlowType, task.Updated.Local().Format(time.DateTime), taskSummary(task.Task))
+	}
+	return tw.Flush()
+}
+
+func showTask(ctx context.Context, c client.Client, w io.Writer, workspaceId, taskId string) error {
+	task, err := c.GetTask(workspaceId, taskId)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%s\n", task.Id)
+	if task.Title != "" {
+		fmt.Fprintf(tw, "Title:\t%s\n", task.Title)
+	}
+	fmt.Fprintf(tw, "Status:\t%s\n", task.Status)
+	fmt.Fprintf(tw, "Flow type:\t%s\n", task.FlowType)
+	fmt.Fprintf(tw, "Created:\t%s\n", task.Created.Local().Format(time.DateTime))
+	fmt.Fprintf(tw, "Updated:\t%s\n", task.Updated.Local().Format(time.DateTime))
+	if task.Archived != nil {
+		fmt.Fprintf(tw, "Archived:\t%s\n", task.Archived.Local().Format(time.DateTime))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nDescription:\n%s\n", indent(task.Description, "  "))
+
+	for _, taskFlow := range task.Flows {
+		flow, err := c.GetFlow(ctx, workspaceId, taskFlow.Id)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "\nFlow %s (%s): %s\n", flow.Id, flow.Type, flow.Status)
+		for _, worktree := range flow.Worktrees {
+			fmt.Fprintf(w, "  Worktree: %s (branch %s)\n", worktree.WorkingDirectory, worktree.Name)
+		}
+
+		subflows, err := c.GetSubflows(ctx, workspaceId, flow.Id)
+		if err != nil {
+			return err
+		}
+		if len(subflows) > 0 {
+			fmt.Fprintln(w, "  Subflows:")
+			writeSubflowTree(w, subflows, "", "    ")
+		}
+	}
+
+	return nil
+}
+
+// writeSubflowTree writes the subflows with the given parent, and recursively
+// their children, indenting each level further
+func writeSubflowTree(w io.Writer, subflows []domain.Subflow, parentSubflowId, prefix string) {
+	for _, subflow := range subflows {
+		if subflow.ParentSubflowId != parentSubflowId {
+			continue
+		}
+		fmt.Fprintf(w, "%s%s: %s\n", prefix, subflow.Name, subflow.Status)
+		writeSubflowTree(w, subflows, subflow.Id, prefix+"  ")
+	}
+}
+
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "\n"+prefix)
+}
+
+func formatFlowAction(flowAction domain.FlowAction) string {
+	line := fmt.Sprintf("%s  %-9s  %s", flowAction.Updated.Local().Format(time.TimeOnly), flowAction.ActionStatus, flowAction.ActionType)
+	if flowAction.SubflowName != "" {
+		line += fmt.Sprintf(" [%s]", flowAction.SubflowName)
+	}
+	if flowAction.IsHumanAction && flowAction.ActionStatus == domain.ActionStatusPending {
+		line += " (awaiting human input)"
+	}
+	return line
+}
+
+// printTaskLogs writes all the flow actions of the task's flows
+func printTaskLogs(ctx context.Context, c client.Client, w io.Writer, workspaceId, taskId string) error {
+	task, err := c.GetTask(workspaceId, taskId)
+	if err != nil {
+		return err
+	}
+	if len(task.Flows) == 0 {
+		fmt.Fprintln(w, "Task has not started yet")
+		return nil
+	}
+
+	for _, flow := range task.Flows {
+		flowActions, err := c.GetFlowActions(ctx, workspaceId, flow.Id)
+		if err != nil {
+			return err
+		}
+		for _, flowAction := range flowActions {
+			fmt.Fprintln(w, formatFlowAction(flowAction))
+		}
+	}
+	return nil
+}
+
+// followTaskLogs writes flow actions as they are created or change status,
+// along with their progress text, until the task finishes or ctx is done
+func followTaskLogs(ctx context.Context, c client.Client, w io.Writer, workspaceId, taskId string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	task, err := c.GetTask(workspaceId, taskId)
+	if err != nil {
+		return err
+	}
+	for len(task.Flows) == 0 {
+		if isTaskFinished(task.Status) {
+			fmt.Fprintf(w, "Task %s without starting\n", task.Status)
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(TaskPollInterval):
+		}
+		if task, err = c.GetTask(workspaceId, taskId); err != nil {
+			return err
+		}
+	}
+	flowId := task.Flows[0].Id
+
+	subscriptions := make(chan domain.FlowEventSubscription, 100)
+	flowActionCh, flowActionErrCh := c.StreamFlowActionChanges(ctx, workspaceId, flowId)
+	flowEventCh, flowEventErrCh := c.StreamFlowEvents(ctx, workspaceId, flowId, subscriptions)
+
+	ticker := time.NewTicker(TaskPollInterval)
+	defer ticker.Stop()
+
+	lastStatuses := make(map[string]domain.ActionStatus)
+	lastProgress := make(map[string]string)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			task, err := c.GetTask(workspaceId, taskId)
+			if err != nil {
+				return err
+			}
+			if isTaskFinished(task.Status) {
+				fmt.Fprintln(w, finishMessage(task, kanbanLink(workspaceId)))
+				return nil
+			}
+		case err, ok := <-flowActionErrCh:
+			if !ok {
+				flowActionErrCh = nil
+				continue
+			}
+			return err
+		case err, ok := <-flowEventErrCh:
+			if !ok {
+				flowEventErrCh = nil
+				continue
+			}
+			return err
+		case flowAction, ok := <-flowActionCh:
+			if !ok {
+				flowActionCh = nil
+				continue
+			}
+			lastStatus, seen := lastStatuses[flowAction.Id]
+			if seen && lastStatus == flowAction.ActionStatus {
+				continue
+			}
+			lastStatuses[flowAction.Id] = flowAction.ActionStatus
+			fmt.Fprintln(w, formatFlowAction(flowAction))
+			if !seen && flowAction.ActionStatus == domain.ActionStatusStarted {
+				subscriptions <- domain.FlowEventSubscription{ParentId: flowAction.Id}
+			}
+		case flowEvent, ok := <-flowEventCh:
+			if !ok {
+				flowEventCh = nil
+				continue
+			}
+			progress, isProgress := flowEvent.(domain.ProgressTextEvent)
+			if !isProgress || progress.Text == lastProgress[progress.ParentId] {
+				continue
+			}
+			lastProgress[progress.ParentId] = progress.Text
+			fmt.Fprintf(w, "          %s\n", progress.Text)
+		}
+	}
+}
+
+// attachTask shows the progress UI for an existing task until it finishes.
+// Unlike when starting a task, interrupting only detaches from the task.
+func attachTask(ctx context.Context, c client.Client, workspaceId, taskId string) error {
+	task, err := c.GetTask(workspaceId, taskId)
+	if err != nil {
+		return err
+	}
+	if isTaskFinished(task.Status) {
+		fmt.Println(finishMessage(task, kanbanLink(workspaceId)))
+		return nil
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	model := newLifecycleModel(sigChan)
+	model.completeFlowAction = flowActionCompleter(ctx, c)
+	p := tea.NewProgram(model)
+
+	monitor := NewTaskMonitor(c, workspaceId, taskId)
+	go func() {
+		p.Send(taskChangeMsg{task: task})
+		if len(task.Flows) > 0 {
+			p.Send(updateLifecycleMsg{key: "init", content: "Attached to task"})
+		}
+		relayTaskMonitor(ctx, p, monitor, workspaceId, len(task.Flows) > 0)
+	}()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigChan:
+		}
+		monitor.Stop()
+		p.Send(updateLifecycleMsg{key: "finish", content: fmt.Sprintf("Detached. The task continues in the background: %s", kanbanLink(workspaceId))})
+		p.Quit()
+	}()
+
+	if _, err := p.Run(); err != nil {
+		return fmt.Errorf("failed to run task UI: %w", err)
+	}
+	return nil
+}
+
+// pauseTask pauses the task's unfinished flow, if any
+func pauseTask(ctx context.Context, c client.Client, w io.Writer, workspaceId, taskId string) error {
+	task, err := c.GetTask(workspaceId, taskId)
+	if err != nil {
+		return err
+	}
+	if isTaskFinished(task.Status) {
+		return fmt.Errorf("task %s is already %s", taskId, task.Status)
+	}
+
+	for _, flow := range task.Flows {
+		switch flow.Status {
+		case domain.FlowStatusPaused:
+			fmt.Fprintf(w, "Task %s is already paused\n", taskId)
+			return nil
+		case "in_progress":
+			if err := c.PauseFlow(ctx, workspaceId, flow.Id); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "Task %s paused. Resume it by responding in the web UI: %s\n", taskId, kanbanLink(workspaceId))
+			return nil
+		}
+	}
+	return fmt.Errorf("task %s has no in-progress flow to pause", taskId)
+}
+
+var errTaskNotRetryable = errors.New("only failed or canceled tasks can be retried")
+
+// retryTask creates a new task with the same description, flow type and flow
+// options as the given failed or canceled task
+func retryTask(ctx context.Context, c client.Client, workspaceId, taskId string) (client.Task, error) {
+	task, err := c.GetTask(workspaceId, taskId)
+	if err != nil {
+		return client.Task{}, err
+	}
+	if task.Status != domain.TaskStatusFailed && task.Status != domain.TaskStatusCanceled {
+		return client.Task{}, fmt.Errorf("%w, but task %s is %s", errTaskNotRetryable, taskId, task.Status)
+	}
+
+	return c.CreateTask(workspaceId, &client.CreateTaskRequest{
+		Title:       task.Title,
+		Description: task.Description,
+		FlowType:    task.FlowType,
+		FlowOptions: task.FlowOptions,
+	})
+}
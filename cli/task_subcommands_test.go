@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sidekick/client"
+	"sidekick/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTasks(t *testing.T) {
+	ctx := context.Background()
+	older := time.Date(2025, 1, 1, 10, 0, 0, 0, time.Local)
+	newer := older.Add(time.Hour)
+
+	c := &mockClient{}
+	c.On("GetTasks", ctx, "ws_1", []domain.TaskStatus{domain.TaskStatusFailed}).Return([]client.Task{
+		{Task: domain.Task{Id: "task_old", Status: domain.TaskStatusFailed, FlowType: "basic_dev", Description: "first line\nsecond line", Updated: older}},
+		{Task: domain.Task{Id: "task_new", Status: domain.TaskStatusFailed, FlowType: "planned_dev", Title: "Fix tests", Updated: newer}},
+	}, nil)
+	c.On("GetTasks", ctx, "ws_1", []domain.TaskStatus(nil)).Return([]client.Task{}, nil)
+
+	var out bytes.Buffer
+	require.NoError(t, listTasks(ctx, c, &out, "ws_1", []domain.TaskStatus{domain.TaskStatusFailed}))
+	expected := "" +
+		"ID        STATUS  FLOW         UPDATED              TASK\n" +
+		"task_new  failed  planned_dev  2025-01-01 11:00:00  Fix tests\n" +
+		"task_old  failed  basic_dev    2025-01-01 10:00:00  first line\n"
+	assert.Equal(t, expected, out.String())
+
+	out.Reset()
+	require.NoError(t, listTasks(ctx, c, &out, "ws_1", nil))
+	assert.Equal(t, "No tasks found\n", out.String())
+}
+
+func TestShowTask(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 1, 1, 10, 0, 0, 0, time.Local)
+	c := &mockClient{}
+	c.On("GetTask", "ws_1", "task_1").Return(client.Task{
+		Task:  domain.Task{Id: "task_1", Status: domain.TaskStatusInProgress, FlowType: "basic_dev", Description: "Fix the bug\nin the parser", Created: created, Updated: created},
+		Flows: []domain.Flow{{Id: "flow_1"}},
+	}, nil)
+	c.On("GetFlow", ctx, "ws_1", "flow_1").Return(client.Flow{
+		Flow:      domain.Flow{Id: "flow_1", Type: "basic_dev", Status: "in_progress"},
+		Worktrees: []domain.Worktree{{Name: "side/fix-the-bug", WorkingDirectory: "/tmp/worktrees/fix-the-bug"}},
+	}, nil)
+	c.On("GetSubflows", ctx, "ws_1", "flow_1").Return([]domain.Subflow{
+		{Id: "sf_1", Name: "coding", Status: domain.SubflowStatusStarted},
+		{Id: "sf_2", Name: "edit_code", Status: domain.SubflowStatusComplete, ParentSubflowId: "sf_1"},
+	}, nil)
+
+	var out bytes.Buffer
+	require.NoError(t, showTask(ctx, c, &out, "ws_1", "task_1"))
+	expected := "" +
+		"ID:         task_1\n" +
+		"Status:     in_progress\n" +
+		"Flow type:  basic_dev\n" +
+		"Created:    2025-01-01 10:00:00\n" +
+		"Updated:    2025-01-01 10:00:00\n" +
+		"\n" +
+		"Description:\n" +
+		"  Fix the bug\n" +
+		"  in the parser\n" +
+		"\n" +
+		"Flow flow_1 (basic_dev): in_progress\n" +
+		"  Worktree: /tmp/worktrees/fix-the-bug (branch side/fix-the-bug)\n" +
+		"  Subflows:\n" +
+		"    coding: started\n" +
+		"      edit_code: complete\n"
+	assert.Equal(t, expected, out.String())
+}
+
+func TestPrintTaskLogs(t *testing.T) {
+	ctx := context.Background()
+	updated := time.Date(2025, 1, 1, 10, 0, 0, 0, time.Local)
+	c := &mockClient{}
+	c.On("GetTask", "ws_1", "task_1").Return(client.Task{Task: domain.Task{Id: "task_1"}, Flows: []domain.Flow{{Id: "flow_1"}}}, nil)
+	c.On("GetFlowActions", ctx, "ws_1", "flow_1").Return([]domain.FlowAction{
+		{Id: "fa_1", ActionType: "run_tests", ActionStatus: domain.ActionStatusComplete, SubflowName: "coding", Updated: updated},
+		{Id: "fa_2", ActionType: "user_request.approve", ActionStatus: domain.ActionStatusPending, IsHumanAction: true, Updated: updated},
+	}, nil)
+
+	var out bytes.Buffer
+	require.NoError(t, printTaskLogs(ctx, c, &out, "ws_1", "task_1"))
+	expected := "" +
+		"10:00:00  complete   run_tests [coding]\n" +
+		"10:00:00  pending    user_request.approve (awaiting human input)\n"
+	assert.Equal(t, expected, out.String())
+}
+
+func TestFollowTaskLogs(t *testing.T) {
+	ctx := context.Background()
+	updated := time.Date(2025, 1, 1, 10, 0, 0, 0, time.Local)
+	oldPollInterval := TaskPollInterval
+	TaskPollInterval = 50 * time.Millisecond
+	defer func() { TaskPollInterval = oldPollInterval }()
+
+	flowActionCh := make(chan domain.FlowAction, 3)
+	flowActionCh <- domain.FlowAction{Id: "fa_1", ActionType: "run_tests", ActionStatus: domain.ActionStatusStarted, Updated: updated}
+	flowActionCh <- domain.FlowAction{Id: "fa_1", ActionType: "run_tests", ActionStatus: domain.ActionStatusStarted, Updated: updated}
+	flowEventCh := make(chan domain.FlowEvent, 1)
+	flowEventCh <- domain.ProgressTextEvent{EventType: domain.ProgressTextEventType, ParentId: "fa_1", Text: "Running tests..."}
+
+	inProgress := client.Task{Task: domain.Task{Id: "task_1", Status: domain.TaskStatusInProgress}, Flows: []domain.Flow{{Id: "flow_1"}}}
+	complete := client.Task{Task: domain.Task{Id: "task_1", Status: domain.TaskStatusComplete}, Flows: []domain.Flow{{Id: "flow_1"}}}
+
+	c := &mockClient{}
+	c.On("GetTask", "ws_1", "task_1").Return(inProgress, nil).Once()
+	c.On("GetTask", "ws_1", "task_1").Return(complete, nil)
+	c.On("StreamFlowActionChanges", mock.Anything, "ws_1", "flow_1").Return((<-chan domain.FlowAction)(flowActionCh), (<-chan error)(make(chan error)))
+	c.On("StreamFlowEvents", mock.Anything, "ws_1", "flow_1", mock.Anything).Return((<-chan domain.FlowEvent)(flowEventCh), (<-chan error)(make(chan error)))
+
+	var out bytes.Buffer
+	require.NoError(t, followTaskLogs(ctx, c, &out, "ws_1", "task_1"))
+	assert.Contains(t, out.String(), "10:00:00  started    run_tests\n")
+	assert.Equal(t, 1, bytes.Count(out.Bytes(), []byte("run_tests")), "unchanged flow actions should not be printed again")
+	assert.Contains(t, out.String(), "Running tests...\n")
+	assert.Contains(t, out.String(), "Task completed\n")
+}
+
+func TestPauseTask(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		task        client.Task
+		expectPause bool
+		expectedOut string
+		expectedErr string
+	}{
+		{
+			name:        "pauses in-progress flow",
+			task:        client.Task{Task: domain.Task{Status: domain.TaskStatusInProgress}, Flows: []domain.Flow{{Id: "flow_1", Status: "in_progress"}}},
+			expectPause: true,
+			expectedOut: "Task task_1 paused.",
+		},
+		{
+			name:        "already paused",
+			task:        client.Task{Task: domain.Task{Status: domain.TaskStatusBlocked}, Flows: []domain.Flow{{Id: "flow_1", Status: domain.FlowStatusPaused}}},
+			expectedOut: "Task task_1 is already paused\n",
+		},
+		{
+			name:        "finished task",
+			task:        client.Task{Task: domain.Task{Status: domain.TaskStatusComplete}, Flows: []domain.Flow{{Id: "flow_1", Status: "completed"}}},
+			expectedErr: "task task_1 is already complete",
+		},
+		{
+			name:        "not started",
+			task:        client.Task{Task: domain.Task{Status: domain.TaskStatusToDo}},
+			expectedErr: "task task_1 has no in-progress flow to pause",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &mockClient{}
+			c.On("GetTask", "ws_1", "task_1").Return(tt.task, nil)
+			if tt.expectPause {
+				c.On("PauseFlow", ctx, "ws_1", "flow_1").Return(nil)
+			}
+
+			var out bytes.Buffer
+			err := pauseTask(ctx, c, &out, "ws_1", "task_1")
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+				assert.Contains(t, out.String(), tt.expectedOut)
+			}
+			c.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRetryTask(t *testing.T) {
+	ctx := context.Background()
+	flowOptions := map[string]interface{}{"determineRequirements": false}
+
+	t.Run("recreates failed task", func(t *testing.T) {
+		c := &mockClient{}
+		c.On("GetTask", "ws_1", "task_1").Return(client.Task{Task: domain.Task{
+			Id: "task_1", Status: domain.TaskStatusFailed, Description: "Fix the bug", FlowType: "planned_dev", FlowOptions: flowOptions,
+		}}, nil)
+		c.On("CreateTask", "ws_1", &client.CreateTaskRequest{Description: "Fix the bug", FlowType: "planned_dev", FlowOptions: flowOptions}).
+			Return(client.Task{Task: domain.Task{Id: "task_2"}}, nil)
+
+		task, err := retryTask(ctx, c, "ws_1", "task_1")
+		require.NoError(t, err)
+		assert.Equal(t, "task_2", task.Id)
+	})
+
+	t.Run("rejects unfinished task", func(t *testing.T) {
+		c := &mockClient{}
+		c.On("GetTask", "ws_1", "task_1").Return(client.Task{Task: domain.Task{Id: "task_1", Status: domain.TaskStatusInProgress}}, nil)
+
+		_, err := retryTask(ctx, c, "ws_1", "task_1")
+		assert.True(t, errors.Is(err, errTaskNotRetryable))
+		c.AssertNotCalled(t, "CreateTask", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sidekick/client"
+	"sidekick/dev"
+	"sidekick/domain"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// completeFlowActionFunc submits a user's response to a pending human flow
+// action, i.e. a request for user input
+type completeFlowActionFunc func(flowAction domain.FlowAction, response client.UserResponse) error
+
+type userRequestInputMode int
+
+const (
+	// choosing between the options given for the request, eg approve/reject
+	userRequestChoosing userRequestInputMode = iota
+	// typing a free-form response or rejection reason
+	userRequestTyping
+	// editing the target branch of a merge approval
+	userRequestEditingBranch
+	// waiting for the response to be submitted
+	userRequestSubmitting
+)
+
+const maxDiffHeight = 20
+
+var (
+	userRequestTitleStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	userRequestHelpStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	userRequestErrorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	diffAddedStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	diffRemovedStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	diffHunkStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+)
+
+// labels for the approve/reject tags flows may set, matching the web UI
+var userRequestTagLabels = map[string]string{
+	"approve_plan": "Approve",
+	"reject_plan":  "Revise",
+	"done":         "Done",
+	"try_again":    "Try Again",
+}
+
+// userRequestModel renders a pending request for user input and collects the
+// response inline, based on the request's kind
+type userRequestModel struct {
+	flowAction   domain.FlowAction
+	kind         dev.RequestKind
+	choices      []string
+	cursor       int
+	mode         userRequestInputMode
+	previousMode userRequestInputMode
+	rejecting    bool
+	targetBranch string
+	input        textarea.Model
+	branchInput  textinput.Model
+	diff         viewport.Model
+	hasDiff      bool
+	err          error
+	complete     completeFlowActionFunc
+}
+
+// isUserRequest returns whether the flow action is a request for user input
+// that can be answered in the terminal
+func isUserRequest(flowAction domain.FlowAction) bool {
+	if !flowAction.IsHumanAction {
+		return false
+	}
+	switch requestKind(flowAction) {
+	case dev.RequestKindFreeForm, dev.RequestKindMultipleChoice, dev.RequestKindApproval, dev.RequestKindMergeApproval, dev.RequestKindContinue:
+		return true
+	}
+	return false
+}
+
+func requestKind(flowAction domain.FlowAction) dev.RequestKind {
+	kind, _ := flowAction.ActionParams["requestKind"].(string)
+	return dev.RequestKind(kind)
+}
+
+func newUserRequestModel(flowAction domain.FlowAction, width int, complete completeFlowActionFunc) userRequestModel {
+	input := textarea.New()
+	input.ShowLineNumbers = false
+	input.SetWidth(width)
+	input.SetHeight(3)
+	// enter submits, so newlines are entered with alt+enter instead
+	input.KeyMap.InsertNewline = key.NewBinding(key.WithKeys("alt+enter"))
+
+	branchInput := textinput.New()
+	branchInput.Prompt = "Merge into: "
+
+	m := userRequestModel{
+		kind:        requestKind(flowAction),
+		input:       input,
+		branchInput: branchInput,
+		diff:        viewport.New(width, maxDiffHeight),
+		complete:    complete,
+	}
+	m.setFlowAction(flowAction)
+
+	switch m.kind {
+	case dev.RequestKindFreeForm:
+		m.mode = userRequestTyping
+	case dev.RequestKindMultipleChoice:
+		if len(m.choices) == 0 {
+			m.mode = userRequestTyping
+		}
+	}
+	if m.mode == userRequestTyping {
+		m.input.Focus()
+	}
+	return m
+}
+
+// setFlowAction updates the request's params, which can change while pending,
+// eg when a merge approval's diff is regenerated for a new target branch
+func (m *userRequestModel) setFlowAction(flowAction domain.FlowAction) {
+	m.flowAction = flowAction
+
+	m.choices = nil
+	if choices, ok := flowAction.ActionParams["choices"].([]interface{}); ok {
+		for _, choice := range choices {
+			m.choices = append(m.choices, fmt.Sprint(choice))
+		}
+	}
+
+	if info, ok := flowAction.ActionParams["mergeApprovalInfo"].(map[string]interface{}); ok {
+		diff, _ := info["diff"].(string)
+		m.hasDiff = diff != ""
+		m.diff.SetContent(colorizeDiff(diff))
+		m.diff.Height = min(maxDiffHeight, strings.Count(diff, "\n")+1)
+		if m.targetBranch == "" {
+			m.targetBranch, _ = info["defaultTargetBranch"].(string)
+		}
+	}
+}
+
+func colorizeDiff(diff string) string {
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			lines[i] = lipgloss.NewStyle().Bold(true).Render(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = diffAddedStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = diffRemovedStyle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = diffHunkStyle.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m userRequestModel) Init() tea.Cmd {
+	if m.mode == userRequestTyping {
+		return textarea.Blink
+	}
+	return nil
+}
+
+func (m userRequestModel) Update(msg tea.Msg) (userRequestModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.input.SetWidth(msg.Width)
+		m.diff.Width = msg.Width
+		return m, nil
+
+	case userResponseSubmittedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.mode = m.previousMode
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		m.err = nil
+		switch m.mode {
+		case userRequestChoosing:
+			return m.updateChoosing(msg)
+		case userRequestTyping:
+			return m.updateTyping(msg)
+		case userRequestEditingBranch:
+			return m.updateEditingBranch(msg)
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	switch m.mode {
+	case userRequestTyping:
+		m.input, cmd = m.input.Update(msg)
+	case userRequestEditingBranch:
+		m.branchInput, cmd = m.branchInput.Update(msg)
+	}
+	return m, cmd
+}
+
+func (m userRequestModel) updateChoosing(msg tea.KeyMsg) (userRequestModel, tea.Cmd) {
+	switch m.kind {
+	case dev.RequestKindContinue:
+		if msg.Type == tea.KeyEnter {
+			return m.submit(client.UserResponse{})
+		}
+
+	case dev.RequestKindMultipleChoice:
+		switch msg.String() {
+		case "up", "k":
+			m.cursor = max(0, m.cursor-1)
+		case "down", "j":
+			m.cursor = min(len(m.choices)-1, m.cursor+1)
+		case "enter":
+			return m.submit(client.UserResponse{Choice: m.choices[m.cursor]})
+		default:
+			if n, err := strconv.Atoi(msg.String()); err == nil && n >= 1 && n <= len(m.choices) {
+				m.cursor = n - 1
+			}
+		}
+
+	case dev.RequestKindApproval, dev.RequestKindMergeApproval:
+		switch msg.String() {
+		case "y", "a":
+			return m.submitApproval(true, "")
+		case "n", "r":
+			m.rejecting = true
+			m.mode = userRequestTyping
+			m.input.Placeholder = "Rejection reason"
+			return m, m.input.Focus()
+		case "b":
+			if m.kind == dev.RequestKindMergeApproval {
+				m.mode = userRequestEditingBranch
+				m.branchInput.SetValue(m.targetBranch)
+				m.branchInput.CursorEnd()
+				return m, m.branchInput.Focus()
+			}
+		default:
+			if m.hasDiff {
+				var cmd tea.Cmd
+				m.diff, cmd = m.diff.Update(msg)
+				return m, cmd
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m userRequestModel) updateTyping(msg tea.KeyMsg) (userRequestModel, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		if m.rejecting {
+			m.rejecting = false
+			m.mode = userRequestChoosing
+			m.input.Blur()
+		}
+		return m, nil
+	case tea.KeyEnter:
+		content := strings.TrimSpace(m.input.Value())
+		if content == "" {
+			return m, nil
+		}
+		switch {
+		case m.rejecting:
+			return m.submitApproval(false, content)
+		case m.kind == dev.RequestKindMultipleChoice:
+			return m.submit(client.UserResponse{Choice: content})
+		default:
+			return m.submit(client.UserResponse{Content: content})
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m userRequestModel) updateEditingBranch(msg tea.KeyMsg) (userRequestModel, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = userRequestChoosing
+		m.branchInput.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		if branch := strings.TrimSpace(m.branchInput.Value()); branch != "" {
+			m.targetBranch = branch
+		}
+		m.mode = userRequestChoosing
+		m.branchInput.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.branchInput, cmd = m.branchInput.Update(msg)
+	return m, cmd
+}
+
+func (m userRequestModel) submitApproval(approved bool, content string) (userRequestModel, tea.Cmd) {
+	response := client.UserResponse{Approved: &approved, Content: content}
+	if m.kind == dev.RequestKindMergeApproval {
+		response.Params = map[string]interface{}{"targetBranch": m.targetBranch}
+	}
+	return m.submit(response)
+}
+
+func (m userRequestModel) submit(response client.UserResponse) (userRequestModel, tea.Cmd) {
+	m.previousMode = m.mode
+	m.mode = userRequestSubmitting
+	m.input.Blur()
+
+	flowAction := m.flowAction
+	complete := m.complete
+	return m, func() tea.Msg {
+		if complete == nil {
+			return userResponseSubmittedMsg{flowActionId: flowAction.Id, err: fmt.Errorf("responding from the terminal is not supported here")}
+		}
+		return userResponseSubmittedMsg{flowActionId: flowAction.Id, err: complete(flowAction, response)}
+	}
+}
+
+func (m userRequestModel) label(tagParam, fallback string) string {
+	tag, _ := m.flowAction.ActionParams[tagParam].(string)
+	if label, ok := userRequestTagLabels[tag]; ok {
+		return label
+	}
+	return fallback
+}
+
+func (m userRequestModel) View() string {
+	var b strings.Builder
+
+	title := "Sidekick needs your input"
+	if m.flowAction.SubflowName != "" {
+		title += fmt.Sprintf(" (%s)", m.flowAction.SubflowName)
+	}
+	b.WriteString(userRequestTitleStyle.Render(title))
+	b.WriteString("\n\n")
+
+	if content, _ := m.flowAction.ActionParams["requestContent"].(string); content != "" {
+		b.WriteString(strings.TrimSpace(content))
+		b.WriteString("\n\n")
+	}
+	if command, _ := m.flowAction.ActionParams["command"].(string); command != "" {
+		b.WriteString(fmt.Sprintf("  %s\n\n", command))
+	}
+	if m.hasDiff {
+		b.WriteString(m.diff.View())
+		b.WriteString("\n")
+		if !m.diff.AtTop() || !m.diff.AtBottom() {
+			b.WriteString(userRequestHelpStyle.Render(fmt.Sprintf("(%3.f%% of diff shown, scroll with ↑/↓)", m.diff.ScrollPercent()*100)))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	if m.kind == dev.RequestKindMergeApproval {
+		if m.mode == userRequestEditingBranch {
+			b.WriteString(m.branchInput.View())
+		} else {
+			b.WriteString(fmt.Sprintf("Merge into: %s", m.targetBranch))
+		}
+		b.WriteString("\n\n")
+	}
+
+	if m.kind == dev.RequestKindMultipleChoice && len(m.choices) > 0 {
+		for i, choice := range m.choices {
+			cursor := " "
+			if i == m.cursor {
+				cursor = ">"
+			}
+			b.WriteString(fmt.Sprintf("%s %d. %s\n", cursor, i+1, choice))
+		}
+		b.WriteString("\n")
+	}
+
+	if m.mode == userRequestTyping {
+		b.WriteString(m.input.View())
+		b.WriteString("\n")
+	}
+
+	if m.err != nil {
+		b.WriteString(userRequestErrorStyle.Render(fmt.Sprintf("Failed to submit response: %v", m.err)))
+		b.WriteString("\n")
+	}
+
+	b.WriteString(userRequestHelpStyle.Render(m.help()))
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (m userRequestModel) help() string {
+	switch m.mode {
+	case userRequestSubmitting:
+		return "Submitting..."
+	case userRequestEditingBranch:
+		return "enter: confirm branch • esc: cancel"
+	case userRequestTyping:
+		if m.rejecting {
+			return "enter: submit • alt+enter: new line • esc: back"
+		}
+		return "enter: submit • alt+enter: new line"
+	}
+
+	switch m.kind {
+	case dev.RequestKindContinue:
+		return fmt.Sprintf("enter: %s", m.label("continueTag", "Continue"))
+	case dev.RequestKindMultipleChoice:
+		return "↑/↓ or 1-9: select • enter: submit"
+	case dev.RequestKindMergeApproval:
+		return fmt.Sprintf("y: %s • n: %s • b: change target branch", m.label("approveTag", "Approve"), m.label("rejectTag", "Reject"))
+	default:
+		return fmt.Sprintf("y: %s • n: %s", m.label("approveTag", "Approve"), m.label("rejectTag", "Reject"))
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"sidekick/client"
+	"sidekick/domain"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestUserRequest(id string, status domain.ActionStatus) domain.FlowAction {
+	return domain.FlowAction{
+		Id:            id,
+		WorkspaceId:   "ws_1",
+		ActionType:    "user_request.approve.plan",
+		ActionStatus:  status,
+		IsHumanAction: true,
+		ActionParams: map[string]interface{}{
+			"requestKind":    "approval",
+			"requestContent": "Does this plan look good?",
+		},
+	}
+}
+
+func keys(s string) []tea.Msg {
+	var msgs []tea.Msg
+	for _, r := range s {
+		msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return msgs
+}
+
+func TestUserRequestModel(t *testing.T) {
+	t.Parallel()
+	approved := true
+	rejected := false
+
+	mergeApproval := func() domain.FlowAction {
+		flowAction := newTestUserRequest("fa_1", domain.ActionStatusPending)
+		flowAction.ActionParams = map[string]interface{}{
+			"requestKind":    "merge_approval",
+			"requestContent": "Merge these changes?",
+			"mergeApprovalInfo": map[string]interface{}{
+				"defaultTargetBranch": "main",
+				"sourceBranch":        "side/feature",
+				"diff":                "--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-old line\n+new line\n",
+			},
+		}
+		return flowAction
+	}
+
+	tests := []struct {
+		name             string
+		flowAction       func() domain.FlowAction
+		messages         []tea.Msg
+		expectedResponse *client.UserResponse
+		wantContains     []string
+	}{
+		{
+			name:             "approve",
+			flowAction:       func() domain.FlowAction { return newTestUserRequest("fa_1", domain.ActionStatusPending) },
+			messages:         keys("y"),
+			expectedResponse: &client.UserResponse{Approved: &approved},
+		},
+		{
+			name:       "reject with reason",
+			flowAction: func() domain.FlowAction { return newTestUserRequest("fa_1", domain.ActionStatusPending) },
+			messages: append(append(keys("n"), keys("too vague")...),
+				tea.KeyMsg{Type: tea.KeyEnter}),
+			expectedResponse: &client.UserResponse{Approved: &rejected, Content: "too vague"},
+		},
+		{
+			name:       "reject requires a reason",
+			flowAction: func() domain.FlowAction { return newTestUserRequest("fa_1", domain.ActionStatusPending) },
+			messages:   append(keys("n"), tea.KeyMsg{Type: tea.KeyEnter}),
+			wantContains: []string{
+				"Rejection reason",
+			},
+		},
+		{
+			name: "approve tags",
+			flowAction: func() domain.FlowAction {
+				flowAction := newTestUserRequest("fa_1", domain.ActionStatusPending)
+				flowAction.ActionParams["approveTag"] = "done"
+				flowAction.ActionParams["rejectTag"] = "try_again"
+				return flowAction
+			},
+			wantContains: []string{"y: Done • n: Try Again"},
+		},
+		{
+			name:       "merge approval shows diff and target branch",
+			flowAction: mergeApproval,
+			wantContains: []string{
+				"Merge these changes?",
+				"-old line",
+				"+new line",
+				"Merge into: main",
+				"b: change target branch",
+			},
+		},
+		{
+			name:       "merge approval with changed target branch",
+			flowAction: mergeApproval,
+			messages: append(append(append(keys("b"),
+				tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyBackspace}),
+				keys("develop")...),
+				tea.KeyMsg{Type: tea.KeyEnter}, keys("y")[0]),
+			expectedResponse: &client.UserResponse{Approved: &approved, Params: map[string]interface{}{"targetBranch": "develop"}},
+		},
+		{
+			name: "free form",
+			flowAction: func() domain.FlowAction {
+				flowAction := newTestUserRequest("fa_1", domain.ActionStatusPending)
+				flowAction.ActionParams["requestKind"] = "free_form"
+				return flowAction
+			},
+			messages:         append(keys("use the v2 api"), tea.KeyMsg{Type: tea.KeyEnter}),
+			expectedResponse: &client.UserResponse{Content: "use the v2 api"},
+		},
+		{
+			name: "multiple choice",
+			flowAction: func() domain.FlowAction {
+				flowAction := newTestUserRequest("fa_1", domain.ActionStatusPending)
+				flowAction.ActionParams["requestKind"] = "multiple_choice"
+				flowAction.ActionParams["choices"] = []interface{}{"postgres", "sqlite", "redis"}
+				return flowAction
+			},
+			messages:         []tea.Msg{tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyUp}, tea.KeyMsg{Type: tea.KeyEnter}},
+			expectedResponse: &client.UserResponse{Choice: "sqlite"},
+		},
+		{
+			name: "continue",
+			flowAction: func() domain.FlowAction {
+				flowAction := newTestUserRequest("fa_1", domain.ActionStatusPending)
+				flowAction.ActionParams["requestKind"] = "continue"
+				return flowAction
+			},
+			messages:         []tea.Msg{tea.KeyMsg{Type: tea.KeyEnter}},
+			expectedResponse: &client.UserResponse{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var submitted *client.UserResponse
+			complete := func(flowAction domain.FlowAction, response client.UserResponse) error {
+				assert.Equal(t, "fa_1", flowAction.Id)
+				submitted = &response
+				return nil
+			}
+
+			model := newUserRequestModel(tt.flowAction(), 80, complete)
+			for _, msg := range tt.messages {
+				var cmd tea.Cmd
+				model, cmd = model.Update(msg)
+				if model.mode == userRequestSubmitting {
+					require.NotNil(t, cmd)
+					model, _ = model.Update(cmd())
+				}
+			}
+
+			assert.Equal(t, tt.expectedResponse, submitted)
+			view := model.View()
+			for _, want := range tt.wantContains {
+				assert.Contains(t, view, want)
+			}
+		})
+	}
+}
+
+func TestUserRequestModel_SubmitError(t *testing.T) {
+	t.Parallel()
+	complete := func(flowAction domain.FlowAction, response client.UserResponse) error {
+		return errors.New("connection refused")
+	}
+
+	model := newUserRequestModel(newTestUserRequest("fa_1", domain.ActionStatusPending), 80, complete)
+	model, cmd := model.Update(keys("y")[0])
+	require.NotNil(t, cmd)
+	model, _ = model.Update(cmd())
+
+	assert.Equal(t, userRequestChoosing, model.mode, "should be able to retry after a failed submission")
+	assert.Contains(t, model.View(), "Failed to submit response: connection refused")
+}
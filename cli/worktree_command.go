@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"sidekick/client"
+	"sidekick/domain"
+
+	"github.com/urfave/cli/v3"
+)
+
+func NewWorktreeCommand() *cli.Command {
+	workspaceFlag := &cli.StringFlag{Name: "workspace-id", Aliases: []string{"W"}, Usage: "Workspace id, defaults to the workspace for the current directory"}
+
+	return &cli.Command{
+		Name:  "worktree",
+		Usage: "Manage the git worktrees sidekick creates for tasks",
+		Commands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List worktrees, flagging orphaned ones",
+				Flags: []cli.Flag{workspaceFlag},
+				Action: taskAction(func(ctx context.Context, c client.Client, workspaceId string, cmd *cli.Command) error {
+					return listWorktrees(ctx, c, os.Stdout, workspaceId)
+				}),
+			},
+			{
+				Name:  "prune",
+				Usage: "Delete orphaned worktrees past the retention period, archiving unmerged branches as archive/<branch> tags",
+				Flags: []cli.Flag{
+					workspaceFlag,
+					&cli.BoolFlag{Name: "dry-run", Aliases: []string{"n"}, Usage: "Only list the worktrees that would be pruned"},
+					&cli.BoolFlag{Name: "all", Usage: "Prune all orphaned worktrees, ignoring the retention period"},
+				},
+				Action: taskAction(func(ctx context.Context, c client.Client, workspaceId string, cmd *cli.Command) error {
+					return pruneWorktrees(ctx, c, os.Stdout, workspaceId, client.PruneWorktreesRequest{
+						DryRun:          cmd.Bool("dry-run"),
+						IgnoreRetention: cmd.Bool("all"),
+					})
+				}),
+			},
+			{
+				Name:      "open",
+				Usage:     "Open a worktree in $VISUAL, $EDITOR or VS Code",
+				ArgsUsage: "<worktree id|branch|flow id>",
+				Flags: []cli.Flag{
+					workspaceFlag,
+					&cli.BoolFlag{Name: "print", Aliases: []string{"p"}, Usage: "Print the worktree's path instead of opening it, eg for `cd $(side worktree open -p ...)`"},
+				},
+				Action: taskAction(func(ctx context.Context, c client.Client, workspaceId string, cmd *cli.Command) error {
+					ref := cmd.Args().First()
+					if ref == "" {
+						return fmt.Errorf("a worktree id, branch or flow id is required. Run `side worktree list` to find one")
+					}
+					path, err := findWorktreePath(ctx, c, workspaceId, ref)
+					if err != nil {
+						return err
+					}
+					if cmd.Bool("print") {
+						fmt.Println(path)
+						return nil
+					}
+					return openInEditor(path)
+				}),
+			},
+		},
+	}
+}
+
+func formatOrphanReasons(reasons []domain.WorktreeOrphanReason) string {
+	if len(reasons) == 0 {
+		return "-"
+	}
+	strs := make([]string, len(reasons))
+	for i, reason := range reasons {
+		strs[i] = string(reason)
+	}
+	return strings.Join(strs, ",")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func writeWorktreeTable(w io.Writer, worktrees []domain.WorktreeStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tBRANCH\tFLOW STATUS\tCREATED\tORPHANED\tPRUNABLE\tPATH")
+	for _, status := range worktrees {
+		id := "-"
+		if status.Worktree != nil {
+			id = status.Worktree.Id
+		}
+		created := "-"
+		if !status.Created.IsZero() {
+			created = status.Created.Local().Format(time.DateTime)
+		}
+		prunable := "no"
+		if status.Prunable {
+			prunable = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", id, orDash(status.Branch), orDash(status.FlowStatus), created, formatOrphanReasons(status.OrphanReasons), prunable, status.Path)
+	}
+	return tw.Flush()
+}
+
+func listWorktrees(ctx context.Context, c client.Client, w io.Writer, workspaceId string) error {
+	worktrees, err := c.GetWorktrees(ctx, workspaceId)
+	if err != nil {
+		return err
+	}
+	if len(worktrees) == 0 {
+		fmt.Fprintln(w, "No worktrees found")
+		return nil
+	}
+	return writeWorktreeTable(w, worktrees)
+}
+
+func pruneWorktrees(ctx context.Context, c client.Client, w io.Writer, workspaceId string, req client.PruneWorktreesRequest) error {
+	result, err := c.PruneWorktrees(ctx, workspaceId, req)
+	if err != nil {
+		return err
+	}
+	if len(result.Pruned) == 0 && len(result.Failed) == 0 {
+		fmt.Fprintln(w, "No worktrees to prune")
+		return nil
+	}
+
+	if len(result.Pruned) > 0 {
+		if req.DryRun {
+			fmt.Fprintln(w, "Would prune:")
+		} else {
+			fmt.Fprintln(w, "Pruned:")
+		}
+		if err := writeWorktreeTable(w, result.Pruned); err != nil {
+			return err
+		}
+	}
+	for _, failure := range result.Failed {
+		fmt.Fprintf(w, "Failed to prune %s: %s\n", failure.Status.Path, failure.Error)
+	}
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("failed to prune %d worktree(s)", len(result.Failed))
+	}
+	return nil
+}
+
+// findWorktreePath returns the path of the worktree matching the given
+// worktree id, branch name or flow id
+func findWorktreePath(ctx context.Context, c client.Client, workspaceId, ref string) (string, error) {
+	worktrees, err := c.GetWorktrees(ctx, workspaceId)
+	if err != nil {
+		return "", err
+	}
+	for _, status := range worktrees {
+		matches := status.Branch == ref
+		if status.Worktree != nil {
+			matches = matches || status.Worktree.Id == ref || status.Worktree.FlowId == ref || status.Worktree.Name == ref
+		}
+		if !matches {
+			continue
+		}
+		if !status.DirExists {
+			return "", fmt.Errorf("worktree %s no longer exists at %s", ref, status.Path)
+		}
+		return status.Path, nil
+	}
+	return "", fmt.Errorf("no worktree found for %q. Run `side worktree list` to find one", ref)
+}
+
+func openInEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "code"
+	}
+
+	// editors are often configured with arguments, eg "code --wait"
+	fields := strings.Fields(editor)
+	editorCmd := exec.Command(fields[0], append(fields[1:], path)...)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return fmt.Errorf("failed to open %s with %s: %w", path, editor, err)
+	}
+	return nil
+}
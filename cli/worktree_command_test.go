@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"sidekick/client"
+	"sidekick/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListWorktrees(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2025, 1, 1, 10, 0, 0, 0, time.Local)
+
+	c := &mockClient{}
+	c.On("GetWorktrees", ctx, "ws_1").Return([]domain.WorktreeStatus{
+		{
+			Worktree:   &domain.Worktree{Id: "wt_1", FlowId: "flow_1", Name: "side/fix"},
+			Path:       "/wt/fix",
+			Branch:     "side/fix",
+			FlowStatus: "completed",
+			DirExists:  true,
+			Created:    created,
+			OrphanReasons: []domain.WorktreeOrphanReason{
+				domain.WorktreeOrphanReasonFlowFinished,
+				domain.WorktreeOrphanReasonBranchMerged,
+			},
+			Prunable: true,
+		},
+		{Path: "/wt/stray", DirExists: true, OrphanReasons: []domain.WorktreeOrphanReason{domain.WorktreeOrphanReasonUntracked}},
+	}, nil).Once()
+	c.On("GetWorktrees", ctx, "ws_1").Return([]domain.WorktreeStatus{}, nil).Once()
+
+	var out bytes.Buffer
+	require.NoError(t, listWorktrees(ctx, c, &out, "ws_1"))
+	expected := "" +
+		"ID    BRANCH    FLOW STATUS  CREATED              ORPHANED                     PRUNABLE  PATH\n" +
+		"wt_1  side/fix  completed    2025-01-01 10:00:00  flow_finished,branch_merged  yes       /wt/fix\n" +
+		"-     -         -            -                    untracked                    no        /wt/stray\n"
+	assert.Equal(t, expected, out.String())
+
+	out.Reset()
+	require.NoError(t, listWorktrees(ctx, c, &out, "ws_1"))
+	assert.Equal(t, "No worktrees found\n", out.String())
+}
+
+func TestPruneWorktrees(t *testing.T) {
+	ctx := context.Background()
+	pruned := domain.WorktreeStatus{Path: "/wt/old", Branch: "side/old", Prunable: true}
+
+	t.Run("dry run", func(t *testing.T) {
+		req := client.PruneWorktreesRequest{DryRun: true}
+		c := &mockClient{}
+		c.On("PruneWorktrees", ctx, "ws_1", req).Return(client.PruneWorktreesResult{Pruned: []domain.WorktreeStatus{pruned}}, nil)
+
+		var out bytes.Buffer
+		require.NoError(t, pruneWorktrees(ctx, c, &out, "ws_1", req))
+		assert.Contains(t, out.String(), "Would prune:")
+		assert.Contains(t, out.String(), "/wt/old")
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		req := client.PruneWorktreesRequest{}
+		c := &mockClient{}
+		c.On("PruneWorktrees", ctx, "ws_1", req).Return(client.PruneWorktreesResult{
+			Pruned: []domain.WorktreeStatus{pruned},
+			Failed: []client.PruneWorktreeFailure{{Status: domain.WorktreeStatus{Path: "/wt/locked"}, Error: "permission denied"}},
+		}, nil)
+
+		var out bytes.Buffer
+		err := pruneWorktrees(ctx, c, &out, "ws_1", req)
+		require.Error(t, err)
+		assert.Contains(t, out.String(), "Pruned:")
+		assert.Contains(t, out.String(), "Failed to prune /wt/locked: permission denied")
+	})
+
+	t.Run("nothing to prune", func(t *testing.T) {
+		req := client.PruneWorktreesRequest{IgnoreRetention: true}
+		c := &mockClient{}
+		c.On("PruneWorktrees", ctx, "ws_1", req).Return(client.PruneWorktreesResult{}, nil)
+
+		var out bytes.Buffer
+		require.NoError(t, pruneWorktrees(ctx, c, &out, "ws_1", req))
+		assert.Equal(t, "No worktrees to prune\n", out.String())
+	})
+}
+
+func TestFindWorktreePath(t *testing.T) {
+	ctx := context.Background()
+	c := &mockClient{}
+	c.On("GetWorktrees", ctx, "ws_1").Return([]domain.WorktreeStatus{
+		{Worktree: &domain.Worktree{Id: "wt_1", FlowId: "flow_1", Name: "side/fix"}, Path: "/wt/fix", Branch: "side/fix", DirExists: true},
+		{Worktree: &domain.Worktree{Id: "wt_2", FlowId: "flow_2", Name: "side/gone"}, Path: "/wt/gone"},
+		{Path: "/wt/untracked", Branch: "side/untracked", DirExists: true},
+	}, nil)
+
+	for _, ref := range []string{"wt_1", "flow_1", "side/fix"} {
+		path, err := findWorktreePath(ctx, c, "ws_1", ref)
+		require.NoError(t, err)
+		assert.Equal(t, "/wt/fix", path)
+	}
+
+	path, err := findWorktreePath(ctx, c, "ws_1", "side/untracked")
+	require.NoError(t, err)
+	assert.Equal(t, "/wt/untracked", path)
+
+	_, err = findWorktreePath(ctx, c, "ws_1", "wt_2")
+	assert.ErrorContains(t, err, "no longer exists")
+
+	_, err = findWorktreePath(ctx, c, "ws_1", "nope")
+	assert.ErrorContains(t, err, "no worktree found")
+}
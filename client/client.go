@@ -1,9 +1,11 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"sidekick/domain"
 	"time"
@@ -13,7 +15,27 @@ import (
 type Client interface {
 	CreateTask(workspaceID string, req *CreateTaskRequest) (Task, error)
 	GetTask(workspaceID string, taskID string) (Task, error)
+	GetTasks(ctx context.Context, workspaceID string, statuses []domain.TaskStatus) ([]Task, error)
 	CancelTask(workspaceID string, taskID string) error
+	ArchiveTask(ctx context.Context, workspaceID string, taskID string) error
+
+	GetFlow(ctx context.Context, workspaceID string, flowID string) (Flow, error)
+	PauseFlow(ctx context.Context, workspaceID string, flowID string) error
+	CancelFlow(ctx context.Context, workspaceID string, flowID string) error
+	SendUserAction(ctx context.Context, workspaceID string, flowID string, actionType string) error
+	RollbackToCheckpoint(ctx context.Context, workspaceID string, flowID string, stepNumber string) error
+	StreamFlowActionChanges(ctx context.Context, workspaceID string, flowID string) (<-chan domain.FlowAction, <-chan error)
+	StreamFlowEvents(ctx context.Context, workspaceID string, flowID string, subscriptions <-chan domain.FlowEventSubscription) (<-chan domain.FlowEvent, <-chan error)
+
+	GetFlowActions(ctx context.Context, workspaceID string, flowID string) ([]domain.FlowAction, error)
+	CompleteFlowAction(ctx context.Context, workspaceID string, flowActionID string, response UserResponse) (domain.FlowAction, error)
+
+	GetSubflows(ctx context.Context, workspaceID string, flowID string) ([]domain.Subflow, error)
+	GetSubflow(ctx context.Context, workspaceID string, subflowID string) (domain.Subflow, error)
+
+	GetWorktrees(ctx context.Context, workspaceID string) ([]domain.WorktreeStatus, error)
+	PruneWorktrees(ctx context.Context, workspaceID string, req PruneWorktreesRequest) (PruneWorktreesResult, error)
+
 	CreateWorkspace(req *CreateWorkspaceRequest) (*domain.Workspace, error)
 	GetAllWorkspaces(ctx context.Context) ([]domain.Workspace, error)
 	GetBaseURL() string
@@ -53,6 +75,62 @@ func (c *clientImpl) get(ctx context.Context, path string, v interface{}) error
 	return nil
 }
 
+// do performs a request with an optional JSON body and unmarshals any JSON
+// response into v, if non-nil. Error responses are returned as errors
+// including the API's error message when there is one.
+func (c *clientImpl) do(ctx context.Context, method, path string, body interface{}, v interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewBuffer(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body (status %s): %w", resp.Status, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var errorResponse struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(bodyBytes, &errorResponse) == nil {
+			if errorResponse.Error != "" {
+				return fmt.Errorf("API request failed with status %s: %s", resp.Status, errorResponse.Error)
+			} else if errorResponse.Message != "" {
+				return fmt.Errorf("API request failed with status %s: %s", resp.Status, errorResponse.Message)
+			}
+		}
+		return fmt.Errorf("API request failed with status %s: %s", resp.Status, string(bodyBytes))
+	}
+
+	if v == nil || len(bodyBytes) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(bodyBytes, v); err != nil {
+		return fmt.Errorf("failed to decode response (status %s): %w", resp.Status, err)
+	}
+	return nil
+}
+
 // NewClient creates a new Sidekick API client.
 func NewClient(baseURL string) Client {
 	return &clientImpl{
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sidekick/domain"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientRequests(t *testing.T) {
+	approved := true
+	tests := []struct {
+		name         string
+		call         func(c Client) (interface{}, error)
+		expectedPath string
+		status       int
+		response     string
+		expected     interface{}
+		expectedBody string
+		expectedErr  string
+	}{
+		{
+			name: "get tasks with statuses",
+			call: func(c Client) (interface{}, error) {
+				return c.GetTasks(context.Background(), "ws_1", []domain.TaskStatus{"failed", "canceled"})
+			},
+			expectedPath: "GET /api/v1/workspaces/ws_1/tasks/?statuses=failed%2Ccanceled",
+			status:       http.StatusOK,
+			response:     `{"tasks":[{"id":"task_1","status":"failed","flows":[{"id":"flow_1"}]}]}`,
+			expected:     []Task{{Task: domain.Task{Id: "task_1", Status: "failed"}, Flows: []domain.Flow{{Id: "flow_1"}}}},
+		},
+		{
+			name:         "get flow",
+			call:         func(c Client) (interface{}, error) { return c.GetFlow(context.Background(), "ws_1", "flow_1") },
+			expectedPath: "GET /api/v1/workspaces/ws_1/flows/flow_1",
+			status:       http.StatusOK,
+			response:     `{"flow":{"id":"flow_1","status":"in_progress","worktrees":[{"id":"wt_1","name":"side/branch"}]}}`,
+			expected:     Flow{Flow: domain.Flow{Id: "flow_1", Status: "in_progress"}, Worktrees: []domain.Worktree{{Id: "wt_1", Name: "side/branch"}}},
+		},
+		{
+			name:         "get flow actions",
+			call:         func(c Client) (interface{}, error) { return c.GetFlowActions(context.Background(), "ws_1", "flow_1") },
+			expectedPath: "GET /api/v1/workspaces/ws_1/flows/flow_1/actions",
+			status:       http.StatusOK,
+			response:     `{"flowActions":[{"id":"fa_1","actionType":"run_tests"}]}`,
+			expected:     []domain.FlowAction{{Id: "fa_1", ActionType: "run_tests"}},
+		},
+		{
+			name:         "get subflows",
+			call:         func(c Client) (interface{}, error) { return c.GetSubflows(context.Background(), "ws_1", "flow_1") },
+			expectedPath: "GET /api/v1/workspaces/ws_1/flows/flow_1/subflows",
+			status:       http.StatusOK,
+			response:     `{"subflows":[{"id":"sf_1","name":"coding"}]}`,
+			expected:     []domain.Subflow{{Id: "sf_1", Name: "coding"}},
+		},
+		{
+			name:         "get subflow",
+			call:         func(c Client) (interface{}, error) { return c.GetSubflow(context.Background(), "ws_1", "sf_1") },
+			expectedPath: "GET /api/v1/workspaces/ws_1/subflows/sf_1",
+			status:       http.StatusOK,
+			response:     `{"subflow":{"id":"sf_1","name":"coding"}}`,
+			expected:     domain.Subflow{Id: "sf_1", Name: "coding"},
+		},
+		{
+			name: "complete flow action",
+			call: func(c Client) (interface{}, error) {
+				return c.CompleteFlowAction(context.Background(), "ws_1", "fa_1", UserResponse{Approved: &approved})
+			},
+			expectedPath: "POST /api/v1/workspaces/ws_1/flow_actions/fa_1/complete",
+			status:       http.StatusOK,
+			response:     `{"id":"fa_1","actionStatus":"complete"}`,
+			expected:     domain.FlowAction{Id: "fa_1", ActionStatus: "complete"},
+			expectedBody: `{"userResponse":{"approved":true}}`,
+		},
+		{
+			name: "send user action",
+			call: func(c Client) (interface{}, error) {
+				return nil, c.SendUserAction(context.Background(), "ws_1", "flow_1", "go_next_step")
+			},
+			expectedPath: "POST /api/v1/workspaces/ws_1/flows/flow_1/user_action",
+			status:       http.StatusOK,
+			response:     `{}`,
+			expectedBody: `{"actionType":"go_next_step"}`,
+		},
+		{
+			name: "rollback to checkpoint",
+			call: func(c Client) (interface{}, error) {
+				return nil, c.RollbackToCheckpoint(context.Background(), "ws_1", "flow_1", "2")
+			},
+			expectedPath: "POST /api/v1/workspaces/ws_1/flows/flow_1/user_action",
+			status:       http.StatusOK,
+			response:     `{}`,
+			expectedBody: `{"actionType":"rollback_to_checkpoint","stepNumber":"2"}`,
+		},
+		{
+			name: "archive task error message",
+			call: func(c Client) (interface{}, error) {
+				return nil, c.ArchiveTask(context.Background(), "ws_1", "task_1")
+			},
+			expectedPath: "POST /api/v1/workspaces/ws_1/tasks/task_1/archive",
+			status:       http.StatusNotFound,
+			response:     `{"error":"Task not found"}`,
+			expectedErr:  "failed to archive task: API request failed with status 404 Not Found: Task not found",
+		},
+		{
+			name: "pause flow error message field",
+			call: func(c Client) (interface{}, error) {
+				return nil, c.PauseFlow(context.Background(), "ws_1", "flow_1")
+			},
+			expectedPath: "POST /api/v1/workspaces/ws_1/flows/flow_1/pause",
+			status:       http.StatusBadRequest,
+			response:     `{"message":"Flow is not running"}`,
+			expectedErr:  "failed to pause flow: API request failed with status 400 Bad Request: Flow is not running",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath, gotBody string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.Method + " " + r.URL.RequestURI()
+				var body json.RawMessage
+				if json.NewDecoder(r.Body).Decode(&body) == nil {
+					gotBody = string(body)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			result, err := tt.call(NewClient(server.URL))
+			assert.Equal(t, tt.expectedPath, gotPath)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, gotBody)
+			}
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.expected != nil {
+				assert.Equal(t, tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestStreamFlowEvents(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/ws/v1/workspaces/ws_1/flows/flow_1/events", r.URL.Path)
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// echo a progress event for each subscription
+		for {
+			var sub domain.FlowEventSubscription
+			if err := conn.ReadJSON(&sub); err != nil {
+				return
+			}
+			event := domain.ProgressTextEvent{EventType: domain.ProgressTextEventType, ParentId: sub.ParentId, Text: "Running tests..."}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	subscriptions := make(chan domain.FlowEventSubscription, 1)
+	subscriptions <- domain.FlowEventSubscription{ParentId: "fa_1"}
+
+	c := NewClient(server.URL)
+	eventCh, errCh := c.StreamFlowEvents(ctx, "ws_1", "flow_1", subscriptions)
+
+	event := <-eventCh
+	assert.Equal(t, domain.ProgressTextEvent{EventType: domain.ProgressTextEventType, ParentId: "fa_1", Text: "Running tests..."}, event)
+
+	cancel()
+	for range eventCh {
+	}
+	assert.NoError(t, <-errCh, "canceling should end the stream without an error")
+}
+
+func TestStreamFlowActionChanges_ConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL)
+	flowActionCh, errCh := c.StreamFlowActionChanges(context.Background(), "ws_1", "flow_missing")
+
+	err := <-errCh
+	assert.ErrorContains(t, err, "404")
+	_, ok := <-flowActionCh
+	assert.False(t, ok)
+}
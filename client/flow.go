@@ -0,0 +1,233 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"sidekick/domain"
+
+	"github.com/gorilla/websocket"
+)
+
+// Flow is a flow along with the worktrees it has created.
+type Flow struct {
+	domain.Flow
+	Worktrees []domain.Worktree `json:"worktrees"`
+}
+
+type getFlowResponse struct {
+	Flow Flow `json:"flow"`
+}
+
+// GetFlow fetches a flow along with its worktrees.
+func (c *clientImpl) GetFlow(ctx context.Context, workspaceID string, flowID string) (Flow, error) {
+	var response getFlowResponse
+	path := fmt.Sprintf("/api/v1/workspaces/%s/flows/%s", workspaceID, flowID)
+	if err := c.do(ctx, http.MethodGet, path, nil, &response); err != nil {
+		return Flow{}, fmt.Errorf("failed to get flow: %w", err)
+	}
+	return response.Flow, nil
+}
+
+// PauseFlow requests that a flow pause at its next opportunity.
+func (c *clientImpl) PauseFlow(ctx context.Context, workspaceID string, flowID string) error {
+	path := fmt.Sprintf("/api/v1/workspaces/%s/flows/%s/pause", workspaceID, flowID)
+	if err := c.do(ctx, http.MethodPost, path, nil, nil); err != nil {
+		return fmt.Errorf("failed to pause flow: %w", err)
+	}
+	return nil
+}
+
+// CancelFlow cancels a flow that has not yet finished.
+func (c *clientImpl) CancelFlow(ctx context.Context, workspaceID string, flowID string) error {
+	path := fmt.Sprintf("/api/v1/workspaces/%s/flows/%s/cancel", workspaceID, flowID)
+	if err := c.do(ctx, http.MethodPost, path, nil, nil); err != nil {
+		return fmt.Errorf("failed to cancel flow: %w", err)
+	}
+	return nil
+}
+
+// SendUserAction sends a user-initiated action, eg "go_next_step", to a flow.
+func (c *clientImpl) SendUserAction(ctx context.Context, workspaceID string, flowID string, actionType string) error {
+	path := fmt.Sprintf("/api/v1/workspaces/%s/flows/%s/user_action", workspaceID, flowID)
+	body := map[string]string{"actionType": actionType}
+	if err := c.do(ctx, http.MethodPost, path, body, nil); err != nil {
+		return fmt.Errorf("failed to send user action: %w", err)
+	}
+	return nil
+}
+
+// RollbackToCheckpoint asks a flow to restore the checkpoint recorded after the
+// given plan step and resume from the step after it.
+func (c *clientImpl) RollbackToCheckpoint(ctx context.Context, workspaceID string, flowID string, stepNumber string) error {
+	path := fmt.Sprintf("/api/v1/workspaces/%s/flows/%s/user_action", workspaceID, flowID)
+	body := map[string]string{"actionType": "rollback_to_checkpoint", "stepNumber": stepNumber}
+	if err := c.do(ctx, http.MethodPost, path, body, nil); err != nil {
+		return fmt.Errorf("failed to roll back to checkpoint: %w", err)
+	}
+	return nil
+}
+
+type getFlowActionsResponse struct {
+	FlowActions []domain.FlowAction `json:"flowActions"`
+}
+
+// GetFlowActions fetches all flow actions for a flow, in the order they were
+// created.
+func (c *clientImpl) GetFlowActions(ctx context.Context, workspaceID string, flowID string) ([]domain.FlowAction, error) {
+	var response getFlowActionsResponse
+	path := fmt.Sprintf("/api/v1/workspaces/%s/flows/%s/actions", workspaceID, flowID)
+	if err := c.do(ctx, http.MethodGet, path, nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to get flow actions: %w", err)
+	}
+	return response.FlowActions, nil
+}
+
+// UserResponse is a human's response to a flow action that requested human
+// input. Which fields are required depends on the request kind.
+type UserResponse struct {
+	Content  string                 `json:"content,omitempty"`
+	Approved *bool                  `json:"approved,omitempty"`
+	Choice   string                 `json:"choice,omitempty"`
+	Params   map[string]interface{} `json:"params,omitempty"`
+}
+
+type completeFlowActionRequest struct {
+	UserResponse UserResponse `json:"userResponse"`
+}
+
+// CompleteFlowAction completes a pending human flow action with the given
+// response, which is relayed to the waiting flow.
+func (c *clientImpl) CompleteFlowAction(ctx context.Context, workspaceID string, flowActionID string, response UserResponse) (domain.FlowAction, error) {
+	var flowAction domain.FlowAction
+	path := fmt.Sprintf("/api/v1/workspaces/%s/flow_actions/%s/complete", workspaceID, flowActionID)
+	if err := c.do(ctx, http.MethodPost, path, completeFlowActionRequest{UserResponse: response}, &flowAction); err != nil {
+		return domain.FlowAction{}, fmt.Errorf("failed to complete flow action: %w", err)
+	}
+	return flowAction, nil
+}
+
+// dialWebsocket opens a websocket connection to the given path on the API
+// server. The connection is closed when ctx is done.
+func (c *clientImpl) dialWebsocket(ctx context.Context, path string) (*websocket.Conn, error) {
+	wsURL := c.BaseURL + path
+	if strings.HasPrefix(wsURL, "https://") {
+		wsURL = "wss://" + strings.TrimPrefix(wsURL, "https://")
+	} else {
+		wsURL = "ws://" + strings.TrimPrefix(wsURL, "http://")
+	}
+
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("failed to connect to %s: %w (status %s)", path, err, resp.Status)
+		}
+		return nil, fmt.Errorf("failed to connect to %s: %w", path, err)
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	return conn, nil
+}
+
+// StreamFlowActionChanges streams all of a flow's actions, starting from the
+// first, and then any changes to them as they happen. Both channels are closed
+// when the stream ends, which happens when ctx is done or the connection fails.
+func (c *clientImpl) StreamFlowActionChanges(ctx context.Context, workspaceID string, flowID string) (<-chan domain.FlowAction, <-chan error) {
+	flowActionCh := make(chan domain.FlowAction)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(flowActionCh)
+		defer close(errCh)
+
+		path := fmt.Sprintf("/ws/v1/workspaces/%s/flows/%s/action_changes_ws", workspaceID, flowID)
+		conn, err := c.dialWebsocket(ctx, path)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		defer conn.Close()
+
+		for {
+			var flowAction domain.FlowAction
+			if err := conn.ReadJSON(&flowAction); err != nil {
+				if ctx.Err() == nil {
+					errCh <- fmt.Errorf("failed to read flow action change: %w", err)
+				}
+				return
+			}
+			select {
+			case flowActionCh <- flowAction:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return flowActionCh, errCh
+}
+
+// StreamFlowEvents streams events for a flow. Events are only sent for parent
+// ids (eg flow action ids) that have been subscribed to via the subscriptions
+// channel. Both returned channels are closed when the stream ends.
+func (c *clientImpl) StreamFlowEvents(ctx context.Context, workspaceID string, flowID string, subscriptions <-chan domain.FlowEventSubscription) (<-chan domain.FlowEvent, <-chan error) {
+	flowEventCh := make(chan domain.FlowEvent)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(flowEventCh)
+		defer close(errCh)
+
+		path := fmt.Sprintf("/ws/v1/workspaces/%s/flows/%s/events", workspaceID, flowID)
+		conn, err := c.dialWebsocket(ctx, path)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		defer conn.Close()
+
+		// gorilla websocket connections support one concurrent writer, which
+		// is this goroutine
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case sub, ok := <-subscriptions:
+					if !ok {
+						return
+					}
+					if err := conn.WriteJSON(sub); err != nil {
+						return
+					}
+				}
+			}
+		}()
+
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				if ctx.Err() == nil {
+					errCh <- fmt.Errorf("failed to read flow event: %w", err)
+				}
+				return
+			}
+			flowEvent, err := domain.UnmarshalFlowEvent(data)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			select {
+			case flowEventCh <- flowEvent:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return flowEventCh, errCh
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"sidekick/domain"
+)
+
+type getSubflowsResponse struct {
+	Subflows []domain.Subflow `json:"subflows"`
+}
+
+// GetSubflows fetches all subflows of a flow.
+func (c *clientImpl) GetSubflows(ctx context.Context, workspaceID string, flowID string) ([]domain.Subflow, error) {
+	var response getSubflowsResponse
+	path := fmt.Sprintf("/api/v1/workspaces/%s/flows/%s/subflows", workspaceID, flowID)
+	if err := c.do(ctx, http.MethodGet, path, nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to get subflows: %w", err)
+	}
+	return response.Subflows, nil
+}
+
+type getSubflowResponse struct {
+	Subflow domain.Subflow `json:"subflow"`
+}
+
+// GetSubflow fetches a single subflow by id.
+func (c *clientImpl) GetSubflow(ctx context.Context, workspaceID string, subflowID string) (domain.Subflow, error) {
+	var response getSubflowResponse
+	path := fmt.Sprintf("/api/v1/workspaces/%s/subflows/%s", workspaceID, subflowID)
+	if err := c.do(ctx, http.MethodGet, path, nil, &response); err != nil {
+		return domain.Subflow{}, fmt.Errorf("failed to get subflow: %w", err)
+	}
+	return response.Subflow, nil
+}
@@ -2,9 +2,13 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
+	"strings"
 
 	"sidekick/domain"
 )
@@ -113,3 +117,36 @@ func (c *clientImpl) CancelTask(workspaceID string, taskID string) error {
 	}
 	return nil
 }
+
+type getTasksResponse struct {
+	Tasks []Task `json:"tasks"`
+}
+
+// GetTasks fetches all non-archived tasks in a workspace with any of the given
+// statuses, or with any status if none are given.
+func (c *clientImpl) GetTasks(ctx context.Context, workspaceID string, statuses []domain.TaskStatus) ([]Task, error) {
+	path := fmt.Sprintf("/api/v1/workspaces/%s/tasks/", workspaceID)
+	if len(statuses) > 0 {
+		statusStrings := make([]string, len(statuses))
+		for i, status := range statuses {
+			statusStrings[i] = string(status)
+		}
+		path += "?statuses=" + url.QueryEscape(strings.Join(statusStrings, ","))
+	}
+
+	var response getTasksResponse
+	if err := c.do(ctx, http.MethodGet, path, nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+	return response.Tasks, nil
+}
+
+// ArchiveTask archives a finished task, i.e. one that is complete, failed or
+// canceled.
+func (c *clientImpl) ArchiveTask(ctx context.Context, workspaceID string, taskID string) error {
+	path := fmt.Sprintf("/api/v1/workspaces/%s/tasks/%s/archive", workspaceID, taskID)
+	if err := c.do(ctx, http.MethodPost, path, nil, nil); err != nil {
+		return fmt.Errorf("failed to archive task: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"sidekick/domain"
+)
+
+type getWorktreesResponse struct {
+	Worktrees []domain.WorktreeStatus `json:"worktrees"`
+}
+
+// GetWorktrees fetches the status of each sidekick-managed worktree in the
+// workspace, including whether it is orphaned.
+func (c *clientImpl) GetWorktrees(ctx context.Context, workspaceID string) ([]domain.WorktreeStatus, error) {
+	var response getWorktreesResponse
+	path := fmt.Sprintf("/api/v1/workspaces/%s/worktrees", workspaceID)
+	if err := c.do(ctx, http.MethodGet, path, nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to get worktrees: %w", err)
+	}
+	return response.Worktrees, nil
+}
+
+type PruneWorktreesRequest struct {
+	DryRun          bool `json:"dryRun"`
+	IgnoreRetention bool `json:"ignoreRetention"`
+}
+
+type PruneWorktreeFailure struct {
+	Status domain.WorktreeStatus `json:"status"`
+	Error  string                `json:"error"`
+}
+
+type PruneWorktreesResult struct {
+	Pruned []domain.WorktreeStatus `json:"pruned"`
+	Failed []PruneWorktreeFailure  `json:"failed"`
+}
+
+// PruneWorktrees removes the workspace's prunable orphaned worktrees, or only
+// reports which would be removed for a dry run.
+func (c *clientImpl) PruneWorktrees(ctx context.Context, workspaceID string, req PruneWorktreesRequest) (PruneWorktreesResult, error) {
+	var result PruneWorktreesResult
+	path := fmt.Sprintf("/api/v1/workspaces/%s/worktrees/prune", workspaceID)
+	if err := c.do(ctx, http.MethodPost, path, req, &result); err != nil {
+		return PruneWorktreesResult{}, fmt.Errorf("failed to prune worktrees: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,295 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"sidekick/common"
+	"sidekick/env"
+	"sidekick/utils"
+
+	"github.com/rs/zerolog/log"
+	"go.temporal.io/sdk/activity"
+)
+
+const defaultBuiltinCheckTimeout = 60 * time.Second
+
+// activityDeadlineMargin is the time left for the rest of the activity, eg
+// staging or restoring the checked file, when a check is cut short to finish
+// before the activity's deadline
+const activityDeadlineMargin = 30 * time.Second
+
+// missingToolTTL is how long a command that isn't installed is assumed to stay
+// that way, so the PATH isn't probed again for every checked file
+const missingToolTTL = 5 * time.Minute
+
+type missingToolKey struct {
+	workingDir string
+	dir        string
+	command    string
+}
+
+// missingTools maps commands that weren't found in a project to when they
+// were looked up
+var missingTools sync.Map
+
+// BuiltinCheckResult is the outcome of a built-in compile or type check
+type BuiltinCheckResult struct {
+	// Tool is the tool that ran the check, eg "gradle" or "mypy". It is empty
+	// when the check was skipped because no tool is installed.
+	Tool   string
+	Passed bool
+	// Output contains the errors found in the checked file, or why the check
+	// was skipped
+	Output string
+	// Errors lists each error found in the checked file, including any lines
+	// of context that follow it
+	Errors []string
+}
+
+type builtinChecker func(ctx context.Context, envContainer env.EnvContainer, relativeFilePath string) (BuiltinCheckResult, error)
+
+var builtinCheckers = map[string]builtinChecker{
+	"java":   CheckViaJvmBuild,
+	"kotlin": CheckViaJvmBuild,
+	"python": CheckViaPythonTypeChecker,
+}
+
+// RunBuiltinCheck runs the built-in checker for the file's language when it
+// is enabled in the config. Checks that exceed the timeout, or that would run
+// past the calling activity's deadline, are treated as passing, so a slow build
+// never reverts an edit.
+func RunBuiltinCheck(ctx context.Context, envContainer env.EnvContainer, relativeFilePath string, config common.BuiltinChecksConfig) (BuiltinCheckResult, error) {
+	languageName := utils.InferLanguageNameFromFilePath(relativeFilePath)
+	checker, ok := builtinCheckers[languageName]
+	if !ok || !slices.Contains(config.Languages, languageName) {
+		return BuiltinCheckResult{Passed: true}, nil
+	}
+
+	timeout := builtinCheckTimeout(ctx, config)
+	if timeout <= 0 {
+		return BuiltinCheckResult{
+			Passed: true,
+			Output: fmt.Sprintf("%s check was skipped: not enough time left before the activity deadline", languageName),
+		}, nil
+	}
+	return runBuiltinChecker(ctx, checker, languageName, envContainer, relativeFilePath, time.Now().Add(timeout))
+}
+
+// builtinCheckTimeout is the configured timeout for a built-in check, reduced
+// to the time left before the calling activity's deadline
+func builtinCheckTimeout(ctx context.Context, config common.BuiltinChecksConfig) time.Duration {
+	timeout := defaultBuiltinCheckTimeout
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	if remaining, ok := timeUntilActivityDeadline(ctx); ok && remaining < timeout {
+		timeout = remaining
+	}
+	return timeout
+}
+
+// runBuiltinChecker runs the checker until the deadline, after which the check
+// is treated as passing
+func runBuiltinChecker(ctx context.Context, checker builtinChecker, languageName string, envContainer env.EnvContainer, relativeFilePath string, deadline time.Time) (BuiltinCheckResult, error) {
+	timeout := time.Until(deadline).Round(time.Second)
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	result, err := checker(ctx, envContainer, relativeFilePath)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return BuiltinCheckResult{
+			Tool:   result.Tool,
+			Passed: true,
+			Output: fmt.Sprintf("%s check timed out after %s and was skipped", languageName, timeout),
+		}, nil
+	}
+	return result, err
+}
+
+// BuiltinCheck compares the errors a built-in checker finds in a file after
+// it is edited with those found just before, so that errors the file already
+// had don't fail the edit
+type BuiltinCheck struct {
+	checker      builtinChecker
+	languageName string
+	baseline     []string
+	// deadline is shared by the checks before and after the edit, so that
+	// together they take no longer than a single check would
+	deadline time.Time
+}
+
+// PrepareBuiltinCheck runs the built-in checker for the file's language, when
+// it is enabled in the config, before the file is edited. Nil is returned when
+// there is nothing to check or the check failed to run, in which case the
+// edited file should be checked with RunBuiltinCheck instead.
+func PrepareBuiltinCheck(ctx context.Context, envContainer env.EnvContainer, relativeFilePath string, config common.BuiltinChecksConfig) *BuiltinCheck {
+	languageName := utils.InferLanguageNameFromFilePath(relativeFilePath)
+	checker, ok := builtinCheckers[languageName]
+	if !ok || !slices.Contains(config.Languages, languageName) {
+		return nil
+	}
+	timeout := builtinCheckTimeout(ctx, config)
+	if timeout <= 0 {
+		return nil
+	}
+
+	deadline := time.Now().Add(timeout)
+	result, err := runBuiltinChecker(ctx, checker, languageName, envContainer, relativeFilePath, deadline)
+	if err != nil {
+		log.Warn().Err(err).Str("path", relativeFilePath).Msg("Failed to check file before editing it")
+		return nil
+	}
+	if result.Tool == "" {
+		return nil
+	}
+	// a check that timed out before the edit is skipped after it too
+	return &BuiltinCheck{
+		checker:      checker,
+		languageName: languageName,
+		baseline:     result.Errors,
+		deadline:     deadline,
+	}
+}
+
+// Run checks the edited file, only failing on errors that it didn't have
+// before the edit
+func (bc *BuiltinCheck) Run(ctx context.Context, envContainer env.EnvContainer, relativeFilePath string) (BuiltinCheckResult, error) {
+	if !time.Now().Before(bc.deadline) {
+		return BuiltinCheckResult{
+			Passed: true,
+			Output: fmt.Sprintf("%s check was skipped: no time left after checking the file before the edit", bc.languageName),
+		}, nil
+	}
+	result, err := runBuiltinChecker(ctx, bc.checker, bc.languageName, envContainer, relativeFilePath, bc.deadline)
+	if err != nil || result.Passed {
+		return result, err
+	}
+
+	newErrors := bc.newErrors(result.Errors)
+	if len(newErrors) == 0 {
+		return BuiltinCheckResult{
+			Tool:   result.Tool,
+			Passed: true,
+			Output: fmt.Sprintf("%s only found errors that existed before the edit", result.Tool),
+		}, nil
+	}
+	header, _, _ := strings.Cut(result.Output, "\n")
+	result.Output = header + "\n" + strings.Join(newErrors, "\n")
+	result.Errors = newErrors
+	return result, nil
+}
+
+// newErrors returns the errors that aren't in the baseline. Errors are
+// compared without their locations, since edits shift the lines below them.
+func (bc *BuiltinCheck) newErrors(errs []string) []string {
+	remaining := make(map[string]int)
+	for _, err := range bc.baseline {
+		remaining[builtinCheckErrorKey(err)]++
+	}
+	var newErrors []string
+	for _, err := range errs {
+		key := builtinCheckErrorKey(err)
+		if remaining[key] > 0 {
+			remaining[key]--
+			continue
+		}
+		newErrors = append(newErrors, err)
+	}
+	return newErrors
+}
+
+// errorLocationRegex matches the start of an error's first line, up to and
+// including its line and column, eg "main.py:3:5: " or "[ERROR] Foo.java:[12,5] "
+var errorLocationRegex = regexp.MustCompile(`^.*?:\s?[\[(]?\d+(?:[,:]\d+)?[\])]?:?\s*`)
+
+// builtinCheckErrorKey identifies an error by its first line, without the
+// location
+func builtinCheckErrorKey(err string) string {
+	firstLine, _, _ := strings.Cut(err, "\n")
+	return errorLocationRegex.ReplaceAllString(firstLine, "")
+}
+
+// timeUntilActivityDeadline returns the time left before the calling
+// activity's deadline, less activityDeadlineMargin. Outside of an activity,
+// the context's deadline is used instead.
+func timeUntilActivityDeadline(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if activity.IsActivity(ctx) {
+		deadline = activity.GetInfo(ctx).Deadline
+		ok = !deadline.IsZero()
+	}
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline) - activityDeadlineMargin, true
+}
+
+// findTool returns the first available command among the candidates.
+// Candidates containing a slash, eg "./gradlew", are executables relative to
+// dir within the working directory, while others are looked up in the PATH.
+func findTool(ctx context.Context, envContainer env.EnvContainer, dir string, candidates ...string) (string, error) {
+	for _, candidate := range candidates {
+		if strings.Contains(candidate, "/") {
+			path := filepath.Join(envContainer.Env.GetWorkingDirectory(), dir, candidate)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+				return path, nil
+			}
+			continue
+		}
+
+		key := missingToolKey{workingDir: envContainer.Env.GetWorkingDirectory(), dir: dir, command: candidate}
+		if lookedUp, ok := missingTools.Load(key); ok && time.Since(lookedUp.(time.Time)) < missingToolTTL {
+			continue
+		}
+		output, err := envContainer.Env.RunCommand(ctx, env.EnvRunCommandInput{
+			RelativeWorkingDir: dir,
+			Command:            "/usr/bin/env",
+			Category:           "check",
+			Args:               []string{"sh", "-c", `command -v "$1"`, "sh", candidate},
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to look up %s: %w", candidate, err)
+		}
+		if output.ExitStatus == 0 {
+			missingTools.Delete(key)
+			return candidate, nil
+		}
+		missingTools.Store(key, time.Now())
+	}
+	return "", nil
+}
+
+// findProjectDir walks up from the file's directory to the root of the
+// working directory, returning the first directory, relative to the working
+// directory, that contains one of the given files, along with that file name
+func findProjectDir(envContainer env.EnvContainer, relativeFilePath string, fileNames ...string) (string, string, bool) {
+	workingDir := envContainer.Env.GetWorkingDirectory()
+	for _, dir := range ancestorDirs(filepath.Dir(filepath.Clean(relativeFilePath))) {
+		for _, fileName := range fileNames {
+			if _, err := os.Stat(filepath.Join(workingDir, dir, fileName)); err == nil {
+				return dir, fileName, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// ancestorDirs lists a relative directory followed by each of its parents, up
+// to and including "."
+func ancestorDirs(dir string) []string {
+	dirs := []string{dir}
+	for dir != "." && dir != "/" {
+		dir = filepath.Dir(dir)
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
@@ -0,0 +1,336 @@
+package check
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sidekick/common"
+	"sidekick/env"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCheckerTestFile(t *testing.T, path, content string, perm os.FileMode) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), perm))
+}
+
+// useFakeTools replaces the PATH with a directory containing only the given
+// fake tools, plus the basic utilities they need
+func useFakeTools(t *testing.T, tools map[string]string) {
+	t.Helper()
+	binDir := t.TempDir()
+	for name, script := range tools {
+		writeCheckerTestFile(t, filepath.Join(binDir, name), "#!/bin/sh\n"+script, 0755)
+	}
+	t.Setenv("PATH", binDir+":/bin:/usr/bin")
+}
+
+func TestRunBuiltinCheck(t *testing.T) {
+	dir := t.TempDir()
+	writeCheckerTestFile(t, filepath.Join(dir, "main.py"), "x: int = 'a'\n", 0644)
+	envContainer := env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: dir}}
+	useFakeTools(t, map[string]string{
+		"mypy": "echo \"main.py:1: error: Incompatible types in assignment  [assignment]\"\nexit 1\n",
+	})
+
+	t.Run("disabled language", func(t *testing.T) {
+		result, err := RunBuiltinCheck(context.Background(), envContainer, "main.py", common.BuiltinChecksConfig{Languages: []string{"java"}})
+		require.NoError(t, err)
+		assert.Equal(t, BuiltinCheckResult{Passed: true}, result)
+	})
+
+	t.Run("enabled language", func(t *testing.T) {
+		result, err := RunBuiltinCheck(context.Background(), envContainer, "main.py", common.BuiltinChecksConfig{Languages: []string{"python"}})
+		require.NoError(t, err)
+		assert.Equal(t, "mypy", result.Tool)
+		assert.False(t, result.Passed)
+		assert.Contains(t, result.Output, "Incompatible types in assignment")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		useFakeTools(t, map[string]string{"mypy": "exec sleep 5\n"})
+		result, err := RunBuiltinCheck(context.Background(), envContainer, "main.py", common.BuiltinChecksConfig{Languages: []string{"python"}, TimeoutSeconds: 1})
+		require.NoError(t, err)
+		assert.True(t, result.Passed)
+		assert.Contains(t, result.Output, "timed out")
+	})
+
+	t.Run("activity deadline", func(t *testing.T) {
+		useFakeTools(t, map[string]string{"mypy": "exec sleep 5\n"})
+		ctx, cancel := context.WithTimeout(context.Background(), activityDeadlineMargin+time.Second)
+		defer cancel()
+		start := time.Now()
+		result, err := RunBuiltinCheck(ctx, envContainer, "main.py", common.BuiltinChecksConfig{Languages: []string{"python"}})
+		require.NoError(t, err)
+		assert.True(t, result.Passed)
+		assert.Contains(t, result.Output, "timed out")
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("activity deadline too close", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), activityDeadlineMargin/2)
+		defer cancel()
+		result, err := RunBuiltinCheck(ctx, envContainer, "main.py", common.BuiltinChecksConfig{Languages: []string{"python"}})
+		require.NoError(t, err)
+		assert.True(t, result.Passed)
+		assert.Empty(t, result.Tool)
+		assert.Contains(t, result.Output, "skipped")
+	})
+}
+
+func TestBuiltinCheck(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "main.py")
+	writeCheckerTestFile(t, filePath, "x: int = 'a'\n", 0644)
+	envContainer := env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: dir}}
+	config := common.BuiltinChecksConfig{Languages: []string{"python"}}
+	// reports the pre-existing error on the line after any added ones
+	useFakeTools(t, map[string]string{
+		"mypy": `line=$(grep -n "^x: int" main.py | cut -d: -f1)
+echo "main.py:$line: error: Incompatible types in assignment  [assignment]"
+if grep -q bar main.py; then echo "main.py:1: error: Name \"bar\" is not defined  [name-defined]"; fi
+exit 1
+`,
+	})
+
+	t.Run("disabled language", func(t *testing.T) {
+		assert.Nil(t, PrepareBuiltinCheck(ctx, envContainer, "main.py", common.BuiltinChecksConfig{Languages: []string{"java"}}))
+	})
+
+	t.Run("only pre-existing errors", func(t *testing.T) {
+		builtinCheck := PrepareBuiltinCheck(ctx, envContainer, "main.py", config)
+		require.NotNil(t, builtinCheck)
+		writeCheckerTestFile(t, filePath, "import os\nx: int = 'a'\n", 0644)
+
+		result, err := builtinCheck.Run(ctx, envContainer, "main.py")
+		require.NoError(t, err)
+		assert.Equal(t, "mypy", result.Tool)
+		assert.True(t, result.Passed)
+	})
+
+	t.Run("new errors", func(t *testing.T) {
+		writeCheckerTestFile(t, filePath, "x: int = 'a'\n", 0644)
+		builtinCheck := PrepareBuiltinCheck(ctx, envContainer, "main.py", config)
+		require.NotNil(t, builtinCheck)
+		writeCheckerTestFile(t, filePath, "bar\nx: int = 'a'\n", 0644)
+
+		result, err := builtinCheck.Run(ctx, envContainer, "main.py")
+		require.NoError(t, err)
+		assert.False(t, result.Passed)
+		assert.Equal(t, "mypy type errors:\nmain.py:1: error: Name \"bar\" is not defined  [name-defined]", result.Output)
+	})
+
+	t.Run("shared timeout", func(t *testing.T) {
+		useFakeTools(t, map[string]string{"mypy": "exec sleep 5\n"})
+		start := time.Now()
+		builtinCheck := PrepareBuiltinCheck(ctx, envContainer, "main.py", common.BuiltinChecksConfig{Languages: []string{"python"}, TimeoutSeconds: 1})
+		require.NotNil(t, builtinCheck)
+		result, err := builtinCheck.Run(ctx, envContainer, "main.py")
+		require.NoError(t, err)
+		assert.True(t, result.Passed)
+		assert.Contains(t, result.Output, "skipped")
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
+
+func TestBuiltinCheckErrorKey(t *testing.T) {
+	for input, expected := range map[string]string{
+		"main.py:3: error: Incompatible types  [assignment]":           "error: Incompatible types  [assignment]",
+		"main.py:3:5: error: \"foo\" is not defined (reportUndefined)": "error: \"foo\" is not defined (reportUndefined)",
+		"src/Foo.java:12: error: cannot find symbol\n  foo();\n  ^":    "error: cannot find symbol",
+		"e: src/Foo.kt:12:5 Unresolved reference: bar":                 "Unresolved reference: bar",
+		"[ERROR] src/Foo.java:[12,5] cannot find symbol":               "cannot find symbol",
+	} {
+		assert.Equal(t, expected, builtinCheckErrorKey(input), input)
+	}
+}
+
+func TestFindTool_cachesMissingTools(t *testing.T) {
+	ctx := context.Background()
+	envContainer := env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: t.TempDir()}}
+	useFakeTools(t, nil)
+	command, err := findTool(ctx, envContainer, "", "mypy")
+	require.NoError(t, err)
+	assert.Empty(t, command)
+
+	// installing the tool isn't noticed until the cached lookup expires
+	useFakeTools(t, map[string]string{"mypy": "exit 0\n"})
+	command, err = findTool(ctx, envContainer, "", "mypy")
+	require.NoError(t, err)
+	assert.Empty(t, command)
+
+	key := missingToolKey{workingDir: envContainer.Env.GetWorkingDirectory(), command: "mypy"}
+	missingTools.Store(key, time.Now().Add(-missingToolTTL))
+	command, err = findTool(ctx, envContainer, "", "mypy")
+	require.NoError(t, err)
+	assert.Equal(t, "mypy", command)
+}
+
+func TestCheckViaPythonTypeChecker(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("not installed", func(t *testing.T) {
+		dir := t.TempDir()
+		useFakeTools(t, nil)
+		result, err := CheckViaPythonTypeChecker(ctx, env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: dir}}, "main.py")
+		require.NoError(t, err)
+		assert.True(t, result.Passed)
+		assert.Empty(t, result.Tool)
+	})
+
+	t.Run("mypy", func(t *testing.T) {
+		dir := t.TempDir()
+		useFakeTools(t, map[string]string{
+			"mypy": `echo "main.py:1: error: Cannot find implementation or library stub for module named \"requests\"  [import-not-found]"
+echo "main.py:1: note: See https://mypy.readthedocs.io/en/stable/running_mypy.html#missing-imports"
+echo "main.py:3: error: Incompatible types in assignment (expression has type \"str\", variable has type \"int\")  [assignment]"
+exit 1
+`,
+		})
+		result, err := CheckViaPythonTypeChecker(ctx, env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: dir}}, "main.py")
+		require.NoError(t, err)
+		assert.Equal(t, "mypy", result.Tool)
+		assert.False(t, result.Passed)
+		assert.Contains(t, result.Output, "main.py:3: error: Incompatible types in assignment")
+		assert.NotContains(t, result.Output, "requests")
+		assert.NotContains(t, result.Output, "note:")
+	})
+
+	t.Run("only missing imports", func(t *testing.T) {
+		dir := t.TempDir()
+		useFakeTools(t, map[string]string{
+			"mypy": "echo 'main.py:1: error: Library stubs not installed for \"yaml\"  [import-untyped]'\nexit 1\n",
+		})
+		result, err := CheckViaPythonTypeChecker(ctx, env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: dir}}, "main.py")
+		require.NoError(t, err)
+		assert.True(t, result.Passed)
+	})
+
+	t.Run("pyright in virtualenv is preferred", func(t *testing.T) {
+		dir := t.TempDir()
+		useFakeTools(t, map[string]string{"mypy": "exit 1\n"})
+		writeCheckerTestFile(t, filepath.Join(dir, ".venv", "bin", "pyright"), `#!/bin/sh
+cat <<EOF
+{"generalDiagnostics": [
+  {"file": "$PWD/main.py", "severity": "error", "message": "Import \"requests\" could not be resolved", "rule": "reportMissingImports", "range": {"start": {"line": 0, "character": 7}}},
+  {"file": "$PWD/main.py", "severity": "warning", "message": "Variable is unused", "range": {"start": {"line": 1, "character": 0}}},
+  {"file": "$PWD/main.py", "severity": "error", "message": "\"foo\" is not defined", "rule": "reportUndefinedVariable", "range": {"start": {"line": 2, "character": 4}}}
+]}
+EOF
+exit 1
+`, 0755)
+		result, err := CheckViaPythonTypeChecker(ctx, env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: dir}}, "main.py")
+		require.NoError(t, err)
+		assert.Equal(t, "pyright", result.Tool)
+		assert.False(t, result.Passed)
+		assert.Equal(t, "pyright type errors:\nmain.py:3:5: error: \"foo\" is not defined (reportUndefinedVariable)", result.Output)
+	})
+}
+
+func TestCheckViaJvmBuild(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("gradle wrapper in a parent directory", func(t *testing.T) {
+		dir := t.TempDir()
+		useFakeTools(t, nil)
+		writeCheckerTestFile(t, filepath.Join(dir, "settings.gradle.kts"), "", 0644)
+		writeCheckerTestFile(t, filepath.Join(dir, "app", "build.gradle.kts"), "", 0644)
+		writeCheckerTestFile(t, filepath.Join(dir, "gradlew"), `#!/bin/sh
+echo "$PWD $@" > "`+filepath.Join(dir, "gradle-args")+`"
+echo "e: file://$PWD/src/test/kotlin/FooTest.kt:3:5 Unresolved reference: bar" >&2
+echo "w: file://$PWD/src/test/kotlin/FooTest.kt:4:5 Variable 'x' is never used" >&2
+echo "e: file://$PWD/src/test/kotlin/OtherTest.kt:7:1 Unresolved reference: baz" >&2
+echo "FAILURE: Build failed with an exception." >&2
+exit 1
+`, 0755)
+
+		result, err := CheckViaJvmBuild(ctx, env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: dir}}, "app/src/test/kotlin/FooTest.kt")
+		require.NoError(t, err)
+		assert.Equal(t, "gradle", result.Tool)
+		assert.False(t, result.Passed)
+		assert.Equal(t, "gradle compile errors:\ne: app/src/test/kotlin/FooTest.kt:3:5 Unresolved reference: bar", result.Output)
+
+		args, err := os.ReadFile(filepath.Join(dir, "gradle-args"))
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "app")+" --offline --quiet --console=plain compileTestKotlin\n", string(args))
+	})
+
+	t.Run("maven errors in other files", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCheckerTestFile(t, filepath.Join(dir, "pom.xml"), "<project/>", 0644)
+		useFakeTools(t, map[string]string{
+			"mvn": `echo "[ERROR] COMPILATION ERROR :"
+echo "[ERROR] $PWD/src/main/java/Other.java:[5,9] cannot find symbol"
+echo "  symbol:   variable x"
+exit 1
+`,
+		})
+		result, err := CheckViaJvmBuild(ctx, env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: dir}}, "src/main/java/Foo.java")
+		require.NoError(t, err)
+		assert.Equal(t, "maven", result.Tool)
+		assert.True(t, result.Passed)
+	})
+
+	t.Run("not installed", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCheckerTestFile(t, filepath.Join(dir, "build.gradle"), "", 0644)
+		useFakeTools(t, nil)
+		result, err := CheckViaJvmBuild(ctx, env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: dir}}, "src/main/java/Foo.java")
+		require.NoError(t, err)
+		assert.True(t, result.Passed)
+		assert.Empty(t, result.Tool)
+	})
+}
+
+func TestFilterJvmDiagnostics(t *testing.T) {
+	t.Parallel()
+	output := `/repo/src/main/java/Foo.java:12: error: cannot find symbol
+        return bar();
+               ^
+  symbol:   method bar()
+  location: class Foo
+/repo/src/main/java/Foo.java:14: warning: [deprecation] baz() in Foo has been deprecated
+/repo/src/main/java/Other.java:3: error: ';' expected
+/repo/src/main/java/Foo.java:20: error: incompatible types: String cannot be converted to int
+        int x = "a";
+                ^
+Note: Some input files use unchecked or unsafe operations.
+3 errors
+
+[ERROR] /repo/src/main/java/Foo.java:[12,16] cannot find symbol
+  symbol:   method bar()
+[ERROR] -> [Help 1]`
+
+	diagnostics := filterJvmDiagnostics(output, []string{"/repo/src/main/java/Foo.java"})
+	assert.Equal(t, []string{
+		"/repo/src/main/java/Foo.java:12: error: cannot find symbol\n        return bar();\n               ^\n  symbol:   method bar()\n  location: class Foo",
+		"/repo/src/main/java/Foo.java:20: error: incompatible types: String cannot be converted to int\n        int x = \"a\";\n                ^",
+		"[ERROR] /repo/src/main/java/Foo.java:[12,16] cannot find symbol\n  symbol:   method bar()",
+	}, diagnostics)
+}
+
+func TestCheckFileActivity_builtinChecks(t *testing.T) {
+	dir := t.TempDir()
+	writeCheckerTestFile(t, filepath.Join(dir, "main.py"), "x: int = 'a'\n", 0644)
+	useFakeTools(t, map[string]string{
+		"mypy": "echo \"main.py:1: error: Incompatible types in assignment  [assignment]\"\nexit 1\n",
+	})
+
+	output, err := CheckFileActivity(context.Background(), CheckFileActivityInput{
+		EnvContainer:  env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: dir}},
+		FilePath:      "main.py",
+		BuiltinChecks: common.BuiltinChecksConfig{Languages: []string{"python"}},
+	})
+	require.NoError(t, err)
+	assert.False(t, output.AllPassed)
+	assert.Equal(t, map[string]bool{"baseFileValidityChecks": true, "mypy": false}, output.CheckPassed)
+	assert.Contains(t, output.Output, "built-in check: mypy")
+	assert.Contains(t, output.Output, "Incompatible types in assignment")
+}
@@ -6,6 +6,8 @@ import (
 	"sidekick/common"
 	"sidekick/env"
 	"strings"
+
+	"github.com/rs/zerolog/log"
 )
 
 // CheckFileActivityOutput is the output of the CheckFileActivity function.
@@ -16,6 +18,9 @@ type CheckFileActivityOutput struct {
 	AllPassed bool
 	// Output contains the combined output of all check commands.
 	Output string
+	// Diagnostics are the type errors introduced in the file, if it was type
+	// checked
+	Diagnostics []Diagnostic
 }
 
 // returns whether the file passed all the check commands and built-in
@@ -25,9 +30,16 @@ type CheckFileActivityInput struct {
 	EnvContainer  env.EnvContainer
 	FilePath      string
 	CheckCommands []common.CommandConfig
+	BuiltinChecks common.BuiltinChecksConfig
+	// Typescript type checks the file when set, and must be prepared before
+	// the file is edited
+	Typescript *TypescriptCheck `json:"-"`
+	// Builtin compares the file's built-in check against one taken before the
+	// file was edited, when set. Otherwise, all errors in the file are reported.
+	Builtin *BuiltinCheck `json:"-"`
 }
 
-func CheckFileActivity(input CheckFileActivityInput) (CheckFileActivityOutput, error) {
+func CheckFileActivity(ctx context.Context, input CheckFileActivityInput) (CheckFileActivityOutput, error) {
 	// Initialize a variable to store the combined output of all check commands
 	var combinedOutput string
 	allPassed := true
@@ -36,9 +48,10 @@ func CheckFileActivity(input CheckFileActivityInput) (CheckFileActivityOutput, e
 	// Run all the check_commands via a shell using the envContainer.Env.RunCommand
 	for _, command := range input.CheckCommands {
 		shellCommand := strings.ReplaceAll(command.Command, "{file}", input.FilePath)
-		output, err := input.EnvContainer.Env.RunCommand(context.Background(), env.EnvRunCommandInput{
+		output, err := input.EnvContainer.Env.RunCommand(ctx, env.EnvRunCommandInput{
 			RelativeWorkingDir: command.WorkingDir,
 			Command:            "/usr/bin/env",
+			Category:           "check",
 			Args:               []string{"sh", "-c", shellCommand},
 		})
 		if err != nil {
@@ -71,9 +84,52 @@ func CheckFileActivity(input CheckFileActivityInput) (CheckFileActivityOutput, e
 	}
 	checkPassed["baseFileValidityChecks"] = valid
 
+	// compiling or type checking a file with syntax errors only adds noise
+	if valid {
+		var builtinResult BuiltinCheckResult
+		var err error
+		if input.Builtin != nil {
+			builtinResult, err = input.Builtin.Run(ctx, input.EnvContainer, input.FilePath)
+		} else {
+			builtinResult, err = RunBuiltinCheck(ctx, input.EnvContainer, input.FilePath, input.BuiltinChecks)
+		}
+		if err != nil {
+			return CheckFileActivityOutput{}, fmt.Errorf("failed to run built-in check: %w", err)
+		}
+		if builtinResult.Tool != "" {
+			combinedOutput += fmt.Sprintf("built-in check: %s\n", builtinResult.Tool)
+			combinedOutput += fmt.Sprintf("check passed: %t\n", builtinResult.Passed)
+			if !builtinResult.Passed {
+				combinedOutput += builtinResult.Output + "\n"
+				allPassed = false
+			}
+			checkPassed[builtinResult.Tool] = builtinResult.Passed
+		}
+	}
+
+	var diagnostics []Diagnostic
+	if valid && input.Typescript != nil {
+		tool := input.Typescript.watcher.Tool()
+		diagnostics, err = input.Typescript.newDiagnostics(ctx, input.FilePath)
+		if err != nil {
+			// a slow or crashed type checker shouldn't revert edits
+			log.Warn().Err(err).Str("filePath", input.FilePath).Msgf("Skipping %s check", tool)
+		} else {
+			passed := len(diagnostics) == 0
+			combinedOutput += fmt.Sprintf("built-in check: %s\n", tool)
+			combinedOutput += fmt.Sprintf("check passed: %t\n", passed)
+			for _, diagnostic := range diagnostics {
+				combinedOutput += diagnostic.String() + "\n"
+			}
+			allPassed = allPassed && passed
+			checkPassed[tool] = passed
+		}
+	}
+
 	return CheckFileActivityOutput{
 		CheckPassed: checkPassed,
 		AllPassed:   allPassed,
 		Output:      combinedOutput,
+		Diagnostics: diagnostics,
 	}, nil
 }
@@ -1,6 +1,7 @@
 package check
 
 import (
+	"context"
 	"os"
 	"sidekick/env"
 	"testing"
@@ -26,7 +27,7 @@ func TestCheckFileActivity_invalidGoFile(t *testing.T) {
 		EnvContainer: envContainer,
 	}
 
-	output, err := CheckFileActivity(input)
+	output, err := CheckFileActivity(context.Background(), input)
 	assert.NoError(t, err)
 	if output.Output == "" {
 		t.Fatalf("expected output NOT to be empty, but it was")
@@ -54,7 +55,7 @@ func TestCheckFileActivity_invalidVueFile(t *testing.T) {
 		EnvContainer: envContainer,
 	}
 
-	output, err := CheckFileActivity(input)
+	output, err := CheckFileActivity(context.Background(), input)
 	assert.NoError(t, err)
 	if output.Output == "" {
 		t.Fatalf("expected output NOT to be empty, but it was")
@@ -82,7 +83,7 @@ func TestCheckFileActivity_validGoFile(t *testing.T) {
 		EnvContainer: envContainer,
 	}
 
-	output, err := CheckFileActivity(input)
+	output, err := CheckFileActivity(context.Background(), input)
 	assert.NoError(t, err)
 	assert.True(t, output.AllPassed)
 	assert.Empty(t, output.Output)
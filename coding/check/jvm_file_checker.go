@@ -0,0 +1,166 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"sidekick/env"
+	"sidekick/utils"
+)
+
+// jvmDiagnosticRegex matches the first line of a diagnostic from javac,
+// kotlinc or maven, capturing the source file's path, eg:
+//
+//	/repo/src/main/java/Foo.java:12: error: cannot find symbol
+//	e: file:///repo/src/main/kotlin/Foo.kt:12:5 Unresolved reference: bar
+//	[ERROR] /repo/src/main/java/Foo.java:[12,5] cannot find symbol
+var jvmDiagnosticRegex = regexp.MustCompile(`(?:file://)?(/[^\s:\[\]()]+\.kts?|/[^\s:\[\]()]+\.java):\s?[\[(]?\d+`)
+
+var jvmWarningRegex = regexp.MustCompile(`^(w: |\[WARNING\] )|: warning:`)
+
+// jvmDiagnosticEndRegex matches lines that follow the last diagnostic in the
+// output of the build tools
+var jvmDiagnosticEndRegex = regexp.MustCompile(`^(\d+ (errors?|warnings?)$|Note: |FAILURE: |BUILD FAILED|\* What went wrong|\[(ERROR|INFO|WARNING)\] |[ew]: )`)
+
+// javac and maven print the offending source line and a few details after
+// the first line of a diagnostic
+const maxJvmDiagnosticContextLines = 5
+
+// CheckViaJvmBuild compiles the gradle or maven project containing a Java or
+// Kotlin file, using the build tool's incremental compilation and the
+// project's wrapper when present. Only errors in the given file fail the
+// check: other files may be mid-edit, and failures unrelated to the source
+// code, eg dependencies that can't be resolved offline, are not the edit's
+// fault.
+func CheckViaJvmBuild(ctx context.Context, envContainer env.EnvContainer, relativeFilePath string) (BuiltinCheckResult, error) {
+	projectDir, buildFile, ok := findProjectDir(envContainer, relativeFilePath, "build.gradle.kts", "build.gradle", "pom.xml")
+	if !ok {
+		return BuiltinCheckResult{Passed: true, Output: "No gradle or maven project found"}, nil
+	}
+
+	isTest := strings.Contains(filepath.ToSlash(relativeFilePath), "/src/test/")
+	var tool, command string
+	var args []string
+	var err error
+	if buildFile == "pom.xml" {
+		tool = "maven"
+		command, err = findJvmBuildTool(ctx, envContainer, projectDir, "mvnw", "mvn")
+		goal := "compile"
+		if isTest {
+			goal = "test-compile"
+		}
+		args = []string{"--offline", "--quiet", "--batch-mode", goal}
+	} else {
+		tool = "gradle"
+		command, err = findJvmBuildTool(ctx, envContainer, projectDir, "gradlew", "gradle")
+		task := "compile"
+		if isTest {
+			task += "Test"
+		}
+		if utils.InferLanguageNameFromFilePath(relativeFilePath) == "kotlin" {
+			task += "Kotlin"
+		} else {
+			task += "Java"
+		}
+		args = []string{"--offline", "--quiet", "--console=plain", task}
+	}
+	if err != nil {
+		return BuiltinCheckResult{}, err
+	}
+	if command == "" {
+		return BuiltinCheckResult{Passed: true, Output: fmt.Sprintf("Skipped: %s is not installed", tool)}, nil
+	}
+
+	result, err := envContainer.Env.RunCommand(ctx, env.EnvRunCommandInput{
+		RelativeWorkingDir: projectDir,
+		Command:            command,
+		Args:               args,
+	})
+	if err != nil {
+		return BuiltinCheckResult{Tool: tool}, fmt.Errorf("failed to run %s: %w", tool, err)
+	}
+	if result.ExitStatus == 0 {
+		return BuiltinCheckResult{Tool: tool, Passed: true}, nil
+	}
+
+	// build tools may report paths with symlinks resolved
+	workingDirs := []string{envContainer.Env.GetWorkingDirectory()}
+	if resolved, err := filepath.EvalSymlinks(workingDirs[0]); err == nil && resolved != workingDirs[0] {
+		workingDirs = append(workingDirs, resolved)
+	}
+	filePaths := utils.Map(workingDirs, func(dir string) string {
+		return filepath.Join(dir, relativeFilePath)
+	})
+	diagnostics := filterJvmDiagnostics(result.Stdout+"\n"+result.Stderr, filePaths)
+	if len(diagnostics) == 0 {
+		return BuiltinCheckResult{Tool: tool, Passed: true}, nil
+	}
+	diagnostics = utils.Map(diagnostics, func(diagnostic string) string {
+		for _, dir := range workingDirs {
+			diagnostic = strings.ReplaceAll(diagnostic, "file://"+dir+string(filepath.Separator), "")
+			diagnostic = strings.ReplaceAll(diagnostic, dir+string(filepath.Separator), "")
+		}
+		return diagnostic
+	})
+	return BuiltinCheckResult{
+		Tool:   tool,
+		Passed: false,
+		Output: fmt.Sprintf("%s compile errors:\n%s", tool, strings.Join(diagnostics, "\n")),
+		Errors: diagnostics,
+	}, nil
+}
+
+// findJvmBuildTool prefers a wrapper script in the project directory or any
+// of its parents over the build tool installed in the PATH, returning an
+// absolute path for the wrapper
+func findJvmBuildTool(ctx context.Context, envContainer env.EnvContainer, projectDir, wrapper, command string) (string, error) {
+	candidates := make([]string, 0)
+	for _, dir := range ancestorDirs(projectDir) {
+		candidates = append(candidates, "./"+filepath.Join(dir, wrapper))
+	}
+	candidates = append(candidates, command)
+	return findTool(ctx, envContainer, "", candidates...)
+}
+
+// filterJvmDiagnostics extracts the error diagnostics, with their context
+// lines, that refer to any of the given absolute file paths
+func filterJvmDiagnostics(output string, filePaths []string) []string {
+	var diagnostics []string
+	var current []string
+	flush := func() {
+		if len(current) > 0 {
+			diagnostics = append(diagnostics, strings.Join(current, "\n"))
+			current = nil
+		}
+	}
+
+	inDiagnostic := false
+	contextLines := 0
+	for _, line := range strings.Split(output, "\n") {
+		if match := jvmDiagnosticRegex.FindStringSubmatch(line); match != nil {
+			flush()
+			inDiagnostic = !jvmWarningRegex.MatchString(line) && slices.Contains(filePaths, match[1])
+			contextLines = 0
+			if inDiagnostic {
+				current = append(current, line)
+			}
+			continue
+		}
+		if !inDiagnostic {
+			continue
+		}
+		if strings.TrimSpace(line) == "" || jvmDiagnosticEndRegex.MatchString(line) || contextLines >= maxJvmDiagnosticContextLines {
+			flush()
+			inDiagnostic = false
+			continue
+		}
+		current = append(current, line)
+		contextLines++
+	}
+	flush()
+	return diagnostics
+}
@@ -0,0 +1,35 @@
+package check
+
+import (
+	"context"
+	"time"
+)
+
+// MockTypescriptWatcher is a mock implementation of the TypescriptWatcher
+// interface for testing. Each call to Diagnostics returns the next of the
+// Results, repeating the last one once they run out.
+type MockTypescriptWatcher struct {
+	// ToolName is returned by Tool, defaulting to "tsc"
+	ToolName string
+	Results  [][]Diagnostic
+	Calls    int
+	Closed   bool
+}
+
+func (w *MockTypescriptWatcher) Tool() string {
+	if w.ToolName == "" {
+		return "tsc"
+	}
+	return w.ToolName
+}
+
+func (w *MockTypescriptWatcher) Diagnostics(ctx context.Context, changedAt time.Time) ([]Diagnostic, error) {
+	result := w.Results[min(w.Calls, len(w.Results)-1)]
+	w.Calls++
+	return result, nil
+}
+
+func (w *MockTypescriptWatcher) Close() error {
+	w.Closed = true
+	return nil
+}
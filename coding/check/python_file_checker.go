@@ -0,0 +1,127 @@
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"sidekick/env"
+	"sidekick/utils"
+)
+
+// errors about imports that can't be resolved are left out: they usually
+// mean a dependency isn't installed in the environment rather than a bad edit
+var pyrightIgnoredRules = []string{"reportMissingImports", "reportMissingModuleSource"}
+var mypyIgnoredCodes = []string{"import", "import-not-found", "import-untyped"}
+
+// mypyErrorRegex matches mypy errors, eg:
+//
+//	app/main.py:12: error: Incompatible types in assignment  [assignment]
+var mypyErrorRegex = regexp.MustCompile(`^(.+?):(\d+):(?:\d+:)? error: (.*?)(?:  \[([a-z-]+)\])?$`)
+
+// CheckViaPythonTypeChecker type checks a Python file with pyright or mypy,
+// preferring those installed in the project's virtualenv. Imported modules
+// are analyzed as needed but only errors in the given file are reported.
+func CheckViaPythonTypeChecker(ctx context.Context, envContainer env.EnvContainer, relativeFilePath string) (BuiltinCheckResult, error) {
+	command, err := findTool(ctx, envContainer, "",
+		"./.venv/bin/pyright", "./venv/bin/pyright", "./.venv/bin/mypy", "./venv/bin/mypy",
+		"pyright", "mypy")
+	if err != nil {
+		return BuiltinCheckResult{}, err
+	}
+	if command == "" {
+		return BuiltinCheckResult{Passed: true, Output: "Skipped: neither pyright nor mypy is installed"}, nil
+	}
+
+	tool := filepath.Base(command)
+	var args []string
+	if tool == "pyright" {
+		args = []string{"--outputjson", relativeFilePath}
+	} else {
+		// mypy's cache makes repeated runs incremental
+		args = []string{"--follow-imports=silent", "--show-error-codes", "--no-error-summary", "--no-color-output", "--hide-error-context", relativeFilePath}
+	}
+	result, err := envContainer.Env.RunCommand(ctx, env.EnvRunCommandInput{
+		Command: command,
+		Args:    args,
+	})
+	if err != nil {
+		return BuiltinCheckResult{Tool: tool}, fmt.Errorf("failed to run %s: %w", tool, err)
+	}
+
+	var errorLines []string
+	if tool == "pyright" {
+		errorLines, err = parsePyrightErrors(result.Stdout)
+		if err != nil {
+			// pyright only prints invalid json when it fails to run at all
+			return BuiltinCheckResult{Tool: tool, Passed: true, Output: fmt.Sprintf("Skipped: %v", err)}, nil
+		}
+	} else {
+		errorLines = parseMypyErrors(result.Stdout)
+	}
+	if len(errorLines) == 0 {
+		return BuiltinCheckResult{Tool: tool, Passed: true}, nil
+	}
+
+	errorLines = utils.Map(errorLines, func(line string) string {
+		return strings.ReplaceAll(line, envContainer.Env.GetWorkingDirectory()+string(filepath.Separator), "")
+	})
+	return BuiltinCheckResult{
+		Tool:   tool,
+		Passed: false,
+		Output: fmt.Sprintf("%s type errors:\n%s", tool, strings.Join(errorLines, "\n")),
+		Errors: errorLines,
+	}, nil
+}
+
+type pyrightOutput struct {
+	GeneralDiagnostics []struct {
+		File     string `json:"file"`
+		Severity string `json:"severity"`
+		Message  string `json:"message"`
+		Rule     string `json:"rule"`
+		Range    struct {
+			Start struct {
+				Line      int `json:"line"`
+				Character int `json:"character"`
+			} `json:"start"`
+		} `json:"range"`
+	} `json:"generalDiagnostics"`
+}
+
+func parsePyrightErrors(stdout string) ([]string, error) {
+	var output pyrightOutput
+	if err := json.Unmarshal([]byte(stdout), &output); err != nil {
+		return nil, fmt.Errorf("failed to parse pyright output: %w", err)
+	}
+
+	var errorLines []string
+	for _, diagnostic := range output.GeneralDiagnostics {
+		if diagnostic.Severity != "error" || slices.Contains(pyrightIgnoredRules, diagnostic.Rule) {
+			continue
+		}
+		// pyright's lines and characters are zero-based
+		line := fmt.Sprintf("%s:%d:%d: error: %s", diagnostic.File, diagnostic.Range.Start.Line+1, diagnostic.Range.Start.Character+1, diagnostic.Message)
+		if diagnostic.Rule != "" {
+			line += fmt.Sprintf(" (%s)", diagnostic.Rule)
+		}
+		errorLines = append(errorLines, line)
+	}
+	return errorLines, nil
+}
+
+func parseMypyErrors(stdout string) []string {
+	var errorLines []string
+	for _, line := range strings.Split(stdout, "\n") {
+		match := mypyErrorRegex.FindStringSubmatch(line)
+		if match == nil || slices.Contains(mypyIgnoredCodes, match[4]) {
+			continue
+		}
+		errorLines = append(errorLines, line)
+	}
+	return errorLines
+}
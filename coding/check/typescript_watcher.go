@@ -0,0 +1,445 @@
+package check
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sidekick/common"
+	"sidekick/env"
+	"sidekick/utils"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Diagnostic is a single error reported by a type checker
+type Diagnostic struct {
+	// FilePath is relative to the repo root
+	FilePath string `json:"filePath"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s(%d,%d): error %s: %s", d.FilePath, d.Line, d.Column, d.Code, d.Message)
+}
+
+// TypescriptWatcher is a long-lived type checker for a typescript project that
+// recompiles whenever files change
+type TypescriptWatcher interface {
+	// Tool is the type checker being run, eg "tsc" or "vue-tsc"
+	Tool() string
+	// Diagnostics waits for the project to finish compiling, including a
+	// compilation triggered by any change made at or after changedAt, when it
+	// isn't zero. File paths are relative to the project directory.
+	Diagnostics(ctx context.Context, changedAt time.Time) ([]Diagnostic, error)
+	Close() error
+}
+
+var (
+	tscDiagnosticRegex       = regexp.MustCompile(`^(.+?)\((\d+),(\d+)\): error (TS\d+): (.*)$`)
+	tscCompilationStartRegex = regexp.MustCompile(`Starting (compilation in watch mode|incremental compilation)`)
+	tscCompilationEndRegex   = regexp.MustCompile(`Watching for file changes\.`)
+)
+
+const (
+	// tsc waits a moment after a file changes before recompiling
+	tscChangeDetectionTimeout = 3 * time.Second
+	// changes in quick succession can trigger a compilation right after the
+	// previous one finished, which is waited for too
+	tscSettleDuration = 500 * time.Millisecond
+)
+
+type tscWatcher struct {
+	tool string
+	cmd  *exec.Cmd
+
+	mu          sync.Mutex
+	changed     chan struct{}
+	compiling   bool
+	compiled    bool
+	lastStart   time.Time
+	pending     []Diagnostic
+	diagnostics []Diagnostic
+	exited      bool
+}
+
+// StartTscWatcher runs `tsc --watch` for the typescript project in the given
+// directory, or `vue-tsc --watch` if the project depends on vue-tsc. The
+// project's own type checker in node_modules is preferred over one in the
+// PATH. Nil is returned if neither is installed.
+func StartTscWatcher(projectDir string) (TypescriptWatcher, error) {
+	command, tool := findTsc(projectDir)
+	if command == "" {
+		return nil, nil
+	}
+
+	cmd := exec.Command(command, "--watch", "--noEmit", "--pretty", "false", "--preserveWatchOutput")
+	cmd.Dir = projectDir
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("cmd.StdoutPipe() failed: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", tool, err)
+	}
+	w := newTscWatcher(tool, stdout)
+	w.cmd = cmd
+	return w, nil
+}
+
+func findTsc(projectDir string) (string, string) {
+	for _, tool := range []string{"vue-tsc", "tsc"} {
+		dir := projectDir
+		for {
+			path := filepath.Join(dir, "node_modules", ".bin", tool)
+			if _, err := os.Stat(path); err == nil {
+				return path, tool
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+	if path, err := exec.LookPath("tsc"); err == nil {
+		return path, "tsc"
+	}
+	return "", ""
+}
+
+func newTscWatcher(tool string, output io.Reader) *tscWatcher {
+	w := &tscWatcher{tool: tool, changed: make(chan struct{})}
+	go w.read(output)
+	return w
+}
+
+func (w *tscWatcher) Tool() string {
+	return w.tool
+}
+
+func (w *tscWatcher) read(output io.Reader) {
+	scanner := bufio.NewScanner(output)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		w.handleLine(scanner.Text())
+	}
+	if w.cmd != nil {
+		_ = w.cmd.Wait()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.exited = true
+	w.notifyLocked()
+}
+
+func (w *tscWatcher) handleLine(line string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch {
+	case tscCompilationStartRegex.MatchString(line):
+		w.compiling = true
+		w.lastStart = time.Now()
+		w.pending = nil
+	case tscCompilationEndRegex.MatchString(line):
+		w.compiling = false
+		w.compiled = true
+		w.diagnostics = w.pending
+		w.pending = nil
+	case tscDiagnosticRegex.MatchString(line):
+		match := tscDiagnosticRegex.FindStringSubmatch(line)
+		lineNumber, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		w.pending = append(w.pending, Diagnostic{
+			FilePath: filepath.Clean(match[1]),
+			Line:     lineNumber,
+			Column:   column,
+			Code:     match[4],
+			Message:  match[5],
+		})
+		return
+	case strings.HasPrefix(line, "  ") && len(w.pending) > 0:
+		// elaboration of the previous diagnostic's message
+		w.pending[len(w.pending)-1].Message += "\n" + line
+		return
+	default:
+		return
+	}
+	w.notifyLocked()
+}
+
+func (w *tscWatcher) notifyLocked() {
+	close(w.changed)
+	w.changed = make(chan struct{})
+}
+
+func (w *tscWatcher) Diagnostics(ctx context.Context, changedAt time.Time) ([]Diagnostic, error) {
+	var settleUntil time.Time
+	for {
+		w.mu.Lock()
+		if w.exited {
+			w.mu.Unlock()
+			return nil, fmt.Errorf("%s exited", w.tool)
+		}
+		now := time.Now()
+		var deadline time.Time
+		switch {
+		case w.compiling || !w.compiled:
+			// wait for the compilation to finish
+		case !changedAt.IsZero() && w.lastStart.Before(changedAt):
+			deadline = changedAt.Add(tscChangeDetectionTimeout)
+		default:
+			if settleUntil.IsZero() {
+				settleUntil = now.Add(tscSettleDuration)
+			}
+			deadline = settleUntil
+		}
+		if !deadline.IsZero() && !now.Before(deadline) {
+			// no compilation was triggered, eg a file outside the project
+			// changed, so the last diagnostics are still current
+			diagnostics := slices.Clone(w.diagnostics)
+			w.mu.Unlock()
+			return diagnostics, nil
+		}
+		changed := w.changed
+		w.mu.Unlock()
+
+		var timer <-chan time.Time
+		if !deadline.IsZero() {
+			timer = time.After(deadline.Sub(now))
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-changed:
+			settleUntil = time.Time{}
+		case <-timer:
+		}
+	}
+}
+
+func (w *tscWatcher) Close() error {
+	if w.cmd == nil || w.cmd.Process == nil {
+		return nil
+	}
+	return w.cmd.Process.Kill()
+}
+
+// TypescriptWatchers keeps a type checker running for each typescript project
+// that is edited, so each edit only costs an incremental compilation
+type TypescriptWatchers struct {
+	// WatcherProvider starts a watcher for the given project directory,
+	// returning nil if no type checker is installed
+	WatcherProvider       func(projectDir string) (TypescriptWatcher, error)
+	StartedWatchers       map[string]TypescriptWatcher
+	InitializationLockers sync.Map
+	mu                    sync.Mutex
+}
+
+func (tw *TypescriptWatchers) findOrStartWatcher(projectDir string) (TypescriptWatcher, error) {
+	// start a watcher once per project directory
+	locker, _ := tw.InitializationLockers.LoadOrStore(projectDir, &sync.Mutex{})
+	locker.(*sync.Mutex).Lock()
+	defer locker.(*sync.Mutex).Unlock()
+
+	tw.mu.Lock()
+	if tw.StartedWatchers == nil {
+		tw.StartedWatchers = make(map[string]TypescriptWatcher)
+	}
+	watcher, ok := tw.StartedWatchers[projectDir]
+	tw.mu.Unlock()
+	if ok {
+		return watcher, nil
+	}
+
+	tw.closeRemovedProjectWatchers()
+	watcher, err := tw.WatcherProvider(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	// a missing type checker is remembered too, to avoid looking for it again
+	tw.mu.Lock()
+	tw.StartedWatchers[projectDir] = watcher
+	tw.mu.Unlock()
+	return watcher, nil
+}
+
+func (tw *TypescriptWatchers) forgetWatcher(projectDir string, watcher TypescriptWatcher) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.StartedWatchers[projectDir] == watcher {
+		delete(tw.StartedWatchers, projectDir)
+	}
+}
+
+// Close stops all the type checkers that were started, eg when the worker
+// shuts down
+func (tw *TypescriptWatchers) Close() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	for projectDir, watcher := range tw.StartedWatchers {
+		if watcher != nil {
+			if err := watcher.Close(); err != nil {
+				log.Warn().Err(err).Str("projectDir", projectDir).Msg("Failed to stop typescript watcher")
+			}
+		}
+		delete(tw.StartedWatchers, projectDir)
+	}
+}
+
+// closeRemovedProjectWatchers stops watching projects whose directory no
+// longer exists, eg because their worktree was removed
+func (tw *TypescriptWatchers) closeRemovedProjectWatchers() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	for projectDir, watcher := range tw.StartedWatchers {
+		if _, err := os.Stat(projectDir); os.IsNotExist(err) {
+			if watcher != nil {
+				if err := watcher.Close(); err != nil {
+					log.Warn().Err(err).Str("projectDir", projectDir).Msg("Failed to stop typescript watcher")
+				}
+			}
+			delete(tw.StartedWatchers, projectDir)
+		}
+	}
+}
+
+// TypescriptCheck compares a file's type errors after an edit to those from
+// just before it, so that pre-existing errors don't fail the edit
+type TypescriptCheck struct {
+	watcher    TypescriptWatcher
+	workingDir string
+	projectDir string
+	baseline   []Diagnostic
+	changedAt  time.Time
+	// deadline bounds both preparing the check and checking the edit
+	deadline time.Time
+}
+
+// PrepareCheck gets the current type errors in a typescript or vue file, to be
+// called just before editing it. Nil is returned when the file's language
+// isn't enabled in the config, no type checker is installed or the project
+// takes too long to compile. The timeout covers both this and checking the
+// edited file, and is cut short to finish before the activity's deadline.
+func (tw *TypescriptWatchers) PrepareCheck(ctx context.Context, envContainer env.EnvContainer, relativeFilePath string, config common.BuiltinChecksConfig) (*TypescriptCheck, error) {
+	languageName := utils.InferLanguageNameFromFilePath(relativeFilePath)
+	if languageName == "tsx" {
+		languageName = "typescript"
+	}
+	if (languageName != "typescript" && languageName != "vue") || !slices.Contains(config.Languages, languageName) {
+		return nil, nil
+	}
+	projectDir, _, ok := findProjectDir(envContainer, relativeFilePath, "tsconfig.json")
+	if !ok {
+		return nil, nil
+	}
+
+	absoluteProjectDir := filepath.Join(envContainer.Env.GetWorkingDirectory(), projectDir)
+	watcher, err := tw.findOrStartWatcher(absoluteProjectDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start typescript watcher: %w", err)
+	}
+	// plain tsc doesn't check vue files
+	if watcher == nil || (languageName == "vue" && watcher.Tool() != "vue-tsc") {
+		return nil, nil
+	}
+
+	timeout := defaultBuiltinCheckTimeout
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	if remaining, ok := timeUntilActivityDeadline(ctx); ok && remaining < timeout {
+		timeout = remaining
+	}
+	if timeout <= 0 {
+		log.Warn().Str("filePath", relativeFilePath).Msgf("Skipping %s check: not enough time left before the activity deadline", watcher.Tool())
+		return nil, nil
+	}
+	deadline := time.Now().Add(timeout)
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	diagnostics, err := watcher.Diagnostics(ctx, time.Time{})
+	if err != nil {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			// the type checker crashed, so start it again for the next edit
+			tw.forgetWatcher(absoluteProjectDir, watcher)
+		}
+		log.Warn().Err(err).Str("filePath", relativeFilePath).Msgf("Skipping %s check", watcher.Tool())
+		return nil, nil
+	}
+
+	check := &TypescriptCheck{
+		watcher:    watcher,
+		workingDir: envContainer.Env.GetWorkingDirectory(),
+		projectDir: projectDir,
+		deadline:   deadline,
+		changedAt:  time.Now(),
+	}
+	check.baseline = check.fileDiagnostics(diagnostics, relativeFilePath)
+	return check, nil
+}
+
+// newDiagnostics waits for the project to recompile after the file was
+// edited, returning the errors in the file that weren't there before the edit
+func (tc *TypescriptCheck) newDiagnostics(ctx context.Context, relativeFilePath string) ([]Diagnostic, error) {
+	ctx, cancel := context.WithDeadline(ctx, tc.deadline)
+	defer cancel()
+	diagnostics, err := tc.watcher.Diagnostics(ctx, tc.changedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// errors are matched by code and message, since the edit may move them
+	// to other lines
+	key := func(d Diagnostic) string { return d.Code + ":" + d.Message }
+	remaining := make(map[string]int)
+	for _, d := range tc.baseline {
+		remaining[key(d)]++
+	}
+	var newDiagnostics []Diagnostic
+	for _, d := range tc.fileDiagnostics(diagnostics, relativeFilePath) {
+		if remaining[key(d)] > 0 {
+			remaining[key(d)]--
+			continue
+		}
+		newDiagnostics = append(newDiagnostics, d)
+	}
+	return newDiagnostics, nil
+}
+
+// fileDiagnostics filters diagnostics to those for the given file, with paths
+// relative to the repo root
+func (tc *TypescriptCheck) fileDiagnostics(diagnostics []Diagnostic, relativeFilePath string) []Diagnostic {
+	var fileDiagnostics []Diagnostic
+	for _, d := range diagnostics {
+		if filepath.IsAbs(d.FilePath) {
+			relativePath, err := filepath.Rel(tc.workingDir, d.FilePath)
+			if err != nil {
+				continue
+			}
+			d.FilePath = relativePath
+		} else {
+			d.FilePath = filepath.Join(tc.projectDir, d.FilePath)
+		}
+		if d.FilePath == filepath.Clean(relativeFilePath) {
+			fileDiagnostics = append(fileDiagnostics, d)
+		}
+	}
+	return fileDiagnostics
+}
@@ -0,0 +1,168 @@
+package check
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sidekick/common"
+	"sidekick/env"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTscWatcher(t *testing.T) {
+	t.Parallel()
+	reader, writer := io.Pipe()
+	watcher := newTscWatcher("tsc", reader)
+	write := func(lines ...string) {
+		for _, line := range lines {
+			_, err := writer.Write([]byte(line + "\n"))
+			require.NoError(t, err)
+		}
+	}
+
+	go write(
+		"10:00:00 AM - Starting compilation in watch mode...",
+		"src/a.ts(1,7): error TS2322: Type 'string' is not assignable to type 'number'.",
+		"src/b.ts(3,1): error TS2345: Argument of type 'Foo' is not assignable to parameter of type 'Bar'.",
+		"  Property 'baz' is missing in type 'Foo' but required in type 'Bar'.",
+		"10:00:02 AM - Found 2 errors. Watching for file changes.",
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	diagnostics, err := watcher.Diagnostics(ctx, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []Diagnostic{
+		{FilePath: "src/a.ts", Line: 1, Column: 7, Code: "TS2322", Message: "Type 'string' is not assignable to type 'number'."},
+		{FilePath: "src/b.ts", Line: 3, Column: 1, Code: "TS2345", Message: "Argument of type 'Foo' is not assignable to parameter of type 'Bar'.\n  Property 'baz' is missing in type 'Foo' but required in type 'Bar'."},
+	}, diagnostics)
+
+	changedAt := time.Now()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		write(
+			"10:00:05 AM - File change detected. Starting incremental compilation...",
+			"src/a.ts(2,1): error TS2304: Cannot find name 'foo'.",
+			"10:00:06 AM - Found 1 error. Watching for file changes.",
+		)
+	}()
+	diagnostics, err = watcher.Diagnostics(ctx, changedAt)
+	require.NoError(t, err)
+	assert.Equal(t, []Diagnostic{
+		{FilePath: "src/a.ts", Line: 2, Column: 1, Code: "TS2304", Message: "Cannot find name 'foo'."},
+	}, diagnostics)
+
+	require.NoError(t, writer.Close())
+	_, err = watcher.Diagnostics(ctx, time.Time{})
+	assert.ErrorContains(t, err, "tsc exited")
+}
+
+func TestTypescriptCheck(t *testing.T) {
+	dir := t.TempDir()
+	writeCheckerTestFile(t, filepath.Join(dir, "frontend", "tsconfig.json"), "{}", 0644)
+	writeCheckerTestFile(t, filepath.Join(dir, "frontend", "src", "a.ts"), "const a: number = 'a'\nfoo()\n", 0644)
+	writeCheckerTestFile(t, filepath.Join(dir, "frontend", "src", "App.vue"), "<template><div/></template>\n", 0644)
+	envContainer := env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: dir}}
+	config := common.BuiltinChecksConfig{Languages: []string{"typescript", "vue"}}
+
+	preExisting := Diagnostic{FilePath: "src/a.ts", Line: 1, Column: 7, Code: "TS2322", Message: "Type 'string' is not assignable to type 'number'."}
+	otherFile := Diagnostic{FilePath: "src/b.ts", Line: 1, Column: 1, Code: "TS2304", Message: "Cannot find name 'bar'."}
+	introduced := Diagnostic{FilePath: "src/a.ts", Line: 2, Column: 1, Code: "TS2304", Message: "Cannot find name 'foo'."}
+	watcher := &MockTypescriptWatcher{Results: [][]Diagnostic{
+		{preExisting, otherFile},
+		// the pre-existing error moved down a line
+		{introduced, {FilePath: "src/a.ts", Line: 2, Column: 7, Code: preExisting.Code, Message: preExisting.Message}, otherFile},
+	}}
+	var startedDirs []string
+	watchers := &TypescriptWatchers{WatcherProvider: func(projectDir string) (TypescriptWatcher, error) {
+		startedDirs = append(startedDirs, projectDir)
+		return watcher, nil
+	}}
+
+	typescriptCheck, err := watchers.PrepareCheck(context.Background(), envContainer, "frontend/src/a.ts", config)
+	require.NoError(t, err)
+	require.NotNil(t, typescriptCheck)
+	output, err := CheckFileActivity(context.Background(), CheckFileActivityInput{
+		EnvContainer: envContainer,
+		FilePath:     "frontend/src/a.ts",
+		Typescript:   typescriptCheck,
+	})
+	require.NoError(t, err)
+	assert.False(t, output.AllPassed)
+	assert.False(t, output.CheckPassed["tsc"])
+	introduced.FilePath = "frontend/src/a.ts"
+	assert.Equal(t, []Diagnostic{introduced}, output.Diagnostics)
+	assert.Contains(t, output.Output, "frontend/src/a.ts(2,1): error TS2304: Cannot find name 'foo'.")
+
+	t.Run("reuses the project's watcher", func(t *testing.T) {
+		typescriptCheck, err := watchers.PrepareCheck(context.Background(), envContainer, "frontend/src/a.ts", config)
+		require.NoError(t, err)
+		assert.NotNil(t, typescriptCheck)
+		assert.Equal(t, []string{filepath.Join(dir, "frontend")}, startedDirs)
+	})
+
+	t.Run("skips vue files without vue-tsc", func(t *testing.T) {
+		typescriptCheck, err := watchers.PrepareCheck(context.Background(), envContainer, "frontend/src/App.vue", config)
+		require.NoError(t, err)
+		assert.Nil(t, typescriptCheck)
+	})
+
+	t.Run("skips disabled languages", func(t *testing.T) {
+		typescriptCheck, err := watchers.PrepareCheck(context.Background(), envContainer, "frontend/src/a.ts", common.BuiltinChecksConfig{Languages: []string{"vue"}})
+		require.NoError(t, err)
+		assert.Nil(t, typescriptCheck)
+	})
+
+	t.Run("skips when no type checker is installed", func(t *testing.T) {
+		otherDir := t.TempDir()
+		writeCheckerTestFile(t, filepath.Join(otherDir, "tsconfig.json"), "{}", 0644)
+		writeCheckerTestFile(t, filepath.Join(otherDir, "a.ts"), "", 0644)
+		watchers := &TypescriptWatchers{WatcherProvider: func(projectDir string) (TypescriptWatcher, error) {
+			return nil, nil
+		}}
+		typescriptCheck, err := watchers.PrepareCheck(context.Background(), env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: otherDir}}, "a.ts", config)
+		require.NoError(t, err)
+		assert.Nil(t, typescriptCheck)
+	})
+
+	t.Run("stops watching removed projects", func(t *testing.T) {
+		removedDir := t.TempDir()
+		removedWatcher := &MockTypescriptWatcher{}
+		watchers.StartedWatchers[removedDir] = removedWatcher
+		require.NoError(t, os.RemoveAll(removedDir))
+
+		watchers.closeRemovedProjectWatchers()
+		assert.True(t, removedWatcher.Closed)
+		assert.NotContains(t, watchers.StartedWatchers, removedDir)
+		assert.Contains(t, watchers.StartedWatchers, filepath.Join(dir, "frontend"))
+	})
+
+	t.Run("skips when the activity deadline is too close", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), activityDeadlineMargin/2)
+		defer cancel()
+		typescriptCheck, err := watchers.PrepareCheck(ctx, envContainer, "frontend/src/a.ts", config)
+		require.NoError(t, err)
+		assert.Nil(t, typescriptCheck)
+	})
+
+	t.Run("checking the edit shares the prepared check's deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), activityDeadlineMargin+time.Minute)
+		defer cancel()
+		typescriptCheck, err := watchers.PrepareCheck(ctx, envContainer, "frontend/src/a.ts", config)
+		require.NoError(t, err)
+		require.NotNil(t, typescriptCheck)
+		activityDeadline, _ := ctx.Deadline()
+		assert.WithinDuration(t, activityDeadline.Add(-activityDeadlineMargin), typescriptCheck.deadline, time.Second)
+	})
+
+	t.Run("stops all watchers when closed", func(t *testing.T) {
+		watchers.Close()
+		assert.True(t, watcher.Closed)
+		assert.Empty(t, watchers.StartedWatchers)
+	})
+}
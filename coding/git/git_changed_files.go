@@ -0,0 +1,44 @@
+package git
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"sidekick/env"
+)
+
+// GitChangedFilesActivity returns the sorted paths, relative to the repo root,
+// of files that differ from the merge base with the base branch, or from HEAD
+// when no base branch is given. Uncommitted, staged and untracked files are
+// included, as are both sides of a rename.
+func GitChangedFilesActivity(ctx context.Context, envContainer env.EnvContainer, baseBranch string) ([]string, error) {
+	base := "HEAD"
+	if baseBranch != "" {
+		mergeBase, err := runEnvGitCommand(ctx, envContainer, []string{"merge-base", baseBranch, "HEAD"})
+		if err != nil {
+			return nil, err
+		}
+		base = strings.TrimSpace(mergeBase)
+	}
+
+	diffOutput, err := runEnvGitCommand(ctx, envContainer, []string{"diff", "--name-only", "--no-renames", "-z", base})
+	if err != nil {
+		return nil, err
+	}
+	untrackedOutput, err := runEnvGitCommand(ctx, envContainer, []string{"ls-files", "--others", "--exclude-standard", "-z"})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var changedFiles []string
+	for _, path := range strings.Split(diffOutput+untrackedOutput, "\x00") {
+		if path != "" && !seen[path] {
+			seen[path] = true
+			changedFiles = append(changedFiles, path)
+		}
+	}
+	sort.Strings(changedFiles)
+	return changedFiles, nil
+}
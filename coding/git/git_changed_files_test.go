@@ -0,0 +1,56 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sidekick/env"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitChangedFilesActivity(t *testing.T) {
+	t.Parallel()
+	repoDir := setupTestGitRepo(t)
+	writeFile := func(path, content string) {
+		t.Helper()
+		fullPath := filepath.Join(repoDir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+		require.NoError(t, os.WriteFile(fullPath, []byte(content), 0644))
+	}
+	writeFile("api/main.go", "package main\n")
+	writeFile("web/app.ts", "export {}\n")
+	writeFile("README.md", "readme\n")
+	runGitCommandInTestRepo(t, repoDir, "add", ".")
+	runGitCommandInTestRepo(t, repoDir, "commit", "-m", "Initial commit")
+
+	runGitCommandInTestRepo(t, repoDir, "checkout", "-b", "feature")
+	writeFile("api/main.go", "package main\n\nfunc main() {}\n")
+	runGitCommandInTestRepo(t, repoDir, "commit", "-am", "Add main")
+	runGitCommandInTestRepo(t, repoDir, "mv", "README.md", "docs.md")
+	writeFile("web/new.ts", "export {}\n")
+
+	devEnv, err := env.NewLocalEnv(context.Background(), env.LocalEnvParams{RepoDir: repoDir})
+	require.NoError(t, err)
+	envContainer := env.EnvContainer{Env: devEnv}
+	ctx := context.Background()
+
+	t.Run("since base branch", func(t *testing.T) {
+		changedFiles, err := GitChangedFilesActivity(ctx, envContainer, "main")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"README.md", "api/main.go", "docs.md", "web/new.ts"}, changedFiles)
+	})
+
+	t.Run("since HEAD", func(t *testing.T) {
+		changedFiles, err := GitChangedFilesActivity(ctx, envContainer, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"README.md", "docs.md", "web/new.ts"}, changedFiles)
+	})
+
+	t.Run("missing base branch", func(t *testing.T) {
+		_, err := GitChangedFilesActivity(ctx, envContainer, "missing")
+		assert.Error(t, err)
+	})
+}
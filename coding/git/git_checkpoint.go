@@ -0,0 +1,151 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"sidekick/common"
+	"sidekick/env"
+	"strings"
+)
+
+// CheckpointRefPrefix namespaces checkpoint refs so they never collide with
+// branches or tags
+const CheckpointRefPrefix = "refs/sidekick/"
+
+// CheckpointRef returns the ref recording the checkpoint for a step of a flow
+func CheckpointRef(flowId, stepNumber string) string {
+	return fmt.Sprintf("%s%s/step-%s", CheckpointRefPrefix, flowId, stepNumber)
+}
+
+// deleteCheckpointRefs deletes the refs of all of a flow's checkpoints, running
+// git with the given function. Refs are shared by all of a repository's
+// worktrees, so they outlive the worktree the flow ran in unless deleted.
+func deleteCheckpointRefs(flowId string, runGit func(args ...string) (string, error)) error {
+	// an empty flow id would match every flow's checkpoints
+	if flowId == "" {
+		return nil
+	}
+	refs, err := runGit("for-each-ref", "--format=%(refname)", CheckpointRefPrefix+flowId+"/")
+	if err != nil {
+		return fmt.Errorf("failed to list checkpoint refs: %w", err)
+	}
+	for _, ref := range strings.Fields(refs) {
+		if _, err := runGit("update-ref", "-d", ref); err != nil {
+			return fmt.Errorf("failed to delete checkpoint ref %s: %w", ref, err)
+		}
+	}
+	return nil
+}
+
+type CreateCheckpointParams struct {
+	Ref     string
+	Message string
+
+	// Commit makes the checkpoint a commit on the current branch. Otherwise
+	// only the ref points at the checkpoint commit, leaving HEAD, the branch
+	// and the index's relationship to HEAD untouched.
+	Commit bool
+	// Config is the repo's commit settings, used when Commit is set
+	Config common.CommitConfig
+}
+
+type Checkpoint struct {
+	Ref       string `json:"ref"`
+	CommitSha string `json:"commitSha"`
+}
+
+type RollbackToCheckpointParams struct {
+	Ref string
+
+	// ResetBranch moves the current branch back to the checkpoint commit, for
+	// checkpoints created with Commit. Otherwise only the index and working
+	// tree are restored.
+	ResetBranch bool
+}
+
+// CreateCheckpointActivity stages all changes and records them as a commit
+// that the given ref points to. When there are no changes since HEAD, HEAD
+// itself is the checkpoint. Since everything is staged, this is meant for
+// worktrees that sidekick owns rather than the user's own checkout.
+func CreateCheckpointActivity(ctx context.Context, envContainer env.EnvContainer, params CreateCheckpointParams) (Checkpoint, error) {
+	if !strings.HasPrefix(params.Ref, CheckpointRefPrefix) {
+		return Checkpoint{}, fmt.Errorf("checkpoint ref must start with %s: %s", CheckpointRefPrefix, params.Ref)
+	}
+	if _, err := runEnvGitCommand(ctx, envContainer, []string{"add", "-A"}); err != nil {
+		return Checkpoint{}, err
+	}
+
+	tree, err := runEnvGitCommand(ctx, envContainer, []string{"write-tree"})
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	tree = strings.TrimSpace(tree)
+
+	// an unborn branch has no HEAD, so the checkpoint will be a root commit
+	head, _ := runEnvGitCommand(ctx, envContainer, []string{"rev-parse", "--verify", "--quiet", "HEAD"})
+	head = strings.TrimSpace(head)
+
+	var commitSha string
+	if head != "" {
+		headTree, err := runEnvGitCommand(ctx, envContainer, []string{"rev-parse", "HEAD^{tree}"})
+		if err != nil {
+			return Checkpoint{}, err
+		}
+		if strings.TrimSpace(headTree) == tree {
+			commitSha = head
+		}
+	}
+
+	if commitSha == "" {
+		if params.Commit {
+			if _, err := GitCommitActivity(ctx, envContainer, GitCommitParams{CommitMessage: params.Message, Config: params.Config}); err != nil {
+				return Checkpoint{}, err
+			}
+			commitSha, err = runEnvGitCommand(ctx, envContainer, []string{"rev-parse", "HEAD"})
+		} else {
+			args := []string{"-c", "user.name=Sidekick", "-c", "user.email=sidekick@side.dev", "commit-tree", tree, "-m", params.Message}
+			if head != "" {
+				args = append(args, "-p", head)
+			}
+			commitSha, err = runEnvGitCommand(ctx, envContainer, args)
+		}
+		if err != nil {
+			return Checkpoint{}, err
+		}
+		commitSha = strings.TrimSpace(commitSha)
+	}
+
+	if _, err := runEnvGitCommand(ctx, envContainer, []string{"update-ref", params.Ref, commitSha}); err != nil {
+		return Checkpoint{}, err
+	}
+	return Checkpoint{Ref: params.Ref, CommitSha: commitSha}, nil
+}
+
+// RollbackToCheckpointActivity restores the working tree to the state recorded
+// by a checkpoint ref, discarding all changes made since, including new
+// untracked files. Ignored files are left alone. Like checkpoints, this is
+// meant for worktrees that sidekick owns rather than the user's own checkout.
+func RollbackToCheckpointActivity(ctx context.Context, envContainer env.EnvContainer, params RollbackToCheckpointParams) (Checkpoint, error) {
+	if !strings.HasPrefix(params.Ref, CheckpointRefPrefix) {
+		return Checkpoint{}, fmt.Errorf("checkpoint ref must start with %s: %s", CheckpointRefPrefix, params.Ref)
+	}
+	commitSha, err := runEnvGitCommand(ctx, envContainer, []string{"rev-parse", "--verify", params.Ref + "^{commit}"})
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("checkpoint not found: %v", err)
+	}
+	commitSha = strings.TrimSpace(commitSha)
+
+	if params.ResetBranch {
+		_, err = runEnvGitCommand(ctx, envContainer, []string{"reset", "--hard", commitSha})
+	} else {
+		_, err = runEnvGitCommand(ctx, envContainer, []string{"read-tree", "-u", "--reset", commitSha})
+	}
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	if _, err := runEnvGitCommand(ctx, envContainer, []string{"clean", "-fd"}); err != nil {
+		return Checkpoint{}, err
+	}
+	return Checkpoint{Ref: params.Ref, CommitSha: commitSha}, nil
+}
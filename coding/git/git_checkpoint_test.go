@@ -0,0 +1,153 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sidekick/common"
+	"sidekick/env"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCheckpointTestRepo(t *testing.T) (env.EnvContainer, string) {
+	t.Helper()
+	repoDir := setupTestGitRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "a.txt"), []byte("initial\n"), 0644))
+	runGitCommandInTestRepo(t, repoDir, "add", "a.txt")
+	runGitCommandInTestRepo(t, repoDir, "commit", "-m", "Initial commit")
+
+	devEnv, err := env.NewLocalEnv(context.Background(), env.LocalEnvParams{RepoDir: repoDir})
+	require.NoError(t, err)
+	return env.EnvContainer{Env: devEnv}, repoDir
+}
+
+func readTestFile(t *testing.T, repoDir, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(repoDir, name))
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestCheckpointRef(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "refs/sidekick/flow_123/step-2", CheckpointRef("flow_123", "2"))
+}
+
+func TestCreateAndRollbackToCheckpoint_WithoutCommit(t *testing.T) {
+	t.Parallel()
+	envContainer, repoDir := setupCheckpointTestRepo(t)
+	ctx := context.Background()
+	head := runGitCommandInTestRepo(t, repoDir, "rev-parse", "HEAD")
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "a.txt"), []byte("step 1\n"), 0644))
+	ref := CheckpointRef("flow_1", "1")
+	checkpoint, err := CreateCheckpointActivity(ctx, envContainer, CreateCheckpointParams{Ref: ref, Message: "Step 1"})
+	require.NoError(t, err)
+	assert.NotEqual(t, head, checkpoint.CommitSha)
+	assert.Equal(t, checkpoint.CommitSha, runGitCommandInTestRepo(t, repoDir, "rev-parse", ref))
+	assert.Equal(t, head, runGitCommandInTestRepo(t, repoDir, "rev-parse", "HEAD"), "HEAD should not move")
+	assert.Equal(t, head, runGitCommandInTestRepo(t, repoDir, "rev-parse", ref+"^"))
+
+	// a later step modifies, deletes and adds files
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "a.txt"), []byte("step 2\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "b.txt"), []byte("new\n"), 0644))
+	runGitCommandInTestRepo(t, repoDir, "add", "-A")
+
+	rolledBack, err := RollbackToCheckpointActivity(ctx, envContainer, RollbackToCheckpointParams{Ref: ref})
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint.CommitSha, rolledBack.CommitSha)
+	assert.Equal(t, "step 1\n", readTestFile(t, repoDir, "a.txt"))
+	assert.NoFileExists(t, filepath.Join(repoDir, "b.txt"))
+	assert.Equal(t, head, runGitCommandInTestRepo(t, repoDir, "rev-parse", "HEAD"))
+	assert.Equal(t, "M  a.txt", runGitCommandInTestRepo(t, repoDir, "status", "--porcelain"))
+}
+
+func TestCreateAndRollbackToCheckpoint_WithCommit(t *testing.T) {
+	t.Parallel()
+	envContainer, repoDir := setupCheckpointTestRepo(t)
+	ctx := context.Background()
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "a.txt"), []byte("step 1\n"), 0644))
+	ref1 := CheckpointRef("flow_1", "1")
+	checkpoint1, err := CreateCheckpointActivity(ctx, envContainer, CreateCheckpointParams{Ref: ref1, Message: "Step 1", Commit: true})
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint1.CommitSha, runGitCommandInTestRepo(t, repoDir, "rev-parse", "HEAD"))
+	assert.Equal(t, "Step 1", runGitCommandInTestRepo(t, repoDir, "log", "-1", "--format=%s"))
+
+	// no changes: the checkpoint is just HEAD
+	ref2 := CheckpointRef("flow_1", "2")
+	checkpoint2, err := CreateCheckpointActivity(ctx, envContainer, CreateCheckpointParams{Ref: ref2, Message: "Step 2", Commit: true})
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint1.CommitSha, checkpoint2.CommitSha)
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "a.txt"), []byte("step 3\n"), 0644))
+	_, err = CreateCheckpointActivity(ctx, envContainer, CreateCheckpointParams{Ref: CheckpointRef("flow_1", "3"), Message: "Step 3", Commit: true})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "c.txt"), []byte("uncommitted\n"), 0644))
+
+	_, err = RollbackToCheckpointActivity(ctx, envContainer, RollbackToCheckpointParams{Ref: ref1, ResetBranch: true})
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint1.CommitSha, runGitCommandInTestRepo(t, repoDir, "rev-parse", "HEAD"))
+	assert.Equal(t, "step 1\n", readTestFile(t, repoDir, "a.txt"))
+	assert.NoFileExists(t, filepath.Join(repoDir, "c.txt"))
+	assert.Equal(t, "", runGitCommandInTestRepo(t, repoDir, "status", "--porcelain"))
+}
+
+func TestCheckpointActivities_Errors(t *testing.T) {
+	t.Parallel()
+	envContainer, _ := setupCheckpointTestRepo(t)
+	ctx := context.Background()
+
+	_, err := CreateCheckpointActivity(ctx, envContainer, CreateCheckpointParams{Ref: "refs/heads/main", Message: "x"})
+	assert.Error(t, err)
+	_, err = RollbackToCheckpointActivity(ctx, envContainer, RollbackToCheckpointParams{Ref: "refs/heads/main"})
+	assert.Error(t, err)
+	_, err = RollbackToCheckpointActivity(ctx, envContainer, RollbackToCheckpointParams{Ref: CheckpointRef("missing", "1")})
+	assert.Error(t, err)
+}
+
+func TestSquashBranchActivity(t *testing.T) {
+	t.Parallel()
+	envContainer, repoDir := setupCheckpointTestRepo(t)
+	ctx := context.Background()
+	base := runGitCommandInTestRepo(t, repoDir, "rev-parse", "HEAD")
+	runGitCommandInTestRepo(t, repoDir, "checkout", "-b", "side/feature")
+
+	// nothing to squash yet
+	require.NoError(t, SquashBranchActivity(ctx, envContainer, SquashBranchParams{BaseBranch: "main", CommitMessage: "Squashed"}))
+	assert.Equal(t, base, runGitCommandInTestRepo(t, repoDir, "rev-parse", "HEAD"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "a.txt"), []byte("step 1\n"), 0644))
+	runGitCommandInTestRepo(t, repoDir, "commit", "-am", "Step 1")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "b.txt"), []byte("step 2\n"), 0644))
+	runGitCommandInTestRepo(t, repoDir, "add", "b.txt")
+	runGitCommandInTestRepo(t, repoDir, "commit", "-m", "Step 2")
+
+	require.NoError(t, SquashBranchActivity(ctx, envContainer, SquashBranchParams{BaseBranch: "main", CommitMessage: "Squashed"}))
+	assert.Equal(t, base, runGitCommandInTestRepo(t, repoDir, "rev-parse", "HEAD^"))
+	assert.Equal(t, "Squashed", runGitCommandInTestRepo(t, repoDir, "log", "-1", "--format=%s"))
+	assert.Equal(t, "a.txt\nb.txt", runGitCommandInTestRepo(t, repoDir, "diff", "--name-only", "HEAD^", "HEAD"))
+	assert.Equal(t, "", runGitCommandInTestRepo(t, repoDir, "status", "--porcelain"))
+}
+
+func TestSquashBranchActivity_restoresBranchOnFailure(t *testing.T) {
+	t.Parallel()
+	envContainer, repoDir := setupCheckpointTestRepo(t)
+	ctx := context.Background()
+	runGitCommandInTestRepo(t, repoDir, "checkout", "-b", "side/feature")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "a.txt"), []byte("step 1\n"), 0644))
+	runGitCommandInTestRepo(t, repoDir, "commit", "-am", "Step 1")
+	head := runGitCommandInTestRepo(t, repoDir, "rev-parse", "HEAD")
+
+	err := SquashBranchActivity(ctx, envContainer, SquashBranchParams{
+		BaseBranch:    "main",
+		CommitMessage: "Squashed",
+		Config:        common.CommitConfig{Author: "not an author"},
+	})
+	require.Error(t, err)
+	assert.Equal(t, head, runGitCommandInTestRepo(t, repoDir, "rev-parse", "HEAD"))
+	assert.Equal(t, "", runGitCommandInTestRepo(t, repoDir, "status", "--porcelain"))
+}
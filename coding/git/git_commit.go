@@ -3,6 +3,7 @@ package git
 import (
 	"context"
 	"fmt"
+	"sidekick/common"
 	"sidekick/env"
 	"sidekick/flow_action"
 	"strings"
@@ -13,14 +14,51 @@ import (
 type GitCommitParams struct {
 	CommitMessage string
 	CommitAll     bool
+	// the repo's commit settings, eg for hooks and message conventions
+	Config common.CommitConfig
+}
+
+// the start of the error message when a git hook prevented a commit
+const commitHookFailedMessage = "git commit hook failed"
+
+// IsCommitHookFailure reports whether a commit failed because of a git hook,
+// rather than eg because there was nothing to commit
+func IsCommitHookFailure(err error) bool {
+	return err != nil && strings.Contains(err.Error(), commitHookFailedMessage)
 }
 
 func GitCommitActivity(ctx context.Context, envContainer env.EnvContainer, params GitCommitParams) (string, error) {
-	var gitCommitOutput env.EnvRunCommandActivityOutput
-	args := []string{"-c", "user.name='Sidekick'", "-c", "user.email='sidekick@side.dev'", "commit", "-m", params.CommitMessage}
+	message := params.CommitMessage
+	if params.Config.MessageTemplate != "" || params.Config.Conventional {
+		changedFiles := commitChangedFiles(ctx, envContainer, params.CommitAll)
+		var err error
+		message, err = formatCommitMessage(message, changedFiles, params.Config)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	args := []string{"-c", "user.name='Sidekick'", "-c", "user.email='sidekick@side.dev'"}
+	if params.Config.Author != "" {
+		name, email, err := parseCommitAuthor(params.Config.Author)
+		if err != nil {
+			return "", err
+		}
+		args = []string{"-c", "user.name=" + name, "-c", "user.email=" + email}
+	}
+	commitArgs := []string{"commit", "-m", message}
+	for _, trailer := range params.Config.Trailers {
+		commitArgs = append(commitArgs, "--trailer", trailer)
+	}
 	if params.CommitAll {
-		args = append(args, "-a")
+		commitArgs = append(commitArgs, "-a")
+	}
+	if params.Config.SkipHooks {
+		commitArgs = append(commitArgs, "--no-verify")
 	}
+	args = append(args, commitArgs...)
+
+	var gitCommitOutput env.EnvRunCommandActivityOutput
 	gitCommitOutput, err := env.EnvRunCommandActivity(ctx, env.EnvRunCommandActivityInput{
 		EnvContainer:       envContainer,
 		RelativeWorkingDir: "./",
@@ -31,12 +69,42 @@ func GitCommitActivity(ctx context.Context, envContainer env.EnvContainer, param
 		return "", fmt.Errorf("failed to git commit: %v", err)
 	}
 	if gitCommitOutput.ExitStatus != 0 {
-		return "", fmt.Errorf("git commit failed: %s", gitCommitOutput.Stdout+"\n"+gitCommitOutput.Stderr)
+		output := gitCommitOutput.Stdout + "\n" + gitCommitOutput.Stderr
+		if !params.Config.SkipHooks && commitWouldSucceedWithoutHooks(ctx, envContainer, params.CommitAll) {
+			return "", fmt.Errorf("%s: %s", commitHookFailedMessage, output)
+		}
+		return "", fmt.Errorf("git commit failed: %s", output)
 	}
 	return gitCommitOutput.Stdout, nil
 }
 
-func GitCommit(eCtx flow_action.ExecContext, commitMessage string) error {
+// commitChangedFiles lists the files a commit would include, or nothing if
+// that can't be determined
+func commitChangedFiles(ctx context.Context, envContainer env.EnvContainer, commitAll bool) []string {
+	args := []string{"diff", "--cached", "--name-only"}
+	if commitAll {
+		args = []string{"diff", "HEAD", "--name-only"}
+	}
+	output, err := runEnvGitCommand(ctx, envContainer, args)
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(output)
+}
+
+// commitWouldSucceedWithoutHooks distinguishes failing hooks from other
+// reasons a commit can fail, since git doesn't say which hook failed. A dry
+// run doesn't run hooks.
+func commitWouldSucceedWithoutHooks(ctx context.Context, envContainer env.EnvContainer, commitAll bool) bool {
+	args := []string{"commit", "--dry-run", "--no-verify", "-m", "dry run"}
+	if commitAll {
+		args = append(args, "-a")
+	}
+	_, err := runEnvGitCommand(ctx, envContainer, args)
+	return err == nil
+}
+
+func GitCommit(eCtx flow_action.ExecContext, commitMessage string, config common.CommitConfig) error {
 	diff, err := gitDiffStaged(eCtx)
 	if diff == "" && err == nil {
 		// can't commit what ain't staged (points at head)
@@ -45,6 +113,7 @@ func GitCommit(eCtx flow_action.ExecContext, commitMessage string) error {
 
 	commitParams := GitCommitParams{
 		CommitMessage: commitMessage,
+		Config:        config,
 	}
 	commitErr := workflow.ExecuteActivity(eCtx, GitCommitActivity, eCtx.EnvContainer, commitParams).Get(eCtx, nil)
 	if commitErr != nil {
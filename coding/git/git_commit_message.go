@@ -0,0 +1,147 @@
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"sidekick/common"
+)
+
+const conventionalCommitTemplate = "{{.Type}}: {{.Subject}}{{if .Body}}\n\n{{.Body}}{{end}}"
+
+// matches subjects that already follow the conventional commit format, eg
+// "fix(api)!: handle empty input"
+var conventionalSubjectRegex = regexp.MustCompile(`^([a-z]+)(\([^)]*\))?!?: (.*)$`)
+
+type commitMessageData struct {
+	Type    string
+	Subject string
+	Body    string
+	Message string
+}
+
+// formatCommitMessage applies the configured message template or conventional
+// commit format to a commit message. Merge commits are left as-is, since
+// conventional commit tooling ignores them.
+func formatCommitMessage(message string, changedFiles []string, config common.CommitConfig) (string, error) {
+	tmplText := config.MessageTemplate
+	if tmplText == "" && config.Conventional {
+		tmplText = conventionalCommitTemplate
+	}
+	if tmplText == "" || strings.HasPrefix(message, "Merge ") {
+		return message, nil
+	}
+
+	subject, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	data := commitMessageData{
+		Subject: strings.TrimSpace(subject),
+		Body:    strings.TrimSpace(body),
+		Message: message,
+	}
+	if match := conventionalSubjectRegex.FindStringSubmatch(data.Subject); match != nil {
+		data.Type = match[1]
+		data.Subject = match[3]
+	} else {
+		data.Type = inferCommitType(data.Subject, changedFiles)
+	}
+
+	tmpl, err := template.New("commit_message").Parse(tmplText)
+	if err != nil {
+		return "", fmt.Errorf("invalid commit message template: %w", err)
+	}
+	var formatted strings.Builder
+	if err := tmpl.Execute(&formatted, data); err != nil {
+		return "", fmt.Errorf("failed to execute commit message template: %w", err)
+	}
+	return formatted.String(), nil
+}
+
+// keywords at the start of a commit subject that indicate its conventional
+// commit type, checked in order
+var commitTypeKeywords = []struct {
+	commitType string
+	keywords   []string
+}{
+	{"fix", []string{"fix", "resolve", "correct", "repair", "handle", "prevent", "avoid"}},
+	{"refactor", []string{"refactor", "rename", "extract", "move", "simplify", "clean", "restructure", "reorganize", "inline"}},
+	{"perf", []string{"optimize", "speed", "improve performance", "cache"}},
+	{"docs", []string{"document", "docs"}},
+	{"test", []string{"test"}},
+	{"build", []string{"bump", "upgrade", "downgrade"}},
+	{"chore", []string{"remove", "delete", "update"}},
+	{"feat", []string{"add", "implement", "support", "create", "introduce", "allow", "enable", "show"}},
+}
+
+// inferCommitType guesses the conventional commit type of a change, first from
+// the kinds of files it touches, then from its subject, defaulting to "feat"
+func inferCommitType(subject string, changedFiles []string) string {
+	if len(changedFiles) > 0 {
+		allDocs, allTests, allCI := true, true, true
+		for _, file := range changedFiles {
+			allDocs = allDocs && isDocFile(file)
+			allTests = allTests && isTestFile(file)
+			allCI = allCI && isCIFile(file)
+		}
+		switch {
+		case allDocs:
+			return "docs"
+		case allTests:
+			return "test"
+		case allCI:
+			return "ci"
+		}
+	}
+
+	lowerSubject := strings.ToLower(subject)
+	for _, entry := range commitTypeKeywords {
+		for _, keyword := range entry.keywords {
+			if strings.HasPrefix(lowerSubject, keyword) {
+				return entry.commitType
+			}
+		}
+	}
+	return "feat"
+}
+
+func isDocFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".mdx", ".rst", ".txt", ".adoc":
+		return true
+	}
+	return strings.HasPrefix(path, "docs/")
+}
+
+func isTestFile(path string) bool {
+	base := filepath.Base(path)
+	return strings.HasSuffix(base, "_test.go") ||
+		strings.Contains(base, ".test.") ||
+		strings.Contains(base, ".spec.") ||
+		strings.HasPrefix(base, "test_") ||
+		strings.HasSuffix(strings.TrimSuffix(base, filepath.Ext(base)), "Test") ||
+		strings.HasPrefix(path, "test/") ||
+		strings.HasPrefix(path, "tests/") ||
+		strings.Contains(path, "/test/") ||
+		strings.Contains(path, "/tests/") ||
+		strings.Contains(path, "__tests__/")
+}
+
+func isCIFile(path string) bool {
+	return strings.HasPrefix(path, ".github/workflows/") ||
+		strings.HasPrefix(path, ".circleci/") ||
+		path == ".gitlab-ci.yml" ||
+		path == "Jenkinsfile"
+}
+
+// parseCommitAuthor splits an author in the "Name <email>" format
+func parseCommitAuthor(author string) (name, email string, err error) {
+	name, rest, found := strings.Cut(author, "<")
+	name = strings.TrimSpace(name)
+	email = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), ">"))
+	if !found || name == "" || email == "" || !strings.HasSuffix(strings.TrimSpace(rest), ">") {
+		return "", "", fmt.Errorf("invalid commit author %q: expected \"Name <email>\"", author)
+	}
+	return name, email, nil
+}
@@ -0,0 +1,93 @@
+package git
+
+import (
+	"testing"
+
+	"sidekick/common"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatCommitMessage(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		message      string
+		changedFiles []string
+		config       common.CommitConfig
+		expected     string
+	}{
+		{
+			name:     "no conventions",
+			message:  "Fix the thing",
+			expected: "Fix the thing",
+		},
+		{
+			name:     "conventional with body",
+			message:  "Fix empty input handling\n\nReturn early when input is empty.",
+			config:   common.CommitConfig{Conventional: true},
+			expected: "fix: Fix empty input handling\n\nReturn early when input is empty.",
+		},
+		{
+			name:         "conventional type from files",
+			message:      "Add tests for the parser",
+			changedFiles: []string{"parser/parser_test.go"},
+			config:       common.CommitConfig{Conventional: true},
+			expected:     "test: Add tests for the parser",
+		},
+		{
+			name:     "already conventional",
+			message:  "refactor(api): split handlers",
+			config:   common.CommitConfig{Conventional: true},
+			expected: "refactor: split handlers",
+		},
+		{
+			name:     "merge commits are left alone",
+			message:  "Merge branch 'main' into side/foo",
+			config:   common.CommitConfig{Conventional: true},
+			expected: "Merge branch 'main' into side/foo",
+		},
+		{
+			name:     "template",
+			message:  "Add retry flag\n\nDetails",
+			config:   common.CommitConfig{Conventional: true, MessageTemplate: "[{{.Type}}] {{.Subject}}"},
+			expected: "[feat] Add retry flag",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted, err := formatCommitMessage(tt.message, tt.changedFiles, tt.config)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, formatted)
+		})
+	}
+
+	_, err := formatCommitMessage("Fix", nil, common.CommitConfig{MessageTemplate: "{{.Type"})
+	assert.Error(t, err)
+}
+
+func TestInferCommitType(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "docs", inferCommitType("Add usage", []string{"README.md", "docs/usage.md"}))
+	assert.Equal(t, "test", inferCommitType("Cover edge cases", []string{"src/__tests__/a.ts", "b.spec.ts"}))
+	assert.Equal(t, "ci", inferCommitType("Run lint", []string{".github/workflows/ci.yml"}))
+	assert.Equal(t, "fix", inferCommitType("Resolve crash on startup", []string{"main.go", "README.md"}))
+	assert.Equal(t, "refactor", inferCommitType("Extract helper", nil))
+	assert.Equal(t, "build", inferCommitType("Bump go version", nil))
+	assert.Equal(t, "feat", inferCommitType("Add retry flag", nil))
+	assert.Equal(t, "feat", inferCommitType("Something unusual", nil))
+}
+
+func TestParseCommitAuthor(t *testing.T) {
+	t.Parallel()
+	name, email, err := parseCommitAuthor("Jane Doe <jane@example.com>")
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", name)
+	assert.Equal(t, "jane@example.com", email)
+
+	for _, invalid := range []string{"Jane Doe", "<jane@example.com>", "Jane <jane@example.com"} {
+		_, _, err := parseCommitAuthor(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
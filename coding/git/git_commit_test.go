@@ -6,10 +6,12 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sidekick/common"
 	"sidekick/env"
 	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -149,3 +151,47 @@ func createTempGitRepo(t *testing.T) (string, func()) {
 
 	return tempDir, func() {}
 }
+
+func TestGitCommitActivity_Config(t *testing.T) {
+	ctx := context.Background()
+	repoDir := setupTestGitRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "main.go"), []byte("package main"), 0644))
+	runGitCommandInTestRepo(t, repoDir, "add", ".")
+	envContainer := env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: repoDir}}
+
+	_, err := GitCommitActivity(ctx, envContainer, GitCommitParams{
+		CommitMessage: "Fix startup crash",
+		Config: common.CommitConfig{
+			Conventional: true,
+			Trailers:     []string{"Signed-off-by: Jane Doe <jane@example.com>"},
+			Author:       "Jane Doe <jane@example.com>",
+		},
+	})
+	require.NoError(t, err)
+
+	message := runGitCommandInTestRepo(t, repoDir, "log", "-1", "--pretty=%B")
+	assert.Equal(t, "fix: Fix startup crash\n\nSigned-off-by: Jane Doe <jane@example.com>", message)
+	assert.Equal(t, "Jane Doe <jane@example.com>", runGitCommandInTestRepo(t, repoDir, "log", "-1", "--pretty=%an <%ae>"))
+}
+
+func TestGitCommitActivity_Hooks(t *testing.T) {
+	ctx := context.Background()
+	repoDir := setupTestGitRepo(t)
+	hookPath := filepath.Join(repoDir, ".git", "hooks", "pre-commit")
+	require.NoError(t, os.WriteFile(hookPath, []byte("#!/bin/sh\necho 'lint failed: bad.go' >&2\nexit 1\n"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "bad.go"), []byte("package bad"), 0644))
+	runGitCommandInTestRepo(t, repoDir, "add", ".")
+	envContainer := env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: repoDir}}
+
+	_, err := GitCommitActivity(ctx, envContainer, GitCommitParams{CommitMessage: "Add bad"})
+	require.Error(t, err)
+	assert.True(t, IsCommitHookFailure(err))
+	assert.Contains(t, err.Error(), "lint failed: bad.go")
+
+	_, err = GitCommitActivity(ctx, envContainer, GitCommitParams{CommitMessage: "Add bad", Config: common.CommitConfig{SkipHooks: true}})
+	require.NoError(t, err)
+
+	_, err = GitCommitActivity(ctx, envContainer, GitCommitParams{CommitMessage: "Nothing"})
+	require.Error(t, err)
+	assert.False(t, IsCommitHookFailure(err))
+}
@@ -61,6 +61,7 @@ func GitDiffLegacy(eCtx flow_action.ExecContext) (string, error) {
 		EnvContainer:       envContainer,
 		RelativeWorkingDir: "./",
 		Command:            "sh",
+		Category:           "git",
 		Args:               []string{"-c", "git ls-files --others --exclude-standard -z | xargs -0 -n 1 git --no-pager diff /dev/null"},
 	}).Get(eCtx, &gitDiffOutput2)
 
@@ -115,6 +116,7 @@ func stagedAndOrThreeDotDiff(ctx context.Context, envContainer env.EnvContainer,
 		EnvContainer:       envContainer,
 		RelativeWorkingDir: "./",
 		Command:            "sh",
+		Category:           "git",
 		Args:               []string{"-c", shellCommand},
 	})
 	if err != nil {
@@ -164,6 +166,7 @@ func workingTreeAndUntrackedDiff(ctx context.Context, envContainer env.EnvContai
 			EnvContainer:       envContainer,
 			RelativeWorkingDir: "./",
 			Command:            "sh",
+			Category:           "git",
 			Args:               []string{"-c", scriptForUntracked},
 		})
 
@@ -0,0 +1,185 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"sidekick/env"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxHistoryOutputLength keeps history output from crowding out other
+// context when the caller doesn't specify a limit
+const DefaultMaxHistoryOutputLength = 8000
+
+const defaultGitLogMaxCount = 10
+
+type GitLogParams struct {
+	// FilePath optionally restricts the log to a file or directory
+	FilePath string
+
+	// StartLine and EndLine (1-based, inclusive) restrict the log to commits
+	// touching that line range of FilePath, including their patches
+	StartLine int
+	EndLine   int
+
+	// FunctionName restricts the log to commits touching that function in
+	// FilePath, including their patches, as determined by git's funcname
+	// matching. Ignored if a line range is given.
+	FunctionName string
+
+	MaxCount        int
+	MaxOutputLength int
+}
+
+type GitBlameParams struct {
+	FilePath        string
+	StartLine       int
+	EndLine         int
+	MaxOutputLength int
+}
+
+type GitShowParams struct {
+	// Revision is the commit to show, eg a hash or "HEAD~2"
+	Revision string
+
+	// FilePath optionally restricts the shown patch to a file or directory
+	FilePath string
+
+	MaxOutputLength int
+}
+
+// GitLogActivity lists recent commits, most recent first
+func GitLogActivity(ctx context.Context, envContainer env.EnvContainer, params GitLogParams) (string, error) {
+	maxCount := params.MaxCount
+	if maxCount <= 0 {
+		maxCount = defaultGitLogMaxCount
+	}
+	args := []string{"log", "--no-color", "--date=short", "--format=commit %h%nAuthor: %an%nDate: %ad%n%n%w(0,4,4)%B", "-n", strconv.Itoa(maxCount)}
+
+	switch {
+	case params.StartLine > 0 || params.EndLine > 0:
+		if params.FilePath == "" {
+			return "", fmt.Errorf("a file path is required to log a line range")
+		}
+		startLine, endLine, err := normalizeLineRange(params.StartLine, params.EndLine)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, fmt.Sprintf("-L%d,%d:%s", startLine, endLine, params.FilePath))
+	case params.FunctionName != "":
+		if params.FilePath == "" {
+			return "", fmt.Errorf("a file path is required to log a function")
+		}
+		args = append(args, fmt.Sprintf("-L:%s:%s", params.FunctionName, params.FilePath))
+	case params.FilePath != "":
+		args = append(args, "--stat", "--", params.FilePath)
+	}
+
+	output, err := runEnvGitCommand(ctx, envContainer, args)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(output) == "" {
+		return "No commits found.", nil
+	}
+	return TruncateGitOutput(output, params.MaxOutputLength), nil
+}
+
+// GitBlameActivity shows the commit that last changed each line in the range
+func GitBlameActivity(ctx context.Context, envContainer env.EnvContainer, params GitBlameParams) (string, error) {
+	if params.FilePath == "" {
+		return "", fmt.Errorf("a file path is required for git blame")
+	}
+	args := []string{"blame", "--date=short"}
+	if params.StartLine > 0 || params.EndLine > 0 {
+		startLine, endLine, err := normalizeLineRange(params.StartLine, params.EndLine)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, fmt.Sprintf("-L%d,%d", startLine, endLine))
+	}
+	args = append(args, "--", params.FilePath)
+
+	output, err := runEnvGitCommand(ctx, envContainer, args)
+	if err != nil {
+		return "", err
+	}
+	return TruncateGitOutput(output, params.MaxOutputLength), nil
+}
+
+// GitShowActivity shows a commit's message, a summary of changed files and
+// its patch
+func GitShowActivity(ctx context.Context, envContainer env.EnvContainer, params GitShowParams) (string, error) {
+	if params.Revision == "" {
+		return "", fmt.Errorf("a revision is required for git show")
+	}
+	if strings.HasPrefix(params.Revision, "-") {
+		return "", fmt.Errorf("invalid revision: %s", params.Revision)
+	}
+	args := []string{"show", "--no-color", "--date=short", "--stat", "--patch", params.Revision}
+	if params.FilePath != "" {
+		args = append(args, "--", params.FilePath)
+	}
+
+	output, err := runEnvGitCommand(ctx, envContainer, args)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(output) == "" {
+		return fmt.Sprintf("Commit %s doesn't change %s.", params.Revision, params.FilePath), nil
+	}
+	return TruncateGitOutput(output, params.MaxOutputLength), nil
+}
+
+func normalizeLineRange(startLine, endLine int) (int, int, error) {
+	if startLine <= 0 {
+		startLine = 1
+	}
+	if endLine <= 0 {
+		endLine = startLine
+	}
+	if endLine < startLine {
+		return 0, 0, fmt.Errorf("invalid line range: %d-%d", startLine, endLine)
+	}
+	return startLine, endLine, nil
+}
+
+func runEnvGitCommand(ctx context.Context, envContainer env.EnvContainer, args []string) (string, error) {
+	output, err := env.EnvRunCommandActivity(ctx, env.EnvRunCommandActivityInput{
+		EnvContainer:       envContainer,
+		RelativeWorkingDir: "./",
+		Command:            "git",
+		Args:               args,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to run git %s: %v", args[0], err)
+	}
+	if output.ExitStatus != 0 {
+		return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(output.Stderr))
+	}
+	return output.Stdout, nil
+}
+
+// TruncateGitOutput shortens output to at most maxLength bytes, cutting at a
+// line boundary and noting how many lines were left out. A non-positive
+// maxLength means DefaultMaxHistoryOutputLength.
+func TruncateGitOutput(output string, maxLength int) string {
+	if maxLength <= 0 {
+		maxLength = DefaultMaxHistoryOutputLength
+	}
+	output = strings.TrimRight(output, "\n")
+	if len(output) <= maxLength {
+		return output
+	}
+
+	truncated := output[:maxLength]
+	if lastNewline := strings.LastIndex(truncated, "\n"); lastNewline > 0 {
+		truncated = truncated[:lastNewline]
+	}
+	omittedLines := strings.Count(output[len(truncated):], "\n")
+	if omittedLines == 0 {
+		omittedLines = 1
+	}
+	return fmt.Sprintf("%s\n[output truncated: %d more lines]", truncated, omittedLines)
+}
@@ -0,0 +1,121 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sidekick/env"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupHistoryTestRepo(t *testing.T) (env.EnvContainer, string) {
+	t.Helper()
+	repoDir := setupTestGitRepo(t)
+	writeAndCommit := func(content, message string) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, "main.go"), []byte(content), 0644))
+		runGitCommandInTestRepo(t, repoDir, "add", "main.go")
+		runGitCommandInTestRepo(t, repoDir, "commit", "-m", message)
+	}
+	writeAndCommit("package main\n\nfunc add(a, b int) int {\n\treturn a + b\n}\n", "Add add function")
+	writeAndCommit("package main\n\nfunc add(a, b int) int {\n\t// overflow is handled by callers\n\treturn a + b\n}\n\nfunc sub(a, b int) int {\n\treturn a - b\n}\n", "Add sub function\n\nAlso document overflow handling.")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "other.txt"), []byte("other\n"), 0644))
+	runGitCommandInTestRepo(t, repoDir, "add", "other.txt")
+	runGitCommandInTestRepo(t, repoDir, "commit", "-m", "Add other file")
+
+	devEnv, err := env.NewLocalEnv(context.Background(), env.LocalEnvParams{RepoDir: repoDir})
+	require.NoError(t, err)
+	return env.EnvContainer{Env: devEnv}, repoDir
+}
+
+func TestGitLogActivity(t *testing.T) {
+	t.Parallel()
+	envContainer, _ := setupHistoryTestRepo(t)
+	ctx := context.Background()
+
+	t.Run("whole repo", func(t *testing.T) {
+		output, err := GitLogActivity(ctx, envContainer, GitLogParams{MaxCount: 2})
+		require.NoError(t, err)
+		assert.Contains(t, output, "Add other file")
+		assert.Contains(t, output, "    Also document overflow handling.")
+		assert.NotContains(t, output, "Add add function")
+	})
+
+	t.Run("file path", func(t *testing.T) {
+		output, err := GitLogActivity(ctx, envContainer, GitLogParams{FilePath: "main.go"})
+		require.NoError(t, err)
+		assert.Contains(t, output, "Add add function")
+		assert.Contains(t, output, "main.go |")
+		assert.NotContains(t, output, "Add other file")
+	})
+
+	t.Run("line range", func(t *testing.T) {
+		output, err := GitLogActivity(ctx, envContainer, GitLogParams{FilePath: "main.go", StartLine: 8, EndLine: 10})
+		require.NoError(t, err)
+		assert.Contains(t, output, "Add sub function")
+		assert.Contains(t, output, "+\treturn a - b")
+		assert.NotContains(t, output, "Add add function")
+	})
+
+	t.Run("function", func(t *testing.T) {
+		output, err := GitLogActivity(ctx, envContainer, GitLogParams{FilePath: "main.go", FunctionName: "add"})
+		require.NoError(t, err)
+		assert.Contains(t, output, "Add add function")
+		assert.Contains(t, output, "+\t// overflow is handled by callers")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := GitLogActivity(ctx, envContainer, GitLogParams{StartLine: 1})
+		assert.Error(t, err)
+		_, err = GitLogActivity(ctx, envContainer, GitLogParams{FilePath: "main.go", StartLine: 5, EndLine: 2})
+		assert.Error(t, err)
+	})
+}
+
+func TestGitBlameActivity(t *testing.T) {
+	t.Parallel()
+	envContainer, _ := setupHistoryTestRepo(t)
+	ctx := context.Background()
+
+	output, err := GitBlameActivity(ctx, envContainer, GitBlameParams{FilePath: "main.go", StartLine: 4, EndLine: 5})
+	require.NoError(t, err)
+	lines := strings.Split(output, "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "// overflow is handled by callers")
+	assert.Contains(t, lines[0], "Test User")
+	assert.Contains(t, lines[1], "return a + b")
+
+	_, err = GitBlameActivity(ctx, envContainer, GitBlameParams{FilePath: "missing.go"})
+	assert.Error(t, err)
+}
+
+func TestGitShowActivity(t *testing.T) {
+	t.Parallel()
+	envContainer, _ := setupHistoryTestRepo(t)
+	ctx := context.Background()
+
+	output, err := GitShowActivity(ctx, envContainer, GitShowParams{Revision: "HEAD~1"})
+	require.NoError(t, err)
+	assert.Contains(t, output, "Add sub function")
+	assert.Contains(t, output, "main.go | 5 +++++")
+	assert.Contains(t, output, "+func sub(a, b int) int {")
+
+	output, err = GitShowActivity(ctx, envContainer, GitShowParams{Revision: "HEAD~1", FilePath: "other.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, "Commit HEAD~1 doesn't change other.txt.", output)
+
+	_, err = GitShowActivity(ctx, envContainer, GitShowParams{Revision: "--output=/tmp/x"})
+	assert.Error(t, err)
+	_, err = GitShowActivity(ctx, envContainer, GitShowParams{Revision: "nonexistent"})
+	assert.Error(t, err)
+}
+
+func TestTruncateGitOutput(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "short", TruncateGitOutput("short\n", 100))
+	assert.Equal(t, "line1\nline2\n[output truncated: 2 more lines]", TruncateGitOutput("line1\nline2\nline3\nline4\n", 14))
+	assert.Equal(t, "abcdefghij\n[output truncated: 1 more lines]", TruncateGitOutput("abcdefghijklmnop", 10))
+}
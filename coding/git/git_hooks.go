@@ -0,0 +1,87 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sidekick/env"
+)
+
+type PreCommitHookResult struct {
+	// false when there is no pre-commit hook
+	Ran    bool
+	Passed bool
+	Output string
+}
+
+// RunPreCommitHookActivity runs the repo's pre-commit hook against all
+// current changes, as if they were about to be committed. Changes are staged
+// in a temporary copy of the index, so the real index is left untouched.
+func RunPreCommitHookActivity(ctx context.Context, envContainer env.EnvContainer) (PreCommitHookResult, error) {
+	workingDir := envContainer.Env.GetWorkingDirectory()
+	hookPath, err := runEnvGitCommand(ctx, envContainer, []string{"rev-parse", "--git-path", "hooks/pre-commit"})
+	if err != nil {
+		return PreCommitHookResult{}, err
+	}
+	hookPath = absPath(workingDir, strings.TrimSpace(hookPath))
+	if info, err := os.Stat(hookPath); err != nil || info.IsDir() || info.Mode().Perm()&0111 == 0 {
+		// git ignores hooks that aren't executable too
+		return PreCommitHookResult{}, nil
+	}
+
+	indexPath, err := runEnvGitCommand(ctx, envContainer, []string{"rev-parse", "--git-path", "index"})
+	if err != nil {
+		return PreCommitHookResult{}, err
+	}
+	tmpIndex, err := os.CreateTemp("", "sidekick-index-*")
+	if err != nil {
+		return PreCommitHookResult{}, fmt.Errorf("failed to create temporary index: %w", err)
+	}
+	tmpIndex.Close()
+	defer os.Remove(tmpIndex.Name())
+	if content, err := os.ReadFile(absPath(workingDir, strings.TrimSpace(indexPath))); err == nil {
+		if err := os.WriteFile(tmpIndex.Name(), content, 0644); err != nil {
+			return PreCommitHookResult{}, fmt.Errorf("failed to copy index: %w", err)
+		}
+	} else {
+		// an empty file isn't a valid index, but a missing one is
+		os.Remove(tmpIndex.Name())
+	}
+
+	indexEnv := []string{"GIT_INDEX_FILE=" + tmpIndex.Name()}
+	addOutput, err := envContainer.Env.RunCommand(ctx, env.EnvRunCommandInput{
+		Command: "git",
+		Args:    []string{"add", "--all"},
+		EnvVars: indexEnv,
+	})
+	if err != nil {
+		return PreCommitHookResult{}, fmt.Errorf("failed to stage changes: %w", err)
+	}
+	if addOutput.ExitStatus != 0 {
+		return PreCommitHookResult{}, fmt.Errorf("git add failed: %s", strings.TrimSpace(addOutput.Stderr))
+	}
+
+	hookOutput, err := envContainer.Env.RunCommand(ctx, env.EnvRunCommandInput{
+		Command: "git",
+		Args:    []string{"hook", "run", "--ignore-missing", "pre-commit"},
+		EnvVars: indexEnv,
+	})
+	if err != nil {
+		return PreCommitHookResult{}, fmt.Errorf("failed to run pre-commit hook: %w", err)
+	}
+	return PreCommitHookResult{
+		Ran:    true,
+		Passed: hookOutput.ExitStatus == 0,
+		Output: strings.TrimSpace(hookOutput.Stdout + "\n" + hookOutput.Stderr),
+	}, nil
+}
+
+func absPath(workingDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(workingDir, path)
+}
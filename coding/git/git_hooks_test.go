@@ -0,0 +1,51 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sidekick/env"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPreCommitHookActivity(t *testing.T) {
+	ctx := context.Background()
+	repoDir := setupTestGitRepo(t)
+	createCommit(t, repoDir, "Initial commit")
+	envContainer := env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: repoDir}}
+
+	t.Run("no hook", func(t *testing.T) {
+		result, err := RunPreCommitHookActivity(ctx, envContainer)
+		require.NoError(t, err)
+		assert.False(t, result.Ran)
+	})
+
+	// rejects any staged file named bad.go, to check that unstaged changes
+	// are staged for the hook
+	hook := "#!/bin/sh\nif git diff --cached --name-only | grep -q bad.go; then echo 'bad.go is not allowed'; exit 1; fi\n"
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, ".git", "hooks", "pre-commit"), []byte(hook), 0755))
+
+	t.Run("passing", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, "good.go"), []byte("package good"), 0644))
+		result, err := RunPreCommitHookActivity(ctx, envContainer)
+		require.NoError(t, err)
+		assert.True(t, result.Ran)
+		assert.True(t, result.Passed)
+	})
+
+	t.Run("failing", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, "bad.go"), []byte("package bad"), 0644))
+		result, err := RunPreCommitHookActivity(ctx, envContainer)
+		require.NoError(t, err)
+		assert.True(t, result.Ran)
+		assert.False(t, result.Passed)
+		assert.Contains(t, result.Output, "bad.go is not allowed")
+
+		// the real index is untouched
+		assert.Empty(t, runGitCommandInTestRepo(t, repoDir, "diff", "--cached", "--name-only"))
+	})
+}
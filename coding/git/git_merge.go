@@ -3,6 +3,7 @@ package git
 import (
 	"context"
 	"fmt"
+	"sidekick/common"
 	"sidekick/env"
 	"strings"
 )
@@ -56,6 +57,7 @@ func GitMergeActivity(ctx context.Context, envContainer env.EnvContainer, params
 		mergeOutput, mergeErr := env.EnvRunCommandActivity(ctx, env.EnvRunCommandActivityInput{
 			EnvContainer: envContainer,
 			Command:      "sh",
+			Category:     "git",
 			Args:         []string{"-c", mergeCmd},
 		})
 		if mergeErr != nil {
@@ -147,6 +149,7 @@ func GitMergeActivity(ctx context.Context, envContainer env.EnvContainer, params
 			reverseMergeOutput, reverseMergeErr := env.EnvRunCommandActivity(ctx, env.EnvRunCommandActivityInput{
 				EnvContainer: envContainer,
 				Command:      "sh",
+				Category:     "git",
 				Args:         []string{"-c", reverseMergeCmd},
 			})
 			if reverseMergeErr != nil {
@@ -178,3 +181,47 @@ func GitMergeActivity(ctx context.Context, envContainer env.EnvContainer, params
 	// resultErr is nil (unless defer sets it to a restore error).
 	return
 }
+
+type SquashBranchParams struct {
+	BaseBranch    string // the branch to be merged into, which determines which commits are squashed
+	CommitMessage string
+	Config        common.CommitConfig
+}
+
+// SquashBranchActivity replaces all commits on the current branch since it
+// diverged from the base branch with a single commit containing the same
+// changes. Any staged changes are included in that commit too. This rewrites
+// the branch's history, so it's only meant for sidekick's own worktree
+// branches, just before they are merged. If the squashed commit can't be made,
+// the branch is reset back to its original commits.
+func SquashBranchActivity(ctx context.Context, envContainer env.EnvContainer, params SquashBranchParams) (resultErr error) {
+	if params.BaseBranch == "" {
+		return fmt.Errorf("a base branch is required to squash")
+	}
+	originalHead, err := runEnvGitCommand(ctx, envContainer, []string{"rev-parse", "HEAD"})
+	if err != nil {
+		return err
+	}
+	mergeBase, err := runEnvGitCommand(ctx, envContainer, []string{"merge-base", params.BaseBranch, "HEAD"})
+	if err != nil {
+		return err
+	}
+	if _, err := runEnvGitCommand(ctx, envContainer, []string{"reset", "--soft", strings.TrimSpace(mergeBase)}); err != nil {
+		return err
+	}
+	defer func() {
+		if resultErr == nil {
+			return
+		}
+		if _, err := runEnvGitCommand(context.Background(), envContainer, []string{"reset", "--soft", strings.TrimSpace(originalHead)}); err != nil {
+			resultErr = fmt.Errorf("%w; additionally failed to restore the branch to %s: %v", resultErr, strings.TrimSpace(originalHead), err)
+		}
+	}()
+
+	// nothing to squash when the branch has no changes of its own
+	if _, err := runEnvGitCommand(ctx, envContainer, []string{"diff", "--cached", "--quiet"}); err == nil {
+		return nil
+	}
+	_, err = GitCommitActivity(ctx, envContainer, GitCommitParams{CommitMessage: params.CommitMessage, Config: params.Config})
+	return err
+}
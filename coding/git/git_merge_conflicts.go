@@ -0,0 +1,157 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sidekick/env"
+	"strings"
+)
+
+// ConflictHunk is a single conflicted region of a file, delimited by conflict
+// markers. Line numbers are 1-based and include the marker lines.
+type ConflictHunk struct {
+	StartLine   int    `json:"startLine"`
+	EndLine     int    `json:"endLine"`
+	OursLabel   string `json:"oursLabel"`
+	TheirsLabel string `json:"theirsLabel"`
+	Ours        string `json:"ours"`
+	Base        string `json:"base"` // only present for diff3-style conflicts
+	Theirs      string `json:"theirs"`
+}
+
+type ConflictedFile struct {
+	Path    string         `json:"path"`
+	Content string         `json:"content"`
+	Hunks   []ConflictHunk `json:"hunks"`
+}
+
+const (
+	conflictMarkerOurs   = "<<<<<<<"
+	conflictMarkerBase   = "|||||||"
+	conflictMarkerSplit  = "======="
+	conflictMarkerTheirs = ">>>>>>>"
+)
+
+// ParseConflictMarkers finds all complete conflict hunks in the given file
+// content. Unterminated hunks are ignored.
+func ParseConflictMarkers(content string) []ConflictHunk {
+	var hunks []ConflictHunk
+	var current *ConflictHunk
+	var ours, base, theirs []string
+	section := ""
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, conflictMarkerOurs):
+			current = &ConflictHunk{StartLine: i + 1, OursLabel: markerLabel(line)}
+			ours, base, theirs = nil, nil, nil
+			section = "ours"
+		case current == nil:
+			continue
+		case strings.HasPrefix(line, conflictMarkerBase) && section == "ours":
+			section = "base"
+		case line == conflictMarkerSplit && (section == "ours" || section == "base"):
+			section = "theirs"
+		case strings.HasPrefix(line, conflictMarkerTheirs) && section == "theirs":
+			current.EndLine = i + 1
+			current.TheirsLabel = markerLabel(line)
+			current.Ours = joinConflictLines(ours)
+			current.Base = joinConflictLines(base)
+			current.Theirs = joinConflictLines(theirs)
+			hunks = append(hunks, *current)
+			current = nil
+			section = ""
+		default:
+			switch section {
+			case "ours":
+				ours = append(ours, line)
+			case "base":
+				base = append(base, line)
+			case "theirs":
+				theirs = append(theirs, line)
+			}
+		}
+	}
+	return hunks
+}
+
+func markerLabel(line string) string {
+	return strings.TrimSpace(line[len(conflictMarkerOurs):])
+}
+
+func joinConflictLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// ListConflictedFilesActivity returns the files git considers unmerged, along
+// with the conflict hunks still present in each. A file whose conflicts were
+// resolved but not yet staged is listed with no hunks.
+func ListConflictedFilesActivity(ctx context.Context, envContainer env.EnvContainer) ([]ConflictedFile, error) {
+	output, err := runEnvGitCommand(ctx, envContainer, []string{"diff", "--name-only", "--diff-filter=U"})
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, path := range strings.Split(strings.TrimSpace(output), "\n") {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return ReadConflictedFilesActivity(ctx, envContainer, paths)
+}
+
+// ReadConflictedFilesActivity parses the conflict hunks in the given files,
+// regardless of whether git still considers them unmerged. Files that don't
+// exist, eg because one side of the merge deleted them, have no content.
+func ReadConflictedFilesActivity(ctx context.Context, envContainer env.EnvContainer, paths []string) ([]ConflictedFile, error) {
+	var conflictedFiles []ConflictedFile
+	baseDir := envContainer.Env.GetWorkingDirectory()
+	for _, path := range paths {
+		content, err := os.ReadFile(filepath.Join(baseDir, path))
+		if err != nil {
+			if os.IsNotExist(err) {
+				conflictedFiles = append(conflictedFiles, ConflictedFile{Path: path})
+				continue
+			}
+			return nil, fmt.Errorf("failed to read conflicted file %s: %v", path, err)
+		}
+		conflictedFiles = append(conflictedFiles, ConflictedFile{
+			Path:    path,
+			Content: string(content),
+			Hunks:   ParseConflictMarkers(string(content)),
+		})
+	}
+	return conflictedFiles, nil
+}
+
+// RestartMergeActivity aborts an in-progress merge and starts it over, which
+// discards any partial conflict resolution and leaves the original conflicts
+// in place again
+func RestartMergeActivity(ctx context.Context, envContainer env.EnvContainer, mergedBranch string) error {
+	if _, err := runEnvGitCommand(ctx, envContainer, []string{"merge", "--abort"}); err != nil {
+		return err
+	}
+
+	output, err := env.EnvRunCommandActivity(ctx, env.EnvRunCommandActivityInput{
+		EnvContainer:       envContainer,
+		RelativeWorkingDir: "./",
+		Command:            "git",
+		Args:               []string{"merge", "--no-edit", mergedBranch},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to run git merge: %v", err)
+	}
+	// conflicts are expected, so only a failure that didn't leave a merge in
+	// progress is an error
+	if output.ExitStatus != 0 && !strings.Contains(output.Stdout, "CONFLICT") {
+		return fmt.Errorf("git merge failed: %s", strings.TrimSpace(output.Stderr))
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sidekick/env"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConflictMarkers(t *testing.T) {
+	t.Parallel()
+
+	t.Run("two-way conflicts", func(t *testing.T) {
+		content := "a\n<<<<<<< HEAD\nours 1\nours 2\n=======\ntheirs\n>>>>>>> main\nb\n<<<<<<< HEAD\n=======\nadded\n>>>>>>> main\n"
+		hunks := ParseConflictMarkers(content)
+		require.Len(t, hunks, 2)
+		assert.Equal(t, ConflictHunk{StartLine: 2, EndLine: 7, OursLabel: "HEAD", TheirsLabel: "main", Ours: "ours 1\nours 2\n", Theirs: "theirs\n"}, hunks[0])
+		assert.Equal(t, ConflictHunk{StartLine: 9, EndLine: 12, OursLabel: "HEAD", TheirsLabel: "main", Theirs: "added\n"}, hunks[1])
+	})
+
+	t.Run("diff3 conflict", func(t *testing.T) {
+		content := "<<<<<<< ours\nx = 1\n||||||| base\nx = 0\n=======\nx = 2\n>>>>>>> theirs"
+		hunks := ParseConflictMarkers(content)
+		require.Len(t, hunks, 1)
+		assert.Equal(t, "x = 1\n", hunks[0].Ours)
+		assert.Equal(t, "x = 0\n", hunks[0].Base)
+		assert.Equal(t, "x = 2\n", hunks[0].Theirs)
+	})
+
+	t.Run("no or incomplete markers", func(t *testing.T) {
+		assert.Empty(t, ParseConflictMarkers("plain\ncontent\n"))
+		assert.Empty(t, ParseConflictMarkers("<<<<<<< HEAD\nours\n=======\ntheirs\n"))
+		assert.Empty(t, ParseConflictMarkers("=======\n>>>>>>> main\n"))
+	})
+}
+
+func setupConflictTestRepo(t *testing.T) (env.EnvContainer, string) {
+	t.Helper()
+	repoDir := setupTestGitRepo(t)
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, "file.txt"), []byte(content), 0644))
+	}
+	write("line 1\nline 2\nline 3\n")
+	runGitCommandInTestRepo(t, repoDir, "add", "file.txt")
+	runGitCommandInTestRepo(t, repoDir, "commit", "-m", "Initial commit")
+
+	runGitCommandInTestRepo(t, repoDir, "checkout", "-b", "side/feature")
+	write("line 1\nfeature line 2\nline 3\n")
+	runGitCommandInTestRepo(t, repoDir, "commit", "-am", "Feature change")
+
+	runGitCommandInTestRepo(t, repoDir, "checkout", "main")
+	write("line 1\nmain line 2\nline 3\n")
+	runGitCommandInTestRepo(t, repoDir, "commit", "-am", "Main change")
+	runGitCommandInTestRepo(t, repoDir, "checkout", "side/feature")
+
+	cmd := exec.Command("git", "merge", "main")
+	cmd.Dir = repoDir
+	require.Error(t, cmd.Run(), "merge was expected to conflict")
+
+	devEnv, err := env.NewLocalEnv(context.Background(), env.LocalEnvParams{RepoDir: repoDir})
+	require.NoError(t, err)
+	return env.EnvContainer{Env: devEnv}, repoDir
+}
+
+func TestListConflictedFilesActivity(t *testing.T) {
+	t.Parallel()
+	envContainer, repoDir := setupConflictTestRepo(t)
+	ctx := context.Background()
+
+	conflictedFiles, err := ListConflictedFilesActivity(ctx, envContainer)
+	require.NoError(t, err)
+	require.Len(t, conflictedFiles, 1)
+	assert.Equal(t, "file.txt", conflictedFiles[0].Path)
+	require.Len(t, conflictedFiles[0].Hunks, 1)
+	assert.Equal(t, "feature line 2\n", conflictedFiles[0].Hunks[0].Ours)
+	assert.Equal(t, "main line 2\n", conflictedFiles[0].Hunks[0].Theirs)
+
+	// resolved but unstaged files are still listed, without hunks
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "file.txt"), []byte("line 1\nfeature and main line 2\nline 3\n"), 0644))
+	conflictedFiles, err = ListConflictedFilesActivity(ctx, envContainer)
+	require.NoError(t, err)
+	require.Len(t, conflictedFiles, 1)
+	assert.Empty(t, conflictedFiles[0].Hunks)
+
+	runGitCommandInTestRepo(t, repoDir, "add", "file.txt")
+	conflictedFiles, err = ListConflictedFilesActivity(ctx, envContainer)
+	require.NoError(t, err)
+	assert.Empty(t, conflictedFiles)
+}
+
+func TestRestartMergeActivity(t *testing.T) {
+	t.Parallel()
+	envContainer, repoDir := setupConflictTestRepo(t)
+	ctx := context.Background()
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "file.txt"), []byte("partially resolved\n"), 0644))
+	runGitCommandInTestRepo(t, repoDir, "add", "file.txt")
+
+	require.NoError(t, RestartMergeActivity(ctx, envContainer, "main"))
+	conflictedFiles, err := ListConflictedFilesActivity(ctx, envContainer)
+	require.NoError(t, err)
+	require.Len(t, conflictedFiles, 1)
+	require.Len(t, conflictedFiles[0].Hunks, 1)
+	assert.Equal(t, "feature line 2\n", conflictedFiles[0].Hunks[0].Ours)
+
+	// restarting requires a merge in progress
+	runGitCommandInTestRepo(t, repoDir, "merge", "--abort")
+	assert.Error(t, RestartMergeActivity(ctx, envContainer, "main"))
+}
@@ -0,0 +1,47 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sidekick/env"
+	"strings"
+)
+
+type GitMvActivityInput struct {
+	EnvContainer env.EnvContainer
+	OldPath      string
+	NewPath      string
+}
+
+// GitMvActivity moves a file via git mv, so that the move is staged and git
+// history follows the file. Untracked files are moved without git. The
+// destination's parent directory is created if needed.
+func GitMvActivity(ctx context.Context, input GitMvActivityInput) error {
+	workingDir := input.EnvContainer.Env.GetWorkingDirectory()
+	newAbsPath := filepath.Join(workingDir, input.NewPath)
+	if err := os.MkdirAll(filepath.Dir(newAbsPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %v", input.NewPath, err)
+	}
+
+	gitMvOutput, err := env.EnvRunCommandActivity(ctx, env.EnvRunCommandActivityInput{
+		EnvContainer:       input.EnvContainer,
+		RelativeWorkingDir: "./",
+		Command:            "git",
+		Args:               []string{"mv", input.OldPath, input.NewPath},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to git mv: %v", err)
+	}
+	if gitMvOutput.ExitStatus != 0 {
+		if strings.Contains(gitMvOutput.Stderr, "not under version control") {
+			if err := os.Rename(filepath.Join(workingDir, input.OldPath), newAbsPath); err != nil {
+				return fmt.Errorf("failed to move untracked file: %v", err)
+			}
+			return nil
+		}
+		return fmt.Errorf("git mv failed: %s", gitMvOutput.Stdout+"\n"+gitMvOutput.Stderr)
+	}
+	return nil
+}
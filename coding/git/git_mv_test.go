@@ -0,0 +1,47 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sidekick/env"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitMvActivity(t *testing.T) {
+	t.Parallel()
+	repoDir := setupTestGitRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "tracked.txt"), []byte("tracked"), 0644))
+	runGitCommandInTestRepo(t, repoDir, "add", "tracked.txt")
+	runGitCommandInTestRepo(t, repoDir, "commit", "-m", "initial")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "untracked.txt"), []byte("untracked"), 0644))
+
+	ctx := context.Background()
+	devEnv, err := env.NewLocalEnv(ctx, env.LocalEnvParams{RepoDir: repoDir})
+	require.NoError(t, err)
+	envContainer := env.EnvContainer{Env: devEnv}
+
+	t.Run("tracked file into a new directory", func(t *testing.T) {
+		err := GitMvActivity(ctx, GitMvActivityInput{EnvContainer: envContainer, OldPath: "tracked.txt", NewPath: "nested/dir/moved.txt"})
+		require.NoError(t, err)
+		assert.NoFileExists(t, filepath.Join(repoDir, "tracked.txt"))
+		assert.FileExists(t, filepath.Join(repoDir, "nested/dir/moved.txt"))
+		status := runGitCommandInTestRepo(t, repoDir, "status", "--porcelain", "--", "tracked.txt", "nested")
+		assert.Equal(t, "R  tracked.txt -> nested/dir/moved.txt", status)
+	})
+
+	t.Run("untracked file", func(t *testing.T) {
+		err := GitMvActivity(ctx, GitMvActivityInput{EnvContainer: envContainer, OldPath: "untracked.txt", NewPath: "other/untracked.txt"})
+		require.NoError(t, err)
+		assert.NoFileExists(t, filepath.Join(repoDir, "untracked.txt"))
+		assert.FileExists(t, filepath.Join(repoDir, "other/untracked.txt"))
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		err := GitMvActivity(ctx, GitMvActivityInput{EnvContainer: envContainer, OldPath: "missing.txt", NewPath: "missing2.txt"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,117 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"sidekick/env"
+	"strings"
+)
+
+type UpdateStrategy string
+
+const (
+	UpdateStrategyMerge  UpdateStrategy = "merge"
+	UpdateStrategyRebase UpdateStrategy = "rebase"
+)
+
+type UpdateFromBaseBranchParams struct {
+	BaseBranch string
+	Strategy   UpdateStrategy // defaults to merge
+}
+
+type UpdateFromBaseBranchResult struct {
+	// false when the current branch already contains the base branch
+	Updated bool `json:"updated"`
+	// the strategy actually used, since a conflicting rebase falls back to a
+	// merge
+	Strategy UpdateStrategy `json:"strategy"`
+	// files changed on the base branch since the current branch last forked
+	// from or merged it
+	ChangedFiles []string `json:"changedFiles"`
+	// when true, a merge is in progress with the conflicts left in place
+	HasConflicts bool `json:"hasConflicts"`
+}
+
+// UpdateFromBaseBranchActivity brings the latest changes from the base branch
+// into the current branch, either by rebasing onto it or merging it in. The
+// working tree must be clean. A rebase that runs into conflicts is aborted in
+// favor of a merge, so that any conflicts can be resolved all at once.
+func UpdateFromBaseBranchActivity(ctx context.Context, envContainer env.EnvContainer, params UpdateFromBaseBranchParams) (UpdateFromBaseBranchResult, error) {
+	result := UpdateFromBaseBranchResult{Strategy: params.Strategy}
+	if result.Strategy == "" {
+		result.Strategy = UpdateStrategyMerge
+	}
+
+	status, err := runEnvGitCommand(ctx, envContainer, []string{"status", "--porcelain"})
+	if err != nil {
+		return result, err
+	}
+	if strings.TrimSpace(status) != "" {
+		return result, fmt.Errorf("cannot update from %s with uncommitted changes:\n%s", params.BaseBranch, status)
+	}
+
+	isAncestorOutput, err := runGit(ctx, envContainer, "merge-base", "--is-ancestor", params.BaseBranch, "HEAD")
+	if err != nil {
+		return result, err
+	}
+	switch isAncestorOutput.ExitStatus {
+	case 0:
+		return result, nil
+	case 1:
+		// not yet up to date
+	default:
+		return result, fmt.Errorf("failed to compare with %s: %s", params.BaseBranch, strings.TrimSpace(isAncestorOutput.Stderr))
+	}
+	result.Updated = true
+
+	changedFiles, err := runEnvGitCommand(ctx, envContainer, []string{"diff", "--name-only", "HEAD..." + params.BaseBranch})
+	if err != nil {
+		return result, err
+	}
+	for _, path := range strings.Split(strings.TrimSpace(changedFiles), "\n") {
+		if path != "" {
+			result.ChangedFiles = append(result.ChangedFiles, path)
+		}
+	}
+
+	if result.Strategy == UpdateStrategyRebase {
+		rebaseOutput, err := runGit(ctx, envContainer, "-c", "user.name=Sidekick", "-c", "user.email=sidekick@side.dev", "rebase", params.BaseBranch)
+		if err != nil {
+			return result, err
+		}
+		if rebaseOutput.ExitStatus == 0 {
+			return result, nil
+		}
+		if _, err := runEnvGitCommand(ctx, envContainer, []string{"rebase", "--abort"}); err != nil {
+			return result, fmt.Errorf("failed to abort rebase onto %s: %v", params.BaseBranch, err)
+		}
+		result.Strategy = UpdateStrategyMerge
+	}
+
+	mergeOutput, err := runGit(ctx, envContainer, "-c", "user.name=Sidekick", "-c", "user.email=sidekick@side.dev", "merge", "--no-edit", params.BaseBranch)
+	if err != nil {
+		return result, err
+	}
+	if mergeOutput.ExitStatus != 0 {
+		if !strings.Contains(mergeOutput.Stdout, "CONFLICT") {
+			return result, fmt.Errorf("git merge failed: %s", strings.TrimSpace(mergeOutput.Stdout+"\n"+mergeOutput.Stderr))
+		}
+		result.HasConflicts = true
+	}
+	return result, nil
+}
+
+// runGit runs git in the env's working directory, leaving it to the caller to
+// interpret the exit status
+func runGit(ctx context.Context, envContainer env.EnvContainer, args ...string) (env.EnvRunCommandActivityOutput, error) {
+	output, err := env.EnvRunCommandActivity(ctx, env.EnvRunCommandActivityInput{
+		EnvContainer:       envContainer,
+		RelativeWorkingDir: "./",
+		Command:            "git",
+		Args:               args,
+	})
+	if err != nil {
+		return output, fmt.Errorf("failed to run git: %v", err)
+	}
+	return output, nil
+}
@@ -0,0 +1,104 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sidekick/env"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupDivergedTestRepo creates a repo where side/feature and main have each
+// gained a commit since they forked, with side/feature checked out. The main
+// commit changes the given file to the given content.
+func setupDivergedTestRepo(t *testing.T, mainFile, mainContent string) (env.EnvContainer, string) {
+	t.Helper()
+	repoDir := setupTestGitRepo(t)
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, name), []byte(content), 0644))
+		runGitCommandInTestRepo(t, repoDir, "add", name)
+	}
+	write("file.txt", "line 1\nline 2\nline 3\n")
+	runGitCommandInTestRepo(t, repoDir, "commit", "-m", "Initial commit")
+
+	runGitCommandInTestRepo(t, repoDir, "checkout", "-b", "side/feature")
+	write("file.txt", "line 1\nfeature line 2\nline 3\n")
+	runGitCommandInTestRepo(t, repoDir, "commit", "-m", "Feature change")
+
+	runGitCommandInTestRepo(t, repoDir, "checkout", "main")
+	write(mainFile, mainContent)
+	runGitCommandInTestRepo(t, repoDir, "commit", "-m", "Main change")
+	runGitCommandInTestRepo(t, repoDir, "checkout", "side/feature")
+
+	devEnv, err := env.NewLocalEnv(context.Background(), env.LocalEnvParams{RepoDir: repoDir})
+	require.NoError(t, err)
+	return env.EnvContainer{Env: devEnv}, repoDir
+}
+
+func TestUpdateFromBaseBranchActivity(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("merge", func(t *testing.T) {
+		t.Parallel()
+		envContainer, repoDir := setupDivergedTestRepo(t, "go.sum", "dep v1\n")
+		mainHead := runGitCommandInTestRepo(t, repoDir, "rev-parse", "main")
+
+		result, err := UpdateFromBaseBranchActivity(ctx, envContainer, UpdateFromBaseBranchParams{BaseBranch: "main"})
+		require.NoError(t, err)
+		assert.Equal(t, UpdateFromBaseBranchResult{Updated: true, Strategy: UpdateStrategyMerge, ChangedFiles: []string{"go.sum"}}, result)
+		assert.Equal(t, mainHead, runGitCommandInTestRepo(t, repoDir, "rev-parse", "HEAD^2"))
+
+		// now up to date
+		result, err = UpdateFromBaseBranchActivity(ctx, envContainer, UpdateFromBaseBranchParams{BaseBranch: "main"})
+		require.NoError(t, err)
+		assert.False(t, result.Updated)
+	})
+
+	t.Run("rebase", func(t *testing.T) {
+		t.Parallel()
+		envContainer, repoDir := setupDivergedTestRepo(t, "go.sum", "dep v1\n")
+		mainHead := runGitCommandInTestRepo(t, repoDir, "rev-parse", "main")
+
+		result, err := UpdateFromBaseBranchActivity(ctx, envContainer, UpdateFromBaseBranchParams{BaseBranch: "main", Strategy: UpdateStrategyRebase})
+		require.NoError(t, err)
+		assert.Equal(t, UpdateFromBaseBranchResult{Updated: true, Strategy: UpdateStrategyRebase, ChangedFiles: []string{"go.sum"}}, result)
+		assert.Equal(t, mainHead, runGitCommandInTestRepo(t, repoDir, "rev-parse", "HEAD~1"))
+		assert.Equal(t, "Feature change", runGitCommandInTestRepo(t, repoDir, "log", "-1", "--format=%s"))
+	})
+
+	t.Run("conflicting rebase falls back to merge", func(t *testing.T) {
+		t.Parallel()
+		envContainer, repoDir := setupDivergedTestRepo(t, "file.txt", "line 1\nmain line 2\nline 3\n")
+
+		result, err := UpdateFromBaseBranchActivity(ctx, envContainer, UpdateFromBaseBranchParams{BaseBranch: "main", Strategy: UpdateStrategyRebase})
+		require.NoError(t, err)
+		assert.Equal(t, UpdateFromBaseBranchResult{Updated: true, Strategy: UpdateStrategyMerge, ChangedFiles: []string{"file.txt"}, HasConflicts: true}, result)
+		assert.Equal(t, "side/feature", runGitCommandInTestRepo(t, repoDir, "branch", "--show-current"))
+
+		conflictedFiles, err := ListConflictedFilesActivity(ctx, envContainer)
+		require.NoError(t, err)
+		require.Len(t, conflictedFiles, 1)
+		assert.Equal(t, "file.txt", conflictedFiles[0].Path)
+	})
+
+	t.Run("uncommitted changes", func(t *testing.T) {
+		t.Parallel()
+		envContainer, repoDir := setupDivergedTestRepo(t, "go.sum", "dep v1\n")
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, "new.txt"), []byte("new\n"), 0644))
+
+		_, err := UpdateFromBaseBranchActivity(ctx, envContainer, UpdateFromBaseBranchParams{BaseBranch: "main"})
+		assert.ErrorContains(t, err, "uncommitted changes")
+	})
+
+	t.Run("unknown base branch", func(t *testing.T) {
+		t.Parallel()
+		envContainer, _ := setupDivergedTestRepo(t, "go.sum", "dep v1\n")
+
+		_, err := UpdateFromBaseBranchActivity(ctx, envContainer, UpdateFromBaseBranchParams{BaseBranch: "nonexistent"})
+		assert.Error(t, err)
+	})
+}
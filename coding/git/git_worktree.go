@@ -3,6 +3,7 @@ package git
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"sidekick/env"
 	"strings"
@@ -12,7 +13,8 @@ import (
 // Before deletion, it creates an archive tag with format "archive/<branchName>" pointing to the branch.
 // This should be called after successful merges to clean up temporary worktrees.
 // The function must be run from within the worktree directory that needs to be removed.
-func CleanupWorktreeActivity(ctx context.Context, envContainer env.EnvContainer, worktreePath, branchName, archiveMessage string) error {
+// The checkpoint refs of the flow that used the worktree, if given, are deleted too.
+func CleanupWorktreeActivity(ctx context.Context, envContainer env.EnvContainer, worktreePath, branchName, archiveMessage, flowId string) error {
 	if branchName == "" {
 		return fmt.Errorf("branch name is required for cleanup")
 	}
@@ -79,6 +81,13 @@ func CleanupWorktreeActivity(ctx context.Context, envContainer env.EnvContainer,
 		return fmt.Errorf("failed to delete branch %s: %s", branchName, deleteBranchResult.Stderr)
 	}
 
+	err = deleteCheckpointRefs(flowId, func(args ...string) (string, error) {
+		return runEnvGitCommand(ctx, envContainer, args)
+	})
+	if err != nil {
+		return err
+	}
+
 	// Remove the current worktree using "." since we're running from within the worktree
 	// The working directory is the same as the worktree path that needs to be removed
 	removeResult, err := env.EnvRunCommandActivity(ctx, env.EnvRunCommandActivityInput{
@@ -161,3 +170,159 @@ func ListWorktreesActivity(ctx context.Context, repoDir string) ([]GitWorktree,
 
 	return worktrees, nil
 }
+
+// IsBranchMerged reports whether all commits on the branch are already in the
+// target branch.
+func IsBranchMerged(ctx context.Context, repoDir, branch, targetBranch string) (bool, error) {
+	_, stderr, exitCode, err := runGitCommand(ctx, repoDir, "merge-base", "--is-ancestor", branch, targetBranch)
+	switch {
+	case err == nil:
+		return true, nil
+	case exitCode == 1:
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to check if %s is merged into %s: %w\nstderr: %s", branch, targetBranch, err, stderr)
+	}
+}
+
+// IsWorktreeDirty reports whether the worktree has uncommitted changes,
+// including untracked files
+func IsWorktreeDirty(ctx context.Context, worktreePath string) (bool, error) {
+	stdout, _, _, err := runGitCommand(ctx, worktreePath, "status", "--porcelain")
+	if err != nil {
+		return false, fmt.Errorf("failed to check worktree status: %w", err)
+	}
+	return strings.TrimSpace(stdout) != "", nil
+}
+
+// IsGitWorktreeDir reports whether the directory is the root of a git
+// worktree, as opposed to a leftover directory git no longer knows about
+func IsGitWorktreeDir(path string) bool {
+	_, err := os.Stat(filepath.Join(path, ".git"))
+	return err == nil
+}
+
+type PruneWorktreeParams struct {
+	RepoDir      string
+	WorktreePath string
+	// empty if the worktree's branch no longer exists
+	Branch string
+	// when set, an "archive/<branch>" tag with this message is created before
+	// the branch is deleted, so that unmerged work can still be recovered. Any
+	// uncommitted changes are archived along with the branch. A worktree with
+	// uncommitted changes is never pruned without an archive message.
+	ArchiveMessage string
+	// the flow that used the worktree, whose checkpoint refs are deleted.
+	// Empty for worktrees sidekick doesn't track.
+	FlowId string
+}
+
+// PruneWorktree removes a worktree along with its branch and its flow's
+// checkpoint refs, whether or not its
+// directory still exists. Unlike CleanupWorktreeActivity, it runs from the
+// main repository rather than from within the worktree.
+func PruneWorktree(ctx context.Context, params PruneWorktreeParams) error {
+	worktreeExists := false
+	if params.WorktreePath != "" {
+		_, statErr := os.Stat(params.WorktreePath)
+		worktreeExists = statErr == nil
+	}
+
+	archiveName := params.Branch
+	archiveTarget := params.Branch
+	if worktreeExists && IsGitWorktreeDir(params.WorktreePath) {
+		dirty, err := IsWorktreeDirty(ctx, params.WorktreePath)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			if params.ArchiveMessage == "" {
+				return fmt.Errorf("worktree %s has uncommitted changes", params.WorktreePath)
+			}
+			archiveTarget, err = snapshotWorktreeChanges(ctx, params.WorktreePath, params.ArchiveMessage)
+			if err != nil {
+				return err
+			}
+			if archiveName == "" {
+				archiveName = filepath.Base(params.WorktreePath)
+			}
+		}
+	}
+
+	if archiveTarget != "" && params.ArchiveMessage != "" {
+		tagName, err := unusedArchiveTagName(ctx, params.RepoDir, archiveName)
+		if err != nil {
+			return err
+		}
+		if _, stderr, _, err := runGitCommand(ctx, params.RepoDir, "tag", "-m", params.ArchiveMessage, tagName, archiveTarget); err != nil {
+			return fmt.Errorf("failed to create archive tag %s: %w\nstderr: %s", tagName, err, stderr)
+		}
+	}
+
+	if worktreeExists {
+		// a directory git doesn't know as a worktree is removed directly
+		if _, _, _, err := runGitCommand(ctx, params.RepoDir, "worktree", "remove", "--force", params.WorktreePath); err != nil {
+			if err := os.RemoveAll(params.WorktreePath); err != nil {
+				return fmt.Errorf("failed to remove worktree directory %s: %w", params.WorktreePath, err)
+			}
+		}
+	}
+
+	// clears git's records of worktrees whose directories are gone, which
+	// would otherwise keep their branches from being deleted
+	if _, stderr, _, err := runGitCommand(ctx, params.RepoDir, "worktree", "prune"); err != nil {
+		return fmt.Errorf("failed to prune worktrees: %w\nstderr: %s", err, stderr)
+	}
+
+	if params.Branch != "" {
+		if _, stderr, _, err := runGitCommand(ctx, params.RepoDir, "branch", "-D", params.Branch); err != nil {
+			return fmt.Errorf("failed to delete branch %s: %w\nstderr: %s", params.Branch, err, stderr)
+		}
+	}
+
+	return deleteCheckpointRefs(params.FlowId, func(args ...string) (string, error) {
+		stdout, stderr, _, err := runGitCommand(ctx, params.RepoDir, args...)
+		if err != nil {
+			return "", fmt.Errorf("%w\nstderr: %s", err, stderr)
+		}
+		return stdout, nil
+	})
+}
+
+// snapshotWorktreeChanges records all of the worktree's uncommitted changes,
+// including untracked files, as a commit on top of its HEAD, without moving
+// HEAD or its branch. The commit's sha is returned.
+func snapshotWorktreeChanges(ctx context.Context, worktreePath, message string) (string, error) {
+	if _, _, _, err := runGitCommand(ctx, worktreePath, "add", "-A"); err != nil {
+		return "", fmt.Errorf("failed to stage uncommitted changes: %w", err)
+	}
+	tree, _, _, err := runGitCommand(ctx, worktreePath, "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("failed to write tree of uncommitted changes: %w", err)
+	}
+	args := []string{"-c", "user.name=Sidekick", "-c", "user.email=sidekick@side.dev", "commit-tree", strings.TrimSpace(tree), "-m", message}
+	// an unborn branch has no HEAD, so the snapshot will be a root commit
+	if head, _, _, err := runGitCommand(ctx, worktreePath, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		args = append(args, "-p", strings.TrimSpace(head))
+	}
+	commitSha, _, _, err := runGitCommand(ctx, worktreePath, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to commit uncommitted changes: %w", err)
+	}
+	return strings.TrimSpace(commitSha), nil
+}
+
+// unusedArchiveTagName returns "archive/<name>", suffixed with a number when
+// that tag already exists, so that earlier archives are never overwritten
+func unusedArchiveTagName(ctx context.Context, repoDir, name string) (string, error) {
+	tagName := "archive/" + name
+	for i := 2; ; i++ {
+		_, _, exitCode, err := runGitCommand(ctx, repoDir, "rev-parse", "--verify", "--quiet", "refs/tags/"+tagName)
+		if err != nil && exitCode == 1 {
+			return tagName, nil
+		} else if err != nil {
+			return "", fmt.Errorf("failed to check for archive tag %s: %w", tagName, err)
+		}
+		tagName = fmt.Sprintf("archive/%s-%d", name, i)
+	}
+}
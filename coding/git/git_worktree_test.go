@@ -190,8 +190,13 @@ func TestCleanupWorktreeActivity(t *testing.T) {
 		branches := runGitCommandInTestRepo(t, repoDir, "branch")
 		assert.Contains(t, branches, branchName, "Feature branch should exist before cleanup")
 
+		// Checkpoints of this flow and another one
+		runGitCommandInTestRepo(t, repoDir, "update-ref", CheckpointRef("flow_cleanup", "1"), "HEAD")
+		runGitCommandInTestRepo(t, repoDir, "update-ref", CheckpointRef("flow_cleanup", "2"), "HEAD")
+		runGitCommandInTestRepo(t, repoDir, "update-ref", CheckpointRef("flow_other", "1"), "HEAD")
+
 		// Perform cleanup from within the worktree
-		err = CleanupWorktreeActivity(ctx, envContainer, devEnv.GetWorkingDirectory(), branchName, "Test cleanup with archive message")
+		err = CleanupWorktreeActivity(ctx, envContainer, devEnv.GetWorkingDirectory(), branchName, "Test cleanup with archive message", "flow_cleanup")
 		require.NoError(t, err, "Cleanup should succeed")
 
 		// Verify the worktree was removed
@@ -213,6 +218,10 @@ func TestCleanupWorktreeActivity(t *testing.T) {
 		expectedTag := fmt.Sprintf("archive/%s", branchName)
 		assert.Contains(t, tagOutput, expectedTag, "Archive tag should be created")
 
+		// Verify only the flow's checkpoint refs were deleted
+		refs := runGitCommandInTestRepo(t, repoDir, "for-each-ref", "--format=%(refname)", CheckpointRefPrefix)
+		assert.Equal(t, CheckpointRef("flow_other", "1"), refs)
+
 		// Verify the tag message
 		tagMessageOutput := runGitCommandInTestRepo(t, repoDir, "tag", "-l", "-n1", expectedTag)
 		assert.Contains(t, tagMessageOutput, "Test cleanup with archive message", "Archive tag should have the correct message")
@@ -224,7 +233,7 @@ func TestCleanupWorktreeActivity(t *testing.T) {
 		require.NoError(t, err)
 		envContainer := env.EnvContainer{Env: devEnv}
 
-		err = CleanupWorktreeActivity(ctx, envContainer, repoDir, "", "")
+		err = CleanupWorktreeActivity(ctx, envContainer, repoDir, "", "", "")
 		require.Error(t, err, "Should fail with empty branch name")
 		assert.Contains(t, err.Error(), "branch name is required", "Error should mention missing branch name")
 	})
@@ -237,7 +246,7 @@ func TestCleanupWorktreeActivity(t *testing.T) {
 		require.NoError(t, err)
 		envContainer := env.EnvContainer{Env: devEnv}
 
-		err = CleanupWorktreeActivity(ctx, envContainer, repoDir, "non-existent-branch", "")
+		err = CleanupWorktreeActivity(ctx, envContainer, repoDir, "non-existent-branch", "", "")
 		require.Error(t, err, "Should fail with non-existent branch")
 		assert.Contains(t, err.Error(), "failed to create archive tag", "Error should mention archive tag creation failure")
 	})
@@ -260,7 +269,7 @@ func TestCleanupWorktreeActivity(t *testing.T) {
 		envContainer := env.EnvContainer{Env: devEnv}
 
 		// Perform cleanup with empty archive message
-		err = CleanupWorktreeActivity(ctx, envContainer, devEnv.GetWorkingDirectory(), branchName, "")
+		err = CleanupWorktreeActivity(ctx, envContainer, devEnv.GetWorkingDirectory(), branchName, "", "")
 		require.NoError(t, err, "Cleanup should succeed with empty archive message")
 
 		// Verify the worktree was removed
@@ -289,3 +298,117 @@ func TestCleanupWorktreeActivity(t *testing.T) {
 		assert.NotContains(t, tagMessageOutput, "Test cleanup", "Tag should not have a message when created with empty archiveMessage")
 	})
 }
+
+func TestIsBranchMerged(t *testing.T) {
+	ctx := context.Background()
+	repoDir := setupTestGitRepo(t)
+	createCommit(t, repoDir, "Initial commit")
+	runGitCommandInTestRepo(t, repoDir, "branch", "merged-branch")
+	runGitCommandInTestRepo(t, repoDir, "checkout", "-b", "unmerged-branch")
+	createCommit(t, repoDir, "Unmerged commit")
+	runGitCommandInTestRepo(t, repoDir, "checkout", "main")
+
+	merged, err := IsBranchMerged(ctx, repoDir, "merged-branch", "main")
+	require.NoError(t, err)
+	assert.True(t, merged)
+
+	merged, err = IsBranchMerged(ctx, repoDir, "unmerged-branch", "main")
+	require.NoError(t, err)
+	assert.False(t, merged)
+
+	_, err = IsBranchMerged(ctx, repoDir, "missing-branch", "main")
+	assert.Error(t, err)
+}
+
+func TestPruneWorktree(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Archives And Removes Worktree", func(t *testing.T) {
+		repoDir := setupTestGitRepo(t)
+		createCommit(t, repoDir, "Initial commit")
+		worktreePath := filepath.Join(t.TempDir(), "wt")
+		runGitCommandInTestRepo(t, repoDir, "worktree", "add", "-b", "side/prune-me", worktreePath)
+
+		runGitCommandInTestRepo(t, repoDir, "update-ref", CheckpointRef("flow_prune", "1"), "HEAD")
+
+		err := PruneWorktree(ctx, PruneWorktreeParams{
+			RepoDir:        repoDir,
+			WorktreePath:   worktreePath,
+			Branch:         "side/prune-me",
+			ArchiveMessage: "Pruned orphan",
+			FlowId:         "flow_prune",
+		})
+		require.NoError(t, err)
+		assert.Empty(t, runGitCommandInTestRepo(t, repoDir, "for-each-ref", CheckpointRefPrefix))
+
+		_, err = os.Stat(worktreePath)
+		assert.True(t, os.IsNotExist(err))
+		assert.NotContains(t, runGitCommandInTestRepo(t, repoDir, "branch"), "side/prune-me")
+		assert.Contains(t, runGitCommandInTestRepo(t, repoDir, "tag", "-l", "-n1", "archive/side/prune-me"), "Pruned orphan")
+	})
+
+	t.Run("Archives Uncommitted Changes", func(t *testing.T) {
+		repoDir := setupTestGitRepo(t)
+		createCommit(t, repoDir, "Initial commit")
+		worktreePath := filepath.Join(t.TempDir(), "wt")
+		runGitCommandInTestRepo(t, repoDir, "worktree", "add", "-b", "side/dirty", worktreePath)
+		require.NoError(t, os.WriteFile(filepath.Join(worktreePath, "new.txt"), []byte("uncommitted work"), 0644))
+		// an earlier archive of the same branch must survive
+		runGitCommandInTestRepo(t, repoDir, "tag", "archive/side/dirty", "main")
+
+		dirty, err := IsWorktreeDirty(ctx, worktreePath)
+		require.NoError(t, err)
+		assert.True(t, dirty)
+
+		err = PruneWorktree(ctx, PruneWorktreeParams{
+			RepoDir:        repoDir,
+			WorktreePath:   worktreePath,
+			Branch:         "side/dirty",
+			ArchiveMessage: "Pruned orphan",
+		})
+		require.NoError(t, err)
+
+		_, err = os.Stat(worktreePath)
+		assert.True(t, os.IsNotExist(err))
+		assert.Equal(t, runGitCommandInTestRepo(t, repoDir, "rev-parse", "main"), runGitCommandInTestRepo(t, repoDir, "rev-parse", "archive/side/dirty^{commit}"))
+		assert.Equal(t, "uncommitted work", runGitCommandInTestRepo(t, repoDir, "show", "archive/side/dirty-2:new.txt"))
+	})
+
+	t.Run("Refuses Uncommitted Changes Without Archive", func(t *testing.T) {
+		repoDir := setupTestGitRepo(t)
+		createCommit(t, repoDir, "Initial commit")
+		worktreePath := filepath.Join(t.TempDir(), "wt")
+		runGitCommandInTestRepo(t, repoDir, "worktree", "add", "-b", "side/dirty", worktreePath)
+		require.NoError(t, os.WriteFile(filepath.Join(worktreePath, "new.txt"), []byte("uncommitted work"), 0644))
+
+		err := PruneWorktree(ctx, PruneWorktreeParams{
+			RepoDir:      repoDir,
+			WorktreePath: worktreePath,
+			Branch:       "side/dirty",
+		})
+		assert.ErrorContains(t, err, "uncommitted changes")
+		_, err = os.Stat(filepath.Join(worktreePath, "new.txt"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Directory Already Missing", func(t *testing.T) {
+		repoDir := setupTestGitRepo(t)
+		createCommit(t, repoDir, "Initial commit")
+		worktreePath := filepath.Join(t.TempDir(), "wt")
+		runGitCommandInTestRepo(t, repoDir, "worktree", "add", "-b", "side/gone", worktreePath)
+		require.NoError(t, os.RemoveAll(worktreePath))
+
+		err := PruneWorktree(ctx, PruneWorktreeParams{
+			RepoDir:      repoDir,
+			WorktreePath: worktreePath,
+			Branch:       "side/gone",
+		})
+		require.NoError(t, err)
+
+		worktrees, err := ListWorktreesActivity(ctx, repoDir)
+		require.NoError(t, err)
+		assert.Len(t, worktrees, 1)
+		assert.NotContains(t, runGitCommandInTestRepo(t, repoDir, "branch"), "side/gone")
+		assert.Empty(t, runGitCommandInTestRepo(t, repoDir, "tag", "-l", "archive/*"))
+	})
+}
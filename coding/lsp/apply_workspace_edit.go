@@ -121,8 +121,30 @@ func writeURI(documentURI, updatedContents string) error {
 	return nil
 }
 
+// TextDocumentEdits returns the workspace edit's document changes, or when
+// there are none, its plain changes converted to text document edits, ordered
+// by URI. Per the spec, documentChanges take precedence when both are given.
+func (workspaceEdit WorkspaceEdit) TextDocumentEdits() []TextDocumentEdit {
+	if len(workspaceEdit.DocumentChanges) > 0 {
+		return workspaceEdit.DocumentChanges
+	}
+	uris := make([]string, 0, len(workspaceEdit.Changes))
+	for uri := range workspaceEdit.Changes {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	documentEdits := make([]TextDocumentEdit, 0, len(uris))
+	for _, uri := range uris {
+		documentEdits = append(documentEdits, TextDocumentEdit{
+			TextDocument: OptionalVersionedTextDocumentIdentifier{TextDocumentIdentifier: TextDocumentIdentifier{URI: uri}},
+			Edits:        workspaceEdit.Changes[uri],
+		})
+	}
+	return documentEdits
+}
+
 func ApplyWorkspaceEdit(ctx context.Context, envContainer env.EnvContainer, workspaceEdit WorkspaceEdit) error {
-	for _, documentEdit := range workspaceEdit.DocumentChanges {
+	for _, documentEdit := range workspaceEdit.TextDocumentEdits() {
 		originalContents, err := readURI(documentEdit.TextDocument.TextDocumentIdentifier.URI)
 		if err != nil {
 			return err
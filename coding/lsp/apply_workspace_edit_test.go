@@ -313,6 +313,32 @@ Line 5`
 	}
 }
 
+func TestWorkspaceEditTextDocumentEdits(t *testing.T) {
+	edit := TextEdit{Range: Range{Start: Position{Line: 0, Character: 0}, End: Position{Line: 0, Character: 1}}, NewText: "x"}
+	documentChange := TextDocumentEdit{
+		TextDocument: OptionalVersionedTextDocumentIdentifier{TextDocumentIdentifier: TextDocumentIdentifier{URI: "file:///c.go"}},
+		Edits:        []TextEdit{edit},
+	}
+
+	t.Run("changes are converted in URI order", func(t *testing.T) {
+		workspaceEdit := WorkspaceEdit{Changes: map[string][]TextEdit{"file:///b.go": {edit}, "file:///a.go": {edit}}}
+		documentEdits := workspaceEdit.TextDocumentEdits()
+		if assert.Len(t, documentEdits, 2) {
+			assert.Equal(t, "file:///a.go", documentEdits[0].TextDocument.URI)
+			assert.Equal(t, "file:///b.go", documentEdits[1].TextDocument.URI)
+			assert.Equal(t, []TextEdit{edit}, documentEdits[1].Edits)
+		}
+	})
+
+	t.Run("document changes take precedence", func(t *testing.T) {
+		workspaceEdit := WorkspaceEdit{
+			DocumentChanges: []TextDocumentEdit{documentChange},
+			Changes:         map[string][]TextEdit{"file:///a.go": {edit}},
+		}
+		assert.Equal(t, []TextDocumentEdit{documentChange}, workspaceEdit.TextDocumentEdits())
+	})
+}
+
 func TestApplyWorkspaceEditSpaceInPath(t *testing.T) {
 	const originalContents = `Line 1
 Line 2
@@ -120,5 +120,17 @@ var defaultClientCapabilities = ClientCapabilities{
 				},
 			},
 		},
+		Hover: &HoverClientCapabilities{
+			ContentFormat: []string{"markdown", "plaintext"},
+		},
+	},
+	Workspace: &WorkspaceClientCapabilities{
+		WorkspaceEdit: &WorkspaceEditClientCapabilities{
+			DocumentChanges: true,
+		},
+		FileOperations: &FileOperationClientCapabilities{
+			WillRename: true,
+			DidRename:  true,
+		},
 	},
 }
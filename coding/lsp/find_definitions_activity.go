@@ -0,0 +1,40 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sidekick/env"
+	"sidekick/utils"
+)
+
+// FindDefinitionsActivityInput represents the input for the FindDefinitionsActivity function
+type FindDefinitionsActivityInput struct {
+	EnvContainer     env.EnvContainer
+	RelativeFilePath string
+	Position         Position
+}
+
+// FindDefinitionsActivity finds the definitions of the symbol at the given
+// position using the LSP client
+func (lspa *LSPActivities) FindDefinitionsActivity(ctx context.Context, input FindDefinitionsActivityInput) ([]Location, error) {
+	baseDir := input.EnvContainer.Env.GetWorkingDirectory()
+	lang := utils.InferLanguageNameFromFilePath(input.RelativeFilePath)
+	lspClient, err := lspa.findOrInitClient(ctx, baseDir, lang)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find or initialize lsp client: %w", err)
+	}
+
+	absoluteFilepath := filepath.Join(baseDir, input.RelativeFilePath)
+	uri, err := url.Parse("file://" + absoluteFilepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse uri file://%s: %w", absoluteFilepath, err)
+	}
+	definitions, err := lspClient.TextDocumentDefinition(ctx, uri.String(), input.Position.Line, input.Position.Character)
+	if err != nil {
+		return nil, fmt.Errorf("failed to invoke lsp text document definition: %w", err)
+	}
+
+	return definitions, nil
+}
@@ -0,0 +1,47 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sidekick/env"
+	"sidekick/utils"
+)
+
+// FindImplementationsActivityInput represents the input for the
+// FindImplementationsActivity function
+type FindImplementationsActivityInput struct {
+	EnvContainer     env.EnvContainer
+	RelativeFilePath string
+	SymbolText       string
+	Range            *Range // Optional
+}
+
+// FindImplementationsActivity finds implementations of the given symbol, eg of
+// an interface or one of its methods, using the LSP client
+func (lspa *LSPActivities) FindImplementationsActivity(ctx context.Context, input FindImplementationsActivityInput) ([]Location, error) {
+	baseDir := input.EnvContainer.Env.GetWorkingDirectory()
+	lang := utils.InferLanguageNameFromFilePath(input.RelativeFilePath)
+	lspClient, err := lspa.findOrInitClient(ctx, baseDir, lang)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find or initialize lsp client: %w", err)
+	}
+
+	absoluteFilepath := filepath.Join(baseDir, input.RelativeFilePath)
+	position, err := findSymbolPositionInFile(absoluteFilepath, input.Range, input.SymbolText)
+	if err != nil {
+		return nil, err
+	}
+
+	uri, err := url.Parse("file://" + absoluteFilepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse uri file://%s: %w", absoluteFilepath, err)
+	}
+	implementations, err := lspClient.TextDocumentImplementation(ctx, uri.String(), position.Line, position.Character)
+	if err != nil {
+		return nil, fmt.Errorf("failed to invoke lsp text document implementation: %w", err)
+	}
+
+	return implementations, nil
+}
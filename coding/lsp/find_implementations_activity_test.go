@@ -0,0 +1,55 @@
+package lsp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sidekick/env"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestE2eFindImplementationsActivity(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	testFile := `package testpkg
+
+type Shape interface {
+	Area() float64
+}
+
+type Square struct{ side float64 }
+
+func (s Square) Area() float64 { return s.side * s.side }
+
+type Circle struct{ radius float64 }
+
+func (c *Circle) Area() float64 { return 3 * c.radius * c.radius }
+`
+	err := os.WriteFile(filepath.Join(tempDir, "test.go"), []byte(testFile), 0644)
+	require.NoError(t, err)
+
+	lspa := &LSPActivities{
+		LSPClientProvider: func(language string) LSPClient {
+			return &Jsonrpc2LSPClient{
+				LanguageName: "golang",
+			}
+		},
+		InitializedClients: map[string]LSPClient{},
+	}
+
+	locations, err := lspa.FindImplementationsActivity(context.Background(), FindImplementationsActivityInput{
+		EnvContainer:     env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: tempDir}},
+		RelativeFilePath: "test.go",
+		SymbolText:       "Shape",
+	})
+	require.NoError(t, err)
+
+	var lines []string
+	for _, location := range locations {
+		lines = append(lines, getLineContentFromLocation(t, location))
+	}
+	assert.ElementsMatch(t, []string{"type Square struct{ side float64 }", "type Circle struct{ radius float64 }"}, lines)
+}
@@ -41,16 +41,9 @@ func (lspa *LSPActivities) FindReferencesActivity(ctx context.Context, input Fin
 	}
 
 	absoluteFilepath := filepath.Join(baseDir, input.RelativeFilePath)
-	file, err := os.Open(absoluteFilepath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
-	}
-	defer file.Close()
-	reader := bufio.NewReader(file)
-
-	position, err := findSymbolPosition(reader, input.Range, input.SymbolText)
+	position, err := findSymbolPositionInFile(absoluteFilepath, input.Range, input.SymbolText)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find symbol position: %w", err)
+		return nil, err
 	}
 
 	uri, err := url.Parse("file://" + absoluteFilepath)
@@ -100,6 +93,23 @@ func (lspa *LSPActivities) findOrInitClient(ctx context.Context, baseDir string,
 	return lspClient, nil
 }
 
+// findSymbolPositionInFile finds the position of the last character of the
+// first matching text in the file, within the range if given
+func findSymbolPositionInFile(absoluteFilepath string, fileRange *Range, symbolText string) (Position, error) {
+	file, err := os.Open(absoluteFilepath)
+	if err != nil {
+		return Position{}, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+
+	position, err := findSymbolPosition(reader, fileRange, symbolText)
+	if err != nil {
+		return Position{}, fmt.Errorf("failed to find symbol position: %w", err)
+	}
+	return position, nil
+}
+
 // findSymbolPosition finds the position of the last character of the first matching text in the file content
 func findSymbolPosition(reader io.Reader, fileRange *Range, symbolText string) (Position, error) {
 	scanner := bufio.NewScanner(reader)
@@ -0,0 +1,50 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sidekick/env"
+	"sidekick/utils"
+)
+
+// HoverActivityInput represents the input for the HoverActivity function
+type HoverActivityInput struct {
+	EnvContainer     env.EnvContainer
+	RelativeFilePath string
+	SymbolText       string
+	Range            *Range // Optional
+}
+
+// HoverActivity gets the hover information for the given symbol, which
+// usually includes its type or signature and documentation. An empty string
+// is returned if the server has no information for the symbol.
+func (lspa *LSPActivities) HoverActivity(ctx context.Context, input HoverActivityInput) (string, error) {
+	baseDir := input.EnvContainer.Env.GetWorkingDirectory()
+	lang := utils.InferLanguageNameFromFilePath(input.RelativeFilePath)
+	lspClient, err := lspa.findOrInitClient(ctx, baseDir, lang)
+	if err != nil {
+		return "", fmt.Errorf("failed to find or initialize lsp client: %w", err)
+	}
+
+	absoluteFilepath := filepath.Join(baseDir, input.RelativeFilePath)
+	position, err := findSymbolPositionInFile(absoluteFilepath, input.Range, input.SymbolText)
+	if err != nil {
+		return "", err
+	}
+
+	uri, err := url.Parse("file://" + absoluteFilepath)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse uri file://%s: %w", absoluteFilepath, err)
+	}
+	hover, err := lspClient.TextDocumentHover(ctx, uri.String(), position.Line, position.Character)
+	if err != nil {
+		return "", fmt.Errorf("failed to invoke lsp text document hover: %w", err)
+	}
+	if hover == nil {
+		return "", nil
+	}
+
+	return hover.Text(), nil
+}
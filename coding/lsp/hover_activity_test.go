@@ -0,0 +1,72 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sidekick/env"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHoverText(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		contents string
+		expected string
+	}{
+		{"markup content", `{"kind": "markdown", "value": "func Foo()"}`, "func Foo()"},
+		{"marked string", `"func Foo()"`, "func Foo()"},
+		{"marked string with language", `{"language": "go", "value": "func Foo()"}`, "func Foo()"},
+		{"marked string array", `["func Foo()", {"language": "go", "value": "Foo does things"}]`, "func Foo()\n\nFoo does things"},
+		{"empty", `null`, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hover := Hover{Contents: json.RawMessage(tc.contents)}
+			assert.Equal(t, tc.expected, hover.Text())
+		})
+	}
+}
+
+func TestE2eHoverActivity(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	testFile := `package testpkg
+
+// Greet returns a greeting for the given name
+func Greet(name string) string {
+	return "hello " + name
+}
+
+func main() {
+	Greet("world")
+}
+`
+	err := os.WriteFile(filepath.Join(tempDir, "test.go"), []byte(testFile), 0644)
+	require.NoError(t, err)
+
+	lspa := &LSPActivities{
+		LSPClientProvider: func(language string) LSPClient {
+			return &Jsonrpc2LSPClient{
+				LanguageName: "golang",
+			}
+		},
+		InitializedClients: map[string]LSPClient{},
+	}
+
+	hoverText, err := lspa.HoverActivity(context.Background(), HoverActivityInput{
+		EnvContainer:     env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: tempDir}},
+		RelativeFilePath: "test.go",
+		SymbolText:       "Greet",
+		Range:            &Range{Start: Position{Line: 8}, End: Position{Line: 8}},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, hoverText, "func Greet(name string) string")
+	assert.Contains(t, hoverText, "Greet returns a greeting for the given name")
+}
@@ -296,3 +296,58 @@ func (lspa *LSPActivities) TextDocumentDidSaveActivity(ctx context.Context, inpu
 
 	return lspClient.TextDocumentDidSave(ctx, params)
 }
+
+// RenameFileActivityInput represents input for the workspace/willRenameFiles
+// request and workspace/didRenameFiles notification for a single file.
+type RenameFileActivityInput struct {
+	RepoDir     string `json:"repo_dir"`
+	OldFilePath string `json:"old_file_path"`
+	NewFilePath string `json:"new_file_path"`
+}
+
+func (input RenameFileActivityInput) renameFilesParams() RenameFilesParams {
+	return RenameFilesParams{
+		Files: []FileRename{{
+			OldURI: convertFilePathToURI(input.RepoDir, input.OldFilePath),
+			NewURI: convertFilePathToURI(input.RepoDir, input.NewFilePath),
+		}},
+	}
+}
+
+// WorkspaceWillRenameFileActivity asks the LSP server for the edits needed to
+// keep other files working once a file is renamed, eg updated imports. This
+// must be called before the file is actually renamed. A nil edit is returned
+// if the server doesn't support willRenameFiles for the file.
+func (lspa *LSPActivities) WorkspaceWillRenameFileActivity(ctx context.Context, input RenameFileActivityInput) (*WorkspaceEdit, error) {
+	langName := utils.InferLanguageNameFromFilePath(input.OldFilePath)
+	lspClient, err := lspa.findOrInitClient(ctx, input.RepoDir, langName)
+	if err != nil {
+		return nil, err
+	}
+
+	params := input.renameFilesParams()
+	workspace := lspClient.GetServerCapabilities().Workspace
+	if workspace == nil || workspace.FileOperations == nil || !workspace.FileOperations.WillRename.Matches(params.Files[0].OldURI, false) {
+		return nil, nil // Server doesn't support willRenameFiles for this file
+	}
+
+	return lspClient.WorkspaceWillRenameFiles(ctx, params)
+}
+
+// WorkspaceDidRenameFileActivity sends a workspace/didRenameFiles notification
+// to the LSP server after a file was renamed.
+func (lspa *LSPActivities) WorkspaceDidRenameFileActivity(ctx context.Context, input RenameFileActivityInput) error {
+	langName := utils.InferLanguageNameFromFilePath(input.OldFilePath)
+	lspClient, err := lspa.findOrInitClient(ctx, input.RepoDir, langName)
+	if err != nil {
+		return err
+	}
+
+	params := input.renameFilesParams()
+	workspace := lspClient.GetServerCapabilities().Workspace
+	if workspace == nil || workspace.FileOperations == nil || !workspace.FileOperations.DidRename.Matches(params.Files[0].OldURI, false) {
+		return nil // Server doesn't support didRenameFiles for this file
+	}
+
+	return lspClient.WorkspaceDidRenameFiles(ctx, params)
+}
@@ -0,0 +1,88 @@
+package lsp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileOperationRegistrationOptionsMatches(t *testing.T) {
+	t.Parallel()
+	options := &FileOperationRegistrationOptions{
+		Filters: []FileOperationFilter{
+			{Scheme: "file", Pattern: FileOperationPattern{Glob: "**/*.go", Matches: "file"}},
+			{Pattern: FileOperationPattern{Glob: "**/*.TS", Options: &FileOperationPatternOptions{IgnoreCase: true}}},
+		},
+	}
+
+	assert.True(t, options.Matches("file:///repo/pkg/a.go", false))
+	assert.False(t, options.Matches("file:///repo/pkg/a.go", true))
+	assert.False(t, options.Matches("untitled:///repo/pkg/a.go", false))
+	assert.True(t, options.Matches("file:///repo/src/a.ts", false))
+	assert.False(t, options.Matches("file:///repo/README.md", false))
+
+	var nilOptions *FileOperationRegistrationOptions
+	assert.False(t, nilOptions.Matches("file:///repo/pkg/a.go", false))
+}
+
+func TestWorkspaceWillRenameFileActivity(t *testing.T) {
+	t.Parallel()
+	input := RenameFileActivityInput{RepoDir: "/repo", OldFilePath: "a/old.go", NewFilePath: "b/new.go"}
+	expectedEdit := &WorkspaceEdit{DocumentChanges: []TextDocumentEdit{{
+		TextDocument: OptionalVersionedTextDocumentIdentifier{TextDocumentIdentifier: TextDocumentIdentifier{URI: "file:///repo/main.go"}},
+		Edits:        []TextEdit{{NewText: "\"example.com/b\""}},
+	}}}
+
+	t.Run("supported", func(t *testing.T) {
+		t.Parallel()
+		var receivedParams RenameFilesParams
+		didRename := false
+		lspa := &LSPActivities{
+			LSPClientProvider: func(language string) LSPClient {
+				return MockLSPClient{
+					ServerCapabilities: ServerCapabilities{
+						Workspace: &WorkspaceSpecificCapabilties{
+							FileOperations: &FileOperationOptions{
+								WillRename: &FileOperationRegistrationOptions{Filters: []FileOperationFilter{{Pattern: FileOperationPattern{Glob: "**/*.go"}}}},
+								DidRename:  &FileOperationRegistrationOptions{Filters: []FileOperationFilter{{Pattern: FileOperationPattern{Glob: "**"}}}},
+							},
+						},
+					},
+					WorkspaceWillRenameFilesFunc: func(ctx context.Context, params RenameFilesParams) (*WorkspaceEdit, error) {
+						receivedParams = params
+						return expectedEdit, nil
+					},
+					WorkspaceDidRenameFilesFunc: func(ctx context.Context, params RenameFilesParams) error {
+						didRename = true
+						return nil
+					},
+				}
+			},
+		}
+
+		workspaceEdit, err := lspa.WorkspaceWillRenameFileActivity(context.Background(), input)
+		require.NoError(t, err)
+		assert.Equal(t, expectedEdit, workspaceEdit)
+		assert.Equal(t, []FileRename{{OldURI: "file:///repo/a/old.go", NewURI: "file:///repo/b/new.go"}}, receivedParams.Files)
+
+		require.NoError(t, lspa.WorkspaceDidRenameFileActivity(context.Background(), input))
+		assert.True(t, didRename)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		t.Parallel()
+		lspa := &LSPActivities{
+			LSPClientProvider: func(language string) LSPClient {
+				// the mock panics if the request is made
+				return MockLSPClient{}
+			},
+		}
+
+		workspaceEdit, err := lspa.WorkspaceWillRenameFileActivity(context.Background(), input)
+		require.NoError(t, err)
+		assert.Nil(t, workspaceEdit)
+		require.NoError(t, lspa.WorkspaceDidRenameFileActivity(context.Background(), input))
+	})
+}
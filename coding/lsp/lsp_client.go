@@ -17,6 +17,8 @@ type LSPClient interface {
 	TextDocumentCodeAction(ctx context.Context, params CodeActionParams) ([]CodeAction, error)
 	TextDocumentImplementation(ctx context.Context, uri string, line int, character int) ([]Location, error)
 	TextDocumentReferences(ctx context.Context, uri string, line int, character int) ([]Location, error)
+	TextDocumentHover(ctx context.Context, uri string, line int, character int) (*Hover, error)
+	TextDocumentRename(ctx context.Context, params RenameParams) (*WorkspaceEdit, error)
 	GetServerCapabilities() ServerCapabilities
 
 	// Text document synchronization notifications
@@ -24,6 +26,10 @@ type LSPClient interface {
 	TextDocumentDidChange(ctx context.Context, params DidChangeTextDocumentParams) error
 	TextDocumentDidSave(ctx context.Context, params DidSaveTextDocumentParams) error
 	TextDocumentDidClose(ctx context.Context, params DidCloseTextDocumentParams) error
+
+	// Workspace file operations
+	WorkspaceWillRenameFiles(ctx context.Context, params RenameFilesParams) (*WorkspaceEdit, error)
+	WorkspaceDidRenameFiles(ctx context.Context, params RenameFilesParams) error
 }
 
 type Jsonrpc2LSPClient struct {
@@ -195,6 +201,41 @@ func (l *Jsonrpc2LSPClient) TextDocumentImplementation(ctx context.Context, uri
 	return locations, nil
 }
 
+// textDocument/hover
+func (l *Jsonrpc2LSPClient) TextDocumentHover(ctx context.Context, uri string, line int, character int) (*Hover, error) {
+	if l.Conn == nil {
+		return nil, fmt.Errorf("TextDocumentHover called before Initialize")
+	}
+	params := TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{
+			URI: uri,
+		},
+		Position: Position{
+			Line:      line,
+			Character: character,
+		},
+	}
+	var hover *Hover
+	err := l.Conn.Call(ctx, "textDocument/hover", params, &hover)
+	if err != nil {
+		return nil, err
+	}
+	return hover, nil
+}
+
+// textDocument/rename
+func (l *Jsonrpc2LSPClient) TextDocumentRename(ctx context.Context, params RenameParams) (*WorkspaceEdit, error) {
+	if l.Conn == nil {
+		return nil, fmt.Errorf("TextDocumentRename called before Initialize")
+	}
+	var workspaceEdit *WorkspaceEdit
+	err := l.Conn.Call(ctx, "textDocument/rename", params, &workspaceEdit)
+	if err != nil {
+		return nil, err
+	}
+	return workspaceEdit, nil
+}
+
 func (l *Jsonrpc2LSPClient) GetServerCapabilities() ServerCapabilities {
 	return l.ServerCapabilities
 }
@@ -230,3 +271,24 @@ func (l *Jsonrpc2LSPClient) TextDocumentDidClose(ctx context.Context, params Did
 	}
 	return l.Conn.Notify(ctx, "textDocument/didClose", params)
 }
+
+// workspace/willRenameFiles
+func (l *Jsonrpc2LSPClient) WorkspaceWillRenameFiles(ctx context.Context, params RenameFilesParams) (*WorkspaceEdit, error) {
+	if l.Conn == nil {
+		return nil, fmt.Errorf("WorkspaceWillRenameFiles called before Initialize")
+	}
+	var workspaceEdit *WorkspaceEdit
+	err := l.Conn.Call(ctx, "workspace/willRenameFiles", params, &workspaceEdit)
+	if err != nil {
+		return nil, err
+	}
+	return workspaceEdit, nil
+}
+
+// workspace/didRenameFiles notification
+func (l *Jsonrpc2LSPClient) WorkspaceDidRenameFiles(ctx context.Context, params RenameFilesParams) error {
+	if l.Conn == nil {
+		return fmt.Errorf("WorkspaceDidRenameFiles called before Initialize")
+	}
+	return l.Conn.Notify(ctx, "workspace/didRenameFiles", params)
+}
@@ -3,6 +3,10 @@ package lsp
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
 )
 
 // The structs below represent the data structures used in the LSP's initialize request and response
@@ -28,6 +32,50 @@ type ReferenceContext struct {
 	IncludeDeclaration bool `json:"includeDeclaration"`
 }
 
+type RenameParams struct {
+	TextDocumentPositionParams
+	// The new name of the symbol. If the given name is not valid the request
+	// must return a ResponseError with an appropriate message set.
+	NewName string `json:"newName"`
+}
+
+// The result of a hover request.
+type Hover struct {
+	// The hover's content, which may be a MarkupContent, a MarkedString or an
+	// array of MarkedStrings. See Hover.Text for a plain string.
+	Contents json.RawMessage `json:"contents"`
+	Range    *Range          `json:"range,omitempty"`
+}
+
+type MarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// Text returns the hover contents as a single string, joining the parts of
+// deprecated MarkedString arrays with blank lines
+func (h Hover) Text() string {
+	var markupContent MarkupContent
+	if err := json.Unmarshal(h.Contents, &markupContent); err == nil && markupContent.Value != "" {
+		return markupContent.Value
+	}
+	var plain string
+	if err := json.Unmarshal(h.Contents, &plain); err == nil {
+		return plain
+	}
+	var parts []json.RawMessage
+	if err := json.Unmarshal(h.Contents, &parts); err == nil {
+		var texts []string
+		for _, part := range parts {
+			if text := (Hover{Contents: part}).Text(); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		return strings.Join(texts, "\n\n")
+	}
+	return ""
+}
+
 type CodeAction struct {
 	Title       string          `json:"title"`
 	Kind        *CodeActionKind `json:"kind,omitempty"`
@@ -87,12 +135,11 @@ type WorkspaceEdit struct {
 	DocumentChanges []TextDocumentEdit `json:"documentChanges,omitempty"`
 
 	/*
-		TODO later if any servers only support old changes and not documentChanges:
-		If a client neither supports `documentChanges` nor
-		`workspace.workspaceEdit.resourceOperations` then only plain `TextEdit`s
-		using the `changes` property are supported.
+		Holds changes to existing resources, keyed by document URI. Some
+		servers only send these, even when the client supports
+		`documentChanges`. See TextDocumentEdits.
 	*/
-	// Changes           map[string][]TextEdit       `json:"changes,omitempty"`
+	Changes           map[string][]TextEdit       `json:"changes,omitempty"`
 	ChangeAnnotations map[string]ChangeAnnotation `json:"changeAnnotations,omitempty"`
 }
 
@@ -207,11 +254,38 @@ type Implementation struct {
 type ClientCapabilities struct {
 	TextDocument   TextDocumentClientCapabilities `json:"textDocument"`
 	Implementation Implementation                 `json:"implementation"`
+	Workspace      *WorkspaceClientCapabilities   `json:"workspace,omitempty"`
+}
+
+type WorkspaceClientCapabilities struct {
+	WorkspaceEdit  *WorkspaceEditClientCapabilities `json:"workspaceEdit,omitempty"`
+	FileOperations *FileOperationClientCapabilities `json:"fileOperations,omitempty"`
+}
+
+type WorkspaceEditClientCapabilities struct {
+	// The client supports versioned document changes in `WorkspaceEdit`s
+	DocumentChanges bool `json:"documentChanges,omitempty"`
+}
+
+// FileOperationClientCapabilities describes which workspace file operation
+// requests and notifications the client sends
+type FileOperationClientCapabilities struct {
+	// The client has support for sending didRenameFiles notifications.
+	DidRename bool `json:"didRename,omitempty"`
+	// The client has support for sending willRenameFiles requests.
+	WillRename bool `json:"willRename,omitempty"`
 }
 
 type TextDocumentClientCapabilities struct {
 	Synchronization *TextDocumentSyncClientCapabilities `json:"synchronization,omitempty"`
 	CodeAction      CodeActionClientCapabilities        `json:"codeAction,omitempty"`
+	Hover           *HoverClientCapabilities            `json:"hover,omitempty"`
+}
+
+type HoverClientCapabilities struct {
+	// Client supports the following content formats if the content property
+	// refers to a `literal of type MarkupContent`, in order of preference.
+	ContentFormat []string `json:"contentFormat,omitempty"`
 }
 
 // CodeActionKind represents the kind of a code action.
@@ -356,6 +430,84 @@ type WorkspaceSpecificCapabilties struct {
 		Supported           bool            `json:"supported"`
 		ChangeNotifications ChangeNotifType `json:"changeNotifications,omitempty"`
 	} `json:"workspaceFolders"`
+	FileOperations *FileOperationOptions `json:"fileOperations,omitempty"`
+}
+
+// FileOperationOptions describes which workspace file operations the server is
+// interested in, if any
+type FileOperationOptions struct {
+	DidRename  *FileOperationRegistrationOptions `json:"didRename,omitempty"`
+	WillRename *FileOperationRegistrationOptions `json:"willRename,omitempty"`
+}
+
+type FileOperationRegistrationOptions struct {
+	// The actual filters.
+	Filters []FileOperationFilter `json:"filters"`
+}
+
+// A filter to describe in which file operation requests or notifications the
+// server is interested in.
+type FileOperationFilter struct {
+	// A Uri like `file`. If not present all schemes match.
+	Scheme  string               `json:"scheme,omitempty"`
+	Pattern FileOperationPattern `json:"pattern"`
+}
+
+type FileOperationPattern struct {
+	// The glob pattern to match, eg `**/*.go`
+	Glob string `json:"glob"`
+	// Whether to match files or folders with this pattern. Matches both if
+	// undefined.
+	Matches string                       `json:"matches,omitempty"`
+	Options *FileOperationPatternOptions `json:"options,omitempty"`
+}
+
+type FileOperationPatternOptions struct {
+	IgnoreCase bool `json:"ignoreCase,omitempty"`
+}
+
+// Matches reports whether the server is interested in an operation on the
+// given file URI, based on the registered filters
+func (o *FileOperationRegistrationOptions) Matches(fileURI string, isDir bool) bool {
+	if o == nil {
+		return false
+	}
+	parsed, err := url.Parse(fileURI)
+	if err != nil {
+		return false
+	}
+	for _, filter := range o.Filters {
+		if filter.Scheme != "" && filter.Scheme != parsed.Scheme {
+			continue
+		}
+		if (filter.Pattern.Matches == "file" && isDir) || (filter.Pattern.Matches == "folder" && !isDir) {
+			continue
+		}
+		glob, filePath := filter.Pattern.Glob, parsed.Path
+		if filter.Pattern.Options != nil && filter.Pattern.Options.IgnoreCase {
+			glob, filePath = strings.ToLower(glob), strings.ToLower(filePath)
+		}
+		// globs are usually relative, eg "**/*.go", and don't need to match
+		// the leading slash of an absolute path
+		if ok, _ := doublestar.Match(strings.TrimPrefix(glob, "/"), strings.TrimPrefix(filePath, "/")); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// The parameters sent in notifications/requests for user-initiated renames of
+// files.
+type RenameFilesParams struct {
+	// An array of all files/folders renamed in this operation. When a folder
+	// is renamed, only the folder will be included, and not its children.
+	Files []FileRename `json:"files"`
+}
+
+// Represents information on a file/folder rename.
+type FileRename struct {
+	OldURI string `json:"oldUri"`
+	NewURI string `json:"newUri"`
 }
 
 type ChangeNotifType struct {
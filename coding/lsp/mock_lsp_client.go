@@ -9,10 +9,15 @@ type MockLSPClient struct {
 	TextDocumentCodeActionFunc     func(ctx context.Context, params CodeActionParams) ([]CodeAction, error)
 	TextDocumentImplementationFunc func(ctx context.Context, uri string, line int, character int) ([]Location, error)
 	TextDocumentReferencesFunc     func(ctx context.Context, uri string, line int, character int) ([]Location, error)
+	TextDocumentHoverFunc          func(ctx context.Context, uri string, line int, character int) (*Hover, error)
+	TextDocumentRenameFunc         func(ctx context.Context, params RenameParams) (*WorkspaceEdit, error)
 	TextDocumentDidOpenFunc        func(ctx context.Context, params DidOpenTextDocumentParams) error
 	TextDocumentDidChangeFunc      func(ctx context.Context, params DidChangeTextDocumentParams) error
 	TextDocumentDidSaveFunc        func(ctx context.Context, params DidSaveTextDocumentParams) error
 	TextDocumentDidCloseFunc       func(ctx context.Context, params DidCloseTextDocumentParams) error
+	WorkspaceWillRenameFilesFunc   func(ctx context.Context, params RenameFilesParams) (*WorkspaceEdit, error)
+	WorkspaceDidRenameFilesFunc    func(ctx context.Context, params RenameFilesParams) error
+	ServerCapabilities             ServerCapabilities
 }
 
 func (m MockLSPClient) Initialize(ctx context.Context, params InitializeParams) (InitializeResponse, error) {
@@ -52,8 +57,22 @@ func (m MockLSPClient) TextDocumentReferences(ctx context.Context, uri string, l
 	return m.TextDocumentReferencesFunc(ctx, uri, line, character)
 }
 
+func (m MockLSPClient) TextDocumentHover(ctx context.Context, uri string, line int, character int) (*Hover, error) {
+	if m.TextDocumentHoverFunc == nil {
+		panic("TextDocumentHoverFunc is not set on mock lsp client")
+	}
+	return m.TextDocumentHoverFunc(ctx, uri, line, character)
+}
+
+func (m MockLSPClient) TextDocumentRename(ctx context.Context, params RenameParams) (*WorkspaceEdit, error) {
+	if m.TextDocumentRenameFunc == nil {
+		panic("TextDocumentRenameFunc is not set on mock lsp client")
+	}
+	return m.TextDocumentRenameFunc(ctx, params)
+}
+
 func (m MockLSPClient) GetServerCapabilities() ServerCapabilities {
-	return ServerCapabilities{}
+	return m.ServerCapabilities
 }
 
 func (m MockLSPClient) TextDocumentDidOpen(ctx context.Context, params DidOpenTextDocumentParams) error {
@@ -83,3 +102,17 @@ func (m MockLSPClient) TextDocumentDidClose(ctx context.Context, params DidClose
 	}
 	return m.TextDocumentDidCloseFunc(ctx, params)
 }
+
+func (m MockLSPClient) WorkspaceWillRenameFiles(ctx context.Context, params RenameFilesParams) (*WorkspaceEdit, error) {
+	if m.WorkspaceWillRenameFilesFunc == nil {
+		panic("WorkspaceWillRenameFilesFunc is not set on mock lsp client")
+	}
+	return m.WorkspaceWillRenameFilesFunc(ctx, params)
+}
+
+func (m MockLSPClient) WorkspaceDidRenameFiles(ctx context.Context, params RenameFilesParams) error {
+	if m.WorkspaceDidRenameFilesFunc == nil {
+		return nil // Default to no-op for notifications
+	}
+	return m.WorkspaceDidRenameFilesFunc(ctx, params)
+}
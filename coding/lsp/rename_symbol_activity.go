@@ -0,0 +1,71 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sidekick/env"
+	"sidekick/utils"
+)
+
+// RenameSymbolActivityInput represents the input for the RenameSymbolActivity
+// function
+type RenameSymbolActivityInput struct {
+	EnvContainer     env.EnvContainer
+	RelativeFilePath string
+	SymbolText       string
+	Range            *Range // Optional
+	NewName          string
+}
+
+var ErrRenameNotSupported = errors.New("the language server does not support renaming")
+
+// RenameSymbolActivity renames the given symbol everywhere it's referenced via
+// the LSP client, applying the resulting workspace edit. The applied edit is
+// returned.
+func (lspa *LSPActivities) RenameSymbolActivity(ctx context.Context, input RenameSymbolActivityInput) (WorkspaceEdit, error) {
+	baseDir := input.EnvContainer.Env.GetWorkingDirectory()
+	lang := utils.InferLanguageNameFromFilePath(input.RelativeFilePath)
+	lspClient, err := lspa.findOrInitClient(ctx, baseDir, lang)
+	if err != nil {
+		return WorkspaceEdit{}, fmt.Errorf("failed to find or initialize lsp client: %w", err)
+	}
+	renameProvider := lspClient.GetServerCapabilities().RenameProvider
+	if renameProvider == nil || renameProvider == false {
+		return WorkspaceEdit{}, ErrRenameNotSupported
+	}
+
+	absoluteFilepath := filepath.Join(baseDir, input.RelativeFilePath)
+	position, err := findSymbolPositionInFile(absoluteFilepath, input.Range, input.SymbolText)
+	if err != nil {
+		return WorkspaceEdit{}, err
+	}
+
+	uri, err := url.Parse("file://" + absoluteFilepath)
+	if err != nil {
+		return WorkspaceEdit{}, fmt.Errorf("failed to parse uri file://%s: %w", absoluteFilepath, err)
+	}
+	params := RenameParams{
+		TextDocumentPositionParams: TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: uri.String()},
+			Position:     position,
+		},
+		NewName: input.NewName,
+	}
+	workspaceEdit, err := lspClient.TextDocumentRename(ctx, params)
+	if err != nil {
+		return WorkspaceEdit{}, fmt.Errorf("failed to invoke lsp text document rename: %w", err)
+	}
+	if workspaceEdit == nil || len(workspaceEdit.TextDocumentEdits()) == 0 {
+		return WorkspaceEdit{}, fmt.Errorf("the language server returned no edits for renaming %s", input.SymbolText)
+	}
+
+	err = ApplyWorkspaceEdit(ctx, input.EnvContainer, *workspaceEdit)
+	if err != nil {
+		return WorkspaceEdit{}, fmt.Errorf("failed to apply rename edits: %w", err)
+	}
+
+	return *workspaceEdit, nil
+}
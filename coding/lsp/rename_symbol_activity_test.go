@@ -0,0 +1,68 @@
+package lsp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sidekick/env"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestE2eRenameSymbolActivity(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"go.mod":   "module example.com/renametest\n\ngo 1.21\n",
+		"main.go":  "package main\n\nfunc helper() int {\n\treturn 1\n}\n\nfunc main() {\n\t_ = helper()\n}\n",
+		"other.go": "package main\n\nvar x = helper()\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644))
+	}
+
+	lspa := &LSPActivities{
+		LSPClientProvider: func(language string) LSPClient {
+			return &Jsonrpc2LSPClient{
+				LanguageName: "golang",
+			}
+		},
+		InitializedClients: map[string]LSPClient{},
+	}
+
+	workspaceEdit, err := lspa.RenameSymbolActivity(context.Background(), RenameSymbolActivityInput{
+		EnvContainer:     env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: tempDir}},
+		RelativeFilePath: "main.go",
+		SymbolText:       "helper",
+		NewName:          "computeValue",
+	})
+	require.NoError(t, err)
+	assert.Len(t, workspaceEdit.DocumentChanges, 2)
+
+	mainContent, err := os.ReadFile(filepath.Join(tempDir, "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nfunc computeValue() int {\n\treturn 1\n}\n\nfunc main() {\n\t_ = computeValue()\n}\n", string(mainContent))
+	otherContent, err := os.ReadFile(filepath.Join(tempDir, "other.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nvar x = computeValue()\n", string(otherContent))
+}
+
+func TestRenameSymbolActivityNotSupported(t *testing.T) {
+	t.Parallel()
+	lspa := &LSPActivities{
+		LSPClientProvider: func(language string) LSPClient {
+			// the mock panics if the rename request is made
+			return MockLSPClient{}
+		},
+	}
+
+	_, err := lspa.RenameSymbolActivity(context.Background(), RenameSymbolActivityInput{
+		EnvContainer:     env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: t.TempDir()}},
+		RelativeFilePath: "main.go",
+		SymbolText:       "helper",
+		NewName:          "computeValue",
+	})
+	assert.ErrorIs(t, err, ErrRenameNotSupported)
+}
@@ -0,0 +1,263 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"sidekick/common"
+)
+
+// Query describes what to search for, with the same semantics as rg's
+// equivalent flags
+type Query struct {
+	SearchTerm      string
+	FixedStrings    bool
+	CaseInsensitive bool
+}
+
+// RegexParseError is reported for invalid search terms, matching the message
+// rg gives so callers can retry with fixed strings
+const RegexParseError = "regex parse error"
+
+// Compile turns the query into a regex that matches within single lines
+func (q Query) Compile() (*regexp.Regexp, error) {
+	pattern := q.SearchTerm
+	if q.FixedStrings {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	flags := "(?m)"
+	if q.CaseInsensitive {
+		flags = "(?mi)"
+	}
+	re, err := regexp.Compile(flags + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s:\n    %v", RegexParseError, err)
+	}
+	return re, nil
+}
+
+// git considers files with a NUL byte near their start to be binary
+const binaryDetectionLength = 8000
+
+// ListFiles lists the regular files in baseDir, relative to it and sorted,
+// skipping those ignored by .gitignore, .ignore and .sideignore files or the
+// given external ignore files. Like `rg --files --hidden`, hidden files are
+// included, but never the .git directory.
+func ListFiles(baseDir string, ignoreFilePaths []string) ([]string, error) {
+	var files []string
+	err := common.WalkCodeDirectoryWithIgnoreFiles(baseDir, ignoreFilePaths, func(path string, entry fs.DirEntry) error {
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(baseDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, relativePath)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// FilesWithMatches returns the files, relative to baseDir, that contain a line
+// matching the regex, in the given order. Binary files are skipped.
+func FilesWithMatches(baseDir string, files []string, re *regexp.Regexp) ([]string, error) {
+	matched := make([]bool, len(files))
+	err := forEachFile(baseDir, files, func(i int, content []byte) {
+		matched[i] = len(matchingLines(content, re, true)) > 0
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var matchingFiles []string
+	for i, file := range files {
+		if matched[i] {
+			matchingFiles = append(matchingFiles, file)
+		}
+	}
+	return matchingFiles, nil
+}
+
+// Grep searches the files, relative to baseDir, and formats the matching
+// lines like `git grep --heading --line-number --show-function --context N`,
+// so that results look the same whether or not rg is installed. Binary files
+// are skipped.
+func Grep(baseDir string, files []string, re *regexp.Regexp, contextLines int) (string, error) {
+	results := make([]string, len(files))
+	err := forEachFile(baseDir, files, func(i int, content []byte) {
+		results[i] = grepFile(files[i], content, re, contextLines)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var output strings.Builder
+	for _, result := range results {
+		if result == "" {
+			continue
+		}
+		// git separates files with a hunk mark only when showing context
+		if output.Len() > 0 && contextLines > 0 {
+			output.WriteString("--\n")
+		}
+		output.WriteString(result)
+	}
+	return output.String(), nil
+}
+
+// forEachFile reads the non-binary files concurrently, calling handle with
+// each file's index and content
+func forEachFile(baseDir string, files []string, handle func(i int, content []byte)) error {
+	indexes := make(chan int)
+	errs := make(chan error, len(files))
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				content, err := os.ReadFile(filepath.Join(baseDir, files[i]))
+				if err != nil {
+					errs <- fmt.Errorf("failed to read %s: %w", files[i], err)
+					continue
+				}
+				if bytes.IndexByte(content[:min(len(content), binaryDetectionLength)], 0) != -1 {
+					continue
+				}
+				handle(i, content)
+			}
+		}()
+	}
+	for i := range files {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+// matchingLines returns the 0-based indexes of lines matching the regex,
+// stopping after the first when firstOnly is set
+func matchingLines(content []byte, re *regexp.Regexp, firstOnly bool) []int {
+	// checking the whole file first is much faster, since most files don't
+	// match at all
+	if !re.Match(content) {
+		return nil
+	}
+	var matches []int
+	for i, line := range splitLines(content) {
+		if re.Match(line) {
+			matches = append(matches, i)
+			if firstOnly {
+				break
+			}
+		}
+	}
+	return matches
+}
+
+func splitLines(content []byte) [][]byte {
+	lines := bytes.Split(content, []byte("\n"))
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// isFunctionLine is git's default heuristic for lines that start a function
+// or other definition: they start with a letter, underscore or dollar sign
+func isFunctionLine(line []byte) bool {
+	if len(line) == 0 {
+		return false
+	}
+	c := line[0]
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c == '$'
+}
+
+// grepFile formats a single file's matches the way git grep does, returning an
+// empty string if there are none
+func grepFile(path string, content []byte, re *regexp.Regexp, contextLines int) string {
+	matches := matchingLines(content, re, false)
+	if len(matches) == 0 {
+		return ""
+	}
+	lines := splitLines(content)
+
+	var output strings.Builder
+	output.WriteString(path + "\n")
+	// lastShown is the 1-based number of the last line written, 0 if none
+	lastShown := 0
+	showLine := func(lineNumber int, sign byte) {
+		if contextLines > 0 && lastShown > 0 && lineNumber > lastShown+1 {
+			output.WriteString("--\n")
+		}
+		lastShown = lineNumber
+		output.WriteString(fmt.Sprintf("%d%c", lineNumber, sign))
+		output.Write(lines[lineNumber-1])
+		output.WriteByte('\n')
+	}
+
+	isMatch := make(map[int]bool, len(matches))
+	for _, match := range matches {
+		isMatch[match+1] = true
+	}
+
+	lastMatch := 0
+	for _, match := range matches {
+		lineNumber := match + 1
+
+		// post-context of the previous match
+		for n := lastShown + 1; lastMatch > 0 && n < lineNumber && n <= lastMatch+contextLines; n++ {
+			showLine(n, '-')
+		}
+
+		from := max(1, lineNumber-contextLines, lastShown+1)
+		// the nearest function line within the pre-context is marked as such,
+		// otherwise the nearest one before it is shown on its own
+		functionLine := 0
+		for n := lineNumber - 1; n >= from; n-- {
+			if isFunctionLine(lines[n-1]) {
+				functionLine = n
+				break
+			}
+		}
+		if functionLine == 0 {
+			for n := from - 1; n > lastShown; n-- {
+				if isFunctionLine(lines[n-1]) {
+					showLine(n, '=')
+					break
+				}
+			}
+		}
+		for n := from; n < lineNumber; n++ {
+			sign := byte('-')
+			if n == functionLine {
+				sign = '='
+			}
+			showLine(n, sign)
+		}
+
+		showLine(lineNumber, ':')
+		lastMatch = lineNumber
+	}
+	for n := lastShown + 1; n <= len(lines) && n <= lastMatch+contextLines; n++ {
+		if !isMatch[n] {
+			showLine(n, '-')
+		}
+	}
+	return output.String()
+}
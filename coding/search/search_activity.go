@@ -0,0 +1,109 @@
+package search
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"sidekick/env"
+)
+
+// SearchMode selects what SearchActivity outputs, mirroring the rg and git
+// grep invocations it stands in for
+type SearchMode string
+
+const (
+	// SearchModeFiles lists all files that aren't ignored, like `rg --files`
+	SearchModeFiles SearchMode = "files"
+	// SearchModeFilesWithMatches lists files containing a match, like `rg --files-with-matches`
+	SearchModeFilesWithMatches SearchMode = "files_with_matches"
+	// SearchModeGrep outputs matching lines with context, like `git grep --heading --show-function`
+	SearchModeGrep SearchMode = "grep"
+)
+
+type SearchActivityInput struct {
+	EnvContainer    env.EnvContainer
+	Mode            SearchMode
+	SearchTerm      string
+	FixedStrings    bool
+	CaseInsensitive bool
+	ContextLines    int
+	// IgnoreFiles are extra ignore files, either absolute or relative to the
+	// working directory, on top of the ignore files found in the repository
+	IgnoreFiles []string
+	// Files limits the search to the given files, relative to the working
+	// directory. All files that aren't ignored are searched when empty.
+	Files []string
+	// Subdirectory limits the search to files within it, relative to the
+	// working directory, when Files is empty. Output paths remain relative to
+	// the working directory.
+	Subdirectory string
+}
+
+// SearchActivity searches the environment's working directory without
+// shelling out to rg. Output and exit statuses follow rg's conventions: 1 when
+// nothing matched and 2 along with a message on stderr for invalid input.
+func SearchActivity(ctx context.Context, input SearchActivityInput) (env.EnvRunCommandOutput, error) {
+	baseDir, err := filepath.Abs(input.EnvContainer.Env.GetWorkingDirectory())
+	if err != nil {
+		return env.EnvRunCommandOutput{}, err
+	}
+
+	files := input.Files
+	if len(files) == 0 {
+		ignoreFiles := make([]string, len(input.IgnoreFiles))
+		for i, ignoreFile := range input.IgnoreFiles {
+			if !filepath.IsAbs(ignoreFile) {
+				ignoreFile = filepath.Join(baseDir, ignoreFile)
+			}
+			ignoreFiles[i] = ignoreFile
+		}
+		files, err = ListFiles(filepath.Join(baseDir, input.Subdirectory), ignoreFiles)
+		if err != nil {
+			return env.EnvRunCommandOutput{}, err
+		}
+		if input.Subdirectory != "" {
+			subdirectory := filepath.ToSlash(input.Subdirectory)
+			for i, file := range files {
+				files[i] = path.Join(subdirectory, file)
+			}
+		}
+	}
+	if input.Mode == SearchModeFiles {
+		return linesOutput(files), nil
+	}
+
+	re, err := Query{
+		SearchTerm:      input.SearchTerm,
+		FixedStrings:    input.FixedStrings,
+		CaseInsensitive: input.CaseInsensitive,
+	}.Compile()
+	if err != nil {
+		return env.EnvRunCommandOutput{Stderr: err.Error(), ExitStatus: 2}, nil
+	}
+
+	matchingFiles, err := FilesWithMatches(baseDir, files, re)
+	if err != nil {
+		return env.EnvRunCommandOutput{}, err
+	}
+	if input.Mode == SearchModeFilesWithMatches {
+		return linesOutput(matchingFiles), nil
+	}
+
+	output, err := Grep(baseDir, matchingFiles, re, input.ContextLines)
+	if err != nil {
+		return env.EnvRunCommandOutput{}, err
+	}
+	if output == "" {
+		return env.EnvRunCommandOutput{ExitStatus: 1}, nil
+	}
+	return env.EnvRunCommandOutput{Stdout: output}, nil
+}
+
+func linesOutput(lines []string) env.EnvRunCommandOutput {
+	if len(lines) == 0 {
+		return env.EnvRunCommandOutput{ExitStatus: 1}
+	}
+	return env.EnvRunCommandOutput{Stdout: strings.Join(lines, "\n") + "\n"}
+}
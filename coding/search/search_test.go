@@ -0,0 +1,206 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sidekick/env"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSearchTestFiles(t testing.TB, dir string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		fullPath := filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+		require.NoError(t, os.WriteFile(fullPath, []byte(content), 0644))
+	}
+}
+
+const goSource = `package main
+
+import "fmt"
+
+func helper() int {
+	x := 1
+	y := 2
+	return x + y
+}
+
+func main() {
+	value := helper()
+	fmt.Println(value)
+
+	other := value * 2
+	fmt.Println(other)
+	// value again
+}
+`
+
+func TestQueryCompile(t *testing.T) {
+	t.Parallel()
+
+	re, err := Query{SearchTerm: "a.b", FixedStrings: true}.Compile()
+	require.NoError(t, err)
+	assert.True(t, re.MatchString("xa.by"))
+	assert.False(t, re.MatchString("axb"))
+
+	re, err = Query{SearchTerm: "^foo", CaseInsensitive: true}.Compile()
+	require.NoError(t, err)
+	assert.True(t, re.MatchString("bar\nFOO"))
+
+	_, err = Query{SearchTerm: "foo("}.Compile()
+	assert.ErrorContains(t, err, RegexParseError)
+}
+
+func TestListFiles(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeSearchTestFiles(t, dir, map[string]string{
+		".gitignore":        "*.log\n",
+		".sideignore":       "generated/\n",
+		".hidden/file.txt":  "",
+		".git/config":       "",
+		"a.go":              "",
+		"debug.log":         "",
+		"generated/gen.go":  "",
+		"sub/b.go":          "",
+		"sub/vendor/c.go":   "",
+		"extra-ignore-file": "",
+	})
+	coreIgnore := filepath.Join(t.TempDir(), "core-ignore")
+	require.NoError(t, os.WriteFile(coreIgnore, []byte("vendor/\nextra-ignore-file\n"), 0644))
+
+	files, err := ListFiles(dir, []string{coreIgnore})
+	require.NoError(t, err)
+	assert.Equal(t, []string{".gitignore", ".hidden/file.txt", ".sideignore", "a.go", "sub/b.go"}, files)
+}
+
+func TestFilesWithMatches(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeSearchTestFiles(t, dir, map[string]string{
+		"a.txt":   "hello world\n",
+		"b.txt":   "goodbye\n",
+		"c.txt":   "say hello\n",
+		"bin.dat": "hello\x00world\n",
+	})
+	re, err := Query{SearchTerm: "hello"}.Compile()
+	require.NoError(t, err)
+
+	files, err := FilesWithMatches(dir, []string{"a.txt", "b.txt", "bin.dat", "c.txt"}, re)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt", "c.txt"}, files)
+
+	_, err = FilesWithMatches(dir, []string{"missing.txt"}, re)
+	assert.Error(t, err)
+}
+
+// TestGrep_matchesGitGrep checks that the output is identical to git grep's,
+// which tools parsing search results depend on
+func TestGrep_matchesGitGrep(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	writeSearchTestFiles(t, dir, map[string]string{
+		"main.go":     goSource,
+		"other.go":    "package main\n\nvar value = 1\n",
+		"notes.txt":   "nothing to see\n",
+		"no_eol.txt":  "first\nvalue",
+		"indented.py": "    value = 1\n    other = 2\n\n    value = 3\n",
+	})
+	files := []string{"indented.py", "main.go", "no_eol.txt", "notes.txt", "other.go"}
+
+	for _, searchTerm := range []string{"value", "fmt", "return", "^func", "x|y"} {
+		for _, contextLines := range []int{0, 1, 2, 5} {
+			t.Run(fmt.Sprintf("%s with %d context lines", searchTerm, contextLines), func(t *testing.T) {
+				t.Parallel()
+				cmd := exec.Command("git", append([]string{"grep", "--no-index", "--show-function", "--heading", "--line-number",
+					"--context", fmt.Sprint(contextLines), "-E", "--", searchTerm}, files...)...)
+				cmd.Dir = dir
+				expected, err := cmd.Output()
+				require.NoError(t, err)
+
+				re, err := Query{SearchTerm: searchTerm}.Compile()
+				require.NoError(t, err)
+				output, err := Grep(dir, files, re, contextLines)
+				require.NoError(t, err)
+				assert.Equal(t, string(expected), output)
+			})
+		}
+	}
+}
+
+func TestSearchActivity(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeSearchTestFiles(t, dir, map[string]string{
+		".gitignore":  "ignored.go\n",
+		"main.go":     goSource,
+		"ignored.go":  "value\n",
+		"README.md":   "Value\n",
+		"sub/util.go": "package sub\n",
+	})
+	envContainer := env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: dir}}
+	ctx := context.Background()
+
+	output, err := SearchActivity(ctx, SearchActivityInput{EnvContainer: envContainer, Mode: SearchModeFiles})
+	require.NoError(t, err)
+	assert.Equal(t, ".gitignore\nREADME.md\nmain.go\nsub/util.go\n", output.Stdout)
+
+	output, err = SearchActivity(ctx, SearchActivityInput{EnvContainer: envContainer, Mode: SearchModeFilesWithMatches, SearchTerm: "value", CaseInsensitive: true})
+	require.NoError(t, err)
+	assert.Equal(t, "README.md\nmain.go\n", output.Stdout)
+
+	output, err = SearchActivity(ctx, SearchActivityInput{EnvContainer: envContainer, Mode: SearchModeFiles, Subdirectory: "sub"})
+	require.NoError(t, err)
+	assert.Equal(t, "sub/util.go\n", output.Stdout)
+
+	output, err = SearchActivity(ctx, SearchActivityInput{EnvContainer: envContainer, Mode: SearchModeGrep, SearchTerm: "Value", Files: []string{"README.md", "main.go"}})
+	require.NoError(t, err)
+	assert.Equal(t, "README.md\n1:Value\n", output.Stdout)
+
+	output, err = SearchActivity(ctx, SearchActivityInput{EnvContainer: envContainer, Mode: SearchModeGrep, SearchTerm: "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, output.ExitStatus)
+	assert.Empty(t, output.Stdout)
+
+	output, err = SearchActivity(ctx, SearchActivityInput{EnvContainer: envContainer, Mode: SearchModeGrep, SearchTerm: "value("})
+	require.NoError(t, err)
+	assert.Equal(t, 2, output.ExitStatus)
+	assert.Contains(t, output.Stderr, RegexParseError)
+}
+
+func BenchmarkSearch(b *testing.B) {
+	dir := b.TempDir()
+	files := map[string]string{}
+	for i := 0; i < 2000; i++ {
+		content := strings.Repeat(goSource, 20)
+		if i%100 == 0 {
+			content += "// needle\n"
+		}
+		files[fmt.Sprintf("pkg%d/file%d.go", i%50, i)] = content
+	}
+	writeSearchTestFiles(b, dir, files)
+	re, err := Query{SearchTerm: "need(le|ful)"}.Compile()
+	require.NoError(b, err)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		files, err := ListFiles(dir, nil)
+		require.NoError(b, err)
+		matchingFiles, err := FilesWithMatches(dir, files, re)
+		require.NoError(b, err)
+		_, err = Grep(dir, matchingFiles, re, 3)
+		require.NoError(b, err)
+	}
+}
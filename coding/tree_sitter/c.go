@@ -0,0 +1,73 @@
+package tree_sitter
+
+import (
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// writeCSignatureCapture writes signatures based on the corresponding
+// signature_queries/signature_c.scm.mustache. Function definitions are written
+// without their bodies, while prototypes, globals, types and macros are
+// written in full.
+func writeCSignatureCapture(out *strings.Builder, sourceCode *[]byte, c sitter.QueryCapture, name string) {
+	if !isCFileScope(c.Node) {
+		return
+	}
+	switch name {
+	case "function.declaration":
+		out.WriteString(declarationHeader(sourceCode, c.Node, "body"))
+	case "struct.declaration", "union.declaration", "enum.declaration":
+		if isCTypedefSpecifier(c.Node) {
+			// the typedef's signature already includes the type
+			return
+		}
+		out.WriteString(c.Node.Content(*sourceCode))
+	case "declaration.declaration", "typedef.declaration", "macro.declaration":
+		out.WriteString(strings.TrimSpace(c.Node.Content(*sourceCode)))
+	}
+}
+
+func writeCSymbolCapture(out *strings.Builder, sourceCode *[]byte, c sitter.QueryCapture, name string) {
+	if !isCFileScope(c.Node) {
+		return
+	}
+	switch name {
+	case "function.declarator", "declaration.declarator", "field.declarator":
+		out.WriteString(cDeclaratorName(sourceCode, c.Node))
+	case "struct.name", "union.name", "enum.name", "typedef.name", "macro.name":
+		out.WriteString(c.Node.Content(*sourceCode))
+	}
+}
+
+// cDeclaratorName finds the name within a possibly nested declarator, eg
+// "name" in "*name(void)" or "counter" in "counter = 0". Qualified names like
+// "Foo::bar" are returned as "Foo.bar".
+func cDeclaratorName(sourceCode *[]byte, node *sitter.Node) string {
+	for {
+		if inner := node.ChildByFieldName("declarator"); inner != nil {
+			node = inner
+		} else if node.Type() == "parenthesized_declarator" && node.NamedChildCount() > 0 {
+			node = node.NamedChild(0)
+		} else {
+			break
+		}
+	}
+	return strings.ReplaceAll(node.Content(*sourceCode), "::", ".")
+}
+
+// isCFileScope reports whether the node is declared outside of any function
+// body, eg not a local variable
+func isCFileScope(node *sitter.Node) bool {
+	for current := node.Parent(); current != nil; current = current.Parent() {
+		if current.Type() == "compound_statement" {
+			return false
+		}
+	}
+	return true
+}
+
+func isCTypedefSpecifier(node *sitter.Node) bool {
+	parent := node.Parent()
+	return parent != nil && parent.Type() == "type_definition"
+}
@@ -0,0 +1,251 @@
+package tree_sitter
+
+import (
+	"os"
+	"sidekick/utils"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFileSignaturesStringC(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			code:     "",
+			expected: "",
+		},
+		{
+			name:     "function",
+			code:     "int add(int a, int b) { return a + b; }",
+			expected: "int add(int a, int b)\n---\n",
+		},
+		{
+			name:     "function returning pointer",
+			code:     "static inline unsigned long long *make(struct point *p, ...) { return 0; }",
+			expected: "static inline unsigned long long *make(struct point *p, ...)\n---\n",
+		},
+		{
+			name:     "prototype",
+			code:     "static char *name(void);",
+			expected: "static char *name(void);\n---\n",
+		},
+		{
+			name:     "struct, union and enum",
+			code:     "struct point { int x; int y; };\nunion value { int i; float f; };\nenum color { RED, GREEN };",
+			expected: "struct point { int x; int y; }\n---\nunion value { int i; float f; }\n---\nenum color { RED, GREEN }\n---\n",
+		},
+		{
+			name:     "typedefs",
+			code:     "typedef struct point point_t;\ntypedef struct { int a; } anon_t;",
+			expected: "typedef struct point point_t;\n---\ntypedef struct { int a; } anon_t;\n---\n",
+		},
+		{
+			name:     "global variables",
+			code:     "int counter = 0;\nextern const char *version;",
+			expected: "int counter = 0;\n---\nextern const char *version;\n---\n",
+		},
+		{
+			name:     "macros",
+			code:     "#define MAX 10\n#define SQUARE(x) ((x) * (x))",
+			expected: "#define MAX 10\n---\n#define SQUARE(x) ((x) * (x))\n---\n",
+		},
+		{
+			name:     "local variables are skipped",
+			code:     "void run(void) { int i = 0; struct local { int a; } l; }",
+			expected: "void run(void)\n---\n",
+		},
+		{
+			name:     "declarations within include guards",
+			code:     "#ifndef FOO_H\n#define FOO_H\nint foo(void);\n#endif",
+			expected: "#define FOO_H\n---\nint foo(void);\n---\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "c", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			result, err := GetFileSignaturesString(filePath)
+			assert.Nil(t, err)
+			if result != tc.expected {
+				t.Errorf("GetFileSignaturesString returned incorrect result. Expected:\n%s\nGot:\n%s", utils.PanicJSON(tc.expected), utils.PanicJSON(result))
+			}
+		})
+	}
+}
+
+func TestGetFileSymbolsStringC(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			code:     "",
+			expected: "",
+		},
+		{
+			name:     "functions and prototypes",
+			code:     "int add(int a, int b) { return a + b; }\nstatic char *name(void);",
+			expected: "add, name",
+		},
+		{
+			name:     "types, variables and macros",
+			code:     "#define MAX 10\nstruct point { int x; };\ntypedef struct point point_t;\nenum color { RED };\nint counter = 0;",
+			expected: "MAX, point, point_t, color, counter",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "c", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			symbolsString, err := GetFileSymbolsString(filePath)
+			if err != nil {
+				t.Fatalf("Failed to get symbols: %v", err)
+			}
+			assert.Equal(t, tc.expected, symbolsString)
+		})
+	}
+}
+
+func TestGetFileHeadersStringC(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			code:     "",
+			expected: "",
+		},
+		{
+			name:     "includes",
+			code:     "#include <stdio.h>\n#include \"foo.h\"\n\nint main(void) { return 0; }",
+			expected: "#include <stdio.h>\n#include \"foo.h\"\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "c", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			result, err := GetFileHeadersString(filePath, 0)
+			assert.Nil(t, err)
+			if result != tc.expected {
+				t.Errorf("GetFileHeadersString returned incorrect result. Expected:\n%s\nGot:\n%s", utils.PanicJSON(tc.expected), utils.PanicJSON(result))
+			}
+		})
+	}
+}
+
+func TestGetSymbolDefinitionC(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name               string
+		symbolName         string
+		code               string
+		expectedDefinition string
+		expectedError      string
+	}{
+		{
+			name:          "symbol not found",
+			symbolName:    "missing",
+			code:          "int main(void) { return 0; }",
+			expectedError: "symbol not found: missing",
+		},
+		{
+			name:       "function with comment",
+			symbolName: "add",
+			code: `#include <stdio.h>
+
+// Adds numbers
+int add(int a, int b) {
+    return a + b;
+}`,
+			expectedDefinition: `// Adds numbers
+int add(int a, int b) {
+    return a + b;
+}`,
+		},
+		{
+			name:               "pointer-returning prototype",
+			symbolName:         "name",
+			code:               "static char *name(void);",
+			expectedDefinition: "static char *name(void);",
+		},
+		{
+			name:               "struct",
+			symbolName:         "point",
+			code:               "int x;\nstruct point {\n    int x;\n};",
+			expectedDefinition: "struct point {\n    int x;\n};",
+		},
+		{
+			name:               "typedef",
+			symbolName:         "point_t",
+			code:               "typedef struct point point_t;",
+			expectedDefinition: "typedef struct point point_t;",
+		},
+		{
+			name:               "macro",
+			symbolName:         "SQUARE",
+			code:               "#define SQUARE(x) ((x) * (x))",
+			expectedDefinition: "#define SQUARE(x) ((x) * (x))",
+		},
+		{
+			name:               "global variable",
+			symbolName:         "counter",
+			code:               "int counter = 0;",
+			expectedDefinition: "int counter = 0;",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "c", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			definition, err := GetSymbolDefinitionsString(filePath, tc.symbolName, 0)
+			if err != nil {
+				if tc.expectedError == "" {
+					t.Fatalf("Unexpected error: %v", err)
+				} else if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("Expected error: %s, got: %v", tc.expectedError, err)
+				}
+			}
+			if strings.TrimSuffix(definition, "\n") != strings.TrimSuffix(tc.expectedDefinition, "\n") {
+				t.Errorf("Expected definition:\n%s\nGot:\n%s", utils.PanicJSON(tc.expectedDefinition), utils.PanicJSON(definition))
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package tree_sitter
+
+import (
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// writeCppSignatureCapture writes signatures based on the corresponding
+// signature_queries/signature_cpp.scm.mustache. Classes, structs and
+// namespaces are written without their bodies, with members written
+// separately and indented, and anything C-like is left to
+// writeCSignatureCapture.
+func writeCppSignatureCapture(out *strings.Builder, sourceCode *[]byte, c sitter.QueryCapture, name string) {
+	if !isCFileScope(c.Node) {
+		return
+	}
+	switch name {
+	case "function.declaration":
+		writeCppIndentLevel(c.Node, out)
+		out.WriteString(cppDeclarationHeader(sourceCode, c.Node))
+	case "class.declaration", "struct.declaration", "union.declaration":
+		if isCTypedefSpecifier(c.Node) {
+			return
+		}
+		writeCppIndentLevel(c.Node, out)
+		out.WriteString(cppDeclarationHeader(sourceCode, c.Node))
+	case "namespace.declaration":
+		out.WriteString(declarationHeader(sourceCode, c.Node, "body"))
+	case "declaration.declaration", "field.declaration", "type_alias.declaration":
+		writeCppIndentLevel(c.Node, out)
+		start := c.Node
+		if parent := c.Node.Parent(); parent != nil && parent.Type() == "template_declaration" {
+			start = parent
+		}
+		out.WriteString(strings.TrimSpace(string((*sourceCode)[start.StartByte():c.Node.EndByte()])))
+	case "enum.declaration":
+		if isCTypedefSpecifier(c.Node) {
+			return
+		}
+		writeCppIndentLevel(c.Node, out)
+		out.WriteString(c.Node.Content(*sourceCode))
+	default:
+		writeCSignatureCapture(out, sourceCode, c, name)
+	}
+}
+
+func writeCppSymbolCapture(out *strings.Builder, sourceCode *[]byte, c sitter.QueryCapture, name string) {
+	switch name {
+	case "class.name", "namespace.name", "type_alias.name":
+		if isCFileScope(c.Node) {
+			out.WriteString(c.Node.Content(*sourceCode))
+		}
+	default:
+		writeCSymbolCapture(out, sourceCode, c, name)
+	}
+}
+
+// cppDeclarationHeader is like declarationHeader, but also includes any
+// template prefix, eg "template <typename T>"
+func cppDeclarationHeader(sourceCode *[]byte, node *sitter.Node) string {
+	parent := node.Parent()
+	if parent == nil || parent.Type() != "template_declaration" {
+		return declarationHeader(sourceCode, node, "body")
+	}
+	end := node.EndByte()
+	if body := node.ChildByFieldName("body"); body != nil {
+		end = body.StartByte()
+	}
+	return strings.TrimSpace(string((*sourceCode)[parent.StartByte():end]))
+}
+
+// getCppIndentLevel returns the number of class, struct or union bodies the
+// node is nested within. Namespaces don't add indentation, following the usual
+// C++ formatting convention.
+func getCppIndentLevel(node *sitter.Node) int {
+	level := 0
+	for current := node.Parent(); current != nil; current = current.Parent() {
+		if current.Type() == "field_declaration_list" {
+			level++
+		}
+	}
+	return level
+}
+
+func writeCppIndentLevel(node *sitter.Node, out *strings.Builder) {
+	level := getCppIndentLevel(node)
+	for i := 0; i < level; i++ {
+		out.WriteString("\t")
+	}
+}
@@ -0,0 +1,253 @@
+package tree_sitter
+
+import (
+	"os"
+	"sidekick/utils"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFileSignaturesStringCpp(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			code:     "",
+			expected: "",
+		},
+		{
+			name: "class with members",
+			code: `class Point : public Base {
+public:
+  Point(int x, int y);
+  ~Point();
+  int norm() const { return x_; }
+  static int count;
+  virtual void draw() = 0;
+private:
+  int x_;
+};`,
+			expected: "class Point : public Base\n---\n\tPoint(int x, int y);\n---\n\t~Point();\n---\n\tint norm() const\n---\n\tstatic int count;\n---\n\tvirtual void draw() = 0;\n---\n\tint x_;\n---\n",
+		},
+		{
+			name:     "class template",
+			code:     "template <typename T>\nclass Box { T value; };",
+			expected: "template <typename T>\nclass Box\n---\n\tT value;\n---\n",
+		},
+		{
+			name:     "function template",
+			code:     "template <typename T> T max(T a, T b) { return a; }",
+			expected: "template <typename T> T max(T a, T b)\n---\n",
+		},
+		{
+			name:     "namespace",
+			code:     "namespace geo {\nstruct Size { int w; };\n}",
+			expected: "namespace geo\n---\nstruct Size\n---\n\tint w;\n---\n",
+		},
+		{
+			name:     "out of line member definitions",
+			code:     "void geo::Point::draw() {}\nint Foo::bar(int a) const { return a; }",
+			expected: "void geo::Point::draw()\n---\nint Foo::bar(int a) const\n---\n",
+		},
+		{
+			name:     "enum class and type alias",
+			code:     "enum class Color { Red, Green };\nusing Id = int;",
+			expected: "enum class Color { Red, Green }\n---\nusing Id = int;\n---\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "cpp", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			result, err := GetFileSignaturesString(filePath)
+			assert.Nil(t, err)
+			if result != tc.expected {
+				t.Errorf("GetFileSignaturesString returned incorrect result. Expected:\n%s\nGot:\n%s", utils.PanicJSON(tc.expected), utils.PanicJSON(result))
+			}
+		})
+	}
+}
+
+func TestGetFileSymbolsStringCpp(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			code:     "",
+			expected: "",
+		},
+		{
+			name:     "namespace, class and members",
+			code:     "namespace geo {\nclass Point {\n  int norm() const;\n  int x_;\n};\n}",
+			expected: "geo, Point, norm, x_",
+		},
+		{
+			name:     "out of line member definitions",
+			code:     "void geo::Point::draw() {}\nint Foo::bar(int a) const { return a; }",
+			expected: "geo.Point.draw, Foo.bar",
+		},
+		{
+			name:     "header file parsed as C++",
+			code:     "#define MAX 10\nstruct point { int x; };\nint add(int a, int b);",
+			expected: "MAX, point, x, add",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			extension := "cpp"
+			if strings.HasPrefix(tc.name, "header") {
+				extension = "h"
+			}
+			filePath, err := utils.WriteTestTempFile(t, extension, tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			symbolsString, err := GetFileSymbolsString(filePath)
+			if err != nil {
+				t.Fatalf("Failed to get symbols: %v", err)
+			}
+			assert.Equal(t, tc.expected, symbolsString)
+		})
+	}
+}
+
+func TestGetFileHeadersStringCpp(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			code:     "",
+			expected: "",
+		},
+		{
+			name:     "includes and using declarations",
+			code:     "#include <vector>\n#include \"foo.hpp\"\nusing namespace std;\nusing std::string;\n\nint main() { return 0; }",
+			expected: "#include <vector>\n#include \"foo.hpp\"\nusing namespace std;\nusing std::string;\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "cpp", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			result, err := GetFileHeadersString(filePath, 0)
+			assert.Nil(t, err)
+			if result != tc.expected {
+				t.Errorf("GetFileHeadersString returned incorrect result. Expected:\n%s\nGot:\n%s", utils.PanicJSON(tc.expected), utils.PanicJSON(result))
+			}
+		})
+	}
+}
+
+func TestGetSymbolDefinitionCpp(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name               string
+		symbolName         string
+		code               string
+		expectedDefinition string
+		expectedError      string
+	}{
+		{
+			name:          "symbol not found",
+			symbolName:    "Missing",
+			code:          "int main() { return 0; }",
+			expectedError: "symbol not found: Missing",
+		},
+		{
+			name:       "class template includes template prefix",
+			symbolName: "Box",
+			code: `// A box
+template <typename T>
+class Box {
+  Box(T value);
+  T value;
+};`,
+			expectedDefinition: `// A box
+template <typename T>
+class Box {
+  Box(T value);
+  T value;
+};`,
+		},
+		{
+			name:       "method declaration and out of line definition",
+			symbolName: "draw",
+			code: `class Point {
+  void draw();
+};
+void Point::draw() {}`,
+			expectedDefinition: "  void draw();\nvoid Point::draw() {}",
+		},
+		{
+			name:               "qualified method name",
+			symbolName:         "Foo.bar",
+			code:               "int Foo::bar(int a) const { return a; }",
+			expectedDefinition: "int Foo::bar(int a) const { return a; }",
+		},
+		{
+			name:               "function template",
+			symbolName:         "max",
+			code:               "template <typename T> T max(T a, T b) { return a; }",
+			expectedDefinition: "template <typename T> T max(T a, T b) { return a; }",
+		},
+		{
+			name:               "type alias",
+			symbolName:         "Id",
+			code:               "using Id = int;",
+			expectedDefinition: "using Id = int;",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "cpp", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			definition, err := GetSymbolDefinitionsString(filePath, tc.symbolName, 0)
+			if err != nil {
+				if tc.expectedError == "" {
+					t.Fatalf("Unexpected error: %v", err)
+				} else if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("Expected error: %s, got: %v", tc.expectedError, err)
+				}
+			}
+			if strings.TrimSuffix(definition, "\n") != strings.TrimSuffix(tc.expectedDefinition, "\n") {
+				t.Errorf("Expected definition:\n%s\nGot:\n%s", utils.PanicJSON(tc.expectedDefinition), utils.PanicJSON(definition))
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package tree_sitter
+
+import (
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// writeCsharpSignatureCapture writes signatures based on the corresponding
+// signature_queries/signature_csharp.scm.mustache
+func writeCsharpSignatureCapture(out *strings.Builder, sourceCode *[]byte, c sitter.QueryCapture, name string) {
+	switch name {
+	case "namespace.declaration", "class.declaration", "interface.declaration", "struct.declaration", "record.declaration",
+		"method.declaration", "constructor.declaration":
+		writeCsharpIndentLevel(c.Node, out)
+		out.WriteString(strings.TrimSuffix(declarationHeader(sourceCode, c.Node, "body"), ";"))
+	case "property.declaration":
+		writeCsharpIndentLevel(c.Node, out)
+		writeCsharpPropertySignature(out, sourceCode, c.Node)
+	case "enum.declaration", "delegate.declaration", "field.declaration":
+		writeCsharpIndentLevel(c.Node, out)
+		out.WriteString(c.Node.Content(*sourceCode))
+	}
+}
+
+// writeCsharpPropertySignature writes a property without any accessor bodies
+// or initializer, eg "public int Count { get; private set; }"
+func writeCsharpPropertySignature(out *strings.Builder, sourceCode *[]byte, node *sitter.Node) {
+	accessors := node.ChildByFieldName("accessors")
+	if accessors == nil {
+		out.WriteString(declarationHeader(sourceCode, node, "value"))
+		return
+	}
+	out.WriteString(declarationHeader(sourceCode, node, "accessors"))
+	out.WriteString(" {")
+	for i := 0; i < int(accessors.NamedChildCount()); i++ {
+		accessor := accessors.NamedChild(i)
+		if accessor.Type() != "accessor_declaration" {
+			continue
+		}
+		out.WriteString(" ")
+		out.WriteString(strings.TrimSuffix(declarationHeader(sourceCode, accessor, "body"), ";"))
+		out.WriteString(";")
+	}
+	out.WriteString(" }")
+}
+
+func writeCsharpSymbolCapture(out *strings.Builder, sourceCode *[]byte, c sitter.QueryCapture, name string) {
+	switch name {
+	case "class.name", "interface.name", "struct.name", "record.name", "enum.name", "delegate.name",
+		"method.name", "property.name", "field.name":
+		out.WriteString(c.Node.Content(*sourceCode))
+	}
+}
+
+// getCsharpIndentLevel returns the number of declaration ancestors, eg
+// namespaces and classes, between the node and the compilation unit
+func getCsharpIndentLevel(node *sitter.Node) int {
+	level := 0
+	for current := node.Parent(); current != nil; current = current.Parent() {
+		if strings.HasSuffix(current.Type(), "_declaration") {
+			level++
+		}
+	}
+	return level
+}
+
+func writeCsharpIndentLevel(node *sitter.Node, out *strings.Builder) {
+	level := getCsharpIndentLevel(node)
+	for i := 0; i < level; i++ {
+		out.WriteString("\t")
+	}
+}
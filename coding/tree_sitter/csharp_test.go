@@ -0,0 +1,226 @@
+package tree_sitter
+
+import (
+	"os"
+	"sidekick/utils"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFileSignaturesStringCsharp(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			code:     "",
+			expected: "",
+		},
+		{
+			name: "class in namespace",
+			code: `namespace Geo.Shapes
+{
+    public class Point<T> : IShape where T : struct
+    {
+        public const int Max = 10;
+        private readonly T x;
+        public T X { get; set; }
+        public Point(T x) { this.x = x; }
+        public double Area() => 0;
+        private static void Helper(int a, string b) { }
+    }
+}`,
+			expected: "namespace Geo.Shapes\n---\n\tpublic class Point<T> : IShape where T : struct\n---\n\t\tpublic const int Max = 10;\n---\n\t\tprivate readonly T x;\n---\n\t\tpublic T X { get; set; }\n---\n\t\tpublic Point(T x)\n---\n\t\tpublic double Area()\n---\n\t\tprivate static void Helper(int a, string b)\n---\n",
+		},
+		{
+			name:     "interface",
+			code:     "public interface IShape { double Area(); }",
+			expected: "public interface IShape\n---\n\tdouble Area()\n---\n",
+		},
+		{
+			name:     "enum, struct, record and delegate",
+			code:     "public enum Color { Red, Green }\npublic struct Size { public int W; }\npublic record Person(string Name);\npublic delegate void Handler(object sender);",
+			expected: "public enum Color { Red, Green }\n---\npublic struct Size\n---\n\tpublic int W;\n---\npublic record Person(string Name)\n---\npublic delegate void Handler(object sender);\n---\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "cs", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			result, err := GetFileSignaturesString(filePath)
+			assert.Nil(t, err)
+			if result != tc.expected {
+				t.Errorf("GetFileSignaturesString returned incorrect result. Expected:\n%s\nGot:\n%s", utils.PanicJSON(tc.expected), utils.PanicJSON(result))
+			}
+		})
+	}
+}
+
+func TestGetFileSymbolsStringCsharp(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			code:     "",
+			expected: "",
+		},
+		{
+			name:     "class members",
+			code:     "class Point { const int Max = 1; int x; int X { get; set; } Point() {} double Area() => 0; }",
+			expected: "Point, Max, x, X, Area",
+		},
+		{
+			name:     "interface, enum and delegate",
+			code:     "interface IShape { double Area(); }\nenum Color { Red }\ndelegate void Handler();",
+			expected: "IShape, Area, Color, Handler",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "cs", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			symbolsString, err := GetFileSymbolsString(filePath)
+			if err != nil {
+				t.Fatalf("Failed to get symbols: %v", err)
+			}
+			assert.Equal(t, tc.expected, symbolsString)
+		})
+	}
+}
+
+func TestGetFileHeadersStringCsharp(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			code:     "",
+			expected: "",
+		},
+		{
+			name:     "using directives",
+			code:     "using System;\nusing System.Collections.Generic;\n\nclass Foo {}",
+			expected: "using System;\nusing System.Collections.Generic;\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "cs", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			result, err := GetFileHeadersString(filePath, 0)
+			assert.Nil(t, err)
+			if result != tc.expected {
+				t.Errorf("GetFileHeadersString returned incorrect result. Expected:\n%s\nGot:\n%s", utils.PanicJSON(tc.expected), utils.PanicJSON(result))
+			}
+		})
+	}
+}
+
+func TestGetSymbolDefinitionCsharp(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name               string
+		symbolName         string
+		code               string
+		expectedDefinition string
+		expectedError      string
+	}{
+		{
+			name:          "symbol not found",
+			symbolName:    "Missing",
+			code:          "class Foo {}",
+			expectedError: "symbol not found: Missing",
+		},
+		{
+			name:       "class with doc comment and constructor",
+			symbolName: "Point",
+			code: `using System;
+
+/// <summary>A point</summary>
+public class Point
+{
+    public Point(int x) { }
+}`,
+			expectedDefinition: `/// <summary>A point</summary>
+public class Point
+{
+    public Point(int x) { }
+}`,
+		},
+		{
+			name:       "method",
+			symbolName: "Area",
+			code: `public class Point
+{
+    // Computes the area
+    public double Area() => 0;
+}`,
+			expectedDefinition: "    // Computes the area\n    public double Area() => 0;",
+		},
+		{
+			name:               "property",
+			symbolName:         "X",
+			code:               "class Point {\n    public int X { get; set; }\n}",
+			expectedDefinition: "    public int X { get; set; }",
+		},
+		{
+			name:               "field",
+			symbolName:         "x",
+			code:               "class Point {\n    private readonly int x;\n}",
+			expectedDefinition: "    private readonly int x;",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "cs", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			definition, err := GetSymbolDefinitionsString(filePath, tc.symbolName, 0)
+			if err != nil {
+				if tc.expectedError == "" {
+					t.Fatalf("Unexpected error: %v", err)
+				} else if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("Expected error: %s, got: %v", tc.expectedError, err)
+				}
+			}
+			if strings.TrimSuffix(definition, "\n") != strings.TrimSuffix(tc.expectedDefinition, "\n") {
+				t.Errorf("Expected definition:\n%s\nGot:\n%s", utils.PanicJSON(tc.expectedDefinition), utils.PanicJSON(definition))
+			}
+		})
+	}
+}
@@ -439,6 +439,40 @@ type Something = string
 `,
 			expectedDefinition: `type Something = string`,
 		},
+		{
+			name:       "method qualified by receiver type",
+			symbolName: "Client.Start",
+			code: `package main
+
+func (s *Server) Start() error {
+	return nil
+}
+
+// Start starts the client
+func (c Client) Start() error {
+	return nil
+}
+
+func (l *List[T]) Start() {}
+`,
+			expectedDefinition: `// Start starts the client
+func (c Client) Start() error {
+	return nil
+}`,
+		},
+		{
+			name:       "generic method qualified by receiver type",
+			symbolName: "List.Start",
+			code: `package main
+
+func (s *Server) Start() error {
+	return nil
+}
+
+func (l *List[T]) Start() {}
+`,
+			expectedDefinition: `func (l *List[T]) Start() {}`,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1 @@
+(preproc_include) @header
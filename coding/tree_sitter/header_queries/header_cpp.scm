@@ -0,0 +1,2 @@
+(preproc_include) @header
+(translation_unit (using_declaration) @header)
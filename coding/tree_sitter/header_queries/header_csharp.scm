@@ -0,0 +1 @@
+(using_directive) @header
@@ -0,0 +1,12 @@
+(namespace_definition !body) @header
+(namespace_use_declaration) @header
+(program
+  (expression_statement
+    [
+      (include_expression)
+      (include_once_expression)
+      (require_expression)
+      (require_once_expression)
+    ]
+  ) @header
+)
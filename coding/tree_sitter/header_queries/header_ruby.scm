@@ -0,0 +1,6 @@
+(program
+  (call
+    method: (identifier) @method
+    (#match? @method "^(require|require_relative|load|autoload)$")
+  ) @header
+)
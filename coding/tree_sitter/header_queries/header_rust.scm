@@ -0,0 +1,3 @@
+(use_declaration) @header
+(extern_crate_declaration) @header
+(mod_item !body) @header
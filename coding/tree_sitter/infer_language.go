@@ -7,10 +7,16 @@ import (
 	"sidekick/utils"
 
 	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/c"
+	"github.com/smacker/go-tree-sitter/cpp"
+	"github.com/smacker/go-tree-sitter/csharp"
 	"github.com/smacker/go-tree-sitter/golang"
 	"github.com/smacker/go-tree-sitter/java"
 	"github.com/smacker/go-tree-sitter/kotlin"
+	"github.com/smacker/go-tree-sitter/php"
 	"github.com/smacker/go-tree-sitter/python"
+	"github.com/smacker/go-tree-sitter/ruby"
+	"github.com/smacker/go-tree-sitter/rust"
 	"github.com/smacker/go-tree-sitter/typescript/tsx"
 	"github.com/smacker/go-tree-sitter/typescript/typescript"
 )
@@ -35,6 +41,18 @@ func inferLanguageFromFilePath(filePath string) (string, *sitter.Language, error
 		return "java", java.GetLanguage(), nil
 	case "kotlin":
 		return "kotlin", kotlin.GetLanguage(), nil
+	case "rust":
+		return "rust", rust.GetLanguage(), nil
+	case "c":
+		return "c", c.GetLanguage(), nil
+	case "cpp":
+		return "cpp", cpp.GetLanguage(), nil
+	case "csharp":
+		return "csharp", csharp.GetLanguage(), nil
+	case "ruby":
+		return "ruby", ruby.GetLanguage(), nil
+	case "php":
+		return "php", php.GetLanguage(), nil
 	default:
 		return "", nil, fmt.Errorf("%w: %s", ErrFailedInferLanguage, filePath)
 	}
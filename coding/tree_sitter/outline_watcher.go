@@ -0,0 +1,308 @@
+package tree_sitter
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sidekick/common"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+// changes are processed in batches once files stop changing for this long,
+// since editors and git often touch many files at once
+const outlineWatcherSettleDuration = 500 * time.Millisecond
+
+// maxOutlineWatches bounds the watches a single OutlineWatcher may add, so
+// that large repositories fall back to outlining on demand instead of
+// exhausting the process's file descriptors or the system's inotify watches
+var maxOutlineWatches = 8192
+
+var errTooManyOutlineWatches = errors.New("too many files to watch for outline changes")
+
+// kqueue, used on macOS and the BSDs, needs a file descriptor for every file
+// within a watched directory, so those count towards the limit too
+var outlineWatchesIncludeFiles = runtime.GOOS == "darwin" || runtime.GOOS == "dragonfly" || strings.HasSuffix(runtime.GOOS, "bsd")
+
+// OutlineWatcher watches a directory for file changes, keeping the cached
+// signature outlines of its files up to date in the background, so that
+// GetDirectorySignatureOutlines only needs to process files that changed
+// since it was last called.
+type OutlineWatcher struct {
+	baseDir  string
+	watcher  *fsnotify.Watcher
+	onUpdate func(changedPaths []string)
+	// only accessed from the run goroutine once started
+	watchedDirs map[string]bool
+	// dirWatches counts the watches added for each watched directory, which
+	// add up to watchCount
+	dirWatches map[string]int
+	watchCount int
+	pending    map[string]bool
+	done       chan struct{}
+	stopped    chan struct{}
+	closeOnce  sync.Once
+	// settled is set once all seen changes were processed and passed to
+	// onUpdate
+	settled atomic.Bool
+	// exhausted is set once the directory outgrew maxOutlineWatches, after
+	// which changes are no longer watched
+	exhausted atomic.Bool
+}
+
+// WatchOutlines starts watching the directory, computing outlines for all its
+// files in the background. onUpdate is called from the watcher's goroutine
+// with the absolute paths of changed files after their outlines are
+// refreshed, so it should hand off any slow work.
+func WatchOutlines(baseDir string, onUpdate func(changedPaths []string)) (*OutlineWatcher, error) {
+	baseDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+
+	w := &OutlineWatcher{
+		baseDir:     baseDir,
+		watcher:     fsWatcher,
+		onUpdate:    onUpdate,
+		watchedDirs: map[string]bool{},
+		dirWatches:  map[string]int{},
+		pending:     map[string]bool{},
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+	if err := w.addDirectory(baseDir); err != nil {
+		fsWatcher.Close()
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+// Close stops watching
+func (w *OutlineWatcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		err = w.watcher.Close()
+		<-w.stopped
+	})
+	return err
+}
+
+// Settled reports whether every change seen so far, including the initial
+// outlining of all files, has been processed and passed to onUpdate. It never
+// is once the directory has too many files to keep watching.
+func (w *OutlineWatcher) Settled() bool {
+	return w.settled.Load() && !w.exhausted.Load()
+}
+
+// addDirectory watches the directory and its subdirectories, skipping ignored
+// ones, and queues the files in newly watched directories to be outlined
+func (w *OutlineWatcher) addDirectory(dir string) error {
+	newDirs := map[string]bool{}
+	watchDir := func(dir string) error {
+		if w.watchedDirs[dir] {
+			return nil
+		}
+		watches := 1
+		if outlineWatchesIncludeFiles {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				return fmt.Errorf("failed to list %s: %w", dir, err)
+			}
+			watches += len(entries)
+		}
+		if w.watchCount+watches > maxOutlineWatches {
+			return fmt.Errorf("%w: %s exceeds %d watches", errTooManyOutlineWatches, w.baseDir, maxOutlineWatches)
+		}
+		if err := w.watcher.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+		w.watchedDirs[dir] = true
+		w.dirWatches[dir] = watches
+		w.watchCount += watches
+		newDirs[dir] = true
+		return nil
+	}
+
+	if err := watchDir(dir); err != nil {
+		return err
+	}
+	return common.WalkCodeDirectory(dir, func(path string, entry fs.DirEntry) error {
+		if entry.IsDir() {
+			return watchDir(path)
+		}
+		if entry.Type().IsRegular() && newDirs[filepath.Dir(path)] {
+			w.pending[path] = true
+		}
+		return nil
+	})
+}
+
+func (w *OutlineWatcher) run() {
+	defer close(w.stopped)
+	// the initial outlines are computed right away
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if w.queue(event) {
+				timer.Reset(outlineWatcherSettleDuration)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warn().Err(err).Str("dir", w.baseDir).Msg("outline watcher error")
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				// changes were missed, so nothing can be trusted. re-adding
+				// existing watches is a no-op, but requeues all files.
+				w.settled.Store(false)
+				w.watchedDirs = map[string]bool{}
+				w.dirWatches = map[string]int{}
+				w.watchCount = 0
+				if err := w.addDirectory(w.baseDir); err != nil {
+					w.handleWatchError(err, w.baseDir, "failed to rescan directory after missed changes")
+				}
+				timer.Reset(outlineWatcherSettleDuration)
+			}
+		case <-timer.C:
+			w.process()
+			if len(w.pending) > 0 {
+				timer.Reset(outlineWatcherSettleDuration)
+			}
+		}
+	}
+}
+
+// queue records a change to be processed, returning false if it's irrelevant
+func (w *OutlineWatcher) queue(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod || filepath.Base(event.Name) == ".git" {
+		return false
+	}
+	// the old outline must not be used while waiting for the change to settle
+	w.settled.Store(false)
+	invalidateCachedFileOutline(event.Name)
+	if w.watchedDirs[event.Name] && (event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename)) {
+		delete(w.watchedDirs, event.Name)
+		w.watchCount -= w.dirWatches[event.Name]
+		delete(w.dirWatches, event.Name)
+		invalidateCachedOutlinesUnder(event.Name)
+	}
+	w.pending[event.Name] = true
+	return true
+}
+
+// process refreshes the outlines of all pending paths
+func (w *OutlineWatcher) process() {
+	paths := w.pending
+	w.pending = map[string]bool{}
+
+	ignoreManagers := map[string]*common.IgnoreManager{}
+	isIgnored := func(path string, isDir bool) bool {
+		dir := filepath.Dir(path)
+		ignoreManager, ok := ignoreManagers[dir]
+		if !ok {
+			var err error
+			ignoreManager, err = common.NewIgnoreManager(dir)
+			if err != nil {
+				log.Warn().Err(err).Str("dir", dir).Msg("failed to load ignore files")
+			}
+			ignoreManagers[dir] = ignoreManager
+		}
+		return ignoreManager != nil && ignoreManager.IsIgnored(path, isDir)
+	}
+
+	var changedPaths []string
+	for path := range paths {
+		info, err := os.Lstat(path)
+		if err != nil {
+			// deleted files are no longer part of the outline, which matters
+			// as much as any other change
+			if !errors.Is(err, fs.ErrNotExist) {
+				log.Warn().Err(err).Str("path", path).Msg("failed to stat changed file")
+			}
+			changedPaths = append(changedPaths, path)
+			continue
+		}
+
+		switch {
+		case info.IsDir():
+			if w.watchedDirs[path] || isIgnored(path, true) {
+				continue
+			}
+			// files in new directories are outlined in the next batch
+			if err := w.addDirectory(path); err != nil {
+				w.handleWatchError(err, path, "failed to watch new directory")
+			}
+		case info.Mode().IsRegular():
+			name := filepath.Base(path)
+			if name == common.GitIgnoreType.String() || name == common.IgnoreType.String() || name == common.SideIgnoreType.String() {
+				// newly unignored directories need to be watched
+				if err := w.addDirectory(filepath.Dir(path)); err != nil {
+					w.handleWatchError(err, filepath.Dir(path), "failed to rescan directory after ignore file change")
+				}
+			}
+			if isIgnored(path, false) {
+				continue
+			}
+			// unsupported languages are expected to fail, and aren't cached
+			_, _ = getCachedFileSignaturesString(path)
+			changedPaths = append(changedPaths, path)
+		}
+	}
+
+	// changes made while processing are part of the next batch
+	w.drainEvents()
+
+	if w.onUpdate != nil && len(changedPaths) > 0 {
+		w.onUpdate(changedPaths)
+	}
+	w.settled.Store(len(w.pending) == 0)
+}
+
+func (w *OutlineWatcher) drainEvents() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.queue(event)
+		default:
+			return
+		}
+	}
+}
+
+// handleWatchError logs the error, and stops watching altogether when there
+// are too many files to watch, releasing the watches added so far
+func (w *OutlineWatcher) handleWatchError(err error, dir string, msg string) {
+	log.Warn().Err(err).Str("dir", dir).Msg(msg)
+	if errors.Is(err, errTooManyOutlineWatches) && !w.exhausted.Swap(true) {
+		// closing ends the run loop once the events channel is drained
+		if err := w.watcher.Close(); err != nil {
+			log.Warn().Err(err).Str("dir", w.baseDir).Msg("failed to close outline watcher")
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package tree_sitter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutlineWatcher(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	writeFile := func(relativePath, content string) string {
+		path := filepath.Join(dir, relativePath)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+	mainPath := writeFile("main.go", "package main\n\nfunc Foo() {}\n")
+	writeFile(".gitignore", "*.log\n")
+
+	updates := make(chan []string, 10)
+	watcher, err := WatchOutlines(dir, func(changedPaths []string) {
+		updates <- changedPaths
+	})
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	waitForUpdate := func() []string {
+		t.Helper()
+		select {
+		case changedPaths := <-updates:
+			return changedPaths
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for outline update")
+			return nil
+		}
+	}
+	isCached := func(path string) bool {
+		_, ok := cachedOutlines.Load(path)
+		return ok
+	}
+
+	assert.Contains(t, waitForUpdate(), mainPath)
+	assert.Eventually(t, watcher.Settled, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, isCached(mainPath))
+	outline, err := getCachedFileSignaturesString(mainPath)
+	require.NoError(t, err)
+	assert.Contains(t, outline, "func Foo()")
+
+	t.Run("changed file", func(t *testing.T) {
+		writeFile("main.go", "package main\n\nfunc Bar() {}\n")
+		assert.Equal(t, []string{mainPath}, waitForUpdate())
+		assert.True(t, isCached(mainPath))
+		outlines, err := GetDirectorySignatureOutlines(dir, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, outlines, 2)
+		assert.Contains(t, outlines[1].Content, "func Bar()")
+		assert.NotContains(t, outlines[1].Content, "func Foo()")
+	})
+
+	t.Run("ignored file", func(t *testing.T) {
+		writeFile("debug.log", "hello")
+		writeFile("other.go", "package main\n")
+		assert.Equal(t, []string{filepath.Join(dir, "other.go")}, waitForUpdate())
+	})
+
+	t.Run("new directory", func(t *testing.T) {
+		subPath := writeFile("sub/sub.go", "package sub\n\nfunc Sub() {}\n")
+		changedPaths := waitForUpdate()
+		if !assert.ObjectsAreEqual([]string{subPath}, changedPaths) {
+			// the directory was picked up before the file was written
+			changedPaths = waitForUpdate()
+		}
+		assert.Equal(t, []string{subPath}, changedPaths)
+		assert.True(t, isCached(subPath))
+
+		writeFile("sub/sub.go", "package sub\n\nfunc Sub2() {}\n")
+		assert.Equal(t, []string{subPath}, waitForUpdate())
+		outline, err := getCachedFileSignaturesString(subPath)
+		require.NoError(t, err)
+		assert.Contains(t, outline, "func Sub2()")
+	})
+
+	t.Run("removed directory", func(t *testing.T) {
+		subPath := filepath.Join(dir, "sub", "sub.go")
+		require.NoError(t, os.RemoveAll(filepath.Join(dir, "sub")))
+		waitForUpdate()
+		assert.False(t, isCached(subPath))
+	})
+
+	t.Run("stale cache", func(t *testing.T) {
+		// the checksum guards against changes the watcher hasn't seen yet
+		writeFile("main.go", "package main\n\nfunc Baz() {}\n")
+		outline, err := getCachedFileSignaturesString(mainPath)
+		require.NoError(t, err)
+		assert.Contains(t, outline, "func Baz()")
+	})
+
+	t.Run("close", func(t *testing.T) {
+		require.NoError(t, watcher.Close())
+	})
+}
+
+func TestOutlineWatcherTooManyWatches(t *testing.T) {
+	defaultMaxOutlineWatches := maxOutlineWatches
+	t.Cleanup(func() { maxOutlineWatches = defaultMaxOutlineWatches })
+	maxOutlineWatches = 3
+	if outlineWatchesIncludeFiles {
+		maxOutlineWatches = 6
+	}
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	for _, subdir := range []string{"a", "b", "c"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, subdir), 0755))
+	}
+	_, err = WatchOutlines(dir, nil)
+	assert.ErrorIs(t, err, errTooManyOutlineWatches)
+
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "c")))
+	watcher, err := WatchOutlines(dir, nil)
+	require.NoError(t, err)
+	defer watcher.Close()
+	assert.Eventually(t, watcher.Settled, 5*time.Second, 10*time.Millisecond)
+
+	// outgrowing the limit later stops watching for good
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "c"), 0755))
+	assert.Eventually(t, func() bool {
+		return watcher.exhausted.Load()
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.False(t, watcher.Settled())
+	require.NoError(t, watcher.Close())
+}
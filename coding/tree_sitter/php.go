@@ -0,0 +1,46 @@
+package tree_sitter
+
+import (
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// writePhpSignatureCapture writes signatures based on the corresponding
+// signature_queries/signature_php.scm.mustache
+func writePhpSignatureCapture(out *strings.Builder, sourceCode *[]byte, c sitter.QueryCapture, name string) {
+	switch name {
+	case "class.declaration", "interface.declaration", "trait.declaration", "function.declaration", "method.declaration":
+		writePhpIndentLevel(c.Node, out)
+		out.WriteString(strings.TrimSuffix(declarationHeader(sourceCode, c.Node, "body"), ";"))
+	case "const.declaration", "property.declaration":
+		writePhpIndentLevel(c.Node, out)
+		out.WriteString(c.Node.Content(*sourceCode))
+	}
+}
+
+func writePhpSymbolCapture(out *strings.Builder, sourceCode *[]byte, c sitter.QueryCapture, name string) {
+	switch name {
+	case "class.name", "interface.name", "trait.name", "function.name", "method.name", "const.name", "property.name":
+		out.WriteString(c.Node.Content(*sourceCode))
+	}
+}
+
+// getPhpIndentLevel returns the number of class, interface or trait
+// declarations the node is nested within
+func getPhpIndentLevel(node *sitter.Node) int {
+	level := 0
+	for current := node.Parent(); current != nil; current = current.Parent() {
+		if strings.HasSuffix(current.Type(), "_declaration") {
+			level++
+		}
+	}
+	return level
+}
+
+func writePhpIndentLevel(node *sitter.Node, out *strings.Builder) {
+	level := getPhpIndentLevel(node)
+	for i := 0; i < level; i++ {
+		out.WriteString("\t")
+	}
+}
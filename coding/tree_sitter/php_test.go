@@ -0,0 +1,207 @@
+package tree_sitter
+
+import (
+	"os"
+	"sidekick/utils"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFileSignaturesStringPhp(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			code:     "<?php\n",
+			expected: "",
+		},
+		{
+			name: "class",
+			code: `<?php
+final class Point extends Base implements Shape
+{
+    use HasFactory;
+    public const MAX = 10;
+    private $x = 0;
+    public static function origin(): static { return new static(0); }
+    abstract protected function area(): float;
+}`,
+			expected: "final class Point extends Base implements Shape\n---\n\tpublic const MAX = 10;\n---\n\tprivate $x = 0;\n---\n\tpublic static function origin(): static\n---\n\tabstract protected function area(): float\n---\n",
+		},
+		{
+			name:     "interface and trait",
+			code:     "<?php\ninterface Shape { public function area(): float; }\ntrait HasFactory { public function make() {} }",
+			expected: "interface Shape\n---\n\tpublic function area(): float\n---\ntrait HasFactory\n---\n\tpublic function make()\n---\n",
+		},
+		{
+			name:     "function and constant",
+			code:     "<?php\nfunction helper(int $a, ...$rest): ?string { return null; }\nconst VERSION = '1.0';",
+			expected: "function helper(int $a, ...$rest): ?string\n---\nconst VERSION = '1.0';\n---\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "php", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			result, err := GetFileSignaturesString(filePath)
+			assert.Nil(t, err)
+			if result != tc.expected {
+				t.Errorf("GetFileSignaturesString returned incorrect result. Expected:\n%s\nGot:\n%s", utils.PanicJSON(tc.expected), utils.PanicJSON(result))
+			}
+		})
+	}
+}
+
+func TestGetFileSymbolsStringPhp(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			code:     "<?php\n",
+			expected: "",
+		},
+		{
+			name:     "class members",
+			code:     "<?php\nclass Point {\n  const MAX = 1;\n  private $x;\n  public function area() {}\n}\nfunction helper() {}",
+			expected: "Point, MAX, $x, area, helper",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "php", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			symbolsString, err := GetFileSymbolsString(filePath)
+			if err != nil {
+				t.Fatalf("Failed to get symbols: %v", err)
+			}
+			assert.Equal(t, tc.expected, symbolsString)
+		})
+	}
+}
+
+func TestGetFileHeadersStringPhp(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "namespace, use and require",
+			code:     "<?php\nnamespace App\\Models;\n\nuse Illuminate\\Support\\Str;\nrequire_once 'helpers.php';\n\nclass Foo {}",
+			expected: "namespace App\\Models;\n\nuse Illuminate\\Support\\Str;\nrequire_once 'helpers.php';\n",
+		},
+		{
+			name:     "grouped use",
+			code:     "<?php\nuse App\\Contracts\\{Shape, Area};",
+			expected: "use App\\Contracts\\{Shape, Area};\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "php", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			result, err := GetFileHeadersString(filePath, 0)
+			assert.Nil(t, err)
+			if result != tc.expected {
+				t.Errorf("GetFileHeadersString returned incorrect result. Expected:\n%s\nGot:\n%s", utils.PanicJSON(tc.expected), utils.PanicJSON(result))
+			}
+		})
+	}
+}
+
+func TestGetSymbolDefinitionPhp(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name               string
+		symbolName         string
+		code               string
+		expectedDefinition string
+		expectedError      string
+	}{
+		{
+			name:          "symbol not found",
+			symbolName:    "Missing",
+			code:          "<?php\nclass Foo {}",
+			expectedError: "symbol not found: Missing",
+		},
+		{
+			name:       "class with doc comment",
+			symbolName: "Point",
+			code: `<?php
+/** A point */
+class Point
+{
+    public function area() { return 0; }
+}`,
+			expectedDefinition: `/** A point */
+class Point
+{
+    public function area() { return 0; }
+}`,
+		},
+		{
+			name:               "method",
+			symbolName:         "area",
+			code:               "<?php\nclass Point\n{\n    public function area() { return 0; }\n}",
+			expectedDefinition: "    public function area() { return 0; }",
+		},
+		{
+			name:               "property with or without sigil",
+			symbolName:         "x",
+			code:               "<?php\nclass Point\n{\n    private $x = 0;\n}",
+			expectedDefinition: "    private $x = 0;",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "php", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			definition, err := GetSymbolDefinitionsString(filePath, tc.symbolName, 0)
+			if err != nil {
+				if tc.expectedError == "" {
+					t.Fatalf("Unexpected error: %v", err)
+				} else if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("Expected error: %s, got: %v", tc.expectedError, err)
+				}
+			}
+			if strings.TrimSuffix(definition, "\n") != strings.TrimSuffix(tc.expectedDefinition, "\n") {
+				t.Errorf("Expected definition:\n%s\nGot:\n%s", utils.PanicJSON(tc.expectedDefinition), utils.PanicJSON(definition))
+			}
+		})
+	}
+}
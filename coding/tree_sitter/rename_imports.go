@@ -0,0 +1,437 @@
+package tree_sitter
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sidekick/coding/tree_sitter/language_bindings/vue"
+	"sidekick/common"
+	"sidekick/utils"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/golang"
+	"github.com/smacker/go-tree-sitter/typescript/tsx"
+	"github.com/smacker/go-tree-sitter/typescript/typescript"
+)
+
+// sourceEdit replaces a byte range within a file's source code
+type sourceEdit struct {
+	startByte uint32
+	endByte   uint32
+	newText   string
+}
+
+// UpdateImportsForRename rewrites imports so they keep referring to a file
+// that has already been moved from oldPath to newPath, both relative to the
+// base directory. It's a fallback for when no language server can do this,
+// and only handles:
+//
+//   - relative TypeScript imports (including in Vue files) of the moved file,
+//     and those within the moved file itself
+//   - the moved Go file's package clause, plus Go import paths of the old
+//     package when the move leaves its directory without any Go files
+//
+// It returns the original contents of each file that was updated, keyed by
+// relative path.
+func UpdateImportsForRename(baseDir, oldPath, newPath string) (map[string][]byte, error) {
+	oldPath = filepath.ToSlash(filepath.Clean(oldPath))
+	newPath = filepath.ToSlash(filepath.Clean(newPath))
+
+	originals := make(map[string][]byte)
+	if err := updateTypescriptImportsForRename(baseDir, oldPath, newPath, originals); err != nil {
+		return originals, err
+	}
+	if strings.HasSuffix(oldPath, ".go") && strings.HasSuffix(newPath, ".go") {
+		if err := updateGoImportsForRename(baseDir, oldPath, newPath, originals); err != nil {
+			return originals, err
+		}
+	}
+	return originals, nil
+}
+
+func recordOriginal(originals map[string][]byte, relativePath string, sourceCode []byte) {
+	if _, ok := originals[relativePath]; !ok {
+		originals[relativePath] = sourceCode
+	}
+}
+
+// walkRelativeFiles calls handleFile with the slash-separated relative path of
+// each code file within the base directory
+func walkRelativeFiles(baseDir string, handleFile func(relativePath string) error) error {
+	return common.WalkCodeDirectory(baseDir, func(filePath string, entry fs.DirEntry) error {
+		if entry.IsDir() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(baseDir, filePath)
+		if err != nil {
+			return err
+		}
+		return handleFile(filepath.ToSlash(relativePath))
+	})
+}
+
+// applySourceEdits applies non-overlapping edits to the file, returning
+// whether it changed
+func applySourceEdits(absolutePath string, sourceCode []byte, edits []sourceEdit) (bool, error) {
+	if len(edits) == 0 {
+		return false, nil
+	}
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].startByte > edits[j].startByte
+	})
+	updated := string(sourceCode)
+	for _, edit := range edits {
+		updated = updated[:edit.startByte] + edit.newText + updated[edit.endByte:]
+	}
+	if updated == string(sourceCode) {
+		return false, nil
+	}
+	if err := os.WriteFile(absolutePath, []byte(updated), 0644); err != nil {
+		return false, fmt.Errorf("failed to write updated imports to %s: %w", absolutePath, err)
+	}
+	return true, nil
+}
+
+const typescriptImportSourceQuery = `
+(import_statement source: (string (string_fragment) @source))
+(export_statement source: (string (string_fragment) @source))
+(call_expression
+  function: (identifier) @function
+  arguments: (arguments . (string (string_fragment) @source))
+  (#eq? @function "require"))
+(call_expression
+  function: (import)
+  arguments: (arguments . (string (string_fragment) @source)))
+`
+
+var javascriptExtensionPattern = regexp.MustCompile(`\.(js|jsx|mjs|cjs)$`)
+
+func updateTypescriptImportsForRename(baseDir, oldPath, newPath string, originals map[string][]byte) error {
+	return walkRelativeFiles(baseDir, func(relativePath string) error {
+		languageName := utils.InferLanguageNameFromFilePath(relativePath)
+		if languageName != "typescript" && languageName != "tsx" && languageName != "vue" {
+			return nil
+		}
+
+		absolutePath := filepath.Join(baseDir, relativePath)
+		sourceCode, err := os.ReadFile(absolutePath)
+		if err != nil {
+			return err
+		}
+		sources, err := typescriptImportSources(languageName, sourceCode)
+		if err != nil {
+			return err
+		}
+
+		isMovedFile := relativePath == newPath
+		importerDir := path.Dir(relativePath)
+		if isMovedFile {
+			importerDir = path.Dir(oldPath)
+		}
+
+		var edits []sourceEdit
+		for _, source := range sources {
+			specifier := string(sourceCode[source.startByte:source.endByte])
+			if !strings.HasPrefix(specifier, "./") && !strings.HasPrefix(specifier, "../") {
+				continue
+			}
+			target := path.Join(importerDir, specifier)
+			newSpecifier := ""
+			if newTarget, ok := renamedImportTarget(target, specifier, oldPath, newPath); ok {
+				newSpecifier = relativeImportSpecifier(path.Dir(relativePath), newTarget)
+			} else if isMovedFile {
+				newSpecifier = relativeImportSpecifier(path.Dir(newPath), target)
+			}
+			if newSpecifier != "" && newSpecifier != specifier {
+				source.newText = newSpecifier
+				edits = append(edits, source)
+			}
+		}
+
+		changed, err := applySourceEdits(absolutePath, sourceCode, edits)
+		if changed {
+			recordOriginal(originals, relativePath, sourceCode)
+		}
+		return err
+	})
+}
+
+// typescriptImportSources returns the byte ranges of import specifiers, as
+// source edits without any new text
+func typescriptImportSources(languageName string, sourceCode []byte) ([]sourceEdit, error) {
+	var sitterLanguage *sitter.Language
+	var tree *sitter.Tree
+	var err error
+	switch languageName {
+	case "vue":
+		sitterLanguage = typescript.GetLanguage()
+		parser := sitter.NewParser()
+		parser.SetLanguage(vue.GetLanguage())
+		vueTree, err := parser.ParseCtx(context.Background(), nil, sourceCode)
+		if err != nil {
+			return nil, err
+		}
+		defer vueTree.Close()
+		tree, err = GetVueEmbeddedTypescriptTree(vueTree, &sourceCode)
+		if err != nil || tree == nil {
+			return nil, err
+		}
+	default:
+		sitterLanguage = typescript.GetLanguage()
+		if languageName == "tsx" {
+			sitterLanguage = tsx.GetLanguage()
+		}
+		parser := sitter.NewParser()
+		parser.SetLanguage(sitterLanguage)
+		tree, err = parser.ParseCtx(context.Background(), nil, sourceCode)
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer tree.Close()
+
+	q, err := sitter.NewQuery([]byte(typescriptImportSourceQuery), sitterLanguage)
+	if err != nil {
+		return nil, fmt.Errorf("error creating import source query: %w", err)
+	}
+	qc := sitter.NewQueryCursor()
+	qc.Exec(q, tree.RootNode())
+
+	var sources []sourceEdit
+	for {
+		m, ok := qc.NextMatch()
+		if !ok {
+			break
+		}
+		m = qc.FilterPredicates(m, sourceCode)
+		for _, c := range m.Captures {
+			if q.CaptureNameForId(c.Index) == "source" {
+				sources = append(sources, sourceEdit{startByte: c.Node.StartByte(), endByte: c.Node.EndByte()})
+			}
+		}
+	}
+	return sources, nil
+}
+
+// renamedImportTarget determines whether an import target, ie the relative
+// specifier joined to the importer's directory, refers to the old path. If so,
+// it returns the equivalent target for the new path, in the same style, eg
+// without an extension, or with a .js extension for a .ts file.
+func renamedImportTarget(target, specifier, oldPath, newPath string) (string, bool) {
+	oldWithoutExt := strings.TrimSuffix(oldPath, path.Ext(oldPath))
+	newWithoutExt := strings.TrimSuffix(newPath, path.Ext(newPath))
+	isIndex := strings.TrimSuffix(path.Base(oldPath), path.Ext(oldPath)) == "index"
+
+	switch {
+	case target == oldPath:
+		return newPath, true
+	case target == oldWithoutExt:
+		return newWithoutExt, true
+	case javascriptExtensionPattern.MatchString(specifier) && strings.TrimSuffix(target, path.Ext(target)) == oldWithoutExt:
+		return newWithoutExt + path.Ext(target), true
+	case isIndex && target == path.Dir(oldPath):
+		if strings.TrimSuffix(path.Base(newPath), path.Ext(newPath)) == "index" {
+			return path.Dir(newPath), true
+		}
+		return newWithoutExt, true
+	}
+	return "", false
+}
+
+func relativeImportSpecifier(fromDir, target string) string {
+	relativePath, err := filepath.Rel(fromDir, target)
+	if err != nil {
+		return ""
+	}
+	relativePath = filepath.ToSlash(relativePath)
+	if !strings.HasPrefix(relativePath, ".") {
+		relativePath = "./" + relativePath
+	}
+	return relativePath
+}
+
+const goImportPathQuery = `(import_spec name: (_)? @name path: (_) @path)`
+
+var goPackageClausePattern = regexp.MustCompile(`(?m)^package\s+(\w+)`)
+
+func updateGoImportsForRename(baseDir, oldPath, newPath string, originals map[string][]byte) error {
+	oldDir, newDir := path.Dir(oldPath), path.Dir(newPath)
+	if oldDir == newDir {
+		return nil
+	}
+
+	oldDirPackage := goDirectoryPackageName(baseDir, oldDir, "")
+	newDirPackage := goDirectoryPackageName(baseDir, newDir, newPath)
+
+	// the moved file must join the package of its new directory
+	absoluteNewPath := filepath.Join(baseDir, newPath)
+	sourceCode, err := os.ReadFile(absoluteNewPath)
+	if err != nil {
+		return err
+	}
+	clause := goPackageClausePattern.FindSubmatchIndex(sourceCode)
+	if clause == nil {
+		return nil
+	}
+	movedPackage := string(sourceCode[clause[2]:clause[3]])
+	isExternalTest := strings.HasSuffix(movedPackage, "_test") && strings.HasSuffix(newPath, "_test.go")
+	basePackage := strings.TrimSuffix(movedPackage, "_test")
+	if !isExternalTest {
+		basePackage = movedPackage
+	}
+	newPackage := basePackage
+	if newDirPackage != "" {
+		newPackage = newDirPackage
+	} else if basePackage == path.Base(oldDir) && token.IsIdentifier(path.Base(newDir)) {
+		newPackage = path.Base(newDir)
+	}
+	if isExternalTest {
+		newPackage += "_test"
+	}
+	changed, err := applySourceEdits(absoluteNewPath, sourceCode, []sourceEdit{{startByte: uint32(clause[2]), endByte: uint32(clause[3]), newText: newPackage}})
+	if err != nil {
+		return err
+	}
+	if changed {
+		recordOriginal(originals, newPath, sourceCode)
+	}
+
+	// importers of the old package can only be pointed at the new one when
+	// nothing else remains in the old package
+	if oldDirPackage != "" {
+		return nil
+	}
+	oldImportPath, err := goImportPath(baseDir, oldDir)
+	if err != nil || oldImportPath == "" {
+		return err
+	}
+	newImportPath, err := goImportPath(baseDir, newDir)
+	if err != nil || newImportPath == "" {
+		return err
+	}
+	oldPackageName := strings.TrimSuffix(basePackage, "_test")
+	newPackageName := strings.TrimSuffix(newPackage, "_test")
+
+	q, err := sitter.NewQuery([]byte(goImportPathQuery), golang.GetLanguage())
+	if err != nil {
+		return fmt.Errorf("error creating import path query: %w", err)
+	}
+	return walkRelativeFiles(baseDir, func(relativePath string) error {
+		if !strings.HasSuffix(relativePath, ".go") {
+			return nil
+		}
+		absolutePath := filepath.Join(baseDir, relativePath)
+		sourceCode, err := os.ReadFile(absolutePath)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(sourceCode), strconv.Quote(oldImportPath)) {
+			return nil
+		}
+
+		parser := sitter.NewParser()
+		parser.SetLanguage(golang.GetLanguage())
+		tree, err := parser.ParseCtx(context.Background(), nil, sourceCode)
+		if err != nil {
+			return err
+		}
+		defer tree.Close()
+
+		var edits []sourceEdit
+		qc := sitter.NewQueryCursor()
+		qc.Exec(q, tree.RootNode())
+		for {
+			m, ok := qc.NextMatch()
+			if !ok {
+				break
+			}
+			var nameNode, pathNode *sitter.Node
+			for _, c := range m.Captures {
+				switch q.CaptureNameForId(c.Index) {
+				case "name":
+					nameNode = c.Node
+				case "path":
+					pathNode = c.Node
+				}
+			}
+			if pathNode == nil || pathNode.Content(sourceCode) != strconv.Quote(oldImportPath) {
+				continue
+			}
+			newText := strconv.Quote(newImportPath)
+			// an alias keeps existing references compiling when the package
+			// was renamed
+			if nameNode == nil && newPackageName != oldPackageName {
+				newText = oldPackageName + " " + newText
+			}
+			edits = append(edits, sourceEdit{startByte: pathNode.StartByte(), endByte: pathNode.EndByte(), newText: newText})
+		}
+
+		changed, err := applySourceEdits(absolutePath, sourceCode, edits)
+		if changed {
+			recordOriginal(originals, relativePath, sourceCode)
+		}
+		return err
+	})
+}
+
+// goDirectoryPackageName returns the package name used by non-test Go files in
+// the directory, ignoring the excluded file, or an empty string if there are
+// no such files
+func goDirectoryPackageName(baseDir, relativeDir, excludedPath string) string {
+	entries, err := os.ReadDir(filepath.Join(baseDir, relativeDir))
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || path.Join(relativeDir, name) == excludedPath {
+			continue
+		}
+		sourceCode, err := os.ReadFile(filepath.Join(baseDir, relativeDir, name))
+		if err != nil {
+			continue
+		}
+		if match := goPackageClausePattern.FindSubmatch(sourceCode); match != nil {
+			return string(match[1])
+		}
+	}
+	return ""
+}
+
+var goModulePattern = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+// goImportPath derives a directory's import path from the nearest go.mod
+// within the base directory, returning an empty string if there is none
+func goImportPath(baseDir, relativeDir string) (string, error) {
+	for moduleDir := relativeDir; ; moduleDir = path.Dir(moduleDir) {
+		goMod, err := os.ReadFile(filepath.Join(baseDir, moduleDir, "go.mod"))
+		if err == nil {
+			match := goModulePattern.FindSubmatch(goMod)
+			if match == nil {
+				return "", fmt.Errorf("no module directive in %s", path.Join(moduleDir, "go.mod"))
+			}
+			packageDir := strings.TrimPrefix(strings.TrimPrefix(relativeDir, moduleDir), "/")
+			if moduleDir == "." {
+				packageDir = relativeDir
+			}
+			if packageDir == "." || packageDir == "" {
+				return string(match[1]), nil
+			}
+			return string(match[1]) + "/" + packageDir, nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+		if moduleDir == "." || moduleDir == "/" {
+			return "", nil
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package tree_sitter
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func updatedRenameTestPaths(originals map[string][]byte) []string {
+	paths := make([]string, 0, len(originals))
+	for relativePath := range originals {
+		paths = append(paths, relativePath)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func readRenameTestFile(t *testing.T, dir, relativePath string) string {
+	content, err := os.ReadFile(filepath.Join(dir, relativePath))
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestUpdateImportsForRenameTypescript(t *testing.T) {
+	t.Parallel()
+	// the moved file is already at its new path
+	dir := writeSymbolGraphTestFiles(t, map[string]string{
+		"src/lib/format/date.ts": "import { pad } from './pad';\nimport type { Locale } from '../locale';\nexport const x = 1;\n",
+		"src/lib/pad.ts":         "export const pad = 1;\n",
+		"src/lib/locale.ts":      "export type Locale = string;\n",
+		"src/app.ts":             "import { x } from './lib/date';\nimport { pad } from './lib/pad';\nconst y = require('./lib/date.js');\nexport * from \"./lib/date.ts\";\n",
+		"src/lib/other.tsx":      "const lazy = import('./date');\nimport React from 'react';\n",
+		"src/Comp.vue":           "<template><div /></template>\n<script lang=\"ts\">\nimport { x } from './lib/date'\n</script>\n",
+	})
+
+	originals, err := UpdateImportsForRename(dir, "src/lib/date.ts", "src/lib/format/date.ts")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/Comp.vue", "src/app.ts", "src/lib/format/date.ts", "src/lib/other.tsx"}, updatedRenameTestPaths(originals))
+
+	assert.Equal(t, "import { pad } from '../pad';\nimport type { Locale } from '../../locale';\nexport const x = 1;\n", readRenameTestFile(t, dir, "src/lib/format/date.ts"))
+	assert.Equal(t, "import { x } from './lib/format/date';\nimport { pad } from './lib/pad';\nconst y = require('./lib/format/date.js');\nexport * from \"./lib/format/date.ts\";\n", readRenameTestFile(t, dir, "src/app.ts"))
+	assert.Equal(t, "const lazy = import('./format/date');\nimport React from 'react';\n", readRenameTestFile(t, dir, "src/lib/other.tsx"))
+	assert.Equal(t, "<template><div /></template>\n<script lang=\"ts\">\nimport { x } from './lib/format/date'\n</script>\n", readRenameTestFile(t, dir, "src/Comp.vue"))
+}
+
+func TestUpdateImportsForRenameTypescriptIndex(t *testing.T) {
+	t.Parallel()
+	dir := writeSymbolGraphTestFiles(t, map[string]string{
+		"src/widgets/button.ts": "export const b = 1;\n",
+		"src/main.ts":           "import { b } from './components';\n",
+	})
+
+	originals, err := UpdateImportsForRename(dir, "src/components/index.ts", "src/widgets/button.ts")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"src/main.ts"}, updatedRenameTestPaths(originals))
+	assert.Equal(t, "import { b } from './components';\n", string(originals["src/main.ts"]))
+	assert.Equal(t, "import { b } from './widgets/button';\n", readRenameTestFile(t, dir, "src/main.ts"))
+}
+
+func TestUpdateImportsForRenameGo(t *testing.T) {
+	t.Parallel()
+
+	t.Run("package moved to a new directory", func(t *testing.T) {
+		t.Parallel()
+		dir := writeSymbolGraphTestFiles(t, map[string]string{
+			"go.mod":               "module example.com/app\n\ngo 1.21\n",
+			"internal/util/str.go": "package strutil\n\nfunc Upper() {}\n",
+			"main.go":              "package main\n\nimport (\n\t\"fmt\"\n\t\"example.com/app/strutil\"\n)\n\nfunc main() { fmt.Println(strutil.Upper) }\n",
+			"cmd/tool.go":          "package main\n\nimport su \"example.com/app/strutil\"\n",
+		})
+
+		originals, err := UpdateImportsForRename(dir, "strutil/str.go", "internal/util/str.go")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cmd/tool.go", "internal/util/str.go", "main.go"}, updatedRenameTestPaths(originals))
+		assert.Equal(t, "package util\n\nfunc Upper() {}\n", readRenameTestFile(t, dir, "internal/util/str.go"))
+		assert.Equal(t, "package main\n\nimport (\n\t\"fmt\"\n\tstrutil \"example.com/app/internal/util\"\n)\n\nfunc main() { fmt.Println(strutil.Upper) }\n", readRenameTestFile(t, dir, "main.go"))
+		assert.Equal(t, "package main\n\nimport su \"example.com/app/internal/util\"\n", readRenameTestFile(t, dir, "cmd/tool.go"))
+	})
+
+	t.Run("file moved into an existing package", func(t *testing.T) {
+		t.Parallel()
+		dir := writeSymbolGraphTestFiles(t, map[string]string{
+			"go.mod":          "module example.com/app\n",
+			"a/remaining.go":  "package a\n",
+			"b/b.go":          "package bee\n",
+			"b/moved.go":      "package a\n\nfunc Moved() {}\n",
+			"main.go":         "package main\n\nimport \"example.com/app/a\"\n",
+			"b/moved_test.go": "package a_test\n",
+		})
+
+		originals, err := UpdateImportsForRename(dir, "a/moved.go", "b/moved.go")
+		require.NoError(t, err)
+		// importers are left alone, since the old package still exists
+		assert.Equal(t, []string{"b/moved.go"}, updatedRenameTestPaths(originals))
+		assert.Equal(t, "package bee\n\nfunc Moved() {}\n", readRenameTestFile(t, dir, "b/moved.go"))
+
+		originals, err = UpdateImportsForRename(dir, "a/moved_test.go", "b/moved_test.go")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b/moved_test.go"}, updatedRenameTestPaths(originals))
+		assert.Equal(t, "package bee_test\n", readRenameTestFile(t, dir, "b/moved_test.go"))
+	})
+
+	t.Run("same directory", func(t *testing.T) {
+		t.Parallel()
+		dir := writeSymbolGraphTestFiles(t, map[string]string{
+			"go.mod":   "module example.com/app\n",
+			"a/new.go": "package a\n",
+		})
+
+		originals, err := UpdateImportsForRename(dir, "a/old.go", "a/new.go")
+		require.NoError(t, err)
+		assert.Empty(t, originals)
+	})
+}
@@ -0,0 +1,71 @@
+package tree_sitter
+
+import (
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// writeRubySignatureCapture writes signatures based on the corresponding
+// signature_queries/signature_ruby.scm.mustache. Ruby has no syntax for a
+// declaration without its body, so the signatures are assembled from parts,
+// eg "def self.origin(x, y = 0)".
+func writeRubySignatureCapture(out *strings.Builder, sourceCode *[]byte, c sitter.QueryCapture, name string) {
+	content := c.Node.Content(*sourceCode)
+	switch name {
+	case "module.declaration":
+		writeRubyIndentLevel(c.Node, out)
+		out.WriteString("module ")
+	case "class.declaration":
+		writeRubyIndentLevel(c.Node, out)
+		out.WriteString("class ")
+	case "method.declaration":
+		writeRubyIndentLevel(c.Node, out)
+		out.WriteString("def ")
+	case "singleton_method.declaration":
+		writeRubyIndentLevel(c.Node, out)
+		out.WriteString("def ")
+		out.WriteString(c.Node.ChildByFieldName("object").Content(*sourceCode))
+		out.WriteString(".")
+	case "module.name", "class.name", "method.name":
+		out.WriteString(content)
+	case "class.superclass":
+		out.WriteString(" ")
+		out.WriteString(content)
+	case "method.parameters":
+		if !strings.HasPrefix(content, "(") {
+			out.WriteString(" ")
+		}
+		out.WriteString(content)
+	case "constant.declaration":
+		writeRubyIndentLevel(c.Node, out)
+		out.WriteString(content)
+	}
+}
+
+func writeRubySymbolCapture(out *strings.Builder, sourceCode *[]byte, c sitter.QueryCapture, name string) {
+	switch name {
+	case "module.name", "class.name", "method.name", "constant.name":
+		out.WriteString(c.Node.Content(*sourceCode))
+	}
+}
+
+// getRubyIndentLevel returns the number of modules and classes the node is
+// nested within
+func getRubyIndentLevel(node *sitter.Node) int {
+	level := 0
+	for current := node.Parent(); current != nil; current = current.Parent() {
+		switch current.Type() {
+		case "module", "class", "singleton_class":
+			level++
+		}
+	}
+	return level
+}
+
+func writeRubyIndentLevel(node *sitter.Node, out *strings.Builder) {
+	level := getRubyIndentLevel(node)
+	for i := 0; i < level; i++ {
+		out.WriteString("\t")
+	}
+}
@@ -0,0 +1,213 @@
+package tree_sitter
+
+import (
+	"os"
+	"sidekick/utils"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFileSignaturesStringRuby(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			code:     "",
+			expected: "",
+		},
+		{
+			name:     "top-level method",
+			code:     "def top_level(a, *rest, key:, &block)\n  a\nend",
+			expected: "def top_level(a, *rest, key:, &block)\n---\n",
+		},
+		{
+			name: "module with class",
+			code: `module Shapes
+  PI = 3.14
+  class Point < Base
+    def initialize(x, y = 0)
+      @x = x
+    end
+    def self.origin
+      new(0, 0)
+    end
+    def to_s = "(#{x})"
+  end
+end`,
+			expected: "module Shapes\n---\n\tPI = 3.14\n---\n\tclass Point < Base\n---\n\t\tdef initialize(x, y = 0)\n---\n\t\tdef self.origin\n---\n\t\tdef to_s\n---\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "rb", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			result, err := GetFileSignaturesString(filePath)
+			assert.Nil(t, err)
+			if result != tc.expected {
+				t.Errorf("GetFileSignaturesString returned incorrect result. Expected:\n%s\nGot:\n%s", utils.PanicJSON(tc.expected), utils.PanicJSON(result))
+			}
+		})
+	}
+}
+
+func TestGetFileSymbolsStringRuby(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			code:     "",
+			expected: "",
+		},
+		{
+			name:     "module, class, constant and methods",
+			code:     "module Shapes\n  PI = 3.14\n  class Point\n    def self.origin; end\n    def to_s; end\n  end\nend",
+			expected: "Shapes, PI, Point, origin, to_s",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "rb", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			symbolsString, err := GetFileSymbolsString(filePath)
+			if err != nil {
+				t.Fatalf("Failed to get symbols: %v", err)
+			}
+			assert.Equal(t, tc.expected, symbolsString)
+		})
+	}
+}
+
+func TestGetFileHeadersStringRuby(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			code:     "",
+			expected: "",
+		},
+		{
+			name:     "requires",
+			code:     "require 'json'\nrequire_relative 'foo'\n\nclass Foo\nend",
+			expected: "require 'json'\nrequire_relative 'foo'\n",
+		},
+		{
+			name:     "requires within methods are ignored",
+			code:     "def load_json\n  require 'json'\nend",
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "rb", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			result, err := GetFileHeadersString(filePath, 0)
+			assert.Nil(t, err)
+			if result != tc.expected {
+				t.Errorf("GetFileHeadersString returned incorrect result. Expected:\n%s\nGot:\n%s", utils.PanicJSON(tc.expected), utils.PanicJSON(result))
+			}
+		})
+	}
+}
+
+func TestGetSymbolDefinitionRuby(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name               string
+		symbolName         string
+		code               string
+		expectedDefinition string
+		expectedError      string
+	}{
+		{
+			name:          "symbol not found",
+			symbolName:    "missing",
+			code:          "def foo; end",
+			expectedError: "symbol not found: missing",
+		},
+		{
+			name:       "class with comment",
+			symbolName: "Point",
+			code: `require 'json'
+
+# A point
+class Point < Base
+  def to_s
+    "point"
+  end
+end`,
+			expectedDefinition: `# A point
+class Point < Base
+  def to_s
+    "point"
+  end
+end`,
+		},
+		{
+			name:               "class method",
+			symbolName:         "origin",
+			code:               "class Point\n  def self.origin\n    new(0, 0)\n  end\nend",
+			expectedDefinition: "  def self.origin\n    new(0, 0)\n  end",
+		},
+		{
+			name:               "constant",
+			symbolName:         "PI",
+			code:               "module Shapes\n  PI = 3.14\nend",
+			expectedDefinition: "  PI = 3.14",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "rb", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			definition, err := GetSymbolDefinitionsString(filePath, tc.symbolName, 0)
+			if err != nil {
+				if tc.expectedError == "" {
+					t.Fatalf("Unexpected error: %v", err)
+				} else if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("Expected error: %s, got: %v", tc.expectedError, err)
+				}
+			}
+			if strings.TrimSuffix(definition, "\n") != strings.TrimSuffix(tc.expectedDefinition, "\n") {
+				t.Errorf("Expected definition:\n%s\nGot:\n%s", utils.PanicJSON(tc.expectedDefinition), utils.PanicJSON(definition))
+			}
+		})
+	}
+}
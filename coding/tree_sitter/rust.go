@@ -0,0 +1,62 @@
+package tree_sitter
+
+import (
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// writeRustSignatureCapture writes signatures based on the corresponding
+// signature_queries/signature_rust.scm.mustache. Functions, impls, traits and
+// modules are written without their bodies, while data types, constants and
+// statics are written in full.
+func writeRustSignatureCapture(out *strings.Builder, sourceCode *[]byte, c sitter.QueryCapture, name string) {
+	switch name {
+	case "function.declaration", "method.declaration", "impl.declaration", "trait.declaration", "module.declaration":
+		writeRustIndentLevel(c.Node, out)
+		out.WriteString(strings.TrimSuffix(declarationHeader(sourceCode, c.Node, "body"), ";"))
+	case "struct.declaration", "enum.declaration", "union.declaration", "type_alias.declaration", "const.declaration", "static.declaration":
+		writeRustIndentLevel(c.Node, out)
+		out.WriteString(c.Node.Content(*sourceCode))
+	case "macro.declaration":
+		writeRustIndentLevel(c.Node, out)
+		out.WriteString("macro_rules! ")
+		out.WriteString(c.Node.ChildByFieldName("name").Content(*sourceCode))
+	}
+}
+
+// writeRustSymbolCapture writes symbol names, with methods prefixed by the
+// type or trait they belong to, eg "Point.new"
+func writeRustSymbolCapture(out *strings.Builder, sourceCode *[]byte, c sitter.QueryCapture, name string) {
+	content := c.Node.Content(*sourceCode)
+	switch name {
+	case "parent.method.type":
+		// generic arguments aren't part of the type's name
+		typeName, _, _ := strings.Cut(content, "<")
+		out.WriteString(typeName)
+		out.WriteString(".")
+	case "function.name", "method.name", "trait.name", "module.name", "struct.name", "enum.name", "union.name", "type_alias.name", "const.name", "static.name", "macro.name":
+		out.WriteString(content)
+	}
+}
+
+// getRustIndentLevel returns the number of item ancestors, eg impl blocks or
+// modules, between the node and the source file
+func getRustIndentLevel(node *sitter.Node) int {
+	level := 0
+	current := node.Parent()
+	for current != nil {
+		if strings.HasSuffix(current.Type(), "_item") {
+			level++
+		}
+		current = current.Parent()
+	}
+	return level
+}
+
+func writeRustIndentLevel(node *sitter.Node, out *strings.Builder) {
+	level := getRustIndentLevel(node)
+	for i := 0; i < level; i++ {
+		out.WriteString("\t")
+	}
+}
@@ -0,0 +1,296 @@
+package tree_sitter
+
+import (
+	"os"
+	"sidekick/utils"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFileSignaturesStringRust(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			code:     "",
+			expected: "",
+		},
+		{
+			name:     "function",
+			code:     "pub fn add(a: i32, b: i32) -> i32 { a + b }",
+			expected: "pub fn add(a: i32, b: i32) -> i32\n---\n",
+		},
+		{
+			name:     "doc comments are omitted",
+			code:     "/// Adds numbers\nfn add(a: i32, b: i32) -> i32 { a + b }",
+			expected: "fn add(a: i32, b: i32) -> i32\n---\n",
+		},
+		{
+			name:     "struct",
+			code:     "pub struct Point<T> { pub x: T, y: T }",
+			expected: "pub struct Point<T> { pub x: T, y: T }\n---\n",
+		},
+		{
+			name:     "unit struct",
+			code:     "struct Unit;",
+			expected: "struct Unit;\n---\n",
+		},
+		{
+			name:     "enum",
+			code:     "pub enum Shape { Circle(f64), Square { side: f64 } }",
+			expected: "pub enum Shape { Circle(f64), Square { side: f64 } }\n---\n",
+		},
+		{
+			name: "trait",
+			code: `pub trait Area {
+    fn area(&self) -> f64;
+    fn name(&self) -> String { String::new() }
+}`,
+			expected: "pub trait Area\n---\n\tfn area(&self) -> f64\n---\n\tfn name(&self) -> String\n---\n",
+		},
+		{
+			name: "impl",
+			code: `impl<T: Copy> Point<T> {
+    pub fn new(x: T, y: T) -> Self { Point { x, y } }
+}`,
+			expected: "impl<T: Copy> Point<T>\n---\n\tpub fn new(x: T, y: T) -> Self\n---\n",
+		},
+		{
+			name:     "trait impl",
+			code:     "impl Area for Shape { fn area(&self) -> f64 { 0.0 } }",
+			expected: "impl Area for Shape\n---\n\tfn area(&self) -> f64\n---\n",
+		},
+		{
+			name:     "const and static",
+			code:     "pub const MAX: u32 = 10;\nstatic mut COUNTER: u32 = 0;",
+			expected: "pub const MAX: u32 = 10;\n---\nstatic mut COUNTER: u32 = 0;\n---\n",
+		},
+		{
+			name:     "type alias",
+			code:     "pub type Result<T> = std::result::Result<T, Error>;",
+			expected: "pub type Result<T> = std::result::Result<T, Error>;\n---\n",
+		},
+		{
+			name:     "macro",
+			code:     "macro_rules! square { ($x:expr) => { $x * $x }; }",
+			expected: "macro_rules! square\n---\n",
+		},
+		{
+			name:     "inline module",
+			code:     "pub mod shapes { pub fn helper() {} }",
+			expected: "pub mod shapes\n---\n\tpub fn helper()\n---\n",
+		},
+		{
+			name:     "nested function is skipped",
+			code:     "fn outer() { fn inner() {} }",
+			expected: "fn outer()\n---\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "rs", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			result, err := GetFileSignaturesString(filePath)
+			assert.Nil(t, err)
+			if result != tc.expected {
+				t.Errorf("GetFileSignaturesString returned incorrect result. Expected:\n%s\nGot:\n%s", utils.PanicJSON(tc.expected), utils.PanicJSON(result))
+			}
+		})
+	}
+}
+
+func TestGetFileSymbolsStringRust(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			code:     "",
+			expected: "",
+		},
+		{
+			name:     "function and struct",
+			code:     "struct Point { x: i32 }\nfn add(a: i32) -> i32 { a }",
+			expected: "Point, add",
+		},
+		{
+			name: "methods are qualified by their type",
+			code: `trait Area { fn area(&self) -> f64; }
+impl<T> Point<T> { fn new() -> Self { todo!() } }
+impl Area for Shape { fn area(&self) -> f64 { 0.0 } }`,
+			expected: "Area, Area.area, Point.new, Shape.area",
+		},
+		{
+			name:     "const, static, type, macro and module",
+			code:     "const MAX: u32 = 1;\nstatic COUNTER: u32 = 0;\ntype Id = u64;\nmacro_rules! square { () => {}; }\nmod shapes { fn helper() {} }",
+			expected: "MAX, COUNTER, Id, square, shapes, helper",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "rs", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			symbolsString, err := GetFileSymbolsString(filePath)
+			if err != nil {
+				t.Fatalf("Failed to get symbols: %v", err)
+			}
+			assert.Equal(t, tc.expected, symbolsString)
+		})
+	}
+}
+
+func TestGetFileHeadersStringRust(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			code:     "",
+			expected: "",
+		},
+		{
+			name:     "use declarations",
+			code:     "use std::fmt;\nuse crate::foo::{Bar, Baz};\n\nfn main() {}",
+			expected: "use std::fmt;\nuse crate::foo::{Bar, Baz};\n",
+		},
+		{
+			name:     "module declarations without bodies",
+			code:     "mod inner;\nmod shapes { fn helper() {} }",
+			expected: "mod inner;\n",
+		},
+		{
+			name:     "extern crate",
+			code:     "extern crate foo;",
+			expected: "extern crate foo;\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "rs", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			result, err := GetFileHeadersString(filePath, 0)
+			assert.Nil(t, err)
+			if result != tc.expected {
+				t.Errorf("GetFileHeadersString returned incorrect result. Expected:\n%s\nGot:\n%s", utils.PanicJSON(tc.expected), utils.PanicJSON(result))
+			}
+		})
+	}
+}
+
+func TestGetSymbolDefinitionRust(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name               string
+		symbolName         string
+		code               string
+		expectedDefinition string
+		expectedError      string
+	}{
+		{
+			name:          "symbol not found",
+			symbolName:    "Missing",
+			code:          "fn main() {}",
+			expectedError: "symbol not found: Missing",
+		},
+		{
+			name:       "function with doc comment and attribute",
+			symbolName: "add",
+			code: `use std::fmt;
+
+/// Adds numbers
+#[inline]
+pub fn add(a: i32, b: i32) -> i32 {
+    a + b
+}`,
+			expectedDefinition: `/// Adds numbers
+#[inline]
+pub fn add(a: i32, b: i32) -> i32 {
+    a + b
+}`,
+		},
+		{
+			name:       "struct and its impl",
+			symbolName: "Point",
+			code: `#[derive(Debug)]
+pub struct Point { x: i32 }
+
+fn other() {}
+
+impl Point {
+    pub fn new(x: i32) -> Self { Point { x } }
+}`,
+			expectedDefinition: `#[derive(Debug)]
+pub struct Point { x: i32 }
+impl Point {
+    pub fn new(x: i32) -> Self { Point { x } }
+}`,
+		},
+		{
+			name:       "method qualified by type",
+			symbolName: "Point.new",
+			code: `impl Point {
+    pub fn new(x: i32) -> Self { Point { x } }
+}`,
+			expectedDefinition: "    pub fn new(x: i32) -> Self { Point { x } }",
+		},
+		{
+			name:               "enum variant",
+			symbolName:         "Circle",
+			code:               "enum Shape {\n    Circle(f64),\n    Square,\n}",
+			expectedDefinition: "    Circle(f64),",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			filePath, err := utils.WriteTestTempFile(t, "rs", tc.code)
+			if err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+			defer os.Remove(filePath)
+
+			definition, err := GetSymbolDefinitionsString(filePath, tc.symbolName, 0)
+			if err != nil {
+				if tc.expectedError == "" {
+					t.Fatalf("Unexpected error: %v", err)
+				} else if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("Expected error: %s, got: %v", tc.expectedError, err)
+				}
+			}
+			if strings.TrimSuffix(definition, "\n") != strings.TrimSuffix(tc.expectedDefinition, "\n") {
+				t.Errorf("Expected definition:\n%s\nGot:\n%s", utils.PanicJSON(tc.expectedDefinition), utils.PanicJSON(definition))
+			}
+		})
+	}
+}
@@ -254,6 +254,13 @@ func removeComments(sourceCode SourceCode) (bool, SourceCode) {
 (line_comment) @comment
 (multiline_comment) @comment
 `
+	case "rust", "rust-signatures":
+		queryString = `
+(line_comment) @comment
+(block_comment) @comment
+`
+	case "c", "cpp", "csharp", "ruby", "php", "c-signatures", "cpp-signatures", "csharp-signatures", "ruby-signatures", "php-signatures":
+		queryString = `(comment) @comment`
 	}
 
 	// no query
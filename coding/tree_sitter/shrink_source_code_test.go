@@ -124,19 +124,19 @@ func TestShrinkEmbeddedCodeContext(t *testing.T) {
 		},
 		{
 			name:          "unsupported language remains unchanged",
-			input:         createMarkdownCodeBlock("ruby", "def hello\n  puts 'world'\nend"),
+			input:         createMarkdownCodeBlock("elixir", "def hello do\n  IO.puts \"world\"\nend"),
 			maxLength:     10,
 			longestFirst:  true,
-			wantContent:   createMarkdownCodeBlock("ruby", "def hello\n  puts 'world'\nend"),
+			wantContent:   createMarkdownCodeBlock("elixir", "def hello do\n  IO.puts \"world\"\nend"),
 			wantDidShrink: false,
 		},
 		{
 			name: "mixed supported and unsupported languages",
-			input: createMarkdownCodeBlock("ruby", "def hello\n  puts 'world'\nend") + "\n" +
+			input: createMarkdownCodeBlock("elixir", "def hello do\n  IO.puts \"world\"\nend") + "\n" +
 				createMarkdownCodeBlock("go", "func main() {\n\tfmt.Println(\"hello\")\n}"),
 			maxLength:    40,
 			longestFirst: true,
-			wantContent: createMarkdownCodeBlock("ruby", "def hello\n  puts 'world'\nend") + "\n" +
+			wantContent: createMarkdownCodeBlock("elixir", "def hello do\n  IO.puts \"world\"\nend") + "\n" +
 				"Shrank context - here are the extracted code signatures and docstrings only, in lieu of full code:\n" +
 				createMarkdownCodeBlock("go-signatures", "func main()"),
 			wantDidShrink: true,
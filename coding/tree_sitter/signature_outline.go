@@ -137,9 +137,60 @@ func getChecksum(path string) (string, error) {
 	return checksum, nil
 }
 
+// getCachedFileSignaturesString returns the file's signatures string, reusing
+// the cached one when the file hasn't changed since it was computed. The
+// checksum is checked even for files an OutlineWatcher keeps up to date,
+// since its change events arrive with a delay.
+func getCachedFileSignaturesString(path string) (string, error) {
+	checksum, err := getChecksum(path)
+	if err != nil {
+		fmt.Printf("error getting checksum for file %s: %v\n", path, err)
+	}
+	if val, ok := checksums.Load(path); ok && val == checksum && err == nil {
+		if val, ok := cachedOutlines.Load(path); ok {
+			return val.(string), nil
+		}
+	}
+
+	outlineContent, err := GetFileSignaturesString(path)
+	if err != nil {
+		return "", err
+	}
+	cachedOutlines.Store(path, outlineContent)
+	checksums.Store(path, checksum)
+	return outlineContent, nil
+}
+
+// invalidateCachedFileOutline forgets the cached outline for the path
+func invalidateCachedFileOutline(path string) {
+	cachedOutlines.Delete(path)
+	checksums.Delete(path)
+}
+
+// invalidateCachedOutlinesUnder forgets the cached outlines for all paths
+// within the directory
+func invalidateCachedOutlinesUnder(dir string) {
+	prefix := dir + string(filepath.Separator)
+	for _, cache := range []*sync.Map{&cachedOutlines, &checksums} {
+		cache.Range(func(key, value any) bool {
+			if strings.HasPrefix(key.(string), prefix) {
+				cache.Delete(key)
+			}
+			return true
+		})
+	}
+}
+
 // nil for the showPaths means: show all paths
 // nil for signaturePaths means: outline signatures for all paths
 func GetDirectorySignatureOutlines(baseDirectory string, showPaths *map[string]bool, signaturePaths *map[string]int) (outlines []FileOutline, err error) {
+	return GetSubdirectorySignatureOutlines(baseDirectory, "", showPaths, signaturePaths)
+}
+
+// GetSubdirectorySignatureOutlines is like GetDirectorySignatureOutlines, but
+// only includes files within the given subdirectory of the base directory.
+// Paths remain relative to the base directory.
+func GetSubdirectorySignatureOutlines(baseDirectory string, subdirectory string, showPaths *map[string]bool, signaturePaths *map[string]int) (outlines []FileOutline, err error) {
 	baseDirectory, err = filepath.Abs(baseDirectory)
 	if err != nil {
 		return outlines, err
@@ -148,8 +199,12 @@ func GetDirectorySignatureOutlines(baseDirectory string, showPaths *map[string]b
 	if !strings.HasSuffix(baseDirectory, string(os.PathSeparator)) {
 		baseDirectory = baseDirectory + string(os.PathSeparator)
 	}
+	walkDirectory := baseDirectory
+	if subdirectory != "" {
+		walkDirectory = filepath.Join(baseDirectory, subdirectory)
+	}
 
-	err = common.WalkCodeDirectory(baseDirectory, func(path string, entry fs.DirEntry) error {
+	err = common.WalkCodeDirectory(walkDirectory, func(path string, entry fs.DirEntry) error {
 		relativePath := strings.Replace(path, baseDirectory, "", 1)
 
 		if entry.IsDir() {
@@ -173,58 +228,29 @@ func GetDirectorySignatureOutlines(baseDirectory string, showPaths *map[string]b
 			return nil
 		}
 
-		var outlineContent string
-		checksum, err := getChecksum(path)
+		outlineContent, err := getCachedFileSignaturesString(path)
 		if err != nil {
-			fmt.Printf("error getting checksum for file %s: %v\n", path, err)
-		}
-		if val, ok := checksums.Load(path); ok && val == checksum && err == nil {
-			if val, ok := cachedOutlines.Load(path); ok {
-				outlineContent = val.(string)
-			}
-		} else {
-			outlineContent, err = GetFileSignaturesString(path)
-			if err != nil {
-				l := logger.Get()
-				if strings.Contains(err.Error(), path) {
-					l.Debug().Err(err).Msg("error getting signatures")
-				} else {
-					l.Debug().Err(err).Msg(fmt.Sprintf("error getting signatures for file %s", relativePath))
-				}
-				outlines = append(outlines, FileOutline{
-					Path:        relativePath,
-					OutlineType: OutlineTypeFileUnhandled,
-				})
-				return nil
+			l := logger.Get()
+			if strings.Contains(err.Error(), path) {
+				l.Debug().Err(err).Msg("error getting signatures")
+			} else {
+				l.Debug().Err(err).Msg(fmt.Sprintf("error getting signatures for file %s", relativePath))
 			}
-			cachedOutlines.Store(path, outlineContent)
-			checksums.Store(path, checksum)
+			outlines = append(outlines, FileOutline{
+				Path:        relativePath,
+				OutlineType: OutlineTypeFileUnhandled,
+			})
+			return nil
 		}
 
-		// NOTE max embed size is 8192 tokens, this character limit is trying to
-		// avoid hitting that with a decent margin of error
-		maxContentLength := 30000
+		maxContentLength := maxSignatureOutlineLength
 		if signaturePaths != nil {
 			maxContentLength = min((*signaturePaths)[relativePath], maxContentLength)
 		}
-		if len(outlineContent) > maxContentLength {
-			languageName := utils.InferLanguageNameFromFilePath(path)
-			sourceCode := SourceCode{
-				Content:              outlineContent,
-				LanguageName:         languageName,
-				OriginalLanguageName: languageName + "-signatures",
-			}
-			_, newSourceCode := removeComments(sourceCode)
-			outlineContent = newSourceCode.Content
-		}
-		if len(outlineContent) > maxContentLength {
-			outlineContent = outlineContent[:maxContentLength] + fmt.Sprintf("\n... [truncated %d characters]", len(outlineContent)-maxContentLength)
-		}
-
 		outline := FileOutline{
 			Path:        relativePath,
 			OutlineType: OutlineTypeFileSignature,
-			Content:     outlineContent,
+			Content:     limitSignatureOutline(path, outlineContent, maxContentLength),
 		}
 		outlines = append(outlines, outline)
 
@@ -234,6 +260,29 @@ func GetDirectorySignatureOutlines(baseDirectory string, showPaths *map[string]b
 	return outlines, err
 }
 
+// NOTE max embed size is 8192 tokens, this character limit is trying to
+// avoid hitting that with a decent margin of error
+const maxSignatureOutlineLength = 30000
+
+// limitSignatureOutline shortens a file's signature outline to the given
+// length, first by removing comments, then by truncating it
+func limitSignatureOutline(path string, outlineContent string, maxContentLength int) string {
+	if len(outlineContent) > maxContentLength {
+		languageName := utils.InferLanguageNameFromFilePath(path)
+		sourceCode := SourceCode{
+			Content:              outlineContent,
+			LanguageName:         languageName,
+			OriginalLanguageName: languageName + "-signatures",
+		}
+		_, newSourceCode := removeComments(sourceCode)
+		outlineContent = newSourceCode.Content
+	}
+	if len(outlineContent) > maxContentLength {
+		outlineContent = outlineContent[:maxContentLength] + fmt.Sprintf("\n... [truncated %d characters]", len(outlineContent)-maxContentLength)
+	}
+	return outlineContent
+}
+
 func GetDirectorySignatureOutlinesString(baseDirectory string) (string, error) {
 	outlines, err := GetDirectorySignatureOutlines(baseDirectory, nil, nil)
 	if err != nil {
@@ -390,6 +439,19 @@ func shouldExtendSignatureRange(languageName, captureName string) bool {
 		case "property.declaration", "function.declaration":
 			return true
 		}
+	case "rust":
+		switch captureName {
+		case "struct.declaration", "enum.declaration", "union.declaration", "type_alias.declaration", "const.declaration", "static.declaration", "macro.declaration":
+			return true
+		}
+	case "c", "cpp":
+		switch captureName {
+		case "declaration.declaration", "field.declaration", "typedef.declaration", "type_alias.declaration", "macro.declaration", "enum.declaration":
+			return true
+		case "struct.declaration", "union.declaration":
+			// only written in full for c
+			return languageName == "c"
+		}
 	case "vue":
 		{
 			// extend the range for <template>, <script>, and <style>
@@ -443,6 +505,30 @@ func writeSignatureCapture(languageName string, out *strings.Builder, sourceCode
 		{
 			writeKotlinSignatureCapture(out, sourceCode, c, name)
 		}
+	case "rust":
+		{
+			writeRustSignatureCapture(out, sourceCode, c, name)
+		}
+	case "c":
+		{
+			writeCSignatureCapture(out, sourceCode, c, name)
+		}
+	case "cpp":
+		{
+			writeCppSignatureCapture(out, sourceCode, c, name)
+		}
+	case "csharp":
+		{
+			writeCsharpSignatureCapture(out, sourceCode, c, name)
+		}
+	case "ruby":
+		{
+			writeRubySignatureCapture(out, sourceCode, c, name)
+		}
+	case "php":
+		{
+			writePhpSignatureCapture(out, sourceCode, c, name)
+		}
 	default:
 		{
 			// NOTE this is expected to provide quite bad output until tweaked per language
@@ -451,6 +537,17 @@ func writeSignatureCapture(languageName string, out *strings.Builder, sourceCode
 	}
 }
 
+// declarationHeader returns the node's source up to, but excluding, the child
+// in the given field, eg a function without its body. When there is no such
+// child, the node's full source is returned.
+func declarationHeader(sourceCode *[]byte, node *sitter.Node, fieldName string) string {
+	body := node.ChildByFieldName(fieldName)
+	if body == nil {
+		return strings.TrimSpace(node.Content(*sourceCode))
+	}
+	return strings.TrimSpace(string((*sourceCode)[node.StartByte():body.StartByte()]))
+}
+
 //go:embed signature_queries/*
 var signatureQueriesFS embed.FS
 
@@ -0,0 +1,37 @@
+package tree_sitter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSubdirectorySignatureOutlines(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for relativePath, content := range map[string]string{
+		".gitignore":               "*.gen.go\n",
+		"main.go":                  "package main\n\nfunc Main() {}\n",
+		"services/api/api.go":      "package api\n\nfunc Serve() {}\n",
+		"services/api/api.gen.go":  "package api\n\nfunc Generated() {}\n",
+		"services/api/db/db.go":    "package db\n\nfunc Query() {}\n",
+		"services/worker/works.go": "package worker\n\nfunc Work() {}\n",
+	} {
+		path := filepath.Join(dir, relativePath)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	outlines, err := GetSubdirectorySignatureOutlines(dir, "services/api", nil, nil)
+	require.NoError(t, err)
+
+	var paths []string
+	for _, outline := range outlines {
+		paths = append(paths, outline.Path)
+	}
+	assert.Equal(t, []string{"services/api/api.go", "services/api/db", "services/api/db/db.go"}, paths)
+	assert.Contains(t, outlines[0].Content, "func Serve()")
+}
@@ -0,0 +1,381 @@
+package tree_sitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sidekick/utils"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+var ErrInvalidStructuralSearch = errors.New("invalid structural search")
+
+type StructuralSearchInput struct {
+	// LanguageName selects which files are searched, eg "golang" or "python"
+	LanguageName string
+
+	// Query is a tree-sitter query. The node captured as @match is returned
+	// for each match, otherwise the outermost captured node.
+	Query string
+
+	// Pattern is a code snippet in which "$NAME" metavariables match any
+	// single node, with repeated uses requiring identical text, "$_" matches
+	// any single node and "$$$" matches any number of nodes. Used only when
+	// Query is empty.
+	Pattern string
+
+	// PathGlob optionally restricts which files are searched
+	PathGlob string
+
+	// Subdirectory optionally limits the search to files within it, relative
+	// to the base directory. Paths in the results remain relative to the base
+	// directory.
+	Subdirectory string
+
+	ContextLines int
+}
+
+type StructuralSearchFileResult struct {
+	FilePath     string
+	SourceBlocks []SourceBlock
+}
+
+// StructuralSearch searches the non-ignored files of the given language within
+// the base directory by syntax tree rather than text, returning the matching
+// source blocks, with context lines and merged, per file
+func StructuralSearch(baseDir string, input StructuralSearchInput) ([]StructuralSearchFileResult, error) {
+	languageName := normalizeLanguageName(input.LanguageName)
+	sitterLanguage, err := getSitterLanguage(languageName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidStructuralSearch, err)
+	}
+	if languageName == "vue" {
+		return nil, fmt.Errorf("%w: vue files are not supported, search typescript instead", ErrInvalidStructuralSearch)
+	}
+
+	var matcher func(tree *sitter.Tree, sourceCode []byte) []SourceBlock
+	if strings.TrimSpace(input.Query) != "" {
+		query, err := sitter.NewQuery([]byte(input.Query), sitterLanguage)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to parse query: %v", ErrInvalidStructuralSearch, err)
+		}
+		defer query.Close()
+		matcher = func(tree *sitter.Tree, sourceCode []byte) []SourceBlock {
+			return queryMatches(query, tree, sourceCode)
+		}
+	} else if strings.TrimSpace(input.Pattern) != "" {
+		pattern, err := parseStructuralPattern(languageName, sitterLanguage, input.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		defer pattern.tree.Close()
+		matcher = func(tree *sitter.Tree, sourceCode []byte) []SourceBlock {
+			return pattern.matches(tree, sourceCode)
+		}
+	} else {
+		return nil, fmt.Errorf("%w: either a query or a pattern is required", ErrInvalidStructuralSearch)
+	}
+
+	subdirectory := path.Clean(filepath.ToSlash(input.Subdirectory))
+	var results []StructuralSearchFileResult
+	err = walkRelativeFiles(baseDir, func(relativePath string) error {
+		if normalizeLanguageName(utils.InferLanguageNameFromFilePath(relativePath)) != languageName {
+			return nil
+		}
+		if subdirectory != "." && !strings.HasPrefix(relativePath, subdirectory+"/") {
+			return nil
+		}
+		if input.PathGlob != "" {
+			matched, err := doublestar.PathMatch(input.PathGlob, relativePath)
+			if err != nil {
+				return fmt.Errorf("%w: invalid glob pattern %s: %v", ErrInvalidStructuralSearch, input.PathGlob, err)
+			}
+			if !matched {
+				return nil
+			}
+		}
+
+		sourceCode, err := os.ReadFile(filepath.Join(baseDir, relativePath))
+		if err != nil {
+			return err
+		}
+		parser := sitter.NewParser()
+		parser.SetLanguage(sitterLanguage)
+		tree, err := parser.ParseCtx(context.Background(), nil, sourceTransform(languageName, &sourceCode))
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", relativePath, err)
+		}
+		sourceBlocks := matcher(tree, sourceCode)
+		tree.Close()
+		if len(sourceBlocks) == 0 {
+			return nil
+		}
+
+		sourceBlocks = expandToWholeLines(sourceBlocks, input.ContextLines, sourceCode)
+		sourceBlocks = MergeAdjacentOrOverlappingSourceBlocks(sourceBlocks, strings.Split(string(sourceCode), "\n"))
+		results = append(results, StructuralSearchFileResult{FilePath: relativePath, SourceBlocks: sourceBlocks})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func queryMatches(query *sitter.Query, tree *sitter.Tree, sourceCode []byte) []SourceBlock {
+	qc := sitter.NewQueryCursor()
+	defer qc.Close()
+	qc.Exec(query, tree.RootNode())
+
+	var sourceBlocks []SourceBlock
+	for {
+		m, ok := qc.NextMatch()
+		if !ok {
+			break
+		}
+		m = qc.FilterPredicates(m, sourceCode)
+		var matchNode *sitter.Node
+		for _, c := range m.Captures {
+			if query.CaptureNameForId(c.Index) == "match" {
+				matchNode = c.Node
+				break
+			}
+			if matchNode == nil || c.Node.EndByte()-c.Node.StartByte() > matchNode.EndByte()-matchNode.StartByte() {
+				matchNode = c.Node
+			}
+		}
+		if matchNode != nil {
+			sourceBlocks = append(sourceBlocks, nodeSourceBlock(matchNode, &sourceCode))
+		}
+	}
+	return sortedUniqueSourceBlocks(sourceBlocks)
+}
+
+// expandToWholeLines expands source blocks to span whole lines, plus the given
+// number of context lines before and after
+func expandToWholeLines(sourceBlocks []SourceBlock, numContextLines int, sourceCode []byte) []SourceBlock {
+	lineStarts := []uint32{0}
+	for i, b := range sourceCode {
+		if b == '\n' && i+1 < len(sourceCode) {
+			lineStarts = append(lineStarts, uint32(i+1))
+		}
+	}
+	lastRow := uint32(len(lineStarts) - 1)
+
+	expanded := make([]SourceBlock, 0, len(sourceBlocks))
+	for _, sb := range sourceBlocks {
+		startRow := sb.Range.StartPoint.Row - min(uint32(numContextLines), sb.Range.StartPoint.Row)
+		endRow := min(sb.Range.EndPoint.Row+uint32(numContextLines), lastRow)
+		endByte := uint32(len(sourceCode))
+		if endRow < lastRow {
+			endByte = lineStarts[endRow+1]
+		}
+		expanded = append(expanded, SourceBlock{
+			Source: sb.Source,
+			Range: sitter.Range{
+				StartPoint: sitter.Point{Row: startRow, Column: 0},
+				EndPoint:   sitter.Point{Row: endRow, Column: endByte - lineStarts[endRow]},
+				StartByte:  lineStarts[startRow],
+				EndByte:    endByte,
+			},
+		})
+	}
+	return expanded
+}
+
+func nodeSourceBlock(node *sitter.Node, sourceCode *[]byte) SourceBlock {
+	return SourceBlock{
+		Source: sourceCode,
+		Range: sitter.Range{
+			StartPoint: node.StartPoint(),
+			EndPoint:   node.EndPoint(),
+			StartByte:  node.StartByte(),
+			EndByte:    node.EndByte(),
+		},
+	}
+}
+
+// sortedUniqueSourceBlocks orders source blocks by start point, as required
+// for merging them, dropping duplicates
+func sortedUniqueSourceBlocks(sourceBlocks []SourceBlock) []SourceBlock {
+	unique := make([]SourceBlock, 0, len(sourceBlocks))
+	seen := make(map[sitter.Range]bool)
+	for _, sb := range sourceBlocks {
+		if !seen[sb.Range] {
+			seen[sb.Range] = true
+			unique = append(unique, sb)
+		}
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		return unique[i].Range.StartByte < unique[j].Range.StartByte
+	})
+	return unique
+}
+
+// metavariables are swapped for identifiers so the pattern parses as regular
+// code in the target language
+var metavariablePattern = regexp.MustCompile(`\$\$\$|\$(_|[A-Z][A-Z0-9_]*)`)
+
+const metavariablePrefix = "__SIDEKICK_MV_"
+const multiMetavariable = metavariablePrefix + "MULTI__"
+const wildcardMetavariable = metavariablePrefix + "ANY__"
+
+var metavariableIdentifierPattern = regexp.MustCompile(`^__SIDEKICK_MV_[A-Z0-9_]+__$`)
+
+// delimiterTokens are ignored when matching patterns, so that eg "f(a, $$$)"
+// matches "f(a)"
+var delimiterTokens = map[string]bool{",": true, ";": true, "(": true, ")": true, "{": true, "}": true, "[": true, "]": true, "\n": true}
+
+type structuralPattern struct {
+	tree       *sitter.Tree
+	root       *sitter.Node
+	sourceCode []byte
+}
+
+func parseStructuralPattern(languageName string, sitterLanguage *sitter.Language, pattern string) (*structuralPattern, error) {
+	replaced := metavariablePattern.ReplaceAllStringFunc(pattern, func(metavariable string) string {
+		switch metavariable {
+		case "$$$":
+			return multiMetavariable
+		case "$_":
+			return wildcardMetavariable
+		default:
+			return metavariablePrefix + "VAR_" + strings.TrimPrefix(metavariable, "$") + "__"
+		}
+	})
+	var sourceCode []byte
+	var tree *sitter.Tree
+	// expression statements need a terminator in some languages, eg java
+	for _, candidate := range []string{replaced, replaced + ";"} {
+		sourceCode = []byte(candidate)
+		parser := sitter.NewParser()
+		parser.SetLanguage(sitterLanguage)
+		parsed, err := parser.ParseCtx(context.Background(), nil, sourceTransform(languageName, &sourceCode))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pattern: %w", err)
+		}
+		if !parsed.RootNode().HasError() {
+			tree = parsed
+			break
+		}
+		parsed.Close()
+	}
+	if tree == nil {
+		return nil, fmt.Errorf("%w: the pattern is not valid %s code, try a complete statement or declaration, or a tree-sitter query instead", ErrInvalidStructuralSearch, languageName)
+	}
+
+	// skip wrapper nodes such as the root and expression statements, so the
+	// pattern can match anywhere
+	root := tree.RootNode()
+	for {
+		children := significantChildren(root)
+		if len(children) != 1 || nodeText(children[0], sourceCode) != nodeText(root, sourceCode) {
+			break
+		}
+		root = children[0]
+	}
+	if root.Type() == tree.RootNode().Type() || metavariableIdentifierPattern.MatchString(nodeText(root, sourceCode)) {
+		tree.Close()
+		return nil, fmt.Errorf("%w: the pattern must contain a single statement, declaration or expression that isn't just a metavariable", ErrInvalidStructuralSearch)
+	}
+
+	return &structuralPattern{tree: tree, root: root, sourceCode: sourceCode}, nil
+}
+
+func (p *structuralPattern) matches(tree *sitter.Tree, sourceCode []byte) []SourceBlock {
+	var sourceBlocks []SourceBlock
+	var visit func(node *sitter.Node)
+	visit = func(node *sitter.Node) {
+		if node.Type() == p.root.Type() {
+			if _, ok := p.matchNode(p.root, node, sourceCode, map[string]string{}); ok {
+				sourceBlocks = append(sourceBlocks, nodeSourceBlock(node, &sourceCode))
+				// nested matches would be merged into this one anyway
+				return
+			}
+		}
+		for i := 0; i < int(node.NamedChildCount()); i++ {
+			visit(node.NamedChild(i))
+		}
+	}
+	visit(tree.RootNode())
+	return sourceBlocks
+}
+
+// matchNode matches a pattern node against a target node, returning the
+// updated metavariable bindings
+func (p *structuralPattern) matchNode(patternNode, node *sitter.Node, sourceCode []byte, bindings map[string]string) (map[string]string, bool) {
+	patternText := nodeText(patternNode, p.sourceCode)
+	if patternText == wildcardMetavariable {
+		return bindings, true
+	}
+	if metavariableIdentifierPattern.MatchString(patternText) && patternText != multiMetavariable {
+		text := nodeText(node, sourceCode)
+		if bound, ok := bindings[patternText]; ok {
+			return bindings, bound == text
+		}
+		updated := make(map[string]string, len(bindings)+1)
+		for k, v := range bindings {
+			updated[k] = v
+		}
+		updated[patternText] = text
+		return updated, true
+	}
+
+	if patternNode.Type() != node.Type() {
+		return nil, false
+	}
+	patternChildren := significantChildren(patternNode)
+	if len(patternChildren) == 0 {
+		return bindings, patternText == nodeText(node, sourceCode)
+	}
+	return p.matchSequence(patternChildren, significantChildren(node), sourceCode, bindings)
+}
+
+func (p *structuralPattern) matchSequence(patternNodes, nodes []*sitter.Node, sourceCode []byte, bindings map[string]string) (map[string]string, bool) {
+	if len(patternNodes) == 0 {
+		return bindings, len(nodes) == 0
+	}
+	if nodeText(patternNodes[0], p.sourceCode) == multiMetavariable {
+		// try consuming as few nodes as possible first
+		for consumed := 0; consumed <= len(nodes); consumed++ {
+			if updated, ok := p.matchSequence(patternNodes[1:], nodes[consumed:], sourceCode, bindings); ok {
+				return updated, true
+			}
+		}
+		return nil, false
+	}
+	if len(nodes) == 0 {
+		return nil, false
+	}
+	updated, ok := p.matchNode(patternNodes[0], nodes[0], sourceCode, bindings)
+	if !ok {
+		return nil, false
+	}
+	return p.matchSequence(patternNodes[1:], nodes[1:], sourceCode, updated)
+}
+
+// significantChildren returns the children that matter when matching a
+// pattern, ie excluding comments and delimiters
+func significantChildren(node *sitter.Node) []*sitter.Node {
+	var children []*sitter.Node
+	for i := 0; i < int(node.ChildCount()); i++ {
+		child := node.Child(i)
+		if strings.Contains(child.Type(), "comment") || (!child.IsNamed() && delimiterTokens[child.Type()]) {
+			continue
+		}
+		children = append(children, child)
+	}
+	return children
+}
+
+func nodeText(node *sitter.Node, sourceCode []byte) string {
+	return strings.TrimSpace(node.Content(sourceCode))
+}
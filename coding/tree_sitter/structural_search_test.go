@@ -0,0 +1,163 @@
+package tree_sitter
+
+import (
+	"sidekick/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const structuralSearchWorkflowSource = `package flows
+
+func RunFlow(ctx workflow.Context, input string) error {
+	workflow.ExecuteActivity(ctx, SomeActivity, input)
+	return nil
+}
+
+func Helper(ctx workflow.Context) string {
+	return "no activities here"
+}
+
+func other(ctx context.Context) {
+	workflow.ExecuteActivity(ctx, SomeActivity)
+}
+`
+
+func structuralSearchStrings(results []StructuralSearchFileResult) map[string][]string {
+	strs := make(map[string][]string)
+	for _, result := range results {
+		for _, sourceBlock := range result.SourceBlocks {
+			strs[result.FilePath] = append(strs[result.FilePath], sourceBlock.String())
+		}
+	}
+	return strs
+}
+
+func TestStructuralSearchQuery(t *testing.T) {
+	t.Parallel()
+	dir := writeSymbolGraphTestFiles(t, map[string]string{
+		"flows/flows.go":   structuralSearchWorkflowSource,
+		"ignored/flows.go": structuralSearchWorkflowSource,
+		".gitignore":       "ignored/\n",
+		"flows/flows.py":   "def RunFlow(ctx):\n    pass\n",
+	})
+
+	query := `
+(function_declaration
+  parameters: (parameter_list
+    (parameter_declaration
+      type: (qualified_type) @param_type (#eq? @param_type "workflow.Context")))
+  body: (block (expression_statement (call_expression
+    function: (selector_expression field: (field_identifier) @method (#eq? @method "ExecuteActivity")))))) @function
+`
+	results, err := StructuralSearch(dir, StructuralSearchInput{LanguageName: "go", Query: query})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"flows/flows.go": {"func RunFlow(ctx workflow.Context, input string) error {\n\tworkflow.ExecuteActivity(ctx, SomeActivity, input)\n\treturn nil\n}\n"},
+	}, structuralSearchStrings(results))
+
+	t.Run("match capture", func(t *testing.T) {
+		t.Parallel()
+		results, err := StructuralSearch(dir, StructuralSearchInput{
+			LanguageName: "golang",
+			Query:        `(function_declaration name: (identifier) @match (#match? @match "^[A-Z]"))`,
+			ContextLines: 1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]string{
+			"flows/flows.go": {
+				"\nfunc RunFlow(ctx workflow.Context, input string) error {\n\tworkflow.ExecuteActivity(ctx, SomeActivity, input)\n",
+				"\nfunc Helper(ctx workflow.Context) string {\n\treturn \"no activities here\"\n",
+			},
+		}, structuralSearchStrings(results))
+	})
+
+	t.Run("subdirectory", func(t *testing.T) {
+		t.Parallel()
+		results, err := StructuralSearch(dir, StructuralSearchInput{LanguageName: "go", Query: query, Subdirectory: "flows"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"flows/flows.go"}, utils.Map(results, func(r StructuralSearchFileResult) string { return r.FilePath }))
+
+		for _, subdirectory := range []string{"other", "flow"} {
+			results, err := StructuralSearch(dir, StructuralSearchInput{LanguageName: "go", Query: query, Subdirectory: subdirectory})
+			require.NoError(t, err)
+			assert.Empty(t, results, subdirectory)
+		}
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		t.Parallel()
+		_, err := StructuralSearch(dir, StructuralSearchInput{LanguageName: "golang", Query: "(not_a_node_type)"})
+		assert.ErrorIs(t, err, ErrInvalidStructuralSearch)
+	})
+}
+
+func TestStructuralSearchPattern(t *testing.T) {
+	t.Parallel()
+	dir := writeSymbolGraphTestFiles(t, map[string]string{
+		"flows/flows.go": structuralSearchWorkflowSource,
+		"math/math.go":   "package math\n\nfunc f(a, b int) bool {\n\tif a == a {\n\t\treturn true\n\t}\n\treturn a == b\n}\n",
+		"app/app.ts":     "function greet(name: string) {\n  // say hi\n  console.log('hi', name);\n}\nconsole.log();\n",
+	})
+
+	testCases := []struct {
+		name     string
+		input    StructuralSearchInput
+		expected map[string][]string
+	}{
+		{
+			name:  "metavariables and multi-node wildcard",
+			input: StructuralSearchInput{LanguageName: "golang", Pattern: "workflow.ExecuteActivity($CTX, SomeActivity, $$$)"},
+			expected: map[string][]string{"flows/flows.go": {
+				"\tworkflow.ExecuteActivity(ctx, SomeActivity, input)\n",
+				"\tworkflow.ExecuteActivity(ctx, SomeActivity)\n",
+			}},
+		},
+		{
+			name:  "function declaration",
+			input: StructuralSearchInput{LanguageName: "golang", Pattern: "func $NAME($_ workflow.Context) $_ { $$$ }", PathGlob: "flows/**"},
+			expected: map[string][]string{"flows/flows.go": {
+				"func Helper(ctx workflow.Context) string {\n\treturn \"no activities here\"\n}\n",
+			}},
+		},
+		{
+			name:     "repeated metavariables must match the same text",
+			input:    StructuralSearchInput{LanguageName: "golang", Pattern: "$X == $X"},
+			expected: map[string][]string{"math/math.go": {"\tif a == a {\n"}},
+		},
+		{
+			name:  "comments are ignored",
+			input: StructuralSearchInput{LanguageName: "typescript", Pattern: "function $F($$$) { console.log($$$); }"},
+			expected: map[string][]string{"app/app.ts": {
+				"function greet(name: string) {\n  // say hi\n  console.log('hi', name);\n}\n",
+			}},
+		},
+		{
+			name:     "no matches",
+			input:    StructuralSearchInput{LanguageName: "golang", Pattern: "fmt.Println($$$)"},
+			expected: map[string][]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			results, err := StructuralSearch(dir, tc.input)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, structuralSearchStrings(results))
+		})
+	}
+
+	t.Run("invalid patterns", func(t *testing.T) {
+		t.Parallel()
+		for _, pattern := range []string{"func (", "$X", "a := 1\nb := 2"} {
+			_, err := StructuralSearch(dir, StructuralSearchInput{LanguageName: "golang", Pattern: pattern})
+			assert.ErrorIs(t, err, ErrInvalidStructuralSearch, pattern)
+		}
+		_, err := StructuralSearch(dir, StructuralSearchInput{LanguageName: "cobol", Pattern: "x"})
+		assert.ErrorIs(t, err, ErrInvalidStructuralSearch)
+		_, err = StructuralSearch(dir, StructuralSearchInput{LanguageName: "golang"})
+		assert.ErrorIs(t, err, ErrInvalidStructuralSearch)
+	})
+}
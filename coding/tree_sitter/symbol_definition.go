@@ -181,7 +181,29 @@ func symbolDefinitionSourceBlocks(q *sitter.Query, tree *sitter.Tree, sourceCode
 		}
 	}
 
-	return sourceBlocks
+	return keepWidestSourceBlocks(sourceBlocks)
+}
+
+// keepWidestSourceBlocks drops blocks that are contained within another block,
+// since the outer block already shows them. This happens when a definition is
+// matched both on its own and via a wrapping node, eg a C++ class and the
+// template declaration around it, or when a constructor shares its class name.
+func keepWidestSourceBlocks(sourceBlocks []SourceBlock) []SourceBlock {
+	var result []SourceBlock
+outer:
+	for i, block := range sourceBlocks {
+		for j, other := range sourceBlocks {
+			if i == j {
+				continue
+			}
+			contained := other.Range.StartByte <= block.Range.StartByte && other.Range.EndByte >= block.Range.EndByte
+			if contained && (other.Range != block.Range || j < i) {
+				continue outer
+			}
+		}
+		result = append(result, block)
+	}
+	return result
 }
 
 func getEmbeddedLanguageSymbolDefinition(languageName string, tree *sitter.Tree, sourceCode *[]byte, symbolName string) ([]SourceBlock, error) {
@@ -0,0 +1,406 @@
+package tree_sitter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sidekick/common"
+	"sidekick/logger"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// SymbolReference is an identifier referenced within a file, along with the
+// position of its first occurrence and how often it occurs
+type SymbolReference struct {
+	Name  string
+	Point sitter.Point
+	Count int
+}
+
+// fileSymbolIndex holds the symbols a file defines and the identifiers it
+// references, which is all that's needed to connect it to other files
+type fileSymbolIndex struct {
+	Definitions []string
+	References  []SymbolReference
+}
+
+// DefinitionResolver resolves a reference to the relative paths of the files
+// that define it, eg via an LSP server. It's only consulted for names that are
+// defined in multiple files, and should return an empty slice or an error when
+// it can't resolve the reference, in which case all definers are used.
+type DefinitionResolver func(relativePath string, reference SymbolReference) ([]string, error)
+
+// SymbolGraph is a graph of files in a repository, with weighted edges from
+// files referencing identifiers to the files defining them
+type SymbolGraph struct {
+	// Files are the relative paths of all files that could be indexed
+	Files []string
+	// Edges maps each referencing file to the files it depends on
+	Edges map[string]map[string]float64
+}
+
+const (
+	// names shorter than this are too generic to connect files meaningfully
+	minGraphSymbolNameLength = 3
+	// names defined in more files than this are too ambiguous to be useful
+	maxDefinersPerSymbol = 10
+	// bounds the time spent on definition resolution for large repos
+	maxResolvedReferences = 200
+
+	pageRankDamping       = 0.85
+	pageRankMaxIterations = 50
+	pageRankTolerance     = 1e-6
+)
+
+const (
+	// one graph per base directory, eg per worktree
+	symbolGraphCacheSize        = 16
+	resolvedDefinitionCacheSize = 10000
+	// file paths are absolute, so worktrees of the same repo don't share
+	// entries and stale ones must be evicted eventually
+	symbolIndexCacheSize = 50000
+)
+
+// cachedSymbolIndex is a file's symbol index along with the checksum of the
+// content it was computed from
+type cachedSymbolIndex struct {
+	checksum string
+	index    fileSymbolIndex
+}
+
+var cachedSymbolIndexes, _ = lru.New[string, cachedSymbolIndex](symbolIndexCacheSize)
+
+// cachedSymbolGraph is a graph along with the fingerprint of the checksums of
+// the files it was built from
+type cachedSymbolGraph struct {
+	fingerprint string
+	graph       *SymbolGraph
+}
+
+var cachedSymbolGraphs, _ = lru.New[string, cachedSymbolGraph](symbolGraphCacheSize)
+
+// resolvedDefinitions caches the resolver's results, keyed by the referencing
+// file's checksum and the reference, so only references in changed files are
+// resolved again
+var resolvedDefinitions, _ = lru.New[string, []string](resolvedDefinitionCacheSize)
+
+// BuildSymbolGraph indexes all code files within the base directory and
+// connects them via the identifiers they reference. The resolver is optional.
+// The graph is reused until any file's checksum changes.
+func BuildSymbolGraph(baseDirectory string, resolver DefinitionResolver) (*SymbolGraph, error) {
+	baseDirectory, err := filepath.Abs(baseDirectory)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(baseDirectory, string(os.PathSeparator)) {
+		baseDirectory = baseDirectory + string(os.PathSeparator)
+	}
+
+	graph := &SymbolGraph{Edges: make(map[string]map[string]float64)}
+	indexes := make(map[string]fileSymbolIndex)
+	checksums := make(map[string]string)
+	cacheable := true
+	err = common.WalkCodeDirectory(baseDirectory, func(path string, entry fs.DirEntry) error {
+		if entry.IsDir() {
+			return nil
+		}
+		if _, _, err := inferLanguageFromFilePath(path); err != nil {
+			return nil
+		}
+		relativePath := strings.Replace(path, baseDirectory, "", 1)
+		index, checksum, err := getFileSymbolIndex(path)
+		if err != nil {
+			l := logger.Get()
+			l.Debug().Err(err).Msgf("error indexing symbols for file %s", relativePath)
+			return nil
+		}
+		if checksum == "" {
+			cacheable = false
+		}
+		graph.Files = append(graph.Files, relativePath)
+		indexes[relativePath] = index
+		checksums[relativePath] = checksum
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// graphs built with and without a resolver differ
+	graphKey := fmt.Sprintf("%s\x00%t", baseDirectory, resolver != nil)
+	fingerprint := symbolGraphFingerprint(graph.Files, checksums)
+	if cached, ok := cachedSymbolGraphs.Get(graphKey); ok && cacheable && cached.fingerprint == fingerprint {
+		return cached.graph, nil
+	}
+
+	definers := make(map[string][]string)
+	for _, relativePath := range graph.Files {
+		for _, name := range indexes[relativePath].Definitions {
+			definers[name] = append(definers[name], relativePath)
+		}
+	}
+
+	resolvedCount := 0
+	for _, relativePath := range graph.Files {
+		// references to a file's own definitions, including the defining
+		// identifiers themselves, are assumed to be local
+		ownDefinitions := make(map[string]bool)
+		for _, name := range indexes[relativePath].Definitions {
+			ownDefinitions[name] = true
+		}
+		for _, reference := range indexes[relativePath].References {
+			definingPaths := definers[reference.Name]
+			if ownDefinitions[reference.Name] || len(definingPaths) == 0 || len(definingPaths) > maxDefinersPerSymbol {
+				continue
+			}
+			if len(definingPaths) > 1 && resolver != nil {
+				resolutionKey := fmt.Sprintf("%s\x00%s\x00%s\x00%d:%d\x00%s", baseDirectory+relativePath, checksums[relativePath], reference.Name, reference.Point.Row, reference.Point.Column, strings.Join(definingPaths, ","))
+				resolvedPaths, ok := resolvedDefinitions.Get(resolutionKey)
+				if !ok && resolvedCount < maxResolvedReferences {
+					resolvedCount++
+					var err error
+					resolvedPaths, err = resolver(relativePath, reference)
+					if err != nil {
+						// a later build may be able to resolve it
+						cacheable = false
+						resolvedPaths = nil
+					} else if checksums[relativePath] != "" {
+						resolvedDefinitions.Add(resolutionKey, resolvedPaths)
+					}
+				}
+				if len(resolvedPaths) > 0 {
+					definingPaths = resolvedPaths
+				}
+			}
+
+			// repeated references count for more, but with diminishing returns,
+			// and ambiguous names are split across their definers
+			weight := math.Sqrt(float64(reference.Count)) / float64(len(definingPaths))
+			for _, definingPath := range definingPaths {
+				graph.AddEdge(relativePath, definingPath, weight)
+			}
+		}
+	}
+
+	// hitting the resolution limit leaves the graph incomplete, and caching
+	// it would stop the remaining references from ever being resolved
+	if cacheable && resolvedCount < maxResolvedReferences {
+		cachedSymbolGraphs.Add(graphKey, cachedSymbolGraph{fingerprint: fingerprint, graph: graph})
+	}
+	return graph, nil
+}
+
+// symbolGraphFingerprint hashes the relative paths and checksums of the files
+func symbolGraphFingerprint(files []string, checksums map[string]string) string {
+	hash := sha256.New()
+	for _, file := range files {
+		fmt.Fprintf(hash, "%s\x00%s\n", file, checksums[file])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// AddEdge adds weight to the edge between the two files, ignoring self-edges
+func (g *SymbolGraph) AddEdge(from, to string, weight float64) {
+	if from == to || weight <= 0 {
+		return
+	}
+	if g.Edges[from] == nil {
+		g.Edges[from] = make(map[string]float64)
+	}
+	g.Edges[from][to] += weight
+}
+
+// PersonalizedPageRank scores each file by its centrality in the graph,
+// biased towards the files in the personalization map (relative path to
+// weight). When no given file is in the graph, plain PageRank is computed.
+func (g *SymbolGraph) PersonalizedPageRank(personalization map[string]float64) map[string]float64 {
+	n := len(g.Files)
+	scores := make(map[string]float64, n)
+	if n == 0 {
+		return scores
+	}
+
+	teleport := make(map[string]float64, n)
+	var total float64
+	for _, file := range g.Files {
+		if weight := personalization[file]; weight > 0 {
+			teleport[file] = weight
+			total += weight
+		}
+	}
+	if total == 0 {
+		for _, file := range g.Files {
+			teleport[file] = 1
+		}
+		total = float64(n)
+	}
+	for file := range teleport {
+		teleport[file] /= total
+	}
+
+	outWeights := make(map[string]float64, len(g.Edges))
+	for from, edges := range g.Edges {
+		for _, weight := range edges {
+			outWeights[from] += weight
+		}
+	}
+
+	for file, weight := range teleport {
+		scores[file] = weight
+	}
+	for i := 0; i < pageRankMaxIterations; i++ {
+		next := make(map[string]float64, n)
+		var danglingScore float64
+		for _, file := range g.Files {
+			score := scores[file]
+			if score == 0 {
+				continue
+			}
+			if outWeights[file] == 0 {
+				danglingScore += score
+				continue
+			}
+			for to, weight := range g.Edges[file] {
+				next[to] += pageRankDamping * score * weight / outWeights[file]
+			}
+		}
+		// random jumps and dangling files both follow the personalization
+		for file, weight := range teleport {
+			next[file] += ((1 - pageRankDamping) + pageRankDamping*danglingScore) * weight
+		}
+
+		var delta float64
+		for _, file := range g.Files {
+			delta += math.Abs(next[file] - scores[file])
+		}
+		scores = next
+		if delta < pageRankTolerance {
+			break
+		}
+	}
+
+	return scores
+}
+
+// RankedFiles returns the files with a non-zero personalized PageRank score,
+// most central first
+func (g *SymbolGraph) RankedFiles(personalization map[string]float64) []string {
+	scores := g.PersonalizedPageRank(personalization)
+	ranked := make([]string, 0, len(scores))
+	for file, score := range scores {
+		if score > 0 {
+			ranked = append(ranked, file)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if scores[ranked[i]] != scores[ranked[j]] {
+			return scores[ranked[i]] > scores[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+	return ranked
+}
+
+// getFileSymbolIndex indexes the file, reusing the previous index when the
+// file's checksum hasn't changed. The checksum is empty when it couldn't be
+// computed.
+func getFileSymbolIndex(path string) (fileSymbolIndex, string, error) {
+	checksum, checksumErr := getChecksum(path)
+	if checksumErr == nil {
+		if cached, ok := cachedSymbolIndexes.Get(path); ok && cached.checksum == checksum {
+			return cached.index, checksum, nil
+		}
+	} else {
+		checksum = ""
+	}
+
+	index, err := indexFileSymbols(path)
+	if err != nil {
+		return fileSymbolIndex{}, "", err
+	}
+	if checksumErr == nil {
+		cachedSymbolIndexes.Add(path, cachedSymbolIndex{checksum: checksum, index: index})
+	}
+	return index, checksum, nil
+}
+
+func indexFileSymbols(path string) (fileSymbolIndex, error) {
+	languageName, sitterLanguage, err := inferLanguageFromFilePath(path)
+	if err != nil {
+		return fileSymbolIndex{}, err
+	}
+	sourceCode, err := os.ReadFile(path)
+	if err != nil {
+		return fileSymbolIndex{}, fmt.Errorf("failed to read file %s when indexing symbols: %v", path, err)
+	}
+	parser := sitter.NewParser()
+	parser.SetLanguage(sitterLanguage)
+	tree, err := parser.ParseCtx(context.Background(), nil, sourceTransform(languageName, &sourceCode))
+	if err != nil {
+		return fileSymbolIndex{}, err
+	}
+	defer tree.Close()
+
+	symbols, err := getSourceSymbolsInternal(languageName, sitterLanguage, tree, &sourceCode)
+	if err != nil {
+		return fileSymbolIndex{}, err
+	}
+
+	var index fileSymbolIndex
+	seenDefinitions := make(map[string]bool)
+	for _, symbol := range symbols {
+		// qualified names like "Type.method" are referenced by the last part
+		name := symbol.Content
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		if len(name) < minGraphSymbolNameLength || seenDefinitions[name] {
+			continue
+		}
+		seenDefinitions[name] = true
+		index.Definitions = append(index.Definitions, name)
+	}
+
+	referenceIndexes := make(map[string]int)
+	var visit func(node *sitter.Node)
+	visit = func(node *sitter.Node) {
+		childCount := int(node.NamedChildCount())
+		if childCount == 0 {
+			if isIdentifierNodeType(node.Type()) {
+				name := strings.TrimPrefix(node.Content(sourceCode), "$")
+				if len(name) >= minGraphSymbolNameLength {
+					if i, ok := referenceIndexes[name]; ok {
+						index.References[i].Count++
+					} else {
+						referenceIndexes[name] = len(index.References)
+						index.References = append(index.References, SymbolReference{Name: name, Point: node.StartPoint(), Count: 1})
+					}
+				}
+			}
+			return
+		}
+		for i := 0; i < childCount; i++ {
+			visit(node.NamedChild(i))
+		}
+	}
+	visit(tree.RootNode())
+
+	return index, nil
+}
+
+// isIdentifierNodeType covers the names of identifier nodes across the
+// supported grammars, eg "identifier", "type_identifier" and
+// "field_identifier", plus ruby's "constant" and php's "name"
+func isIdentifierNodeType(nodeType string) bool {
+	return strings.HasSuffix(nodeType, "identifier") || nodeType == "constant" || nodeType == "name"
+}
@@ -0,0 +1,169 @@
+package tree_sitter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSymbolGraphTestFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for relativePath, content := range files {
+		path := filepath.Join(dir, relativePath)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestBuildSymbolGraph(t *testing.T) {
+	t.Parallel()
+	dir := writeSymbolGraphTestFiles(t, map[string]string{
+		"models/user.go":    "package models\n\ntype User struct {\n\tName string\n}\n",
+		"store/store.go":    "package store\n\nfunc SaveUser(u models.User) error {\n\treturn nil\n}\n",
+		"api/handler.go":    "package api\n\nfunc handle() {\n\tstore.SaveUser(models.User{})\n\tstore.SaveUser(models.User{})\n}\n",
+		"unrelated/misc.go": "package unrelated\n\nfunc helper() {}\n",
+		"README.md":         "User docs",
+	})
+
+	graph, err := BuildSymbolGraph(dir, nil)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"models/user.go", "store/store.go", "api/handler.go", "unrelated/misc.go"}, graph.Files)
+	assert.Contains(t, graph.Edges["store/store.go"], "models/user.go")
+	assert.Contains(t, graph.Edges["api/handler.go"], "models/user.go")
+	assert.Contains(t, graph.Edges["api/handler.go"], "store/store.go")
+	assert.NotContains(t, graph.Edges, "models/user.go")
+	assert.NotContains(t, graph.Edges, "unrelated/misc.go")
+
+	// referenced twice, so weighted more than a single reference
+	assert.Greater(t, graph.Edges["api/handler.go"]["store/store.go"], 1.0)
+}
+
+func TestBuildSymbolGraphAmbiguousNames(t *testing.T) {
+	t.Parallel()
+	dir := writeSymbolGraphTestFiles(t, map[string]string{
+		"a/config.go": "package a\n\ntype Config struct{}\n",
+		"b/config.go": "package b\n\ntype Config struct{}\n",
+		"main.go":     "package main\n\nvar c a.Config\n",
+	})
+
+	t.Run("split across definers", func(t *testing.T) {
+		t.Parallel()
+		graph, err := BuildSymbolGraph(dir, nil)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.5, graph.Edges["main.go"]["a/config.go"], 0.001)
+		assert.InDelta(t, 0.5, graph.Edges["main.go"]["b/config.go"], 0.001)
+	})
+
+	t.Run("resolved by resolver", func(t *testing.T) {
+		t.Parallel()
+		var resolved []SymbolReference
+		graph, err := BuildSymbolGraph(dir, func(relativePath string, reference SymbolReference) ([]string, error) {
+			assert.Equal(t, "main.go", relativePath)
+			resolved = append(resolved, reference)
+			return []string{"a/config.go"}, nil
+		})
+		require.NoError(t, err)
+		assert.InDelta(t, 1.0, graph.Edges["main.go"]["a/config.go"], 0.001)
+		assert.NotContains(t, graph.Edges["main.go"], "b/config.go")
+		require.Len(t, resolved, 1)
+		assert.Equal(t, "Config", resolved[0].Name)
+		assert.Equal(t, uint32(2), resolved[0].Point.Row)
+	})
+}
+
+func TestBuildSymbolGraphCachesByChecksum(t *testing.T) {
+	t.Parallel()
+	dir := writeSymbolGraphTestFiles(t, map[string]string{
+		"a/config.go": "package a\n\ntype Config struct{}\n",
+		"b/config.go": "package b\n\ntype Config struct{}\n",
+		"main.go":     "package main\n\nvar c a.Config\n",
+		"other.go":    "package main\n\nvar d b.Config\n",
+	})
+	resolveCount := 0
+	resolver := func(relativePath string, reference SymbolReference) ([]string, error) {
+		resolveCount++
+		if relativePath == "main.go" {
+			return []string{"a/config.go"}, nil
+		}
+		return []string{"b/config.go"}, nil
+	}
+
+	graph, err := BuildSymbolGraph(dir, resolver)
+	require.NoError(t, err)
+	assert.Equal(t, 2, resolveCount)
+
+	cachedGraph, err := BuildSymbolGraph(dir, resolver)
+	require.NoError(t, err)
+	assert.Same(t, graph, cachedGraph)
+	assert.Equal(t, 2, resolveCount)
+
+	// only references in the changed file are resolved again
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.go"), []byte("package main\n\nvar e b.Config\n"), 0644))
+	rebuiltGraph, err := BuildSymbolGraph(dir, resolver)
+	require.NoError(t, err)
+	assert.NotSame(t, graph, rebuiltGraph)
+	assert.Equal(t, 3, resolveCount)
+	assert.InDelta(t, 1.0, rebuiltGraph.Edges["other.go"]["b/config.go"], 0.001)
+}
+
+func TestSymbolGraphPersonalizedPageRank(t *testing.T) {
+	t.Parallel()
+	graph := &SymbolGraph{
+		Files: []string{"a.go", "b.go", "c.go", "d.go", "e.go"},
+		Edges: map[string]map[string]float64{},
+	}
+	graph.AddEdge("a.go", "b.go", 1)
+	graph.AddEdge("c.go", "b.go", 1)
+	graph.AddEdge("d.go", "b.go", 1)
+	graph.AddEdge("e.go", "d.go", 1)
+	graph.AddEdge("e.go", "e.go", 1)
+
+	t.Run("self edges are ignored", func(t *testing.T) {
+		assert.NotContains(t, graph.Edges["e.go"], "e.go")
+	})
+
+	t.Run("plain pagerank favours the most referenced file", func(t *testing.T) {
+		ranked := graph.RankedFiles(nil)
+		require.Len(t, ranked, 5)
+		assert.Equal(t, "b.go", ranked[0])
+		assert.Equal(t, "d.go", ranked[1])
+
+		var total float64
+		for _, score := range graph.PersonalizedPageRank(nil) {
+			total += score
+		}
+		assert.InDelta(t, 1.0, total, 0.001)
+	})
+
+	t.Run("personalization only reaches connected files", func(t *testing.T) {
+		ranked := graph.RankedFiles(map[string]float64{"e.go": 1, "missing.go": 1})
+		assert.Equal(t, []string{"e.go", "d.go", "b.go"}, ranked)
+	})
+
+	t.Run("unknown personalization falls back to plain pagerank", func(t *testing.T) {
+		assert.Equal(t, graph.RankedFiles(nil), graph.RankedFiles(map[string]float64{"missing.go": 1}))
+	})
+}
+
+func TestGetFileSymbolIndexCachesByChecksum(t *testing.T) {
+	t.Parallel()
+	dir := writeSymbolGraphTestFiles(t, map[string]string{
+		"main.go": "package main\n\nfunc First() {}\n",
+	})
+	path := filepath.Join(dir, "main.go")
+
+	index, checksum, err := getFileSymbolIndex(path)
+	require.NoError(t, err)
+	assert.NotEmpty(t, checksum)
+	assert.Equal(t, []string{"First"}, index.Definitions)
+
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc Second() {}\n"), 0644))
+	index, _, err = getFileSymbolIndex(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Second"}, index.Definitions)
+}
@@ -11,10 +11,16 @@ import (
 	"strings"
 
 	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/c"
+	"github.com/smacker/go-tree-sitter/cpp"
+	"github.com/smacker/go-tree-sitter/csharp"
 	"github.com/smacker/go-tree-sitter/golang"
 	"github.com/smacker/go-tree-sitter/java"
 	"github.com/smacker/go-tree-sitter/kotlin"
+	"github.com/smacker/go-tree-sitter/php"
 	"github.com/smacker/go-tree-sitter/python"
+	"github.com/smacker/go-tree-sitter/ruby"
+	"github.com/smacker/go-tree-sitter/rust"
 	"github.com/smacker/go-tree-sitter/typescript/tsx"
 	"github.com/smacker/go-tree-sitter/typescript/typescript"
 )
@@ -296,6 +302,11 @@ func shouldExtendSymbolRange(languageName, captureName string) bool {
 				return false
 			}
 		}
+	case "c", "cpp":
+		{
+			// c declarators contain the name, eg "*name(void)"
+			return strings.HasSuffix(captureName, ".declarator")
+		}
 	}
 
 	return false
@@ -313,6 +324,14 @@ var nameToSymbolType map[string]string = map[string]string{
 	"interface.name":  "interface",
 	"class.name":      "class",
 	"enum.name":       "enum",
+	"struct.name":     "struct",
+	"union.name":      "union",
+	"trait.name":      "trait",
+	"module.name":     "module",
+	"namespace.name":  "namespace",
+	"macro.name":      "macro",
+	"typedef.name":    "type",
+	"static.name":     "static_variable",
 }
 
 func getSymbolType(languageName string, names []string) string {
@@ -356,6 +375,30 @@ func writeSymbolCapture(languageName string, out *strings.Builder, sourceCode *[
 		{
 			writeKotlinSymbolCapture(out, sourceCode, c, name)
 		}
+	case "rust":
+		{
+			writeRustSymbolCapture(out, sourceCode, c, name)
+		}
+	case "c":
+		{
+			writeCSymbolCapture(out, sourceCode, c, name)
+		}
+	case "cpp":
+		{
+			writeCppSymbolCapture(out, sourceCode, c, name)
+		}
+	case "csharp":
+		{
+			writeCsharpSymbolCapture(out, sourceCode, c, name)
+		}
+	case "ruby":
+		{
+			writeRubySymbolCapture(out, sourceCode, c, name)
+		}
+	case "php":
+		{
+			writePhpSymbolCapture(out, sourceCode, c, name)
+		}
 	default:
 		{
 			// NOTE this is expected to provide quite bad output until tweaked per language
@@ -419,6 +462,14 @@ func normalizeLanguageName(s string) string {
 		return "java"
 	case "ts", "typescript":
 		return "typescript"
+	case "rs", "rust":
+		return "rust"
+	case "c++", "cc", "cxx", "cpp":
+		return "cpp"
+	case "cs", "c#", "csharp":
+		return "csharp"
+	case "rb", "ruby":
+		return "ruby"
 	default:
 		return s
 	}
@@ -440,6 +491,18 @@ func getSitterLanguage(languageName string) (*sitter.Language, error) {
 		return tsx.GetLanguage(), nil
 	case "vue":
 		return vue.GetLanguage(), nil
+	case "rs", "rust":
+		return rust.GetLanguage(), nil
+	case "c":
+		return c.GetLanguage(), nil
+	case "c++", "cc", "cxx", "cpp":
+		return cpp.GetLanguage(), nil
+	case "cs", "c#", "csharp":
+		return csharp.GetLanguage(), nil
+	case "rb", "ruby":
+		return ruby.GetLanguage(), nil
+	case "php":
+		return php.GetLanguage(), nil
 	// Add more languages as needed
 	default:
 		return nil, fmt.Errorf("unsupported language: %s", languageName)
@@ -3,6 +3,7 @@ package tree_sitter
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"sidekick/srv"
 	"sidekick/utils"
 	"strings"
@@ -21,8 +22,14 @@ const ContentTypeDirChunk = "dir:chunk"
 // TODO move to RagActivities
 // TODO add param for context.Context
 func (t *TreeSitterActivities) CreateDirSignatureOutlines(workspaceId string, directoryPath string, maxCharacterLimit int) ([]string, error) {
+	return t.CreateSubdirSignatureOutlines(workspaceId, directoryPath, "", maxCharacterLimit)
+}
+
+// CreateSubdirSignatureOutlines is like CreateDirSignatureOutlines, limited to
+// the files within the given subdirectory of the directory
+func (t *TreeSitterActivities) CreateSubdirSignatureOutlines(workspaceId string, directoryPath string, subdirectory string, maxCharacterLimit int) ([]string, error) {
 	// FIXME perf: have a way to skip getting outlines for the ones we already set in the DB, eg using checksums
-	outlines, err := GetDirectorySignatureOutlines(directoryPath, nil, nil)
+	outlines, err := GetSubdirectorySignatureOutlines(directoryPath, subdirectory, nil, nil)
 
 	if err != nil {
 		return []string{}, err
@@ -31,20 +38,7 @@ func (t *TreeSitterActivities) CreateDirSignatureOutlines(workspaceId string, di
 	values := make(map[string]interface{})
 	hashes := make([]string, 0, len(outlines))
 	for _, outline := range outlines {
-		if outline.OutlineType == OutlineTypeFileSignature && outline.Content != "" {
-			goodCharacterLimit := DefaultPreferredChunkChars
-			if maxCharacterLimit < goodCharacterLimit {
-				goodCharacterLimit = maxCharacterLimit
-			}
-			outlineChunks := splitOutlineIntoChunks(outline.Content, goodCharacterLimit, maxCharacterLimit)
-			for _, chunk := range outlineChunks {
-				value := outline.Path + "\n" + chunk
-				hash := utils.Hash256(value)
-				hashes = append(hashes, hash)
-				key := fmt.Sprintf("%s:%s", ContentTypeFileSignature, hash)
-				values[key] = value
-			}
-		}
+		hashes = append(hashes, signatureOutlineChunks(outline, maxCharacterLimit, values)...)
 	}
 
 	err = t.DatabaseAccessor.MSet(context.Background(), workspaceId, values)
@@ -55,6 +49,60 @@ func (t *TreeSitterActivities) CreateDirSignatureOutlines(workspaceId string, di
 	return hashes, nil
 }
 
+// CreateFileSignatureOutlines is like CreateDirSignatureOutlines, limited to
+// the given files, with paths relative to the directory. The subkeys are
+// returned per file, and files without an outline, eg because they were
+// removed or their language isn't supported, have none.
+func (t *TreeSitterActivities) CreateFileSignatureOutlines(workspaceId string, directoryPath string, relativePaths []string, maxCharacterLimit int) (map[string][]string, error) {
+	values := make(map[string]interface{})
+	hashesByPath := make(map[string][]string, len(relativePaths))
+	for _, relativePath := range relativePaths {
+		path := filepath.Join(directoryPath, relativePath)
+		outlineContent, err := getCachedFileSignaturesString(path)
+		if err != nil {
+			// unsupported languages are expected to fail
+			hashesByPath[relativePath] = nil
+			continue
+		}
+		outline := FileOutline{
+			Path:        relativePath,
+			OutlineType: OutlineTypeFileSignature,
+			Content:     limitSignatureOutline(path, outlineContent, maxSignatureOutlineLength),
+		}
+		hashesByPath[relativePath] = signatureOutlineChunks(outline, maxCharacterLimit, values)
+	}
+
+	if len(values) > 0 {
+		err := t.DatabaseAccessor.MSet(context.Background(), workspaceId, values)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hashesByPath, nil
+}
+
+// signatureOutlineChunks splits a file's signature outline into chunks no
+// longer than the limit, adding them to values by key and returning their
+// subkeys
+func signatureOutlineChunks(outline FileOutline, maxCharacterLimit int, values map[string]interface{}) []string {
+	if outline.OutlineType != OutlineTypeFileSignature || outline.Content == "" {
+		return nil
+	}
+	goodCharacterLimit := DefaultPreferredChunkChars
+	if maxCharacterLimit < goodCharacterLimit {
+		goodCharacterLimit = maxCharacterLimit
+	}
+	var hashes []string
+	for _, chunk := range splitOutlineIntoChunks(outline.Content, goodCharacterLimit, maxCharacterLimit) {
+		value := outline.Path + "\n" + chunk
+		hash := utils.Hash256(value)
+		hashes = append(hashes, hash)
+		key := fmt.Sprintf("%s:%s", ContentTypeFileSignature, hash)
+		values[key] = value
+	}
+	return hashes
+}
+
 func splitOutlineIntoChunks(s string, goodChunkSize int, maxChunkSize int) []string {
 	if s == "" {
 		return []string{}
@@ -6,10 +6,15 @@ import (
 	"errors"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"sidekick/logger"
+	"sidekick/telemetry"
 	"sidekick/utils"
 	"strings"
 	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type RunCommandActivityInput struct {
@@ -17,6 +22,9 @@ type RunCommandActivityInput struct {
 	Command    string   `json:"command"`
 	Args       []string `json:"args"`
 	EnvVars    []string `json:"envVars"`
+	// Category labels the command in metrics, eg "run_tests" or "search".
+	// Defaults to the base name of Command.
+	Category string `json:"category,omitempty"`
 }
 
 type RunCommandActivityOutput struct {
@@ -25,7 +33,25 @@ type RunCommandActivityOutput struct {
 	ExitStatus int    `json:"exitStatus"`
 }
 
-func RunCommandActivity(ctx context.Context, input RunCommandActivityInput) (RunCommandActivityOutput, error) {
+func RunCommandActivity(ctx context.Context, input RunCommandActivityInput) (output RunCommandActivityOutput, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "command",
+		attribute.String("process.command", input.Command),
+		attribute.StringSlice("process.command_args", input.Args),
+		attribute.String("process.working_directory", input.WorkingDir),
+	)
+	start := time.Now()
+	defer func() {
+		span.SetAttributes(attribute.Int("process.exit.code", output.ExitStatus))
+		telemetry.EndSpan(span, err)
+		if input.Command != "" {
+			category := input.Category
+			if category == "" {
+				category = filepath.Base(input.Command)
+			}
+			telemetry.RecordCommand(category, output.ExitStatus, time.Since(start), err)
+		}
+	}()
+
 	if input.WorkingDir == "" {
 		return RunCommandActivityOutput{}, errors.New("WorkingDir must be provided")
 	}
@@ -52,7 +78,7 @@ func RunCommandActivity(ctx context.Context, input RunCommandActivityInput) (Run
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	err = cmd.Run()
 
 	exitStatus := 0
 	// Check if there's an error and if it's an ExitError.
@@ -68,7 +94,7 @@ func RunCommandActivity(ctx context.Context, input RunCommandActivityInput) (Run
 		}
 		err = nil
 	}
-	output := RunCommandActivityOutput{
+	output = RunCommandActivityOutput{
 		Stdout:     stdout.String(),
 		Stderr:     stderr.String(),
 		ExitStatus: exitStatus,
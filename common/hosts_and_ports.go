@@ -24,6 +24,20 @@ func GetServerPort() int {
 	return intPort
 }
 
+// GetWorkerMetricsPort returns the port a standalone worker serves /metrics on
+func GetWorkerMetricsPort() int {
+	port := os.Getenv("SIDE_WORKER_METRICS_PORT")
+	if port == "" {
+		return GetServerPort() + 1
+	}
+
+	intPort, err := strconv.Atoi(port)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to parse side worker metrics port: %s", port))
+	}
+	return intPort
+}
+
 func GetTemporalNamespace() string {
 	temporalNamespace := os.Getenv("SIDE_TEMPORAL_NAMESPACE")
 	if temporalNamespace == "" {
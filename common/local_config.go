@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"time"
 
 	"github.com/adrg/xdg"
 	"github.com/knadh/koanf/parsers/yaml"
@@ -17,6 +18,36 @@ type LocalConfig struct {
 	Providers []ModelProviderConfig    `koanf:"providers,omitempty"`
 	LLM       map[string][]ModelConfig `koanf:"llm,omitempty"`
 	Embedding map[string][]ModelConfig `koanf:"embedding,omitempty"`
+
+	// SecretManagers are consulted in order when looking up secrets. When
+	// empty, the system keyring and then this config file are used.
+	SecretManagers []SecretManagerConfig `koanf:"secret_managers,omitempty"`
+
+	Worktrees WorktreeRetentionConfig `koanf:"worktrees,omitempty"`
+}
+
+const DefaultWorktreeRetentionDays = 7
+
+// WorktreeRetentionConfig determines when orphaned worktrees, eg those left
+// behind by failed or canceled tasks, may be pruned
+type WorktreeRetentionConfig struct {
+	// orphaned worktrees with unmerged work are kept for this many days after
+	// they were created. Defaults to DefaultWorktreeRetentionDays, while a
+	// negative value keeps them indefinitely.
+	RetentionDays int `koanf:"retention_days,omitempty"`
+}
+
+// Retention returns how long orphaned worktrees with unmerged work are kept,
+// or a negative duration if they are kept indefinitely
+func (c WorktreeRetentionConfig) Retention() time.Duration {
+	days := c.RetentionDays
+	if days == 0 {
+		days = DefaultWorktreeRetentionDays
+	}
+	if days < 0 {
+		return -1
+	}
+	return time.Duration(days) * 24 * time.Hour
 }
 
 // getCustomProviderNames returns a slice of custom provider names
@@ -56,12 +87,21 @@ func (c LocalConfig) Validate() error {
 		}
 	}
 
+	for i, sm := range c.SecretManagers {
+		if err := sm.Validate(); err != nil {
+			return fmt.Errorf("invalid secret manager at index %d: %w", i, err)
+		}
+	}
+
 	// Validate LLM configs
 	for useCase, configs := range c.LLM {
 		for _, mc := range configs {
 			if err := c.validateProvider(mc.Provider, true); err != nil {
 				return fmt.Errorf("invalid provider in LLM config for use case %s: %w", useCase, err)
 			}
+			if err := mc.validateEditFormat(); err != nil {
+				return fmt.Errorf("invalid LLM config for use case %s: %w", useCase, err)
+			}
 		}
 	}
 
@@ -9,6 +9,8 @@ type LocalPublicConfig struct {
 	Providers []ModelProviderPublicConfig `json:"providers,omitempty"`
 	LLM       LLMConfig                   `json:"llm"`
 	Embedding EmbeddingConfig             `json:"embedding"`
+	// SecretManagers only reference secrets, so they are safe to share
+	SecretManagers []SecretManagerConfig `json:"secret_managers,omitempty"`
 }
 
 // ModelProviderPublicConfig represents the model provider configuration without keys
@@ -77,8 +79,9 @@ func GetLocalConfig() (LocalPublicConfig, error) {
 	}
 
 	return LocalPublicConfig{
-		Providers: providers,
-		LLM:       llmConfig,
-		Embedding: embeddingConfig,
+		Providers:      providers,
+		LLM:            llmConfig,
+		Embedding:      embeddingConfig,
+		SecretManagers: config.SecretManagers,
 	}, nil
 }
@@ -118,4 +118,70 @@ providers:
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "invalid provider type: invalid_type")
 	})
+
+	t.Run("edit format", func(t *testing.T) {
+		configYAML := `
+llm:
+  defaults:
+    - provider: openai
+      model: gpt-4o
+      edit_format: unified_diff
+`
+		require.NoError(t, os.WriteFile(configPath, []byte(configYAML), 0644))
+
+		config, err := LoadSidekickConfig(configPath)
+		require.NoError(t, err)
+		assert.Equal(t, EditFormatUnifiedDiff, config.LLM["defaults"][0].EditFormat)
+		assert.Equal(t, EditFormatUnifiedDiff, config.LLM["defaults"][0].NormalizedEditFormat())
+		assert.Equal(t, EditFormatSearchReplace, ModelConfig{Provider: "openai"}.NormalizedEditFormat())
+	})
+
+	t.Run("invalid config - unknown edit format", func(t *testing.T) {
+		configYAML := `
+llm:
+  defaults:
+    - provider: openai
+      edit_format: patch
+`
+		require.NoError(t, os.WriteFile(configPath, []byte(configYAML), 0644))
+
+		_, err := LoadSidekickConfig(configPath)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid edit format "patch"`)
+	})
+
+	t.Run("secret managers", func(t *testing.T) {
+		configYAML := `
+secret_managers:
+  - type: vault
+    address: https://vault.example.com
+    mount: kv
+    path: team/sidekick
+  - type: encrypted_file
+    file: ~/.config/sidekick/secrets.age
+    identity_file: ~/.config/sidekick/key.txt
+  - type: keyring
+`
+		require.NoError(t, os.WriteFile(configPath, []byte(configYAML), 0644))
+
+		config, err := LoadSidekickConfig(configPath)
+		require.NoError(t, err)
+		assert.Equal(t, []SecretManagerConfig{
+			{Type: "vault", Address: "https://vault.example.com", Mount: "kv", Path: "team/sidekick"},
+			{Type: "encrypted_file", File: "~/.config/sidekick/secrets.age", IdentityFile: "~/.config/sidekick/key.txt"},
+			{Type: "keyring"},
+		}, config.SecretManagers)
+	})
+
+	t.Run("invalid config - unknown secret manager", func(t *testing.T) {
+		configYAML := `
+secret_managers:
+  - type: lastpass
+`
+		require.NoError(t, os.WriteFile(configPath, []byte(configYAML), 0644))
+
+		_, err := LoadSidekickConfig(configPath)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid secret manager type: lastpass")
+	})
 }
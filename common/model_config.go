@@ -1,13 +1,49 @@
 package common
 
-import "strings"
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Edit formats determine how a coding model is asked to express code changes
+const (
+	// EditFormatSearchReplace uses search/replace edit blocks, the default
+	EditFormatSearchReplace = "search_replace"
+	// EditFormatUnifiedDiff uses unified diff hunks, applied with fuzzy
+	// context matching
+	EditFormatUnifiedDiff = "unified_diff"
+	// EditFormatWholeFile allows small files to be rewritten in full, in
+	// addition to search/replace edit blocks
+	EditFormatWholeFile = "whole_file"
+)
+
+var EditFormats = []string{EditFormatSearchReplace, EditFormatUnifiedDiff, EditFormatWholeFile}
 
 type ModelConfig struct {
 	// Provider here is the provider name, not the provider type (though they may be the same)
 	Provider string `koanf:"provider" json:"provider"`
 	Model    string `koanf:"model,omitempty" json:"model,omitempty"`
+	// EditFormat is only relevant for models used to edit code. Empty means
+	// EditFormatSearchReplace.
+	EditFormat string `koanf:"edit_format,omitempty" json:"editFormat,omitempty"`
 }
 
 func (c ModelConfig) NormalizedProviderName() string {
 	return strings.ToUpper(strings.ReplaceAll(strings.ReplaceAll(c.Provider, " ", "_"), "-", "_"))
 }
+
+// NormalizedEditFormat returns the edit format, defaulting to search/replace
+func (c ModelConfig) NormalizedEditFormat() string {
+	if c.EditFormat == "" {
+		return EditFormatSearchReplace
+	}
+	return c.EditFormat
+}
+
+func (c ModelConfig) validateEditFormat() error {
+	if c.EditFormat != "" && !slices.Contains(EditFormats, c.EditFormat) {
+		return fmt.Errorf("invalid edit format %q, must be one of: %s", c.EditFormat, strings.Join(EditFormats, ", "))
+	}
+	return nil
+}
@@ -1,5 +1,12 @@
 package common
 
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
 type RepoConfig struct {
 	/** A set of commands to run to check the code for basic issues, eg syntax
 	 * err, after an edit to determine if it is a good edit. A failed check
@@ -7,6 +14,10 @@ type RepoConfig struct {
 	 * GenAI is not able to easily self-repair iteratively after a mistake. */
 	CheckCommands []CommandConfig `toml:"check_commands,omitempty"`
 
+	/** Built-in compile and type checks to run after each edit, in addition
+	 * to the check commands. */
+	BuiltinChecks BuiltinChecksConfig `toml:"builtin_checks,omitempty"`
+
 	/** A set of commands to run to fix the code after applying an edit. This
 	 * helps avoid checks reverting code for simple issues. Ideal for things
 	 * like auto-importing for example. */
@@ -53,6 +64,189 @@ type RepoConfig struct {
 	 * The script is executed using /usr/bin/env sh -c and must return a zero
 	 * exit code to be considered successful. */
 	WorktreeSetup string `toml:"worktree_setup,omitempty"`
+
+	/** Dependency directories to share between worktrees, so that the worktree
+	 * setup script only needs to run when lockfiles change. */
+	DependencyCache DependencyCacheConfig `toml:"dependency_cache,omitempty"`
+
+	/** How worktree branches are named. Sidekick's "side/<summary>" names are
+	 * used by default. */
+	BranchNaming BranchNamingConfig `toml:"branch_naming,omitempty"`
+
+	/** How sidekick makes git commits: hooks, message conventions, trailers
+	 * and author. */
+	Commit CommitConfig `toml:"commit,omitempty"`
+
+	/** How to bring the latest changes from the base branch into a worktree's
+	 * branch: either "merge" (the default) or "rebase". A rebase that runs
+	 * into conflicts falls back to a merge. */
+	BaseBranchUpdateStrategy string `toml:"base_branch_update_strategy,omitempty"`
+
+	/** When enabled, a worktree's branch is updated with the latest changes
+	 * from the base branch before asking for merge approval, so the review
+	 * shows an up-to-date diff. Any conflicts are resolved and tests are run
+	 * again first. */
+	UpdateBaseBranchBeforeMerge bool `toml:"update_base_branch_before_merge,omitempty"`
+
+	/** Sub-projects of a monorepo, each a directory with its own side.toml.
+	 * These are discovered rather than configured: nested side.toml files may
+	 * set check, autofix, test and integration test commands, mission and
+	 * edit_code hints, and all other settings come from the root side.toml. */
+	SubProjects []SubProject `toml:"-"`
+}
+
+// SubProject is a directory in the repo with its own side.toml. Its command
+// working directories are resolved relative to the repo root when loaded.
+type SubProject struct {
+	// Path is the slash-separated path of the directory, relative to the repo
+	// root
+	Path   string
+	Config RepoConfig
+}
+
+// SubProject returns the sub-project at the given path, if any
+func (c RepoConfig) SubProject(subProjectPath string) (SubProject, bool) {
+	for _, subProject := range c.SubProjects {
+		if subProject.Path == subProjectPath {
+			return subProject, true
+		}
+	}
+	return SubProject{}, false
+}
+
+// configsFor returns the configs that apply to the given path relative to the
+// repo root, innermost sub-project first and ending with the root config
+func (c RepoConfig) configsFor(relativePath string) []RepoConfig {
+	var enclosing []SubProject
+	for _, subProject := range c.SubProjects {
+		if relativePath == subProject.Path || strings.HasPrefix(relativePath, subProject.Path+"/") {
+			enclosing = append(enclosing, subProject)
+		}
+	}
+	sort.Slice(enclosing, func(i, j int) bool {
+		return len(enclosing[i].Path) > len(enclosing[j].Path)
+	})
+
+	configs := make([]RepoConfig, 0, len(enclosing)+1)
+	for _, subProject := range enclosing {
+		configs = append(configs, subProject.Config)
+	}
+	return append(configs, c)
+}
+
+// CommandsForPaths returns the commands that apply to any of the given paths,
+// relative to the repo root, without duplicates. The commands for each path
+// come from its innermost enclosing sub-project that configures any, falling
+// back to the root config, eg a diff touching only one sub-project's files
+// only runs that sub-project's tests.
+func (c RepoConfig) CommandsForPaths(paths []string, getCommands func(RepoConfig) []CommandConfig) []CommandConfig {
+	var commands []CommandConfig
+	seen := map[CommandConfig]bool{}
+	for _, relativePath := range paths {
+		relativePath = path.Clean(filepath.ToSlash(relativePath))
+		for _, config := range c.configsFor(relativePath) {
+			configCommands := getCommands(config)
+			if len(configCommands) == 0 {
+				continue
+			}
+			for _, command := range configCommands {
+				if !seen[command] {
+					seen[command] = true
+					commands = append(commands, command)
+				}
+			}
+			break
+		}
+	}
+	return commands
+}
+
+// ScopedTo returns the config for tasks scoped to the given sub-project path.
+// The edit_code hints of the root and all enclosing sub-projects are combined,
+// outermost first, and the innermost mission is used.
+func (c RepoConfig) ScopedTo(subProjectPath string) RepoConfig {
+	scoped := c
+	configs := c.configsFor(subProjectPath)
+	var hints []string
+	for i := len(configs) - 1; i >= 0; i-- {
+		if configs[i].EditCode.Hints != "" {
+			hints = append(hints, configs[i].EditCode.Hints)
+		}
+		if configs[i].Mission != "" {
+			scoped.Mission = configs[i].Mission
+		}
+	}
+	scoped.EditCode.Hints = strings.Join(hints, "\n\n")
+	return scoped
+}
+
+type BuiltinChecksConfig struct {
+	/** Languages to check after each edit: "java" and "kotlin" are compiled
+	 * with gradle or maven, "python" is type checked with pyright or mypy, and
+	 * "typescript" and "vue" are type checked with tsc or vue-tsc in watch
+	 * mode. Errors that an edit introduces in the edited file fail the check
+	 * like a failed check command. Languages whose tools aren't installed are
+	 * skipped. */
+	Languages []string `toml:"languages,omitempty"`
+
+	/** The maximum number of seconds a built-in check may take, after which
+	 * it is skipped. Defaults to 60. */
+	TimeoutSeconds int `toml:"timeout_seconds,omitempty"`
+}
+
+type DependencyCacheConfig struct {
+	/** Globs relative to the repo root matching the directories that the
+	 * worktree setup script populates, eg "node_modules". They are cached per
+	 * workspace, keyed by the contents of all tracked lockfiles, and cloned
+	 * into new worktrees instead of running the setup script again. Only
+	 * directories that can be moved are suitable: python virtualenvs, for
+	 * one, hardcode their absolute path and would keep using the worktree they
+	 * were created in. */
+	Paths []string `toml:"paths,omitempty"`
+}
+
+type BranchNamingConfig struct {
+	/** A go text/template for branch names, given .Slug (a short kebab-case
+	 * summary of the task), .TaskId, .Ticket (the first ticket id in the task's
+	 * requirements), .Date (YYYY-MM-DD) and .User. Defaults to
+	 * "side/{{.Slug}}". */
+	Template string `toml:"template,omitempty"`
+
+	/** Generated branch names must match this regex, eg
+	 * "^[a-z0-9.-]+/[A-Z]+-[0-9]+-[a-z0-9-]+$". */
+	ValidationRegex string `toml:"validation_regex,omitempty"`
+
+	/** The regex used to find a ticket id in the task's requirements for
+	 * .Ticket. Defaults to Jira-style ids like "ABC-123". */
+	TicketPattern string `toml:"ticket_pattern,omitempty"`
+
+	/** The value of .User. Defaults to the part of the git user.email before
+	 * the "@". */
+	User string `toml:"user,omitempty"`
+}
+
+type CommitConfig struct {
+	/** Git hooks run when sidekick commits, and a failing pre-commit hook is
+	 * fed back to the coding loop like a failing test. Set this to skip hooks
+	 * instead, ie commit with --no-verify. */
+	SkipHooks bool `toml:"skip_hooks,omitempty"`
+
+	/** Prefix commit subjects with a conventional commit type inferred from the
+	 * message and changed files, eg "fix: Handle empty input". */
+	Conventional bool `toml:"conventional,omitempty"`
+
+	/** A go text/template for commit messages, given .Type (the inferred
+	 * conventional commit type), .Subject, .Body and .Message (the original
+	 * message). Takes precedence over Conventional. */
+	MessageTemplate string `toml:"message_template,omitempty"`
+
+	/** Trailers added to every commit message, eg
+	 * "Signed-off-by: Jane Doe <jane@example.com>". */
+	Trailers []string `toml:"trailers,omitempty"`
+
+	/** The commit author as "Name <email>". Defaults to
+	 * "Sidekick <sidekick@side.dev>". */
+	Author string `toml:"author,omitempty"`
 }
 
 type CommandConfig struct {
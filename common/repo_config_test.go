@@ -0,0 +1,83 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoConfigCommandsForPaths(t *testing.T) {
+	t.Parallel()
+	rootTest := CommandConfig{Command: "make test"}
+	apiTest := CommandConfig{WorkingDir: "services/api", Command: "go test ./..."}
+	authTest := CommandConfig{WorkingDir: "services/api/auth", Command: "go test ./..."}
+	webCheck := CommandConfig{WorkingDir: "web", Command: "npm run lint"}
+	config := RepoConfig{
+		TestCommands: []CommandConfig{rootTest},
+		SubProjects: []SubProject{
+			{Path: "services/api", Config: RepoConfig{TestCommands: []CommandConfig{apiTest}}},
+			{Path: "services/api/auth", Config: RepoConfig{TestCommands: []CommandConfig{authTest}}},
+			{Path: "web", Config: RepoConfig{CheckCommands: []CommandConfig{webCheck}}},
+		},
+	}
+	testCommands := func(c RepoConfig) []CommandConfig { return c.TestCommands }
+	checkCommands := func(c RepoConfig) []CommandConfig { return c.CheckCommands }
+
+	testCases := []struct {
+		name     string
+		paths    []string
+		get      func(RepoConfig) []CommandConfig
+		expected []CommandConfig
+	}{
+		{"root file", []string{"README.md"}, testCommands, []CommandConfig{rootTest}},
+		{"sub-project file", []string{"services/api/main.go"}, testCommands, []CommandConfig{apiTest}},
+		{"nested sub-project file", []string{"services/api/auth/auth.go"}, testCommands, []CommandConfig{authTest}},
+		{"sub-project directory", []string{"services/api"}, testCommands, []CommandConfig{apiTest}},
+		{"similar prefix", []string{"services/api2/main.go"}, testCommands, []CommandConfig{rootTest}},
+		{"falls back to root", []string{"web/app.ts"}, testCommands, []CommandConfig{rootTest}},
+		{"other command type", []string{"web/app.ts"}, checkCommands, []CommandConfig{webCheck}},
+		{"none configured", []string{"README.md"}, checkCommands, nil},
+		{
+			name:     "multiple sub-projects without duplicates",
+			paths:    []string{"services/api/a.go", "services/api/auth/b.go", "services/api/c.go", "web/app.ts"},
+			get:      testCommands,
+			expected: []CommandConfig{apiTest, authTest, rootTest},
+		},
+		{"whole repo", []string{""}, testCommands, []CommandConfig{rootTest}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, config.CommandsForPaths(tc.paths, tc.get))
+		})
+	}
+}
+
+func TestRepoConfigScopedTo(t *testing.T) {
+	t.Parallel()
+	config := RepoConfig{
+		Mission:       "A monorepo",
+		MaxIterations: 5,
+		EditCode:      EditCodeConfig{Hints: "root hints"},
+		SubProjects: []SubProject{
+			{Path: "services", Config: RepoConfig{Mission: "Backend services"}},
+			{Path: "services/api", Config: RepoConfig{EditCode: EditCodeConfig{Hints: "api hints"}}},
+			{Path: "web", Config: RepoConfig{Mission: "The web app", EditCode: EditCodeConfig{Hints: "web hints"}}},
+		},
+	}
+
+	scoped := config.ScopedTo("services/api")
+	assert.Equal(t, "Backend services", scoped.Mission)
+	assert.Equal(t, "root hints\n\napi hints", scoped.EditCode.Hints)
+	assert.Equal(t, 5, scoped.MaxIterations)
+	assert.Equal(t, config.SubProjects, scoped.SubProjects)
+
+	scoped = config.ScopedTo("web")
+	assert.Equal(t, "The web app", scoped.Mission)
+	assert.Equal(t, "root hints\n\nweb hints", scoped.EditCode.Hints)
+
+	subProject, ok := config.SubProject("services/api")
+	assert.True(t, ok)
+	assert.Equal(t, "api hints", subProject.Config.EditCode.Hints)
+	_, ok = config.SubProject("services/missing")
+	assert.False(t, ok)
+}
@@ -0,0 +1,51 @@
+package common
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ValidSecretManagerTypes are the secret manager types that may be configured
+// in the local config
+var ValidSecretManagerTypes = []string{"env", "keyring", "local_config", "pass", "1password", "vault", "encrypted_file"}
+
+// SecretManagerConfig configures one of the secret managers that sidekick
+// consults, in order, when it needs a secret such as an API key. Only
+// references to secrets are configured here, never the secrets themselves.
+type SecretManagerConfig struct {
+	Type string `koanf:"type" json:"type"`
+
+	// pass: the directory within the password store holding secrets
+	Prefix string `koanf:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// 1password: the vault, item field and optionally account to read from
+	Vault   string `koanf:"vault,omitempty" json:"vault,omitempty"`
+	Field   string `koanf:"field,omitempty" json:"field,omitempty"`
+	Account string `koanf:"account,omitempty" json:"account,omitempty"`
+
+	// vault: the server address, KV v2 mount, secret path and namespace
+	Address   string `koanf:"address,omitempty" json:"address,omitempty"`
+	Mount     string `koanf:"mount,omitempty" json:"mount,omitempty"`
+	Path      string `koanf:"path,omitempty" json:"path,omitempty"`
+	Namespace string `koanf:"namespace,omitempty" json:"namespace,omitempty"`
+
+	// encrypted_file: the age-encrypted file, plus either an age identity
+	// file or the name of an env var holding the passphrase
+	File             string `koanf:"file,omitempty" json:"file,omitempty"`
+	IdentityFile     string `koanf:"identity_file,omitempty" json:"identity_file,omitempty"`
+	PassphraseEnvVar string `koanf:"passphrase_env_var,omitempty" json:"passphrase_env_var,omitempty"`
+}
+
+// Validate ensures the SecretManagerConfig is valid
+func (c SecretManagerConfig) Validate() error {
+	if c.Type == "" {
+		return fmt.Errorf("secret manager type is required")
+	}
+	if !slices.Contains(ValidSecretManagerTypes, c.Type) {
+		return fmt.Errorf("invalid secret manager type: %s", c.Type)
+	}
+	if c.Type == "encrypted_file" && c.File == "" {
+		return fmt.Errorf("file is required for encrypted_file secret manager")
+	}
+	return nil
+}
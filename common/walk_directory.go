@@ -20,6 +20,11 @@ const (
 	SideIgnoreType
 )
 
+// ExternalIgnoreType is for ignore files given explicitly rather than found in
+// the directory tree, eg the core ignore file. They take the lowest
+// precedence, like rg's --ignore-file.
+const ExternalIgnoreType IgnoreFileType = -1
+
 // String returns the filename for the ignore file type
 func (t IgnoreFileType) String() string {
 	switch t {
@@ -133,6 +138,23 @@ func (im *IgnoreManager) AddIgnoreFile(ignoreType IgnoreFileType, dir string) er
 	return nil
 }
 
+// AddExternalIgnoreFile adds an ignore file from anywhere on disk, with its
+// patterns relative to dir
+func (im *IgnoreManager) AddExternalIgnoreFile(path string, dir string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	im.files = append(im.files, IgnoreFile{
+		Type:      ExternalIgnoreType,
+		Dir:       dir,
+		GitIgnore: gitignore.New(file, dir, nil),
+	})
+	sortIgnoreFiles(im.files)
+	return nil
+}
+
 // NewIgnoreManager creates a new IgnoreManager for the given directory
 func NewIgnoreManager(baseDirectory string) (*IgnoreManager, error) {
 	files, err := collectAncestorIgnoreFiles(baseDirectory)
@@ -155,11 +177,23 @@ func (im *IgnoreManager) IsIgnored(path string, isDir bool) bool {
 }
 
 func WalkCodeDirectory(baseDirectory string, handleEntry func(string, fs.DirEntry) error) error {
+	return WalkCodeDirectoryWithIgnoreFiles(baseDirectory, nil, handleEntry)
+}
+
+// WalkCodeDirectoryWithIgnoreFiles walks the directory like WalkCodeDirectory,
+// also skipping paths matched by the given external ignore files, whose
+// patterns are relative to baseDirectory
+func WalkCodeDirectoryWithIgnoreFiles(baseDirectory string, ignoreFilePaths []string, handleEntry func(string, fs.DirEntry) error) error {
 	// Create ignore manager to handle all ignore files
 	ignoreManager, err := NewIgnoreManager(baseDirectory)
 	if err != nil {
 		return err
 	}
+	for _, ignoreFilePath := range ignoreFilePaths {
+		if err := ignoreManager.AddExternalIgnoreFile(ignoreFilePath, baseDirectory); err != nil {
+			return err
+		}
+	}
 
 	// Validate that baseDirectory is a directory
 	info, err := os.Stat(baseDirectory)
@@ -180,9 +214,12 @@ func WalkCodeDirectory(baseDirectory string, handleEntry func(string, fs.DirEntr
 			return nil
 		}
 
-		// Skip the .git directory
-		if entry.IsDir() && entry.Name() == ".git" {
-			return filepath.SkipDir
+		// Skip the .git directory, or the .git file of a linked worktree
+		if entry.Name() == ".git" {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
 		}
 
 		// Check if path should be ignored
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -29,7 +30,8 @@ import (
 )
 
 type DevActivities struct {
-	LSPActivities *lsp.LSPActivities
+	LSPActivities      *lsp.LSPActivities
+	TypescriptWatchers *check.TypescriptWatchers
 }
 
 type ApplyEditBlockReport struct {
@@ -52,6 +54,10 @@ type ApplyEditBlockReport struct {
 	InitialDiff string `json:"initialDiff"`
 	/* FinalDiff records the diff after autofixes are applied (if any) */
 	FinalDiff string `json:"finalDiff"`
+
+	// UpdatedFilePaths lists other files that were updated to account for a
+	// rename, eg to fix their imports
+	UpdatedFilePaths []string `json:"updatedFilePaths,omitempty"`
 }
 
 type ApplyEditBlockActivityInput struct {
@@ -59,6 +65,17 @@ type ApplyEditBlockActivityInput struct {
 	EditBlocks    []EditBlock
 	EnabledFlags  []string
 	CheckCommands []common.CommandConfig
+	BuiltinChecks common.BuiltinChecksConfig
+	// SubProjects determine the check and autofix commands for files within
+	// them, in place of the root config's
+	SubProjects []common.SubProject
+}
+
+// checkCommandsFor returns the check commands for the given file, see
+// common.RepoConfig.CommandsForPaths
+func (input ApplyEditBlockActivityInput) checkCommandsFor(filePath string) []common.CommandConfig {
+	repoConfig := common.RepoConfig{CheckCommands: input.CheckCommands, SubProjects: input.SubProjects}
+	return repoConfig.CommandsForPaths([]string{filePath}, selectCheckCommands)
 }
 
 // DEPRECATED: use DevActivities.ApplyEditBlocks instead
@@ -85,19 +102,29 @@ func (da *DevActivities) ApplyEditBlocks(ctx context.Context, input ApplyEditBlo
 	for i, block := range input.EditBlocks {
 		var report ApplyEditBlockReport
 		var err error
+		typescriptCheck := da.prepareTypescriptCheck(ctx, input, block)
+		builtinCheck := prepareBuiltinCheck(ctx, input, block)
 
 		switch block.EditType {
 		case "create":
 			report, err = ApplyCreateEditBlock(block, baseDir)
-			AutofixIfEditSucceeded(ctx, da, input.EnvContainer, &report)
+			AutofixIfEditSucceeded(ctx, da, input.EnvContainer, input.SubProjects, &report)
 		case "update":
 			report, err = ApplyUpdateEditBlock(block, baseDir)
-			AutofixIfEditSucceeded(ctx, da, input.EnvContainer, &report)
+			AutofixIfEditSucceeded(ctx, da, input.EnvContainer, input.SubProjects, &report)
 		case "append":
 			report, err = ApplyAppendEditBlock(block, baseDir)
-			AutofixIfEditSucceeded(ctx, da, input.EnvContainer, &report)
+			AutofixIfEditSucceeded(ctx, da, input.EnvContainer, input.SubProjects, &report)
+		case "rewrite":
+			report, err = ApplyRewriteEditBlock(block, baseDir)
+			AutofixIfEditSucceeded(ctx, da, input.EnvContainer, input.SubProjects, &report)
+		case EditTypeReplaceSymbol, EditTypeDeleteSymbol, EditTypeInsertBeforeSymbol, EditTypeInsertAfterSymbol:
+			report, err = ApplySymbolEditBlock(block, baseDir)
+			AutofixIfEditSucceeded(ctx, da, input.EnvContainer, input.SubProjects, &report)
 		case "delete":
 			report, err = ApplyDeleteEditBlock(block, baseDir)
+		case "rename":
+			report, err = da.ApplyRenameEditBlock(ctx, input.EnvContainer, block)
 		default:
 			report = ApplyEditBlockReport{
 				OriginalEditBlock: block,
@@ -148,8 +175,19 @@ func (da *DevActivities) ApplyEditBlocks(ctx context.Context, input ApplyEditBlo
 						currentBlockError += "\n" + errMsg
 					}
 				}
-			} else { // create, update, append
-				checkResult, checkErr := checkAndStageOrRestoreFile(input.EnvContainer, input.CheckCommands, block.FilePath, block.EditType != "create")
+			} else if block.EditType == "rename" {
+				// Like deletions, renames aren't checked: the updates are
+				// mechanical and were already staged along with the move.
+				report.FinalDiff = report.InitialDiff
+			} else { // create, update, append, rewrite, symbol edits
+				checkResult, checkErr := checkAndStageOrRestoreFile(ctx, check.CheckFileActivityInput{
+					EnvContainer:  input.EnvContainer,
+					FilePath:      block.FilePath,
+					CheckCommands: input.checkCommandsFor(block.FilePath),
+					BuiltinChecks: input.BuiltinChecks,
+					Typescript:    typescriptCheck,
+					Builtin:       builtinCheck,
+				}, block.EditType != "create")
 				report.CheckResult = checkResult
 
 				if !checkResult.Success {
@@ -201,8 +239,10 @@ func (da *DevActivities) ApplyEditBlocks(ctx context.Context, input ApplyEditBlo
 			* made, because this takes into account both the edit and any
 			* autofixes that were applied.
 			 */
-			lineEdits := getLineEditsFromDiff(report.FinalDiff)
-			updateVisibleFileRanges(input.EditBlocks[i:], block.FilePath, lineEdits)
+			if block.EditType != "rename" {
+				lineEdits := getLineEditsFromDiff(report.FinalDiff)
+				updateVisibleFileRanges(input.EditBlocks[i:], block.FilePath, lineEdits)
+			}
 
 			// Notify LSP server about the file changes
 			err := da.notifyLSPServerOfFileChanges(ctx, input.EnvContainer, block.FilePath, block.EditType)
@@ -224,6 +264,38 @@ func (da *DevActivities) ApplyEditBlocks(ctx context.Context, input ApplyEditBlo
 	return reports, nil
 }
 
+// prepareTypescriptCheck records the type errors in a typescript or vue file
+// before it is edited, so that only errors introduced by the edit fail its
+// checks
+func (da *DevActivities) prepareTypescriptCheck(ctx context.Context, input ApplyEditBlockActivityInput, block EditBlock) *check.TypescriptCheck {
+	if da.TypescriptWatchers == nil || !slices.Contains(input.EnabledFlags, fflag.CheckEdits) {
+		return nil
+	}
+	if block.EditType == "delete" || block.EditType == "rename" {
+		return nil
+	}
+	typescriptCheck, err := da.TypescriptWatchers.PrepareCheck(ctx, input.EnvContainer, block.FilePath, input.BuiltinChecks)
+	if err != nil {
+		log.Warn().Err(err).Str("filePath", block.FilePath).Msg("Failed to prepare typescript check")
+		return nil
+	}
+	return typescriptCheck
+}
+
+// prepareBuiltinCheck records the errors that the built-in compile or type
+// check finds in a file before it is edited, so that only new ones fail the
+// edit
+func prepareBuiltinCheck(ctx context.Context, input ApplyEditBlockActivityInput, block EditBlock) *check.BuiltinCheck {
+	if !slices.Contains(input.EnabledFlags, fflag.CheckEdits) {
+		return nil
+	}
+	// new files have no errors to begin with
+	if block.EditType == "create" || block.EditType == "delete" || block.EditType == "rename" {
+		return nil
+	}
+	return check.PrepareBuiltinCheck(ctx, input.EnvContainer, block.FilePath, input.BuiltinChecks)
+}
+
 // notifyLSPServerOfFileChanges notifies the LSP server about file changes
 // by sending appropriate textDocument notifications based on edit type and
 // server capabilities
@@ -232,7 +304,7 @@ func (da *DevActivities) ApplyEditBlocks(ctx context.Context, input ApplyEditBlo
 // throughout, then close at the end
 func (da *DevActivities) notifyLSPServerOfFileChanges(ctx context.Context, envContainer env.EnvContainer, filePath string, editType string) error {
 	switch editType {
-	case "update", "append":
+	case "update", "append", "rewrite", EditTypeReplaceSymbol, EditTypeDeleteSymbol, EditTypeInsertBeforeSymbol, EditTypeInsertAfterSymbol:
 		return da.notifyDidOpenChangeSaveAndClose(ctx, envContainer, filePath)
 	case "create":
 		return da.notifyDidOpenChangeSaveAndClose(ctx, envContainer, filePath)
@@ -240,6 +312,9 @@ func (da *DevActivities) notifyLSPServerOfFileChanges(ctx context.Context, envCo
 	case "delete":
 		return nil
 		// TODO call notifyDeleteFile if server supports it
+	case "rename":
+		// didRenameFiles is sent as part of applying the rename
+		return nil
 	default:
 		return fmt.Errorf("unknown edit type: %s", editType)
 	}
@@ -375,6 +450,10 @@ func fixCheckHint(report ApplyEditBlockReport) string {
 		hint = hint + "Make sure to add enough context in the old lines, more than just 2 or 3 lines, at least 5 if available.\n"
 	}
 
+	if isSymbolEditType(report.OriginalEditBlock.EditType) && report.OriginalEditBlock.EditType != EditTypeDeleteSymbol {
+		hint = hint + "Symbol edits operate on whole lines of the symbol's full definition, including its doc comment, so the new lines must be complete definitions with correct indentation.\n"
+	}
+
 	if hint == "" {
 		if strings.Contains(report.CheckResult.Message, check.SyntaxError) {
 			hint = "Ensure the replacement of old lines with new lines results in good syntax, and make sure to do something different than what failed.\n"
@@ -398,12 +477,10 @@ func countUnbalanced(lines []string, openingDelimiter, closingDelimiter string)
 // Checks the file after applying the edit. If the checks fail, the file is
 // restored, otherwise it is staged, so that future restores don't affect this
 // change.
-func checkAndStageOrRestoreFile(envContainer env.EnvContainer, checkCommands []common.CommandConfig, filePath string, isExistingFile bool) (CheckResult, error) {
-	checkOutput, checkErr := check.CheckFileActivity(check.CheckFileActivityInput{
-		EnvContainer:  envContainer,
-		FilePath:      filePath,
-		CheckCommands: checkCommands,
-	})
+func checkAndStageOrRestoreFile(ctx context.Context, checkInput check.CheckFileActivityInput, isExistingFile bool) (CheckResult, error) {
+	envContainer := checkInput.EnvContainer
+	filePath := checkInput.FilePath
+	checkOutput, checkErr := check.CheckFileActivity(ctx, checkInput)
 
 	if checkErr != nil && checkOutput.Output == "" {
 		return CheckResult{}, checkErr
@@ -411,10 +488,10 @@ func checkAndStageOrRestoreFile(envContainer env.EnvContainer, checkCommands []c
 
 	// if checks failed, restore the file to its previous state
 	if !checkOutput.AllPassed {
-		checkResult := CheckResult{Success: false, Message: fmt.Sprintf("Checks failed:\n%s", checkOutput.Output)}
+		checkResult := CheckResult{Success: false, Message: fmt.Sprintf("Checks failed:\n%s", checkOutput.Output), Diagnostics: checkOutput.Diagnostics}
 		if isExistingFile {
 			// Restoring the file to its previous state in case of an error
-			err := git.GitRestoreActivity(context.Background(), envContainer, filePath)
+			err := git.GitRestoreActivity(ctx, envContainer, filePath)
 			if err != nil {
 				return checkResult, fmt.Errorf("%v\nFailed to git restore: %v", checkErr, err)
 			}
@@ -529,6 +606,137 @@ func ApplyAppendEditBlock(block EditBlock, baseDir string) (ApplyEditBlockReport
 	return report, nil
 }
 
+// maxRewriteFileLines limits whole-file rewrites to small files: rewriting
+// larger files is slow and risks the model silently dropping code
+const maxRewriteFileLines = 300
+
+func ApplyRewriteEditBlock(block EditBlock, baseDir string) (ApplyEditBlockReport, error) {
+	report := ApplyEditBlockReport{
+		OriginalEditBlock: block,
+	}
+
+	absoluteFilePath := filepath.Join(baseDir, block.FilePath)
+	originalContents, err := os.ReadFile(absoluteFilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			report.Error = fmt.Sprintf("file does not exist: %s. Use CREATE_FILE to create new files.", block.FilePath)
+			return report, errors.New(report.Error)
+		}
+		report.Error = fmt.Errorf("failed to read file %s: %v", absoluteFilePath, err).Error()
+		return report, err
+	}
+
+	originalLineCount := len(strings.Split(strings.TrimSuffix(string(originalContents), "\n"), "\n"))
+	if originalLineCount > maxRewriteFileLines {
+		report.Error = fmt.Sprintf("file %s has %d lines, which is too long to rewrite in full (the maximum is %d lines). Use %s edit blocks to edit it instead.", block.FilePath, originalLineCount, maxRewriteFileLines, search)
+		return report, errors.New(report.Error)
+	}
+
+	newContents := strings.Join(block.NewLines, "\n")
+	if strings.HasSuffix(string(originalContents), "\n") && !strings.HasSuffix(newContents, "\n") {
+		newContents += "\n"
+	}
+	err = os.WriteFile(absoluteFilePath, []byte(newContents), 0644)
+	if err != nil {
+		report.Error = fmt.Errorf("failed to rewrite file %s: %v", absoluteFilePath, err).Error()
+		return report, err
+	}
+	report.InitialDiff = string(diffp.Diff(block.FilePath, []byte(originalContents), block.FilePath, []byte(newContents)))
+
+	return report, nil
+}
+
+// ApplySymbolEditBlock applies a symbol edit to the lines spanned by the
+// symbol's definition, which must be unique within the file
+func ApplySymbolEditBlock(block EditBlock, baseDir string) (ApplyEditBlockReport, error) {
+	report := ApplyEditBlockReport{
+		OriginalEditBlock: block,
+	}
+
+	absoluteFilePath := filepath.Join(baseDir, block.FilePath)
+	originalContents, err := os.ReadFile(absoluteFilePath)
+	if err != nil {
+		report.Error = fmt.Errorf("failed to read file %s: %v", absoluteFilePath, err).Error()
+		return report, err
+	}
+	if block.SymbolName == "" {
+		report.Error = fmt.Sprintf("missing symbol name for %s edit block", block.EditType)
+		return report, errors.New(report.Error)
+	}
+
+	definitions, err := tree_sitter.GetSymbolDefinitions(absoluteFilePath, block.SymbolName, 0)
+	if err != nil {
+		report.Error = fmt.Sprintf("failed to find symbol %s in %s: %v", block.SymbolName, block.FilePath, err)
+		return report, errors.New(report.Error)
+	}
+	if len(definitions) > 1 {
+		lineRanges := utils.Map(definitions, func(definition tree_sitter.SourceBlock) string {
+			startLine, endLine := symbolDefinitionLineRange(definition)
+			return fmt.Sprintf("lines %d-%d", startLine+1, endLine+1)
+		})
+		report.Error = fmt.Sprintf("symbol %s is ambiguous in %s, found %d definitions at %s. Qualify the symbol with its parent, eg Parent.child, or use %s edit blocks instead.", block.SymbolName, block.FilePath, len(definitions), strings.Join(lineRanges, ", "), search)
+		return report, errors.New(report.Error)
+	}
+
+	modifiedContents := applySymbolEdit(block, string(originalContents), definitions[0])
+	err = os.WriteFile(absoluteFilePath, []byte(modifiedContents), 0644)
+	if err != nil {
+		report.Error = fmt.Errorf("failed to write modified content to file %s: %v", absoluteFilePath, err).Error()
+		return report, err
+	}
+	report.InitialDiff = string(diffp.Diff(block.FilePath, originalContents, block.FilePath, []byte(modifiedContents)))
+
+	return report, nil
+}
+
+// symbolDefinitionLineRange returns the 0-indexed, inclusive range of lines
+// spanned by the definition
+func symbolDefinitionLineRange(definition tree_sitter.SourceBlock) (int, int) {
+	startLine := int(definition.Range.StartPoint.Row)
+	endLine := int(definition.Range.EndPoint.Row)
+	if definition.Range.EndPoint.Column == 0 && endLine > startLine {
+		endLine--
+	}
+	return startLine, endLine
+}
+
+func applySymbolEdit(block EditBlock, originalContents string, definition tree_sitter.SourceBlock) string {
+	originalLines := strings.Split(originalContents, "\n")
+	startLine, endLine := symbolDefinitionLineRange(definition)
+	before := originalLines[:startLine]
+	after := originalLines[endLine+1:]
+	definitionLines := originalLines[startLine : endLine+1]
+
+	var middle []string
+	switch block.EditType {
+	case EditTypeReplaceSymbol:
+		middle = block.NewLines
+	case EditTypeDeleteSymbol:
+		// avoid leaving behind the blank line that separated the definition
+		if len(after) > 0 && isWhitespace(after[0]) && (len(before) == 0 || isWhitespace(before[len(before)-1])) {
+			after = after[1:]
+		}
+	case EditTypeInsertBeforeSymbol:
+		middle = append(middle, block.NewLines...)
+		if len(block.NewLines) > 0 && !isWhitespace(block.NewLines[len(block.NewLines)-1]) {
+			middle = append(middle, "")
+		}
+		middle = append(middle, definitionLines...)
+	case EditTypeInsertAfterSymbol:
+		middle = append(middle, definitionLines...)
+		if len(block.NewLines) > 0 && !isWhitespace(block.NewLines[0]) {
+			middle = append(middle, "")
+		}
+		middle = append(middle, block.NewLines...)
+	}
+
+	newLines := make([]string, 0, len(before)+len(middle)+len(after))
+	newLines = append(newLines, before...)
+	newLines = append(newLines, middle...)
+	newLines = append(newLines, after...)
+	return strings.Join(newLines, "\n")
+}
+
 // TODO /gen write tests for this
 func validateAndApplyEditBlocks(dCtx DevContext, editBlocks []EditBlock) ([]ApplyEditBlockReport, error) {
 	actionParams := map[string]interface{}{
@@ -552,13 +760,18 @@ func validateAndApplyEditBlocks(dCtx DevContext, editBlocks []EditBlock) ([]Appl
 		if visibilityVersion >= 1 && fflag.IsEnabled(dCtx, fflag.DisableContextCodeVisibilityCheck) {
 			validEditBlocks = editBlocks
 		} else {
-			validEditBlocks, invalidReports = validateEditBlocks(editBlocks)
+			checks := editBlockVisibilityChecks{
+				rewrites:    workflow.GetVersion(dCtx, "rewrite-visibility-check", workflow.DefaultVersion, 1) >= 1,
+				symbolEdits: workflow.GetVersion(dCtx, "symbol-edit-visibility-check", workflow.DefaultVersion, 1) >= 1,
+			}
+			validEditBlocks, invalidReports = validateEditBlocks(editBlocks, checks)
 		}
 		//fmt.Printf("Validated %d edit blocks\n", len(validEditBlocks))
 		//fmt.Printf("Invalid reports: %d\n", len(invalidReports))
 
 		enabledFlags := make([]string, 0)
-		if fflag.IsEnabled(dCtx, fflag.CheckEdits) {
+		// conflict resolutions are checked by checkMergeConflictResolution
+		if !dCtx.ResolvingMergeConflicts && fflag.IsEnabled(dCtx, fflag.CheckEdits) {
 			enabledFlags = append(enabledFlags, fflag.CheckEdits)
 		}
 
@@ -567,6 +780,8 @@ func validateAndApplyEditBlocks(dCtx DevContext, editBlocks []EditBlock) ([]Appl
 			EditBlocks:    validEditBlocks,
 			EnabledFlags:  enabledFlags,
 			CheckCommands: dCtx.RepoConfig.CheckCommands,
+			BuiltinChecks: dCtx.RepoConfig.BuiltinChecks,
+			SubProjects:   dCtx.RepoConfig.SubProjects,
 		}
 
 		noRetryCtx := utils.NoRetryCtx(dCtx)
@@ -603,11 +818,49 @@ func validateAndApplyEditBlocks(dCtx DevContext, editBlocks []EditBlock) ([]Appl
 	return fullReports, err
 }
 
-func validateEditBlocks(editBlocks []EditBlock) (validEditBlocks []EditBlock, invalidReports []ApplyEditBlockReport) {
+// editBlockVisibilityChecks enables the visibility checks for edit blocks
+// without old lines, which older workflow versions didn't perform
+type editBlockVisibilityChecks struct {
+	// rewrites require the rewritten file to have been shown
+	rewrites bool
+	// symbolEdits require the symbol, or any other part of its file, to have
+	// been shown
+	symbolEdits bool
+}
+
+func validateEditBlocks(editBlocks []EditBlock, checks editBlockVisibilityChecks) (validEditBlocks []EditBlock, invalidReports []ApplyEditBlockReport) {
 	// for each edit block, check if it's valid by checking if the old lines are
 	// present within any SourceCodeBlock in the chat history if not, add it to
 	// the invalidReports. if valid, add it to the validEditBlocks
 	for _, editBlock := range editBlocks {
+		if checks.rewrites && editBlock.EditType == "rewrite" {
+			// rewrites replace the whole file, so they must be based on its
+			// current contents, which must have been shown
+			if isFileVisible(editBlock) {
+				validEditBlocks = append(validEditBlocks, editBlock)
+			} else {
+				invalidReports = append(invalidReports, ApplyEditBlockReport{
+					OriginalEditBlock: editBlock,
+					DidApply:          false,
+					Error:             fmt.Sprintf("No code context found in the chat history for %s. You must view the file's current contents by using one of the tools before rewriting it.", editBlock.FilePath),
+				})
+			}
+			continue
+		}
+		if checks.symbolEdits && isSymbolEditType(editBlock.EditType) {
+			// the file's visible code blocks include any showing the symbol
+			if isFileVisible(editBlock) {
+				validEditBlocks = append(validEditBlocks, editBlock)
+			} else {
+				invalidReports = append(invalidReports, ApplyEditBlockReport{
+					OriginalEditBlock: editBlock,
+					DidApply:          false,
+					Error:             fmt.Sprintf("No code context found in the chat history for symbol %s in %s. You must view the symbol's current definition by using one of the tools before editing it.", editBlock.SymbolName, editBlock.FilePath),
+				})
+			}
+			continue
+		}
+
 		if len(editBlock.OldLines) == 0 {
 			// creating a file or appending to a file doesn't require old lines. we
 			// only validate old lines for now, so let's just consider these valid.
@@ -675,6 +928,14 @@ the tools before making an edit block.`, strings.Join(editBlock.OldLines, "\n"),
 	return validEditBlocks, invalidReports
 }
 
+// isFileVisible reports whether any of the edit block's file was shown in the
+// chat history when the edit block was authored
+func isFileVisible(editBlock EditBlock) bool {
+	return slices.ContainsFunc(editBlock.VisibleCodeBlocks, func(codeBlock tree_sitter.CodeBlock) bool {
+		return filepath.Clean(codeBlock.FilePath) == filepath.Clean(editBlock.FilePath)
+	})
+}
+
 // go should really get generics. or have a better way to do this.
 func firstLines(strs []string, n int) string {
 	if n > len(strs) {
@@ -814,6 +1075,171 @@ func ApplyDeleteEditBlock(block EditBlock, baseDir string) (ApplyEditBlockReport
 	}, nil
 }
 
+// ApplyRenameEditBlock moves a file via git mv, then updates references to it
+// in other files. The language server provides these updates when it supports
+// willRenameFiles for the file, otherwise imports are rewritten via
+// tree-sitter, which only supports some languages.
+func (da *DevActivities) ApplyRenameEditBlock(ctx context.Context, envContainer env.EnvContainer, block EditBlock) (ApplyEditBlockReport, error) {
+	report := ApplyEditBlockReport{
+		OriginalEditBlock: block,
+	}
+
+	baseDir := envContainer.Env.GetWorkingDirectory()
+	if block.NewFilePath == "" {
+		report.Error = fmt.Sprintf("no new file path given for renaming %s", block.FilePath)
+		return report, errors.New(report.Error)
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, block.FilePath)); err != nil {
+		if os.IsNotExist(err) {
+			report.Error = fmt.Sprintf("file does not exist: %s", block.FilePath)
+		} else {
+			report.Error = fmt.Sprintf("failed to check if file exists %s: %v", block.FilePath, err)
+		}
+		return report, errors.New(report.Error)
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, block.NewFilePath)); err == nil {
+		report.Error = fmt.Sprintf("file already exists: %s", block.NewFilePath)
+		return report, errors.New(report.Error)
+	} else if !os.IsNotExist(err) {
+		report.Error = fmt.Sprintf("failed to check if file exists %s: %v", block.NewFilePath, err)
+		return report, errors.New(report.Error)
+	}
+
+	// the language server must be asked before the file is actually moved
+	renameInput := lsp.RenameFileActivityInput{RepoDir: baseDir, OldFilePath: block.FilePath, NewFilePath: block.NewFilePath}
+	workspaceEdit, err := da.LSPActivities.WorkspaceWillRenameFileActivity(ctx, renameInput)
+	if err != nil {
+		log.Debug().Err(err).Str("filePath", block.FilePath).Msg("Language server didn't provide edits for rename")
+	}
+	var documentEdits []lsp.TextDocumentEdit
+	if workspaceEdit != nil {
+		documentEdits = workspaceEdit.TextDocumentEdits()
+	}
+	useLSPEdit := len(documentEdits) > 0
+
+	// original contents of updated files, keyed by relative path
+	originals := map[string][]byte{}
+	if useLSPEdit {
+		for _, documentEdit := range documentEdits {
+			relativePath, err := relativePathFromURI(baseDir, documentEdit.TextDocument.URI)
+			if err != nil {
+				report.Error = fmt.Sprintf("invalid document URI in language server edits for rename: %v", err)
+				return report, errors.New(report.Error)
+			}
+			original, err := os.ReadFile(filepath.Join(baseDir, relativePath))
+			if err != nil {
+				report.Error = fmt.Sprintf("failed to read file edited by language server for rename: %v", err)
+				return report, errors.New(report.Error)
+			}
+			// the moved file's own edits are made before it's moved
+			if relativePath == block.FilePath {
+				relativePath = block.NewFilePath
+			}
+			if _, ok := originals[relativePath]; !ok {
+				originals[relativePath] = original
+			}
+		}
+		if err := lsp.ApplyWorkspaceEdit(ctx, envContainer, *workspaceEdit); err != nil {
+			report.Error = fmt.Sprintf("failed to apply language server edits for rename: %v", err)
+			if restoreErr := restoreRenameOriginals(baseDir, block, originals, false); restoreErr != nil {
+				report.Error += fmt.Sprintf("\nfailed to revert the edits: %v", restoreErr)
+			}
+			return report, errors.New(report.Error)
+		}
+	}
+
+	err = git.GitMvActivity(ctx, git.GitMvActivityInput{EnvContainer: envContainer, OldPath: block.FilePath, NewPath: block.NewFilePath})
+	if err != nil {
+		report.Error = fmt.Sprintf("failed to move %s to %s: %v", block.FilePath, block.NewFilePath, err)
+		if restoreErr := restoreRenameOriginals(baseDir, block, originals, false); restoreErr != nil {
+			report.Error += fmt.Sprintf("\nfailed to revert the language server edits: %v", restoreErr)
+		}
+		return report, errors.New(report.Error)
+	}
+
+	if !useLSPEdit {
+		originals, err = tree_sitter.UpdateImportsForRename(baseDir, block.FilePath, block.NewFilePath)
+		if err != nil {
+			// a partial rename would leave broken imports behind, so undo it
+			report.Error = fmt.Sprintf("failed to update imports for moving %s to %s, so the move was reverted: %v", block.FilePath, block.NewFilePath, err)
+			restoreErr := restoreRenameOriginals(baseDir, block, originals, true)
+			if restoreErr == nil {
+				restoreErr = git.GitMvActivity(ctx, git.GitMvActivityInput{EnvContainer: envContainer, OldPath: block.NewFilePath, NewPath: block.FilePath})
+			}
+			if restoreErr != nil {
+				report.Error += fmt.Sprintf("\nfailed to revert the move: %v", restoreErr)
+			}
+			return report, errors.New(report.Error)
+		}
+	}
+	if err := da.LSPActivities.WorkspaceDidRenameFileActivity(ctx, renameInput); err != nil {
+		log.Debug().Err(err).Str("filePath", block.NewFilePath).Msg("Failed to notify LSP server of rename")
+	}
+
+	for relativePath := range originals {
+		if relativePath != block.NewFilePath {
+			report.UpdatedFilePaths = append(report.UpdatedFilePaths, relativePath)
+		}
+	}
+	sort.Strings(report.UpdatedFilePaths)
+
+	// git mv staged the move, but not the updates made to it or its
+	// importers. these are staged too, so that restoring a later failed edit
+	// doesn't undo part of the rename
+	for _, relativePath := range append([]string{block.NewFilePath}, report.UpdatedFilePaths...) {
+		if err := gitAdd(envContainer, relativePath); err != nil {
+			report.Error = fmt.Sprintf("moved %s to %s, but failed to stage %s: %v", block.FilePath, block.NewFilePath, relativePath, err)
+		}
+	}
+
+	var initialDiff strings.Builder
+	fmt.Fprintf(&initialDiff, "rename from %s\nrename to %s\n", block.FilePath, block.NewFilePath)
+	if original, ok := originals[block.NewFilePath]; ok {
+		newContents, _ := os.ReadFile(filepath.Join(baseDir, block.NewFilePath))
+		initialDiff.Write(diffp.Diff(block.FilePath, original, block.NewFilePath, newContents))
+	}
+	for _, relativePath := range report.UpdatedFilePaths {
+		newContents, _ := os.ReadFile(filepath.Join(baseDir, relativePath))
+		initialDiff.Write(diffp.Diff(relativePath, originals[relativePath], relativePath, newContents))
+	}
+	report.InitialDiff = initialDiff.String()
+
+	return report, nil
+}
+
+// restoreRenameOriginals writes back the original contents of the files
+// updated for a rename. The moved file's original is keyed by its new path,
+// but is still at its old path unless moved is true.
+func restoreRenameOriginals(baseDir string, block EditBlock, originals map[string][]byte, moved bool) error {
+	var errs []error
+	for relativePath, original := range originals {
+		if relativePath == block.NewFilePath && !moved {
+			relativePath = block.FilePath
+		}
+		if err := os.WriteFile(filepath.Join(baseDir, relativePath), original, 0644); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// relativePathFromURI converts a file URI within the base directory to a
+// slash-separated relative path
+func relativePathFromURI(baseDir, documentURI string) (string, error) {
+	u, err := url.Parse(documentURI)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("not a file URI: %s", documentURI)
+	}
+	relativePath, err := filepath.Rel(baseDir, u.Path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(relativePath), nil
+}
+
 var whitespaceOrEndingDelimiterPattern = regexp.MustCompile(`^\s*([})\]]*\s*)+$`)
 
 func isWhitespaceOrEndingDelimiter(s string) bool {
@@ -1213,11 +1639,11 @@ func getLineEditsFromDiff(diff string) []lineEdit {
 	return lineEdits
 }
 
-func AutofixIfEditSucceeded(ctx context.Context, devActivities *DevActivities, envContainer env.EnvContainer, report *ApplyEditBlockReport) {
+func AutofixIfEditSucceeded(ctx context.Context, devActivities *DevActivities, envContainer env.EnvContainer, subProjects []common.SubProject, report *ApplyEditBlockReport) {
 	if report.Error != "" {
 		return
 	}
-	runAutofixCommands(envContainer, report)
+	runAutofixCommands(envContainer, subProjects, report)
 
 	// LSP-based autofix
 
@@ -1241,21 +1667,24 @@ func AutofixIfEditSucceeded(ctx context.Context, devActivities *DevActivities, e
 }
 
 // command-based autofix
-func runAutofixCommands(envContainer env.EnvContainer, report *ApplyEditBlockReport) {
+func runAutofixCommands(envContainer env.EnvContainer, subProjects []common.SubProject, report *ApplyEditBlockReport) {
 	var combinedOutput string
 	// FIXME don't lookup config, instead have AutofixCommands as a field in the
 	// ApplyEditBlockActivityInput and in this function's arguments etc
-	repoConfig, err := GetRepoConfigActivity(envContainer)
+	// sub-projects are passed in, so only the root side.toml needs reading
+	repoConfig, err := readRepoConfigFile(envContainer.Env.GetWorkingDirectory())
 	if err != nil {
 		combinedOutput = fmt.Sprintf("failed to get coding config: %v", err)
 	}
-	autofixCommands := repoConfig.AutofixCommands
+	repoConfig.SubProjects = subProjects
+	autofixCommands := repoConfig.CommandsForPaths([]string{report.OriginalEditBlock.FilePath}, selectAutofixCommands)
 	for _, command := range autofixCommands {
 		// allow the file path to be used in the command
 		shellCommand := strings.ReplaceAll(command.Command, "{file}", report.OriginalEditBlock.FilePath)
 		output, err := envContainer.Env.RunCommand(context.Background(), env.EnvRunCommandInput{
 			RelativeWorkingDir: command.WorkingDir,
 			Command:            "/usr/bin/env",
+			Category:           "autofix",
 			Args:               []string{"sh", "-c", shellCommand},
 		})
 		if err != nil {
@@ -1275,4 +1704,6 @@ func runAutofixCommands(envContainer env.EnvContainer, report *ApplyEditBlockRep
 type CheckResult struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
+	// Diagnostics are the type errors that the edit introduced
+	Diagnostics []check.Diagnostic `json:"diagnostics,omitempty"`
 }
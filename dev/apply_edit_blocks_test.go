@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sidekick/coding/check"
 	"sidekick/coding/lsp"
 	"sidekick/coding/tree_sitter"
 	"sidekick/common"
@@ -45,7 +46,7 @@ func TestValidateEditBlocksEmptyChatHistory(t *testing.T) {
 	}
 
 	// Call validateEditBlocks
-	validEditBlocks, invalidReports := validateEditBlocks(editBlocks)
+	validEditBlocks, invalidReports := validateEditBlocks(editBlocks, editBlockVisibilityChecks{})
 
 	assert.Equal(t, 1, len(validEditBlocks), "Expected edit block with empty old lines to be valid")
 	assert.Equal(t, len(editBlocks)-1, len(invalidReports), "Expected all edit blocks with old lines to be invalid")
@@ -84,7 +85,7 @@ func TestValidateEditBlocksWithValidBlocks(t *testing.T) {
 	}
 
 	// Call validateEditBlocks
-	validEditBlocks, invalidReports := validateEditBlocks(editBlocks)
+	validEditBlocks, invalidReports := validateEditBlocks(editBlocks, editBlockVisibilityChecks{})
 	fmt.Printf("invalidReports: %v\n", invalidReports)
 
 	// Assert that the correct edit blocks are marked as valid
@@ -92,6 +93,52 @@ func TestValidateEditBlocksWithValidBlocks(t *testing.T) {
 	assert.Equal(t, 0, len(invalidReports), "Expected no invalid edit blocks")
 }
 
+func TestValidateEditBlocksRewriteVisibility(t *testing.T) {
+	visibleCodeBlocks := []tree_sitter.CodeBlock{{FilePath: "shown.go", Code: "package main"}}
+	editBlocks := []EditBlock{
+		{EditType: "rewrite", FilePath: "shown.go", NewLines: []string{"package main"}, VisibleCodeBlocks: visibleCodeBlocks},
+		{EditType: "rewrite", FilePath: "unseen.go", NewLines: []string{"package main"}, VisibleCodeBlocks: visibleCodeBlocks},
+		{EditType: "create", FilePath: "new.go", NewLines: []string{"package main"}},
+	}
+
+	validEditBlocks, invalidReports := validateEditBlocks(editBlocks, editBlockVisibilityChecks{rewrites: true})
+	require.Len(t, validEditBlocks, 2)
+	assert.Equal(t, "shown.go", validEditBlocks[0].FilePath)
+	assert.Equal(t, "new.go", validEditBlocks[1].FilePath)
+	require.Len(t, invalidReports, 1)
+	assert.Equal(t, "unseen.go", invalidReports[0].OriginalEditBlock.FilePath)
+	assert.Contains(t, invalidReports[0].Error, "before rewriting it")
+
+	// older workflow versions didn't check rewrites
+	validEditBlocks, invalidReports = validateEditBlocks(editBlocks, editBlockVisibilityChecks{})
+	assert.Len(t, validEditBlocks, 3)
+	assert.Empty(t, invalidReports)
+}
+
+func TestValidateEditBlocksSymbolEditVisibility(t *testing.T) {
+	chatHistory := []llm.ChatMessage{
+		{Content: "File: shown.go\nSymbol: Foo\nLines: 3-3\n```go\nfunc Foo() {}\n```"},
+	}
+	visibleCodeBlocks := extractAllCodeBlocks(chatHistory)
+	editBlocks := []EditBlock{
+		{EditType: EditTypeReplaceSymbol, FilePath: "shown.go", SymbolName: "Foo", NewLines: []string{"func Foo() { bar() }"}, VisibleCodeBlocks: visibleCodeBlocks},
+		{EditType: EditTypeDeleteSymbol, FilePath: "unseen.go", SymbolName: "Foo", VisibleCodeBlocks: nil},
+		{EditType: EditTypeInsertAfterSymbol, FilePath: "unseen.go", SymbolName: "Bar", NewLines: []string{"func Baz() {}"}},
+	}
+
+	validEditBlocks, invalidReports := validateEditBlocks(editBlocks, editBlockVisibilityChecks{symbolEdits: true})
+	require.Len(t, validEditBlocks, 1)
+	assert.Equal(t, "shown.go", validEditBlocks[0].FilePath)
+	require.Len(t, invalidReports, 2)
+	assert.Contains(t, invalidReports[0].Error, "symbol Foo in unseen.go")
+	assert.Contains(t, invalidReports[1].Error, "symbol Bar in unseen.go")
+
+	// older workflow versions didn't check symbol edits
+	validEditBlocks, invalidReports = validateEditBlocks(editBlocks, editBlockVisibilityChecks{})
+	assert.Len(t, validEditBlocks, 3)
+	assert.Empty(t, invalidReports)
+}
+
 func TestValidateEditBlocksWithInvalidBlocks(t *testing.T) {
 	// Create a chat history that doesn't include the old lines from our edit blocks
 	chatHistory := []llm.ChatMessage{
@@ -118,7 +165,7 @@ func TestValidateEditBlocksWithInvalidBlocks(t *testing.T) {
 	}
 
 	// Call validateEditBlocks
-	validEditBlocks, invalidReports := validateEditBlocks(editBlocks)
+	validEditBlocks, invalidReports := validateEditBlocks(editBlocks, editBlockVisibilityChecks{})
 
 	// Assert that all edit blocks are marked as invalid
 	assert.Equal(t, 0, len(validEditBlocks), "Expected 0 valid edit blocks")
@@ -197,6 +244,28 @@ func TestApplyEditBlockActivity_basicCRUD(t *testing.T) {
 			editBlock:       EditBlock{EditType: "update", FilePath: "existing.txt", OldLines: []string{"Non-matching content"}, NewLines: []string{"New content"}},
 			wantErr:         true,
 		},
+		{
+			name:            "Rewrite when file exists",
+			isExistingFile:  true,
+			existingContent: "Old content\nMore old content\n",
+			editBlock:       EditBlock{EditType: "rewrite", FilePath: "existing.txt", NewLines: []string{"New content"}},
+			wantErr:         false,
+			expectedContent: "New content\n",
+		},
+		{
+			name:           "Rewrite when file does not exist",
+			isExistingFile: false,
+			editBlock:      EditBlock{EditType: "rewrite", FilePath: "nonexistent.txt", NewLines: []string{"New content"}},
+			wantErr:        true,
+		},
+		{
+			name:            "Rewrite when file is too long",
+			isExistingFile:  true,
+			existingContent: strings.Repeat("line\n", maxRewriteFileLines+1),
+			editBlock:       EditBlock{EditType: "rewrite", FilePath: "existing.txt", NewLines: []string{"New content"}},
+			wantErr:         true,
+			expectedContent: strings.Repeat("line\n", maxRewriteFileLines+1),
+		},
 		{
 			name:           "File exists and EditType is delete",
 			isExistingFile: true,
@@ -267,6 +336,98 @@ func TestApplyEditBlockActivity_basicCRUD(t *testing.T) {
 	}
 }
 
+func TestApplySymbolEditBlock(t *testing.T) {
+	original := `package main
+
+// Server serves things
+type Server struct{}
+
+// Start starts the server
+func (s *Server) Start() error {
+	return nil
+}
+
+func helper() {}
+
+func (c *Client) Start() error {
+	return nil
+}
+`
+	tests := []struct {
+		name            string
+		editBlock       EditBlock
+		wantErr         string
+		expectedContent string
+	}{
+		{
+			name: "replace",
+			editBlock: EditBlock{EditType: EditTypeReplaceSymbol, SymbolName: "helper", NewLines: []string{
+				"func helper() int {",
+				"\treturn 1",
+				"}",
+			}},
+			expectedContent: strings.Replace(original, "func helper() {}", "func helper() int {\n\treturn 1\n}", 1),
+		},
+		{
+			name:            "replace includes doc comment",
+			editBlock:       EditBlock{EditType: EditTypeReplaceSymbol, SymbolName: "Server", NewLines: []string{"type Server struct{ port int }"}},
+			expectedContent: strings.Replace(original, "// Server serves things\ntype Server struct{}", "type Server struct{ port int }", 1),
+		},
+		{
+			name:            "delete collapses blank lines",
+			editBlock:       EditBlock{EditType: EditTypeDeleteSymbol, SymbolName: "helper"},
+			expectedContent: strings.Replace(original, "func helper() {}\n\n", "", 1),
+		},
+		{
+			name:            "insert before",
+			editBlock:       EditBlock{EditType: EditTypeInsertBeforeSymbol, SymbolName: "helper", NewLines: []string{"func other() {}"}},
+			expectedContent: strings.Replace(original, "func helper() {}", "func other() {}\n\nfunc helper() {}", 1),
+		},
+		{
+			name:            "insert after",
+			editBlock:       EditBlock{EditType: EditTypeInsertAfterSymbol, SymbolName: "helper", NewLines: []string{"func other() {}"}},
+			expectedContent: strings.Replace(original, "func helper() {}", "func helper() {}\n\nfunc other() {}", 1),
+		},
+		{
+			name:            "qualified with parent",
+			editBlock:       EditBlock{EditType: EditTypeDeleteSymbol, SymbolName: "Client.Start"},
+			expectedContent: strings.Replace(original, "\nfunc (c *Client) Start() error {\n\treturn nil\n}\n", "", 1),
+		},
+		{
+			name:      "ambiguous",
+			editBlock: EditBlock{EditType: EditTypeDeleteSymbol, SymbolName: "Start"},
+			wantErr:   "symbol Start is ambiguous in main.go, found 2 definitions at lines 6-9, lines 13-15",
+		},
+		{
+			name:      "not found",
+			editBlock: EditBlock{EditType: EditTypeReplaceSymbol, SymbolName: "missing", NewLines: []string{"func missing() {}"}},
+			wantErr:   "failed to find symbol missing in main.go",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			filePath := filepath.Join(tmpDir, "main.go")
+			require.NoError(t, os.WriteFile(filePath, []byte(original), 0644))
+			tt.editBlock.FilePath = "main.go"
+
+			report, err := ApplySymbolEditBlock(tt.editBlock, tmpDir)
+			content, readErr := os.ReadFile(filePath)
+			require.NoError(t, readErr)
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, report.Error, tt.wantErr)
+				assert.Equal(t, original, string(content))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedContent, string(content))
+			assert.NotEmpty(t, report.InitialDiff)
+		})
+	}
+}
+
 func TestApplyEditBlockActivity_deleteWithCheckEdits(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -342,6 +503,217 @@ func TestApplyEditBlockActivity_deleteWithCheckEdits(t *testing.T) {
 	assert.True(t, os.IsNotExist(err), "File should still be deleted post-stash")
 }
 
+func TestApplyEditBlockActivity_renameWithCheckEdits(t *testing.T) {
+	setupRepo := func(t *testing.T) string {
+		tmpDir := t.TempDir()
+		files := map[string]string{
+			"src/util.ts": "import { a } from './a';\nexport const util = a;\n",
+			"src/a.ts":    "export const a = 1;\n",
+			"src/main.ts": "import { util } from './util';\n",
+		}
+		for relativePath, content := range files {
+			require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(tmpDir, relativePath)), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(tmpDir, relativePath), []byte(content), 0644))
+		}
+		for _, args := range [][]string{{"init"}, {"add", "."}, {"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-m", "initial commit"}} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = tmpDir
+			require.NoError(t, cmd.Run())
+		}
+		return tmpDir
+	}
+	editBlock := EditBlock{EditType: "rename", FilePath: "src/util.ts", NewFilePath: "src/lib/util.ts"}
+
+	t.Run("tree-sitter fallback", func(t *testing.T) {
+		tmpDir := setupRepo(t)
+		devActivities := &DevActivities{
+			LSPActivities: &lsp.LSPActivities{
+				LSPClientProvider: func(languageName string) lsp.LSPClient {
+					return lsp.MockLSPClient{}
+				},
+			},
+		}
+
+		reports, err := devActivities.ApplyEditBlocks(context.Background(), ApplyEditBlockActivityInput{
+			EnvContainer: env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: tmpDir}},
+			EditBlocks:   []EditBlock{editBlock},
+			EnabledFlags: []string{fflag.CheckEdits},
+		})
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		assert.Equal(t, "", reports[0].Error)
+		assert.True(t, reports[0].DidApply)
+		assert.Equal(t, []string{"src/main.ts"}, reports[0].UpdatedFilePaths)
+		assert.Contains(t, reports[0].FinalDiff, "rename from src/util.ts\nrename to src/lib/util.ts\n")
+		assert.Contains(t, reports[0].FinalDiff, "+import { a } from '../a';")
+		assert.Contains(t, reports[0].FinalDiff, "+import { util } from './lib/util';")
+
+		_, err = os.Stat(filepath.Join(tmpDir, "src/util.ts"))
+		assert.True(t, os.IsNotExist(err))
+		content, err := os.ReadFile(filepath.Join(tmpDir, "src/lib/util.ts"))
+		require.NoError(t, err)
+		assert.Equal(t, "import { a } from '../a';\nexport const util = a;\n", string(content))
+
+		// everything, including the import updates, should be staged
+		cmd := exec.Command("git", "status", "--porcelain")
+		cmd.Dir = tmpDir
+		status, err := cmd.Output()
+		require.NoError(t, err)
+		assert.Equal(t, "A  src/lib/util.ts\nM  src/main.ts\nD  src/util.ts\n", string(status))
+	})
+
+	t.Run("language server edits", func(t *testing.T) {
+		tmpDir := setupRepo(t)
+		var willRenameParams lsp.RenameFilesParams
+		devActivities := &DevActivities{
+			LSPActivities: &lsp.LSPActivities{
+				LSPClientProvider: func(languageName string) lsp.LSPClient {
+					return lsp.MockLSPClient{
+						ServerCapabilities: lsp.ServerCapabilities{
+							Workspace: &lsp.WorkspaceSpecificCapabilties{
+								FileOperations: &lsp.FileOperationOptions{
+									WillRename: &lsp.FileOperationRegistrationOptions{Filters: []lsp.FileOperationFilter{{Pattern: lsp.FileOperationPattern{Glob: "**/*.ts"}}}},
+								},
+							},
+						},
+						WorkspaceWillRenameFilesFunc: func(ctx context.Context, params lsp.RenameFilesParams) (*lsp.WorkspaceEdit, error) {
+							willRenameParams = params
+							return &lsp.WorkspaceEdit{DocumentChanges: []lsp.TextDocumentEdit{{
+								TextDocument: lsp.OptionalVersionedTextDocumentIdentifier{TextDocumentIdentifier: lsp.TextDocumentIdentifier{URI: "file://" + filepath.Join(tmpDir, "src/main.ts")}},
+								Edits: []lsp.TextEdit{{
+									Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 22}, End: lsp.Position{Line: 0, Character: 28}},
+									NewText: "./lib/util",
+								}},
+							}}}, nil
+						},
+					}
+				},
+			},
+		}
+
+		reports, err := devActivities.ApplyEditBlocks(context.Background(), ApplyEditBlockActivityInput{
+			EnvContainer: env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: tmpDir}},
+			EditBlocks:   []EditBlock{editBlock},
+			EnabledFlags: []string{fflag.CheckEdits},
+		})
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		assert.Equal(t, "", reports[0].Error)
+		assert.Equal(t, []string{"src/main.ts"}, reports[0].UpdatedFilePaths)
+		assert.Equal(t, "file://"+filepath.Join(tmpDir, "src/util.ts"), willRenameParams.Files[0].OldURI)
+
+		content, err := os.ReadFile(filepath.Join(tmpDir, "src/main.ts"))
+		require.NoError(t, err)
+		assert.Equal(t, "import { util } from './lib/util';\n", string(content))
+		// only the language server's edits are made
+		content, err = os.ReadFile(filepath.Join(tmpDir, "src/lib/util.ts"))
+		require.NoError(t, err)
+		assert.Equal(t, "import { a } from './a';\nexport const util = a;\n", string(content))
+	})
+
+	willRenameActivities := func(workspaceEdit lsp.WorkspaceEdit) *DevActivities {
+		return &DevActivities{
+			LSPActivities: &lsp.LSPActivities{
+				LSPClientProvider: func(languageName string) lsp.LSPClient {
+					return lsp.MockLSPClient{
+						ServerCapabilities: lsp.ServerCapabilities{
+							Workspace: &lsp.WorkspaceSpecificCapabilties{
+								FileOperations: &lsp.FileOperationOptions{
+									WillRename: &lsp.FileOperationRegistrationOptions{Filters: []lsp.FileOperationFilter{{Pattern: lsp.FileOperationPattern{Glob: "**/*.ts"}}}},
+								},
+							},
+						},
+						WorkspaceWillRenameFilesFunc: func(ctx context.Context, params lsp.RenameFilesParams) (*lsp.WorkspaceEdit, error) {
+							return &workspaceEdit, nil
+						},
+					}
+				},
+			},
+		}
+	}
+
+	t.Run("language server changes are applied and staged without check edits", func(t *testing.T) {
+		tmpDir := setupRepo(t)
+		devActivities := willRenameActivities(lsp.WorkspaceEdit{Changes: map[string][]lsp.TextEdit{
+			"file://" + filepath.Join(tmpDir, "src/main.ts"): {{
+				Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 22}, End: lsp.Position{Line: 0, Character: 28}},
+				NewText: "./lib/util",
+			}},
+		}})
+
+		reports, err := devActivities.ApplyEditBlocks(context.Background(), ApplyEditBlockActivityInput{
+			EnvContainer: env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: tmpDir}},
+			EditBlocks:   []EditBlock{editBlock},
+		})
+		require.NoError(t, err)
+		require.Len(t, reports, 1)
+		assert.Equal(t, "", reports[0].Error)
+		assert.True(t, reports[0].DidApply)
+		assert.Equal(t, []string{"src/main.ts"}, reports[0].UpdatedFilePaths)
+
+		content, err := os.ReadFile(filepath.Join(tmpDir, "src/main.ts"))
+		require.NoError(t, err)
+		assert.Equal(t, "import { util } from './lib/util';\n", string(content))
+
+		cmd := exec.Command("git", "status", "--porcelain")
+		cmd.Dir = tmpDir
+		status, err := cmd.Output()
+		require.NoError(t, err)
+		assert.Equal(t, "R  src/util.ts -> src/lib/util.ts\nM  src/main.ts\n", string(status))
+	})
+
+	t.Run("failed language server edits are reverted", func(t *testing.T) {
+		tmpDir := setupRepo(t)
+		devActivities := willRenameActivities(lsp.WorkspaceEdit{DocumentChanges: []lsp.TextDocumentEdit{
+			{
+				TextDocument: lsp.OptionalVersionedTextDocumentIdentifier{TextDocumentIdentifier: lsp.TextDocumentIdentifier{URI: "file://" + filepath.Join(tmpDir, "src/main.ts")}},
+				Edits: []lsp.TextEdit{{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 22}, End: lsp.Position{Line: 0, Character: 28}},
+					NewText: "./lib/util",
+				}},
+			},
+			{
+				TextDocument: lsp.OptionalVersionedTextDocumentIdentifier{TextDocumentIdentifier: lsp.TextDocumentIdentifier{URI: "file://" + filepath.Join(tmpDir, "src/a.ts")}},
+				Edits: []lsp.TextEdit{{
+					Range:   lsp.Range{Start: lsp.Position{Line: 10, Character: 0}, End: lsp.Position{Line: 10, Character: 1}},
+					NewText: "oops",
+				}},
+			},
+		}})
+
+		reports, err := devActivities.ApplyEditBlocks(context.Background(), ApplyEditBlockActivityInput{
+			EnvContainer: env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: tmpDir}},
+			EditBlocks:   []EditBlock{editBlock},
+			EnabledFlags: []string{fflag.CheckEdits},
+		})
+		require.NoError(t, err)
+		assert.False(t, reports[0].DidApply)
+		assert.Contains(t, reports[0].Error, "failed to apply language server edits for rename")
+
+		content, err := os.ReadFile(filepath.Join(tmpDir, "src/main.ts"))
+		require.NoError(t, err)
+		assert.Equal(t, "import { util } from './util';\n", string(content))
+		cmd := exec.Command("git", "status", "--porcelain")
+		cmd.Dir = tmpDir
+		status, err := cmd.Output()
+		require.NoError(t, err)
+		assert.Equal(t, "", string(status))
+	})
+
+	t.Run("new path already exists", func(t *testing.T) {
+		tmpDir := setupRepo(t)
+		devActivities := &DevActivities{LSPActivities: &lsp.LSPActivities{}}
+
+		reports, err := devActivities.ApplyEditBlocks(context.Background(), ApplyEditBlockActivityInput{
+			EnvContainer: env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: tmpDir}},
+			EditBlocks:   []EditBlock{{EditType: "rename", FilePath: "src/util.ts", NewFilePath: "src/a.ts"}},
+		})
+		require.NoError(t, err)
+		assert.False(t, reports[0].DidApply)
+		assert.Contains(t, reports[0].Error, "file already exists")
+	})
+}
+
 func TestGetUpdatedContents(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -1768,3 +2140,53 @@ func DoSomething() {
 	require.NoError(t, err)
 	assert.Empty(t, actions)
 }
+
+func TestApplyEditBlocks_TypescriptCheckFails(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalContent := "export const a: number = 1;\n"
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "tsconfig.json"), []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "a.ts"), []byte(originalContent), 0644))
+	for _, args := range [][]string{{"init"}, {"add", "."}, {"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-m", "initial"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+
+	introduced := check.Diagnostic{FilePath: "a.ts", Line: 1, Column: 14, Code: "TS2322", Message: "Type 'string' is not assignable to type 'number'."}
+	devActivities := &DevActivities{
+		LSPActivities: &lsp.LSPActivities{
+			LSPClientProvider: func(languageName string) lsp.LSPClient {
+				return &lsp.Jsonrpc2LSPClient{LanguageName: languageName}
+			},
+			InitializedClients: map[string]lsp.LSPClient{},
+		},
+		TypescriptWatchers: &check.TypescriptWatchers{
+			WatcherProvider: func(projectDir string) (check.TypescriptWatcher, error) {
+				return &check.MockTypescriptWatcher{Results: [][]check.Diagnostic{{}, {introduced}}}, nil
+			},
+		},
+	}
+
+	reports, err := devActivities.ApplyEditBlocks(context.Background(), ApplyEditBlockActivityInput{
+		EnvContainer: env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: tmpDir}},
+		EditBlocks: []EditBlock{{
+			EditType: "update",
+			FilePath: "a.ts",
+			OldLines: []string{"export const a: number = 1;"},
+			NewLines: []string{"export const a: number = 'a';"},
+		}},
+		EnabledFlags:  []string{fflag.CheckEdits},
+		BuiltinChecks: common.BuiltinChecksConfig{Languages: []string{"typescript"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.False(t, reports[0].DidApply)
+	assert.False(t, reports[0].CheckResult.Success)
+	assert.Equal(t, []check.Diagnostic{introduced}, reports[0].CheckResult.Diagnostics)
+	assert.Contains(t, reports[0].Error, "a.ts(1,14): error TS2322")
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "a.ts"))
+	require.NoError(t, err)
+	assert.Equal(t, originalContent, string(content))
+}
@@ -28,6 +28,7 @@ type BasicDevOptions struct {
 	DetermineRequirements bool        `json:"determineRequirements"`
 	EnvType               env.EnvType `json:"envType,omitempty" default:"local"`
 	StartBranch           *string     `json:"startBranch,omitempty"`
+	SubProject            string      `json:"subProject,omitempty"`
 }
 
 type MergeWithReviewParams struct {
@@ -37,6 +38,12 @@ type MergeWithReviewParams struct {
 	SubflowType    string                                                   // for tracking purposes
 	SubflowName    string                                                   // for tracking purposes
 	CommitRequired bool
+	OfferSquash    bool // let the user choose between squashing and keeping the source branch's commits
+
+	// RollbackRequested, when set, is checked after the review is rejected:
+	// if it returns true, ErrRollbackToCheckpoint is returned instead of
+	// addressing the review
+	RollbackRequested func() bool
 }
 
 // formatRequirementsWithReview combines original requirements with review history and work done
@@ -87,7 +94,7 @@ func BasicDevWorkflow(ctx workflow.Context, input BasicDevWorkflowInput) (result
 
 	ctx = utils.DefaultRetryCtx(ctx)
 
-	dCtx, err := SetupDevContext(ctx, input.WorkspaceId, input.RepoDir, string(input.EnvType), input.BasicDevOptions.StartBranch, input.Requirements)
+	dCtx, err := SetupDevContext(ctx, input.WorkspaceId, input.RepoDir, string(input.EnvType), input.BasicDevOptions.StartBranch, input.BasicDevOptions.SubProject, input.Requirements)
 	if err != nil {
 		_ = signalWorkflowClosure(ctx, "failed")
 		return "", err
@@ -222,12 +229,19 @@ func codingSubflow(dCtx DevContext, requirements string, startBranch *string) (r
 
 		// Step 2: edit code
 		err = EditCode(dCtx, modelConfig, contextSizeExtension, chatHistory, promptInfo)
+		for errors.Is(err, PendingActionError) && isUpdateFromBaseBranchPending(dCtx) {
+			promptInfo, err = handleUpdateFromBaseBranchAction(dCtx, requirements)
+			if err != nil {
+				return "", err
+			}
+			err = EditCode(dCtx, modelConfig, contextSizeExtension, chatHistory, promptInfo)
+		}
 		if err != nil {
 			return "", fmt.Errorf("failed to write edit blocks: %v", err)
 		}
 
 		// Step 3: run tests
-		testResult, err = RunTests(dCtx, dCtx.RepoConfig.TestCommands)
+		testResult, err = runTestsForChanges(dCtx, selectTestCommands)
 		if err != nil {
 			return "", fmt.Errorf("failed to run tests: %v", err)
 		}
@@ -238,9 +252,25 @@ func codingSubflow(dCtx DevContext, requirements string, startBranch *string) (r
 			continue
 		}
 
+		var hookResult TestResult
+		hookResult, err = runPreCommitHookCheck(dCtx)
+		if err != nil {
+			return "", err
+		}
+		if !hookResult.TestsPassed {
+			testResult = hookResult
+			promptInfo = FeedbackInfo{Feedback: hookResult.Output}
+			attemptCount++
+			continue
+		}
+
 		// Run integration tests if regular tests passed and integration tests are configured
-		if len(dCtx.RepoConfig.IntegrationTestCommands) > 0 {
-			integrationTestResult, err := RunTests(dCtx, dCtx.RepoConfig.IntegrationTestCommands)
+		integrationTestCommands, err := commandsForChanges(dCtx, selectIntegrationTestCommands)
+		if err != nil {
+			return "", err
+		}
+		if len(integrationTestCommands) > 0 {
+			integrationTestResult, err := RunTests(dCtx, integrationTestCommands)
 			if err != nil {
 				return "", fmt.Errorf("failed to run integration tests: %v", err)
 			}
@@ -342,7 +372,7 @@ Feedback: %s`, fulfillment.Analysis, fulfillment.FeedbackMessage),
 	return testResult.Output, nil
 }
 
-func getMergeApproval(dCtx DevContext, defaultTarget string, getGitDiff func(dCtx DevContext, baseBranch string) (string, error)) (MergeApprovalResponse, string, error) {
+func getMergeApproval(dCtx DevContext, defaultTarget string, offerSquash bool, getGitDiff func(dCtx DevContext, baseBranch string) (string, error)) (MergeApprovalResponse, string, error) {
 	// Generate initial diff with default target branch
 	// This is also the diff used in any followups (we don't use the diff
 	// against an updated target branch selection from the user, as that could
@@ -358,6 +388,7 @@ func getMergeApproval(dCtx DevContext, defaultTarget string, getGitDiff func(dCt
 		SourceBranch:        dCtx.Worktree.Name,
 		DefaultTargetBranch: defaultTarget,
 		Diff:                gitDiff,
+		OfferSquash:         offerSquash,
 	}
 
 	approvalResponse, err := GetUserMergeApproval(dCtx, "Please review these changes", map[string]any{
@@ -399,6 +430,10 @@ func reviewAndResolve(dCtx DevContext, params MergeWithReviewParams) error {
 			}
 
 			if !mergeInfo.Approved {
+				if params.RollbackRequested != nil && params.RollbackRequested() {
+					return ErrRollbackToCheckpoint
+				}
+
 				// retain new choice of target branch next iteration, in case it was changed
 				params.StartBranch = &mergeInfo.TargetBranch
 
@@ -442,7 +477,39 @@ func mergeWorktreeIfApproved(dCtx DevContext, params MergeWithReviewParams) (str
 		}
 	}
 
-	mergeInfo, gitDiff, err := getMergeApproval(dCtx, defaultTarget, params.GetGitDiff)
+	commitMessage := strings.TrimSpace(params.Requirements)
+	if strings.Contains(commitMessage, "Overview:\n") {
+		commitMessage = strings.Split(commitMessage, "Overview:\n")[1]
+		commitMessage = strings.TrimSpace(commitMessage)
+	}
+	commitMessage = strings.Split(commitMessage, "\n")[0]
+	if len(commitMessage) > 100 {
+		commitMessage = commitMessage[:100] + "...\n\n..." + commitMessage[100:]
+	}
+
+	// review against the latest base branch, sending test failures back to
+	// coding as if the review was rejected
+	if dCtx.RepoConfig.UpdateBaseBranchBeforeMerge && dCtx.Worktree != nil {
+		v := workflow.GetVersion(dCtx, "update-base-branch-before-merge", workflow.DefaultVersion, 1)
+		if v >= 1 {
+			testOutput, err := UpdateFromBaseBranch(dCtx, UpdateFromBaseBranchParams{
+				Requirements:  params.Requirements,
+				BaseBranch:    defaultTarget,
+				CommitMessage: commitMessage,
+			})
+			if err != nil {
+				return "", MergeApprovalResponse{}, err
+			}
+			if testOutput != "" {
+				return "", MergeApprovalResponse{
+					TargetBranch: defaultTarget,
+					Message:      fmt.Sprintf("The latest changes from %s were brought into this branch before review, after which tests are failing:\n\n%s", defaultTarget, testOutput),
+				}, nil
+			}
+		}
+	}
+
+	mergeInfo, gitDiff, err := getMergeApproval(dCtx, defaultTarget, params.OfferSquash, params.GetGitDiff)
 	if err != nil {
 		return "", MergeApprovalResponse{}, fmt.Errorf("failed to get merge approval: %v", err)
 	}
@@ -459,20 +526,12 @@ func mergeWorktreeIfApproved(dCtx DevContext, params MergeWithReviewParams) (str
 	}
 
 	// Commit any pending changes first
-	commitMessage := strings.TrimSpace(params.Requirements)
-	if strings.Contains(commitMessage, "Overview:\n") {
-		commitMessage = strings.Split(commitMessage, "Overview:\n")[1]
-		commitMessage = strings.TrimSpace(commitMessage)
-	}
-	commitMessage = strings.Split(commitMessage, "\n")[0]
-	if len(commitMessage) > 100 {
-		commitMessage = commitMessage[:100] + "...\n\n..." + commitMessage[100:]
-	}
 
 	gitCommitVersion := workflow.GetVersion(dCtx, "git-commit-in-flow-action", workflow.DefaultVersion, 1)
 	if gitCommitVersion < 1 {
 		err = workflow.ExecuteActivity(dCtx, git.GitCommitActivity, dCtx.EnvContainer, git.GitCommitParams{
 			CommitMessage: commitMessage,
+			Config:        dCtx.RepoConfig.Commit,
 		}).Get(dCtx, nil)
 		if err != nil {
 			return "", MergeApprovalResponse{}, fmt.Errorf("failed to commit changes: %v", err)
@@ -485,12 +544,25 @@ func mergeWorktreeIfApproved(dCtx DevContext, params MergeWithReviewParams) (str
 		if gitCommitVersion >= 1 && params.CommitRequired {
 			err = workflow.ExecuteActivity(dCtx, git.GitCommitActivity, dCtx.EnvContainer, git.GitCommitParams{
 				CommitMessage: commitMessage,
+				Config:        dCtx.RepoConfig.Commit,
 			}).Get(dCtx, nil)
-			if err != nil {
+			// updating from the base branch may have committed everything already
+			if err != nil && !strings.Contains(err.Error(), "nothing to commit") {
 				return mergeResult, fmt.Errorf("failed to commit changes: %v", err)
 			}
 		}
 
+		if mergeInfo.MergeStrategy == MergeStrategySquash {
+			err = workflow.ExecuteActivity(dCtx, git.SquashBranchActivity, dCtx.EnvContainer, git.SquashBranchParams{
+				BaseBranch:    mergeInfo.TargetBranch,
+				CommitMessage: commitMessage,
+				Config:        dCtx.RepoConfig.Commit,
+			}).Get(dCtx, nil)
+			if err != nil {
+				return mergeResult, fmt.Errorf("failed to squash commits: %v", err)
+			}
+		}
+
 		future := workflow.ExecuteActivity(dCtx, git.GitMergeActivity, dCtx.EnvContainer, git.GitMergeParams{
 			SourceBranch: dCtx.Worktree.Name,
 			TargetBranch: mergeInfo.TargetBranch,
@@ -506,52 +578,73 @@ func mergeWorktreeIfApproved(dCtx DevContext, params MergeWithReviewParams) (str
 	}
 
 	if mergeResult.HasConflicts {
-		// Present continue request with enhanced conflict message
-		actionCtx := dCtx.NewActionContext("user_request.continue")
-		var conflictMessage string
-		if mergeResult.ConflictOnTargetBranch {
-			conflictMessage = fmt.Sprintf("Merge conflicts detected at %s. Please resolve conflicts and commit the merge, then continue.", mergeResult.ConflictDirPath)
-		} else {
-			conflictMessage = fmt.Sprintf("Merge conflicts detected at %s. Conflicts are from merging %s into %s. Please resolve conflicts, commit the merge, then continue.", mergeResult.ConflictDirPath, mergeInfo.TargetBranch, dCtx.Worktree.Name)
-		}
-
-		err := GetUserContinue(actionCtx, conflictMessage, map[string]any{
-			"continueTag": "done",
-		})
-		if err != nil {
-			return "", MergeApprovalResponse{}, fmt.Errorf("failed to get continue approval: %v", err)
-		}
-
-		// Handle reverse conflict scenario - need final merge from source to target
+		// conflicts from the reverse merge are in this flow's own worktree, so
+		// try resolving them before falling back to the user
+		resolvedConflicts := false
 		if !mergeResult.ConflictOnTargetBranch {
-			for {
-				// Verify conflicts are resolved by checking git status
-				// FIXME let's check if MERGE_HEAD exists instead as better way to confirm conflicts are resolved
-				var statusOutput env.EnvRunCommandActivityOutput
-				statusFuture := workflow.ExecuteActivity(dCtx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
-					EnvContainer: *dCtx.EnvContainer,
-					Command:      "git",
-					Args:         []string{"status", "--porcelain"},
+			v := workflow.GetVersion(dCtx, "resolve-merge-conflicts", workflow.DefaultVersion, 1)
+			if v >= 1 {
+				resolvedConflicts, err = ResolveMergeConflicts(dCtx, ResolveMergeConflictsParams{
+					Requirements: params.Requirements,
+					SourceBranch: dCtx.Worktree.Name,
+					TargetBranch: mergeInfo.TargetBranch,
 				})
-				statusErr := statusFuture.Get(dCtx, &statusOutput)
-				if statusErr != nil {
-					return "", MergeApprovalResponse{}, fmt.Errorf("failed to check git status: %v", statusErr)
+				if err != nil {
+					return "", MergeApprovalResponse{}, fmt.Errorf("failed to resolve merge conflicts: %v", err)
 				}
+			}
+		}
+
+		if !resolvedConflicts {
+			// Present continue request with enhanced conflict message
+			actionCtx := dCtx.NewActionContext("user_request.continue")
+			var conflictMessage string
+			if mergeResult.ConflictOnTargetBranch {
+				conflictMessage = fmt.Sprintf("Merge conflicts detected at %s. Please resolve conflicts and commit the merge, then continue.", mergeResult.ConflictDirPath)
+			} else {
+				conflictMessage = fmt.Sprintf("Merge conflicts detected at %s. Conflicts are from merging %s into %s. Please resolve conflicts, commit the merge, then continue.", mergeResult.ConflictDirPath, mergeInfo.TargetBranch, dCtx.Worktree.Name)
+			}
+
+			err := GetUserContinue(actionCtx, conflictMessage, map[string]any{
+				"continueTag": "done",
+			})
+			if err != nil {
+				return "", MergeApprovalResponse{}, fmt.Errorf("failed to get continue approval: %v", err)
+			}
 
-				// Check if there are still unmerged files
-				if strings.Contains(statusOutput.Stdout, "UU ") || strings.Contains(statusOutput.Stdout, "AA ") || strings.Contains(statusOutput.Stdout, "DD ") {
-					message := "Merge conflicts are not fully resolved, please resolve all conflicts and commit. Git status:\n\n" + statusOutput.Stdout
-					err := GetUserContinue(actionCtx, message, map[string]any{
-						"continueTag": "done",
+			if !mergeResult.ConflictOnTargetBranch {
+				for {
+					// Verify conflicts are resolved by checking git status
+					// FIXME let's check if MERGE_HEAD exists instead as better way to confirm conflicts are resolved
+					var statusOutput env.EnvRunCommandActivityOutput
+					statusFuture := workflow.ExecuteActivity(dCtx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
+						EnvContainer: *dCtx.EnvContainer,
+						Command:      "git",
+						Args:         []string{"status", "--porcelain"},
 					})
-					if err != nil {
-						return "", MergeApprovalResponse{}, fmt.Errorf("failed to get continue approval: %v", err)
+					statusErr := statusFuture.Get(dCtx, &statusOutput)
+					if statusErr != nil {
+						return "", MergeApprovalResponse{}, fmt.Errorf("failed to check git status: %v", statusErr)
 					}
+
+					// Check if there are still unmerged files
+					if strings.Contains(statusOutput.Stdout, "UU ") || strings.Contains(statusOutput.Stdout, "AA ") || strings.Contains(statusOutput.Stdout, "DD ") {
+						message := "Merge conflicts are not fully resolved, please resolve all conflicts and commit. Git status:\n\n" + statusOutput.Stdout
+						err := GetUserContinue(actionCtx, message, map[string]any{
+							"continueTag": "done",
+						})
+						if err != nil {
+							return "", MergeApprovalResponse{}, fmt.Errorf("failed to get continue approval: %v", err)
+						}
+					}
+					break
 				}
-				break
 			}
+		}
 
-			mergeInfo, gitDiff, err = getMergeApproval(dCtx, mergeInfo.TargetBranch, params.GetGitDiff)
+		// Handle reverse conflict scenario - need final merge from source to target
+		if !mergeResult.ConflictOnTargetBranch {
+			mergeInfo, gitDiff, err = getMergeApproval(dCtx, mergeInfo.TargetBranch, params.OfferSquash, params.GetGitDiff)
 			if err != nil {
 				return "", MergeApprovalResponse{}, fmt.Errorf("failed to get final merge approval: %v", err)
 			}
@@ -592,7 +685,7 @@ func mergeWorktreeIfApproved(dCtx DevContext, params MergeWithReviewParams) (str
 		v := workflow.GetVersion(dCtx, "hide-cleanup-worktree", workflow.DefaultVersion, 1)
 		trackOptions := flow_action.TrackOptions{FailuresOnly: v >= 1}
 		_, err := flow_action.TrackWithOptions(actionCtx.FlowActionContext(), trackOptions, func(flowAction domain.FlowAction) (interface{}, error) {
-			future := workflow.ExecuteActivity(dCtx, git.CleanupWorktreeActivity, dCtx.EnvContainer, dCtx.EnvContainer.Env.GetWorkingDirectory(), dCtx.Worktree.Name, "Sidekick task completed and merged")
+			future := workflow.ExecuteActivity(dCtx, git.CleanupWorktreeActivity, dCtx.EnvContainer, dCtx.EnvContainer.Env.GetWorkingDirectory(), dCtx.Worktree.Name, "Sidekick task completed and merged", dCtx.Worktree.FlowId)
 			return nil, future.Get(dCtx, nil)
 		})
 		if err != nil {
@@ -0,0 +1,177 @@
+package dev
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"sidekick/common"
+	"sidekick/domain"
+	"sidekick/env"
+	"sidekick/flow_action"
+	"sidekick/srv"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	defaultBranchNameTemplate = branchNamePrefix + "{{.Slug}}"
+	defaultTicketPattern      = `[A-Z][A-Z0-9]+-[0-9]+`
+)
+
+// branchNameVars are the variables available to branch name templates
+type branchNameVars struct {
+	Slug   string
+	TaskId string
+	Ticket string
+	Date   string
+	User   string
+}
+
+// branchNamePolicy turns slugs into full branch names following the repo's
+// branch naming config
+type branchNamePolicy struct {
+	templateText string
+	template     *template.Template
+	validation   *regexp.Regexp
+	vars         branchNameVars
+}
+
+func (p branchNamePolicy) isDefault() bool {
+	return p.templateText == defaultBranchNameTemplate && p.validation == nil
+}
+
+// branchName renders the branch name for a slug, returning false if it's not
+// a valid git branch name or doesn't match the validation regex
+func (p branchNamePolicy) branchName(slug string) (string, bool) {
+	vars := p.vars
+	vars.Slug = slug
+	var rendered strings.Builder
+	if err := p.template.Execute(&rendered, vars); err != nil {
+		return "", false
+	}
+	name := strings.TrimSpace(rendered.String())
+	if len(name) > maxBranchLength || !isValidBranchName(name) {
+		return "", false
+	}
+	if p.validation != nil && !p.validation.MatchString(name) {
+		return "", false
+	}
+	return name, true
+}
+
+// format shows the shape of branch names with a placeholder for the slug, to
+// guide slug generation
+func (p branchNamePolicy) format() string {
+	vars := p.vars
+	vars.Slug = "<suffix>"
+	var rendered strings.Builder
+	if err := p.template.Execute(&rendered, vars); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(rendered.String())
+}
+
+func newBranchNamePolicy(config common.BranchNamingConfig, vars branchNameVars) (branchNamePolicy, error) {
+	tmplText := config.Template
+	if tmplText == "" {
+		tmplText = defaultBranchNameTemplate
+	}
+	tmpl, err := template.New("branch_name").Option("missingkey=error").Parse(tmplText)
+	if err != nil {
+		return branchNamePolicy{}, fmt.Errorf("invalid branch naming template: %w", err)
+	}
+	policy := branchNamePolicy{templateText: tmplText, template: tmpl, vars: vars}
+	if config.ValidationRegex != "" {
+		policy.validation, err = regexp.Compile(config.ValidationRegex)
+		if err != nil {
+			return branchNamePolicy{}, fmt.Errorf("invalid branch naming validation regex: %w", err)
+		}
+	}
+	return policy, nil
+}
+
+// resolveBranchNamePolicy gathers the variables used by the configured branch
+// naming template, looking up only those that are actually used
+func resolveBranchNamePolicy(eCtx flow_action.ExecContext, req BranchNameRequest) (branchNamePolicy, error) {
+	config := req.Naming
+	vars := branchNameVars{
+		Date: workflow.Now(eCtx).Format(time.DateOnly),
+		User: config.User,
+	}
+
+	ticketPattern := config.TicketPattern
+	if ticketPattern == "" {
+		ticketPattern = defaultTicketPattern
+	}
+	ticketRegex, err := regexp.Compile(ticketPattern)
+	if err != nil {
+		return branchNamePolicy{}, fmt.Errorf("invalid branch naming ticket pattern: %w", err)
+	}
+	vars.Ticket = ticketRegex.FindString(req.Requirements)
+
+	if strings.Contains(config.Template, ".TaskId") {
+		flowId := workflow.GetInfo(eCtx).WorkflowExecution.ID
+		var flow domain.Flow
+		err := workflow.ExecuteActivity(eCtx, srv.Activities.GetFlow, eCtx.WorkspaceId, flowId).Get(eCtx, &flow)
+		if err != nil {
+			return branchNamePolicy{}, fmt.Errorf("failed to get flow for branch name: %v", err)
+		}
+		vars.TaskId = flow.ParentId
+	}
+
+	if vars.User == "" && strings.Contains(config.Template, ".User") {
+		var output env.EnvRunCommandActivityOutput
+		err := workflow.ExecuteActivity(eCtx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
+			EnvContainer: *eCtx.EnvContainer,
+			Command:      "git",
+			Args:         []string{"config", "user.email"},
+		}).Get(eCtx, &output)
+		if err != nil {
+			return branchNamePolicy{}, fmt.Errorf("failed to get git user for branch name: %v", err)
+		}
+		// the local part of an email is usually the closest thing to a handle
+		vars.User, _, _ = strings.Cut(strings.TrimSpace(output.Stdout), "@")
+		if vars.User == "" {
+			return branchNamePolicy{}, fmt.Errorf("the branch naming template uses .User, but git user.email isn't set: set branch_naming.user in side.toml instead")
+		}
+	}
+	vars.User = sanitizeBranchNameComponent(vars.User)
+
+	return newBranchNamePolicy(config, vars)
+}
+
+var invalidBranchNameCharsRegex = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// sanitizeBranchNameComponent makes a value safe to use within a single
+// segment of a branch name
+func sanitizeBranchNameComponent(value string) string {
+	value = invalidBranchNameCharsRegex.ReplaceAllString(strings.ToLower(value), "-")
+	return strings.Trim(value, "-.")
+}
+
+// isValidBranchName approximates `git check-ref-format --branch`
+func isValidBranchName(name string) bool {
+	if name == "" || name == "@" || strings.HasPrefix(name, "-") || strings.HasPrefix(name, "/") {
+		return false
+	}
+	if strings.Contains(name, "..") || strings.Contains(name, "//") || strings.Contains(name, "@{") {
+		return false
+	}
+	if strings.HasSuffix(name, "/") || strings.HasSuffix(name, ".") {
+		return false
+	}
+	for _, r := range name {
+		if r <= ' ' || r == 0x7f || strings.ContainsRune(`~^:?*[\`, r) {
+			return false
+		}
+	}
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return false
+		}
+	}
+	return true
+}
@@ -22,6 +22,10 @@ import (
 type BranchNameRequest struct {
 	Requirements string `json:"requirements" jsonschema:"description=The requirements text to use as context for generating branch names"`
 	Hints        string `json:"editHints" jsonschema:"description=Additional hints that might provide context for branch name generation"`
+	// the repo's branch naming policy, if any
+	Naming common.BranchNamingConfig `json:"naming"`
+	// shows where generated suffixes go in a non-default branch name format
+	NameFormat string `json:"branch_name_format,omitempty"`
 }
 
 type SubmitBranchNamesParams struct {
@@ -55,20 +59,47 @@ func GenerateBranchName(eCtx flow_action.ExecContext, req BranchNameRequest) (st
 		branches[branch] = true
 	}
 
+	policy, err := newBranchNamePolicy(common.BranchNamingConfig{}, branchNameVars{})
+	if err != nil {
+		return "", err
+	}
+	v := workflow.GetVersion(eCtx, "branch-naming-policy", workflow.DefaultVersion, 1)
+	if v >= 1 {
+		remoteBranches, err := listRemoteBranches(eCtx)
+		if err != nil {
+			return "", err
+		}
+		for _, branch := range remoteBranches {
+			branches[branch] = true
+		}
+
+		policy, err = resolveBranchNamePolicy(eCtx, req)
+		if err != nil {
+			return "", err
+		}
+		if !policy.isDefault() {
+			req.NameFormat = policy.format()
+		}
+	}
+	isValidSuffix := func(suffix string) bool {
+		_, ok := policy.branchName(suffix)
+		return validateBranchNameSuffix(suffix) && ok
+	}
+
 	// Try LLM generation with retries
-	candidates, err := generateBranchNameCandidates(eCtx, req)
+	candidates, err := generateBranchNameCandidates(eCtx, req, isValidSuffix)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to generate branch names via LLM")
 	}
 
 	// Try each candidate
 	for _, suffix := range candidates {
-		if !validateBranchNameSuffix(suffix) {
+		if !isValidSuffix(suffix) {
 			continue
 		}
 
-		branchName := branchNamePrefix + suffix
-		if _, exists := branches[branchName]; !exists {
+		branchName, _ := policy.branchName(suffix)
+		if !branchNameTaken(branchName, branches) {
 			return branchName, nil
 		}
 	}
@@ -76,8 +107,11 @@ func GenerateBranchName(eCtx flow_action.ExecContext, req BranchNameRequest) (st
 	// Try with numeric suffix if needed
 	for i := 2; i <= maxNumericSuffix; i++ {
 		for _, suffix := range candidates {
-			branchName := fmt.Sprintf("%s%s-%d", branchNamePrefix, suffix, i)
-			if _, exists := branches[branchName]; !exists {
+			branchName, ok := policy.branchName(fmt.Sprintf("%s-%d", suffix, i))
+			if !ok {
+				continue
+			}
+			if !branchNameTaken(branchName, branches) {
 				return branchName, nil
 			}
 		}
@@ -87,18 +121,64 @@ func GenerateBranchName(eCtx flow_action.ExecContext, req BranchNameRequest) (st
 	// NOTE: this fallback should be only be if we got no candidates, i.e. llm
 	// call failed, or if all candidates are taken, even with numeric suffixes
 	suffix := generateFallbackSuffix(req.Requirements)
-	if !validateBranchNameSuffix(suffix) {
+	if !isValidSuffix(suffix) {
+		if !policy.isDefault() {
+			return "", fmt.Errorf("failed to generate a branch name following the branch naming policy in side.toml, eg %s", policy.format())
+		}
 		return "", fmt.Errorf("failed to generate valid branch name after all attempts")
 	}
-	branchName := branchNamePrefix + suffix
-	if _, exists := branches[branchName]; !exists {
+	branchName, _ := policy.branchName(suffix)
+	if !branchNameTaken(branchName, branches) {
 		return branchName, nil
 	}
 
 	return "", fmt.Errorf("failed to generate unique branch name")
 }
 
-func generateBranchNameCandidates(eCtx flow_action.ExecContext, req BranchNameRequest) ([]string, error) {
+// branchNameTaken reports whether a branch can't be created because it or a
+// ref it'd need as a directory or file already exists. Git stores refs as
+// paths, so eg "jane.doe" and "jane.doe/fix-login" can't both be branches.
+func branchNameTaken(branchName string, branches map[string]bool) bool {
+	if branches[branchName] {
+		return true
+	}
+	for i, c := range branchName {
+		if c == '/' && branches[branchName[:i]] {
+			return true
+		}
+	}
+	for branch := range branches {
+		if strings.HasPrefix(branch, branchName+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// listRemoteBranches lists branch names on all remotes, without the remote
+// name prefix, so generated branch names can avoid colliding with them once
+// pushed
+func listRemoteBranches(eCtx flow_action.ExecContext) ([]string, error) {
+	var output env.EnvRunCommandActivityOutput
+	err := workflow.ExecuteActivity(eCtx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
+		EnvContainer: *eCtx.EnvContainer,
+		Command:      "git",
+		Args:         []string{"for-each-ref", "--format=%(refname:lstrip=3)", "refs/remotes"},
+	}).Get(eCtx, &output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get remote branches: %v", err)
+	}
+	var branches []string
+	for _, branch := range strings.Split(output.Stdout, "\n") {
+		branch = strings.TrimSpace(branch)
+		if branch != "" && branch != "HEAD" {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
+}
+
+func generateBranchNameCandidates(eCtx flow_action.ExecContext, req BranchNameRequest, isValidSuffix func(string) bool) ([]string, error) {
 	reqMap := make(map[string]any)
 	utils.Transcode(req, &reqMap)
 	chatHistory := []llm.ChatMessage{
@@ -125,7 +205,7 @@ func generateBranchNameCandidates(eCtx flow_action.ExecContext, req BranchNameRe
 		err = json.Unmarshal([]byte(llm.RepairJson(jsonStr)), &branchResp)
 		if err == nil {
 			// if any are valid, we're done
-			if slices.ContainsFunc(branchResp.Candidates, validateBranchNameSuffix) {
+			if slices.ContainsFunc(branchResp.Candidates, isValidSuffix) {
 				break
 			}
 			err = errors.New("Those candidates are invalid, not following the presribed format. Let's try that again, change it up.")
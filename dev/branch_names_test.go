@@ -15,6 +15,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	tlog "go.temporal.io/sdk/log"
 	"go.temporal.io/sdk/testsuite"
@@ -28,6 +29,8 @@ type BranchNameTestSuite struct {
 	env          *testsuite.TestWorkflowEnvironment
 	dir          string
 	envContainer env.EnvContainer
+	// output of listing remote branches
+	remoteBranches string
 
 	wrapperWorkflow func(ctx workflow.Context, req BranchNameRequest) (string, error)
 }
@@ -65,11 +68,18 @@ func (s *BranchNameTestSuite) SetupTest() {
 	var fa *flow_action.FlowActivities // use a nil struct pointer to call activities that are part of a structure
 	s.env.OnActivity(fa.PersistFlowAction, mock.Anything, mock.Anything).Return(nil)
 
-	// Mock git command to simulate existing branches
-	s.env.OnActivity(env.EnvRunCommandActivity, mock.Anything, mock.Anything).Return(env.EnvRunCommandActivityOutput{
-		ExitStatus: 0,
-		Stdout:     "  main\n  side/add-user-auth\n  side/other-branch",
-	}, nil).Maybe()
+	// Mock git commands to simulate existing local and remote branches
+	s.remoteBranches = ""
+	s.env.OnActivity(env.EnvRunCommandActivity, mock.Anything, mock.Anything).Return(func(ctx context.Context, input env.EnvRunCommandActivityInput) (env.EnvRunCommandActivityOutput, error) {
+		switch input.Args[0] {
+		case "for-each-ref":
+			return env.EnvRunCommandActivityOutput{Stdout: s.remoteBranches}, nil
+		case "config":
+			return env.EnvRunCommandActivityOutput{Stdout: "jane.doe@example.com\n"}, nil
+		default:
+			return env.EnvRunCommandActivityOutput{Stdout: "  main\n  side/add-user-auth\n  side/other-branch"}, nil
+		}
+	}).Maybe()
 
 	// Create temporary directory using t.TempDir()
 	s.dir = s.T().TempDir()
@@ -183,6 +193,114 @@ func (s *BranchNameTestSuite) TestBranchNameUniqueness() {
 	s.Equal("side/implement-login", result)
 }
 
+func (s *BranchNameTestSuite) TestRemoteBranchCollision() {
+	var la *persisted_ai.LlmActivities
+	validResponse := testBranchNameToolResponse(s.T(), `{"candidates": ["implement-login", "setup-authentication"]}`)
+	s.env.OnActivity(la.ChatStream, mock.Anything, mock.Anything).Return(validResponse, nil).Once()
+	s.remoteBranches = "HEAD\nmain\nside/implement-login\n"
+
+	s.env.ExecuteWorkflow(s.wrapperWorkflow, BranchNameRequest{Requirements: "Add login"})
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var result string
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal("side/setup-authentication", result)
+}
+
+func (s *BranchNameTestSuite) TestBranchNamingPolicy() {
+	var la *persisted_ai.LlmActivities
+	validResponse := testBranchNameToolResponse(s.T(), `{"candidates": ["user-auth-feature", "implement-login"]}`)
+	s.env.OnActivity(la.ChatStream, mock.Anything, mock.Anything).Return(validResponse, nil).Once()
+	s.remoteBranches = "jane.doe/ABC-123-user-auth-feature\n"
+
+	req := BranchNameRequest{
+		Requirements: "Add user authn/login functionality for ABC-123",
+		Naming: common.BranchNamingConfig{
+			Template:        "{{.User}}/{{.Ticket}}-{{.Slug}}",
+			ValidationRegex: `^[a-z.]+/[A-Z]+-[0-9]+-[a-z0-9-]+$`,
+		},
+	}
+	s.env.ExecuteWorkflow(s.wrapperWorkflow, req)
+	s.True(s.env.IsWorkflowCompleted())
+	s.NoError(s.env.GetWorkflowError())
+
+	var result string
+	s.NoError(s.env.GetWorkflowResult(&result))
+	s.Equal("jane.doe/ABC-123-implement-login", result)
+}
+
+func (s *BranchNameTestSuite) TestBranchNamingPolicyUnsatisfiable() {
+	var la *persisted_ai.LlmActivities
+	s.env.OnActivity(la.ChatStream, mock.Anything, mock.Anything).Return(nil, assert.AnError)
+
+	// no ticket in the requirements
+	req := BranchNameRequest{
+		Requirements: "Add user authn/login functionality",
+		Naming: common.BranchNamingConfig{
+			Template:        "{{.Ticket}}-{{.Slug}}",
+			ValidationRegex: `^[A-Z]+-[0-9]+-[a-z0-9-]+$`,
+		},
+	}
+	s.env.ExecuteWorkflow(s.wrapperWorkflow, req)
+	s.True(s.env.IsWorkflowCompleted())
+	s.Error(s.env.GetWorkflowError())
+	s.ErrorContains(s.env.GetWorkflowError(), "branch naming policy")
+}
+
+func TestBranchNamePolicy(t *testing.T) {
+	t.Parallel()
+	policy, err := newBranchNamePolicy(common.BranchNamingConfig{}, branchNameVars{})
+	require.NoError(t, err)
+	assert.True(t, policy.isDefault())
+	name, ok := policy.branchName("add-login")
+	assert.True(t, ok)
+	assert.Equal(t, "side/add-login", name)
+
+	policy, err = newBranchNamePolicy(common.BranchNamingConfig{
+		Template:        "{{.User}}/{{.Date}}/{{.Slug}}",
+		ValidationRegex: `^[a-z]+/`,
+	}, branchNameVars{User: "jane", Date: "2025-01-02"})
+	require.NoError(t, err)
+	assert.False(t, policy.isDefault())
+	assert.Equal(t, "jane/2025-01-02/<suffix>", policy.format())
+	name, ok = policy.branchName("add-login")
+	assert.True(t, ok)
+	assert.Equal(t, "jane/2025-01-02/add-login", name)
+
+	policy, err = newBranchNamePolicy(common.BranchNamingConfig{Template: "{{.User}}/{{.Slug}}"}, branchNameVars{})
+	require.NoError(t, err)
+	_, ok = policy.branchName("add-login")
+	assert.False(t, ok, "an empty user leaves an invalid leading slash")
+
+	_, err = newBranchNamePolicy(common.BranchNamingConfig{Template: "{{.Nope"}, branchNameVars{})
+	assert.Error(t, err)
+	_, err = newBranchNamePolicy(common.BranchNamingConfig{ValidationRegex: "("}, branchNameVars{})
+	assert.Error(t, err)
+}
+
+func TestIsValidBranchName(t *testing.T) {
+	t.Parallel()
+	for _, valid := range []string{"side/add-login", "jane.doe/ABC-123-fix", "feature_x"} {
+		assert.True(t, isValidBranchName(valid), valid)
+	}
+	for _, invalid := range []string{"", "/leading", "trailing/", "a..b", "a//b", "has space", "-dash", "a/.hidden", "a.lock", "a~1", "a:b", "x@{y}"} {
+		assert.False(t, isValidBranchName(invalid), invalid)
+	}
+	assert.Equal(t, "jane-doe", sanitizeBranchNameComponent("Jane Doe"))
+	assert.Equal(t, "j.doe", sanitizeBranchNameComponent(".j.doe-"))
+}
+
+func TestBranchNameTaken(t *testing.T) {
+	t.Parallel()
+	branches := map[string]bool{"main": true, "jane.doe": true, "side/add-login": true}
+	assert.True(t, branchNameTaken("main", branches))
+	assert.True(t, branchNameTaken("jane.doe/ABC-1-fix-login", branches), "an existing branch is the new branch's directory")
+	assert.True(t, branchNameTaken("side", branches), "an existing branch is under the new branch")
+	assert.False(t, branchNameTaken("side/fix-login", branches))
+	assert.False(t, branchNameTaken("jane.doe-2/fix-login", branches))
+}
+
 func TestBranchNameTestSuite(t *testing.T) {
 	suite.Run(t, new(BranchNameTestSuite))
 }
@@ -289,8 +289,11 @@ func generateDevPlan(dCtx DevContext, chatHistory *[]llm.ChatMessage) (*llm.Chat
 		&recordDevPlanTool,
 		getRetrieveCodeContextTool(),
 		&bulkSearchRepositoryTool,
+		&structuralSearchTool,
 		&bulkReadFileTool,
 	}
+	tools = append(tools, lspReadOnlyTools...)
+	tools = append(tools, gitHistoryTools...)
 	if !dCtx.RepoConfig.DisableHumanInTheLoop {
 		tools = append(tools, &getHelpOrInputTool)
 	}
@@ -320,6 +323,7 @@ func renderInitialRecordPlanPrompt(dCtx DevContext, codeContext, requirements, p
 		"codeContext":            codeContext,
 		"requirements":           requirements,
 		"recordPlanFunctionName": recordDevPlanTool.Name,
+		"gitLogFunctionName":     gitLogTool.Name,
 		"planningPrompt":         planningPrompt,
 		"reproducePrompt":        reproducePrompt,
 		"reproduceIssue":         reproduceIssue,
@@ -191,8 +191,11 @@ func generateDevRequirements(dCtx DevContext, chatHistory *[]llm.ChatMessage) (*
 		&recordDevRequirementsTool,
 		getRetrieveCodeContextTool(),
 		&bulkSearchRepositoryTool,
+		&structuralSearchTool,
 		&bulkReadFileTool,
 	}
+	tools = append(tools, lspReadOnlyTools...)
+	tools = append(tools, gitHistoryTools...)
 	if !dCtx.RepoConfig.DisableHumanInTheLoop {
 		tools = append(tools, &getHelpOrInputTool)
 	}
@@ -359,7 +362,8 @@ As an ex-software developer, you are able to also read code via the
 technical requirements as well as understand the status quo before suggesting
 changes that software engineers will need to implement. Searching the repository
 for relevant text which may be strewn across comments or READMEs, using the `+
-		bulkSearchRepositoryTool.Name+` tool, may also be helpful.
+		bulkSearchRepositoryTool.Name+` tool, may also be helpful. Recent commits,
+via the `+gitLogTool.Name+` tool, can explain why things are the way they are.
 
 Consider how the different requirements interact with each other in the context
 of the codebase. If the requirements clash with each other in any way, try to
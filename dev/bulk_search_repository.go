@@ -23,6 +23,8 @@ var bulkSearchRepositoryTool = llm.Tool{
 type BulkSearchRepositoryParams struct {
 	ContextLines int                  `json:"context_lines" jsonschema:"description=The number of lines of context to include around the search term."`
 	Searches     []SingleSearchParams `json:"searches" jsonschema:"description=The list of searches to perform."`
+	// Subdirectory is set from the task's sub-project rather than by the LLM
+	Subdirectory string `json:"-"`
 }
 
 func BulkSearchRepository(ctx workflow.Context, envContainer env.EnvContainer, bulkSearchRepositoryParams BulkSearchRepositoryParams) (string, error) {
@@ -32,6 +34,7 @@ func BulkSearchRepository(ctx workflow.Context, envContainer env.EnvContainer, b
 			PathGlob:     searchParams.PathGlob,
 			SearchTerm:   searchParams.SearchTerm,
 			ContextLines: bulkSearchRepositoryParams.ContextLines,
+			Subdirectory: bulkSearchRepositoryParams.Subdirectory,
 		})
 		if err != nil {
 			return "", err
@@ -78,6 +81,7 @@ func isExistentFilePath(ctx workflow.Context, envContainer env.EnvContainer, pat
 			EnvContainer:       envContainer,
 			RelativeWorkingDir: "./",
 			Command:            "cat",
+			Category:           "search",
 			Args:               []string{path},
 		}).Get(ctx, &catOutput)
 		if err != nil {
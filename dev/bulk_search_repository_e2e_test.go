@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	code_search "sidekick/coding/search"
 	"sidekick/env"
 	"sidekick/utils"
 	"testing"
@@ -64,6 +65,7 @@ func (s *BulkSearchRepositoryE2ETestSuite) ResetWorkflowEnvironment() {
 
 	s.env = s.NewTestWorkflowEnvironment()
 	s.env.RegisterActivity(env.EnvRunCommandActivity)
+	s.env.RegisterActivity(code_search.SearchActivity)
 	s.env.RegisterActivity(GetSymbolsActivity)
 
 	s.wrapperWorkflow = func(ctx workflow.Context, envContainer env.EnvContainer, params BulkSearchRepositoryParams) (string, error) {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sidekick/coding"
+	"sidekick/coding/git"
 	"sidekick/coding/tree_sitter"
 	"sidekick/common"
 	"sidekick/domain"
@@ -100,7 +101,8 @@ func prepareInitialCodeContextSubflow(dCtx DevContext, requirements string, plan
 }
 
 func PrepareRepoSummary(dCtx DevContext, requirements string) (string, string, error) {
-	repoSummary, err := GetRankedRepoSummary(dCtx, requirements)
+	contextFilePaths := rankingContextFilePaths(dCtx)
+	repoSummary, err := GetRankedRepoSummary(dCtx, requirements, contextFilePaths)
 	if err != nil {
 		return "", "", err
 	}
@@ -118,12 +120,30 @@ func PrepareRepoSummary(dCtx DevContext, requirements string) (string, string, e
 
 	// Append the identified needs to the requirements for second round of ranked signatures
 	rankQuery := fmt.Sprintf("%s\n\n%s", requirements, strings.Join(infoNeeds.Needs, "\n"))
-	repoSummary, err = GetRankedRepoSummary(dCtx, rankQuery)
+	repoSummary, err = GetRankedRepoSummary(dCtx, rankQuery, contextFilePaths)
 
 	return repoSummary, needs, err
 }
 
-func GetRankedRepoSummary(dCtx DevContext, rankQuery string) (string, error) {
+// rankingContextFilePaths returns the files the task has already changed,
+// which earlier steps and feedback iterations brought into context, so the
+// repo summary favours code related to them. Failing to list them only loses
+// that personalization, so errors are logged rather than returned.
+func rankingContextFilePaths(dCtx DevContext) []string {
+	v := workflow.GetVersion(dCtx, "ranked-summary-context-files", workflow.DefaultVersion, 1)
+	if v < 1 {
+		return nil
+	}
+	var changedFiles []string
+	err := workflow.ExecuteActivity(dCtx, git.GitChangedFilesActivity, *dCtx.EnvContainer, dCtx.BaseBranch).Get(dCtx, &changedFiles)
+	if err != nil {
+		workflow.GetLogger(dCtx).Warn("Failed to list changed files for ranking", "error", err)
+		return nil
+	}
+	return changedFiles
+}
+
+func GetRankedRepoSummary(dCtx DevContext, rankQuery string, contextFilePaths []string) (string, error) {
 	options := persisted_ai.RankedDirSignatureOutlineOptions{
 		RankedViaEmbeddingOptions: persisted_ai.RankedViaEmbeddingOptions{
 			WorkspaceId:  dCtx.WorkspaceId,
@@ -132,7 +152,9 @@ func GetRankedRepoSummary(dCtx DevContext, rankQuery string) (string, error) {
 			Secrets:      *dCtx.Secrets,
 			ModelConfig:  dCtx.GetEmbeddingModelConfig(common.DefaultKey),
 		},
-		CharLimit: min(defaultMaxChatHistoryLength/2, 15000), // ensure we leave space for other messages
+		CharLimit:        min(defaultMaxChatHistoryLength/2, 15000), // ensure we leave space for other messages
+		ContextFilePaths: contextFilePaths,
+		Subdirectory:     dCtx.SubProject,
 	}
 
 	attempts := 0
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
 	"sidekick/coding/git"
 	"sidekick/common"
 	"sidekick/domain"
@@ -23,6 +25,15 @@ type DevContext struct {
 	GlobalState *GlobalState
 	Worktree    *domain.Worktree
 	RepoConfig  common.RepoConfig
+	// the branch the worktree was started from, only set for worktree envs
+	BaseBranch string
+	// the path of the sub-project the task is scoped to, relative to the repo
+	// root, if any. code context ranking and search are limited to it.
+	SubProject string
+	// set while resolving merge conflicts, when files with conflict markers
+	// can't pass checks until all of their conflicts are resolved, nor be
+	// restored while unmerged
+	ResolvingMergeConflicts bool
 }
 
 // WithContext returns a new DevContext with the workflow.Context updated.
@@ -39,7 +50,7 @@ func (dCtx DevContext) WithCancelOnPause() DevContext {
 	return dCtx
 }
 
-func SetupDevContext(ctx workflow.Context, workspaceId string, repoDir string, envType string, startBranch *string, requirements string) (DevContext, error) {
+func SetupDevContext(ctx workflow.Context, workspaceId string, repoDir string, envType string, startBranch *string, subProject string, requirements string) (DevContext, error) {
 	initialExecCtx := flow_action.ExecContext{
 		Context:     ctx,
 		WorkspaceId: workspaceId,
@@ -50,12 +61,12 @@ func SetupDevContext(ctx workflow.Context, workspaceId string, repoDir string, e
 	return flow_action.TrackSubflowFailureOnly(initialExecCtx, "flow_init", "Initialize", func(_ domain.Subflow) (DevContext, error) {
 		actionCtx := initialExecCtx.NewActionContext("setup_dev_context")
 		return flow_action.TrackFailureOnly(actionCtx, func(_ domain.FlowAction) (DevContext, error) {
-			return setupDevContextAction(ctx, workspaceId, repoDir, envType, startBranch, requirements)
+			return setupDevContextAction(ctx, workspaceId, repoDir, envType, startBranch, subProject, requirements)
 		})
 	})
 }
 
-func setupDevContextAction(ctx workflow.Context, workspaceId string, repoDir string, envType string, startBranch *string, requirements string) (DevContext, error) {
+func setupDevContextAction(ctx workflow.Context, workspaceId string, repoDir string, envType string, startBranch *string, subProject string, requirements string) (DevContext, error) {
 	ctx = utils.NoRetryCtx(ctx)
 
 	var devEnv env.Env
@@ -81,6 +92,11 @@ func setupDevContextAction(ctx workflow.Context, workspaceId string, repoDir str
 	if err != nil {
 		return DevContext{}, fmt.Errorf("failed to create temp local env: %v", err)
 	}
+	// when config isn't loaded yet, this falls back to the default secret managers
+	tempSecretManager, err := secret_manager.NewSecretManagerFromConfig(localConfig.SecretManagers)
+	if err != nil {
+		return DevContext{}, err
+	}
 	// this is *only* to be used temporarily during setup, until the real/full eCtx is created
 	tempLocalExecContext := flow_action.ExecContext{
 		FlowScope:    &flow_action.FlowScope{},
@@ -88,10 +104,7 @@ func setupDevContextAction(ctx workflow.Context, workspaceId string, repoDir str
 		WorkspaceId:  workspaceId,
 		EnvContainer: &env.EnvContainer{Env: tempLocalEnv},
 		Secrets: &secret_manager.SecretManagerContainer{
-			SecretManager: secret_manager.NewCompositeSecretManager([]secret_manager.SecretManager{
-				secret_manager.KeyringSecretManager{},
-				secret_manager.LocalConfigSecretManager{},
-			}),
+			SecretManager: tempSecretManager,
 		},
 		Providers:       localConfig.Providers, // TODO merge with workspace providers
 		LLMConfig:       finalLLMConfig,
@@ -124,6 +137,7 @@ func setupDevContextAction(ctx workflow.Context, workspaceId string, repoDir str
 			branchName, err = GenerateBranchName(tempLocalExecContext, BranchNameRequest{
 				Requirements: requirements,
 				Hints:        editHints,
+				Naming:       tempLocalRepoConfig.BranchNaming,
 			})
 			if err != nil {
 				return DevContext{}, fmt.Errorf("failed to generate branch name: %v", err)
@@ -138,6 +152,7 @@ func setupDevContextAction(ctx workflow.Context, workspaceId string, repoDir str
 			FlowId:      flowId,
 			Name:        branchName,
 			WorkspaceId: workspaceId,
+			Created:     workflow.Now(ctx),
 		}
 		err = workflow.ExecuteActivity(ctx, env.NewLocalGitWorktreeActivity, env.LocalEnvParams{
 			RepoDir:     repoDir,
@@ -163,16 +178,18 @@ func setupDevContextAction(ctx workflow.Context, workspaceId string, repoDir str
 		}
 	}
 
+	secretManager, err := secret_manager.NewSecretManagerFromConfig(localConfig.SecretManagers)
+	if err != nil {
+		return DevContext{}, err
+	}
+
 	eCtx := flow_action.ExecContext{
 		FlowScope:    &flow_action.FlowScope{},
 		Context:      ctx,
 		WorkspaceId:  workspaceId,
 		EnvContainer: &envContainer,
 		Secrets: &secret_manager.SecretManagerContainer{
-			SecretManager: secret_manager.NewCompositeSecretManager([]secret_manager.SecretManager{
-				secret_manager.KeyringSecretManager{},
-				secret_manager.LocalConfigSecretManager{},
-			}),
+			SecretManager: secretManager,
 		},
 		Providers:       localConfig.Providers, // TODO merge with workspace providers
 		LLMConfig:       finalLLMConfig,
@@ -193,15 +210,20 @@ func setupDevContextAction(ctx workflow.Context, workspaceId string, repoDir str
 		return DevContext{}, fmt.Errorf("failed to get repo config: %v\n\n%s", err, hint)
 	}
 
+	// an empty sub-project, or ".", scopes the task to the whole repo
+	subProject = path.Clean("/" + filepath.ToSlash(subProject))[1:]
+	if subProject != "" {
+		if _, ok := repoConfig.SubProject(subProject); !ok {
+			return DevContext{}, fmt.Errorf("sub-project %q not found: sub-projects are directories with their own side.toml", subProject)
+		}
+		repoConfig = repoConfig.ScopedTo(subProject)
+	}
+
 	// Execute worktree setup script if configured and using git worktree environment
 	if envType == string(env.EnvTypeLocalGitWorktree) && repoConfig.WorktreeSetup != "" {
-		err = workflow.ExecuteActivity(ctx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
-			EnvContainer: envContainer,
-			Command:      "/usr/bin/env",
-			Args:         []string{"sh", "-c", repoConfig.WorktreeSetup},
-		}).Get(ctx, nil)
+		err = setUpWorktree(ctx, envContainer, workspaceId, repoConfig)
 		if err != nil {
-			return DevContext{}, fmt.Errorf("failed to execute worktree setup script: %v", err)
+			return DevContext{}, err
 		}
 	}
 
@@ -210,11 +232,69 @@ func setupDevContextAction(ctx workflow.Context, workspaceId string, repoDir str
 		ExecContext: eCtx,
 		Worktree:    worktree,
 		RepoConfig:  repoConfig,
+		SubProject:  subProject,
+	}
+	if worktree != nil {
+		// matches the default target branch when merging
+		devCtx.BaseBranch = "main"
+		if startBranch != nil && *startBranch != "" {
+			devCtx.BaseBranch = *startBranch
+		}
 	}
 
 	return devCtx, nil
 }
 
+// setUpWorktree runs the worktree setup script, unless the configured
+// dependency directories could be restored from a cache populated by an
+// earlier run with the same lockfiles
+func setUpWorktree(ctx workflow.Context, envContainer env.EnvContainer, workspaceId string, repoConfig common.RepoConfig) error {
+	version := workflow.GetVersion(ctx, "dependency-cache", workflow.DefaultVersion, 1)
+	if version < 1 || len(repoConfig.DependencyCache.Paths) == 0 {
+		return runWorktreeSetup(ctx, envContainer, repoConfig.WorktreeSetup)
+	}
+
+	cacheParams := env.DependencyCacheParams{
+		EnvContainer: envContainer,
+		WorkspaceId:  workspaceId,
+		Paths:        repoConfig.DependencyCache.Paths,
+		SetupScript:  repoConfig.WorktreeSetup,
+	}
+	var restoreResult env.RestoreDependencyCacheResult
+	err := workflow.ExecuteActivity(ctx, env.RestoreDependencyCacheActivity, cacheParams).Get(ctx, &restoreResult)
+	if err != nil {
+		// the cache is only an optimization, so setup proceeds without it
+		workflow.GetLogger(ctx).Warn("Failed to restore dependency cache", "error", err)
+	} else if restoreResult.Restored {
+		return nil
+	}
+
+	if err := runWorktreeSetup(ctx, envContainer, repoConfig.WorktreeSetup); err != nil {
+		return err
+	}
+	if restoreResult.Key == "" {
+		return nil
+	}
+	err = workflow.ExecuteActivity(ctx, env.SaveDependencyCacheActivity, cacheParams, restoreResult.Key).Get(ctx, nil)
+	if err != nil {
+		workflow.GetLogger(ctx).Warn("Failed to save dependency cache", "error", err)
+	}
+	return nil
+}
+
+func runWorktreeSetup(ctx workflow.Context, envContainer env.EnvContainer, script string) error {
+	err := workflow.ExecuteActivity(ctx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
+		EnvContainer: envContainer,
+		Command:      "/usr/bin/env",
+		Category:     "worktree_setup",
+		Args:         []string{"sh", "-c", script},
+	}).Get(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to execute worktree setup script: %v", err)
+	}
+	return nil
+}
+
 // cleanup on cancel for resources created during setupDevContextAction
 func handleFlowCancel(dCtx DevContext) {
 	if !errors.Is(dCtx.Err(), workflow.ErrCanceled) {
@@ -226,7 +306,7 @@ func handleFlowCancel(dCtx DevContext) {
 	_ = signalWorkflowClosure(disconnectedCtx, "canceled")
 
 	if dCtx.Worktree != nil {
-		future := workflow.ExecuteActivity(disconnectedCtx, git.CleanupWorktreeActivity, dCtx.EnvContainer, dCtx.EnvContainer.Env.GetWorkingDirectory(), dCtx.Worktree.Name, "Sidekick task cancelled")
+		future := workflow.ExecuteActivity(disconnectedCtx, git.CleanupWorktreeActivity, dCtx.EnvContainer, dCtx.EnvContainer.Env.GetWorkingDirectory(), dCtx.Worktree.Name, "Sidekick task cancelled", dCtx.Worktree.FlowId)
 		if err := future.Get(disconnectedCtx, nil); err != nil {
 			workflow.GetLogger(dCtx).Error("Failed to cleanup worktree during workflow cancellation", "error", err, "worktree", dCtx.Worktree.Name)
 		}
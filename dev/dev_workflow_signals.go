@@ -13,6 +13,10 @@ const SignalNameWorkflowClosed = "workflowClosed"
 const SignalNamePause = "pause"
 const SignalNameUserAction = "userAction"
 
+// SignalNameRollbackToCheckpoint is sent with the step number whose checkpoint
+// should be restored
+const SignalNameRollbackToCheckpoint = "rollbackToCheckpoint"
+
 type WorkflowClosure struct {
 	FlowId string
 	Reason string // reasons are: https://docs.temporal.io/workflows#closed
@@ -2,7 +2,10 @@ package dev
 
 import (
 	"bufio"
+	"fmt"
+	"regexp"
 	"sidekick/coding/tree_sitter"
+	"sidekick/common"
 	"sidekick/llm"
 	"sidekick/utils"
 	"strconv" // Added to use the Atoi function
@@ -16,8 +19,14 @@ type EditBlock struct {
 	AbsoluteFilePath string   `json:"-"`
 	OldLines         []string `json:"oldLines"`
 	NewLines         []string `json:"newLines"`
-	// TODO /gen typedef string with consts for: "create", "append", "update", or "delete"
+	// TODO /gen typedef string with consts for: "create", "append", "update", "rewrite", "delete"
+	// or "rename", plus the symbol edit types
 	EditType string `json:"editType"`
+	// SymbolName is the symbol targeted by symbol edit types, optionally
+	// qualified by its parent, eg "Type.method"
+	SymbolName string `json:"symbolName,omitempty"`
+	// NewFilePath is where the file is moved to by the rename edit type
+	NewFilePath string `json:"newFilePath,omitempty"`
 	// Sequence number of the edit block
 	SequenceNumber int `json:"sequenceNumber"`
 	// file ranges that were visible when this edit block was created
@@ -47,6 +56,34 @@ func extractAllCodeBlocks(chatHistory []llm.ChatMessage) []tree_sitter.CodeBlock
 	return codeBlocks
 }
 
+// Symbol edits target a symbol's full definition, including its doc comment,
+// by name rather than by quoting old lines
+const (
+	EditTypeReplaceSymbol      = "replace_symbol"
+	EditTypeDeleteSymbol       = "delete_symbol"
+	EditTypeInsertBeforeSymbol = "insert_before_symbol"
+	EditTypeInsertAfterSymbol  = "insert_after_symbol"
+)
+
+var symbolEditTypesByMarker = map[string]string{
+	"REPLACE_SYMBOL":       EditTypeReplaceSymbol,
+	"DELETE_SYMBOL":        EditTypeDeleteSymbol,
+	"INSERT_BEFORE_SYMBOL": EditTypeInsertBeforeSymbol,
+	"INSERT_AFTER_SYMBOL":  EditTypeInsertAfterSymbol,
+}
+
+var symbolEditMarkerPattern = regexp.MustCompile(`^<<<<<<<\s*(REPLACE_SYMBOL|DELETE_SYMBOL|INSERT_BEFORE_SYMBOL|INSERT_AFTER_SYMBOL)\s+(\S+)`)
+
+var renameFileMarkerPattern = regexp.MustCompile(`^<<<<<<<\s*RENAME_FILE\s+(\S+)`)
+
+func isSymbolEditType(editType string) bool {
+	switch editType {
+	case EditTypeReplaceSymbol, EditTypeDeleteSymbol, EditTypeInsertBeforeSymbol, EditTypeInsertAfterSymbol:
+		return true
+	}
+	return false
+}
+
 // ExtractEditBlocks extracts edit blocks from the given string.
 func ExtractEditBlocks(text string) ([]*EditBlock, error) {
 	scanner := bufio.NewScanner(strings.NewReader(text))
@@ -57,12 +94,25 @@ func ExtractEditBlocks(text string) ([]*EditBlock, error) {
 	var sequenceNumber int // the sequence number for the current block
 
 	inCodeBlock := false    // flag whether the scanner is in a code block
+	inWholeFile := false    // flag whether the scanner is in a whole-file rewrite
 	lastFilePath := ""      // keeps the last file path
 	maybeNextFilePath := "" // keeps the potential next file path
 
 	for scanner.Scan() {
 		line := scanner.Text()
 
+		// whole files may contain anything, including fences and dividers, so
+		// only the end marker is special
+		if inWholeFile {
+			if strings.HasPrefix(line, ">>>>>>>") {
+				inWholeFile = false
+				newLines = nil
+			} else {
+				*newLines = append(*newLines, line)
+			}
+			continue
+		}
+
 		// If a backtick fence is found, toggle the inCodeBlock flag
 		if strings.HasPrefix(line, "```") {
 			inCodeBlock = !inCodeBlock
@@ -84,13 +134,24 @@ func ExtractEditBlocks(text string) ([]*EditBlock, error) {
 
 		if strings.HasPrefix(line, "<<<<<<<") {
 			editType := "update" // default edit type, corresponds to SEARCH but we aren't checking that
+			symbolName := ""
+			newFilePath := ""
 			switch {
+			case renameFileMarkerPattern.MatchString(line):
+				editType = "rename"
+				newFilePath = renameFileMarkerPattern.FindStringSubmatch(line)[1]
+			case symbolEditMarkerPattern.MatchString(line):
+				submatches := symbolEditMarkerPattern.FindStringSubmatch(line)
+				editType = symbolEditTypesByMarker[submatches[1]]
+				symbolName = submatches[2]
 			case strings.Contains(line, "CREATE_FILE"):
 				editType = "create"
 			case strings.Contains(line, "APPEND_TO_FILE"):
 				editType = "append"
 			case strings.Contains(line, "DELETE_FILE"):
 				editType = "delete"
+			case strings.Contains(line, "WHOLE_FILE"):
+				editType = "rewrite"
 			}
 			filePath := maybeNextFilePath
 			if filePath == "" {
@@ -98,11 +159,17 @@ func ExtractEditBlocks(text string) ([]*EditBlock, error) {
 			} else {
 				lastFilePath = maybeNextFilePath
 			}
-			block = &EditBlock{FilePath: filePath, EditType: editType, SequenceNumber: sequenceNumber}
+			block = &EditBlock{FilePath: filePath, EditType: editType, SymbolName: symbolName, NewFilePath: newFilePath, SequenceNumber: sequenceNumber}
 			// Reset sequence number after creating a new block
 			sequenceNumber = 0
 			oldLines = &block.OldLines
 			newLines = nil
+			if editType == "rewrite" {
+				// there are no old lines to replace, just the full new contents
+				oldLines = nil
+				newLines = &block.NewLines
+				inWholeFile = true
+			}
 			blocks = append(blocks, block)
 		} else if strings.HasPrefix(line, "=======") {
 			oldLines = nil
@@ -136,7 +203,8 @@ func ExtractEditBlocks(text string) ([]*EditBlock, error) {
 	}
 
 	for _, block := range blocks {
-		if (block.EditType == "append" || block.EditType == "create") && len(block.NewLines) == 0 && len(block.OldLines) > 0 {
+		hasNoOldLines := block.EditType == "append" || block.EditType == "create" || isSymbolEditType(block.EditType)
+		if hasNoOldLines && len(block.NewLines) == 0 && len(block.OldLines) > 0 {
 			// infer a missing divider, we'll parse this generously as adding new lines
 			block.NewLines = block.OldLines
 			block.OldLines = nil
@@ -150,8 +218,22 @@ func ExtractEditBlocks(text string) ([]*EditBlock, error) {
 	return blocks, nil
 }
 
-func ExtractEditBlocksWithVisibility(text string, chatHistory []llm.ChatMessage) ([]EditBlock, error) {
-	editBlocksWithoutVisibility, err := ExtractEditBlocks(text)
+// ExtractEditBlocksForFormat extracts edit blocks written in the given edit
+// format, as configured for the coding model
+func ExtractEditBlocksForFormat(text string, editFormat string) ([]*EditBlock, error) {
+	switch editFormat {
+	case common.EditFormatUnifiedDiff:
+		return ExtractUnifiedDiffEditBlocks(text)
+	case common.EditFormatSearchReplace, common.EditFormatWholeFile, "":
+		// whole-file rewrites are a variant of search/replace edit blocks
+		return ExtractEditBlocks(text)
+	default:
+		return nil, fmt.Errorf("unsupported edit format: %s", editFormat)
+	}
+}
+
+func ExtractEditBlocksWithVisibility(text string, chatHistory []llm.ChatMessage, editFormat string) ([]EditBlock, error) {
+	editBlocksWithoutVisibility, err := ExtractEditBlocksForFormat(text, editFormat)
 	if err != nil {
 		return nil, err
 	}
@@ -330,6 +330,114 @@ d
 	},
 }
 
+var wholeFileRewrite = EditBlockTestCase{
+	name: "Whole file rewrite",
+	testInput: `
+` + "```" + `markdown
+edit_block:1
+README.md
+` + wholeFile + `
+# Title
+=======
+
+` + "```" + `go
+fmt.Println("hi")
+` + "```" + `
+` + endWholeFile + `
+` + "```" + `
+`,
+	expectedResult: []*EditBlock{
+		{
+			FilePath: "README.md",
+			NewLines: []string{
+				"# Title",
+				"=======",
+				"",
+				"```go",
+				"fmt.Println(\"hi\")",
+				"```",
+			},
+			EditType:       "rewrite",
+			SequenceNumber: 1,
+		},
+	},
+}
+
+var symbolEdits = EditBlockTestCase{
+	name: "Symbol edits",
+	testInput: `
+` + "```" + `go
+edit_block:1
+path/to/file.go
+<<<<<<< REPLACE_SYMBOL Server.Start
+` + divider + `
+func (s *Server) Start() error {
+	return nil
+}
+>>>>>>> NEW_LINES
+` + "```" + `
+
+` + "```" + `go
+edit_block:2
+path/to/file.go
+<<<<<<< DELETE_SYMBOL unusedHelper
+>>>>>>> NEW_LINES
+` + "```" + `
+
+` + "```" + `go
+edit_block:3
+path/to/file.go
+<<<<<<< INSERT_AFTER_SYMBOL Server
+func NewServer() *Server {
+	return &Server{}
+}
+>>>>>>> NEW_LINES
+` + "```" + `
+`,
+	expectedResult: []*EditBlock{
+		{
+			FilePath:       "path/to/file.go",
+			NewLines:       []string{"func (s *Server) Start() error {", "\treturn nil", "}"},
+			EditType:       EditTypeReplaceSymbol,
+			SymbolName:     "Server.Start",
+			SequenceNumber: 1,
+		},
+		{
+			FilePath:       "path/to/file.go",
+			EditType:       EditTypeDeleteSymbol,
+			SymbolName:     "unusedHelper",
+			SequenceNumber: 2,
+		},
+		{
+			FilePath:       "path/to/file.go",
+			NewLines:       []string{"func NewServer() *Server {", "\treturn &Server{}", "}"},
+			EditType:       EditTypeInsertAfterSymbol,
+			SymbolName:     "Server",
+			SequenceNumber: 3,
+		},
+	},
+}
+
+var renameFile = EditBlockTestCase{
+	name: "Rename file",
+	testInput: `
+` + "```" + `
+edit_block:1
+src/old/name.ts
+<<<<<<< RENAME_FILE src/new/name.ts
+>>>>>>> END_RENAME_FILE
+` + "```" + `
+`,
+	expectedResult: []*EditBlock{
+		{
+			FilePath:       "src/old/name.ts",
+			EditType:       "rename",
+			NewFilePath:    "src/new/name.ts",
+			SequenceNumber: 1,
+		},
+	},
+}
+
 func TestExtractEditBlocks(t *testing.T) {
 	testCases := []EditBlockTestCase{
 		basicCase,
@@ -344,6 +452,9 @@ func TestExtractEditBlocks(t *testing.T) {
 		missingDividerCreateFile,
 		multipleEditsInSameFile,
 		multipleEditsInSameFile2,
+		wholeFileRewrite,
+		symbolEdits,
+		renameFile,
 	}
 
 	combinedTestInput := ""
@@ -292,7 +292,7 @@ func authorEditBlocks(dCtx DevContext, codingModelConfig common.ModelConfig, con
 			// generated
 			visibleChatHistory = authorEditBlockInput.Params.Messages
 		}
-		currentExtractedBlocks, err := ExtractEditBlocksWithVisibility(chatResponse.ChatMessage.Content, visibleChatHistory)
+		currentExtractedBlocks, err := ExtractEditBlocksWithVisibility(chatResponse.ChatMessage.Content, visibleChatHistory, codingModelConfig.NormalizedEditFormat())
 		if err != nil {
 			return []EditBlock{}, fmt.Errorf("failed to extract edit blocks: %v", err)
 		}
@@ -347,10 +347,10 @@ func buildAuthorEditBlockInput(dCtx DevContext, codingModelConfig common.ModelCo
 	cacheControl := ""
 	switch info := promptInfo.(type) {
 	case InitialCodeInfo:
-		content = renderAuthorEditBlockInitialPrompt(dCtx, info.CodeContext, info.Requirements)
+		content = renderAuthorEditBlockInitialPrompt(dCtx, codingModelConfig.NormalizedEditFormat(), info.CodeContext, info.Requirements)
 		cacheControl = "ephemeral"
 	case InitialDevStepInfo:
-		content = renderAuthorEditBlockInitialDevStepPrompt(dCtx, info.CodeContext, info.Requirements, info.PlanExecution.String(), info.Step.Definition)
+		content = renderAuthorEditBlockInitialDevStepPrompt(dCtx, codingModelConfig.NormalizedEditFormat(), info.CodeContext, info.Requirements, info.PlanExecution.String(), info.Step.Definition)
 	case SkipInfo:
 		skip = true
 	case FeedbackInfo:
@@ -380,9 +380,13 @@ func buildAuthorEditBlockInput(dCtx DevContext, codingModelConfig common.ModelCo
 
 	var tools []*llm.Tool
 	tools = append(tools, &bulkSearchRepositoryTool)
+	tools = append(tools, &structuralSearchTool)
 	tools = append(tools, getRetrieveCodeContextTool())
 	tools = append(tools, &bulkReadFileTool)
 	tools = append(tools, &runCommandTool)
+	tools = append(tools, lspReadOnlyTools...)
+	tools = append(tools, &renameSymbolTool)
+	tools = append(tools, gitHistoryTools...)
 
 	if !dCtx.RepoConfig.DisableHumanInTheLoop {
 		tools = append(tools, &getHelpOrInputTool)
@@ -409,11 +413,13 @@ func buildAuthorEditBlockInput(dCtx DevContext, codingModelConfig common.ModelCo
 const search = "<<<<<<< SEARCH_EXACT"
 const divider = "======="
 const replace = ">>>>>>> REPLACE_EXACT"
+const wholeFile = "<<<<<<< WHOLE_FILE"
+const endWholeFile = ">>>>>>> END_WHOLE_FILE"
 
 const startInitialCodeContext = "#START INITIAL CODE CONTEXT"
 const endInitialCodeContext = "#END INITIAL CODE CONTEXT"
 
-func renderAuthorEditBlockInitialPrompt(dCtx DevContext, codeContext, requirements string) string {
+func renderAuthorEditBlockInitialPrompt(dCtx DevContext, editFormat, codeContext, requirements string) string {
 	data := map[string]interface{}{
 		"codeContext":                     codeContext,
 		"requirements":                    requirements,
@@ -426,14 +432,17 @@ func renderAuthorEditBlockInitialPrompt(dCtx DevContext, codeContext, requiremen
 		"replace":                         replace,
 		"editCodeHints":                   dCtx.RepoConfig.EditCode.Hints,
 		"retrieveCodeContextFunctionName": getRetrieveCodeContextTool().Name,
+		"gitBlameFunctionName":            gitBlameTool.Name,
+		"gitLogFunctionName":              gitLogTool.Name,
 	}
 	if !dCtx.RepoConfig.DisableHumanInTheLoop {
 		data["getHelpOrInputFunctionName"] = getHelpOrInputTool.Name
 	}
+	addEditFormatPromptData(data, editFormat)
 	return RenderPrompt(AuthorEditBlockInitial, data)
 }
 
-func renderAuthorEditBlockInitialDevStepPrompt(dCtx DevContext, codeContext, requirements, planContext, currentStep string) string {
+func renderAuthorEditBlockInitialDevStepPrompt(dCtx DevContext, editFormat, codeContext, requirements, planContext, currentStep string) string {
 	data := map[string]interface{}{
 		"codeContext":                     codeContext,
 		"requirements":                    requirements,
@@ -448,14 +457,31 @@ func renderAuthorEditBlockInitialDevStepPrompt(dCtx DevContext, codeContext, req
 		"replace":                         replace,
 		"editCodeHints":                   dCtx.RepoConfig.EditCode.Hints,
 		"retrieveCodeContextFunctionName": getRetrieveCodeContextTool().Name,
+		"gitBlameFunctionName":            gitBlameTool.Name,
+		"gitLogFunctionName":              gitLogTool.Name,
 	}
 	if !dCtx.RepoConfig.DisableHumanInTheLoop {
 		data["getHelpOrInputFunctionName"] = getHelpOrInputTool.Name
 	}
+	addEditFormatPromptData(data, editFormat)
 
 	return RenderPrompt(AuthorEditBlockInitialWithPlan, data)
 }
 
+// addEditFormatPromptData selects the edit block instructions matching the
+// coding model's edit format
+func addEditFormatPromptData(data map[string]interface{}, editFormat string) {
+	switch editFormat {
+	case common.EditFormatUnifiedDiff:
+		data["unifiedDiffEditFormat"] = true
+	case common.EditFormatWholeFile:
+		data["wholeFileEditFormat"] = true
+		data["wholeFile"] = wholeFile
+		data["endWholeFile"] = endWholeFile
+		data["maxRewriteFileLines"] = maxRewriteFileLines
+	}
+}
+
 // renderAuthorEditBlockFeedbackPrompt formats the author edit block feedback
 // into a prompt specialized for fixing issues in applying the edit block
 // TODO only provide the first hint if the feedback is about applying edit
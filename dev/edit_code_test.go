@@ -126,14 +126,14 @@ func TestBuildAuthorEditBlockInitialPrompt(t *testing.T) {
 			DisableHumanInTheLoop: false,
 		},
 	}
-	prompt := renderAuthorEditBlockInitialPrompt(dCtx, "some code", "some requirements")
+	prompt := renderAuthorEditBlockInitialPrompt(dCtx, common.EditFormatSearchReplace, "some code", "some requirements")
 	assert.NotEmpty(t, prompt)
 	assert.Contains(t, prompt, "some code")
 	assert.Contains(t, prompt, "some requirements")
 	assert.Contains(t, prompt, getHelpOrInputTool.Name)
 
 	dCtx.RepoConfig.DisableHumanInTheLoop = true
-	prompt = renderAuthorEditBlockInitialPrompt(dCtx, "some code", "some requirements")
+	prompt = renderAuthorEditBlockInitialPrompt(dCtx, common.EditFormatSearchReplace, "some code", "some requirements")
 	assert.NotEmpty(t, prompt)
 	assert.Contains(t, prompt, "some code")
 	assert.Contains(t, prompt, "some requirements")
@@ -146,7 +146,7 @@ func TestBuildAuthorEditBlockInitialDevStepPrompt(t *testing.T) {
 			DisableHumanInTheLoop: false,
 		},
 	}
-	prompt := renderAuthorEditBlockInitialDevStepPrompt(dCtx, "some code", "some requirements", "plan", "step")
+	prompt := renderAuthorEditBlockInitialDevStepPrompt(dCtx, common.EditFormatSearchReplace, "some code", "some requirements", "plan", "step")
 	assert.NotEmpty(t, prompt)
 	assert.Contains(t, prompt, "some code")
 	assert.Contains(t, prompt, "some requirements")
@@ -155,7 +155,7 @@ func TestBuildAuthorEditBlockInitialDevStepPrompt(t *testing.T) {
 	assert.Contains(t, prompt, getHelpOrInputTool.Name)
 
 	dCtx.RepoConfig.DisableHumanInTheLoop = true
-	prompt = renderAuthorEditBlockInitialDevStepPrompt(dCtx, "some code", "some requirements", "plan", "step")
+	prompt = renderAuthorEditBlockInitialDevStepPrompt(dCtx, common.EditFormatSearchReplace, "some code", "some requirements", "plan", "step")
 	assert.NotEmpty(t, prompt)
 	assert.Contains(t, prompt, "some code")
 	assert.Contains(t, prompt, "some requirements")
@@ -163,3 +163,22 @@ func TestBuildAuthorEditBlockInitialDevStepPrompt(t *testing.T) {
 	assert.Contains(t, prompt, "step")
 	assert.NotContains(t, prompt, getHelpOrInputTool.Name)
 }
+
+func TestBuildAuthorEditBlockInitialPromptEditFormats(t *testing.T) {
+	dCtx := DevContext{}
+
+	prompt := renderAuthorEditBlockInitialPrompt(dCtx, common.EditFormatSearchReplace, "some code", "some requirements")
+	assert.Contains(t, prompt, search)
+	assert.NotContains(t, prompt, wholeFile)
+	assert.NotContains(t, prompt, "+++ ")
+
+	prompt = renderAuthorEditBlockInitialPrompt(dCtx, common.EditFormatUnifiedDiff, "some code", "some requirements")
+	assert.Contains(t, prompt, "--- /dev/null")
+	assert.NotContains(t, prompt, search)
+
+	prompt = renderAuthorEditBlockInitialDevStepPrompt(dCtx, common.EditFormatWholeFile, "some code", "some requirements", "plan", "step")
+	assert.Contains(t, prompt, search)
+	assert.Contains(t, prompt, wholeFile)
+	assert.Contains(t, prompt, endWholeFile)
+	assert.Contains(t, prompt, "up to 300 lines")
+}
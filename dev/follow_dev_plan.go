@@ -9,6 +9,7 @@ import (
 	"sidekick/env"
 	"sidekick/fflag"
 	"sidekick/llm"
+	"slices"
 	"strings"
 
 	"github.com/rs/zerolog/log"
@@ -26,8 +27,20 @@ type DevStepExecution struct {
 	// not sure about this, but maybe helpful if the "complete" boolean on
 	// previous plan steps is not enough context for the next steps
 	ExecutionSummary string
+	// Checkpoint records the state of the repo right after the step completed,
+	// so that the user can roll back to it. Nil if the step isn't complete or
+	// ran before checkpoints existed.
+	Checkpoint *git.Checkpoint `json:",omitempty"`
 }
 
+// maxCommitHookAttempts limits how many times a step is redone because git
+// hooks rejected its checkpoint commit
+const maxCommitHookAttempts = 3
+
+// ErrRollbackToCheckpoint stops the current step when the user asks to roll
+// back to an earlier step's checkpoint
+var ErrRollbackToCheckpoint = errors.New("rollback to checkpoint requested")
+
 type DevStepResult struct {
 	Successful  bool
 	Summary     string
@@ -84,6 +97,10 @@ type FollowDevPlanInput struct {
 	EnvContainer env.EnvContainer
 	Requirements string
 	DevPlan      *DevPlan
+
+	// PlanExecution resumes an earlier execution of the plan from its first
+	// incomplete step, eg after rolling back to a checkpoint
+	PlanExecution *DevPlanExecution
 }
 
 // NOTE this is not yet used, but will be used in the future
@@ -99,11 +116,46 @@ func followDevPlanSubflow(dCtx DevContext, input FollowDevPlanInput) (DevPlanExe
 		Plan:           plan,
 		StepExecutions: initializeStepExecutions(plan.Steps),
 	}
+	startIndex := 0
+	if input.PlanExecution != nil {
+		planExecution = *input.PlanExecution
+		for startIndex < len(planExecution.StepExecutions) && planExecution.StepExecutions[startIndex].Complete {
+			startIndex++
+		}
+	}
+
+	checkpointVersion := workflow.GetVersion(dCtx, "step-checkpoints", workflow.DefaultVersion, 1)
+	useCheckpoints := checkpointVersion >= 1
+	if useCheckpoints && workflow.GetVersion(dCtx, "worktree-only-checkpoints", workflow.DefaultVersion, 1) >= 1 {
+		// checkpoints stage everything and rollbacks discard untracked files,
+		// which is only safe in a worktree sidekick owns, not the user's own
+		// checkout
+		useCheckpoints = dCtx.EnvContainer.Env.GetType() == env.EnvTypeLocalGitWorktree
+	}
+
+	// feedback from git hooks that rejected the last step's checkpoint commit
+	commitHookFeedback := ""
+	commitHookAttempts := 0
 
 	// XXX this loop does not allow for goto to work, so let's adjust so we get
 	// the next dev step based on the current plan execution + last result
-	for i, step := range plan.Steps {
-		result, err := completeDevStep(dCtx, input.Requirements, planExecution, step)
+	for i := startIndex; i < len(plan.Steps); i++ {
+		step := plan.Steps[i]
+		stepToComplete := step
+		if commitHookFeedback != "" {
+			stepToComplete.Definition += "\n\n" + commitHookFeedback
+		}
+		result, err := completeDevStep(dCtx, input.Requirements, planExecution, stepToComplete)
+		if checkpointVersion >= 1 && errors.Is(err, ErrRollbackToCheckpoint) {
+			targetIndex := pendingRollbackTargetIndex(dCtx, planExecution, step)
+			if err := rollbackToStepCheckpoint(dCtx, &planExecution, targetIndex); err != nil {
+				return planExecution, err
+			}
+			// resume from the step after the restored checkpoint
+			i = targetIndex
+			continue
+		}
+
 		planExecution.StepExecutions[i].Complete = result.Successful
 		planExecution.StepExecutions[i].ExecutionSummary = result.Summary
 
@@ -113,11 +165,144 @@ func followDevPlanSubflow(dCtx DevContext, input FollowDevPlanInput) (DevPlanExe
 		if !result.Successful {
 			return planExecution, fmt.Errorf("step %s was not successful: %s", step.StepNumber, result.Summary)
 		}
+
+		if useCheckpoints {
+			checkpoint, err := createStepCheckpoint(dCtx, step)
+			if git.IsCommitHookFailure(err) && commitHookAttempts < maxCommitHookAttempts {
+				if v := workflow.GetVersion(dCtx, "checkpoint-commit-hook-feedback", workflow.DefaultVersion, 1); v >= 1 {
+					// redo the step, so the hook's complaints get fixed
+					commitHookAttempts++
+					commitHookFeedback = fmt.Sprintf("The changes for this step were already made, but committing them failed because of the repository's git hooks. Fix the problems the hooks reported, so that the changes can be committed:\n\n%v", err)
+					i--
+					continue
+				}
+			}
+			if err != nil {
+				return planExecution, err
+			}
+			planExecution.StepExecutions[i].Checkpoint = &checkpoint
+		}
+		commitHookFeedback = ""
+		commitHookAttempts = 0
 	}
 
 	return planExecution, nil
 }
 
+// createStepCheckpoint records the repo state after a step. In a git worktree
+// the checkpoint is a commit on the worktree's branch, so the branch history
+// mirrors the plan. Flows that started before checkpoints were limited to
+// worktrees may still checkpoint other envs, where it's only referenced by
+// the checkpoint ref, to avoid committing onto the user's own branch.
+func createStepCheckpoint(dCtx DevContext, step DevStep) (git.Checkpoint, error) {
+	flowId := workflow.GetInfo(dCtx).WorkflowExecution.ID
+	actionCtx := dCtx.NewActionContext("checkpoint")
+	actionCtx.ActionParams = map[string]any{
+		"stepNumber": step.StepNumber,
+		"stepTitle":  step.Title,
+	}
+	return Track(actionCtx, func(flowAction domain.FlowAction) (git.Checkpoint, error) {
+		var checkpoint git.Checkpoint
+		err := workflow.ExecuteActivity(dCtx, git.CreateCheckpointActivity, *dCtx.EnvContainer, git.CreateCheckpointParams{
+			Ref:     git.CheckpointRef(flowId, step.StepNumber),
+			Message: checkpointMessage(step),
+			Commit:  dCtx.EnvContainer.Env.GetType() == env.EnvTypeLocalGitWorktree,
+			Config:  dCtx.RepoConfig.Commit,
+		}).Get(dCtx, &checkpoint)
+		if err != nil {
+			return checkpoint, fmt.Errorf("failed to create checkpoint for step %s: %w", step.StepNumber, err)
+		}
+		return checkpoint, nil
+	})
+}
+
+func checkpointMessage(step DevStep) string {
+	subject := step.Title
+	if step.StepNumber != "" {
+		subject = fmt.Sprintf("Step %s: %s", step.StepNumber, step.Title)
+	}
+	return strings.TrimSpace(subject + "\n\n" + step.Definition)
+}
+
+// rollbackTargetIndex returns the index of the step execution whose checkpoint
+// is to be restored, or -1 if the given step number doesn't belong to a
+// checkpointed step preceding the current one
+func rollbackTargetIndex(planExecution DevPlanExecution, currentStep DevStep, stepNumber string) int {
+	targetIndex := -1
+	for i, stepExecution := range planExecution.StepExecutions {
+		if stepExecution.DevStep.StepNumber == currentStep.StepNumber {
+			return targetIndex
+		}
+		if stepExecution.DevStep.StepNumber == stepNumber && stepExecution.Checkpoint != nil {
+			targetIndex = i
+		}
+	}
+	return -1
+}
+
+// completedPlanRollbackTargetIndex returns the index of the step execution
+// whose checkpoint is to be restored once the whole plan was followed, or -1
+// if the given step number doesn't belong to a checkpointed step
+func completedPlanRollbackTargetIndex(planExecution DevPlanExecution, stepNumber string) int {
+	for i, stepExecution := range planExecution.StepExecutions {
+		if stepExecution.DevStep.StepNumber == stepNumber && stepExecution.Checkpoint != nil {
+			return i
+		}
+	}
+	return -1
+}
+
+// pendingRollbackTargetIndex returns the index of the step execution whose
+// checkpoint the pending rollback restores, or -1 if it can't be honoured.
+// Steps outside the plan, eg the final test-fixing step, only run once the
+// plan was followed, so they can roll back to any step's checkpoint.
+func pendingRollbackTargetIndex(dCtx DevContext, planExecution DevPlanExecution, currentStep DevStep) int {
+	stepNumber := dCtx.GlobalState.GetPendingRollbackStepNumber()
+	inPlan := slices.ContainsFunc(planExecution.StepExecutions, func(stepExecution DevStepExecution) bool {
+		return stepExecution.DevStep.StepNumber == currentStep.StepNumber
+	})
+	if !inPlan && workflow.GetVersion(dCtx, "rollback-after-plan", workflow.DefaultVersion, 1) >= 1 {
+		return completedPlanRollbackTargetIndex(planExecution, stepNumber)
+	}
+	return rollbackTargetIndex(planExecution, currentStep, stepNumber)
+}
+
+// rollbackToStepCheckpoint consumes the pending rollback, restores the
+// checkpoint of the step execution at the target index and marks all steps
+// after it as incomplete again
+func rollbackToStepCheckpoint(dCtx DevContext, planExecution *DevPlanExecution, targetIndex int) error {
+	stepNumber := dCtx.GlobalState.GetPendingRollbackStepNumber()
+	dCtx.GlobalState.ConsumePendingUserAction()
+	if targetIndex < 0 {
+		return fmt.Errorf("no checkpoint to roll back to for step %s", stepNumber)
+	}
+	checkpoint := *planExecution.StepExecutions[targetIndex].Checkpoint
+
+	actionCtx := dCtx.NewActionContext("rollback_to_checkpoint")
+	actionCtx.ActionParams = map[string]any{
+		"stepNumber": stepNumber,
+		"ref":        checkpoint.Ref,
+	}
+	_, err := Track(actionCtx, func(flowAction domain.FlowAction) (git.Checkpoint, error) {
+		var restored git.Checkpoint
+		err := workflow.ExecuteActivity(dCtx, git.RollbackToCheckpointActivity, *dCtx.EnvContainer, git.RollbackToCheckpointParams{
+			Ref:         checkpoint.Ref,
+			ResetBranch: dCtx.EnvContainer.Env.GetType() == env.EnvTypeLocalGitWorktree,
+		}).Get(dCtx, &restored)
+		return restored, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to roll back to checkpoint for step %s: %w", stepNumber, err)
+	}
+
+	for i := targetIndex + 1; i < len(planExecution.StepExecutions); i++ {
+		planExecution.StepExecutions[i].Complete = false
+		planExecution.StepExecutions[i].ExecutionSummary = ""
+		planExecution.StepExecutions[i].Checkpoint = nil
+	}
+	return nil
+}
+
 func initializeStepExecutions(steps []DevStep) []DevStepExecution {
 	stepExecutions := make([]DevStepExecution, len(steps))
 
@@ -219,7 +404,7 @@ func completeDevStepSubflow(dCtx DevContext, requirements string, planExecution
 			}
 
 			// Run tests (TODO: replace this with using the latest DevStepResult)
-			testResult, err := RunTests(dCtx, dCtx.RepoConfig.TestCommands)
+			testResult, err := runTestsForChanges(dCtx, selectTestCommands)
 			if err != nil {
 				return result, fmt.Errorf("failed to run tests: %v", err)
 			}
@@ -243,6 +428,12 @@ func completeDevStepSubflow(dCtx DevContext, requirements string, planExecution
 
 		// Step 2: execute step
 		err = performStep(dCtx, modelConfig, contextSizeExtension, chatHistory, promptInfo, step, planExecution)
+		for errors.Is(err, PendingActionError) && isUpdateFromBaseBranchPending(dCtx) {
+			promptInfo, err = handleUpdateFromBaseBranchAction(dCtx, requirements)
+			if err == nil {
+				err = performStep(dCtx, modelConfig, contextSizeExtension, chatHistory, promptInfo, step, planExecution)
+			}
+		}
 		if err != nil && !errors.Is(err, PendingActionError) {
 			log.Warn().Err(err).Msg("Error executing step")
 			// TODO: on repeated overloaded_error from anthropic, we want to
@@ -264,6 +455,13 @@ func completeDevStepSubflow(dCtx DevContext, requirements string, planExecution
 			if action != nil && *action == UserActionGoNext {
 				dCtx.GlobalState.ConsumePendingUserAction()
 				executeNormalStepEvaluation = false
+			} else if action != nil && *action == UserActionRollbackToCheckpoint {
+				// rollbacks are only possible to checkpoints of earlier steps
+				// in the plan, otherwise the request is dropped
+				if pendingRollbackTargetIndex(dCtx, planExecution, step) >= 0 {
+					return result, ErrRollbackToCheckpoint
+				}
+				dCtx.GlobalState.ConsumePendingUserAction()
 			}
 		}
 		if executeNormalStepEvaluation {
@@ -306,7 +504,7 @@ func completeDevStepSubflow(dCtx DevContext, requirements string, planExecution
 
 		if fflag.IsEnabled(dCtx, fflag.CheckEdits) {
 			// TODO use an LLM to write a better commit message
-			err = git.GitCommit(dCtx.ExecContext, fmt.Sprintf("%s\n\n%s", step.Title, step.Definition))
+			err = git.GitCommit(dCtx.ExecContext, fmt.Sprintf("%s\n\n%s", step.Title, step.Definition), dCtx.RepoConfig.Commit)
 			if err != nil {
 				return result, fmt.Errorf("failed to git commit: %w", err)
 			}
@@ -326,10 +524,21 @@ func checkIfDevStepCompleted(dCtx DevContext, overallRequirements string, step D
 	switch step.Type {
 	case "edit":
 		// Pass a git diff of the repo + test results to the llm and ask if it looks good
-		testResult, err := RunTests(dCtx, dCtx.RepoConfig.TestCommands)
+		testResult, err := runTestsForChanges(dCtx, selectTestCommands)
 		if err != nil {
 			return result, fmt.Errorf("failed to run tests: %v", err)
 		}
+		// the step's changes are committed once it's complete, so they must
+		// pass the pre-commit hook first
+		hookResult, err := runPreCommitHookCheck(dCtx)
+		if err != nil {
+			return result, err
+		}
+		if !hookResult.TestsPassed {
+			result.Successful = false
+			result.Summary = result.Summary + "\n" + testResult.Output + "\n" + hookResult.Output
+			return result, nil
+		}
 		fulfillment, err := CheckWorkMeetsCriteria(dCtx, CheckWorkInfo{
 			CodeContext:   "", // TODO providing the code context will help with checking for criteria fulfillment
 			Requirements:  overallRequirements,
@@ -0,0 +1,49 @@
+package dev
+
+import (
+	"sidekick/coding/git"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollbackTargetIndex(t *testing.T) {
+	t.Parallel()
+	steps := []DevStep{{StepNumber: "1"}, {StepNumber: "2"}, {StepNumber: "3"}}
+	planExecution := DevPlanExecution{StepExecutions: initializeStepExecutions(steps)}
+	planExecution.StepExecutions[0].Checkpoint = &git.Checkpoint{Ref: git.CheckpointRef("flow", "1")}
+	planExecution.StepExecutions[1].Checkpoint = &git.Checkpoint{Ref: git.CheckpointRef("flow", "2")}
+
+	assert.Equal(t, 0, rollbackTargetIndex(planExecution, steps[2], "1"))
+	assert.Equal(t, 1, rollbackTargetIndex(planExecution, steps[2], "2"))
+	assert.Equal(t, 0, rollbackTargetIndex(planExecution, steps[1], "1"))
+
+	// only checkpoints of earlier steps can be rolled back to
+	assert.Equal(t, -1, rollbackTargetIndex(planExecution, steps[1], "2"))
+	assert.Equal(t, -1, rollbackTargetIndex(planExecution, steps[0], "1"))
+	assert.Equal(t, -1, rollbackTargetIndex(planExecution, steps[2], "3"))
+	assert.Equal(t, -1, rollbackTargetIndex(planExecution, steps[2], "4"))
+
+	// steps outside the plan, eg the final test-fixing step, can't roll back
+	assert.Equal(t, -1, rollbackTargetIndex(planExecution, DevStep{Title: "Ensure Tests Pass"}, "1"))
+}
+
+func TestCompletedPlanRollbackTargetIndex(t *testing.T) {
+	t.Parallel()
+	steps := []DevStep{{StepNumber: "1"}, {StepNumber: "2"}, {StepNumber: "3"}}
+	planExecution := DevPlanExecution{StepExecutions: initializeStepExecutions(steps)}
+	planExecution.StepExecutions[0].Checkpoint = &git.Checkpoint{Ref: git.CheckpointRef("flow", "1")}
+	planExecution.StepExecutions[2].Checkpoint = &git.Checkpoint{Ref: git.CheckpointRef("flow", "3")}
+
+	assert.Equal(t, 0, completedPlanRollbackTargetIndex(planExecution, "1"))
+	// the last step's checkpoint discards changes made after the plan
+	assert.Equal(t, 2, completedPlanRollbackTargetIndex(planExecution, "3"))
+	assert.Equal(t, -1, completedPlanRollbackTargetIndex(planExecution, "2"))
+	assert.Equal(t, -1, completedPlanRollbackTargetIndex(planExecution, "4"))
+}
+
+func TestCheckpointMessage(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "Step 2: Add parser\n\nAdd a parser for the config file.", checkpointMessage(DevStep{StepNumber: "2", Title: "Add parser", Definition: "Add a parser for the config file."}))
+	assert.Equal(t, "Add parser", checkpointMessage(DevStep{Title: "Add parser"}))
+}
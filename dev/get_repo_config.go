@@ -2,21 +2,41 @@ package dev
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sidekick/common"
 	"sidekick/env"
 	"sidekick/flow_action"
 
 	"github.com/BurntSushi/toml"
+	"github.com/rs/zerolog/log"
 	"go.temporal.io/sdk/workflow"
 )
 
-// GetRepoConfigActivity reads the side.toml file and returns a RepoConfig object
+// GetRepoConfigActivity reads the side.toml file and returns a RepoConfig object,
+// including the sub-projects defined by side.toml files nested in the repo
 // TODO /gen define GetRepoConfigActivityInput struct that has EnvContainer
 // inside it. write tests for get coding config activity too.
 func GetRepoConfigActivity(envContainer env.EnvContainer) (common.RepoConfig, error) {
-	data, err := os.ReadFile(filepath.Join(envContainer.Env.GetWorkingDirectory(), "side.toml"))
+	workingDir := envContainer.Env.GetWorkingDirectory()
+	config, err := readRepoConfigFile(workingDir)
+	if err != nil {
+		return common.RepoConfig{}, err
+	}
+
+	subProjects, err := findSubProjects(workingDir)
+	if err != nil {
+		return common.RepoConfig{}, err
+	}
+	config.SubProjects = subProjects
+
+	return config, nil
+}
+
+func readRepoConfigFile(dir string) (common.RepoConfig, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "side.toml"))
 	if err != nil {
 		return common.RepoConfig{}, fmt.Errorf("failed to read TOML file: %v", err)
 	}
@@ -32,7 +52,7 @@ func GetRepoConfigActivity(envContainer env.EnvContainer) (common.RepoConfig, er
 	// If the [edit_code] section is missing, Hints and HintsPath will be zero-valued (empty strings),
 	// so the condition correctly handles this case without the nil check.
 	if config.EditCode.Hints == "" && config.EditCode.HintsPath != "" {
-		hintsFilePath := filepath.Join(envContainer.Env.GetWorkingDirectory(), config.EditCode.HintsPath)
+		hintsFilePath := filepath.Join(dir, config.EditCode.HintsPath)
 		hintsData, err := os.ReadFile(hintsFilePath)
 		if err != nil {
 			return common.RepoConfig{}, fmt.Errorf("failed to read hints file specified in side.toml (hints_path: %q): %w", config.EditCode.HintsPath, err)
@@ -43,6 +63,64 @@ func GetRepoConfigActivity(envContainer env.EnvContainer) (common.RepoConfig, er
 	return config, nil
 }
 
+// findSubProjects loads the side.toml files nested in the repo, skipping
+// ignored directories and any that fail to load
+func findSubProjects(workingDir string) ([]common.SubProject, error) {
+	var subProjects []common.SubProject
+	err := common.WalkCodeDirectory(workingDir, func(filePath string, entry fs.DirEntry) error {
+		if entry.IsDir() || entry.Name() != "side.toml" {
+			return nil
+		}
+		dir := filepath.Dir(filePath)
+		relativeDir, err := filepath.Rel(workingDir, dir)
+		if err != nil {
+			return err
+		}
+		if relativeDir == "." {
+			return nil
+		}
+
+		// a broken sub-project config shouldn't keep the rest of the repo
+		// from being worked on
+		nestedConfig, err := readRepoConfigFile(dir)
+		if err != nil {
+			log.Warn().Err(err).Str("subProject", relativeDir).Msg("Skipping sub-project with an invalid side.toml")
+			return nil
+		}
+		subProjectPath := filepath.ToSlash(relativeDir)
+		subProjects = append(subProjects, common.SubProject{
+			Path: subProjectPath,
+			Config: common.RepoConfig{
+				CheckCommands:           resolveWorkingDirs(subProjectPath, nestedConfig.CheckCommands),
+				AutofixCommands:         resolveWorkingDirs(subProjectPath, nestedConfig.AutofixCommands),
+				TestCommands:            resolveWorkingDirs(subProjectPath, nestedConfig.TestCommands),
+				IntegrationTestCommands: resolveWorkingDirs(subProjectPath, nestedConfig.IntegrationTestCommands),
+				Mission:                 nestedConfig.Mission,
+				EditCode:                nestedConfig.EditCode,
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find nested side.toml files: %w", err)
+	}
+	return subProjects, nil
+}
+
+// resolveWorkingDirs makes the working directories of a sub-project's
+// commands, which default to the sub-project itself, relative to the repo root
+func resolveWorkingDirs(subProjectPath string, commands []common.CommandConfig) []common.CommandConfig {
+	if len(commands) == 0 {
+		return nil
+	}
+	resolved := make([]common.CommandConfig, len(commands))
+	for i, command := range commands {
+		command.WorkingDir = path.Join(subProjectPath, filepath.ToSlash(command.WorkingDir))
+		resolved[i] = command
+	}
+	return resolved
+}
+
 func GetRepoConfig(eCtx flow_action.ExecContext) (common.RepoConfig, error) {
 	var repoConfig common.RepoConfig
 	err := workflow.ExecuteActivity(eCtx, GetRepoConfigActivity, eCtx.EnvContainer).Get(eCtx, &repoConfig)
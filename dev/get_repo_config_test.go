@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"sidekick/common"
 	"sidekick/env"
 	"testing"
 
@@ -156,6 +157,76 @@ mission = "Test mission"
 		assert.Contains(t, err.Error(), "failed to unmarshal TOML data")
 	})
 
+	t.Run("Discovers nested side.toml files as sub-projects", func(t *testing.T) {
+		envContainer := setupTestEnv(t, `
+mission = "A monorepo"
+
+[[test_commands]]
+command = "make test"
+`, "", "")
+		workingDir := envContainer.Env.GetWorkingDirectory()
+		writeFile := func(relativePath, content string) {
+			path := filepath.Join(workingDir, relativePath)
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+			require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		}
+		writeFile("services/api/side.toml", `
+mission = "The API"
+max_iterations = 3
+
+[[test_commands]]
+command = "go test ./..."
+
+[[check_commands]]
+working_dir = "cmd"
+command = "go vet ./..."
+
+[edit_code]
+hints_path = "hints.md"
+`)
+		writeFile("services/api/hints.md", "API hints")
+		writeFile("web/side.toml", `
+[[autofix_commands]]
+command = "npx eslint --fix {file}"
+`)
+		writeFile(".gitignore", "ignored/\n")
+		writeFile("ignored/side.toml", "this is not valid toml [")
+
+		config, err := GetRepoConfigActivity(envContainer)
+
+		require.NoError(t, err)
+		assert.Equal(t, "A monorepo", config.Mission)
+		require.Len(t, config.SubProjects, 2)
+
+		api := config.SubProjects[0]
+		assert.Equal(t, "services/api", api.Path)
+		assert.Equal(t, "The API", api.Config.Mission)
+		assert.Equal(t, "API hints", api.Config.EditCode.Hints)
+		assert.Zero(t, api.Config.MaxIterations, "only some settings can be set per sub-project")
+		assert.Equal(t, []common.CommandConfig{{WorkingDir: "services/api", Command: "go test ./..."}}, api.Config.TestCommands)
+		assert.Equal(t, []common.CommandConfig{{WorkingDir: "services/api/cmd", Command: "go vet ./..."}}, api.Config.CheckCommands)
+
+		web := config.SubProjects[1]
+		assert.Equal(t, "web", web.Path)
+		assert.Equal(t, []common.CommandConfig{{WorkingDir: "web", Command: "npx eslint --fix {file}"}}, web.Config.AutofixCommands)
+		assert.Empty(t, web.Config.TestCommands)
+	})
+
+	t.Run("Skips invalid nested side.toml", func(t *testing.T) {
+		envContainer := setupTestEnv(t, "", "", "")
+		workingDir := envContainer.Env.GetWorkingDirectory()
+		require.NoError(t, os.MkdirAll(filepath.Join(workingDir, "api"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(workingDir, "api", "side.toml"), []byte("this is not valid toml ["), 0644))
+		require.NoError(t, os.MkdirAll(filepath.Join(workingDir, "web"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(workingDir, "web", "side.toml"), []byte(`mission = "The web app"`), 0644))
+
+		config, err := GetRepoConfigActivity(envContainer)
+
+		require.NoError(t, err)
+		require.Len(t, config.SubProjects, 1)
+		assert.Equal(t, "web", config.SubProjects[0].Path)
+	})
+
 	t.Run("Handles missing side.toml file", func(t *testing.T) {
 		tempDir := t.TempDir()
 		mock := &mockEnv{workingDir: tempDir}
@@ -0,0 +1,91 @@
+package dev
+
+import (
+	"fmt"
+	"sidekick/coding/git"
+	"sidekick/llm"
+
+	"github.com/invopop/jsonschema"
+	"go.temporal.io/sdk/workflow"
+)
+
+type GitLogToolParams struct {
+	FilePath     string `json:"file_path,omitempty" jsonschema:"description=Optional file or directory path\\, relative to the repo root\\, to restrict the log to."`
+	StartLine    int    `json:"start_line,omitempty" jsonschema:"description=Optional first line of a range within file_path. Only commits changing that range are listed\\, along with their changes to it."`
+	EndLine      int    `json:"end_line,omitempty" jsonschema:"description=Optional last line of the range within file_path."`
+	FunctionName string `json:"function_name,omitempty" jsonschema:"description=Optional name of a function within file_path. Only commits changing that function are listed\\, along with their changes to it. Ignored if a line range is given."`
+	MaxCount     int    `json:"max_count,omitempty" jsonschema:"description=Maximum number of commits to list. Defaults to 10."`
+}
+
+type GitBlameToolParams struct {
+	FilePath  string `json:"file_path" jsonschema:"description=The file path\\, relative to the repo root."`
+	StartLine int    `json:"start_line" jsonschema:"description=The first line to blame."`
+	EndLine   int    `json:"end_line" jsonschema:"description=The last line to blame."`
+}
+
+type GitShowToolParams struct {
+	Revision string `json:"revision" jsonschema:"description=The commit to show\\, eg a commit hash from git_log or git_blame output."`
+	FilePath string `json:"file_path,omitempty" jsonschema:"description=Optional file or directory path to restrict the shown changes to."`
+}
+
+var gitLogTool = llm.Tool{
+	Name:        "git_log",
+	Description: "Lists recent commits with their messages, optionally only those changing a given file, line range or function. Useful for understanding why code is the way it is.",
+	Parameters:  (&jsonschema.Reflector{DoNotReference: true}).Reflect(&GitLogToolParams{}),
+}
+
+var gitBlameTool = llm.Tool{
+	Name:        "git_blame",
+	Description: "Shows the commit, author and date that last changed each line in a range of a file. Follow up with git_show to see the full commit.",
+	Parameters:  (&jsonschema.Reflector{DoNotReference: true}).Reflect(&GitBlameToolParams{}),
+}
+
+var gitShowTool = llm.Tool{
+	Name:        "git_show",
+	Description: "Shows a commit's message, the files it changed and its diff. Long diffs are truncated, so restrict to a file path when only part of a large commit matters.",
+	Parameters:  (&jsonschema.Reflector{DoNotReference: true}).Reflect(&GitShowToolParams{}),
+}
+
+var gitHistoryTools = []*llm.Tool{&gitLogTool, &gitBlameTool, &gitShowTool}
+
+// GitLog returns git errors, eg for a bad path, as the response so the model
+// can correct its request
+func GitLog(dCtx DevContext, params GitLogToolParams) (string, error) {
+	var output string
+	err := workflow.ExecuteActivity(dCtx, git.GitLogActivity, *dCtx.EnvContainer, git.GitLogParams{
+		FilePath:     params.FilePath,
+		StartLine:    params.StartLine,
+		EndLine:      params.EndLine,
+		FunctionName: params.FunctionName,
+		MaxCount:     params.MaxCount,
+	}).Get(dCtx, &output)
+	if err != nil {
+		return fmt.Sprintf("git_log failed: %v", err), nil
+	}
+	return output, nil
+}
+
+func GitBlame(dCtx DevContext, params GitBlameToolParams) (string, error) {
+	var output string
+	err := workflow.ExecuteActivity(dCtx, git.GitBlameActivity, *dCtx.EnvContainer, git.GitBlameParams{
+		FilePath:  params.FilePath,
+		StartLine: params.StartLine,
+		EndLine:   params.EndLine,
+	}).Get(dCtx, &output)
+	if err != nil {
+		return fmt.Sprintf("git_blame failed: %v", err), nil
+	}
+	return output, nil
+}
+
+func GitShow(dCtx DevContext, params GitShowToolParams) (string, error) {
+	var output string
+	err := workflow.ExecuteActivity(dCtx, git.GitShowActivity, *dCtx.EnvContainer, git.GitShowParams{
+		Revision: params.Revision,
+		FilePath: params.FilePath,
+	}).Get(dCtx, &output)
+	if err != nil {
+		return fmt.Sprintf("git_show failed: %v", err), nil
+	}
+	return output, nil
+}
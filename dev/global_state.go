@@ -13,6 +13,14 @@ type UserActionType string
 const (
 	// UserActionGoNext represents the action to go to the next step.
 	UserActionGoNext UserActionType = "go_next_step"
+
+	// UserActionRollbackToCheckpoint represents the action to restore the
+	// checkpoint of a completed plan step and resume from the step after it.
+	UserActionRollbackToCheckpoint UserActionType = "rollback_to_checkpoint"
+
+	// UserActionUpdateFromBaseBranch represents the action to bring the latest
+	// changes from the base branch into the worktree's branch.
+	UserActionUpdateFromBaseBranch UserActionType = "update_from_base_branch"
 )
 
 type GlobalState struct {
@@ -20,6 +28,9 @@ type GlobalState struct {
 	cancelQueue       []func()
 	mu                sync.Mutex
 	PendingUserAction *UserActionType
+	// PendingRollbackStepNumber is the step whose checkpoint to restore when
+	// the pending action is UserActionRollbackToCheckpoint
+	PendingRollbackStepNumber string
 }
 
 func (g *GlobalState) AddCancelFunc(cancel func()) {
@@ -43,6 +54,7 @@ func (g *GlobalState) SetUserAction(action UserActionType) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 	g.PendingUserAction = &action
+	g.PendingRollbackStepNumber = ""
 }
 
 // GetPendingUserAction returns the pointer to the current PendingUserAction.
@@ -53,9 +65,27 @@ func (g *GlobalState) GetPendingUserAction() *UserActionType {
 	return g.PendingUserAction
 }
 
+// SetRollbackToCheckpoint sets a pending rollback to the given step's
+// checkpoint, overwriting any other pending action.
+func (g *GlobalState) SetRollbackToCheckpoint(stepNumber string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	action := UserActionRollbackToCheckpoint
+	g.PendingUserAction = &action
+	g.PendingRollbackStepNumber = stepNumber
+}
+
+// GetPendingRollbackStepNumber returns the step number of a pending rollback.
+func (g *GlobalState) GetPendingRollbackStepNumber() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.PendingRollbackStepNumber
+}
+
 // ConsumePendingUserAction sets PendingUserAction to nil.
 func (g *GlobalState) ConsumePendingUserAction() {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 	g.PendingUserAction = nil
+	g.PendingRollbackStepNumber = ""
 }
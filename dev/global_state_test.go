@@ -76,6 +76,33 @@ func TestGlobalState_UserActions(t *testing.T) {
 			t.Errorf("Expected no pending action after consuming nil, got %v", *action)
 		}
 	})
+
+	t.Run("rollback to checkpoint", func(t *testing.T) {
+		gs := &GlobalState{}
+		gs.SetRollbackToCheckpoint("2")
+
+		action := gs.GetPendingUserAction()
+		if action == nil || *action != UserActionRollbackToCheckpoint {
+			t.Fatalf("Expected action %v, got %v", UserActionRollbackToCheckpoint, action)
+		}
+		if stepNumber := gs.GetPendingRollbackStepNumber(); stepNumber != "2" {
+			t.Errorf("Expected rollback step number 2, got %q", stepNumber)
+		}
+
+		gs.SetUserAction(UserActionGoNext)
+		if stepNumber := gs.GetPendingRollbackStepNumber(); stepNumber != "" {
+			t.Errorf("Expected rollback step number to be cleared by another action, got %q", stepNumber)
+		}
+
+		gs.SetRollbackToCheckpoint("1")
+		gs.ConsumePendingUserAction()
+		if action := gs.GetPendingUserAction(); action != nil {
+			t.Errorf("Expected no pending action after consumption, got %v", *action)
+		}
+		if stepNumber := gs.GetPendingRollbackStepNumber(); stepNumber != "" {
+			t.Errorf("Expected rollback step number to be cleared after consumption, got %q", stepNumber)
+		}
+	})
 }
 
 func TestGlobalState_UserActions_ConcurrentAccess(t *testing.T) {
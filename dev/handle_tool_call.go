@@ -73,8 +73,34 @@ func handleToolCall(dCtx DevContext, toolCall llm.ToolCall) (toolCallResult Tool
 		case bulkSearchRepositoryTool.Name:
 			var bulkSearchRepositoryParams BulkSearchRepositoryParams
 			response, err = unmarshalAndInvoke(toolCall, &bulkSearchRepositoryParams, func() (string, error) {
+				bulkSearchRepositoryParams.Subdirectory = dCtx.SubProject
 				return BulkSearchRepository(dCtx, *dCtx.EnvContainer, bulkSearchRepositoryParams)
 			})
+		case structuralSearchTool.Name:
+			var structuralSearchParams StructuralSearchParams
+			response, err = unmarshalAndInvoke(toolCall, &structuralSearchParams, func() (string, error) {
+				return StructuralSearch(dCtx, structuralSearchParams)
+			})
+		case findReferencesTool.Name, goToImplementationTool.Name, getHoverTypeInfoTool.Name, renameSymbolTool.Name:
+			var symbolParams RenameSymbolParams
+			response, err = unmarshalAndInvoke(toolCall, &symbolParams, func() (string, error) {
+				return InvokeLSPTool(dCtx, toolCall.Name, symbolParams)
+			})
+		case gitLogTool.Name:
+			var gitLogParams GitLogToolParams
+			response, err = unmarshalAndInvoke(toolCall, &gitLogParams, func() (string, error) {
+				return GitLog(dCtx, gitLogParams)
+			})
+		case gitBlameTool.Name:
+			var gitBlameParams GitBlameToolParams
+			response, err = unmarshalAndInvoke(toolCall, &gitBlameParams, func() (string, error) {
+				return GitBlame(dCtx, gitBlameParams)
+			})
+		case gitShowTool.Name:
+			var gitShowParams GitShowToolParams
+			response, err = unmarshalAndInvoke(toolCall, &gitShowParams, func() (string, error) {
+				return GitShow(dCtx, gitShowParams)
+			})
 		case recordDevPlanTool.Name:
 			response, err = "recorded", nil
 		case runCommandTool.Name:
@@ -0,0 +1,251 @@
+package dev
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sidekick/coding"
+	"sidekick/coding/lsp"
+	"sidekick/env"
+	"sidekick/llm"
+	"sidekick/utils"
+	"sort"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+	"go.temporal.io/sdk/workflow"
+)
+
+// SymbolLocationParams identifies a symbol by where it's written in a file,
+// which is how language servers look up symbols
+type SymbolLocationParams struct {
+	FilePath   string `json:"file_path" jsonschema:"description=The path to the file\\, relative to the repo root\\, in which the symbol appears\\, eg: \"foo/bar/something.go\""`
+	SymbolText string `json:"symbol_text" jsonschema:"description=The name of the symbol exactly as it appears in the file\\, eg: \"SomeFunction\" or \"someVariable\""`
+	LineNumber int    `json:"line_number,omitempty" jsonschema:"description=Optional line number on which the symbol appears\\, to pick a specific occurrence. Defaults to the first occurrence in the file."`
+}
+
+type RenameSymbolParams struct {
+	SymbolLocationParams
+	NewName string `json:"new_name" jsonschema:"description=The new name for the symbol."`
+}
+
+var findReferencesTool = llm.Tool{
+	Name:        "find_references",
+	Description: "Uses the language server to find all references to a symbol across the repo, showing each with surrounding lines. Unlike searching, this only matches real references to that specific symbol. Only works for languages with language server support.",
+	Parameters:  (&jsonschema.Reflector{DoNotReference: true}).Reflect(&SymbolLocationParams{}),
+}
+
+var goToImplementationTool = llm.Tool{
+	Name:        "go_to_implementation",
+	Description: "Uses the language server to find implementations of an interface, abstract type or method, showing each with surrounding lines. Only works for languages with language server support.",
+	Parameters:  (&jsonschema.Reflector{DoNotReference: true}).Reflect(&SymbolLocationParams{}),
+}
+
+var getHoverTypeInfoTool = llm.Tool{
+	Name:        "get_hover_type_info",
+	Description: "Uses the language server to get the type or signature of a symbol, along with its documentation, as shown when hovering over it in an editor. Useful for finding the type of a variable or expression result. Only works for languages with language server support.",
+	Parameters:  (&jsonschema.Reflector{DoNotReference: true}).Reflect(&SymbolLocationParams{}),
+}
+
+var renameSymbolTool = llm.Tool{
+	Name:        "rename_symbol",
+	Description: "Uses the language server to rename a symbol and update all references to it across the repo. The change is applied immediately, so there is no need for edit blocks to do the rename. Only works for languages with language server support.",
+	Parameters:  (&jsonschema.Reflector{DoNotReference: true}).Reflect(&RenameSymbolParams{}),
+}
+
+// lspReadOnlyTools are safe to offer whenever the model is gathering context
+var lspReadOnlyTools = []*llm.Tool{&findReferencesTool, &goToImplementationTool, &getHoverTypeInfoTool}
+
+// maxLocationsShown limits the output of location-based tools, since popular
+// symbols can have a huge number of references
+const maxLocationsShown = 50
+
+// locationContextLines is the number of lines shown around each location
+const locationContextLines = 2
+
+type LSPToolActivityInput struct {
+	EnvContainer env.EnvContainer
+	ToolName     string
+	Params       RenameSymbolParams
+}
+
+func (p SymbolLocationParams) lspRange() *lsp.Range {
+	if p.LineNumber < 1 {
+		return nil
+	}
+	line := p.LineNumber - 1
+	return &lsp.Range{Start: lsp.Position{Line: line}, End: lsp.Position{Line: line}}
+}
+
+// InvokeLSPTool handles the language server tool calls. Failures, eg due to a
+// missing symbol, are returned as the response so the model can correct them.
+func InvokeLSPTool(dCtx DevContext, toolName string, params RenameSymbolParams) (string, error) {
+	input := LSPToolActivityInput{
+		EnvContainer: *dCtx.EnvContainer,
+		ToolName:     toolName,
+		Params:       params,
+	}
+	var da *DevActivities
+	var result string
+	err := workflow.ExecuteActivity(dCtx, da.LSPToolActivity, input).Get(dCtx, &result)
+	if err != nil {
+		// TODO strip the "activity error (type: ...) from the error message" before returning it upstream
+		return fmt.Sprintf("%s failed: %v", toolName, err), nil
+	}
+	return result, nil
+}
+
+func (da *DevActivities) LSPToolActivity(ctx context.Context, input LSPToolActivityInput) (string, error) {
+	params := input.Params
+	baseDir := input.EnvContainer.Env.GetWorkingDirectory()
+
+	switch input.ToolName {
+	case findReferencesTool.Name:
+		locations, err := da.LSPActivities.FindReferencesActivity(ctx, lsp.FindReferencesActivityInput{
+			EnvContainer:     input.EnvContainer,
+			RelativeFilePath: params.FilePath,
+			SymbolText:       params.SymbolText,
+			Range:            params.lspRange(),
+		})
+		if err != nil {
+			return "", err
+		}
+		if len(locations) == 0 {
+			return fmt.Sprintf("No references found for %s in %s", params.SymbolText, params.FilePath), nil
+		}
+		return fmt.Sprintf("References to %s:\n\n%s", params.SymbolText, formatLocations(baseDir, locations)), nil
+
+	case goToImplementationTool.Name:
+		locations, err := da.LSPActivities.FindImplementationsActivity(ctx, lsp.FindImplementationsActivityInput{
+			EnvContainer:     input.EnvContainer,
+			RelativeFilePath: params.FilePath,
+			SymbolText:       params.SymbolText,
+			Range:            params.lspRange(),
+		})
+		if err != nil {
+			return "", err
+		}
+		if len(locations) == 0 {
+			return fmt.Sprintf("No implementations found for %s in %s", params.SymbolText, params.FilePath), nil
+		}
+		return fmt.Sprintf("Implementations of %s:\n\n%s", params.SymbolText, formatLocations(baseDir, locations)), nil
+
+	case getHoverTypeInfoTool.Name:
+		hoverText, err := da.LSPActivities.HoverActivity(ctx, lsp.HoverActivityInput{
+			EnvContainer:     input.EnvContainer,
+			RelativeFilePath: params.FilePath,
+			SymbolText:       params.SymbolText,
+			Range:            params.lspRange(),
+		})
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(hoverText) == "" {
+			return fmt.Sprintf("No type info found for %s in %s", params.SymbolText, params.FilePath), nil
+		}
+		return fmt.Sprintf("Type info for %s in %s:\n\n%s", params.SymbolText, params.FilePath, hoverText), nil
+
+	case renameSymbolTool.Name:
+		if params.NewName == "" {
+			return "", fmt.Errorf("new_name is required")
+		}
+		workspaceEdit, err := da.LSPActivities.RenameSymbolActivity(ctx, lsp.RenameSymbolActivityInput{
+			EnvContainer:     input.EnvContainer,
+			RelativeFilePath: params.FilePath,
+			SymbolText:       params.SymbolText,
+			Range:            params.lspRange(),
+			NewName:          params.NewName,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		var locations []lsp.Location
+		documentChanges := workspaceEdit.TextDocumentEdits()
+		for _, documentChange := range documentChanges {
+			for _, edit := range documentChange.Edits {
+				locations = append(locations, lsp.Location{URI: documentChange.TextDocument.URI, Range: edit.Range})
+			}
+			// like applied edit blocks, staged so that restoring a later
+			// failed edit doesn't undo part of the rename
+			relativePath, err := relativePathFromURI(baseDir, documentChange.TextDocument.URI)
+			if err == nil {
+				err = gitAdd(input.EnvContainer, relativePath)
+			}
+			if err != nil {
+				return "", fmt.Errorf("renamed %s to %s, but failed to stage the changes: %v", params.SymbolText, params.NewName, err)
+			}
+		}
+		return fmt.Sprintf("Renamed %s to %s in %d file(s). Updated lines:\n\n%s", params.SymbolText, params.NewName, len(documentChanges), formatLocations(baseDir, locations)), nil
+
+	default:
+		return "", fmt.Errorf("unknown language server tool: %s", input.ToolName)
+	}
+}
+
+// formatLocations formats locations like retrieved code context, showing each
+// with surrounding lines. Nearby locations in the same file are merged.
+func formatLocations(baseDir string, locations []lsp.Location) string {
+	type lineRange struct{ start, end int } // 0-based, inclusive
+	rangesByFile := make(map[string][]lineRange)
+	var filePaths []string
+	shown := locations
+	if len(shown) > maxLocationsShown {
+		shown = shown[:maxLocationsShown]
+	}
+	for _, location := range shown {
+		u, err := url.Parse(location.URI)
+		if err != nil {
+			continue
+		}
+		if _, ok := rangesByFile[u.Path]; !ok {
+			filePaths = append(filePaths, u.Path)
+		}
+		rangesByFile[u.Path] = append(rangesByFile[u.Path], lineRange{
+			start: max(location.Range.Start.Line-locationContextLines, 0),
+			end:   location.Range.End.Line + locationContextLines,
+		})
+	}
+	sort.Strings(filePaths)
+
+	var results []string
+	for _, absolutePath := range filePaths {
+		displayPath := absolutePath
+		if relativePath, err := filepath.Rel(baseDir, absolutePath); err == nil && !strings.HasPrefix(relativePath, "..") {
+			displayPath = relativePath
+		}
+		contents, err := os.ReadFile(absolutePath)
+		if err != nil {
+			results = append(results, fmt.Sprintf("File: %s\nFailed to read file: %v", displayPath, err))
+			continue
+		}
+		lines := strings.Split(strings.TrimSuffix(string(contents), "\n"), "\n")
+		fence := coding.CodeFenceStartForLanguage(utils.InferLanguageNameFromFilePath(absolutePath))
+
+		ranges := rangesByFile[absolutePath]
+		sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+		var merged []lineRange
+		for _, r := range ranges {
+			r.end = min(r.end, len(lines)-1)
+			if len(merged) > 0 && r.start <= merged[len(merged)-1].end+1 {
+				merged[len(merged)-1].end = max(merged[len(merged)-1].end, r.end)
+			} else {
+				merged = append(merged, r)
+			}
+		}
+		for _, r := range merged {
+			if r.start > r.end {
+				continue
+			}
+			results = append(results, fmt.Sprintf("File: %s\nLines: %d-%d\n%s%s\n```", displayPath, r.start+1, r.end+1, fence, strings.Join(lines[r.start:r.end+1], "\n")))
+		}
+	}
+
+	output := strings.Join(results, "\n\n")
+	if len(locations) > maxLocationsShown {
+		output += fmt.Sprintf("\n\nOnly the first %d of %d locations are shown.", maxLocationsShown, len(locations))
+	}
+	return output
+}
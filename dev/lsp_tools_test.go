@@ -0,0 +1,114 @@
+package dev
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sidekick/coding/lsp"
+	"sidekick/env"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lineLocation(absolutePath string, line int) lsp.Location {
+	return lsp.Location{
+		URI: "file://" + absolutePath,
+		Range: lsp.Range{
+			Start: lsp.Position{Line: line, Character: 0},
+			End:   lsp.Position{Line: line, Character: 1},
+		},
+	}
+}
+
+func TestFormatLocations(t *testing.T) {
+	t.Parallel()
+	baseDir := t.TempDir()
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line%d", i))
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(baseDir, "pkg"), 0755))
+	aPath := filepath.Join(baseDir, "pkg", "a.go")
+	bPath := filepath.Join(baseDir, "b.py")
+	require.NoError(t, os.WriteFile(aPath, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+	require.NoError(t, os.WriteFile(bPath, []byte("x = 1\ny = x\n"), 0644))
+
+	t.Run("merges nearby locations and clamps to file bounds", func(t *testing.T) {
+		t.Parallel()
+		output := formatLocations(baseDir, []lsp.Location{
+			lineLocation(aPath, 18),
+			lineLocation(bPath, 1),
+			lineLocation(aPath, 3),
+			lineLocation(aPath, 5),
+		})
+		expected := "File: b.py\nLines: 1-2\n```python\nx = 1\ny = x\n```\n\n" +
+			"File: pkg/a.go\nLines: 2-8\n```go\nline2\nline3\nline4\nline5\nline6\nline7\nline8\n```\n\n" +
+			"File: pkg/a.go\nLines: 17-20\n```go\nline17\nline18\nline19\nline20\n```"
+		assert.Equal(t, expected, output)
+	})
+
+	t.Run("limits the number of locations", func(t *testing.T) {
+		t.Parallel()
+		var locations []lsp.Location
+		for i := 0; i < maxLocationsShown+5; i++ {
+			locations = append(locations, lineLocation(aPath, i%20))
+		}
+		output := formatLocations(baseDir, locations)
+		assert.True(t, strings.HasSuffix(output, fmt.Sprintf("Only the first %d of %d locations are shown.", maxLocationsShown, maxLocationsShown+5)))
+	})
+
+	t.Run("keeps paths outside the repo absolute", func(t *testing.T) {
+		t.Parallel()
+		outsidePath := filepath.Join(t.TempDir(), "c.go")
+		require.NoError(t, os.WriteFile(outsidePath, []byte("package c\n"), 0644))
+		output := formatLocations(baseDir, []lsp.Location{lineLocation(outsidePath, 0)})
+		assert.Equal(t, fmt.Sprintf("File: %s\nLines: 1-1\n```go\npackage c\n```", outsidePath), output)
+	})
+}
+
+func TestLSPToolActivity_findReferences(t *testing.T) {
+	t.Parallel()
+	repoDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "main.go"), []byte("package main\n\nfunc helper() int {\n\treturn 1\n}\n\nfunc main() {\n\t_ = helper()\n}\n"), 0644))
+
+	referenceURI := "file://" + filepath.Join(repoDir, "main.go")
+	da := &DevActivities{
+		LSPActivities: &lsp.LSPActivities{
+			LSPClientProvider: func(language string) lsp.LSPClient {
+				return lsp.MockLSPClient{
+					TextDocumentReferencesFunc: func(ctx context.Context, uri string, line int, character int) ([]lsp.Location, error) {
+						assert.Equal(t, 2, line)
+						return []lsp.Location{{
+							URI:   referenceURI,
+							Range: lsp.Range{Start: lsp.Position{Line: 7, Character: 5}, End: lsp.Position{Line: 7, Character: 11}},
+						}}, nil
+					},
+				}
+			},
+			InitializedClients: map[string]lsp.LSPClient{},
+		},
+	}
+
+	result, err := da.LSPToolActivity(context.Background(), LSPToolActivityInput{
+		EnvContainer: env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: repoDir}},
+		ToolName:     findReferencesTool.Name,
+		Params: RenameSymbolParams{SymbolLocationParams: SymbolLocationParams{
+			FilePath:   "main.go",
+			SymbolText: "helper",
+			LineNumber: 3,
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "References to helper:\n\nFile: main.go\nLines: 6-9\n```go\n\nfunc main() {\n\t_ = helper()\n}\n```", result)
+
+	_, err = da.LSPToolActivity(context.Background(), LSPToolActivityInput{
+		EnvContainer: env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: repoDir}},
+		ToolName:     renameSymbolTool.Name,
+		Params:       RenameSymbolParams{SymbolLocationParams: SymbolLocationParams{FilePath: "main.go", SymbolText: "helper"}},
+	})
+	assert.ErrorContains(t, err, "new_name is required")
+}
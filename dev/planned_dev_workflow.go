@@ -25,6 +25,7 @@ type PlannedDevOptions struct {
 	DetermineRequirements bool        `json:"determineRequirements"`
 	EnvType               env.EnvType `json:"envType,omitempty" default:"local"`
 	StartBranch           *string     `json:"startBranch,omitempty"` // Optional branch for git worktree env
+	SubProject            string      `json:"subProject,omitempty"`  // Optional sub-project path to scope the task to
 }
 
 var SideAppEnv = os.Getenv("SIDE_APP_ENV")
@@ -51,7 +52,7 @@ func PlannedDevWorkflow(ctx workflow.Context, input PlannedDevInput) (planExec D
 
 	ctx = utils.DefaultRetryCtx(ctx)
 
-	dCtx, err := SetupDevContext(ctx, input.WorkspaceId, input.RepoDir, string(input.EnvType), input.PlannedDevOptions.StartBranch, input.Requirements)
+	dCtx, err := SetupDevContext(ctx, input.WorkspaceId, input.RepoDir, string(input.EnvType), input.PlannedDevOptions.StartBranch, input.PlannedDevOptions.SubProject, input.Requirements)
 	if err != nil {
 		_ = signalWorkflowClosure(ctx, "failed")
 		return DevPlanExecution{}, fmt.Errorf("failed to setup dev context: %v", err)
@@ -87,31 +88,81 @@ func PlannedDevWorkflow(ctx workflow.Context, input PlannedDevInput) (planExec D
 		return DevPlanExecution{}, err
 	}
 
-	planExec, err = FollowDevPlan(dCtx, FollowDevPlanInput{
+	followInput := FollowDevPlanInput{
 		DevPlan:      devPlan,
 		WorkspaceId:  input.WorkspaceId,
 		EnvContainer: *dCtx.EnvContainer,
 		Requirements: input.Requirements,
-	})
+	}
+	rollbackVersion := workflow.GetVersion(ctx, "rollback-after-plan", workflow.DefaultVersion, 1)
+	for {
+		planExec, err = FollowDevPlan(dCtx, followInput)
+		if err != nil {
+			return DevPlanExecution{}, err
+		}
+
+		err = followedDevPlanUntilMerged(dCtx, input, devPlan, &planExec, rollbackVersion >= 1)
+		if rollbackVersion >= 1 && errors.Is(err, ErrRollbackToCheckpoint) {
+			// the user asked to go back to a step after the plan was followed,
+			// so restore it and follow the rest of the plan again
+			targetIndex := completedPlanRollbackTargetIndex(planExec, dCtx.GlobalState.GetPendingRollbackStepNumber())
+			if err = rollbackToStepCheckpoint(dCtx, &planExec, targetIndex); err != nil {
+				return DevPlanExecution{}, err
+			}
+			followInput.PlanExecution = &planExec
+			continue
+		}
+		if err != nil {
+			return DevPlanExecution{}, err
+		}
+		break
+	}
+
+	// emit signal when workflow ends successfully
+	err = signalWorkflowClosure(ctx, "completed")
 	if err != nil {
-		return DevPlanExecution{}, err
+		return DevPlanExecution{}, fmt.Errorf("failed to signal workflow closure: %v", err)
+	}
+
+	return planExec, nil
+}
+
+// followedDevPlanUntilMerged finalizes a followed plan: it gets tests passing
+// and, in worktrees, reviews and merges the changes. When allowRollback is
+// set, ErrRollbackToCheckpoint is returned if the user asks to roll back to a
+// step's checkpoint in the meantime.
+func followedDevPlanUntilMerged(dCtx DevContext, input PlannedDevInput, devPlan *DevPlan, planExec *DevPlanExecution, allowRollback bool) error {
+	rollbackRequested := func() bool {
+		action := dCtx.GlobalState.GetPendingUserAction()
+		if action == nil || *action != UserActionRollbackToCheckpoint {
+			return false
+		}
+		if completedPlanRollbackTargetIndex(*planExec, dCtx.GlobalState.GetPendingRollbackStepNumber()) < 0 {
+			dCtx.GlobalState.ConsumePendingUserAction()
+			return false
+		}
+		return true
 	}
 
-	err = EnsureTestsPassAfterDevPlanExecuted(dCtx, input, planExec)
+	err := EnsureTestsPassAfterDevPlanExecuted(dCtx, input, *planExec)
 	if err != nil {
-		return DevPlanExecution{}, err
+		return err
+	}
+	if allowRollback && rollbackRequested() {
+		return ErrRollbackToCheckpoint
 	}
 
 	err = AutoFormatCode(dCtx)
 	if err != nil {
-		return DevPlanExecution{}, fmt.Errorf("failed to auto-format code: %v", err)
+		return fmt.Errorf("failed to auto-format code: %v", err)
 	}
 
 	// Handle merge if using worktree and workflow version is new enough
-	v := workflow.GetVersion(ctx, "git-worktree-merge", workflow.DefaultVersion, 1)
+	v := workflow.GetVersion(dCtx, "git-worktree-merge", workflow.DefaultVersion, 1)
 	if input.EnvType == env.EnvTypeLocalGitWorktree && v == 1 {
-		err := reviewAndResolve(dCtx, MergeWithReviewParams{
+		params := MergeWithReviewParams{
 			CommitRequired: false, // planned dev flow writes commits already
+			OfferSquash:    true,  // one commit per step may be more history than wanted
 			Requirements: input.Requirements + `
 
 Here is the plan for meeting the requirements, along with updates per step:
@@ -119,19 +170,13 @@ Here is the plan for meeting the requirements, along with updates per step:
 ` + devPlan.String(),
 			StartBranch: input.StartBranch,
 			GetGitDiff:  nil,
-		})
-		if err != nil {
-			return DevPlanExecution{}, err
 		}
+		if allowRollback {
+			params.RollbackRequested = rollbackRequested
+		}
+		return reviewAndResolve(dCtx, params)
 	}
-
-	// emit signal when workflow ends successfully
-	err = signalWorkflowClosure(ctx, "completed")
-	if err != nil {
-		return DevPlanExecution{}, fmt.Errorf("failed to signal workflow closure: %v", err)
-	}
-
-	return planExec, nil
+	return nil
 }
 
 func EnsureTestsPassAfterDevPlanExecuted(dCtx DevContext, input PlannedDevInput, planExec DevPlanExecution) error {
@@ -149,17 +194,21 @@ func ensureTestsPassAfterDevPlanExecutedSubflow(dCtx DevContext, input PlannedDe
 		}
 		attempts++
 
-		testResult, err := RunTests(dCtx, dCtx.RepoConfig.TestCommands)
+		testResult, err := runTestsForChanges(dCtx, selectTestCommands)
 		if err != nil {
 			return fmt.Errorf("failed to run tests: %v", err)
 		}
 
 		if testResult.TestsPassed {
-			if len(dCtx.RepoConfig.IntegrationTestCommands) == 0 {
+			integrationTestCommands, err := commandsForChanges(dCtx, selectIntegrationTestCommands)
+			if err != nil {
+				return err
+			}
+			if len(integrationTestCommands) == 0 {
 				break
 			}
 
-			integrationTestResult, err := RunTests(dCtx, dCtx.RepoConfig.IntegrationTestCommands)
+			integrationTestResult, err := RunTests(dCtx, integrationTestCommands)
 			if err != nil {
 				return fmt.Errorf("failed to run integration tests: %v", err)
 			}
@@ -0,0 +1,50 @@
+package dev
+
+import (
+	"fmt"
+	"sidekick/coding/git"
+	"sidekick/env"
+	"sidekick/fflag"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// runPreCommitHookCheck runs the repo's pre-commit hook against the current
+// changes, so that a hook that would reject sidekick's commit can be fixed in
+// the coding loop like a failing test, before anything is committed. Passes
+// when hooks are skipped, there is no pre-commit hook, or sidekick won't
+// commit the changes itself.
+func runPreCommitHookCheck(dCtx DevContext) (TestResult, error) {
+	passed := TestResult{TestsPassed: true}
+	if dCtx.RepoConfig.Commit.SkipHooks {
+		return passed, nil
+	}
+	v := workflow.GetVersion(dCtx, "pre-commit-hook-check", workflow.DefaultVersion, 1)
+	if v < 1 {
+		return passed, nil
+	}
+	// changes are only committed in worktrees, or after each step when edits
+	// are checked
+	commitsVersion := workflow.GetVersion(dCtx, "pre-commit-hook-check-commits-only", workflow.DefaultVersion, 1)
+	if commitsVersion >= 1 && dCtx.EnvContainer.Env.GetType() != env.EnvTypeLocalGitWorktree && !fflag.IsEnabled(dCtx, fflag.CheckEdits) {
+		return passed, nil
+	}
+
+	var hookResult git.PreCommitHookResult
+	err := workflow.ExecuteActivity(dCtx, git.RunPreCommitHookActivity, *dCtx.EnvContainer).Get(dCtx, &hookResult)
+	if err != nil {
+		return TestResult{}, fmt.Errorf("failed to run pre-commit hook: %v", err)
+	}
+	if !hookResult.Ran || hookResult.Passed {
+		return passed, nil
+	}
+
+	output := "Check: git pre-commit hook\nCheck Result: Failed\nThe changes must pass the pre-commit hook before they can be committed. Hook output:\n" + hookResult.Output
+	if len(output) > maxTestOutputSize {
+		output, err = SummarizeTestOutput(dCtx, output)
+		if err != nil {
+			return TestResult{}, fmt.Errorf("failed to summarize pre-commit hook output: %v", err)
+		}
+	}
+	return TestResult{TestsPassed: false, Output: output}, nil
+}
@@ -0,0 +1,216 @@
+package dev
+
+import (
+	"errors"
+	"fmt"
+	"sidekick/coding"
+	"sidekick/coding/git"
+	"sidekick/common"
+	"sidekick/domain"
+	"sidekick/llm"
+	"sidekick/utils"
+	"strings"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+type ResolveMergeConflictsParams struct {
+	Requirements string // the requirements for the work done on the source branch
+	SourceBranch string // the branch being merged into, i.e. this flow's branch
+	TargetBranch string // the branch whose changes conflict
+}
+
+// rounds of editing, whether ending in failing checks or a rejection by the
+// user, before the conflicts are left to the user
+const maxMergeConflictResolutionAttempts = 5
+
+// conflicted files up to this many lines are shown in full, otherwise only the
+// lines around each conflict are
+const maxFullConflictedFileLines = 300
+const conflictContextLines = 20
+
+// ResolveMergeConflicts tries to resolve the conflicts of the merge in
+// progress in the flow's worktree, and commits the merge once the user
+// approves the resolution. When that doesn't work out, the merge is restarted
+// to discard any partial resolution, leaving the original conflicts for the
+// user, and false is returned.
+func ResolveMergeConflicts(dCtx DevContext, params ResolveMergeConflictsParams) (bool, error) {
+	return RunSubflow(dCtx, "resolve_merge_conflicts", "Resolve Merge Conflicts", func(_ domain.Subflow) (bool, error) {
+		return resolveMergeConflictsSubflow(dCtx, params)
+	})
+}
+
+func resolveMergeConflictsSubflow(dCtx DevContext, params ResolveMergeConflictsParams) (bool, error) {
+	var conflictedFiles []git.ConflictedFile
+	err := workflow.ExecuteActivity(dCtx, git.ListConflictedFilesActivity, *dCtx.EnvContainer).Get(dCtx, &conflictedFiles)
+	if err != nil {
+		return false, fmt.Errorf("failed to list conflicted files: %v", err)
+	}
+	if len(conflictedFiles) == 0 {
+		return false, nil
+	}
+	filePaths := make([]string, 0, len(conflictedFiles))
+	for _, conflictedFile := range conflictedFiles {
+		filePaths = append(filePaths, conflictedFile.Path)
+	}
+
+	// edits are checked all together once applied instead of one by one
+	if v := workflow.GetVersion(dCtx, "merge-conflicts-skip-edit-checks", workflow.DefaultVersion, 1); v >= 1 {
+		dCtx.ResolvingMergeConflicts = true
+	}
+
+	var promptInfo PromptInfo = InitialCodeInfo{
+		CodeContext:  formatConflictedFiles(conflictedFiles),
+		Requirements: mergeConflictRequirements(params),
+	}
+	chatHistory := &[]llm.ChatMessage{}
+	modelConfig := dCtx.GetModelConfig(common.CodingKey, 0, "default")
+
+	for attempt := 0; attempt < maxMergeConflictResolutionAttempts; attempt++ {
+		err = EditCode(dCtx, modelConfig, 0, chatHistory, promptInfo)
+		if errors.Is(err, ErrMaxAttemptsReached) {
+			break
+		} else if err != nil {
+			return false, fmt.Errorf("failed to edit conflicted files: %w", err)
+		}
+
+		feedback, err := checkMergeConflictResolution(dCtx, filePaths)
+		if err != nil {
+			return false, err
+		}
+		if feedback != "" {
+			promptInfo = FeedbackInfo{Feedback: feedback}
+			continue
+		}
+
+		var diff string
+		err = workflow.ExecuteActivity(dCtx, git.GitDiffActivity, *dCtx.EnvContainer, git.GitDiffParams{
+			Staged:    true,
+			FilePaths: filePaths,
+		}).Get(dCtx, &diff)
+		if err != nil {
+			return false, fmt.Errorf("failed to get diff of conflict resolution: %v", err)
+		}
+
+		approvalPrompt := fmt.Sprintf("Merging %s into %s resulted in conflicts, which were resolved as shown. Approve to complete the merge, or reject with feedback to revise the resolution.", params.TargetBranch, params.SourceBranch)
+		userResponse, err := GetUserApproval(dCtx, "merge_conflict_resolution", approvalPrompt, map[string]any{
+			"diff": diff,
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to get approval of conflict resolution: %v", err)
+		}
+		if userResponse.Approved != nil && *userResponse.Approved {
+			err = workflow.ExecuteActivity(dCtx, git.GitCommitActivity, *dCtx.EnvContainer, git.GitCommitParams{
+				CommitMessage: fmt.Sprintf("Merge branch '%s' into %s", params.TargetBranch, params.SourceBranch),
+				Config:        dCtx.RepoConfig.Commit,
+			}).Get(dCtx, nil)
+			if err != nil {
+				return false, fmt.Errorf("failed to commit merge: %v", err)
+			}
+			return true, nil
+		}
+
+		promptInfo = FeedbackInfo{Feedback: "The user rejected this conflict resolution. Revise it based on their feedback:\n\n" + userResponse.Content}
+	}
+
+	err = workflow.ExecuteActivity(dCtx, git.RestartMergeActivity, *dCtx.EnvContainer, params.TargetBranch).Get(dCtx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to restore merge conflicts: %v", err)
+	}
+	return false, nil
+}
+
+// checkMergeConflictResolution stages the resolved files and runs check and
+// test commands, returning feedback for the model if anything is amiss
+func checkMergeConflictResolution(dCtx DevContext, filePaths []string) (string, error) {
+	var resolvedFiles []git.ConflictedFile
+	err := workflow.ExecuteActivity(dCtx, git.ReadConflictedFilesActivity, *dCtx.EnvContainer, filePaths).Get(dCtx, &resolvedFiles)
+	if err != nil {
+		return "", fmt.Errorf("failed to read conflicted files: %v", err)
+	}
+	var unresolvedPaths []string
+	for _, resolvedFile := range resolvedFiles {
+		if len(resolvedFile.Hunks) > 0 {
+			unresolvedPaths = append(unresolvedPaths, resolvedFile.Path)
+		}
+	}
+	if len(unresolvedPaths) > 0 {
+		return fmt.Sprintf("Conflict markers remain in these files: %s. Resolve every conflict, removing all conflict markers.", strings.Join(unresolvedPaths, ", ")), nil
+	}
+
+	for _, filePath := range filePaths {
+		err := workflow.ExecuteActivity(dCtx, git.GitAddActivity, git.GitAddActivityInput{
+			EnvContainer: *dCtx.EnvContainer,
+			Path:         filePath,
+		}).Get(dCtx, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to stage resolved file %s: %v", filePath, err)
+		}
+	}
+
+	for _, getCommands := range []func(common.RepoConfig) []common.CommandConfig{selectCheckCommands, selectTestCommands} {
+		commands, err := commandsForChanges(dCtx, getCommands)
+		if err != nil {
+			return "", err
+		}
+		if len(commands) == 0 {
+			continue
+		}
+		testResult, err := RunTests(dCtx, commands)
+		if err != nil {
+			return "", fmt.Errorf("failed to run tests: %v", err)
+		}
+		if !testResult.TestsPassed {
+			return "The conflicts were resolved, but checks or tests are failing:\n\n" + testResult.Output, nil
+		}
+	}
+	return "", nil
+}
+
+func mergeConflictRequirements(params ResolveMergeConflictsParams) string {
+	return fmt.Sprintf(`Resolve the conflicts from merging the %[1]s branch into the %[2]s branch. In each conflicted region, the lines after "<<<<<<<" are from %[2]s, the lines after "=======" are from %[1]s, and, if present, the lines after "|||||||" are from their common ancestor.
+
+Replace each conflicted region, including all of its conflict markers, with code that keeps the intent of both sides: %[2]s must still fulfill its requirements given below, while keeping the changes made on %[1]s. Only change code outside the conflicted regions when needed for both sides to work together.
+
+Requirements for the work done on %[2]s:
+
+%[3]s`, params.TargetBranch, params.SourceBranch, params.Requirements)
+}
+
+func formatConflictedFiles(conflictedFiles []git.ConflictedFile) string {
+	var out strings.Builder
+	for _, conflictedFile := range conflictedFiles {
+		if conflictedFile.Content == "" {
+			fmt.Fprintf(&out, "File: %s\nThis file was deleted on one side of the merge and changed on the other. Recreate it if the changes are still needed, otherwise leave it deleted.\n\n", conflictedFile.Path)
+			continue
+		}
+
+		fence := coding.CodeFenceStartForLanguage(utils.InferLanguageNameFromFilePath(conflictedFile.Path))
+		lines := strings.Split(strings.TrimSuffix(conflictedFile.Content, "\n"), "\n")
+		for _, lineRange := range conflictLineRanges(len(lines), conflictedFile.Hunks) {
+			content := strings.Join(lines[lineRange[0]-1:lineRange[1]], "\n")
+			fmt.Fprintf(&out, "File: %s\nLines: %d-%d\n%s%s\n```\n\n", conflictedFile.Path, lineRange[0], lineRange[1], fence, content)
+		}
+	}
+	return strings.TrimSpace(out.String())
+}
+
+// conflictLineRanges returns the 1-based, inclusive line ranges of a file to
+// show: the whole file if short, otherwise each hunk with some context, with
+// overlapping ranges merged
+func conflictLineRanges(numLines int, hunks []git.ConflictHunk) [][2]int {
+	if numLines <= maxFullConflictedFileLines || len(hunks) == 0 {
+		return [][2]int{{1, numLines}}
+	}
+	var ranges [][2]int
+	for _, hunk := range hunks {
+		start := max(1, hunk.StartLine-conflictContextLines)
+		end := min(numLines, hunk.EndLine+conflictContextLines)
+		if len(ranges) > 0 && start <= ranges[len(ranges)-1][1]+1 {
+			ranges[len(ranges)-1][1] = max(end, ranges[len(ranges)-1][1])
+			continue
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges
+}
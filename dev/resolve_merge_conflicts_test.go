@@ -0,0 +1,43 @@
+package dev
+
+import (
+	"sidekick/coding/git"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConflictLineRanges(t *testing.T) {
+	t.Parallel()
+	hunks := []git.ConflictHunk{{StartLine: 100, EndLine: 105}, {StartLine: 130, EndLine: 140}, {StartLine: 400, EndLine: 410}}
+
+	assert.Equal(t, [][2]int{{1, 50}}, conflictLineRanges(50, hunks[:1]))
+	assert.Equal(t, [][2]int{{80, 160}, {380, 420}}, conflictLineRanges(420, hunks))
+	assert.Equal(t, [][2]int{{380, 415}}, conflictLineRanges(415, hunks[2:]))
+}
+
+func TestFormatConflictedFiles(t *testing.T) {
+	t.Parallel()
+	content := "package main\n\n<<<<<<< HEAD\nvar x = 1\n=======\nvar x = 2\n>>>>>>> main\n"
+	conflictedFiles := []git.ConflictedFile{
+		{Path: "main.go", Content: content, Hunks: git.ParseConflictMarkers(content)},
+		{Path: "deleted.go"},
+	}
+
+	formatted := formatConflictedFiles(conflictedFiles)
+	assert.True(t, strings.HasPrefix(formatted, "File: main.go\nLines: 1-7\n```go\npackage main\n\n<<<<<<< HEAD\n"), formatted)
+	assert.Contains(t, formatted, ">>>>>>> main\n```\n\nFile: deleted.go\nThis file was deleted on one side")
+}
+
+func TestMergeConflictRequirements(t *testing.T) {
+	t.Parallel()
+	requirements := mergeConflictRequirements(ResolveMergeConflictsParams{
+		Requirements: "Add a flag",
+		SourceBranch: "side/add-flag",
+		TargetBranch: "main",
+	})
+	assert.Contains(t, requirements, "merging the main branch into the side/add-flag branch")
+	assert.Contains(t, requirements, `the lines after "<<<<<<<" are from side/add-flag`)
+	assert.True(t, strings.HasSuffix(requirements, "Requirements for the work done on side/add-flag:\n\nAdd a flag"))
+}
@@ -50,6 +50,7 @@ func RunCommand(dCtx DevContext, params RunCommandParams) (string, error) {
 	err = workflow.ExecuteActivity(dCtx.Context, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
 		EnvContainer:       *dCtx.EnvContainer,
 		Command:            "sh",
+		Category:           "run_command",
 		Args:               []string{"-c", params.Command},
 		RelativeWorkingDir: relWorkDir,
 	}).Get(dCtx.Context, &output)
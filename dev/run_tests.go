@@ -106,6 +106,7 @@ func runSingleTest(ctx workflow.Context, workingDir string, fullCommand string,
 		EnvContainer:       envContainer,
 		RelativeWorkingDir: "./",
 		Command:            "/usr/bin/env",
+		Category:           "run_tests",
 		Args:               []string{"sh", "-c", fullCommand},
 	}
 	if workingDir != "" {
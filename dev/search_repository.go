@@ -10,6 +10,7 @@ import (
 	"sidekick/utils"
 	"strings"
 
+	code_search "sidekick/coding/search"
 	tree_sitter "sidekick/coding/tree_sitter"
 
 	doublestar "github.com/bmatcuk/doublestar/v4"
@@ -75,6 +76,9 @@ const maxSearchOutputLength = 2000
 // addSearchPrefix adds a consistent search description prefix to the given output
 func addSearchPrefix(input SearchRepositoryInput, output string) string {
 	prefix := fmt.Sprintf("Searched for %q in %q", input.SearchTerm, input.PathGlob)
+	if input.Subdirectory != "" {
+		prefix += fmt.Sprintf(" within %q", input.Subdirectory)
+	}
 	return fmt.Sprintf("%s\n%s", prefix, output)
 }
 
@@ -84,6 +88,9 @@ type SearchRepositoryInput struct {
 	ContextLines    int
 	CaseInsensitive bool
 	FixedStrings    bool
+	// Subdirectory limits the search to files within it, relative to the
+	// repo root, eg the sub-project a task is scoped to
+	Subdirectory string
 }
 
 // TODO /gen include the function name in the associated with each search result
@@ -98,6 +105,10 @@ type searchContext struct {
 	escapedSearchTerm      string
 	useManualGlobFiltering bool
 	sideIgnoreExists       bool
+	useBuiltinSearch       bool
+	// appended to rg commands after the search term to limit the search to
+	// the subdirectory, if any
+	rgPathArgs string
 }
 
 func initSearchContext(ctx workflow.Context, envContainer env.EnvContainer, input SearchRepositoryInput) (*searchContext, error) {
@@ -114,6 +125,9 @@ func initSearchContext(ctx workflow.Context, envContainer env.EnvContainer, inpu
 	}
 
 	sCtx.escapedSearchTerm = escapeShellArg(input.SearchTerm)
+	if input.Subdirectory != "" {
+		sCtx.rgPathArgs = " " + escapeShellArg(input.Subdirectory)
+	}
 
 	// Base rgArgs
 	sCtx.rgArgs = "--files-with-matches --hidden --ignore-file " + escapeShellArg(sCtx.coreIgnorePath)
@@ -142,6 +156,7 @@ func initSearchContext(ctx workflow.Context, envContainer env.EnvContainer, inpu
 		EnvContainer:       sCtx.envContainer,
 		RelativeWorkingDir: "./",
 		Command:            "cat",
+		Category:           "search",
 		Args:               []string{".sideignore"},
 	}).Get(sCtx.ctx, &catOutput)
 	if err != nil {
@@ -156,9 +171,45 @@ func initSearchContext(ctx workflow.Context, envContainer env.EnvContainer, inpu
 	}
 	// If catOutput.ExitStatus != 0 (e.g. file not found), we proceed without adding .sideignore to rgArgs, which is the desired behavior.
 
+	// rg is optional: fall back to the slower built-in search without it
+	v = workflow.GetVersion(sCtx.ctx, "builtin-search-fallback", workflow.DefaultVersion, 1)
+	if v >= 1 {
+		var rgCheckOutput env.EnvRunCommandOutput
+		err = workflow.ExecuteActivity(sCtx.ctx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
+			EnvContainer:       sCtx.envContainer,
+			RelativeWorkingDir: "./",
+			Command:            "sh",
+			Category:           "search",
+			Args:               []string{"-c", "command -v rg"},
+		}).Get(sCtx.ctx, &rgCheckOutput)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check whether rg is installed: %w", err)
+		}
+		sCtx.useBuiltinSearch = rgCheckOutput.ExitStatus != 0
+	}
+
 	return sCtx, nil
 }
 
+// builtinSearch runs the equivalent of our rg and git grep commands without
+// either being installed, limited to the given files if any
+func (sCtx *searchContext) builtinSearch(mode code_search.SearchMode, files []string) (env.EnvRunCommandOutput, error) {
+	var output env.EnvRunCommandOutput
+	// .sideignore is picked up while walking the repository, like .gitignore
+	err := workflow.ExecuteActivity(sCtx.ctx, code_search.SearchActivity, code_search.SearchActivityInput{
+		EnvContainer:    sCtx.envContainer,
+		Mode:            mode,
+		SearchTerm:      sCtx.input.SearchTerm,
+		FixedStrings:    sCtx.input.FixedStrings,
+		CaseInsensitive: sCtx.input.CaseInsensitive,
+		ContextLines:    sCtx.input.ContextLines,
+		IgnoreFiles:     []string{sCtx.coreIgnorePath},
+		Files:           files,
+		Subdirectory:    sCtx.input.Subdirectory,
+	}).Get(sCtx.ctx, &output)
+	return output, err
+}
+
 // by processing the search results further through tree-sitter (note: This
 // requires StructuredSearchRepository to be implemented first.) The tree-sitter
 // stuff might be done simply if we find the smallest symbol definition that
@@ -249,14 +300,18 @@ func (sCtx *searchContext) executeMainSearch() (string, string, []string, []stri
 		// 1. Get the list of files from rg (respecting ignore files) that contain the search term
 		// 2. Filter them manually using the glob pattern
 		// 3. Run git grep on the filtered files
-		listFilesCmd := fmt.Sprintf(`rg %s --files-with-matches -- %s`, sCtx.rgArgs, sCtx.escapedSearchTerm)
-
-		err = workflow.ExecuteActivity(sCtx.ctx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
-			EnvContainer:       sCtx.envContainer,
-			RelativeWorkingDir: "./",
-			Command:            "sh",
-			Args:               []string{"-c", listFilesCmd},
-		}).Get(sCtx.ctx, &listFilesOutput)
+		if sCtx.useBuiltinSearch {
+			listFilesOutput, err = sCtx.builtinSearch(code_search.SearchModeFilesWithMatches, nil)
+		} else {
+			listFilesCmd := fmt.Sprintf(`rg %s --files-with-matches -- %s%s`, sCtx.rgArgs, sCtx.escapedSearchTerm, sCtx.rgPathArgs)
+			err = workflow.ExecuteActivity(sCtx.ctx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
+				EnvContainer:       sCtx.envContainer,
+				RelativeWorkingDir: "./",
+				Command:            "sh",
+				Category:           "search",
+				Args:               []string{"-c", listFilesCmd},
+			}).Get(sCtx.ctx, &listFilesOutput)
+		}
 		if err != nil {
 			return "", "", nil, nil, fmt.Errorf("failed to list files for manual glob filtering: %w", err)
 		}
@@ -282,35 +337,47 @@ func (sCtx *searchContext) executeMainSearch() (string, string, []string, []stri
 			// Return empty stdout, but include stderr from the listing command if any.
 			return "", listFilesOutput.Stderr, allFilesMatchingSearchTerm, filesMatchingGlobAndSearchTerm, nil
 		} else {
-			// Run git grep on the filtered files
-			escapedFiles := make([]string, len(filesMatchingGlobAndSearchTerm))
-			for i, file := range filesMatchingGlobAndSearchTerm {
-				escapedFiles[i] = escapeShellArg(file)
+			if sCtx.useBuiltinSearch {
+				searchOutput, err = sCtx.builtinSearch(code_search.SearchModeGrep, filesMatchingGlobAndSearchTerm)
+			} else {
+				// Run git grep on the filtered files
+				escapedFiles := make([]string, len(filesMatchingGlobAndSearchTerm))
+				for i, file := range filesMatchingGlobAndSearchTerm {
+					escapedFiles[i] = escapeShellArg(file)
+				}
+				filesArg := strings.Join(escapedFiles, " ")
+				fullCmd := fmt.Sprintf(`%s -- %s %s`, sCtx.gitGrepArgs, sCtx.escapedSearchTerm, filesArg)
+
+				// searchOutput will be populated by this activity
+				err = workflow.ExecuteActivity(sCtx.ctx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
+					EnvContainer:       sCtx.envContainer,
+					RelativeWorkingDir: "./",
+					Command:            "sh",
+					Category:           "search",
+					Args:               []string{"-c", fullCmd},
+				}).Get(sCtx.ctx, &searchOutput)
 			}
-			filesArg := strings.Join(escapedFiles, " ")
-			fullCmd := fmt.Sprintf(`%s -- %s %s`, sCtx.gitGrepArgs, sCtx.escapedSearchTerm, filesArg)
-
-			// searchOutput will be populated by this activity
-			err = workflow.ExecuteActivity(sCtx.ctx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
-				EnvContainer:       sCtx.envContainer,
-				RelativeWorkingDir: "./",
-				Command:            "sh",
-				Args:               []string{"-c", fullCmd},
-			}).Get(sCtx.ctx, &searchOutput)
 			if err != nil {
 				return "", searchOutput.Stderr, allFilesMatchingSearchTerm, filesMatchingGlobAndSearchTerm, fmt.Errorf("failed to search filtered files: %w", err)
 			}
 			// Success, return stdout and stderr from the git grep command
 			return searchOutput.Stdout, searchOutput.Stderr, allFilesMatchingSearchTerm, filesMatchingGlobAndSearchTerm, nil
 		}
+	} else if sCtx.useBuiltinSearch {
+		searchOutput, err = sCtx.builtinSearch(code_search.SearchModeGrep, nil)
+		if err != nil {
+			return "", searchOutput.Stderr, nil, nil, fmt.Errorf("failed to search the repository: %w", err)
+		}
+		return searchOutput.Stdout, searchOutput.Stderr, nil, nil, nil
 	} else {
 		// Original behavior: use rg + git grep pipeline
-		fullCmd := fmt.Sprintf(`rg %s -- %s | xargs -r %s -- %s`, sCtx.rgArgs, sCtx.escapedSearchTerm, sCtx.gitGrepArgs, sCtx.escapedSearchTerm)
+		fullCmd := fmt.Sprintf(`rg %s -- %s%s | xargs -r %s -- %s`, sCtx.rgArgs, sCtx.escapedSearchTerm, sCtx.rgPathArgs, sCtx.gitGrepArgs, sCtx.escapedSearchTerm)
 
 		err = workflow.ExecuteActivity(sCtx.ctx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
 			EnvContainer:       sCtx.envContainer,
 			RelativeWorkingDir: "./",
 			Command:            "sh",
+			Category:           "search",
 			Args:               []string{"-c", fullCmd},
 		}).Get(sCtx.ctx, &searchOutput)
 		if err != nil {
@@ -342,13 +409,18 @@ func (sCtx *searchContext) handleOutputLengthChecks(rawOutput string, globMatche
 			fileListString = strings.Join(globMatchedFiles, "\n")
 		} else {
 			var listFilesOutput env.EnvRunCommandOutput
-			filesToListCmd = fmt.Sprintf(`rg %s -- %s`, sCtx.rgArgs, sCtx.escapedSearchTerm)
-			err = workflow.ExecuteActivity(sCtx.ctx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
-				EnvContainer:       sCtx.envContainer,
-				RelativeWorkingDir: "./",
-				Command:            "sh",
-				Args:               []string{"-c", filesToListCmd},
-			}).Get(sCtx.ctx, &listFilesOutput)
+			if sCtx.useBuiltinSearch {
+				listFilesOutput, err = sCtx.builtinSearch(code_search.SearchModeFilesWithMatches, nil)
+			} else {
+				filesToListCmd = fmt.Sprintf(`rg %s -- %s%s`, sCtx.rgArgs, sCtx.escapedSearchTerm, sCtx.rgPathArgs)
+				err = workflow.ExecuteActivity(sCtx.ctx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
+					EnvContainer:       sCtx.envContainer,
+					RelativeWorkingDir: "./",
+					Command:            "sh",
+					Category:           "search",
+					Args:               []string{"-c", filesToListCmd},
+				}).Get(sCtx.ctx, &listFilesOutput)
+			}
 			if err != nil {
 				return "", true, fmt.Errorf("failed to list files (for too long output): %w", err)
 			}
@@ -447,14 +519,23 @@ func (sCtx *searchContext) getFilesMatchingPathGlob() ([]string, error) {
 	if sCtx.sideIgnoreExists {
 		rgFilesCmdParts = append(rgFilesCmdParts, "--ignore-file", ".sideignore")
 	}
+	if sCtx.input.Subdirectory != "" {
+		rgFilesCmdParts = append(rgFilesCmdParts, "--", sCtx.input.Subdirectory)
+	}
 
-	// version guard not needed: this already existed before
-	err := workflow.ExecuteActivity(sCtx.ctx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
-		EnvContainer:       sCtx.envContainer,
-		RelativeWorkingDir: "./",
-		Command:            "rg",
-		Args:               rgFilesCmdParts,
-	}).Get(sCtx.ctx, &rgFilesOutput)
+	var err error
+	if sCtx.useBuiltinSearch {
+		rgFilesOutput, err = sCtx.builtinSearch(code_search.SearchModeFiles, nil)
+	} else {
+		// version guard not needed: this already existed before
+		err = workflow.ExecuteActivity(sCtx.ctx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
+			EnvContainer:       sCtx.envContainer,
+			RelativeWorkingDir: "./",
+			Command:            "rg",
+			Category:           "search",
+			Args:               rgFilesCmdParts,
+		}).Get(sCtx.ctx, &rgFilesOutput)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("activity execution failed for rg to list files for glob '%s': %w", sCtx.input.PathGlob, err)
 	}
@@ -504,22 +585,27 @@ func (sCtx *searchContext) processGlobalFallbackResults(allFiles []string) (stri
 
 	// Case: 0 < numFilesFound <= 3. Execute git grep.
 	var gitGrepOutput env.EnvRunCommandOutput
-	escapedFiles := make([]string, len(nonMatchingFiles))
-	for i, f := range nonMatchingFiles {
-		escapedFiles[i] = escapeShellArg(f)
-	}
+	if sCtx.useBuiltinSearch {
+		gitGrepOutput, err = sCtx.builtinSearch(code_search.SearchModeGrep, nonMatchingFiles)
+	} else {
+		escapedFiles := make([]string, len(nonMatchingFiles))
+		for i, f := range nonMatchingFiles {
+			escapedFiles[i] = escapeShellArg(f)
+		}
 
-	// sCtx.gitGrepArgs already includes context lines, --show-function, --heading, --line-number,
-	// and incorporates input.CaseInsensitive and input.FixedStrings.
-	// sCtx.escapedSearchTerm is already shell-escaped.
-	cmdStr := fmt.Sprintf("git grep %s %s -- %s", sCtx.gitGrepArgs, sCtx.escapedSearchTerm, strings.Join(escapedFiles, " "))
+		// sCtx.gitGrepArgs already includes context lines, --show-function, --heading, --line-number,
+		// and incorporates input.CaseInsensitive and input.FixedStrings.
+		// sCtx.escapedSearchTerm is already shell-escaped.
+		cmdStr := fmt.Sprintf("git grep %s %s -- %s", sCtx.gitGrepArgs, sCtx.escapedSearchTerm, strings.Join(escapedFiles, " "))
 
-	err = workflow.ExecuteActivity(sCtx.ctx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
-		EnvContainer:       sCtx.envContainer,
-		RelativeWorkingDir: "./",
-		Command:            "sh",
-		Args:               []string{"-c", cmdStr},
-	}).Get(sCtx.ctx, &gitGrepOutput)
+		err = workflow.ExecuteActivity(sCtx.ctx, env.EnvRunCommandActivity, env.EnvRunCommandActivityInput{
+			EnvContainer:       sCtx.envContainer,
+			RelativeWorkingDir: "./",
+			Command:            "sh",
+			Category:           "search",
+			Args:               []string{"-c", cmdStr},
+		}).Get(sCtx.ctx, &gitGrepOutput)
+	}
 
 	if err != nil {
 		return "", fmt.Errorf("failed to execute git grep for global fallback: %w", err)
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	code_search "sidekick/coding/search"
 	"sidekick/coding/tree_sitter"
 	"sidekick/env"
 	"sidekick/utils"
@@ -71,6 +72,7 @@ func (s *SearchRepositoryE2ETestSuite) ResetWorkflowEnvironment() {
 	s.env = s.NewTestWorkflowEnvironment()
 
 	s.env.RegisterActivity(env.EnvRunCommandActivity)
+	s.env.RegisterActivity(code_search.SearchActivity)
 	s.wrapperWorkflow = func(ctx workflow.Context, envContainer env.EnvContainer, input SearchRepositoryInput) (string, error) {
 		ctx1 := utils.NoRetryCtx(ctx)
 		return SearchRepository(ctx1, envContainer, input)
@@ -367,6 +369,26 @@ func (s *SearchRepositoryE2ETestSuite) TestNoResults() {
 	s.Contains(result, expectedMsg, "Expected no results message")
 }
 
+func (s *SearchRepositoryE2ETestSuite) TestSubdirectorySearch() {
+	s.Require().NoError(os.MkdirAll(filepath.Join(s.dir, "api"), 0755))
+	s.createTestFile("root.txt", "needle at the root")
+	s.createTestFile("api/api.txt", "needle in the api")
+
+	for _, pathGlob := range []string{"*", "*.txt"} {
+		result, err := s.executeSearchRepository(SearchRepositoryInput{
+			PathGlob:     pathGlob,
+			SearchTerm:   "needle",
+			ContextLines: 0,
+			Subdirectory: "api",
+		})
+		s.Require().NoError(err)
+		s.Contains(result, `Searched for "needle" in "`+pathGlob+`" within "api"`)
+		s.Contains(result, "api/api.txt")
+		s.NotContains(result, "root.txt")
+		s.ResetWorkflowEnvironment()
+	}
+}
+
 func (s *SearchRepositoryE2ETestSuite) TestRespectIgnoreFiles() {
 	// Create .sideignore file
 	s.createTestFile(".sideignore", "ignored*\n*.genignore")
@@ -0,0 +1,108 @@
+package dev
+
+import (
+	"context"
+	"fmt"
+	"sidekick/coding"
+	"sidekick/coding/tree_sitter"
+	"sidekick/env"
+	"sidekick/llm"
+	"sidekick/utils"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+	"go.temporal.io/sdk/workflow"
+)
+
+var structuralSearchTool = llm.Tool{
+	Name: "structural_search",
+	Description: `Searches code by syntax tree rather than text, across all non-ignored files of the given language. Use this for searches that plain text search handles poorly, eg "all functions that take a workflow.Context and call ExecuteActivity". Provide either a pattern or a tree-sitter query.
+
+A pattern is a code snippet in the given language, where "$NAME" (uppercase) metavariables match any single expression, type, identifier etc, "$_" matches anything without binding, and "$$$" matches any number of arguments, parameters or statements. A metavariable used more than once must match identical text each time. Comments and whitespace are ignored. Example golang patterns: "workflow.ExecuteActivity($CTX, $$$)", "func $NAME($_ workflow.Context, $$$) error { $$$ }", "if err != nil { return $$$ }".
+
+A tree-sitter query is more powerful, eg supporting predicates like #eq? and #match?. The node captured as @match is returned, otherwise the outermost captured node.`,
+	Parameters: (&jsonschema.Reflector{DoNotReference: true}).Reflect(&StructuralSearchParams{}),
+}
+
+type StructuralSearchParams struct {
+	Language     string `json:"language" jsonschema:"description=The language of the files to search\\, eg golang\\, typescript\\, tsx\\, python\\, java\\, kotlin\\, rust\\, c\\, cpp\\, csharp\\, ruby or php."`
+	Pattern      string `json:"pattern,omitempty" jsonschema:"description=A code pattern with metavariables to search for. Required unless a query is given."`
+	Query        string `json:"query,omitempty" jsonschema:"description=A tree-sitter query to search with instead of a pattern."`
+	PathGlob     string `json:"path_glob,omitempty" jsonschema:"description=Optional glob to restrict which files are searched\\, eg \"src/**\"."`
+	ContextLines int    `json:"context_lines,omitempty" jsonschema:"description=The number of lines of context to include around each match."`
+}
+
+type StructuralSearchActivityInput struct {
+	EnvContainer env.EnvContainer
+	Params       StructuralSearchParams
+	// Subdirectory limits the search to the task's sub-project, if any
+	Subdirectory string `json:",omitempty"`
+}
+
+// StructuralSearch runs a structural search, returning invalid patterns or
+// queries as the response so the model can fix them
+func StructuralSearch(dCtx DevContext, params StructuralSearchParams) (string, error) {
+	var da *DevActivities
+	var result string
+	err := workflow.ExecuteActivity(dCtx, da.StructuralSearchActivity, StructuralSearchActivityInput{
+		EnvContainer: *dCtx.EnvContainer,
+		Params:       params,
+		Subdirectory: dCtx.SubProject,
+	}).Get(dCtx, &result)
+	if err != nil {
+		return fmt.Sprintf("Structural search failed: %v", err), nil
+	}
+	return result, nil
+}
+
+func (da *DevActivities) StructuralSearchActivity(ctx context.Context, input StructuralSearchActivityInput) (string, error) {
+	params := input.Params
+	results, err := tree_sitter.StructuralSearch(input.EnvContainer.Env.GetWorkingDirectory(), tree_sitter.StructuralSearchInput{
+		LanguageName: params.Language,
+		Query:        params.Query,
+		Pattern:      params.Pattern,
+		PathGlob:     params.PathGlob,
+		Subdirectory: input.Subdirectory,
+		ContextLines: params.ContextLines,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	searchDescription := fmt.Sprintf("Structural search for %q in %s files", params.Pattern, params.Language)
+	if strings.TrimSpace(params.Query) != "" {
+		searchDescription = fmt.Sprintf("Structural search with query %q in %s files", params.Query, params.Language)
+	}
+	if params.PathGlob != "" {
+		searchDescription += fmt.Sprintf(" matching %q", params.PathGlob)
+	}
+	if input.Subdirectory != "" {
+		searchDescription += fmt.Sprintf(" within %q", input.Subdirectory)
+	}
+	if len(results) == 0 {
+		return searchDescription + "\nNo results found.", nil
+	}
+
+	return searchDescription + "\n" + formatStructuralSearchResults(results), nil
+}
+
+func formatStructuralSearchResults(results []tree_sitter.StructuralSearchFileResult) string {
+	var out strings.Builder
+	var filePaths []string
+	for _, result := range results {
+		filePaths = append(filePaths, result.FilePath)
+		fence := coding.CodeFenceStartForLanguage(utils.InferLanguageNameFromFilePath(result.FilePath))
+		for _, sourceBlock := range result.SourceBlocks {
+			fmt.Fprintf(&out, "\nFile: %s\nLines: %d-%d\n%s%s\n```\n", result.FilePath, sourceBlock.Range.StartPoint.Row+1, sourceBlock.Range.EndPoint.Row+1, fence, strings.TrimRight(sourceBlock.String(), "\n"))
+		}
+	}
+
+	if out.Len() > refuseAtSearchOutputLength {
+		fileList := strings.Join(filePaths, "\n")
+		if len(fileList) > maxSearchOutputLength {
+			return "Search output is too long, and even the list of files that matched is too long. Try a more constrained path glob and/or a more specific pattern or query. Alternatively, skip doing this search entirely if it's not essential."
+		}
+		return fmt.Sprintf("Search output is too long. You could try with fewer context lines, a more constrained path glob and a more specific pattern or query. Alternatively, skip doing this search entirely if it's not essential. Here is the list of matching files:\n\n%s", fileList)
+	}
+	return out.String()
+}
@@ -0,0 +1,43 @@
+package dev
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sidekick/env"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructuralSearchActivity(t *testing.T) {
+	t.Parallel()
+	repoDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "flow.go"), []byte("package flows\n\nfunc run(ctx workflow.Context) {\n\tworkflow.ExecuteActivity(ctx, A)\n\n\tworkflow.ExecuteActivity(ctx, B)\n}\n"), 0644))
+	envContainer := env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: repoDir}}
+	da := &DevActivities{}
+
+	result, err := da.StructuralSearchActivity(context.Background(), StructuralSearchActivityInput{
+		EnvContainer: envContainer,
+		Params:       StructuralSearchParams{Language: "golang", Pattern: "workflow.ExecuteActivity($CTX, $$$)"},
+	})
+	require.NoError(t, err)
+	expected := "Structural search for \"workflow.ExecuteActivity($CTX, $$$)\" in golang files\n" +
+		"\nFile: flow.go\nLines: 4-6\n```go\n\tworkflow.ExecuteActivity(ctx, A)\n\n\tworkflow.ExecuteActivity(ctx, B)\n```\n"
+	assert.Equal(t, expected, result)
+
+	result, err = da.StructuralSearchActivity(context.Background(), StructuralSearchActivityInput{
+		EnvContainer: envContainer,
+		Params:       StructuralSearchParams{Language: "golang", Query: "(call_expression) @match", PathGlob: "other/**"},
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(result, "No results found."))
+
+	_, err = da.StructuralSearchActivity(context.Background(), StructuralSearchActivityInput{
+		EnvContainer: envContainer,
+		Params:       StructuralSearchParams{Language: "golang", Pattern: "func ("},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,59 @@
+package dev
+
+import (
+	"fmt"
+	"sidekick/coding/git"
+	"sidekick/common"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+func selectTestCommands(c common.RepoConfig) []common.CommandConfig {
+	return c.TestCommands
+}
+
+func selectIntegrationTestCommands(c common.RepoConfig) []common.CommandConfig {
+	return c.IntegrationTestCommands
+}
+
+func selectCheckCommands(c common.RepoConfig) []common.CommandConfig {
+	return c.CheckCommands
+}
+
+func selectAutofixCommands(c common.RepoConfig) []common.CommandConfig {
+	return c.AutofixCommands
+}
+
+// commandsForChanges returns the commands that apply to the files changed by
+// the task so far, so that in a monorepo only the affected sub-projects are
+// tested. Before anything changed, the task's own sub-project, or else the
+// root config, determines the commands.
+func commandsForChanges(dCtx DevContext, getCommands func(common.RepoConfig) []common.CommandConfig) ([]common.CommandConfig, error) {
+	if len(dCtx.RepoConfig.SubProjects) == 0 {
+		return getCommands(dCtx.RepoConfig), nil
+	}
+	v := workflow.GetVersion(dCtx, "sub-project-commands", workflow.DefaultVersion, 1)
+	if v < 1 {
+		return getCommands(dCtx.RepoConfig), nil
+	}
+
+	var changedFiles []string
+	err := workflow.ExecuteActivity(dCtx, git.GitChangedFilesActivity, *dCtx.EnvContainer, dCtx.BaseBranch).Get(dCtx, &changedFiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed files: %v", err)
+	}
+	if len(changedFiles) == 0 {
+		changedFiles = []string{dCtx.SubProject}
+	}
+	return dCtx.RepoConfig.CommandsForPaths(changedFiles, getCommands), nil
+}
+
+// runTestsForChanges runs the commands that apply to the task's changes, see
+// commandsForChanges
+func runTestsForChanges(dCtx DevContext, getCommands func(common.RepoConfig) []common.CommandConfig) (TestResult, error) {
+	commands, err := commandsForChanges(dCtx, getCommands)
+	if err != nil {
+		return TestResult{}, err
+	}
+	return RunTests(dCtx, commands)
+}
@@ -0,0 +1,159 @@
+package dev
+
+import (
+	"strings"
+)
+
+const devNull = "/dev/null"
+
+// ExtractUnifiedDiffEditBlocks extracts edit blocks from unified diffs within
+// fenced code blocks. Each hunk becomes its own "update" edit block: the old
+// lines are the hunk's context and removed lines, and the new lines are its
+// context and added lines. Hunks are thus located via the same fuzzy matching
+// as search/replace edit blocks rather than via their line numbers, which
+// models frequently get wrong. Diffs from /dev/null create files and diffs to
+// /dev/null delete them, while git-style "rename from" and "rename to" lines
+// move them.
+//
+// Hunks are numbered in order of appearance, starting from 1, since unified
+// diffs have no place for an explicit sequence number.
+func ExtractUnifiedDiffEditBlocks(text string) ([]*EditBlock, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	var blocks []*EditBlock
+	var hunk *EditBlock // the block currently being added to, if any
+	var oldPath, newPath string
+	renamedPaths := map[string]string{} // old path to new path
+	sequenceNumber := 0
+	inCodeBlock := false
+	trailingBareEmptyLines := 0
+
+	addContextLine := func(line string) {
+		// new files have no old lines, so context is just more new lines
+		if hunk.EditType == "update" {
+			hunk.OldLines = append(hunk.OldLines, line)
+		}
+		hunk.NewLines = append(hunk.NewLines, line)
+	}
+	endHunk := func() {
+		if hunk != nil {
+			// models tend to leave a blank line before the closing fence, which
+			// would otherwise be treated as an extra context line
+			n := trailingBareEmptyLines
+			if hunk.EditType == "update" {
+				hunk.OldLines = hunk.OldLines[:len(hunk.OldLines)-n]
+			}
+			hunk.NewLines = hunk.NewLines[:len(hunk.NewLines)-n]
+		}
+		hunk = nil
+		trailingBareEmptyLines = 0
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if strings.HasPrefix(line, "```") {
+			endHunk()
+			inCodeBlock = !inCodeBlock
+			oldPath, newPath = "", ""
+			continue
+		}
+		if !inCodeBlock {
+			continue
+		}
+
+		if strings.HasPrefix(line, "rename from ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "rename to ") {
+			endHunk()
+			renameFrom := parseUnifiedDiffPath(strings.TrimPrefix(line, "rename from "))
+			renameTo := parseUnifiedDiffPath(strings.TrimPrefix(lines[i+1], "rename to "))
+			i++
+			sequenceNumber++
+			blocks = append(blocks, &EditBlock{FilePath: renameFrom, NewFilePath: renameTo, EditType: "rename", SequenceNumber: sequenceNumber})
+			renamedPaths[renameFrom] = renameTo
+			continue
+		}
+
+		// a removed line can start with "--- " too, so only a "---" and "+++"
+		// pair is treated as a file header
+		if strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ") {
+			endHunk()
+			oldPath = parseUnifiedDiffPath(line[4:])
+			newPath = parseUnifiedDiffPath(lines[i+1][4:])
+			i++
+			if newPath == devNull && oldPath != devNull {
+				sequenceNumber++
+				blocks = append(blocks, &EditBlock{FilePath: oldPath, EditType: "delete", SequenceNumber: sequenceNumber})
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "@@") {
+			if oldPath == "" || newPath == devNull {
+				endHunk()
+				continue
+			}
+			if oldPath == devNull {
+				// all hunks of a new file contribute to a single create block
+				if hunk == nil || hunk.EditType != "create" {
+					sequenceNumber++
+					hunk = &EditBlock{FilePath: newPath, EditType: "create", SequenceNumber: sequenceNumber}
+					blocks = append(blocks, hunk)
+				}
+				continue
+			}
+			endHunk()
+			sequenceNumber++
+			// hunks following a rename apply after the file was moved
+			filePath := oldPath
+			if renamedPaths[oldPath] == newPath {
+				filePath = newPath
+			}
+			hunk = &EditBlock{FilePath: filePath, EditType: "update", SequenceNumber: sequenceNumber}
+			blocks = append(blocks, hunk)
+			continue
+		}
+
+		if hunk == nil {
+			continue
+		}
+
+		switch {
+		case line == "":
+			addContextLine("")
+			trailingBareEmptyLines++
+			continue
+		case line[0] == ' ':
+			addContextLine(line[1:])
+		case line[0] == '-':
+			if hunk.EditType == "update" {
+				hunk.OldLines = append(hunk.OldLines, line[1:])
+			}
+		case line[0] == '+':
+			hunk.NewLines = append(hunk.NewLines, line[1:])
+		case line[0] == '\\':
+			// eg "\ No newline at end of file"
+		default:
+			// anything else ends the hunk
+			endHunk()
+			continue
+		}
+		trailingBareEmptyLines = 0
+	}
+	endHunk()
+
+	return blocks, nil
+}
+
+// parseUnifiedDiffPath strips the conventional a/ and b/ prefixes, along with
+// any trailing timestamp, from a file header path
+func parseUnifiedDiffPath(path string) string {
+	path, _, _ = strings.Cut(path, "\t")
+	path = strings.TrimSpace(path)
+	if path == devNull {
+		return path
+	}
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		path = path[2:]
+	}
+	return path
+}
@@ -0,0 +1,113 @@
+package dev
+
+import (
+	"sidekick/common"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractUnifiedDiffEditBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []*EditBlock
+	}{
+		{
+			name:  "single hunk",
+			input: "Some explanation\n```diff\n--- a/path/to/file.go\n+++ b/path/to/file.go\n@@ -10,4 +10,5 @@ func main() {\n \tif err != nil {\n-\t\treturn err\n+\t\treturn fmt.Errorf(\"failed: %w\", err)\n \t}\n+\treturn nil\n```\n",
+			expected: []*EditBlock{
+				{
+					FilePath:       "path/to/file.go",
+					OldLines:       []string{"\tif err != nil {", "\t\treturn err", "\t}"},
+					NewLines:       []string{"\tif err != nil {", "\t\treturn fmt.Errorf(\"failed: %w\", err)", "\t}", "\treturn nil"},
+					EditType:       "update",
+					SequenceNumber: 1,
+				},
+			},
+		},
+		{
+			name:  "multiple hunks and files are numbered in order",
+			input: "```diff\n--- a.go\n+++ a.go\n@@ @@\n a\n-b\n+c\n@@ @@\n x\n+y\n```\n\n```diff\n--- b.go\n+++ b.go\n@@ @@\n-d\n+e\n```\n",
+			expected: []*EditBlock{
+				{FilePath: "a.go", OldLines: []string{"a", "b"}, NewLines: []string{"a", "c"}, EditType: "update", SequenceNumber: 1},
+				{FilePath: "a.go", OldLines: []string{"x"}, NewLines: []string{"x", "y"}, EditType: "update", SequenceNumber: 2},
+				{FilePath: "b.go", OldLines: []string{"d"}, NewLines: []string{"e"}, EditType: "update", SequenceNumber: 3},
+			},
+		},
+		{
+			name:  "blank context lines and trailing blank line",
+			input: "```diff\n--- a.go\n+++ a.go\n@@ @@\n a\n\n-b\n+c\n\n```\n",
+			expected: []*EditBlock{
+				{FilePath: "a.go", OldLines: []string{"a", "", "b"}, NewLines: []string{"a", "", "c"}, EditType: "update", SequenceNumber: 1},
+			},
+		},
+		{
+			name:  "removed line that looks like a file header",
+			input: "```diff\n--- schema.sql\n+++ schema.sql\n@@ @@\n--- old comment\n+-- new comment\n SELECT 1;\n```\n",
+			expected: []*EditBlock{
+				{FilePath: "schema.sql", OldLines: []string{"-- old comment", "SELECT 1;"}, NewLines: []string{"-- new comment", "SELECT 1;"}, EditType: "update", SequenceNumber: 1},
+			},
+		},
+		{
+			name:  "create and delete files",
+			input: "```diff\n--- /dev/null\n+++ b/new.json\n@@ -0,0 +1,2 @@\n+{\n+}\n\\ No newline at end of file\n```\n```diff\n--- a/old.json\n+++ /dev/null\n```\n",
+			expected: []*EditBlock{
+				{FilePath: "new.json", NewLines: []string{"{", "}"}, EditType: "create", SequenceNumber: 1},
+				{FilePath: "old.json", EditType: "delete", SequenceNumber: 2},
+			},
+		},
+		{
+			name:  "rename with changes",
+			input: "```diff\nrename from src/old.ts\nrename to src/new/old.ts\n--- a/src/old.ts\n+++ b/src/new/old.ts\n@@ @@\n-import x from './x';\n+import x from '../x';\n```\n",
+			expected: []*EditBlock{
+				{FilePath: "src/old.ts", NewFilePath: "src/new/old.ts", EditType: "rename", SequenceNumber: 1},
+				{FilePath: "src/new/old.ts", OldLines: []string{"import x from './x';"}, NewLines: []string{"import x from '../x';"}, EditType: "update", SequenceNumber: 2},
+			},
+		},
+		{
+			name:     "diffs outside code fences are ignored",
+			input:    "--- a.go\n+++ a.go\n@@ @@\n-a\n+b\n",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, err := ExtractUnifiedDiffEditBlocks(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, blocks)
+		})
+	}
+}
+
+func TestExtractEditBlocksForFormat(t *testing.T) {
+	diffText := "```diff\n--- a.go\n+++ a.go\n@@ @@\n-a\n+b\n```\n"
+	blocks, err := ExtractEditBlocksForFormat(diffText, common.EditFormatUnifiedDiff)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	assert.Equal(t, "update", blocks[0].EditType)
+
+	// search/replace edit blocks don't pick up diffs
+	blocks, err = ExtractEditBlocksForFormat(diffText, common.EditFormatSearchReplace)
+	require.NoError(t, err)
+	assert.Empty(t, blocks)
+
+	_, err = ExtractEditBlocksForFormat(diffText, "patch")
+	assert.Error(t, err)
+}
+
+func TestGetUpdatedContentsFromUnifiedDiffHunk(t *testing.T) {
+	original := "func main() {\n\tif err != nil {\n\t\treturn err\n\t}\n}\n"
+	// the hunk's context has different indentation from the file, as models
+	// sometimes produce
+	blocks, err := ExtractUnifiedDiffEditBlocks("```diff\n--- main.go\n+++ main.go\n@@ -2,3 +2,3 @@\n     if err != nil {\n-\t\treturn err\n+\t\treturn nil\n \t}\n```\n")
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+
+	updated, err := getUpdatedContents(*blocks[0], original)
+	require.NoError(t, err)
+	assert.Contains(t, updated, "\t\treturn nil\n")
+	assert.NotContains(t, updated, "return err")
+}
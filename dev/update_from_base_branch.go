@@ -0,0 +1,140 @@
+package dev
+
+import (
+	"fmt"
+	"sidekick/coding/git"
+	"sidekick/domain"
+	"sidekick/env"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+type UpdateFromBaseBranchParams struct {
+	Requirements  string // used to guide the resolution of any merge conflicts
+	BaseBranch    string
+	CommitMessage string // for committing pending work first, if any
+}
+
+func hasLockfileChanges(changedFiles []string) bool {
+	for _, changedFile := range changedFiles {
+		if env.IsLockfile(changedFile) {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateFromBaseBranch commits any pending work, then brings the latest
+// changes from the base branch into the worktree's branch using the repo's
+// configured strategy, resolving conflicts if needed. The worktree setup
+// script is run again if any lockfiles changed. Returns the output of failing
+// tests afterwards, to be fed back into the coding loop, or an empty string if
+// there's nothing to fix.
+func UpdateFromBaseBranch(dCtx DevContext, params UpdateFromBaseBranchParams) (string, error) {
+	return RunSubflow(dCtx, "update_from_base_branch", "Update From Base Branch", func(_ domain.Subflow) (string, error) {
+		return updateFromBaseBranchSubflow(dCtx, params)
+	})
+}
+
+func updateFromBaseBranchSubflow(dCtx DevContext, params UpdateFromBaseBranchParams) (string, error) {
+	if err := git.GitAddAll(dCtx.ExecContext); err != nil {
+		return "", fmt.Errorf("failed to git add all: %v", err)
+	}
+	commitMessage := params.CommitMessage
+	if commitMessage == "" {
+		commitMessage = fmt.Sprintf("Save work in progress before updating from %s", params.BaseBranch)
+	}
+	if err := git.GitCommit(dCtx.ExecContext, commitMessage, dCtx.RepoConfig.Commit); err != nil {
+		return "", err
+	}
+
+	actionCtx := dCtx.NewActionContext("update_from_base_branch")
+	actionCtx.ActionParams = map[string]any{
+		"baseBranch": params.BaseBranch,
+		"strategy":   dCtx.RepoConfig.BaseBranchUpdateStrategy,
+	}
+	updateResult, err := Track(actionCtx, func(flowAction domain.FlowAction) (git.UpdateFromBaseBranchResult, error) {
+		var updateResult git.UpdateFromBaseBranchResult
+		err := workflow.ExecuteActivity(dCtx, git.UpdateFromBaseBranchActivity, *dCtx.EnvContainer, git.UpdateFromBaseBranchParams{
+			BaseBranch: params.BaseBranch,
+			Strategy:   git.UpdateStrategy(dCtx.RepoConfig.BaseBranchUpdateStrategy),
+		}).Get(dCtx, &updateResult)
+		return updateResult, err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to update from %s: %v", params.BaseBranch, err)
+	}
+	if !updateResult.Updated {
+		return "", nil
+	}
+
+	if updateResult.HasConflicts {
+		resolved, err := ResolveMergeConflicts(dCtx, ResolveMergeConflictsParams{
+			Requirements: params.Requirements,
+			SourceBranch: dCtx.Worktree.Name,
+			TargetBranch: params.BaseBranch,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve merge conflicts: %v", err)
+		}
+		if !resolved {
+			continueCtx := dCtx.NewActionContext("user_request.continue")
+			message := fmt.Sprintf("Merge conflicts detected at %s. Conflicts are from merging %s into %s. Please resolve conflicts, commit the merge, then continue.", dCtx.EnvContainer.Env.GetWorkingDirectory(), params.BaseBranch, dCtx.Worktree.Name)
+			err := GetUserContinue(continueCtx, message, map[string]any{
+				"continueTag": "done",
+			})
+			if err != nil {
+				return "", fmt.Errorf("failed to get continue approval: %v", err)
+			}
+		}
+	}
+
+	if dCtx.RepoConfig.WorktreeSetup != "" && hasLockfileChanges(updateResult.ChangedFiles) {
+		if err := setUpWorktree(dCtx, *dCtx.EnvContainer, dCtx.WorkspaceId, dCtx.RepoConfig); err != nil {
+			return "", err
+		}
+	}
+
+	testCommands, err := commandsForChanges(dCtx, selectTestCommands)
+	if err != nil {
+		return "", err
+	}
+	if len(testCommands) == 0 {
+		return "", nil
+	}
+	testResult, err := RunTests(dCtx, testCommands)
+	if err != nil {
+		return "", fmt.Errorf("failed to run tests: %v", err)
+	}
+	if !testResult.TestsPassed {
+		return testResult.Output, nil
+	}
+	return "", nil
+}
+
+func isUpdateFromBaseBranchPending(dCtx DevContext) bool {
+	action := dCtx.GlobalState.GetPendingUserAction()
+	return action != nil && *action == UserActionUpdateFromBaseBranch
+}
+
+// handleUpdateFromBaseBranchAction consumes a pending request from the user to
+// update from the base branch and carries it out, returning the prompt to
+// continue coding with
+func handleUpdateFromBaseBranchAction(dCtx DevContext, requirements string) (PromptInfo, error) {
+	dCtx.GlobalState.ConsumePendingUserAction()
+	if dCtx.EnvContainer.Env.GetType() != env.EnvTypeLocalGitWorktree || dCtx.Worktree == nil {
+		return FeedbackInfo{Feedback: "Continue where you left off."}, nil
+	}
+
+	testOutput, err := UpdateFromBaseBranch(dCtx, UpdateFromBaseBranchParams{
+		Requirements: requirements,
+		BaseBranch:   dCtx.BaseBranch,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if testOutput != "" {
+		return FeedbackInfo{Feedback: fmt.Sprintf("The latest changes from %s were just brought into this branch, after which tests are failing. Fix them while continuing where you left off:\n\n%s", dCtx.BaseBranch, testOutput)}, nil
+	}
+	return FeedbackInfo{Feedback: fmt.Sprintf("The latest changes from %s were just brought into this branch. Continue where you left off.", dCtx.BaseBranch)}, nil
+}
@@ -0,0 +1,15 @@
+package dev
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasLockfileChanges(t *testing.T) {
+	t.Parallel()
+	assert.False(t, hasLockfileChanges(nil))
+	assert.False(t, hasLockfileChanges([]string{"main.go", "go.mod", "docs/package-lock.json.md"}))
+	assert.True(t, hasLockfileChanges([]string{"main.go", "go.sum"}))
+	assert.True(t, hasLockfileChanges([]string{"frontend/package-lock.json"}))
+}
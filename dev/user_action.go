@@ -5,10 +5,13 @@ import (
 )
 
 // SetupUserActionHandler sets up a signal handler for user actions like "go_next_step".
-// It listens on the "user_action" signal channel. When a UserActionGoNext is received,
-// it updates the GlobalState.
+// It listens on the "user_action" signal channel. When a UserActionGoNext or
+// UserActionUpdateFromBaseBranch is received, it updates the GlobalState.
+// Rollbacks to a step's checkpoint arrive on their own channel, since they
+// carry the step number.
 func SetupUserActionHandler(dCtx DevContext) {
 	signalChan := workflow.GetSignalChannel(dCtx, SignalNameUserAction)
+	rollbackSignalChan := workflow.GetSignalChannel(dCtx, SignalNameRollbackToCheckpoint)
 
 	workflow.Go(dCtx, func(ctx workflow.Context) {
 		for {
@@ -17,11 +20,15 @@ func SetupUserActionHandler(dCtx DevContext) {
 				var action UserActionType
 				c.Receive(ctx, &action) // Receive the UserActionType from the signal
 
-				// Check if the received action is UserActionGoNext
-				if action == UserActionGoNext {
-					dCtx.GlobalState.SetUserAction(UserActionGoNext)
+				switch action {
+				case UserActionGoNext, UserActionUpdateFromBaseBranch:
+					dCtx.GlobalState.SetUserAction(action)
 				}
-				// Other actions could be handled here in the future with an else if or switch.
+			})
+			selector.AddReceive(rollbackSignalChan, func(c workflow.ReceiveChannel, more bool) {
+				var stepNumber string
+				c.Receive(ctx, &stepNumber)
+				dCtx.GlobalState.SetRollbackToCheckpoint(stepNumber)
 			})
 			selector.Select(ctx)
 			if ctx.Err() != nil { // Exit goroutine if context is done
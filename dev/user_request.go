@@ -2,6 +2,7 @@ package dev
 
 import (
 	"fmt"
+	"sidekick/common"
 	"sidekick/domain"
 	"sidekick/flow_action"
 	"sidekick/llm"
@@ -25,12 +26,21 @@ type MergeApprovalParams struct {
 	DefaultTargetBranch string `json:"defaultTargetBranch"` // the default target branch, which is to be confirmed/overridden by the user
 	SourceBranch        string `json:"sourceBranch"`
 	Diff                string `json:"diff"`
+	OfferSquash         bool   `json:"offerSquash"` // whether the user can choose to squash the source branch's commits when merging
 }
 
+type MergeStrategy string
+
+const (
+	MergeStrategyMerge  MergeStrategy = "merge"  // keep the source branch's commit history
+	MergeStrategySquash MergeStrategy = "squash" // squash the source branch's commits into one before merging
+)
+
 type MergeApprovalResponse struct {
-	Approved     bool   `json:"approved"`
-	TargetBranch string `json:"targetBranch"` // actual target branch selected by the user
-	Message      string `json:"message"`      // feedback message when not approved
+	Approved      bool          `json:"approved"`
+	TargetBranch  string        `json:"targetBranch"`  // actual target branch selected by the user
+	Message       string        `json:"message"`       // feedback message when not approved
+	MergeStrategy MergeStrategy `json:"mergeStrategy"` // empty means MergeStrategyMerge
 }
 
 type RequestForUser struct {
@@ -175,10 +185,12 @@ func GetUserMergeApproval(
 		return MergeApprovalResponse{}, err
 	}
 
+	mergeStrategy, _ := userResponse.Params["mergeStrategy"].(string)
 	return MergeApprovalResponse{
-		Approved:     *userResponse.Approved,
-		TargetBranch: userResponse.Params["targetBranch"].(string),
-		Message:      userResponse.Content,
+		Approved:      *userResponse.Approved,
+		TargetBranch:  userResponse.Params["targetBranch"].(string),
+		Message:       userResponse.Content,
+		MergeStrategy: MergeStrategy(mergeStrategy),
 	}, nil
 }
 
@@ -332,7 +344,10 @@ func GetUserFeedback(dCtx DevContext, currentPromptInfo PromptInfo, guidanceCont
 		feedbackInfo := FeedbackInfo{Feedback: userResponse.Content}
 		return feedbackInfo, nil
 	case InitialDevStepInfo:
-		content := renderAuthorEditBlockInitialDevStepPrompt(dCtx, info.CodeContext, info.Requirements, info.PlanExecution.String(), info.Step.Definition)
+		// the model for the next attempt isn't known here, so assume the
+		// primary coding model's edit format
+		editFormat := dCtx.GetModelConfig(common.CodingKey, 0, "default").NormalizedEditFormat()
+		content := renderAuthorEditBlockInitialDevStepPrompt(dCtx, editFormat, info.CodeContext, info.Requirements, info.PlanExecution.String(), info.Step.Definition)
 		*chatHistory = append(*chatHistory, llm.ChatMessage{
 			Role:    llm.ChatMessageRoleUser,
 			Content: content,
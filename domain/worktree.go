@@ -16,6 +16,41 @@ type Worktree struct {
 	WorkingDirectory string    `json:"workingDirectory"`
 }
 
+// WorktreeOrphanReason explains why a worktree is no longer in use
+type WorktreeOrphanReason string
+
+const (
+	// the worktree's flow has completed, failed or been canceled, or no longer
+	// exists
+	WorktreeOrphanReasonFlowFinished WorktreeOrphanReason = "flow_finished"
+	// the worktree's directory was removed without cleaning up the rest
+	WorktreeOrphanReasonDirMissing WorktreeOrphanReason = "dir_missing"
+	// all of the worktree branch's commits are in the default branch
+	WorktreeOrphanReasonBranchMerged WorktreeOrphanReason = "branch_merged"
+	// the worktree exists in git or on disk, but has no Worktree record
+	WorktreeOrphanReasonUntracked WorktreeOrphanReason = "untracked"
+)
+
+// WorktreeStatus describes a sidekick-managed worktree as found in git and on
+// disk, reconciled with its Worktree record if there is one
+type WorktreeStatus struct {
+	Worktree   *Worktree `json:"worktree,omitempty"` // nil when untracked
+	Path       string    `json:"path"`
+	Branch     string    `json:"branch,omitempty"` // empty when the branch no longer exists
+	FlowStatus string    `json:"flowStatus,omitempty"`
+	DirExists  bool      `json:"dirExists"`
+	// has uncommitted changes, including untracked files
+	Dirty bool `json:"dirty"`
+	// all of the branch's commits are in the default branch and there are no
+	// uncommitted changes
+	Merged bool `json:"merged"`
+	// when the worktree was created, or its directory last modified if untracked
+	Created       time.Time              `json:"created"`
+	OrphanReasons []WorktreeOrphanReason `json:"orphanReasons,omitempty"`
+	// orphaned and past the retention period, or with nothing left to lose
+	Prunable bool `json:"prunable"`
+}
+
 // WorktreeStorage defines the interface for worktree-related database operations
 type WorktreeStorage interface {
 	PersistWorktree(ctx context.Context, worktree Worktree) error
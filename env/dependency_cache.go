@@ -0,0 +1,408 @@
+package env
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"sidekick/common"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/segmentio/ksuid"
+)
+
+// lockfileNames are the files that pin a project's dependencies, so changes to
+// them usually mean dependencies need to be installed again
+var lockfileNames = map[string]bool{
+	"go.sum":            true,
+	"package-lock.json": true,
+	"yarn.lock":         true,
+	"pnpm-lock.yaml":    true,
+	"bun.lockb":         true,
+	"bun.lock":          true,
+	"Cargo.lock":        true,
+	"poetry.lock":       true,
+	"uv.lock":           true,
+	"Pipfile.lock":      true,
+	"Gemfile.lock":      true,
+	"composer.lock":     true,
+	"mix.lock":          true,
+	"pubspec.lock":      true,
+	"Podfile.lock":      true,
+	"gradle.lockfile":   true,
+}
+
+func IsLockfile(path string) bool {
+	return lockfileNames[filepath.Base(path)]
+}
+
+// the number of most recently used cache entries kept per workspace
+const maxDependencyCacheEntries = 3
+
+const dependencyCacheManifestName = "manifest.json"
+
+type CloneMethod string
+
+const (
+	CloneMethodReflink CloneMethod = "reflink"
+	CloneMethodCopy    CloneMethod = "copy"
+)
+
+// clone methods in the order they are attempted, from cheapest to slowest.
+// Hardlinks aren't among them: dependency managers and build tools modify
+// files in place, eg a venv's scripts or node_modules/.cache, which would
+// corrupt the cache and every other worktree restored from it.
+var cloneMethods = []CloneMethod{CloneMethodReflink, CloneMethodCopy}
+
+type DependencyCacheParams struct {
+	EnvContainer EnvContainer
+	WorkspaceId  string
+	// globs relative to the working directory, eg "node_modules" or
+	// "frontend/node_modules"
+	Paths []string
+	// the worktree setup script, which is part of the cache key since it
+	// determines what ends up in the cached paths
+	SetupScript string
+}
+
+type RestoreDependencyCacheResult struct {
+	// identifies the cache entry for the current lockfiles, to save to if it
+	// wasn't restored
+	Key      string
+	Restored bool
+	Method   CloneMethod `json:",omitempty"`
+}
+
+type dependencyCacheManifest struct {
+	// cached paths relative to the working directory
+	Paths []string `json:"paths"`
+}
+
+func dependencyCacheDir(workspaceId string) (string, error) {
+	sidekickDataHome, err := common.GetSidekickDataHome()
+	if err != nil {
+		return "", fmt.Errorf("failed to get Sidekick data home: %w", err)
+	}
+	return filepath.Join(sidekickDataHome, "dependency_cache", workspaceId), nil
+}
+
+// dependencyCacheKey hashes the contents of all lockfiles tracked by git in
+// the working directory, along with everything else that affects what gets
+// cached
+func dependencyCacheKey(ctx context.Context, params DependencyCacheParams) (string, error) {
+	output, err := params.EnvContainer.Env.RunCommand(ctx, EnvRunCommandInput{
+		Command: "git",
+		Args:    []string{"ls-files", "-z"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list tracked files: %w", err)
+	}
+	if output.ExitStatus != 0 {
+		return "", fmt.Errorf("git ls-files failed with exit status %d: %s", output.ExitStatus, output.Stderr)
+	}
+
+	var lockfiles []string
+	for _, path := range strings.Split(output.Stdout, "\x00") {
+		if path != "" && IsLockfile(path) {
+			lockfiles = append(lockfiles, path)
+		}
+	}
+	sort.Strings(lockfiles)
+
+	hash := sha256.New()
+	workingDir := params.EnvContainer.Env.GetWorkingDirectory()
+	for _, lockfile := range lockfiles {
+		content, err := os.ReadFile(filepath.Join(workingDir, lockfile))
+		if err != nil {
+			return "", fmt.Errorf("failed to read lockfile %s: %w", lockfile, err)
+		}
+		contentHash := sha256.Sum256(content)
+		fmt.Fprintf(hash, "lockfile %s %x\n", lockfile, contentHash)
+	}
+	paths := slices.Clone(params.Paths)
+	sort.Strings(paths)
+	for _, path := range paths {
+		fmt.Fprintf(hash, "path %s\n", path)
+	}
+	fmt.Fprintf(hash, "setup %s\n", params.SetupScript)
+	return hex.EncodeToString(hash.Sum(nil))[:32], nil
+}
+
+// RestoreDependencyCacheActivity clones the cached dependency directories
+// matching the working directory's current lockfiles into it, if there are
+// any. Any existing directories at the cached paths are replaced.
+func RestoreDependencyCacheActivity(ctx context.Context, params DependencyCacheParams) (RestoreDependencyCacheResult, error) {
+	key, err := dependencyCacheKey(ctx, params)
+	if err != nil {
+		return RestoreDependencyCacheResult{}, err
+	}
+	result := RestoreDependencyCacheResult{Key: key}
+
+	cacheDir, err := dependencyCacheDir(params.WorkspaceId)
+	if err != nil {
+		return result, err
+	}
+	entryDir := filepath.Join(cacheDir, key)
+	manifestBytes, err := os.ReadFile(filepath.Join(entryDir, dependencyCacheManifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return result, nil
+	} else if err != nil {
+		return result, fmt.Errorf("failed to read dependency cache manifest: %w", err)
+	}
+	var manifest dependencyCacheManifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return result, fmt.Errorf("failed to parse dependency cache manifest: %w", err)
+	}
+
+	workingDir := params.EnvContainer.Env.GetWorkingDirectory()
+	method := CloneMethodReflink
+	for _, path := range manifest.Paths {
+		dst := filepath.Join(workingDir, path)
+		if err := os.RemoveAll(dst); err != nil {
+			return result, fmt.Errorf("failed to remove %s before restoring it from the dependency cache: %w", path, err)
+		}
+		pathMethod, err := clonePath(ctx, filepath.Join(entryDir, "files", path), dst)
+		if err != nil {
+			return result, fmt.Errorf("failed to restore %s from the dependency cache: %w", path, err)
+		}
+		// reports the slowest method used
+		if slices.Index(cloneMethods, pathMethod) > slices.Index(cloneMethods, method) {
+			method = pathMethod
+		}
+	}
+
+	// marks the entry as recently used, so it's the last to be evicted
+	now := time.Now()
+	_ = os.Chtimes(entryDir, now, now)
+
+	result.Restored = true
+	result.Method = method
+	return result, nil
+}
+
+// SaveDependencyCacheActivity copies the dependency directories matching the
+// configured paths from the working directory into the cache under the given
+// key, then evicts the least recently used entries beyond the limit
+func SaveDependencyCacheActivity(ctx context.Context, params DependencyCacheParams, key string) error {
+	cacheDir, err := dependencyCacheDir(params.WorkspaceId)
+	if err != nil {
+		return err
+	}
+	entryDir := filepath.Join(cacheDir, key)
+	if _, err := os.Stat(entryDir); err == nil {
+		return nil
+	}
+
+	workingDir := params.EnvContainer.Env.GetWorkingDirectory()
+	paths, err := matchDependencyPaths(workingDir, params.Paths)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	// entries are built in a temporary directory and then renamed, so that a
+	// partially written entry is never restored
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create dependency cache directory: %w", err)
+	}
+	tmpDir := filepath.Join(cacheDir, ".tmp-"+key+"-"+ksuid.New().String())
+	defer os.RemoveAll(tmpDir)
+	for _, path := range paths {
+		dst := filepath.Join(tmpDir, "files", path)
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return fmt.Errorf("failed to create dependency cache directory: %w", err)
+		}
+		if _, err := clonePath(ctx, filepath.Join(workingDir, path), dst); err != nil {
+			return fmt.Errorf("failed to cache %s: %w", path, err)
+		}
+	}
+	manifestBytes, err := json.Marshal(dependencyCacheManifest{Paths: paths})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, dependencyCacheManifestName), manifestBytes, 0644); err != nil {
+		return fmt.Errorf("failed to write dependency cache manifest: %w", err)
+	}
+	if err := os.Rename(tmpDir, entryDir); err != nil {
+		// another worktree saved the same entry first
+		if _, statErr := os.Stat(entryDir); statErr == nil {
+			return nil
+		}
+		return fmt.Errorf("failed to save dependency cache entry: %w", err)
+	}
+
+	return evictDependencyCacheEntries(cacheDir, maxDependencyCacheEntries)
+}
+
+// matchDependencyPaths expands the given globs within the working directory,
+// skipping matches nested within other matches
+func matchDependencyPaths(workingDir string, globs []string) ([]string, error) {
+	fsys := os.DirFS(workingDir)
+	matched := make(map[string]bool)
+	for _, glob := range globs {
+		glob = filepath.ToSlash(filepath.Clean(glob))
+		if !doublestar.ValidatePattern(glob) || glob == "." || strings.HasPrefix(glob, "../") || filepath.IsAbs(glob) {
+			return nil, fmt.Errorf("invalid dependency cache path %q: must be a glob relative to the repo root", glob)
+		}
+		matches, err := doublestar.Glob(fsys, glob, doublestar.WithNoFollow())
+		if err != nil {
+			return nil, fmt.Errorf("failed to match dependency cache path %q: %w", glob, err)
+		}
+		for _, match := range matches {
+			matched[match] = true
+		}
+	}
+
+	var paths []string
+	for match := range matched {
+		paths = append(paths, match)
+	}
+	sort.Strings(paths)
+	var result []string
+	for _, path := range paths {
+		if len(result) > 0 && strings.HasPrefix(path, result[len(result)-1]+"/") {
+			continue
+		}
+		result = append(result, path)
+	}
+	for i := range result {
+		result[i] = filepath.FromSlash(result[i])
+	}
+	return result, nil
+}
+
+func evictDependencyCacheEntries(cacheDir string, maxEntries int) error {
+	dirEntries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return fmt.Errorf("failed to read dependency cache directory: %w", err)
+	}
+	type cacheEntry struct {
+		path    string
+		modTime int64
+	}
+	var entries []cacheEntry
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() || strings.HasPrefix(dirEntry.Name(), ".") {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, cacheEntry{path: filepath.Join(cacheDir, dirEntry.Name()), modTime: info.ModTime().UnixNano()})
+	}
+	if len(entries) <= maxEntries {
+		return nil
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime > entries[j].modTime
+	})
+	for _, entry := range entries[maxEntries:] {
+		if err := os.RemoveAll(entry.path); err != nil {
+			return fmt.Errorf("failed to evict dependency cache entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// clonePath clones src to dst, which must not exist yet, with a copy-on-write
+// clone where the filesystem supports it, and a plain copy otherwise. Either
+// way, dst shares no files with src that modifying one would change in the
+// other.
+func clonePath(ctx context.Context, src, dst string) (CloneMethod, error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return "", err
+	}
+	if err := reflinkPath(ctx, src, dst); err == nil {
+		return CloneMethodReflink, nil
+	}
+	_ = os.RemoveAll(dst)
+	if err := copyPath(src, dst); err != nil {
+		return "", err
+	}
+	return CloneMethodCopy, nil
+}
+
+// reflinkPath makes a copy-on-write clone, which only works on filesystems
+// that support it, eg btrfs, xfs and apfs
+func reflinkPath(ctx context.Context, src, dst string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux":
+		cmd = exec.CommandContext(ctx, "cp", "-a", "--reflink=always", src, dst)
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "cp", "-Rpc", src, dst)
+	default:
+		return fmt.Errorf("reflinks are not supported on %s", runtime.GOOS)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+	return nil
+}
+
+// copyPath recursively copies src to dst, preserving symlinks and file modes
+func copyPath(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		default:
+			// sockets, devices etc aren't meaningful in a dependency directory
+			return nil
+		}
+	})
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,174 @@
+package env
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupDependencyCacheRepo creates a git repo with a tracked lockfile and an
+// untracked node_modules directory
+func setupDependencyCacheRepo(t *testing.T, lockfileContent string) EnvContainer {
+	t.Helper()
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "frontend", "package-lock.json"), lockfileContent)
+	writeTestFile(t, filepath.Join(dir, ".gitignore"), "node_modules\n")
+	for _, args := range [][]string{{"init"}, {"add", "."}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	return EnvContainer{Env: &LocalGitWorktreeEnv{WorkingDirectory: dir}}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestDependencyCache(t *testing.T) {
+	t.Setenv("SIDE_DATA_HOME", t.TempDir())
+	ctx := context.Background()
+
+	first := setupDependencyCacheRepo(t, `{"lockfileVersion": 3}`)
+	firstDir := first.Env.GetWorkingDirectory()
+	writeTestFile(t, filepath.Join(firstDir, "frontend", "node_modules", "left-pad", "index.js"), "module.exports = 1")
+	require.NoError(t, os.MkdirAll(filepath.Join(firstDir, "frontend", "node_modules", ".bin"), 0755))
+	require.NoError(t, os.Symlink("../left-pad/index.js", filepath.Join(firstDir, "frontend", "node_modules", ".bin", "left-pad")))
+
+	params := DependencyCacheParams{
+		EnvContainer: first,
+		WorkspaceId:  "ws_1",
+		Paths:        []string{"**/node_modules"},
+		SetupScript:  "cd frontend && npm ci",
+	}
+	result, err := RestoreDependencyCacheActivity(ctx, params)
+	require.NoError(t, err)
+	assert.False(t, result.Restored)
+	require.NotEmpty(t, result.Key)
+	require.NoError(t, SaveDependencyCacheActivity(ctx, params, result.Key))
+
+	t.Run("restores into a worktree with the same lockfiles", func(t *testing.T) {
+		second := setupDependencyCacheRepo(t, `{"lockfileVersion": 3}`)
+		secondDir := second.Env.GetWorkingDirectory()
+		// stale directories are replaced
+		writeTestFile(t, filepath.Join(secondDir, "frontend", "node_modules", "stale.js"), "")
+		secondParams := params
+		secondParams.EnvContainer = second
+
+		secondResult, err := RestoreDependencyCacheActivity(ctx, secondParams)
+		require.NoError(t, err)
+		assert.True(t, secondResult.Restored)
+		assert.Equal(t, result.Key, secondResult.Key)
+		assert.Contains(t, cloneMethods, secondResult.Method)
+
+		content, err := os.ReadFile(filepath.Join(secondDir, "frontend", "node_modules", "left-pad", "index.js"))
+		require.NoError(t, err)
+		assert.Equal(t, "module.exports = 1", string(content))
+		link, err := os.Readlink(filepath.Join(secondDir, "frontend", "node_modules", ".bin", "left-pad"))
+		require.NoError(t, err)
+		assert.Equal(t, "../left-pad/index.js", link)
+		assert.NoFileExists(t, filepath.Join(secondDir, "frontend", "node_modules", "stale.js"))
+
+		// modifying restored files in place leaves the cache intact
+		require.NoError(t, os.WriteFile(filepath.Join(secondDir, "frontend", "node_modules", "left-pad", "index.js"), []byte("modified"), 0644))
+		third := setupDependencyCacheRepo(t, `{"lockfileVersion": 3}`)
+		thirdParams := params
+		thirdParams.EnvContainer = third
+		thirdResult, err := RestoreDependencyCacheActivity(ctx, thirdParams)
+		require.NoError(t, err)
+		assert.True(t, thirdResult.Restored)
+		content, err = os.ReadFile(filepath.Join(third.Env.GetWorkingDirectory(), "frontend", "node_modules", "left-pad", "index.js"))
+		require.NoError(t, err)
+		assert.Equal(t, "module.exports = 1", string(content))
+	})
+
+	t.Run("misses when lockfiles differ", func(t *testing.T) {
+		other := setupDependencyCacheRepo(t, `{"lockfileVersion": 3, "packages": {}}`)
+		otherParams := params
+		otherParams.EnvContainer = other
+
+		otherResult, err := RestoreDependencyCacheActivity(ctx, otherParams)
+		require.NoError(t, err)
+		assert.False(t, otherResult.Restored)
+		assert.NotEqual(t, result.Key, otherResult.Key)
+	})
+
+	t.Run("misses when the setup script differs", func(t *testing.T) {
+		otherParams := params
+		otherParams.SetupScript = "cd frontend && npm install"
+		otherResult, err := RestoreDependencyCacheActivity(ctx, otherParams)
+		require.NoError(t, err)
+		assert.False(t, otherResult.Restored)
+	})
+}
+
+func TestMatchDependencyPaths(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "node_modules", "a", "node_modules", "b", "index.js"), "")
+	writeTestFile(t, filepath.Join(dir, "frontend", "node_modules", "c", "index.js"), "")
+	writeTestFile(t, filepath.Join(dir, ".venv", "bin", "python"), "")
+
+	paths, err := matchDependencyPaths(dir, []string{"**/node_modules", ".venv", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{".venv", filepath.Join("frontend", "node_modules"), "node_modules"}, paths)
+
+	_, err = matchDependencyPaths(dir, []string{"../elsewhere"})
+	assert.Error(t, err)
+	_, err = matchDependencyPaths(dir, []string{"."})
+	assert.Error(t, err)
+}
+
+func TestEvictDependencyCacheEntries(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+	now := time.Now()
+	for i, name := range []string{"oldest", "older", "newer", "newest"} {
+		entryDir := filepath.Join(cacheDir, name)
+		require.NoError(t, os.MkdirAll(entryDir, 0755))
+		modTime := now.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, os.Chtimes(entryDir, modTime, modTime))
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(cacheDir, ".tmp-in-progress"), 0755))
+
+	require.NoError(t, evictDependencyCacheEntries(cacheDir, 2))
+
+	entries, err := os.ReadDir(cacheDir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{".tmp-in-progress", "newer", "newest"}, names)
+}
+
+func TestCopyPath(t *testing.T) {
+	t.Parallel()
+	src := t.TempDir()
+	writeTestFile(t, filepath.Join(src, "pkg", "index.js"), "content")
+	require.NoError(t, os.Chmod(filepath.Join(src, "pkg", "index.js"), 0755))
+	require.NoError(t, os.Symlink("pkg/index.js", filepath.Join(src, "link")))
+
+	dst := filepath.Join(t.TempDir(), "dst")
+	require.NoError(t, copyPath(src, dst))
+
+	info, err := os.Stat(filepath.Join(dst, "pkg", "index.js"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	srcInfo, err := os.Stat(filepath.Join(src, "pkg", "index.js"))
+	require.NoError(t, err)
+	assert.False(t, os.SameFile(srcInfo, info))
+
+	link, err := os.Readlink(filepath.Join(dst, "link"))
+	require.NoError(t, err)
+	assert.Equal(t, "pkg/index.js", link)
+}
@@ -36,6 +36,8 @@ type EnvRunCommandInput struct {
 	Command            string
 	Args               []string
 	EnvVars            []string
+	// Category labels the command in metrics, eg "run_tests" or "search"
+	Category string
 }
 
 type EnvRunCommandOutput = unix.RunCommandActivityOutput
@@ -73,9 +75,11 @@ func NewLocalGitWorktreeEnv(ctx context.Context, params LocalEnvParams, worktree
 	}
 
 	// Create worktree directory
-	// dirName combines original repo name and suffix of branch name, for better DX
+	// dirName combines original repo name and suffix of branch name, for better DX.
+	// Slashes are flattened so each worktree is a direct child of the
+	// workspace's worktree dir, which is where the worktree inventory looks.
 	repoName := filepath.Base(params.RepoDir)
-	branchSuffix := strings.TrimPrefix(worktree.Name, "side/")
+	branchSuffix := strings.ReplaceAll(strings.TrimPrefix(worktree.Name, "side/"), "/", "-")
 	dirName := repoName + "-" + branchSuffix
 	workingDir := filepath.Join(sidekickDataHome, "worktrees", worktree.WorkspaceId, dirName)
 	if err := os.MkdirAll(workingDir, 0755); err != nil {
@@ -120,6 +124,7 @@ func (e *LocalEnv) RunCommand(ctx context.Context, input EnvRunCommandInput) (En
 		Command:    input.Command,
 		Args:       input.Args,
 		EnvVars:    input.EnvVars,
+		Category:   input.Category,
 	}
 	return unix.RunCommandActivity(ctx, runCommandInput)
 }
@@ -138,6 +143,7 @@ func (e *LocalGitWorktreeEnv) RunCommand(ctx context.Context, input EnvRunComman
 		Command:    input.Command,
 		Args:       input.Args,
 		EnvVars:    input.EnvVars,
+		Category:   input.Category,
 	}
 	return unix.RunCommandActivity(ctx, runCommandInput)
 }
@@ -196,6 +202,7 @@ type EnvRunCommandActivityInput struct {
 	RelativeWorkingDir string   `json:"relativeWorkingDir"`
 	Command            string   `json:"command"`
 	Args               []string `json:"args"`
+	Category           string   `json:"category,omitempty"`
 }
 
 type EnvRunCommandActivityOutput = EnvRunCommandOutput
@@ -206,5 +213,6 @@ func EnvRunCommandActivity(ctx context.Context, input EnvRunCommandActivityInput
 		RelativeWorkingDir: input.RelativeWorkingDir,
 		Command:            input.Command,
 		Args:               input.Args,
+		Category:           input.Category,
 	})
 }
@@ -3,6 +3,8 @@ package env
 import (
 	"context"
 	"encoding/json"
+	"os"
+	"os/exec"
 	"path/filepath"
 	"sidekick/common"
 	"sidekick/domain"
@@ -84,6 +86,43 @@ func TestLocalGitWorktreeEnvironment(t *testing.T) {
 	assert.Contains(t, output.Stdout, expectedWorkingDir)
 }
 
+func TestLocalGitWorktreeEnvironment_SlashBranchName(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("SIDE_DATA_HOME", t.TempDir())
+	repoDir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-b", "main"},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "initial"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", repoDir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	worktree := domain.Worktree{
+		Id:          "wt_" + ksuid.New().String(),
+		Name:        "jane.doe/ABC-123-fix-login",
+		Created:     time.Now(),
+		WorkspaceId: "workspace1",
+	}
+	env, err := NewLocalGitWorktreeEnv(ctx, LocalEnvParams{RepoDir: repoDir}, worktree)
+	require.NoError(t, err)
+
+	sidekickDataHome, err := common.GetSidekickDataHome()
+	require.NoError(t, err)
+	workspaceDir := filepath.Join(sidekickDataHome, "worktrees", worktree.WorkspaceId)
+	expectedDirName := filepath.Base(repoDir) + "-jane.doe-ABC-123-fix-login"
+	assert.Equal(t, filepath.Join(workspaceDir, expectedDirName), env.GetWorkingDirectory())
+
+	entries, err := os.ReadDir(workspaceDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "the worktree must not be nested in a directory per branch name segment")
+	assert.Equal(t, expectedDirName, entries[0].Name())
+
+	out, err := exec.Command("git", "-C", env.GetWorkingDirectory(), "rev-parse", "--abbrev-ref", "HEAD").Output()
+	require.NoError(t, err)
+	assert.Equal(t, worktree.Name, strings.TrimSpace(string(out)))
+}
+
 func TestLocalEnvironment_MarshalUnmarshal(t *testing.T) {
 	ctx := context.Background()
 	params := LocalEnvParams{
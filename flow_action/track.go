@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sidekick/domain"
+	"sidekick/telemetry"
 	"sidekick/utils"
 	"time"
 
@@ -108,6 +109,8 @@ func trackSubflow[T any](eCtx ExecContext, detached bool, subflowType, subflowNa
 	if err != nil {
 		return defaultT, err
 	}
+	endSpan := startSubflowSpan(eCtx, subflow)
+	defer func() { endSpan(err) }()
 	(*eCtx.FlowScope).Subflow = &subflow
 
 	// handle legacy subflow name value
@@ -164,6 +167,8 @@ func trackSubflow[T any](eCtx ExecContext, detached bool, subflowType, subflowNa
 func trackSubflowFailureOnly[T any](eCtx ExecContext, subflowType, subflowName string, f func(subflow domain.Subflow) (T, error)) (defaultT T, err error) {
 	parentSubflow := eCtx.FlowScope.Subflow
 	subflow := setupSubflow(eCtx, false, subflowType, subflowName) // don't persist the subflow yet, only do it if & when it fails
+	endSpan := startSubflowSpan(eCtx, subflow)
+	defer func() { endSpan(err) }()
 
 	(*eCtx.FlowScope).Subflow = &subflow
 
@@ -221,6 +226,8 @@ func trackFlowAction[T any](eCtx ExecContext, isHumanAction bool, actionType str
 	if err != nil {
 		return defaultT, err
 	}
+	endSpan := startFlowActionSpan(eCtx, flowAction)
+	defer func() { endSpan(err) }()
 
 	// perform the actual action being tracked
 	val, err := f(flowAction)
@@ -270,6 +277,8 @@ func trackFlowActionFailureOnly[T any](eCtx ExecContext, actionType string, acti
 		ActionStatus:       initialStatus,
 		ActionParams:       actionParams,
 	}
+	endSpan := startFlowActionSpan(eCtx, flowAction)
+	defer func() { endSpan(err) }()
 
 	// perform the actual action being tracked
 	val, err := f(flowAction)
@@ -351,6 +360,40 @@ func setupSubflow(eCtx ExecContext, detached bool, subflowType, subflowName stri
 	return subflow
 }
 
+func startSubflowSpan(eCtx ExecContext, subflow domain.Subflow) func(error) {
+	subflowType := ""
+	if subflow.Type != nil {
+		subflowType = *subflow.Type
+	}
+	return telemetry.StartWorkflowSpan(eCtx, "Subflow", subflow.Name, map[string]string{
+		"sidekick.workspace_id":      eCtx.WorkspaceId,
+		"sidekick.flow_id":           subflow.FlowId,
+		"sidekick.subflow_id":        subflow.Id,
+		"sidekick.subflow_type":      subflowType,
+		"sidekick.parent_subflow_id": subflow.ParentSubflowId,
+	})
+}
+
+func startFlowActionSpan(eCtx ExecContext, flowAction domain.FlowAction) func(error) {
+	tags := map[string]string{
+		"sidekick.workspace_id":    eCtx.WorkspaceId,
+		"sidekick.flow_id":         flowAction.FlowId,
+		"sidekick.flow_action_id":  flowAction.Id,
+		"sidekick.is_human_action": fmt.Sprintf("%t", flowAction.IsHumanAction),
+	}
+	if eCtx.FlowScope.Subflow != nil {
+		tags["sidekick.subflow_id"] = eCtx.FlowScope.Subflow.Id
+	}
+	endSpan := telemetry.StartWorkflowSpan(eCtx, "FlowAction", flowAction.ActionType, tags)
+	observeDuration := telemetry.ObserveWorkflowDuration(eCtx, func(status string, duration time.Duration) {
+		telemetry.RecordFlowActionDuration(flowAction.ActionType, status, duration)
+	})
+	return func(err error) {
+		observeDuration(err)
+		endSpan(err)
+	}
+}
+
 func putSubflow(eCtx ExecContext, subflow domain.Subflow) (domain.Subflow, error) {
 	var fa *FlowActivities // nil struct pointer for struct-based activities
 	err := workflow.ExecuteActivity(eCtx, fa.PersistSubflow, subflow).Get(eCtx, nil)
@@ -1,6 +1,7 @@
 export interface ModelConfig {
   provider: string
   model: string
+  editFormat?: string
 }
 
 export interface LLMConfig {
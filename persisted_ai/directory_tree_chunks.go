@@ -31,9 +31,19 @@ const (
 )
 
 func GetDirectoryChunks(basePath string) []DirChunk {
+	return GetSubdirectoryChunks(basePath, "")
+}
+
+// GetSubdirectoryChunks is like GetDirectoryChunks, but only includes paths
+// within the given subdirectory of basePath. Paths remain relative to basePath.
+func GetSubdirectoryChunks(basePath string, subdirectory string) []DirChunk {
 	allPaths := []PathInfo{}
 
-	common.WalkCodeDirectory(basePath, func(path string, entry fs.DirEntry) error {
+	walkPath := basePath
+	if subdirectory != "" {
+		walkPath = filepath.Join(basePath, subdirectory)
+	}
+	common.WalkCodeDirectory(walkPath, func(path string, entry fs.DirEntry) error {
 		relativePath := strings.Replace(path, basePath, "", 1)
 		allPaths = append(allPaths, PathInfo{Path: relativePath, DirEntry: entry})
 		return nil
@@ -7,6 +7,7 @@ import (
 	"sidekick/embedding"
 	"sidekick/secret_manager"
 	"sidekick/srv"
+	"sidekick/telemetry"
 
 	"github.com/kelindar/binary"
 	"github.com/rs/zerolog/log"
@@ -67,7 +68,7 @@ func (ea *EmbedActivities) CachedEmbedActivity(ctx context.Context, options Cach
 		}
 	}
 
-	// TODO replace with metric
+	telemetry.RecordEmbeddingCacheLookups(len(embeddingKeys)-len(missingEmbeddingKeys), len(missingEmbeddingKeys))
 	log.Info().Msgf("embedding %d keys\n", len(toEmbedContentKeys))
 	if len(toEmbedContentKeys) > 0 {
 		values, err := ea.Storage.MGet(ctx, options.WorkspaceId, toEmbedContentKeys)
@@ -8,8 +8,11 @@ import (
 	"sidekick/domain"
 	"sidekick/llm"
 	"sidekick/srv"
+	"sidekick/telemetry"
+	"time"
 
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
 	"go.temporal.io/sdk/activity"
 )
 
@@ -24,7 +27,29 @@ type LlmActivities struct {
 	Streamer srv.Streamer
 }
 
-func (la *LlmActivities) ChatStream(ctx context.Context, options ChatStreamOptions) (*llm.ChatMessageResponse, error) {
+func (la *LlmActivities) ChatStream(ctx context.Context, options ChatStreamOptions) (response *llm.ChatMessageResponse, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "llm.chat_stream",
+		attribute.String("gen_ai.system", options.Params.Provider),
+		attribute.String("gen_ai.request.model", options.Params.Model),
+		attribute.String("sidekick.flow_id", options.FlowId),
+		attribute.String("sidekick.flow_action_id", options.FlowActionId),
+	)
+	start := time.Now()
+	defer func() {
+		var usage llm.Usage
+		if response != nil {
+			usage = response.Usage
+			span.SetAttributes(
+				attribute.String("gen_ai.response.model", response.Model),
+				attribute.String("gen_ai.response.finish_reason", response.StopReason),
+				attribute.Int("gen_ai.usage.input_tokens", response.Usage.InputTokens),
+				attribute.Int("gen_ai.usage.output_tokens", response.Usage.OutputTokens),
+			)
+		}
+		telemetry.RecordLLMRequest(options.Params.Provider, options.Params.Model, time.Since(start), usage.InputTokens, usage.OutputTokens, err)
+		telemetry.EndSpan(span, err)
+	}()
+
 	deltaChan := make(chan llm.ChatMessageDelta, 10)
 	progressChan := make(chan llm.ProgressInfo, 10)
 	defer close(deltaChan)
@@ -92,7 +117,7 @@ func (la *LlmActivities) ChatStream(ctx context.Context, options ChatStreamOptio
 	}
 
 	// First attempt
-	response, err := toolChatter.ChatStream(ctx, options.ToolChatOptions, deltaChan, progressChan)
+	response, err = toolChatter.ChatStream(ctx, options.ToolChatOptions, deltaChan, progressChan)
 	if response != nil {
 		response.Provider = options.Params.ModelConfig.Provider
 	}
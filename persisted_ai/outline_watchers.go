@@ -0,0 +1,455 @@
+package persisted_ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"sidekick/coding/tree_sitter"
+	"sidekick/common"
+	"sidekick/embedding"
+	"sidekick/srv"
+
+	"github.com/rs/zerolog/log"
+)
+
+// watchers that aren't used for this long are closed, so that they don't keep
+// refreshing embeddings with the secrets of a long finished task
+const outlineWatcherIdleTimeout = 30 * time.Minute
+
+// OutlineWatchers keeps a long-running tree_sitter.OutlineWatcher for each
+// workspace and repository that ranked outlines were requested for. As files
+// change, their outlines and embeddings are refreshed in the background, so
+// ranking at the start of the next task only has to process what changed
+// since. Linked git worktrees share the watcher of their repository's main
+// working tree, only outlining the files that differ from it.
+type OutlineWatchers struct {
+	DatabaseAccessor srv.Storage
+	mu               sync.Mutex
+	watchers         map[outlineWatcherKey]*workspaceOutlineWatcher
+	// repoRoots maps each working directory that was watched to the
+	// directory its watcher watches
+	repoRoots map[string]string
+}
+
+type outlineWatcherKey struct {
+	workspaceId string
+	// dir is the repository's main working tree, or the working directory
+	// itself when it isn't a linked git worktree
+	dir string
+}
+
+type workspaceOutlineWatcher struct {
+	key     outlineWatcherKey
+	dir     string
+	watcher *tree_sitter.OutlineWatcher
+	// lastUsed and idleTimer are guarded by the OutlineWatchers' mu
+	lastUsed  time.Time
+	idleTimer *time.Timer
+	mu        sync.Mutex
+	// options are those of the most recent request, and determine which
+	// embedding model outlines are embedded with
+	options RankedViaEmbeddingOptions
+	// fileSignatureSubkeys maps the relative path of each file in the
+	// directory to the subkeys of its signature outline chunks
+	fileSignatureSubkeys map[string][]string
+	// dirChunkSubkeys maps each subdirectory that ranked outlines were
+	// requested for to the subkeys of its directory chunks, or nil until
+	// they're first created
+	dirChunkSubkeys map[string][]string
+	// indexed is set once all files were outlined for the first time
+	indexed bool
+	// changedPaths are the absolute paths changed since the last refresh
+	changedPaths map[string]bool
+	refreshing   bool
+	// refresh holds at most one queued refresh, coalescing bursts of changes
+	refresh chan struct{}
+}
+
+// Watch starts watching the options' working directory unless already
+// watched, or the main working tree of its repository when it's a linked git
+// worktree, keeping the directory chunks of the given subdirectory, or all of
+// it when empty, up to date too. Failure to watch is logged rather than
+// returned, since outlines are still computed on demand without a watcher.
+func (ow *OutlineWatchers) Watch(options RankedViaEmbeddingOptions, subdirectory string) {
+	workingDir, err := filepath.Abs(options.EnvContainer.Env.GetWorkingDirectory())
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to resolve directory to watch for outlines")
+		return
+	}
+	dir := repoRootDir(workingDir)
+	key := outlineWatcherKey{workspaceId: options.WorkspaceId, dir: dir}
+
+	ow.mu.Lock()
+	defer ow.mu.Unlock()
+	ow.closeRemovedDirWatchers()
+	if ow.watchers == nil {
+		ow.watchers = map[outlineWatcherKey]*workspaceOutlineWatcher{}
+		ow.repoRoots = map[string]string{}
+	}
+	ow.repoRoots[workingDir] = dir
+	if ww, ok := ow.watchers[key]; ok {
+		ww.lastUsed = time.Now()
+		ww.idleTimer.Reset(outlineWatcherIdleTimeout)
+		ww.mu.Lock()
+		ww.options = options
+		_, ok := ww.dirChunkSubkeys[subdirectory]
+		if !ok {
+			ww.dirChunkSubkeys[subdirectory] = nil
+		}
+		ww.mu.Unlock()
+		if !ok {
+			ww.queueRefresh()
+		}
+		return
+	}
+
+	ww := &workspaceOutlineWatcher{
+		key:                  key,
+		dir:                  dir,
+		options:              options,
+		fileSignatureSubkeys: map[string][]string{},
+		dirChunkSubkeys:      map[string][]string{subdirectory: nil},
+		changedPaths:         map[string]bool{},
+		refresh:              make(chan struct{}, 1),
+	}
+	ww.watcher, err = tree_sitter.WatchOutlines(dir, func(changedPaths []string) {
+		ww.mu.Lock()
+		for _, path := range changedPaths {
+			ww.changedPaths[path] = true
+		}
+		ww.mu.Unlock()
+		ww.queueRefresh()
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("dir", dir).Msg("failed to watch directory for outline changes")
+		return
+	}
+	ww.lastUsed = time.Now()
+	ww.idleTimer = time.AfterFunc(outlineWatcherIdleTimeout, func() {
+		ow.closeIdleWatcher(ww)
+	})
+	go ow.refreshLoop(ww)
+	ow.watchers[key] = ww
+}
+
+func (ww *workspaceOutlineWatcher) queueRefresh() {
+	select {
+	case ww.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// FileSignatureSubkeys returns the subkeys of the file signature outlines
+// within the subdirectory of the working directory, or all of it when empty,
+// as last persisted by its watcher. False is returned unless the directory is
+// watched and all changes to it have been refreshed.
+func (ow *OutlineWatchers) FileSignatureSubkeys(workspaceId string, workingDir string, subdirectory string) ([]string, bool) {
+	fileSignatureSubkeys, _, ok := ow.index(workspaceId, workingDir)
+	if !ok {
+		return nil, false
+	}
+
+	paths := make([]string, 0, len(fileSignatureSubkeys))
+	for path := range fileSignatureSubkeys {
+		if isWithinSubdirectory(path, subdirectory) {
+			paths = append(paths, path)
+		}
+	}
+	// same order as when walking the directory
+	slices.Sort(paths)
+	subkeys := make([]string, 0, len(paths))
+	for _, path := range paths {
+		subkeys = append(subkeys, fileSignatureSubkeys[path]...)
+	}
+	return subkeys, true
+}
+
+// DirChunkSubkeys is like FileSignatureSubkeys, for the directory chunks of
+// the subdirectory. False is also returned when the subdirectory's chunks
+// haven't been created yet.
+func (ow *OutlineWatchers) DirChunkSubkeys(workspaceId string, workingDir string, subdirectory string) ([]string, bool) {
+	_, dirChunkSubkeys, ok := ow.index(workspaceId, workingDir)
+	if !ok {
+		return nil, false
+	}
+
+	subkeys := dirChunkSubkeys[subdirectory]
+	return subkeys, subkeys != nil
+}
+
+// index returns copies of the file signature and directory chunk subkeys of
+// the working directory, if its watcher is up to date. For a linked worktree,
+// the index of the main working tree is adjusted to the files that differ.
+func (ow *OutlineWatchers) index(workspaceId string, workingDir string) (map[string][]string, map[string][]string, bool) {
+	workingDir, err := filepath.Abs(workingDir)
+	if err != nil {
+		return nil, nil, false
+	}
+	ow.mu.Lock()
+	dir, ok := ow.repoRoots[workingDir]
+	ww := ow.watchers[outlineWatcherKey{workspaceId: workspaceId, dir: dir}]
+	ow.mu.Unlock()
+	if !ok || ww == nil {
+		return nil, nil, false
+	}
+
+	ww.mu.Lock()
+	// changes are only passed on once processed, so checking the watcher
+	// last avoids missing a batch in between
+	if !ww.indexed || ww.refreshing || len(ww.changedPaths) > 0 || !ww.watcher.Settled() {
+		ww.mu.Unlock()
+		return nil, nil, false
+	}
+	fileSignatureSubkeys := make(map[string][]string, len(ww.fileSignatureSubkeys))
+	for path, subkeys := range ww.fileSignatureSubkeys {
+		fileSignatureSubkeys[path] = subkeys
+	}
+	dirChunkSubkeys := make(map[string][]string, len(ww.dirChunkSubkeys))
+	for subdirectory, subkeys := range ww.dirChunkSubkeys {
+		dirChunkSubkeys[subdirectory] = slices.Clone(subkeys)
+	}
+	options := ww.options
+	ww.mu.Unlock()
+
+	if workingDir == dir {
+		return fileSignatureSubkeys, dirChunkSubkeys, true
+	}
+	structureChanges, err := ow.applyWorktreeChanges(ww.key, options, workingDir, fileSignatureSubkeys)
+	if err != nil {
+		log.Debug().Err(err).Str("worktree", workingDir).Msg("failed to reuse repository outlines for worktree")
+		return nil, nil, false
+	}
+	for subdirectory := range dirChunkSubkeys {
+		if slices.ContainsFunc(structureChanges, func(path string) bool {
+			return isWithinSubdirectory(path, subdirectory)
+		}) {
+			delete(dirChunkSubkeys, subdirectory)
+		}
+	}
+	return fileSignatureSubkeys, dirChunkSubkeys, true
+}
+
+// Close stops all watchers
+func (ow *OutlineWatchers) Close() {
+	ow.mu.Lock()
+	defer ow.mu.Unlock()
+	for _, ww := range ow.watchers {
+		ow.closeWatcher(ww)
+	}
+}
+
+// closeRemovedDirWatchers stops watching directories that no longer exist,
+// and forgets worktrees cleaned up after their task. Must be called with mu
+// held.
+func (ow *OutlineWatchers) closeRemovedDirWatchers() {
+	for _, ww := range ow.watchers {
+		if _, err := os.Stat(ww.dir); os.IsNotExist(err) {
+			ow.closeWatcher(ww)
+		}
+	}
+	for workingDir := range ow.repoRoots {
+		if _, err := os.Stat(workingDir); os.IsNotExist(err) {
+			delete(ow.repoRoots, workingDir)
+		}
+	}
+}
+
+func (ow *OutlineWatchers) closeIdleWatcher(ww *workspaceOutlineWatcher) {
+	ow.mu.Lock()
+	defer ow.mu.Unlock()
+	// the watcher may have been used again while the timer fired
+	if ow.watchers[ww.key] == ww && time.Since(ww.lastUsed) >= outlineWatcherIdleTimeout {
+		ow.closeWatcher(ww)
+	}
+}
+
+func (ow *OutlineWatchers) closeWatcher(ww *workspaceOutlineWatcher) {
+	ww.idleTimer.Stop()
+	if err := ww.watcher.Close(); err != nil {
+		log.Warn().Err(err).Str("dir", ww.dir).Msg("failed to close outline watcher")
+	}
+	close(ww.refresh)
+	delete(ow.watchers, ww.key)
+}
+
+func (ow *OutlineWatchers) refreshLoop(ww *workspaceOutlineWatcher) {
+	for range ww.refresh {
+		ww.mu.Lock()
+		options := ww.options
+		changedPaths := ww.changedPaths
+		ww.changedPaths = map[string]bool{}
+		ww.refreshing = true
+		ww.mu.Unlock()
+
+		err := ow.refreshEmbeddings(context.Background(), ww, options, changedPaths)
+
+		ww.mu.Lock()
+		ww.refreshing = false
+		if err != nil {
+			// retried along with the next change, while ranking falls back to
+			// outlining the whole directory
+			for path := range changedPaths {
+				ww.changedPaths[path] = true
+			}
+		}
+		ww.mu.Unlock()
+		if err != nil {
+			log.Warn().Err(err).Str("workspaceId", options.WorkspaceId).Msg("failed to refresh outline embeddings")
+		}
+	}
+}
+
+// refreshEmbeddings persists the file signature outlines of the changed
+// paths, along with the directory chunks of the watched subdirectories that
+// files were added to or removed from, embedding any that aren't embedded yet
+func (ow *OutlineWatchers) refreshEmbeddings(ctx context.Context, ww *workspaceOutlineWatcher, options RankedViaEmbeddingOptions, changedPaths map[string]bool) error {
+	maxChars, err := embedding.GetModelMaxChars(options.ModelConfig)
+	if err != nil {
+		return fmt.Errorf("failed to calculate embedding char limits: %w", err)
+	}
+
+	ww.mu.Lock()
+	reindex := !ww.indexed
+	var removedPaths, filePaths []string
+	for path := range changedPaths {
+		relativePath, err := filepath.Rel(ww.dir, path)
+		if err != nil || strings.HasPrefix(relativePath, "..") {
+			continue
+		}
+		if isIgnoreFile(relativePath) {
+			// files may have been ignored or unignored without changing
+			reindex = true
+		}
+		if _, err := os.Lstat(path); err != nil {
+			removedPaths = append(removedPaths, relativePath)
+		} else {
+			filePaths = append(filePaths, relativePath)
+		}
+	}
+	ww.mu.Unlock()
+	if reindex {
+		filePaths, err = listCodeFiles(ww.dir)
+		if err != nil {
+			return err
+		}
+	}
+
+	t := tree_sitter.TreeSitterActivities{DatabaseAccessor: ow.DatabaseAccessor}
+	fileSignatureSubkeys, err := t.CreateFileSignatureOutlines(options.WorkspaceId, ww.dir, filePaths, maxChars)
+	if err != nil {
+		return err
+	}
+
+	// the index is updated before embedding, since the outlines are already
+	// persisted, and ranking embeds whatever is missing anyway
+	ww.mu.Lock()
+	var structureChanges []string
+	if reindex {
+		ww.fileSignatureSubkeys = map[string][]string{}
+		for subdirectory := range ww.dirChunkSubkeys {
+			ww.dirChunkSubkeys[subdirectory] = nil
+		}
+	}
+	for _, removedPath := range removedPaths {
+		for path := range ww.fileSignatureSubkeys {
+			if isWithinSubdirectory(path, removedPath) {
+				delete(ww.fileSignatureSubkeys, path)
+				structureChanges = append(structureChanges, path)
+			}
+		}
+	}
+	var changedSubkeys []string
+	for path, subkeys := range fileSignatureSubkeys {
+		if _, ok := ww.fileSignatureSubkeys[path]; !ok {
+			structureChanges = append(structureChanges, path)
+		}
+		ww.fileSignatureSubkeys[path] = subkeys
+		changedSubkeys = append(changedSubkeys, subkeys...)
+	}
+	var staleSubdirectories []string
+	for subdirectory, subkeys := range ww.dirChunkSubkeys {
+		if subkeys == nil || slices.ContainsFunc(structureChanges, func(path string) bool {
+			return isWithinSubdirectory(path, subdirectory)
+		}) {
+			staleSubdirectories = append(staleSubdirectories, subdirectory)
+		}
+	}
+	ww.mu.Unlock()
+
+	ra := RagActivities{DatabaseAccessor: ow.DatabaseAccessor}
+	var dirChunkSubkeys []string
+	for _, subdirectory := range staleSubdirectories {
+		subkeys, err := ra.createDirChunks(options.WorkspaceId, ww.dir, subdirectory)
+		if err != nil {
+			return err
+		}
+		ww.mu.Lock()
+		ww.dirChunkSubkeys[subdirectory] = subkeys
+		ww.mu.Unlock()
+		dirChunkSubkeys = append(dirChunkSubkeys, subkeys...)
+	}
+	ww.mu.Lock()
+	ww.indexed = true
+	ww.mu.Unlock()
+
+	ea := EmbedActivities{Storage: ow.DatabaseAccessor}
+	for contentType, subkeys := range map[string][]string{
+		tree_sitter.ContentTypeFileSignature: changedSubkeys,
+		tree_sitter.ContentTypeDirChunk:      dirChunkSubkeys,
+	} {
+		if len(subkeys) == 0 {
+			continue
+		}
+		err := ea.CachedEmbedActivity(ctx, CachedEmbedActivityOptions{
+			Secrets:     options.Secrets,
+			WorkspaceId: options.WorkspaceId,
+			ContentType: contentType,
+			ModelConfig: options.ModelConfig,
+			Subkeys:     subkeys,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to embed %s content: %w", contentType, err)
+		}
+	}
+	return nil
+}
+
+// listCodeFiles lists the relative paths of all files in the directory that
+// aren't ignored
+func listCodeFiles(dir string) ([]string, error) {
+	var paths []string
+	err := common.WalkCodeDirectory(dir, func(path string, entry fs.DirEntry) error {
+		if entry.Type().IsRegular() {
+			relativePath, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, relativePath)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to list files in %s: %w", dir, err)
+	}
+	return paths, nil
+}
+
+func isIgnoreFile(path string) bool {
+	name := filepath.Base(path)
+	return name == common.GitIgnoreType.String() || name == common.IgnoreType.String() || name == common.SideIgnoreType.String()
+}
+
+// isWithinSubdirectory reports whether the relative path is the subdirectory
+// or within it. Every path is within the empty subdirectory.
+func isWithinSubdirectory(path string, subdirectory string) bool {
+	subdirectory = filepath.Clean(subdirectory)
+	return subdirectory == "." || path == subdirectory || strings.HasPrefix(path, subdirectory+string(filepath.Separator))
+}
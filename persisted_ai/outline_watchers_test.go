@@ -0,0 +1,208 @@
+package persisted_ai
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sidekick/coding/tree_sitter"
+	"sidekick/common"
+	"sidekick/env"
+	"sidekick/srv/sqlite"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutlineWatchers(t *testing.T) {
+	storage := sqlite.NewTestSqliteStorage(t, "test-outline-watchers")
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(mainPath, []byte("package main\n\nfunc Foo() {}\n"), 0644))
+
+	options := RankedViaEmbeddingOptions{
+		WorkspaceId:  "test",
+		EnvContainer: env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: dir}},
+		ModelConfig:  common.ModelConfig{Provider: "mock", Model: "ada2"},
+	}
+	watchers := &OutlineWatchers{DatabaseAccessor: storage}
+	defer watchers.Close()
+	watchers.Watch(options, "")
+	watchers.Watch(options, "")
+	assert.Len(t, watchers.watchers, 1)
+
+	// waits until the current file signature outlines have all been embedded
+	waitForEmbeddings := func() {
+		t.Helper()
+		assert.Eventually(t, func() bool {
+			tsa := tree_sitter.TreeSitterActivities{DatabaseAccessor: storage}
+			subkeys, err := tsa.CreateDirSignatureOutlines(options.WorkspaceId, dir, 10000)
+			require.NoError(t, err)
+			require.NotEmpty(t, subkeys)
+			for _, subkey := range subkeys {
+				key, err := constructEmbeddingKey(embeddingKeyOptions{
+					provider:    options.ModelConfig.Provider,
+					model:       options.ModelConfig.Model,
+					contentType: tree_sitter.ContentTypeFileSignature,
+					subKey:      subkey,
+				})
+				require.NoError(t, err)
+				values, err := storage.MGet(context.Background(), options.WorkspaceId, []string{key})
+				require.NoError(t, err)
+				if values[0] == nil {
+					return false
+				}
+			}
+			return true
+		}, 5*time.Second, 50*time.Millisecond)
+	}
+
+	// waits until the watcher's index matches the directory's outlines
+	waitForIndex := func(subdirectory string) []string {
+		t.Helper()
+		tsa := tree_sitter.TreeSitterActivities{DatabaseAccessor: storage}
+		expected, err := tsa.CreateSubdirSignatureOutlines(options.WorkspaceId, dir, subdirectory, 10000)
+		require.NoError(t, err)
+		var subkeys []string
+		assert.Eventually(t, func() bool {
+			var ok bool
+			subkeys, ok = watchers.FileSignatureSubkeys(options.WorkspaceId, dir, subdirectory)
+			return ok && assert.ObjectsAreEqual(expected, subkeys)
+		}, 5*time.Second, 50*time.Millisecond)
+		return subkeys
+	}
+
+	waitForEmbeddings()
+	waitForIndex("")
+	require.NoError(t, os.WriteFile(mainPath, []byte("package main\n\nfunc Bar() {}\n"), 0644))
+	waitForEmbeddings()
+	waitForIndex("")
+
+	t.Run("indexes sub-projects", func(t *testing.T) {
+		pkgPath := filepath.Join(dir, "pkg", "util.go")
+		require.NoError(t, os.MkdirAll(filepath.Dir(pkgPath), 0755))
+		require.NoError(t, os.WriteFile(pkgPath, []byte("package pkg\n\nfunc Util() {}\n"), 0644))
+		watchers.Watch(options, "pkg")
+
+		assert.Len(t, waitForIndex("pkg"), 1)
+		assert.Len(t, waitForIndex(""), 2)
+		ra := RagActivities{DatabaseAccessor: storage}
+		expected, err := ra.createDirChunks(options.WorkspaceId, dir, "pkg")
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			subkeys, ok := watchers.DirChunkSubkeys(options.WorkspaceId, dir, "pkg")
+			return ok && assert.ObjectsAreEqual(expected, subkeys)
+		}, 5*time.Second, 50*time.Millisecond)
+
+		require.NoError(t, os.RemoveAll(filepath.Dir(pkgPath)))
+		assert.Eventually(t, func() bool {
+			subkeys, ok := watchers.FileSignatureSubkeys(options.WorkspaceId, dir, "pkg")
+			return ok && len(subkeys) == 0
+		}, 5*time.Second, 50*time.Millisecond)
+		assert.Len(t, waitForIndex(""), 1)
+	})
+
+	t.Run("closes idle watchers", func(t *testing.T) {
+		idleDir := t.TempDir()
+		idleOptions := options
+		idleOptions.EnvContainer = env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: idleDir}}
+		watchers.Watch(idleOptions, "")
+		idleKey := outlineWatcherKey{workspaceId: options.WorkspaceId, dir: idleDir}
+		require.Contains(t, watchers.watchers, idleKey)
+
+		ww := watchers.watchers[idleKey]
+		watchers.closeIdleWatcher(ww)
+		assert.Contains(t, watchers.watchers, idleKey, "recently used watchers are kept")
+
+		watchers.mu.Lock()
+		ww.lastUsed = time.Now().Add(-outlineWatcherIdleTimeout)
+		watchers.mu.Unlock()
+		watchers.closeIdleWatcher(ww)
+		assert.NotContains(t, watchers.watchers, idleKey)
+	})
+
+	t.Run("stops watching removed directories", func(t *testing.T) {
+		removedDir := t.TempDir()
+		watchers.Watch(RankedViaEmbeddingOptions{
+			WorkspaceId:  "test",
+			EnvContainer: env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: removedDir}},
+			ModelConfig:  options.ModelConfig,
+		}, "")
+		assert.Len(t, watchers.watchers, 2)
+		require.NoError(t, os.RemoveAll(removedDir))
+
+		watchers.Watch(options, "")
+		assert.Len(t, watchers.watchers, 1)
+		assert.Contains(t, watchers.watchers, outlineWatcherKey{workspaceId: options.WorkspaceId, dir: dir})
+	})
+}
+
+func TestOutlineWatchersWorktrees(t *testing.T) {
+	storage := sqlite.NewTestSqliteStorage(t, "test-outline-watchers-worktrees")
+	repoDir := t.TempDir()
+	runGit := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	runGit(repoDir, "init", "-b", "main")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "main.go"), []byte("package main\n\nfunc Foo() {}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "util.go"), []byte("package main\n\nfunc Util() {}\n"), 0644))
+	runGit(repoDir, "add", ".")
+	runGit(repoDir, "commit", "-m", "initial")
+	worktreeDir := filepath.Join(t.TempDir(), "worktree")
+	runGit(repoDir, "worktree", "add", "-b", "side/test", worktreeDir)
+
+	optionsFor := func(dir string) RankedViaEmbeddingOptions {
+		return RankedViaEmbeddingOptions{
+			WorkspaceId:  "test",
+			EnvContainer: env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: dir}},
+			ModelConfig:  common.ModelConfig{Provider: "mock", Model: "ada2"},
+		}
+	}
+	watchers := &OutlineWatchers{DatabaseAccessor: storage}
+	defer watchers.Close()
+	watchers.Watch(optionsFor(worktreeDir), "")
+	watchers.Watch(optionsFor(repoDir), "")
+	require.Len(t, watchers.watchers, 1)
+	assert.Contains(t, watchers.watchers, outlineWatcherKey{workspaceId: "test", dir: repoDir})
+
+	tsa := tree_sitter.TreeSitterActivities{DatabaseAccessor: storage}
+	ra := RagActivities{DatabaseAccessor: storage}
+	assertIndexed := func(dir string, expectDirChunks bool) {
+		t.Helper()
+		assert.Eventually(t, func() bool {
+			expected, err := tsa.CreateSubdirSignatureOutlines("test", dir, "", 10000)
+			require.NoError(t, err)
+			subkeys, ok := watchers.FileSignatureSubkeys("test", dir, "")
+			return ok && assert.ObjectsAreEqual(expected, subkeys)
+		}, 5*time.Second, 50*time.Millisecond)
+
+		dirChunkSubkeys, ok := watchers.DirChunkSubkeys("test", dir, "")
+		if !expectDirChunks {
+			assert.False(t, ok, "files were added or removed, so directory chunks can't be reused")
+			return
+		}
+		require.True(t, ok)
+		expectedDirChunkSubkeys, err := ra.createDirChunks("test", dir, "")
+		require.NoError(t, err)
+		assert.Equal(t, expectedDirChunkSubkeys, dirChunkSubkeys)
+	}
+	assertIndexed(repoDir, true)
+	assertIndexed(worktreeDir, true)
+
+	// changes in either working tree are reflected in the worktree's index
+	require.NoError(t, os.WriteFile(filepath.Join(worktreeDir, "main.go"), []byte("package main\n\nfunc Bar() {}\n"), 0644))
+	assertIndexed(worktreeDir, true)
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "util.go"), []byte("package main\n\nfunc Changed() {}\n"), 0644))
+	assertIndexed(repoDir, true)
+	assertIndexed(worktreeDir, true)
+
+	require.NoError(t, os.WriteFile(filepath.Join(worktreeDir, "added.go"), []byte("package main\n\nfunc Added() {}\n"), 0644))
+	assertIndexed(worktreeDir, false)
+}
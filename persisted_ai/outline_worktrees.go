@@ -0,0 +1,180 @@
+package persisted_ai
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"sidekick/coding/tree_sitter"
+	"sidekick/common"
+	"sidekick/embedding"
+)
+
+// repoRootDir returns the main working tree of the git repository that dir is
+// a linked worktree of, or dir itself otherwise
+func repoRootDir(dir string) string {
+	out, err := gitOutput(dir, "rev-parse", "--path-format=absolute", "--show-toplevel", "--git-common-dir")
+	if err != nil {
+		return dir
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		return dir
+	}
+	topLevel, commonDir := lines[0], lines[1]
+	// subdirectories of a repository aren't shared, and neither are bare
+	// repositories' worktrees, since there is no main working tree
+	if resolvedDir, err := filepath.EvalSymlinks(dir); err != nil || resolvedDir != topLevel {
+		return dir
+	}
+	if filepath.Base(commonDir) != ".git" || filepath.Dir(commonDir) == topLevel {
+		return dir
+	}
+	root := filepath.Dir(commonDir)
+	if _, err := os.Stat(root); err != nil {
+		return dir
+	}
+	return root
+}
+
+// worktreeChangedPaths lists the relative paths of the files that may differ
+// between the worktree and the repository's main working tree: those changed
+// in either since the main working tree's HEAD, and untracked ones
+func worktreeChangedPaths(rootDir string, worktreeDir string) ([]string, error) {
+	head, err := gitOutput(rootDir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	commands := []struct {
+		dir  string
+		args []string
+	}{
+		{worktreeDir, []string{"diff", "--name-only", "--no-renames", "-z", strings.TrimSpace(head)}},
+		{worktreeDir, []string{"ls-files", "--others", "--exclude-standard", "-z"}},
+		{rootDir, []string{"diff", "--name-only", "--no-renames", "-z", "HEAD"}},
+		{rootDir, []string{"ls-files", "--others", "--exclude-standard", "-z"}},
+	}
+	seen := map[string]bool{}
+	var paths []string
+	for _, command := range commands {
+		out, err := gitOutput(command.dir, command.args...)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range strings.Split(out, "\x00") {
+			path = filepath.FromSlash(path)
+			if path != "" && !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, nil
+}
+
+// applyWorktreeChanges updates a copy of the main working tree's file
+// signature subkeys to match the worktree, outlining the files that differ.
+// The relative paths of files added or removed in the worktree are returned.
+func (ow *OutlineWatchers) applyWorktreeChanges(key outlineWatcherKey, options RankedViaEmbeddingOptions, worktreeDir string, fileSignatureSubkeys map[string][]string) ([]string, error) {
+	changedPaths, err := worktreeChangedPaths(key.dir, worktreeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ignoreManager *common.IgnoreManager
+	loadedIgnoreDirs := map[string]bool{}
+	var filePaths, structureChanges []string
+	for _, path := range changedPaths {
+		if isIgnoreFile(path) {
+			// ignored files may differ without being listed as changed
+			return nil, errors.New("ignore files differ from the main working tree")
+		}
+		info, err := os.Lstat(filepath.Join(worktreeDir, path))
+		exists := err == nil && info.Mode().IsRegular()
+		_, indexed := fileSignatureSubkeys[path]
+		if !indexed && exists {
+			if ignoreManager == nil {
+				ignoreManager, err = common.NewIgnoreManager(worktreeDir)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if ignored, err := isIgnoredPath(ignoreManager, loadedIgnoreDirs, worktreeDir, path); err != nil {
+				return nil, err
+			} else if ignored {
+				continue
+			}
+		}
+
+		switch {
+		case exists:
+			filePaths = append(filePaths, path)
+			if !indexed {
+				structureChanges = append(structureChanges, path)
+			}
+		case indexed:
+			delete(fileSignatureSubkeys, path)
+			structureChanges = append(structureChanges, path)
+		}
+	}
+	if len(filePaths) == 0 {
+		return structureChanges, nil
+	}
+
+	maxChars, err := embedding.GetModelMaxChars(options.ModelConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate embedding char limits: %w", err)
+	}
+	t := tree_sitter.TreeSitterActivities{DatabaseAccessor: ow.DatabaseAccessor}
+	outlines, err := t.CreateFileSignatureOutlines(key.workspaceId, worktreeDir, filePaths, maxChars)
+	if err != nil {
+		return nil, err
+	}
+	for path, subkeys := range outlines {
+		fileSignatureSubkeys[path] = subkeys
+	}
+	return structureChanges, nil
+}
+
+// isIgnoredPath reports whether walking the directory would skip the file at
+// the relative path, loading the ignore files of its parent directories into
+// the ignore manager along the way, unless already loaded
+func isIgnoredPath(ignoreManager *common.IgnoreManager, loadedDirs map[string]bool, dir string, relativePath string) (bool, error) {
+	parts := strings.Split(relativePath, string(filepath.Separator))
+	current := dir
+	for i, part := range parts {
+		current = filepath.Join(current, part)
+		isDir := i < len(parts)-1
+		if isDir && part == ".git" {
+			return true, nil
+		}
+		if ignoreManager.IsIgnored(current, isDir) {
+			return true, nil
+		}
+		if isDir && !loadedDirs[current] {
+			loadedDirs[current] = true
+			for _, ignoreType := range []common.IgnoreFileType{common.SideIgnoreType, common.IgnoreType, common.GitIgnoreType} {
+				if err := ignoreManager.AddIgnoreFile(ignoreType, current); err != nil {
+					return false, err
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
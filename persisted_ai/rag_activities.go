@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"sidekick/coding/lsp"
 	"sidekick/coding/tree_sitter"
 	"sidekick/common"
 	"sidekick/embedding"
@@ -16,15 +17,29 @@ import (
 	"strings"
 
 	"github.com/kelindar/binary"
+	"github.com/rs/zerolog/log"
 )
 
 type RagActivities struct {
 	DatabaseAccessor srv.Storage
+	// LSPActivities is optional, and used to resolve ambiguous references
+	// when building the symbol graph
+	LSPActivities *lsp.LSPActivities
+	// OutlineWatchers is optional, and keeps outlines and their embeddings up
+	// to date between tasks
+	OutlineWatchers *OutlineWatchers
 }
 
 type RankedDirSignatureOutlineOptions struct {
 	RankedViaEmbeddingOptions
 	CharLimit int
+	// ContextFilePaths are relative paths of files already in context, which
+	// along with files mentioned in the rank query, personalize the symbol
+	// graph ranking
+	ContextFilePaths []string
+	// Subdirectory limits the ranked files and directories to those within
+	// it, relative to the working directory, eg a monorepo's sub-project
+	Subdirectory string
 }
 
 type RankedViaEmbeddingOptions struct {
@@ -37,26 +52,37 @@ type RankedViaEmbeddingOptions struct {
 
 func (options RankedDirSignatureOutlineOptions) ActionParams() map[string]any {
 	return map[string]interface{}{
-		"rankQuery": options.RankQuery,
-		"charLimit": options.CharLimit,
-		"provider":  options.ModelConfig.Provider,
-		"model":     options.ModelConfig.Model,
+		"rankQuery":        options.RankQuery,
+		"charLimit":        options.CharLimit,
+		"contextFilePaths": options.ContextFilePaths,
+		"subdirectory":     options.Subdirectory,
+		"provider":         options.ModelConfig.Provider,
+		"model":            options.ModelConfig.Model,
 	}
 }
 
-// TODO add param for context.Context
-func (ra *RagActivities) RankedDirSignatureOutline(options RankedDirSignatureOutlineOptions) (string, error) {
-	// FIXME put tree sitter activities inside rag activities struct
-	t := tree_sitter.TreeSitterActivities{DatabaseAccessor: ra.DatabaseAccessor}
-
-	maxChars, err := embedding.GetModelMaxChars(options.ModelConfig)
-	if err != nil {
-		return "", fmt.Errorf("failed to calculate embedding char limits: %w", err)
+func (ra *RagActivities) RankedDirSignatureOutline(ctx context.Context, options RankedDirSignatureOutlineOptions) (string, error) {
+	var fileSignatureSubkeys []string
+	indexed := false
+	if ra.OutlineWatchers != nil {
+		ra.OutlineWatchers.Watch(options.RankedViaEmbeddingOptions, options.Subdirectory)
+		// the watcher already persisted the outlines of unchanged files
+		fileSignatureSubkeys, indexed = ra.OutlineWatchers.FileSignatureSubkeys(options.WorkspaceId, options.EnvContainer.Env.GetWorkingDirectory(), options.Subdirectory)
 	}
 
-	fileSignatureSubkeys, err := t.CreateDirSignatureOutlines(options.WorkspaceId, options.EnvContainer.Env.GetWorkingDirectory(), maxChars)
-	if err != nil {
-		return "", err
+	if !indexed {
+		// FIXME put tree sitter activities inside rag activities struct
+		t := tree_sitter.TreeSitterActivities{DatabaseAccessor: ra.DatabaseAccessor}
+
+		maxChars, err := embedding.GetModelMaxChars(options.ModelConfig)
+		if err != nil {
+			return "", fmt.Errorf("failed to calculate embedding char limits: %w", err)
+		}
+
+		fileSignatureSubkeys, err = t.CreateSubdirSignatureOutlines(options.WorkspaceId, options.EnvContainer.Env.GetWorkingDirectory(), options.Subdirectory, maxChars)
+		if err != nil {
+			return "", err
+		}
 	}
 
 	rankedFileSignatureSubkeys, err := ra.RankedSubkeys(RankedSubkeysOptions{
@@ -68,7 +94,19 @@ func (ra *RagActivities) RankedDirSignatureOutline(options RankedDirSignatureOut
 		return "", err
 	}
 
-	rankedDirChunkSubkeys, err := ra.RankedDirChunkSubkeys(RankedDirChunkSubkeysOptions{options.RankedViaEmbeddingOptions})
+	// the symbol graph surfaces callers and callees of relevant files, which
+	// embeddings alone miss when they aren't lexically similar to the query
+	graphRankedFileSignatureSubkeys, err := ra.graphRankedSubkeys(ctx, options, fileSignatureSubkeys)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to rank file signatures via symbol graph, using embedding ranking only")
+	} else if len(graphRankedFileSignatureSubkeys) > 0 {
+		rankedFileSignatureSubkeys = FuseResultsRRF([][]string{rankedFileSignatureSubkeys, graphRankedFileSignatureSubkeys})
+	}
+
+	rankedDirChunkSubkeys, err := ra.RankedDirChunkSubkeys(RankedDirChunkSubkeysOptions{
+		RankedViaEmbeddingOptions: options.RankedViaEmbeddingOptions,
+		Subdirectory:              options.Subdirectory,
+	})
 	if err != nil {
 		return "", err
 	}
@@ -401,11 +439,33 @@ chunksLoop:
 
 type RankedDirChunkSubkeysOptions struct {
 	RankedViaEmbeddingOptions
+	Subdirectory string
 }
 
 func (ra *RagActivities) RankedDirChunkSubkeys(options RankedDirChunkSubkeysOptions) ([]string, error) {
-	basePath := options.EnvContainer.Env.GetWorkingDirectory()
-	chunks := GetDirectoryChunks(basePath)
+	var dirChunkSubkeys []string
+	indexed := false
+	if ra.OutlineWatchers != nil {
+		dirChunkSubkeys, indexed = ra.OutlineWatchers.DirChunkSubkeys(options.WorkspaceId, options.EnvContainer.Env.GetWorkingDirectory(), options.Subdirectory)
+	}
+	if !indexed {
+		var err error
+		dirChunkSubkeys, err = ra.createDirChunks(options.WorkspaceId, options.EnvContainer.Env.GetWorkingDirectory(), options.Subdirectory)
+		if err != nil {
+			return []string{}, err
+		}
+	}
+	return ra.RankedSubkeys(RankedSubkeysOptions{
+		RankedViaEmbeddingOptions: options.RankedViaEmbeddingOptions,
+		ContentType:               tree_sitter.ContentTypeDirChunk,
+		Subkeys:                   dirChunkSubkeys,
+	})
+}
+
+// createDirChunks persists the chunks of the directory tree within the given
+// subdirectory of basePath, or all of it, returning their subkeys
+func (ra *RagActivities) createDirChunks(workspaceId string, basePath string, subdirectory string) ([]string, error) {
+	chunks := GetSubdirectoryChunks(basePath, subdirectory)
 
 	values := make(map[string]interface{})
 	hashes := make([]string, 0, len(chunks))
@@ -417,15 +477,9 @@ func (ra *RagActivities) RankedDirChunkSubkeys(options RankedDirChunkSubkeysOpti
 		key := fmt.Sprintf("%s:%s", tree_sitter.ContentTypeDirChunk, hash)
 		values[key] = value
 	}
-	err := ra.DatabaseAccessor.MSet(context.Background(), options.WorkspaceId, values)
+	err := ra.DatabaseAccessor.MSet(context.Background(), workspaceId, values)
 	if err != nil {
 		return []string{}, fmt.Errorf("error persisting dir chunk content: %w", err)
 	}
-
-	dirChunkSubkeys := hashes
-	return ra.RankedSubkeys(RankedSubkeysOptions{
-		RankedViaEmbeddingOptions: options.RankedViaEmbeddingOptions,
-		ContentType:               tree_sitter.ContentTypeDirChunk,
-		Subkeys:                   dirChunkSubkeys,
-	})
+	return hashes, nil
 }
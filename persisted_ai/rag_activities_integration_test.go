@@ -110,7 +110,7 @@ func TestRankedDirSignatureOutline_Integration(t *testing.T) {
 	}
 
 	// Execute the function under test
-	output, err := ragActivities.RankedDirSignatureOutline(options)
+	output, err := ragActivities.RankedDirSignatureOutline(context.Background(), options)
 	require.NotEmpty(t, output, "RankedDirSignatureOutline output should not be empty")
 	require.NoError(t, err, "RankedDirSignatureOutline returned an error")
 
@@ -0,0 +1,145 @@
+package persisted_ai
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sidekick/coding/lsp"
+	"sidekick/coding/tree_sitter"
+	"sidekick/env"
+	"sidekick/utils"
+	"sort"
+	"strings"
+
+	"github.com/kelindar/binary"
+)
+
+const (
+	// weight for files referenced by their full relative path
+	mentionedPathWeight = 1.0
+	// weight for files only referenced by their file name, which is more
+	// likely to be ambiguous
+	mentionedFileNameWeight = 0.5
+)
+
+// graphRankedSubkeys orders file signature subkeys by the personalized
+// centrality of their files within the repository's symbol graph. Files
+// mentioned in the rank query or already in context are the personalization
+// seeds, so their callers and callees rank highly even when they aren't
+// lexically similar to the query. Subkeys for files that can't be reached
+// from the seeds are omitted.
+func (ra *RagActivities) graphRankedSubkeys(ctx context.Context, options RankedDirSignatureOutlineOptions, subkeys []string) ([]string, error) {
+	basePath := options.EnvContainer.Env.GetWorkingDirectory()
+	graph, err := tree_sitter.BuildSymbolGraph(basePath, ra.lspDefinitionResolver(ctx, options.EnvContainer))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build symbol graph: %w", err)
+	}
+
+	personalization := symbolGraphPersonalization(graph.Files, options.RankQuery, options.ContextFilePaths)
+	scores := graph.PersonalizedPageRank(personalization)
+
+	keys := make([]string, len(subkeys))
+	for i, subkey := range subkeys {
+		keys[i] = fmt.Sprintf("%s:%s", tree_sitter.ContentTypeFileSignature, subkey)
+	}
+	values, err := ra.DatabaseAccessor.MGet(ctx, options.WorkspaceId, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	subkeyScores := make(map[string]float64, len(subkeys))
+	ranked := make([]string, 0, len(subkeys))
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		var text string
+		if err := binary.Unmarshal(value, &text); err != nil {
+			return nil, fmt.Errorf("file signature for key %s failed to unmarshal: %w", keys[i], err)
+		}
+		path, _, _ := strings.Cut(text, "\n")
+		if score := scores[path]; score > 0 {
+			subkeyScores[subkeys[i]] = score
+			ranked = append(ranked, subkeys[i])
+		}
+	}
+
+	// chunks of the same file keep their original relative order
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return subkeyScores[ranked[i]] > subkeyScores[ranked[j]]
+	})
+	return ranked, nil
+}
+
+// symbolGraphPersonalization weights the files mentioned in the query and the
+// files already in context
+func symbolGraphPersonalization(files []string, rankQuery string, contextFilePaths []string) map[string]float64 {
+	personalization := make(map[string]float64)
+	for _, path := range contextFilePaths {
+		personalization[filepath.Clean(path)] = mentionedPathWeight
+	}
+	for _, file := range files {
+		if personalization[file] > 0 {
+			continue
+		}
+		if strings.Contains(rankQuery, file) {
+			personalization[file] = mentionedPathWeight
+		} else if mentionsWord(rankQuery, filepath.Base(file)) {
+			personalization[file] = mentionedFileNameWeight
+		}
+	}
+	return personalization
+}
+
+func mentionsWord(text, word string) bool {
+	pattern := `(^|[^\w.-])` + regexp.QuoteMeta(word) + `($|[^\w-])`
+	matched, _ := regexp.MatchString(pattern, text)
+	return matched
+}
+
+// lspDefinitionResolver resolves ambiguous references via LSP, for languages
+// that have an LSP integration. It returns nil when LSP isn't available.
+func (ra *RagActivities) lspDefinitionResolver(ctx context.Context, envContainer env.EnvContainer) tree_sitter.DefinitionResolver {
+	if ra.LSPActivities == nil {
+		return nil
+	}
+	basePath := envContainer.Env.GetWorkingDirectory()
+	failedLanguages := make(map[string]bool)
+
+	return func(relativePath string, reference tree_sitter.SymbolReference) ([]string, error) {
+		language := utils.InferLanguageNameFromFilePath(relativePath)
+		if language != "golang" || failedLanguages[language] {
+			return nil, nil
+		}
+
+		locations, err := ra.LSPActivities.FindDefinitionsActivity(ctx, lsp.FindDefinitionsActivityInput{
+			EnvContainer:     envContainer,
+			RelativeFilePath: relativePath,
+			Position: lsp.Position{
+				Line:      int(reference.Point.Row),
+				Character: int(reference.Point.Column),
+			},
+		})
+		if err != nil {
+			// avoid retrying a broken or missing language server for every reference
+			failedLanguages[language] = true
+			return nil, err
+		}
+
+		var paths []string
+		for _, location := range locations {
+			parsedUrl, err := url.Parse(location.URI)
+			if err != nil {
+				continue
+			}
+			path, err := filepath.Rel(basePath, parsedUrl.Path)
+			if err != nil || strings.HasPrefix(path, "..") {
+				continue
+			}
+			paths = append(paths, path)
+		}
+		return paths, nil
+	}
+}
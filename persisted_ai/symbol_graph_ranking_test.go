@@ -0,0 +1,117 @@
+package persisted_ai
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sidekick/coding/tree_sitter"
+	"sidekick/env"
+	"sidekick/srv"
+	"sidekick/srv/sqlite"
+
+	"github.com/kelindar/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymbolGraphPersonalization(t *testing.T) {
+	t.Parallel()
+	files := []string{"api/handler.go", "store/store.go", "models/user.go", "models/user_test.go"}
+
+	tests := []struct {
+		name             string
+		rankQuery        string
+		contextFilePaths []string
+		expected         map[string]float64
+	}{
+		{
+			name:      "nothing mentioned",
+			rankQuery: "add a feature",
+			expected:  map[string]float64{},
+		},
+		{
+			name:      "full path mentioned",
+			rankQuery: "update store/store.go to save users",
+			expected:  map[string]float64{"store/store.go": mentionedPathWeight},
+		},
+		{
+			name:      "file name mentioned",
+			rankQuery: "the user.go model needs a new field",
+			expected:  map[string]float64{"models/user.go": mentionedFileNameWeight},
+		},
+		{
+			name:      "file name must match fully",
+			rankQuery: "see my_user.go and user.gox",
+			expected:  map[string]float64{},
+		},
+		{
+			name:             "files in context",
+			rankQuery:        "add a feature",
+			contextFilePaths: []string{"./api/handler.go"},
+			expected:         map[string]float64{"api/handler.go": mentionedPathWeight},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, symbolGraphPersonalization(files, tt.rankQuery, tt.contextFilePaths))
+		})
+	}
+}
+
+func TestGraphRankedSubkeys(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	files := map[string]string{
+		"models/user.go":    "package models\n\ntype User struct {\n\tName string\n}\n",
+		"store/store.go":    "package store\n\nfunc SaveUser(u models.User) error {\n\treturn nil\n}\n",
+		"api/handler.go":    "package api\n\nfunc handle() {\n\tstore.SaveUser(models.User{})\n}\n",
+		"unrelated/misc.go": "package unrelated\n\nfunc helper() {}\n",
+	}
+	for relativePath, content := range files {
+		path := filepath.Join(dir, relativePath)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	storage := sqlite.NewTestSqliteStorage(t, "test-graph-ranked-subkeys")
+	ra := RagActivities{DatabaseAccessor: storage}
+	ts := tree_sitter.TreeSitterActivities{DatabaseAccessor: storage}
+	subkeys, err := ts.CreateDirSignatureOutlines("test", dir, 10000)
+	require.NoError(t, err)
+	require.Len(t, subkeys, 4)
+
+	options := RankedDirSignatureOutlineOptions{
+		RankedViaEmbeddingOptions: RankedViaEmbeddingOptions{
+			WorkspaceId:  "test",
+			EnvContainer: env.EnvContainer{Env: &env.LocalEnv{WorkingDirectory: dir}},
+			RankQuery:    "handle errors in api/handler.go",
+		},
+	}
+	ranked, err := ra.graphRankedSubkeys(context.Background(), options, subkeys)
+	require.NoError(t, err)
+
+	// only the mentioned file and its dependencies are reachable
+	rankedPaths := make([]string, 0, len(ranked))
+	for _, subkey := range ranked {
+		rankedPaths = append(rankedPaths, signatureSubkeyPath(t, storage, subkey))
+	}
+	require.Len(t, rankedPaths, 3)
+	assert.Equal(t, "api/handler.go", rankedPaths[0])
+	assert.ElementsMatch(t, []string{"store/store.go", "models/user.go"}, rankedPaths[1:])
+}
+
+func signatureSubkeyPath(t *testing.T, storage srv.Storage, subkey string) string {
+	values, err := storage.MGet(context.Background(), "test", []string{fmt.Sprintf("%s:%s", tree_sitter.ContentTypeFileSignature, subkey)})
+	require.NoError(t, err)
+	require.NotNil(t, values[0])
+	var text string
+	require.NoError(t, binary.Unmarshal(values[0], &text))
+	path, _, _ := strings.Cut(text, "\n")
+	return path
+}
@@ -0,0 +1,52 @@
+package secret_manager
+
+import (
+	"fmt"
+	"sidekick/common"
+)
+
+// NewSecretManagerFromConfig builds a composite secret manager that consults
+// the configured secret managers in order. Without any configured, the system
+// keyring is tried first and then the local config file.
+func NewSecretManagerFromConfig(configs []common.SecretManagerConfig) (*CompositeSecretManager, error) {
+	if len(configs) == 0 {
+		return NewCompositeSecretManager([]SecretManager{
+			KeyringSecretManager{},
+			LocalConfigSecretManager{},
+		}), nil
+	}
+
+	managers := make([]SecretManager, 0, len(configs))
+	for i, config := range configs {
+		manager, err := newSecretManager(config)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret manager at index %d: %w", i, err)
+		}
+		managers = append(managers, manager)
+	}
+	return NewCompositeSecretManager(managers), nil
+}
+
+func newSecretManager(config common.SecretManagerConfig) (SecretManager, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	switch SecretManagerType(config.Type) {
+	case EnvSecretManagerType:
+		return EnvSecretManager{}, nil
+	case KeyringSecretManagerType:
+		return KeyringSecretManager{}, nil
+	case LocalConfigSecretManagerType:
+		return LocalConfigSecretManager{}, nil
+	case PassSecretManagerType:
+		return PassSecretManager{Prefix: config.Prefix}, nil
+	case OnePasswordSecretManagerType:
+		return OnePasswordSecretManager{Vault: config.Vault, Field: config.Field, Account: config.Account}, nil
+	case VaultSecretManagerType:
+		return VaultSecretManager{Address: config.Address, Mount: config.Mount, Path: config.Path, Namespace: config.Namespace}, nil
+	case EncryptedFileSecretManagerType:
+		return EncryptedFileSecretManager{Path: config.File, IdentityFile: config.IdentityFile, PassphraseEnvVar: config.PassphraseEnvVar}, nil
+	default:
+		return nil, fmt.Errorf("unsupported secret manager type: %s", config.Type)
+	}
+}
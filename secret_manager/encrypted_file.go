@@ -0,0 +1,176 @@
+package secret_manager
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+const defaultPassphraseEnvVar = "SIDE_SECRETS_PASSPHRASE"
+
+// EncryptedFileSecretManager reads secrets from an age-encrypted file, binary
+// or armored, containing KEY=VALUE lines. The file is decrypted with the age
+// identities in IdentityFile when set, and otherwise with the passphrase held
+// in the PassphraseEnvVar env var (SIDE_SECRETS_PASSPHRASE by default).
+type EncryptedFileSecretManager struct {
+	Path             string `json:"path"`
+	IdentityFile     string `json:"identityFile,omitempty"`
+	PassphraseEnvVar string `json:"passphraseEnvVar,omitempty"`
+}
+
+func (e EncryptedFileSecretManager) GetType() SecretManagerType {
+	return EncryptedFileSecretManagerType
+}
+
+func (e EncryptedFileSecretManager) GetSecret(secretName string) (string, error) {
+	secrets, err := e.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[secretName]
+	if !ok || secret == "" {
+		return "", fmt.Errorf("secret %s not found in encrypted file %s", secretName, e.Path)
+	}
+	return secret, nil
+}
+
+type decryptedSecretsFile struct {
+	modTime time.Time
+	size    int64
+	secrets map[string]string
+}
+
+// decrypting with a passphrase is deliberately slow, so decrypted files are
+// cached until they change on disk
+var (
+	decryptedSecretsMu    sync.Mutex
+	decryptedSecretsCache = map[string]decryptedSecretsFile{}
+)
+
+func (e EncryptedFileSecretManager) load() (map[string]string, error) {
+	path, err := expandHome(e.Path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encrypted secrets file: %w", err)
+	}
+
+	cacheKey := strings.Join([]string{path, e.IdentityFile, e.PassphraseEnvVar}, "\x00")
+	decryptedSecretsMu.Lock()
+	defer decryptedSecretsMu.Unlock()
+	if cached, ok := decryptedSecretsCache[cacheKey]; ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.secrets, nil
+	}
+
+	identities, err := e.identities()
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encrypted secrets file: %w", err)
+	}
+	var src io.Reader = bytes.NewReader(ciphertext)
+	if bytes.HasPrefix(bytes.TrimSpace(ciphertext), []byte(armor.Header)) {
+		src = armor.NewReader(bytes.NewReader(bytes.TrimSpace(ciphertext)))
+	}
+	plaintext, err := age.Decrypt(src, identities...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets file %s: %w", e.Path, err)
+	}
+	secrets, err := parseSecretsFile(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse decrypted secrets file %s: %w", e.Path, err)
+	}
+
+	decryptedSecretsCache[cacheKey] = decryptedSecretsFile{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		secrets: secrets,
+	}
+	return secrets, nil
+}
+
+func (e EncryptedFileSecretManager) identities() ([]age.Identity, error) {
+	if e.IdentityFile != "" {
+		path, err := expandHome(e.IdentityFile)
+		if err != nil {
+			return nil, err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open age identity file: %w", err)
+		}
+		defer f.Close()
+		identities, err := age.ParseIdentities(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse age identity file %s: %w", e.IdentityFile, err)
+		}
+		return identities, nil
+	}
+
+	envVar := e.PassphraseEnvVar
+	if envVar == "" {
+		envVar = defaultPassphraseEnvVar
+	}
+	passphrase := os.Getenv(envVar)
+	if passphrase == "" {
+		return nil, fmt.Errorf("no identity file configured and %s is not set", envVar)
+	}
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid passphrase in %s: %w", envVar, err)
+	}
+	return []age.Identity{identity}, nil
+}
+
+// parseSecretsFile parses dotenv-style KEY=VALUE lines. Blank lines and lines
+// starting with # are ignored, and values may be wrapped in quotes.
+func parseSecretsFile(r io.Reader) (map[string]string, error) {
+	secrets := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d is not in KEY=VALUE format", lineNumber)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		secrets[strings.TrimSpace(key)] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to expand %s: %w", path, err)
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
@@ -0,0 +1,119 @@
+package secret_manager
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecretsFileContent = `# sidekick secrets
+OPENAI_API_KEY=sk-openai
+export ANTHROPIC_API_KEY="sk-anthropic"
+
+GOOGLE_API_KEY = 'sk-google'
+`
+
+func writeEncryptedFile(t *testing.T, recipient age.Recipient, armored bool, content string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	var out io.Writer = &buf
+	var armorWriter io.WriteCloser
+	if armored {
+		armorWriter = armor.NewWriter(&buf)
+		out = armorWriter
+	}
+	w, err := age.Encrypt(out, recipient)
+	require.NoError(t, err)
+	_, err = io.WriteString(w, content)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	if armorWriter != nil {
+		require.NoError(t, armorWriter.Close())
+	}
+
+	path := filepath.Join(t.TempDir(), "secrets.age")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0600))
+	return path
+}
+
+func TestEncryptedFileSecretManager_IdentityFile(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	identityFile := filepath.Join(t.TempDir(), "key.txt")
+	require.NoError(t, os.WriteFile(identityFile, []byte("# test key\n"+identity.String()+"\n"), 0600))
+
+	for _, armored := range []bool{false, true} {
+		path := writeEncryptedFile(t, identity.Recipient(), armored, testSecretsFileContent)
+		manager := EncryptedFileSecretManager{Path: path, IdentityFile: identityFile}
+
+		for name, expected := range map[string]string{
+			"OPENAI_API_KEY":    "sk-openai",
+			"ANTHROPIC_API_KEY": "sk-anthropic",
+			"GOOGLE_API_KEY":    "sk-google",
+		} {
+			secret, err := manager.GetSecret(name)
+			require.NoError(t, err)
+			assert.Equal(t, expected, secret)
+		}
+
+		_, err = manager.GetSecret("MISSING")
+		assert.Error(t, err)
+	}
+
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	path := writeEncryptedFile(t, other.Recipient(), false, testSecretsFileContent)
+	_, err = EncryptedFileSecretManager{Path: path, IdentityFile: identityFile}.GetSecret("OPENAI_API_KEY")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to decrypt")
+}
+
+func TestEncryptedFileSecretManager_Passphrase(t *testing.T) {
+	recipient, err := age.NewScryptRecipient("correct horse battery staple")
+	require.NoError(t, err)
+	recipient.SetWorkFactor(10)
+	path := writeEncryptedFile(t, recipient, true, testSecretsFileContent)
+
+	t.Setenv(defaultPassphraseEnvVar, "")
+	_, err = EncryptedFileSecretManager{Path: path}.GetSecret("OPENAI_API_KEY")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), defaultPassphraseEnvVar)
+
+	t.Setenv(defaultPassphraseEnvVar, "correct horse battery staple")
+	secret, err := EncryptedFileSecretManager{Path: path}.GetSecret("OPENAI_API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-openai", secret)
+
+	t.Setenv("MY_PASSPHRASE", "wrong")
+	_, err = EncryptedFileSecretManager{Path: path, PassphraseEnvVar: "MY_PASSPHRASE"}.GetSecret("OPENAI_API_KEY")
+	assert.Error(t, err)
+}
+
+func TestEncryptedFileSecretManager_ReloadsChangedFile(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	identityFile := filepath.Join(t.TempDir(), "key.txt")
+	require.NoError(t, os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600))
+
+	path := writeEncryptedFile(t, identity.Recipient(), false, "OPENAI_API_KEY=old\n")
+	manager := EncryptedFileSecretManager{Path: path, IdentityFile: identityFile}
+	secret, err := manager.GetSecret("OPENAI_API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "old", secret)
+
+	updated := writeEncryptedFile(t, identity.Recipient(), false, "OPENAI_API_KEY=newer\n")
+	content, err := os.ReadFile(updated)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, content, 0600))
+
+	secret, err = manager.GetSecret("OPENAI_API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "newer", secret)
+}
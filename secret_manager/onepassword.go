@@ -0,0 +1,52 @@
+package secret_manager
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultOnePasswordVault = "sidekick"
+	defaultOnePasswordField = "credential"
+)
+
+// OnePasswordSecretManager reads secrets through the 1Password CLI, op, using
+// the secret reference "op://<vault>/<secret name>/<field>". The op CLI must
+// already be signed in, or be given a service account token via the
+// OP_SERVICE_ACCOUNT_TOKEN env var.
+type OnePasswordSecretManager struct {
+	Vault   string `json:"vault,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Account string `json:"account,omitempty"`
+}
+
+func (o OnePasswordSecretManager) GetType() SecretManagerType {
+	return OnePasswordSecretManagerType
+}
+
+func (o OnePasswordSecretManager) GetSecret(secretName string) (string, error) {
+	vault := o.Vault
+	if vault == "" {
+		vault = defaultOnePasswordVault
+	}
+	field := o.Field
+	if field == "" {
+		field = defaultOnePasswordField
+	}
+	reference := fmt.Sprintf("op://%s/%s/%s", vault, secretName, field)
+
+	args := []string{"read", "--no-newline", reference}
+	if o.Account != "" {
+		args = append(args, "--account", o.Account)
+	}
+	out, err := runSecretCommand("op", args...)
+	if err != nil {
+		return "", fmt.Errorf("error retrieving %s from 1password: %w", reference, err)
+	}
+
+	secret := strings.TrimSpace(out)
+	if secret == "" {
+		return "", fmt.Errorf("1password secret %s is empty", reference)
+	}
+	return secret, nil
+}
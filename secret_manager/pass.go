@@ -0,0 +1,57 @@
+package secret_manager
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+const defaultPassPrefix = "sidekick"
+
+// PassSecretManager reads secrets from the standard unix password manager,
+// pass. Each secret is the first line of the "<prefix>/<secret name>" entry,
+// following pass's convention of storing the password on the first line.
+type PassSecretManager struct {
+	Prefix string `json:"prefix,omitempty"`
+}
+
+func (p PassSecretManager) GetType() SecretManagerType {
+	return PassSecretManagerType
+}
+
+func (p PassSecretManager) GetSecret(secretName string) (string, error) {
+	prefix := p.Prefix
+	if prefix == "" {
+		prefix = defaultPassPrefix
+	}
+	entry := path.Join(prefix, secretName)
+
+	out, err := runSecretCommand("pass", "show", entry)
+	if err != nil {
+		return "", fmt.Errorf("error retrieving %s from pass: %w", entry, err)
+	}
+
+	secret, _, _ := strings.Cut(out, "\n")
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return "", fmt.Errorf("pass entry %s is empty", entry)
+	}
+	return secret, nil
+}
+
+// runSecretCommand runs a password manager CLI and returns its stdout. Its
+// stderr is included in the error on failure, since that's where these tools
+// explain what went wrong, eg a missing entry or a locked vault.
+func runSecretCommand(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return string(out), nil
+}
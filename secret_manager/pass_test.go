@@ -0,0 +1,70 @@
+package secret_manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCommand puts an executable shell script with the given name first on
+// PATH, so password manager CLIs can be faked
+func fakeCommand(t *testing.T, name, script string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestPassSecretManager_GetSecret(t *testing.T) {
+	fakeCommand(t, "pass", `
+if [ "$1" != "show" ]; then exit 2; fi
+case "$2" in
+  sidekick/OPENAI_API_KEY) printf 'sk-openai\nurl: https://example.com\n' ;;
+  work/ANTHROPIC_API_KEY) echo 'sk-anthropic' ;;
+  sidekick/EMPTY) echo '' ;;
+  *) echo "Error: $2 is not in the password store." >&2; exit 1 ;;
+esac
+`)
+
+	secret, err := PassSecretManager{}.GetSecret("OPENAI_API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-openai", secret)
+
+	secret, err = PassSecretManager{Prefix: "work"}.GetSecret("ANTHROPIC_API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-anthropic", secret)
+
+	_, err = PassSecretManager{}.GetSecret("MISSING")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not in the password store")
+
+	_, err = PassSecretManager{}.GetSecret("EMPTY")
+	assert.Error(t, err)
+}
+
+func TestOnePasswordSecretManager_GetSecret(t *testing.T) {
+	fakeCommand(t, "op", `
+if [ "$1" != "read" ] || [ "$2" != "--no-newline" ]; then exit 2; fi
+case "$3 $4 $5" in
+  "op://sidekick/OPENAI_API_KEY/credential  ") printf 'sk-openai' ;;
+  "op://Private/OPENAI_API_KEY/password --account my.1password.com") printf 'sk-private' ;;
+  *) echo "[ERROR] could not read secret $3" >&2; exit 1 ;;
+esac
+`)
+
+	secret, err := OnePasswordSecretManager{}.GetSecret("OPENAI_API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-openai", secret)
+
+	secret, err = OnePasswordSecretManager{Vault: "Private", Field: "password", Account: "my.1password.com"}.GetSecret("OPENAI_API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-private", secret)
+
+	_, err = OnePasswordSecretManager{}.GetSecret("MISSING")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "could not read secret op://sidekick/MISSING/credential")
+}
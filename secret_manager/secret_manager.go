@@ -23,6 +23,11 @@ const (
 	KeyringSecretManagerType     SecretManagerType = "keyring"
 	LocalConfigSecretManagerType SecretManagerType = "local_config"
 	CompositeSecretManagerType   SecretManagerType = "composite"
+
+	PassSecretManagerType          SecretManagerType = "pass"
+	OnePasswordSecretManagerType   SecretManagerType = "1password"
+	VaultSecretManagerType         SecretManagerType = "vault"
+	EncryptedFileSecretManagerType SecretManagerType = "encrypted_file"
 )
 
 type EnvSecretManager struct{}
@@ -237,6 +242,30 @@ func (sc *SecretManagerContainer) UnmarshalJSON(data []byte) error {
 			return err
 		}
 		sc.SecretManager = csm
+	case string(PassSecretManagerType):
+		var psm *PassSecretManager
+		if err := json.Unmarshal(v.Manager, &psm); err != nil {
+			return err
+		}
+		sc.SecretManager = psm
+	case string(OnePasswordSecretManagerType):
+		var osm *OnePasswordSecretManager
+		if err := json.Unmarshal(v.Manager, &osm); err != nil {
+			return err
+		}
+		sc.SecretManager = osm
+	case string(VaultSecretManagerType):
+		var vsm *VaultSecretManager
+		if err := json.Unmarshal(v.Manager, &vsm); err != nil {
+			return err
+		}
+		sc.SecretManager = vsm
+	case string(EncryptedFileSecretManagerType):
+		var efsm *EncryptedFileSecretManager
+		if err := json.Unmarshal(v.Manager, &efsm); err != nil {
+			return err
+		}
+		sc.SecretManager = efsm
 	default:
 		return fmt.Errorf("unknown SecretManager type: %s", v.Type)
 	}
@@ -2,6 +2,7 @@ package secret_manager
 
 import (
 	"encoding/json"
+	"sidekick/common"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -24,6 +25,22 @@ func TestSecretManagerContainer_MarshalUnmarshal(t *testing.T) {
 			name:    "MockSecretManager",
 			manager: &MockSecretManager{},
 		},
+		{
+			name:    "PassSecretManager",
+			manager: &PassSecretManager{Prefix: "work"},
+		},
+		{
+			name:    "OnePasswordSecretManager",
+			manager: &OnePasswordSecretManager{Vault: "Private", Field: "password"},
+		},
+		{
+			name:    "VaultSecretManager",
+			manager: &VaultSecretManager{Address: "http://127.0.0.1:8200", Mount: "kv", Path: "sidekick"},
+		},
+		{
+			name:    "EncryptedFileSecretManager",
+			manager: &EncryptedFileSecretManager{Path: "/tmp/secrets.age", PassphraseEnvVar: "MY_PASSPHRASE"},
+		},
 		{
 			name: "CompositeSecretManager",
 			manager: NewCompositeSecretManager([]SecretManager{
@@ -51,3 +68,51 @@ func TestSecretManagerContainer_MarshalUnmarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestNewSecretManagerFromConfig(t *testing.T) {
+	manager, err := NewSecretManagerFromConfig(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, NewCompositeSecretManager([]SecretManager{
+		KeyringSecretManager{},
+		LocalConfigSecretManager{},
+	}), manager)
+
+	manager, err = NewSecretManagerFromConfig([]common.SecretManagerConfig{
+		{Type: "vault", Address: "http://127.0.0.1:8200", Path: "team/sidekick"},
+		{Type: "pass", Prefix: "work"},
+		{Type: "1password", Vault: "Private"},
+		{Type: "encrypted_file", File: "~/secrets.age", IdentityFile: "~/key.txt"},
+		{Type: "env"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, NewCompositeSecretManager([]SecretManager{
+		VaultSecretManager{Address: "http://127.0.0.1:8200", Path: "team/sidekick"},
+		PassSecretManager{Prefix: "work"},
+		OnePasswordSecretManager{Vault: "Private"},
+		EncryptedFileSecretManager{Path: "~/secrets.age", IdentityFile: "~/key.txt"},
+		EnvSecretManager{},
+	}), manager)
+
+	_, err = NewSecretManagerFromConfig([]common.SecretManagerConfig{{Type: "pass"}, {Type: "bogus"}})
+	assert.ErrorContains(t, err, "index 1")
+
+	_, err = NewSecretManagerFromConfig([]common.SecretManagerConfig{{Type: "encrypted_file"}})
+	assert.ErrorContains(t, err, "file is required")
+}
+
+func TestCompositeSecretManager_Order(t *testing.T) {
+	fakeCommand(t, "pass", `echo "from-pass"`)
+	t.Setenv("SIDE_OPENAI_API_KEY", "from-env")
+
+	manager, err := NewSecretManagerFromConfig([]common.SecretManagerConfig{{Type: "env"}, {Type: "pass"}})
+	assert.NoError(t, err)
+	secret, err := manager.GetSecret("OPENAI_API_KEY")
+	assert.NoError(t, err)
+	assert.Equal(t, "from-env", secret)
+
+	manager, err = NewSecretManagerFromConfig([]common.SecretManagerConfig{{Type: "pass"}, {Type: "env"}})
+	assert.NoError(t, err)
+	secret, err = manager.GetSecret("OPENAI_API_KEY")
+	assert.NoError(t, err)
+	assert.Equal(t, "from-pass", secret)
+}
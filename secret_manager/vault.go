@@ -0,0 +1,123 @@
+package secret_manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	defaultVaultMount = "secret"
+	defaultVaultPath  = "sidekick"
+)
+
+var vaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// VaultSecretManager reads secrets from a KV version 2 secrets engine in
+// HashiCorp Vault or a compatible server (eg OpenBao). All secrets are keys
+// within the single secret at <mount>/<path>.
+//
+// The token is deliberately not a field: it is read from the VAULT_TOKEN env
+// var or the ~/.vault-token file written by "vault login" on each lookup, so
+// it's never serialized along with the secret manager.
+type VaultSecretManager struct {
+	// Address defaults to the VAULT_ADDR env var
+	Address string `json:"address,omitempty"`
+	Mount   string `json:"mount,omitempty"`
+	Path    string `json:"path,omitempty"`
+	// Namespace defaults to the VAULT_NAMESPACE env var
+	Namespace string `json:"namespace,omitempty"`
+}
+
+func (v VaultSecretManager) GetType() SecretManagerType {
+	return VaultSecretManagerType
+}
+
+func (v VaultSecretManager) GetSecret(secretName string) (string, error) {
+	address := v.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return "", fmt.Errorf("vault address not configured and VAULT_ADDR is not set")
+	}
+	mount := v.Mount
+	if mount == "" {
+		mount = defaultVaultMount
+	}
+	secretPath := v.Path
+	if secretPath == "" {
+		secretPath = defaultVaultPath
+	}
+	namespace := v.Namespace
+	if namespace == "" {
+		namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+
+	token, err := vaultToken()
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/data/%s", strings.TrimSuffix(address, "/"), strings.Trim(mount, "/"), strings.Trim(secretPath, "/"))
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	resp, err := vaultHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to read vault secret %s/%s: %w", mount, secretPath, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+		Errors []string `json:"errors"`
+	}
+	decodeErr := json.NewDecoder(resp.Body).Decode(&body)
+	if resp.StatusCode != http.StatusOK {
+		if len(body.Errors) > 0 {
+			return "", fmt.Errorf("failed to read vault secret %s/%s: status %s: %s", mount, secretPath, resp.Status, strings.Join(body.Errors, "; "))
+		}
+		return "", fmt.Errorf("failed to read vault secret %s/%s: status %s", mount, secretPath, resp.Status)
+	}
+	if decodeErr != nil {
+		return "", fmt.Errorf("failed to decode vault response: %w", decodeErr)
+	}
+
+	value, ok := body.Data.Data[secretName]
+	if !ok {
+		return "", fmt.Errorf("secret %s not found in vault secret %s/%s", secretName, mount, secretPath)
+	}
+	secret, ok := value.(string)
+	if !ok || secret == "" {
+		return "", fmt.Errorf("secret %s in vault secret %s/%s is not a non-empty string", secretName, mount, secretPath)
+	}
+	return secret, nil
+}
+
+func vaultToken() (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("VAULT_TOKEN is not set and home directory is unknown: %w", err)
+	}
+	token, err := os.ReadFile(filepath.Join(home, ".vault-token"))
+	if err != nil {
+		return "", fmt.Errorf("VAULT_TOKEN is not set and failed to read ~/.vault-token: %w", err)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
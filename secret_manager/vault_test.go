@@ -0,0 +1,92 @@
+package secret_manager
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeVaultServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"permission denied"}})
+			return
+		}
+		var data map[string]interface{}
+		switch {
+		case r.URL.Path == "/v1/secret/data/sidekick":
+			data = map[string]interface{}{"OPENAI_API_KEY": "sk-openai"}
+		case r.URL.Path == "/v1/kv/data/team/sidekick" && r.Header.Get("X-Vault-Namespace") == "eng":
+			data = map[string]interface{}{"ANTHROPIC_API_KEY": "sk-anthropic", "NOT_A_STRING": 42}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     data,
+				"metadata": map[string]interface{}{"version": 1},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestVaultSecretManager_GetSecret(t *testing.T) {
+	server := newFakeVaultServer(t)
+	t.Setenv("VAULT_TOKEN", "test-token")
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_NAMESPACE", "")
+
+	secret, err := VaultSecretManager{}.GetSecret("OPENAI_API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-openai", secret)
+
+	manager := VaultSecretManager{Address: server.URL + "/", Mount: "kv", Path: "team/sidekick", Namespace: "eng"}
+	secret, err = manager.GetSecret("ANTHROPIC_API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-anthropic", secret)
+
+	_, err = manager.GetSecret("NOT_A_STRING")
+	assert.Error(t, err)
+
+	_, err = VaultSecretManager{}.GetSecret("MISSING")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+
+	_, err = VaultSecretManager{Path: "other"}.GetSecret("OPENAI_API_KEY")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+}
+
+func TestVaultSecretManager_TokenFile(t *testing.T) {
+	server := newFakeVaultServer(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("VAULT_TOKEN", "")
+
+	_, err := VaultSecretManager{Address: server.URL}.GetSecret("OPENAI_API_KEY")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ".vault-token")
+
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".vault-token"), []byte("wrong-token\n"), 0600))
+	_, err = VaultSecretManager{Address: server.URL}.GetSecret("OPENAI_API_KEY")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "permission denied")
+
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".vault-token"), []byte("test-token\n"), 0600))
+	secret, err := VaultSecretManager{Address: server.URL}.GetSecret("OPENAI_API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-openai", secret)
+}
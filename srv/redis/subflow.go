@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"sidekick/domain"
+	"sidekick/srv"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -46,7 +47,7 @@ func (s Storage) GetSubflow(ctx context.Context, workspaceId, subflowId string)
 	subflowJSON, err := s.Client.Get(ctx, subflowKey).Result()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			return domain.Subflow{}, fmt.Errorf("subflow not found: %s", subflowId)
+			return domain.Subflow{}, fmt.Errorf("subflow not found: %s: %w", subflowId, srv.ErrNotFound)
 		}
 		return domain.Subflow{}, fmt.Errorf("failed to retrieve subflow: %w", err)
 	}
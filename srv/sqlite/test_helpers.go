@@ -13,6 +13,9 @@ func NewTestSqliteStorage(t *testing.T, dbName string) *Storage {
 	require.NoError(t, err)
 	kvDb, err := sql.Open("sqlite", ":memory:")
 	require.NoError(t, err)
+	// each connection to ":memory:" opens a separate, empty database
+	db.SetMaxOpenConns(1)
+	kvDb.SetMaxOpenConns(1)
 
 	storage := &Storage{db: db, kvDb: kvDb}
 	err = storage.MigrateUp(dbName)
@@ -0,0 +1,121 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// TemporalInterceptors returns the interceptors to set on Temporal client
+// options so that metrics are recorded and, when tracing is enabled, workflow,
+// activity and flow spans are created and propagated.
+func TemporalInterceptors() []interceptor.ClientInterceptor {
+	if temporalTracer == nil {
+		return []interceptor.ClientInterceptor{&sidekickInterceptor{}}
+	}
+	// order matters: our outbound calls must run before the tracing
+	// interceptor's so it sees the current flow span, which requires ours to be
+	// last in the list
+	return []interceptor.ClientInterceptor{
+		interceptor.NewTracingInterceptor(temporalTracer),
+		&sidekickInterceptor{},
+	}
+}
+
+/*
+ObserveWorkflowDuration measures the workflow time elapsed until the returned
+func is called and records it via the given record func. workflow.Now is used
+so the duration is consistent across replays, and nothing is recorded while
+replaying so each duration is only recorded once.
+*/
+func ObserveWorkflowDuration(ctx workflow.Context, record func(status string, duration time.Duration)) func(err error) {
+	start := workflow.Now(ctx)
+	return func(err error) {
+		if workflow.IsReplaying(ctx) {
+			return
+		}
+		record(statusFromError(err), workflow.Now(ctx).Sub(start))
+	}
+}
+
+func statusFromError(err error) string {
+	switch {
+	case err == nil:
+		return "complete"
+	case temporal.IsCanceledError(err):
+		return "canceled"
+	case workflow.IsContinueAsNewError(err):
+		return "continued_as_new"
+	default:
+		return "failed"
+	}
+}
+
+type sidekickInterceptor struct {
+	interceptor.InterceptorBase
+}
+
+func (i *sidekickInterceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	return &sidekickActivityInboundInterceptor{
+		ActivityInboundInterceptorBase: interceptor.ActivityInboundInterceptorBase{Next: next},
+	}
+}
+
+func (i *sidekickInterceptor) InterceptWorkflow(ctx workflow.Context, next interceptor.WorkflowInboundInterceptor) interceptor.WorkflowInboundInterceptor {
+	return &sidekickWorkflowInboundInterceptor{
+		WorkflowInboundInterceptorBase: interceptor.WorkflowInboundInterceptorBase{Next: next},
+	}
+}
+
+type sidekickActivityInboundInterceptor struct {
+	interceptor.ActivityInboundInterceptorBase
+}
+
+func (i *sidekickActivityInboundInterceptor) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+	result, err := i.Next.ExecuteActivity(ctx, in)
+	if err != nil {
+		RecordActivityFailure(activity.GetInfo(ctx).ActivityType.Name)
+	}
+	return result, err
+}
+
+type sidekickWorkflowInboundInterceptor struct {
+	interceptor.WorkflowInboundInterceptorBase
+}
+
+func (i *sidekickWorkflowInboundInterceptor) Init(outbound interceptor.WorkflowOutboundInterceptor) error {
+	return i.Next.Init(&sidekickWorkflowOutboundInterceptor{
+		WorkflowOutboundInterceptorBase: interceptor.WorkflowOutboundInterceptorBase{Next: outbound},
+	})
+}
+
+func (i *sidekickWorkflowInboundInterceptor) ExecuteWorkflow(ctx workflow.Context, in *interceptor.ExecuteWorkflowInput) (interface{}, error) {
+	ctx = workflow.WithValue(ctx, spanStackKey{}, &spanStack{})
+
+	info := workflow.GetInfo(ctx)
+	result, err := i.Next.ExecuteWorkflow(ctx, in)
+	if !workflow.IsReplaying(ctx) {
+		RecordFlowDuration(info.WorkflowType.Name, statusFromError(err), workflow.Now(ctx).Sub(info.WorkflowStartTime))
+	}
+	return result, err
+}
+
+type sidekickWorkflowOutboundInterceptor struct {
+	interceptor.WorkflowOutboundInterceptorBase
+}
+
+func (o *sidekickWorkflowOutboundInterceptor) ExecuteActivity(ctx workflow.Context, activityType string, args ...interface{}) workflow.Future {
+	return o.Next.ExecuteActivity(withCurrentSpan(ctx), activityType, args...)
+}
+
+func (o *sidekickWorkflowOutboundInterceptor) ExecuteLocalActivity(ctx workflow.Context, activityType string, args ...interface{}) workflow.Future {
+	return o.Next.ExecuteLocalActivity(withCurrentSpan(ctx), activityType, args...)
+}
+
+func (o *sidekickWorkflowOutboundInterceptor) ExecuteChildWorkflow(ctx workflow.Context, childWorkflowType string, args ...interface{}) workflow.ChildWorkflowFuture {
+	return o.Next.ExecuteChildWorkflow(withCurrentSpan(ctx), childWorkflowType, args...)
+}
@@ -0,0 +1,182 @@
+package telemetry
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+)
+
+const metricsNamespace = "sidekick"
+
+// registry holds all sidekick metrics. A dedicated registry is used rather
+// than the global default one to avoid picking up metrics from dependencies.
+var registry = prometheus.NewRegistry()
+
+var (
+	flowDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "flow_duration_seconds",
+		Help:      "Duration of flows from start to completion, by flow type and final status.",
+		Buckets:   []float64{10, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200},
+	}, []string{"flow_type", "status"})
+
+	flowActionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "flow_action_duration_seconds",
+		Help:      "Duration of tracked flow actions, by action type and final status.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 3, 10),
+	}, []string{"action_type", "status"})
+
+	llmRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "llm_request_duration_seconds",
+		Help:      "Latency of LLM chat requests, by provider and model.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 10),
+	}, []string{"provider", "model"})
+
+	llmRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "llm_request_errors_total",
+		Help:      "Number of failed LLM chat requests, by provider and model.",
+	}, []string{"provider", "model"})
+
+	llmTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "llm_tokens_total",
+		Help:      "Number of LLM tokens used, by provider, model and direction (input or output).",
+	}, []string{"provider", "model", "direction"})
+
+	commandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "command_duration_seconds",
+		Help:      "Duration of commands executed on behalf of flows, by command category.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 3, 12),
+	}, []string{"command"})
+
+	commandExits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "command_exits_total",
+		Help:      "Number of commands executed on behalf of flows, by command category and exit code.",
+	}, []string{"command", "exit_code"})
+
+	embeddingCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "embedding_cache_requests_total",
+		Help:      "Number of embedding cache lookups, by result (hit or miss).",
+	}, []string{"result"})
+
+	websocketSubscribers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "websocket_subscribers",
+		Help:      "Number of currently connected websocket subscribers, by stream.",
+	}, []string{"stream"})
+
+	activityFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "temporal_activity_failures_total",
+		Help:      "Number of failed temporal activity attempts, by activity type.",
+	}, []string{"activity_type"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		flowDuration,
+		flowActionDuration,
+		llmRequestDuration,
+		llmRequestErrors,
+		llmTokens,
+		commandDuration,
+		commandExits,
+		embeddingCacheRequests,
+		websocketSubscribers,
+		activityFailures,
+	)
+}
+
+// RegisterCollector adds a collector to the registry served by MetricsHandler.
+// Registering the same collector more than once is not an error.
+func RegisterCollector(c prometheus.Collector) error {
+	err := registry.Register(c)
+	var alreadyRegisteredErr prometheus.AlreadyRegisteredError
+	if errors.As(err, &alreadyRegisteredErr) {
+		return nil
+	}
+	return err
+}
+
+// MetricsHandler serves all sidekick metrics in the prometheus exposition format
+func MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// StartMetricsServer serves MetricsHandler at /metrics on the given port, for
+// processes that don't otherwise run an http server, eg a standalone worker.
+func StartMetricsServer(port int) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", MetricsHandler())
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: mux,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Error().Err(err).Int("port", port).Msg("Failed to start metrics server")
+		}
+	}()
+	return srv
+}
+
+func RecordFlowDuration(flowType, status string, duration time.Duration) {
+	flowDuration.WithLabelValues(flowType, status).Observe(duration.Seconds())
+}
+
+func RecordFlowActionDuration(actionType, status string, duration time.Duration) {
+	flowActionDuration.WithLabelValues(actionType, status).Observe(duration.Seconds())
+}
+
+func RecordLLMRequest(provider, model string, duration time.Duration, inputTokens, outputTokens int, err error) {
+	llmRequestDuration.WithLabelValues(provider, model).Observe(duration.Seconds())
+	if err != nil {
+		llmRequestErrors.WithLabelValues(provider, model).Inc()
+	}
+	llmTokens.WithLabelValues(provider, model, "input").Add(float64(inputTokens))
+	llmTokens.WithLabelValues(provider, model, "output").Add(float64(outputTokens))
+}
+
+// RecordCommand records a command execution under the given category, eg
+// "run_tests" or "git", which callers must keep low-cardinality. Commands that
+// failed to run at all are recorded with an exit code of "error".
+func RecordCommand(category string, exitCode int, duration time.Duration, err error) {
+	exitCodeLabel := strconv.Itoa(exitCode)
+	if err != nil {
+		exitCodeLabel = "error"
+	}
+	commandDuration.WithLabelValues(category).Observe(duration.Seconds())
+	commandExits.WithLabelValues(category, exitCodeLabel).Inc()
+}
+
+func RecordEmbeddingCacheLookups(hits, misses int) {
+	embeddingCacheRequests.WithLabelValues("hit").Add(float64(hits))
+	embeddingCacheRequests.WithLabelValues("miss").Add(float64(misses))
+}
+
+// TrackWebsocketSubscriber increments the subscriber count for the given
+// stream and returns a func that decrements it once the subscriber is gone.
+func TrackWebsocketSubscriber(stream string) func() {
+	gauge := websocketSubscribers.WithLabelValues(stream)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+func RecordActivityFailure(activityType string) {
+	activityFailures.WithLabelValues(activityType).Inc()
+}
@@ -0,0 +1,56 @@
+package telemetry
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	RecordFlowDuration("BasicDevWorkflow", "complete", time.Minute)
+	RecordFlowActionDuration("run_tests", "failed", time.Second)
+	RecordLLMRequest("anthropic", "claude-test", time.Second, 100, 20, errors.New("overloaded"))
+	RecordCommand("git", 128, time.Millisecond, nil)
+	RecordCommand("run_tests", 0, time.Millisecond, errors.New("context deadline exceeded"))
+	RecordEmbeddingCacheLookups(3, 1)
+	untrack := TrackWebsocketSubscriber("flow_events")
+	TrackWebsocketSubscriber("task_changes")
+	untrack()
+	RecordActivityFailure("GitMergeActivity")
+
+	recorder := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, 200, recorder.Code)
+	body, err := io.ReadAll(recorder.Body)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"flow duration", `sidekick_flow_duration_seconds_count{flow_type="BasicDevWorkflow",status="complete"} 1`},
+		{"flow action duration", `sidekick_flow_action_duration_seconds_count{action_type="run_tests",status="failed"} 1`},
+		{"llm latency", `sidekick_llm_request_duration_seconds_count{model="claude-test",provider="anthropic"} 1`},
+		{"llm errors", `sidekick_llm_request_errors_total{model="claude-test",provider="anthropic"} 1`},
+		{"llm input tokens", `sidekick_llm_tokens_total{direction="input",model="claude-test",provider="anthropic"} 100`},
+		{"llm output tokens", `sidekick_llm_tokens_total{direction="output",model="claude-test",provider="anthropic"} 20`},
+		{"command duration", `sidekick_command_duration_seconds_count{command="git"} 1`},
+		{"command exit code", `sidekick_command_exits_total{command="git",exit_code="128"} 1`},
+		{"command error", `sidekick_command_exits_total{command="run_tests",exit_code="error"} 1`},
+		{"embedding cache hits", `sidekick_embedding_cache_requests_total{result="hit"} 3`},
+		{"embedding cache misses", `sidekick_embedding_cache_requests_total{result="miss"} 1`},
+		{"websocket subscriber untracked", `sidekick_websocket_subscribers{stream="flow_events"} 0`},
+		{"websocket subscriber tracked", `sidekick_websocket_subscribers{stream="task_changes"} 1`},
+		{"activity failures", `sidekick_temporal_activity_failures_total{activity_type="GitMergeActivity"} 1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Contains(t, string(body), tt.expected)
+		})
+	}
+}
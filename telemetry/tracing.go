@@ -0,0 +1,156 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sidekick/common"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	temporalotel "go.temporal.io/sdk/contrib/opentelemetry"
+	"go.temporal.io/sdk/interceptor"
+)
+
+const tracerName = "sidekick"
+
+type ExporterType string
+
+const (
+	ExporterTypeNone   ExporterType = "none"
+	ExporterTypeOtlp   ExporterType = "otlp"
+	ExporterTypeStdout ExporterType = "stdout"
+	ExporterTypeFile   ExporterType = "file"
+)
+
+// temporalTracer is set by InitTracing and is nil while tracing is disabled
+var temporalTracer interceptor.Tracer
+
+// GetTracingExporterType returns the exporter configured via the
+// SIDE_TRACING_EXPORTER environment variable. Tracing is disabled by default.
+func GetTracingExporterType() ExporterType {
+	exporterType := os.Getenv("SIDE_TRACING_EXPORTER")
+	if exporterType == "" {
+		return ExporterTypeNone
+	}
+	return ExporterType(exporterType)
+}
+
+// GetTracingFilePath returns the file spans are written to when using the
+// file exporter. Can be overridden by setting SIDE_TRACING_FILE.
+func GetTracingFilePath() (string, error) {
+	path := os.Getenv("SIDE_TRACING_FILE")
+	if path != "" {
+		return path, nil
+	}
+	dataHome, err := common.GetSidekickDataHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataHome, "traces.jsonl"), nil
+}
+
+/*
+InitTracing sets up the global tracer provider based on the configured
+exporter and must be called before creating any Temporal clients, so that
+TemporalInterceptors can propagate spans. The returned shutdown func flushes
+any pending spans and should be called on exit.
+
+The OTLP exporter is configured via the standard OTEL_EXPORTER_OTLP_*
+environment variables, eg OTEL_EXPORTER_OTLP_ENDPOINT.
+*/
+func InitTracing(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	noopShutdown := func(context.Context) error { return nil }
+
+	var exporter sdktrace.SpanExporter
+	var closer func() error
+	var err error
+	exporterType := GetTracingExporterType()
+	switch exporterType {
+	case ExporterTypeNone:
+		return noopShutdown, nil
+	case ExporterTypeOtlp:
+		exporter, err = otlptracegrpc.New(ctx)
+	case ExporterTypeStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterTypeFile:
+		var path string
+		path, err = GetTracingFilePath()
+		if err != nil {
+			return noopShutdown, fmt.Errorf("failed to get tracing file path: %w", err)
+		}
+		var file *os.File
+		file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return noopShutdown, fmt.Errorf("failed to open tracing file: %w", err)
+		}
+		closer = file.Close
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	default:
+		return noopShutdown, fmt.Errorf("unknown tracing exporter type: %s", exporterType)
+	}
+	if err != nil {
+		return noopShutdown, fmt.Errorf("failed to create %s trace exporter: %w", exporterType, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return noopShutdown, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	temporalTracer, err = temporalotel.NewTracer(temporalotel.TracerOptions{
+		Tracer:         tracerProvider.Tracer(tracerName),
+		SpanContextKey: spanContextKey{},
+	})
+	if err != nil {
+		return noopShutdown, fmt.Errorf("failed to create temporal tracer: %w", err)
+	}
+
+	log.Info().Str("exporter", string(exporterType)).Msg("Tracing enabled")
+
+	return func(ctx context.Context) error {
+		err := tracerProvider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer())
+		}
+		return err
+	}, nil
+}
+
+// Tracer returns the tracer used for all sidekick spans. Spans are no-ops
+// unless InitTracing has enabled an exporter.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// StartSpan starts a span as a child of any span found in the given context,
+// eg the span of the currently executing Temporal activity.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records the error, if any, and ends the span
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,90 @@
+package telemetry
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/workflow"
+)
+
+type spanContextKey struct{}
+type spanStackKey struct{}
+
+/*
+spanStack tracks the spans started via StartWorkflowSpan within a single
+workflow execution. workflow.Context values are immutable, but flow actions
+and subflows are tracked via closures that capture their caller's context, so
+activities executed within them would otherwise never see the span. Instead,
+a single mutable stack is attached to the workflow context up front and
+consulted whenever an activity or child workflow is started.
+*/
+type spanStack struct {
+	spans []interceptor.TracerSpan
+}
+
+func (s *spanStack) current() interceptor.TracerSpan {
+	if len(s.spans) == 0 {
+		return nil
+	}
+	return s.spans[len(s.spans)-1]
+}
+
+func (s *spanStack) remove(span interceptor.TracerSpan) {
+	// spans may end out of order when tracked in parallel workflow coroutines
+	for i := len(s.spans) - 1; i >= 0; i-- {
+		if s.spans[i] == span {
+			s.spans = append(s.spans[:i], s.spans[i+1:]...)
+			return
+		}
+	}
+}
+
+/*
+StartWorkflowSpan starts a span nested under the current flow span within
+workflow code. Spans are only recorded when not replaying, so this is safe to
+call from workflows without any versioning. The returned func must be called
+with the final error, if any, to end the span.
+*/
+func StartWorkflowSpan(ctx workflow.Context, operation, name string, tags map[string]string) func(err error) {
+	noop := func(error) {}
+	if temporalTracer == nil || workflow.IsReplaying(ctx) {
+		return noop
+	}
+	stack, _ := ctx.Value(spanStackKey{}).(*spanStack)
+	if stack == nil {
+		return noop
+	}
+
+	parent := stack.current()
+	if parent == nil {
+		parent, _ = ctx.Value(spanContextKey{}).(interceptor.TracerSpan)
+	}
+	span, err := temporalTracer.StartSpan(&interceptor.TracerStartSpanOptions{
+		Parent:    parent,
+		Operation: operation,
+		Name:      name,
+		Time:      time.Now(),
+		Tags:      tags,
+	})
+	if err != nil {
+		workflow.GetLogger(ctx).Warn("Failed to start workflow span", "error", err)
+		return noop
+	}
+	stack.spans = append(stack.spans, span)
+
+	return func(err error) {
+		stack.remove(span)
+		span.Finish(&interceptor.TracerFinishSpanOptions{Error: err})
+	}
+}
+
+func withCurrentSpan(ctx workflow.Context) workflow.Context {
+	stack, _ := ctx.Value(spanStackKey{}).(*spanStack)
+	if stack == nil {
+		return ctx
+	}
+	if span := stack.current(); span != nil {
+		return workflow.WithValue(ctx, spanContextKey{}, span)
+	}
+	return ctx
+}
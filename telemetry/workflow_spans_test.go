@@ -0,0 +1,106 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	temporalotel "go.temporal.io/sdk/contrib/opentelemetry"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+func nestedSpansActivity(ctx context.Context) error {
+	_, span := StartSpan(ctx, "command")
+	EndSpan(span, nil)
+	return nil
+}
+
+func nestedSpansWorkflow(ctx workflow.Context) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Minute})
+	endSubflow := StartWorkflowSpan(ctx, "Subflow", "coding", map[string]string{"sidekick.subflow_id": "sf_1"})
+	endAction := StartWorkflowSpan(ctx, "FlowAction", "tool_call.run_command", nil)
+	err := workflow.ExecuteActivity(ctx, nestedSpansActivity).Get(ctx, nil)
+	endAction(err)
+	endSubflow(err)
+	return err
+}
+
+func TestStartWorkflowSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	originalTracer := temporalTracer
+	originalProvider := otel.GetTracerProvider()
+	defer func() {
+		temporalTracer = originalTracer
+		otel.SetTracerProvider(originalProvider)
+	}()
+	otel.SetTracerProvider(provider)
+
+	var err error
+	temporalTracer, err = temporalotel.NewTracer(temporalotel.TracerOptions{
+		Tracer:         provider.Tracer(tracerName),
+		SpanContextKey: spanContextKey{},
+	})
+	require.NoError(t, err)
+
+	var workerInterceptors []interceptor.WorkerInterceptor
+	for _, i := range TemporalInterceptors() {
+		workerInterceptors = append(workerInterceptors, i.(interceptor.WorkerInterceptor))
+	}
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.SetWorkerOptions(worker.Options{Interceptors: workerInterceptors})
+	env.RegisterWorkflow(nestedSpansWorkflow)
+	env.RegisterActivity(nestedSpansActivity)
+	env.ExecuteWorkflow(nestedSpansWorkflow)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	spansByName := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spansByName[span.Name()] = span
+	}
+
+	tests := []struct {
+		name       string
+		parentName string
+	}{
+		{name: "FlowAction:tool_call.run_command", parentName: "Subflow:coding"},
+		{name: "StartActivity:nestedSpansActivity", parentName: "FlowAction:tool_call.run_command"},
+		{name: "command", parentName: "RunActivity:nestedSpansActivity"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			span, ok := spansByName[tt.name]
+			require.True(t, ok, "missing span %s", tt.name)
+			parent, ok := spansByName[tt.parentName]
+			require.True(t, ok, "missing parent span %s", tt.parentName)
+			assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+		})
+	}
+}
+
+func TestStartWorkflowSpanDisabled(t *testing.T) {
+	originalTracer := temporalTracer
+	defer func() { temporalTracer = originalTracer }()
+	temporalTracer = nil
+
+	assert.Len(t, TemporalInterceptors(), 1)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(nestedSpansWorkflow)
+	env.RegisterActivity(nestedSpansActivity)
+	env.ExecuteWorkflow(nestedSpansWorkflow)
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+}
@@ -31,6 +31,19 @@ func InferLanguageNameFromFilePath(filePath string) string {
 		return "java"
 	case ".kt":
 		return "kotlin"
+	case ".rs":
+		return "rust"
+	case ".c":
+		return "c"
+	// .h is also used for C headers, but the C++ grammar parses those too
+	case ".h", ".cc", ".cpp", ".cxx", ".c++", ".hh", ".hpp", ".hxx", ".h++":
+		return "cpp"
+	case ".cs":
+		return "csharp"
+	case ".rb", ".rake":
+		return "ruby"
+	case ".php":
+		return "php"
 	default:
 		return "unknown"
 	}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"sidekick/common"
+	"sidekick/telemetry"
 	"sidekick/worker"
 	"syscall"
 
@@ -22,7 +24,13 @@ func main() {
 		}
 	}
 
+	shutdownTracing, err := telemetry.InitTracing(context.Background(), "sidekick-worker")
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize tracing")
+	}
+
 	w := worker.StartWorker(common.GetTemporalServerHostPort(), common.GetTemporalTaskQueue())
+	metricsServer := telemetry.StartMetricsServer(common.GetWorkerMetricsPort())
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -30,4 +38,8 @@ func main() {
 
 	// graceful shutdown
 	w.Stop()
+	metricsServer.Shutdown(context.Background())
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.Error().Err(err).Msg("Failed to shut down tracing")
+	}
 }
@@ -12,11 +12,14 @@ import (
 
 	"sidekick"
 	"sidekick/coding"
+	"sidekick/coding/check"
 	"sidekick/coding/git"
 	"sidekick/coding/lsp"
+	"sidekick/coding/search"
 	"sidekick/coding/tree_sitter"
 	"sidekick/common"
 	"sidekick/srv"
+	"sidekick/telemetry"
 	"sidekick/workspace"
 
 	"sidekick/dev"
@@ -37,8 +40,9 @@ func StartWorker(hostPort string, taskQueue string) worker.Worker {
 
 	logger := logur.LoggerToKV(zerologadapter.New(log.Logger))
 	clientOptions := client.Options{
-		Logger:   logger,
-		HostPort: hostPort,
+		Logger:       logger,
+		HostPort:     hostPort,
+		Interceptors: telemetry.TemporalInterceptors(),
 	}
 	var temporalClient client.Client
 	for i := 0; i < 5; i++ {
@@ -93,8 +97,11 @@ func StartWorker(hostPort string, taskQueue string) worker.Worker {
 	vectorActivities := &persisted_ai.VectorActivities{
 		DatabaseAccessor: service,
 	}
+	outlineWatchers := &persisted_ai.OutlineWatchers{DatabaseAccessor: service}
 	ragActivities := &persisted_ai.RagActivities{
 		DatabaseAccessor: service,
+		LSPActivities:    lspActivities,
+		OutlineWatchers:  outlineWatchers,
 	}
 
 	pollFailuresActivities := &poll_failures.PollFailuresActivities{
@@ -102,8 +109,12 @@ func StartWorker(hostPort string, taskQueue string) worker.Worker {
 		Service:        service,
 	}
 
+	typescriptWatchers := &check.TypescriptWatchers{
+		WatcherProvider: check.StartTscWatcher,
+	}
 	devActivities := &dev.DevActivities{
-		LSPActivities: lspActivities,
+		LSPActivities:      lspActivities,
+		TypescriptWatchers: typescriptWatchers,
 	}
 
 	w := worker.New(temporalClient, taskQueue, worker.Options{
@@ -123,12 +134,27 @@ func StartWorker(hostPort string, taskQueue string) worker.Worker {
 	w.RegisterActivity(codingActivities)
 	w.RegisterActivity(ragActivities)
 	w.RegisterActivity(env.EnvRunCommandActivity)
+	w.RegisterActivity(search.SearchActivity)
+	w.RegisterActivity(env.RestoreDependencyCacheActivity)
+	w.RegisterActivity(env.SaveDependencyCacheActivity)
 	w.RegisterActivity(git.GitDiffActivity)
+	w.RegisterActivity(git.GitChangedFilesActivity)
 	w.RegisterActivity(git.GitAddActivity)
 	w.RegisterActivity(git.GitRestoreActivity)
 	w.RegisterActivity(git.GitCommitActivity)
+	w.RegisterActivity(git.RunPreCommitHookActivity)
 	w.RegisterActivity(git.GitCheckoutActivity)
 	w.RegisterActivity(git.GitMergeActivity)
+	w.RegisterActivity(git.GitLogActivity)
+	w.RegisterActivity(git.GitBlameActivity)
+	w.RegisterActivity(git.GitShowActivity)
+	w.RegisterActivity(git.CreateCheckpointActivity)
+	w.RegisterActivity(git.RollbackToCheckpointActivity)
+	w.RegisterActivity(git.SquashBranchActivity)
+	w.RegisterActivity(git.ListConflictedFilesActivity)
+	w.RegisterActivity(git.ReadConflictedFilesActivity)
+	w.RegisterActivity(git.RestartMergeActivity)
+	w.RegisterActivity(git.UpdateFromBaseBranchActivity)
 	w.RegisterActivity(git.ListWorktreesActivity)
 	w.RegisterActivity(git.CleanupWorktreeActivity)
 	w.RegisterActivity(git.GetCurrentBranch)
@@ -155,7 +181,30 @@ func StartWorker(hostPort string, taskQueue string) worker.Worker {
 		log.Fatal().Err(err)
 	}
 
-	return w
+	return &sidekickWorker{Worker: w, onStop: []func(){typescriptWatchers.Close, outlineWatchers.Close}}
+}
+
+// sidekickWorker stops the long-lived processes and watchers that activities
+// start, eg type checkers, once the worker stops
+type sidekickWorker struct {
+	worker.Worker
+	onStop []func()
+}
+
+func (w *sidekickWorker) Run(interruptCh <-chan interface{}) error {
+	defer w.stopped()
+	return w.Worker.Run(interruptCh)
+}
+
+func (w *sidekickWorker) Stop() {
+	w.Worker.Stop()
+	w.stopped()
+}
+
+func (w *sidekickWorker) stopped() {
+	for _, stop := range w.onStop {
+		stop()
+	}
 }
 
 func RegisterWorkflows(w worker.WorkflowRegistry) {